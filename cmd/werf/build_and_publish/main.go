@@ -69,7 +69,9 @@ If one or more IMAGE_NAME parameters specified, werf will build images stages an
 			common.LogVersion()
 
 			return common.LogRunningTime(func() error {
-				return runBuildAndPublish(args)
+				return common.WithTracing(&commonCmdData, cmd, func() error {
+					return runBuildAndPublish(args)
+				})
 			})
 		},
 	}
@@ -87,11 +89,17 @@ If one or more IMAGE_NAME parameters specified, werf will build images stages an
 	common.SetupDockerConfig(&commonCmdData, cmd, "Command needs granted permissions to read, pull and push images into the specified stages storage, to push images into the specified images repo, to pull base images")
 	common.SetupInsecureRegistry(&commonCmdData, cmd)
 	common.SetupSkipTlsVerifyRegistry(&commonCmdData, cmd)
+	common.SetupSignKey(&commonCmdData, cmd)
+	common.SetupDaemonlessPublish(&commonCmdData, cmd)
 
 	common.SetupLogOptions(&commonCmdData, cmd)
 	common.SetupLogProjectDir(&commonCmdData, cmd)
 
 	common.SetupIntrospectStage(&commonCmdData, cmd)
+	common.SetupReproducibility(&commonCmdData, cmd)
+	common.SetupContainerBackend(&commonCmdData, cmd)
+	common.SetupReport(&commonCmdData, cmd)
+	common.SetupOtel(&commonCmdData, cmd)
 
 	cmd.Flags().BoolVarP(&cmdData.IntrospectAfterError, "introspect-error", "", false, "Introspect failed stage in the state, right after running failed assembly instruction")
 	cmd.Flags().BoolVarP(&cmdData.IntrospectBeforeError, "introspect-before-error", "", false, "Introspect failed stage in the clean state, before running all assembly instructions of the stage")
@@ -120,6 +128,10 @@ func runBuildAndPublish(imagesToProcess []string) error {
 		return err
 	}
 
+	if err := common.InitContainerBackend(&commonCmdData); err != nil {
+		return err
+	}
+
 	projectDir, err := common.GetProjectDir(&commonCmdData)
 	if err != nil {
 		return fmt.Errorf("getting project dir failed: %s", err)
@@ -151,22 +163,22 @@ func runBuildAndPublish(imagesToProcess []string) error {
 		return err
 	}
 
-	imagesRepoMode, err := common.GetImagesRepoMode(&commonCmdData)
+	imagesRepoManager, err := common.GetImagesRepoManagerByCmdData(projectName, imagesRepo, common.GetWerfConfigImagesNames(werfConfig), &commonCmdData)
 	if err != nil {
 		return err
 	}
 
-	imagesRepoManager, err := common.GetImagesRepoManager(imagesRepo, imagesRepoMode)
+	stagesStorage, err := common.GetStagesStorage(&commonCmdData)
 	if err != nil {
 		return err
 	}
 
-	stagesStorage, err := common.GetStagesStorage(&commonCmdData)
+	tagOpts, err := common.GetTagOptions(&commonCmdData, common.TagOptionsGetterOptions{})
 	if err != nil {
 		return err
 	}
 
-	tagOpts, err := common.GetTagOptions(&commonCmdData, common.TagOptionsGetterOptions{})
+	signer, err := common.GetImageSigner(&commonCmdData)
 	if err != nil {
 		return err
 	}
@@ -186,17 +198,31 @@ func runBuildAndPublish(imagesToProcess []string) error {
 		return err
 	}
 
+	reproducibilityOptions, err := common.GetReproducibilityOptions(&commonCmdData, werfConfig)
+	if err != nil {
+		return err
+	}
+
+	reportOptions, err := common.GetReportOptions(&commonCmdData)
+	if err != nil {
+		return err
+	}
+
 	opts := build.BuildAndPublishOptions{
 		BuildStagesOptions: build.BuildStagesOptions{
 			ImageBuildOptions: image.BuildOptions{
 				IntrospectAfterError:  cmdData.IntrospectAfterError,
 				IntrospectBeforeError: cmdData.IntrospectBeforeError,
 			},
-			IntrospectOptions: introspectOptions,
+			IntrospectOptions:      introspectOptions,
+			ReproducibilityOptions: reproducibilityOptions,
+			ReportOptions:          reportOptions,
 		},
 		PublishImagesOptions: build.PublishImagesOptions{
-			ImagesToPublish: imagesToProcess,
-			TagOptions:      tagOpts,
+			ImagesToPublish:   imagesToProcess,
+			TagOptions:        tagOpts,
+			Signer:            signer,
+			DaemonlessPublish: *commonCmdData.DaemonlessPublish,
 		},
 	}
 
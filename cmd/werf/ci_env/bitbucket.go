@@ -0,0 +1,54 @@
+package ci_env
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+)
+
+// bitbucketProvider uses Bitbucket Pipelines variables, Bitbucket does not provide registry
+type bitbucketProvider struct{}
+
+func (p *bitbucketProvider) ImagesRepo() string {
+	return ""
+}
+
+func (p *bitbucketProvider) DockerLoginCredentials() (string, string) {
+	return "", ""
+}
+
+func (p *bitbucketProvider) GitTag() string {
+	return os.Getenv("BITBUCKET_TAG")
+}
+
+func (p *bitbucketProvider) GitBranch() string {
+	return os.Getenv("BITBUCKET_BRANCH")
+}
+
+func (p *bitbucketProvider) Env() string {
+	return os.Getenv("BITBUCKET_DEPLOYMENT_ENVIRONMENT")
+}
+
+func (p *bitbucketProvider) Annotations() []annotation {
+	var pipelineUrl, stepUrl string
+	repoFullName := os.Getenv("BITBUCKET_REPO_FULL_NAME")
+	buildNumber := os.Getenv("BITBUCKET_BUILD_NUMBER")
+	if repoFullName != "" && buildNumber != "" {
+		pipelineUrl = fmt.Sprintf("https://bitbucket.org/%s/addon/pipelines/home#!/results/%s", repoFullName, buildNumber)
+
+		if stepUuid := os.Getenv("BITBUCKET_STEP_UUID"); stepUuid != "" {
+			stepUrl = fmt.Sprintf("%s/steps/%s", pipelineUrl, url.PathEscape(stepUuid))
+		}
+	}
+
+	return []annotation{
+		projectGitAnnotation(os.Getenv("BITBUCKET_GIT_HTTP_ORIGIN")),
+		ciCommitAnnotation(os.Getenv("BITBUCKET_COMMIT")),
+		{EnvSuffix: "BITBUCKET_PIPELINE_URL", Name: "bitbucket.ci.werf.io/pipeline-url", Value: pipelineUrl},
+		{EnvSuffix: "BITBUCKET_STEP_URL", Name: "bitbucket.ci.werf.io/step-url", Value: stepUrl},
+	}
+}
+
+func (p *bitbucketProvider) LogColorMode() string {
+	return "on"
+}
@@ -1,14 +1,15 @@
 package ci_env
 
 import (
+	"bytes"
 	"fmt"
+	"io"
 	"io/ioutil"
 	"os"
 	"path/filepath"
 
 	"github.com/flant/shluz"
 
-	"github.com/Masterminds/semver"
 	"github.com/ghodss/yaml"
 	"github.com/spf13/cobra"
 
@@ -22,6 +23,7 @@ import (
 
 var cmdData struct {
 	TaggingStrategy string
+	AsFile          bool
 }
 
 var commonCmdData common.CmdData
@@ -33,9 +35,22 @@ func NewCmd() *cobra.Command {
 		Short:                 "Generate werf environment variables for specified CI system",
 		Long: `Generate werf environment variables for specified CI system.
 
-Currently supported only GitLab CI`,
+Supported CI systems:
+* gitlab: GitLab CI;
+* github: GitHub Actions;
+* jenkins: Jenkins with Git plugin or Multibranch Pipeline;
+* bitbucket: Bitbucket Pipelines;
+* generic: any CI system, git tag, branch and commit are detected by the git repository in the current directory.
+
+The command prints the script, which exports werf environment variables, or, with --as-file option, saves the script into the file and prints the file path`,
 		Example: `  # Load generated werf environment variables on GitLab job runner
-  $ source <(werf ci-env gitlab)`,
+  $ source <(werf ci-env gitlab)
+
+  # Load generated werf environment variables in GitHub Actions workflow step
+  $ source <(werf ci-env github --tagging-strategy tag-or-branch)
+
+  # Load generated werf environment variables in shell without process substitution
+  $ . $(werf ci-env jenkins --as-file)`,
 		RunE: runCIEnv,
 	}
 
@@ -48,6 +63,7 @@ Currently supported only GitLab CI`,
 	common.SetupLogOptions(&commonCmdData, cmd)
 
 	cmd.Flags().StringVarP(&cmdData.TaggingStrategy, "tagging-strategy", "", "stages-signature", "stages-signature: always use '--tag-by-stages-signature' option to tag all published images by corresponding stages-signature; tag-or-branch: generate auto '--tag-git-branch' or '--tag-git-tag' tag by specified CI_SYSTEM environment variables")
+	cmd.Flags().BoolVarP(&cmdData.AsFile, "as-file", "", false, "Save the script into the file and print the file path for sourcing")
 
 	return cmd
 }
@@ -77,23 +93,54 @@ func runCIEnv(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("provided tagging-strategy '%s' not supported", cmdData.TaggingStrategy)
 	}
 
-	ciSystem := args[0]
+	provider, err := newCiProvider(args[0])
+	if err != nil {
+		common.PrintHelp(cmd)
+		return err
+	}
 
-	switch ciSystem {
-	case "gitlab":
-		err := generateGitlabEnvs(cmdData.TaggingStrategy)
+	if !cmdData.AsFile {
+		err := generateEnvs(os.Stdout, provider, cmdData.TaggingStrategy)
 		if err != nil {
 			fmt.Println()
-			printError(err.Error())
+			printError(os.Stdout, err.Error())
 		}
 		return err
-	default:
-		common.PrintHelp(cmd)
-		return fmt.Errorf("provided ci system '%s' not supported", ciSystem)
 	}
+
+	var buf bytes.Buffer
+	err = generateEnvs(&buf, provider, cmdData.TaggingStrategy)
+	if err != nil {
+		fmt.Fprintln(&buf)
+		printError(&buf, err.Error())
+	}
+
+	scriptPath, saveErr := saveScriptFile(buf.Bytes())
+	if saveErr != nil {
+		return fmt.Errorf("unable to save script: %s", saveErr)
+	}
+
+	fmt.Println(scriptPath)
+
+	return err
 }
 
-func generateGitlabEnvs(taggingStrategy string) error {
+// saveScriptFile saves the script into the werf tmp dir, the file is not removed by werf to be sourced later
+func saveScriptFile(data []byte) (string, error) {
+	f, err := ioutil.TempFile(werf.GetTmpDir(), "werf-ci-env-*.sh")
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	if _, err := f.Write(data); err != nil {
+		return "", err
+	}
+
+	return f.Name(), nil
+}
+
+func generateEnvs(w io.Writer, provider ciProvider, taggingStrategy string) error {
 	dockerConfigPath := *commonCmdData.DockerConfig
 	if *commonCmdData.DockerConfig == "" {
 		dockerConfigPath = filepath.Join(os.Getenv("HOME"), ".docker")
@@ -115,160 +162,107 @@ func generateGitlabEnvs(taggingStrategy string) error {
 		return err
 	}
 
-	ciRegistryImage := os.Getenv("CI_REGISTRY_IMAGE")
-	ciJobToken := os.Getenv("CI_JOB_TOKEN")
+	imagesRepo := provider.ImagesRepo()
 
-	var imagesUsername, imagesPassword string
-	var doLogin bool
-	if ciRegistryImage != "" && ciJobToken != "" {
-		imagesUsername = "gitlab-ci-token"
-		imagesPassword = ciJobToken
-		doLogin = true
-	}
-
-	if doLogin {
-		err := docker.Login(imagesUsername, imagesPassword, ciRegistryImage)
+	if imagesUsername, imagesPassword := provider.DockerLoginCredentials(); imagesUsername != "" {
+		err := docker.Login(imagesUsername, imagesPassword, imagesRepo)
 		if err != nil {
-			return fmt.Errorf("unable to login into docker repo %s: %s", ciRegistryImage, err)
+			return fmt.Errorf("unable to login into docker repo %s: %s", imagesRepo, err)
 		}
 	}
 
-	printHeader("DOCKER CONFIG", false)
-	printExportCommand("DOCKER_CONFIG", dockerConfig, true)
+	printHeader(w, "DOCKER CONFIG", false)
+	printExportCommand(w, "DOCKER_CONFIG", dockerConfig, true)
 
-	printHeader("IMAGES REPO", true)
-	printExportCommand("WERF_IMAGES_REPO", ciRegistryImage, false)
+	printHeader(w, "IMAGES REPO", true)
+	printExportCommand(w, "WERF_IMAGES_REPO", imagesRepo, false)
 
-	printHeader("TAGGING", true)
+	printHeader(w, "TAGGING", true)
 	switch taggingStrategy {
 	case "tag-or-branch":
-		var ciGitTag, ciGitBranch string
-
-		if os.Getenv("CI_BUILD_TAG") != "" {
-			ciGitTag = os.Getenv("CI_BUILD_TAG")
-		} else if os.Getenv("CI_COMMIT_TAG") != "" {
-			ciGitTag = os.Getenv("CI_COMMIT_TAG")
-		} else if os.Getenv("CI_BUILD_REF_NAME") != "" {
-			ciGitBranch = os.Getenv("CI_BUILD_REF_NAME")
-		} else if os.Getenv("CI_COMMIT_REF_NAME") != "" {
-			ciGitBranch = os.Getenv("CI_COMMIT_REF_NAME")
-		}
+		ciGitTag := provider.GitTag()
+		ciGitBranch := provider.GitBranch()
 
 		if ciGitTag != "" {
-			printExportCommand("WERF_TAG_GIT_TAG", slug.DockerTag(ciGitTag), false)
+			printExportCommand(w, "WERF_TAG_GIT_TAG", slug.DockerTag(ciGitTag), false)
 		}
 		if ciGitBranch != "" {
-			printExportCommand("WERF_TAG_GIT_BRANCH", slug.DockerTag(ciGitBranch), false)
+			printExportCommand(w, "WERF_TAG_GIT_BRANCH", slug.DockerTag(ciGitBranch), false)
 		}
 
 		if ciGitTag == "" && ciGitBranch == "" {
-			return fmt.Errorf("none of enviroment variables $WERF_TAG_GIT_TAG=$CI_COMMIT_TAG or $WERF_TAG_GIT_BRANCH=$CI_COMMIT_REF_NAME for '%s' strategy are detected", cmdData.TaggingStrategy)
+			return fmt.Errorf("neither git tag nor git branch for $WERF_TAG_GIT_TAG or $WERF_TAG_GIT_BRANCH are detected by CI system environment variables for '%s' strategy", taggingStrategy)
 		}
 	case "stages-signature":
-		printExportCommand("WERF_TAG_BY_STAGES_SIGNATURE", "true", false)
+		printExportCommand(w, "WERF_TAG_BY_STAGES_SIGNATURE", "true", false)
 	}
 
-	printHeader("DEPLOY", true)
-	printExportCommand("WERF_ENV", os.Getenv("CI_ENVIRONMENT_SLUG"), false)
+	printHeader(w, "DEPLOY", true)
+	printExportCommand(w, "WERF_ENV", provider.Env(), false)
 
-	var projectGit string
-	ciProjectUrlEnv := os.Getenv("CI_PROJECT_URL")
-	if ciProjectUrlEnv != "" {
-		projectGit = fmt.Sprintf("project.werf.io/git=%s", ciProjectUrlEnv)
-	}
-	printExportCommand("WERF_ADD_ANNOTATION_PROJECT_GIT", projectGit, false)
-
-	var ciCommit string
-	ciCommitShaEnv := os.Getenv("CI_COMMIT_SHA")
-	if ciCommitShaEnv != "" {
-		ciCommit = fmt.Sprintf("ci.werf.io/commit=%s", ciCommitShaEnv)
-	}
-	printExportCommand("WERF_ADD_ANNOTATION_CI_COMMIT", ciCommit, false)
-
-	var gitlabCIPipelineUrl string
-	ciPipelineIdEnv := os.Getenv("CI_PIPELINE_ID")
-	if ciProjectUrlEnv != "" && ciPipelineIdEnv != "" {
-		gitlabCIPipelineUrl = fmt.Sprintf("gitlab.ci.werf.io/pipeline-url=%s/pipelines/%s", ciProjectUrlEnv, ciPipelineIdEnv)
-	}
-	printExportCommand("WERF_ADD_ANNOTATION_GITLAB_CI_PIPELINE_URL", gitlabCIPipelineUrl, false)
-
-	var gitlabCiJobUrl string
-	ciJobIdEnv := os.Getenv("CI_JOB_ID")
-	if ciProjectUrlEnv != "" && os.Getenv("CI_JOB_ID") != "" {
-		gitlabCiJobUrl = fmt.Sprintf("gitlab.ci.werf.io/job-url=%s/-/jobs/%s", ciProjectUrlEnv, ciJobIdEnv)
+	for _, anno := range provider.Annotations() {
+		var value string
+		if anno.Value != "" {
+			value = fmt.Sprintf("%s=%s", anno.Name, anno.Value)
+		}
+		printExportCommand(w, fmt.Sprintf("WERF_ADD_ANNOTATION_%s", anno.EnvSuffix), value, false)
 	}
-	printExportCommand("WERF_ADD_ANNOTATION_GITLAB_CI_JOB_URL", gitlabCiJobUrl, false)
 
 	cleanupConfig, err := getCleanupConfig()
 	if err != nil {
 		return fmt.Errorf("unable to get cleanup config: %s", err)
 	}
 
-	printHeader("IMAGE CLEANUP POLICIES", true)
-	printExportCommand("WERF_GIT_TAG_STRATEGY_LIMIT", fmt.Sprintf("%d", cleanupConfig.GitTagStrategyLimit), false)
-	printExportCommand("WERF_GIT_TAG_STRATEGY_EXPIRY_DAYS", fmt.Sprintf("%d", cleanupConfig.GitTagStrategyExpiryDays), false)
-	printExportCommand("WERF_GIT_COMMIT_STRATEGY_LIMIT", fmt.Sprintf("%d", cleanupConfig.GitCommitStrategyLimit), false)
-	printExportCommand("WERF_GIT_COMMIT_STRATEGY_EXPIRY_DAYS", fmt.Sprintf("%d", cleanupConfig.GitCommitStrategyExpiryDays), false)
-
-	printHeader("OTHER", true)
-
-	werfLogColorMode := "on"
-	ciServerVersion := os.Getenv("CI_SERVER_VERSION")
-	if ciServerVersion != "" {
-		currentVersion, err := semver.NewVersion(ciServerVersion)
-		if err == nil {
-			colorWorkTillVersion, _ := semver.NewVersion("12.1.3")
-			colorWorkSinceVersion, _ := semver.NewVersion("12.2.0")
-
-			if currentVersion.GreaterThan(colorWorkTillVersion) && currentVersion.LessThan(colorWorkSinceVersion) {
-				werfLogColorMode = "off"
-			}
-		}
-	}
+	printHeader(w, "IMAGE CLEANUP POLICIES", true)
+	printExportCommand(w, "WERF_GIT_TAG_STRATEGY_LIMIT", fmt.Sprintf("%d", cleanupConfig.GitTagStrategyLimit), false)
+	printExportCommand(w, "WERF_GIT_TAG_STRATEGY_EXPIRY_DAYS", fmt.Sprintf("%d", cleanupConfig.GitTagStrategyExpiryDays), false)
+	printExportCommand(w, "WERF_GIT_COMMIT_STRATEGY_LIMIT", fmt.Sprintf("%d", cleanupConfig.GitCommitStrategyLimit), false)
+	printExportCommand(w, "WERF_GIT_COMMIT_STRATEGY_EXPIRY_DAYS", fmt.Sprintf("%d", cleanupConfig.GitCommitStrategyExpiryDays), false)
 
-	printExportCommand("WERF_LOG_COLOR_MODE", werfLogColorMode, false)
-	printExportCommand("WERF_LOG_PROJECT_DIR", "1", false)
-	printExportCommand("WERF_ENABLE_PROCESS_EXTERMINATOR", "1", false)
-	printExportCommand("WERF_LOG_TERMINAL_WIDTH", "95", false)
+	printHeader(w, "OTHER", true)
+	printExportCommand(w, "WERF_LOG_COLOR_MODE", provider.LogColorMode(), false)
+	printExportCommand(w, "WERF_LOG_PROJECT_DIR", "1", false)
+	printExportCommand(w, "WERF_ENABLE_PROCESS_EXTERMINATOR", "1", false)
+	printExportCommand(w, "WERF_LOG_TERMINAL_WIDTH", "95", false)
 
 	return nil
 }
 
-func printError(errMsg string) {
+func printError(w io.Writer, errMsg string) {
 	if *commonCmdData.LogVerbose {
-		fmt.Println("echo")
-		fmt.Printf("echo 'Error: %s'\n", errMsg)
+		fmt.Fprintln(w, "echo")
+		fmt.Fprintf(w, "echo 'Error: %s'\n", errMsg)
 	}
 
-	fmt.Printf("exit 1\n")
-	fmt.Println()
+	fmt.Fprintf(w, "exit 1\n")
+	fmt.Fprintln(w)
 }
 
-func printHeader(header string, withNewLine bool) {
+func printHeader(w io.Writer, header string, withNewLine bool) {
 	header = fmt.Sprintf("### %s", header)
 
 	if withNewLine {
-		fmt.Println()
+		fmt.Fprintln(w)
 	}
-	fmt.Println(header)
+	fmt.Fprintln(w, header)
 
 	if *commonCmdData.LogVerbose {
 		if withNewLine {
-			fmt.Println("echo")
+			fmt.Fprintln(w, "echo")
 		}
 		echoHeader := fmt.Sprintf("echo '%s'", header)
-		fmt.Println(echoHeader)
+		fmt.Fprintln(w, echoHeader)
 	}
 }
 
-func printExportCommand(key, value string, override bool) {
+func printExportCommand(w io.Writer, key, value string, override bool) {
 	if !override && os.Getenv(key) != "" {
 		skipComment := fmt.Sprintf("# skip %s=\"%s\"", key, os.Getenv(key))
-		fmt.Println(skipComment)
+		fmt.Fprintln(w, skipComment)
 
 		if *commonCmdData.LogVerbose {
 			echoSkip := fmt.Sprintf("echo '%s'", skipComment)
-			fmt.Println(echoSkip)
+			fmt.Fprintln(w, echoSkip)
 		}
 
 		return
@@ -279,11 +273,11 @@ func printExportCommand(key, value string, override bool) {
 		exportCommand = fmt.Sprintf("# %s", exportCommand)
 	}
 
-	fmt.Println(exportCommand)
+	fmt.Fprintln(w, exportCommand)
 
 	if *commonCmdData.LogVerbose {
 		echoExportCommand := fmt.Sprintf("echo '%s'", exportCommand)
-		fmt.Println(echoExportCommand)
+		fmt.Fprintln(w, echoExportCommand)
 	}
 }
 
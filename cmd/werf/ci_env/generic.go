@@ -0,0 +1,67 @@
+package ci_env
+
+import (
+	"net/url"
+	"os/exec"
+	"strings"
+)
+
+// genericProvider detects git tag, branch, commit and remote url of the git repository in the current directory,
+// images repo and environment should be specified with $WERF_IMAGES_REPO and $WERF_ENV
+type genericProvider struct{}
+
+func (p *genericProvider) ImagesRepo() string {
+	return ""
+}
+
+func (p *genericProvider) DockerLoginCredentials() (string, string) {
+	return "", ""
+}
+
+func (p *genericProvider) GitTag() string {
+	return gitOutput("describe", "--tags", "--exact-match", "HEAD")
+}
+
+func (p *genericProvider) GitBranch() string {
+	if p.GitTag() != "" {
+		return ""
+	}
+
+	return gitOutput("symbolic-ref", "--short", "-q", "HEAD")
+}
+
+func (p *genericProvider) Env() string {
+	return ""
+}
+
+func (p *genericProvider) Annotations() []annotation {
+	return []annotation{
+		projectGitAnnotation(remoteUrlWithoutCredentials(gitOutput("config", "--get", "remote.origin.url"))),
+		ciCommitAnnotation(gitOutput("rev-parse", "HEAD")),
+	}
+}
+
+func (p *genericProvider) LogColorMode() string {
+	return ""
+}
+
+// gitOutput returns empty string if git command failed (e.g. there is no git repository or detached HEAD)
+func gitOutput(args ...string) string {
+	output, err := exec.Command("git", args...).Output()
+	if err != nil {
+		return ""
+	}
+
+	return strings.TrimSpace(string(output))
+}
+
+func remoteUrlWithoutCredentials(remoteUrl string) string {
+	u, err := url.Parse(remoteUrl)
+	if err != nil || u.User == nil {
+		return remoteUrl
+	}
+
+	u.User = nil
+
+	return u.String()
+}
@@ -0,0 +1,77 @@
+package ci_env
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+// githubProvider uses GitHub Container Registry, the workflow should pass secrets.GITHUB_TOKEN with packages write permission as $GITHUB_TOKEN
+type githubProvider struct{}
+
+func (p *githubProvider) ImagesRepo() string {
+	if os.Getenv("GITHUB_REPOSITORY") == "" {
+		return ""
+	}
+
+	return fmt.Sprintf("ghcr.io/%s", strings.ToLower(os.Getenv("GITHUB_REPOSITORY")))
+}
+
+func (p *githubProvider) DockerLoginCredentials() (string, string) {
+	if p.ImagesRepo() != "" && os.Getenv("GITHUB_ACTOR") != "" && os.Getenv("GITHUB_TOKEN") != "" {
+		return os.Getenv("GITHUB_ACTOR"), os.Getenv("GITHUB_TOKEN")
+	}
+
+	return "", ""
+}
+
+func (p *githubProvider) GitTag() string {
+	return strings.TrimPrefix(p.ref("refs/tags/"), "refs/tags/")
+}
+
+func (p *githubProvider) GitBranch() string {
+	// source branch of the pull request
+	if os.Getenv("GITHUB_HEAD_REF") != "" {
+		return os.Getenv("GITHUB_HEAD_REF")
+	}
+
+	return strings.TrimPrefix(p.ref("refs/heads/"), "refs/heads/")
+}
+
+func (p *githubProvider) ref(prefix string) string {
+	if ref := os.Getenv("GITHUB_REF"); strings.HasPrefix(ref, prefix) {
+		return ref
+	}
+
+	return ""
+}
+
+func (p *githubProvider) Env() string {
+	return ""
+}
+
+func (p *githubProvider) Annotations() []annotation {
+	var projectUrl, workflowRunUrl string
+	if os.Getenv("GITHUB_REPOSITORY") != "" {
+		serverUrl := os.Getenv("GITHUB_SERVER_URL")
+		if serverUrl == "" {
+			serverUrl = "https://github.com"
+		}
+
+		projectUrl = fmt.Sprintf("%s/%s", serverUrl, os.Getenv("GITHUB_REPOSITORY"))
+
+		if os.Getenv("GITHUB_RUN_ID") != "" {
+			workflowRunUrl = fmt.Sprintf("%s/actions/runs/%s", projectUrl, os.Getenv("GITHUB_RUN_ID"))
+		}
+	}
+
+	return []annotation{
+		projectGitAnnotation(projectUrl),
+		ciCommitAnnotation(os.Getenv("GITHUB_SHA")),
+		{EnvSuffix: "GITHUB_ACTIONS_WORKFLOW_RUN_URL", Name: "github.ci.werf.io/workflow-run-url", Value: workflowRunUrl},
+	}
+}
+
+func (p *githubProvider) LogColorMode() string {
+	return "on"
+}
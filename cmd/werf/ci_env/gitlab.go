@@ -0,0 +1,86 @@
+package ci_env
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/Masterminds/semver"
+)
+
+type gitlabProvider struct{}
+
+func (p *gitlabProvider) ImagesRepo() string {
+	return os.Getenv("CI_REGISTRY_IMAGE")
+}
+
+func (p *gitlabProvider) DockerLoginCredentials() (string, string) {
+	if os.Getenv("CI_REGISTRY_IMAGE") != "" && os.Getenv("CI_JOB_TOKEN") != "" {
+		return "gitlab-ci-token", os.Getenv("CI_JOB_TOKEN")
+	}
+
+	return "", ""
+}
+
+func (p *gitlabProvider) GitTag() string {
+	if os.Getenv("CI_BUILD_TAG") != "" {
+		return os.Getenv("CI_BUILD_TAG")
+	}
+
+	return os.Getenv("CI_COMMIT_TAG")
+}
+
+func (p *gitlabProvider) GitBranch() string {
+	if p.GitTag() != "" {
+		return ""
+	}
+
+	if os.Getenv("CI_BUILD_REF_NAME") != "" {
+		return os.Getenv("CI_BUILD_REF_NAME")
+	}
+
+	return os.Getenv("CI_COMMIT_REF_NAME")
+}
+
+func (p *gitlabProvider) Env() string {
+	return os.Getenv("CI_ENVIRONMENT_SLUG")
+}
+
+func (p *gitlabProvider) Annotations() []annotation {
+	ciProjectUrlEnv := os.Getenv("CI_PROJECT_URL")
+
+	var gitlabCIPipelineUrl string
+	ciPipelineIdEnv := os.Getenv("CI_PIPELINE_ID")
+	if ciProjectUrlEnv != "" && ciPipelineIdEnv != "" {
+		gitlabCIPipelineUrl = fmt.Sprintf("%s/pipelines/%s", ciProjectUrlEnv, ciPipelineIdEnv)
+	}
+
+	var gitlabCiJobUrl string
+	ciJobIdEnv := os.Getenv("CI_JOB_ID")
+	if ciProjectUrlEnv != "" && ciJobIdEnv != "" {
+		gitlabCiJobUrl = fmt.Sprintf("%s/-/jobs/%s", ciProjectUrlEnv, ciJobIdEnv)
+	}
+
+	return []annotation{
+		projectGitAnnotation(ciProjectUrlEnv),
+		ciCommitAnnotation(os.Getenv("CI_COMMIT_SHA")),
+		{EnvSuffix: "GITLAB_CI_PIPELINE_URL", Name: "gitlab.ci.werf.io/pipeline-url", Value: gitlabCIPipelineUrl},
+		{EnvSuffix: "GITLAB_CI_JOB_URL", Name: "gitlab.ci.werf.io/job-url", Value: gitlabCiJobUrl},
+	}
+}
+
+func (p *gitlabProvider) LogColorMode() string {
+	ciServerVersion := os.Getenv("CI_SERVER_VERSION")
+	if ciServerVersion != "" {
+		currentVersion, err := semver.NewVersion(ciServerVersion)
+		if err == nil {
+			colorWorkTillVersion, _ := semver.NewVersion("12.1.3")
+			colorWorkSinceVersion, _ := semver.NewVersion("12.2.0")
+
+			if currentVersion.GreaterThan(colorWorkTillVersion) && currentVersion.LessThan(colorWorkSinceVersion) {
+				return "off"
+			}
+		}
+	}
+
+	return "on"
+}
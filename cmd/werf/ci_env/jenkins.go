@@ -0,0 +1,64 @@
+package ci_env
+
+import (
+	"os"
+	"strings"
+)
+
+// jenkinsProvider uses variables of the Git plugin and Multibranch Pipeline, Jenkins does not provide registry
+type jenkinsProvider struct{}
+
+func (p *jenkinsProvider) ImagesRepo() string {
+	return ""
+}
+
+func (p *jenkinsProvider) DockerLoginCredentials() (string, string) {
+	return "", ""
+}
+
+func (p *jenkinsProvider) GitTag() string {
+	return os.Getenv("TAG_NAME")
+}
+
+func (p *jenkinsProvider) GitBranch() string {
+	if p.GitTag() != "" {
+		return ""
+	}
+
+	// multibranch pipeline builds change requests with CHANGE_BRANCH source branch
+	for _, env := range []string{"CHANGE_BRANCH", "BRANCH_NAME"} {
+		if os.Getenv(env) != "" {
+			return os.Getenv(env)
+		}
+	}
+
+	// git plugin defines branch with the remote name: origin/master
+	branch := os.Getenv("GIT_BRANCH")
+	if strings.HasPrefix(branch, "refs/heads/") {
+		return strings.TrimPrefix(branch, "refs/heads/")
+	}
+
+	if remote := os.Getenv("GIT_REMOTE"); remote != "" {
+		return strings.TrimPrefix(branch, remote+"/")
+	}
+
+	return strings.TrimPrefix(branch, "origin/")
+}
+
+func (p *jenkinsProvider) Env() string {
+	return ""
+}
+
+func (p *jenkinsProvider) Annotations() []annotation {
+	return []annotation{
+		projectGitAnnotation(os.Getenv("GIT_URL")),
+		ciCommitAnnotation(os.Getenv("GIT_COMMIT")),
+		{EnvSuffix: "JENKINS_BUILD_URL", Name: "jenkins.ci.werf.io/build-url", Value: os.Getenv("BUILD_URL")},
+		{EnvSuffix: "JENKINS_JOB_URL", Name: "jenkins.ci.werf.io/job-url", Value: os.Getenv("JOB_URL")},
+	}
+}
+
+// LogColorMode is off, because colors are displayed only with AnsiColor plugin
+func (p *jenkinsProvider) LogColorMode() string {
+	return "off"
+}
@@ -0,0 +1,63 @@
+package ci_env
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// ciProvider maps environment variables of CI system to werf parameters,
+// empty values are not exported
+type ciProvider interface {
+	// ImagesRepo returns registry repository for the project images
+	ImagesRepo() string
+	// DockerLoginCredentials returns credentials to login into ImagesRepo, empty username means that login is not required
+	DockerLoginCredentials() (username, password string)
+	GitTag() string
+	GitBranch() string
+	Env() string
+	Annotations() []annotation
+	LogColorMode() string
+}
+
+type annotation struct {
+	// EnvSuffix is the suffix of WERF_ADD_ANNOTATION_<SUFFIX> environment variable
+	EnvSuffix string
+	Name      string
+	Value     string
+}
+
+var ciProviders = map[string]func() ciProvider{
+	"gitlab":    func() ciProvider { return &gitlabProvider{} },
+	"github":    func() ciProvider { return &githubProvider{} },
+	"jenkins":   func() ciProvider { return &jenkinsProvider{} },
+	"bitbucket": func() ciProvider { return &bitbucketProvider{} },
+	"generic":   func() ciProvider { return &genericProvider{} },
+}
+
+func newCiProvider(ciSystem string) (ciProvider, error) {
+	newFunc, ok := ciProviders[ciSystem]
+	if !ok {
+		return nil, fmt.Errorf("provided ci system '%s' not supported: %s expected", ciSystem, strings.Join(supportedCiSystems(), ", "))
+	}
+
+	return newFunc(), nil
+}
+
+func supportedCiSystems() []string {
+	var res []string
+	for ciSystem := range ciProviders {
+		res = append(res, ciSystem)
+	}
+	sort.Strings(res)
+
+	return res
+}
+
+func projectGitAnnotation(projectUrl string) annotation {
+	return annotation{EnvSuffix: "PROJECT_GIT", Name: "project.werf.io/git", Value: projectUrl}
+}
+
+func ciCommitAnnotation(commit string) annotation {
+	return annotation{EnvSuffix: "CI_COMMIT", Name: "ci.werf.io/commit", Value: commit}
+}
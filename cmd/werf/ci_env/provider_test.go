@@ -0,0 +1,84 @@
+package ci_env
+
+import (
+	"os"
+	"testing"
+)
+
+func setEnvs(t *testing.T, envs map[string]string) func() {
+	for name, value := range envs {
+		if err := os.Setenv(name, value); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	return func() {
+		for name := range envs {
+			os.Unsetenv(name)
+		}
+	}
+}
+
+func TestCiProviders(t *testing.T) {
+	for _, test := range []struct {
+		ciSystem           string
+		envs               map[string]string
+		expectedImagesRepo string
+		expectedGitTag     string
+		expectedGitBranch  string
+		expectedEnv        string
+	}{
+		{
+			ciSystem:           "github",
+			envs:               map[string]string{"GITHUB_REPOSITORY": "Flant/Werf", "GITHUB_REF": "refs/heads/feature/x"},
+			expectedImagesRepo: "ghcr.io/flant/werf",
+			expectedGitBranch:  "feature/x",
+		},
+		{
+			ciSystem:       "github",
+			envs:           map[string]string{"GITHUB_REF": "refs/tags/v1.0.0"},
+			expectedGitTag: "v1.0.0",
+		},
+		{
+			ciSystem:          "jenkins",
+			envs:              map[string]string{"GIT_BRANCH": "origin/feature/x"},
+			expectedGitBranch: "feature/x",
+		},
+		{
+			ciSystem:       "jenkins",
+			envs:           map[string]string{"TAG_NAME": "v1.0.0", "BRANCH_NAME": "v1.0.0"},
+			expectedGitTag: "v1.0.0",
+		},
+		{
+			ciSystem:          "bitbucket",
+			envs:              map[string]string{"BITBUCKET_BRANCH": "master", "BITBUCKET_DEPLOYMENT_ENVIRONMENT": "production"},
+			expectedGitBranch: "master",
+			expectedEnv:       "production",
+		},
+	} {
+		unsetEnvs := setEnvs(t, test.envs)
+
+		provider, err := newCiProvider(test.ciSystem)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if imagesRepo := provider.ImagesRepo(); imagesRepo != test.expectedImagesRepo {
+			t.Errorf("%s %v: expected images repo %q, got %q", test.ciSystem, test.envs, test.expectedImagesRepo, imagesRepo)
+		}
+
+		if gitTag := provider.GitTag(); gitTag != test.expectedGitTag {
+			t.Errorf("%s %v: expected git tag %q, got %q", test.ciSystem, test.envs, test.expectedGitTag, gitTag)
+		}
+
+		if gitBranch := provider.GitBranch(); gitBranch != test.expectedGitBranch {
+			t.Errorf("%s %v: expected git branch %q, got %q", test.ciSystem, test.envs, test.expectedGitBranch, gitBranch)
+		}
+
+		if env := provider.Env(); env != test.expectedEnv {
+			t.Errorf("%s %v: expected env %q, got %q", test.ciSystem, test.envs, test.expectedEnv, env)
+		}
+
+		unsetEnvs()
+	}
+}
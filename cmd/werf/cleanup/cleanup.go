@@ -55,9 +55,11 @@ It is safe to run this command periodically (daily is enough) by automated clean
 	common.SetupSynchronization(&commonCmdData, cmd)
 	common.SetupImagesRepo(&commonCmdData, cmd)
 	common.SetupImagesRepoMode(&commonCmdData, cmd)
+	common.SetupImagesRepoTemplateEnvs(&commonCmdData, cmd)
 	common.SetupDockerConfig(&commonCmdData, cmd, "Command needs granted permissions to read, pull and delete images from the specified stages storage and images repo")
 	common.SetupInsecureRegistry(&commonCmdData, cmd)
 	common.SetupSkipTlsVerifyRegistry(&commonCmdData, cmd)
+	common.SetupRepoImplementation(&commonCmdData, cmd)
 	common.SetupImagesCleanupPolicies(&commonCmdData, cmd)
 
 	common.SetupKubeConfig(&commonCmdData, cmd)
@@ -82,7 +84,7 @@ func runCleanup() error {
 		return err
 	}
 
-	if err := docker_registry.Init(docker_registry.Options{InsecureRegistry: *commonCmdData.InsecureRegistry, SkipTlsVerifyRegistry: *commonCmdData.SkipTlsVerifyRegistry}); err != nil {
+	if err := docker_registry.Init(docker_registry.Options{InsecureRegistry: *commonCmdData.InsecureRegistry, SkipTlsVerifyRegistry: *commonCmdData.SkipTlsVerifyRegistry, RepoImplementation: *commonCmdData.RepoImplementation}); err != nil {
 		return err
 	}
 
@@ -123,16 +125,6 @@ func runCleanup() error {
 		return err
 	}
 
-	imagesRepoMode, err := common.GetImagesRepoMode(&commonCmdData)
-	if err != nil {
-		return err
-	}
-
-	imagesRepoManager, err := common.GetImagesRepoManager(imagesRepo, imagesRepoMode)
-	if err != nil {
-		return err
-	}
-
 	if _, err := common.GetStagesStorage(&commonCmdData); err != nil {
 		return err
 	}
@@ -147,6 +139,11 @@ func runCleanup() error {
 	}
 	logboek.Debug.LogF("Managed images names: %v\n", imagesNames)
 
+	imagesRepoManager, err := common.GetImagesRepoManagerByCmdData(projectName, imagesRepo, imagesNames, &commonCmdData)
+	if err != nil {
+		return err
+	}
+
 	var localGitRepo cleaning.GitRepo
 	gitDir := filepath.Join(projectDir, ".git")
 	if exist, err := util.DirExists(gitDir); err != nil {
@@ -163,6 +160,11 @@ func runCleanup() error {
 		return err
 	}
 
+	imagesPolicies, err := common.GetManagedImagesCleanupPolicies(projectName, stagesStorage, policies)
+	if err != nil {
+		return err
+	}
+
 	kubernetesContextsClients, err := kube.GetAllContextsClients(kube.GetAllContextsClientsOptions{KubeConfig: *commonCmdData.KubeConfig})
 	if err != nil {
 		return fmt.Errorf("unable to get Kubernetes clusters connections: %s", err)
@@ -178,6 +180,7 @@ func runCleanup() error {
 		KubernetesContextsClients: kubernetesContextsClients,
 		WithoutKube:               *commonCmdData.WithoutKube,
 		Policies:                  policies,
+		ImagesPolicies:            imagesPolicies,
 	}
 
 	stagesCleanupOptions := cleaning.StagesCleanupOptions{
@@ -20,7 +20,11 @@ import (
 	cleanup "github.com/flant/werf/pkg/cleaning"
 	"github.com/flant/werf/pkg/config"
 	"github.com/flant/werf/pkg/deploy/helm"
+	"github.com/flant/werf/pkg/docker_registry"
+	"github.com/flant/werf/pkg/image"
+	"github.com/flant/werf/pkg/image_signing"
 	"github.com/flant/werf/pkg/logging"
+	"github.com/flant/werf/pkg/tracing"
 	"github.com/flant/werf/pkg/util"
 	"github.com/flant/werf/pkg/werf"
 )
@@ -36,6 +40,7 @@ type CmdData struct {
 	TagGitBranch         *string
 	TagGitTag            *string
 	TagGitCommit         *string
+	TagSemver            *string
 	TagByStagesSignature *bool
 
 	Environment                      *string
@@ -57,16 +62,30 @@ type CmdData struct {
 	SecretValues    *[]string
 	IgnoreSecretKey *bool
 
-	StagesStorage   *string
-	Synchronization *string
-	ImagesRepo      *string
-	ImagesRepoMode  *string
+	StagesStorage      *string
+	Synchronization    *string
+	ImagesRepo         *string
+	ImagesRepoMode     *string
+	ImagesRepoTemplate *string
+	// ImagesRepoTemplateEnvs are set up only for the cleanup commands
+	ImagesRepoTemplateEnvs *string
 
 	DockerConfig          *string
 	InsecureRegistry      *bool
 	SkipTlsVerifyRegistry *bool
+	RepoImplementation    *string
 	DryRun                *bool
 
+	SignKey           *string
+	VerifyImagesKey   *string
+	DaemonlessPublish *bool
+
+	ReportPath   *string
+	ReportFormat *string
+
+	OtelEndpoint *string
+	OtelFile     *string
+
 	GitTagStrategyLimit         *int64
 	GitTagStrategyExpiryDays    *int64
 	GitCommitStrategyLimit      *int64
@@ -76,11 +95,18 @@ type CmdData struct {
 
 	StagesToIntrospect *[]string
 
+	VerifyReproducible *bool
+	StagesToVerify     *[]string
+	SetSourceDateEpoch *bool
+
+	ContainerBackend *string
+
 	LogDebug         *bool
 	LogPretty        *bool
 	LogVerbose       *bool
 	LogQuiet         *bool
 	LogColorMode     *string
+	LogFormat        *string
 	LogProjectDir    *bool
 	LogTerminalWidth *int64
 
@@ -91,6 +117,11 @@ const (
 	CleaningCommandsForceOptionDescription = "Remove containers that are based on deleting werf docker images"
 )
 
+const (
+	DockerContainerBackend = "docker"
+	RuncContainerBackend   = "runc"
+)
+
 func GetLongCommandDescription(text string) string {
 	return logboek.FitText(text, logboek.FitTextOptions{MaxWidth: 100})
 }
@@ -150,12 +181,14 @@ func SetupTag(cmdData *CmdData, cmd *cobra.Command) {
 	cmdData.TagGitBranch = new(string)
 	cmdData.TagGitTag = new(string)
 	cmdData.TagGitCommit = new(string)
+	cmdData.TagSemver = new(string)
 	cmdData.TagByStagesSignature = new(bool)
 
 	cmd.Flags().StringArrayVarP(cmdData.TagCustom, "tag-custom", "", tagCustom, "Use custom tagging strategy and tag by the specified arbitrary tags.\nOption can be used multiple times to produce multiple images with the specified tags.\nAlso can be specified in $WERF_TAG_CUSTOM* (e.g. $WERF_TAG_CUSTOM_TAG1=tag1, $WERF_TAG_CUSTOM_TAG2=tag2)")
 	cmd.Flags().StringVarP(cmdData.TagGitBranch, "tag-git-branch", "", os.Getenv("WERF_TAG_GIT_BRANCH"), "Use git-branch tagging strategy and tag by the specified git branch (option can be enabled by specifying git branch in the $WERF_TAG_GIT_BRANCH)")
 	cmd.Flags().StringVarP(cmdData.TagGitTag, "tag-git-tag", "", os.Getenv("WERF_TAG_GIT_TAG"), "Use git-tag tagging strategy and tag by the specified git tag (option can be enabled by specifying git tag in the $WERF_TAG_GIT_TAG)")
 	cmd.Flags().StringVarP(cmdData.TagGitCommit, "tag-git-commit", "", os.Getenv("WERF_TAG_GIT_COMMIT"), "Use git-commit tagging strategy and tag by the specified git commit hash (option can be enabled by specifying git commit hash in the $WERF_TAG_GIT_COMMIT)")
+	cmd.Flags().StringVarP(cmdData.TagSemver, "tag-semver", "", os.Getenv("WERF_TAG_SEMVER"), "Use semver tagging strategy and tag by the specified git tag with semantic version (e.g. v1.4.2).\nFloating aliases MAJOR, MAJOR.MINOR and latest are moved to the image when the release is the highest one in the images repo (option can be enabled by specifying git tag in the $WERF_TAG_SEMVER)")
 	cmd.Flags().BoolVarP(cmdData.TagByStagesSignature, "tag-by-stages-signature", "", GetBoolEnvironmentDefaultFalse("WERF_TAG_BY_STAGES_SIGNATURE"), "Use stages-signature tagging strategy and tag each image by the corresponding signature of last image stage (option can be enabled by specifying $WERF_TAG_BY_STAGES_SIGNATURE=true)")
 }
 
@@ -332,7 +365,15 @@ func SetupImagesRepoMode(cmdData *CmdData, cmd *cobra.Command) {
 		defaultValue = MultirepoImagesRepoMode
 	}
 
-	cmd.Flags().StringVarP(cmdData.ImagesRepoMode, "images-repo-mode", "", defaultValue, fmt.Sprintf(`Define how to store images in Repo: %[1]s or %[2]s (defaults to $WERF_IMAGES_REPO_MODE or %[1]s)`, MultirepoImagesRepoMode, MonorepoImagesRepoMode))
+	cmd.Flags().StringVarP(cmdData.ImagesRepoMode, "images-repo-mode", "", defaultValue, fmt.Sprintf(`Define how to store images in Repo: %[1]s, %[2]s or %[3]s (defaults to $WERF_IMAGES_REPO_MODE or %[1]s)`, MultirepoImagesRepoMode, MonorepoImagesRepoMode, TemplateImagesRepoMode))
+
+	cmdData.ImagesRepoTemplate = new(string)
+	cmd.Flags().StringVarP(cmdData.ImagesRepoTemplate, "images-repo-template", "", os.Getenv("WERF_IMAGES_REPO_TEMPLATE"), `Go template to form the image repository, enables template images repo mode. The values .Repo, .ImageName, .Env and .Project are available in the template (default $WERF_IMAGES_REPO_TEMPLATE)`)
+}
+
+func SetupImagesRepoTemplateEnvs(cmdData *CmdData, cmd *cobra.Command) {
+	cmdData.ImagesRepoTemplateEnvs = new(string)
+	cmd.Flags().StringVarP(cmdData.ImagesRepoTemplateEnvs, "images-repo-template-envs", "", os.Getenv("WERF_IMAGES_REPO_TEMPLATE_ENVS"), `Comma-separated list of all environments of the project to process the images repositories of each environment, required in the template images repo mode if the template uses .Env (default $WERF_IMAGES_REPO_TEMPLATE_ENVS)`)
 }
 
 func SetupInsecureRegistry(cmdData *CmdData, cmd *cobra.Command) {
@@ -345,6 +386,26 @@ func SetupSkipTlsVerifyRegistry(cmdData *CmdData, cmd *cobra.Command) {
 	cmd.Flags().BoolVarP(cmdData.SkipTlsVerifyRegistry, "skip-tls-verify-registry", "", GetBoolEnvironmentDefaultFalse("WERF_SKIP_TLS_VERIFY_REGISTRY"), "Skip TLS certificate validation when accessing a registry (default $WERF_SKIP_TLS_VERIFY_REGISTRY)")
 }
 
+func SetupRepoImplementation(cmdData *CmdData, cmd *cobra.Command) {
+	cmdData.RepoImplementation = new(string)
+	cmd.Flags().StringVarP(cmdData.RepoImplementation, "repo-implementation", "", os.Getenv("WERF_REPO_IMPLEMENTATION"), fmt.Sprintf("Choose the registry implementation to delete images: %s.\nThe implementation is detected by the registry hostname by default (default $WERF_REPO_IMPLEMENTATION)", strings.Join(docker_registry.ImplementationNames(), ", ")))
+}
+
+func SetupSignKey(cmdData *CmdData, cmd *cobra.Command) {
+	cmdData.SignKey = new(string)
+	cmd.Flags().StringVarP(cmdData.SignKey, "sign-key", "", os.Getenv("WERF_SIGN_KEY"), "Sign each published image with the specified cosign-compatible ECDSA private key file and push detached signature into the images repo (default $WERF_SIGN_KEY).\nPassword of an encrypted key can be specified by the $WERF_SIGN_KEY_PASSWORD or $COSIGN_PASSWORD")
+}
+
+func SetupDaemonlessPublish(cmdData *CmdData, cmd *cobra.Command) {
+	cmdData.DaemonlessPublish = new(bool)
+	cmd.Flags().BoolVarP(cmdData.DaemonlessPublish, "daemonless-publish", "", GetBoolEnvironmentDefaultFalse("WERF_DAEMONLESS_PUBLISH"), "Publish images without building final images by docker: only image config with meta information and manifest are pushed, the layers are reused from the image already published from the same stage into the images repo (the layers blobs are checked by digest). Publishing fails if there is no such image (default $WERF_DAEMONLESS_PUBLISH)")
+}
+
+func SetupVerifyImagesKey(cmdData *CmdData, cmd *cobra.Command) {
+	cmdData.VerifyImagesKey = new(string)
+	cmd.Flags().StringVarP(cmdData.VerifyImagesKey, "verify-images-key", "", os.Getenv("WERF_VERIFY_IMAGES_KEY"), "Refuse to deploy images without a valid signature made by the private key corresponding to the specified public key file (default $WERF_VERIFY_IMAGES_KEY)")
+}
+
 func SetupDryRun(cmdData *CmdData, cmd *cobra.Command) {
 	cmdData.DryRun = new(bool)
 	cmd.Flags().BoolVarP(cmdData.DryRun, "dry-run", "", false, "Indicate what the command would do without actually doing that")
@@ -374,6 +435,7 @@ func SetupLogOptions(cmdData *CmdData, cmd *cobra.Command) {
 	SetupLogQuiet(cmdData, cmd)
 	SetupLogColor(cmdData, cmd)
 	SetupLogPretty(cmdData, cmd)
+	SetupLogFormat(cmdData, cmd)
 	SetupTerminalWidth(cmdData, cmd)
 }
 
@@ -501,6 +563,18 @@ func SetupLogPretty(cmdData *CmdData, cmd *cobra.Command) {
 	cmd.Flags().BoolVarP(cmdData.LogPretty, "log-pretty", "", defaultValue, `Enable emojis, auto line wrapping and log process border (default $WERF_LOG_PRETTY or true).`)
 }
 
+func SetupLogFormat(cmdData *CmdData, cmd *cobra.Command) {
+	cmdData.LogFormat = new(string)
+
+	defaultValue := os.Getenv("WERF_LOG_FORMAT")
+	if defaultValue == "" {
+		defaultValue = "text"
+	}
+
+	cmd.Flags().StringVarP(cmdData.LogFormat, "log-format", "", defaultValue, `Set log format: text or json.
+json format prints one json object per event: process start and end with duration and status, log lines with level, image, stage and phase, deployed containers log lines (default $WERF_LOG_FORMAT or text).`)
+}
+
 func SetupTerminalWidth(cmdData *CmdData, cmd *cobra.Command) {
 	cmdData.LogTerminalWidth = new(int64)
 	cmd.Flags().Int64VarP(cmdData.LogTerminalWidth, "log-terminal-width", "", -1, fmt.Sprintf(`Set log terminal width.
@@ -551,6 +625,111 @@ IMAGE_NAME is the name of an image or artifact described in werf.yaml, the namel
 STAGE_NAME should be one of the following: `+strings.Join(allStagesNames(), ", "))
 }
 
+func SetupReproducibility(cmdData *CmdData, cmd *cobra.Command) {
+	cmdData.VerifyReproducible = new(bool)
+	cmdData.StagesToVerify = new([]string)
+	cmdData.SetSourceDateEpoch = new(bool)
+
+	cmd.Flags().BoolVarP(cmdData.VerifyReproducible, "verify-reproducible", "", GetBoolEnvironmentDefaultFalse("WERF_VERIFY_REPRODUCIBLE"), `Rebuild stages from the same parent images after the build and compare files of the rebuilt stages with the stages images, ignoring modification times.
+Non-reproducible files are reported per stage and the command fails (default $WERF_VERIFY_REPRODUCIBLE)`)
+	cmd.Flags().StringArrayVarP(cmdData.StagesToVerify, "verify-reproducible-stage", "", []string{}, `Verify reproducibility only of a specific stage, the option implies --verify-reproducible and can be used multiple times.
+The format is the same as for --introspect-stage: IMAGE_NAME/STAGE_NAME, STAGE_NAME or */STAGE_NAME`)
+	cmd.Flags().BoolVarP(cmdData.SetSourceDateEpoch, "source-date-epoch", "", GetBoolEnvironmentDefaultFalse("WERF_SOURCE_DATE_EPOCH"), `Run assembly instructions with SOURCE_DATE_EPOCH environment variable set to the creation time of the stage parent image.
+Only tools supporting SOURCE_DATE_EPOCH write stable timestamps into files, werf does not change the files of the stages (default $WERF_SOURCE_DATE_EPOCH)`)
+}
+
+func SetupContainerBackend(cmdData *CmdData, cmd *cobra.Command) {
+	cmdData.ContainerBackend = new(string)
+
+	defaultValue := os.Getenv("WERF_CONTAINER_BACKEND")
+	if defaultValue == "" {
+		defaultValue = DockerContainerBackend
+	}
+
+	cmd.Flags().StringVarP(cmdData.ContainerBackend, "container-backend", "", defaultValue, fmt.Sprintf(`Run stapel stages containers with %[1]s or %[2]s (default $WERF_CONTAINER_BACKEND or %[1]s).
+The experimental %[2]s backend runs the containers by the runc binary instead of docker containers. It is not daemonless: docker daemon is still required, base images are taken from docker daemon and the built stages are loaded back into docker daemon`, DockerContainerBackend, RuncContainerBackend))
+}
+
+func GetContainerBackendName(cmdData *CmdData) (string, error) {
+	switch *cmdData.ContainerBackend {
+	case DockerContainerBackend, RuncContainerBackend:
+		return *cmdData.ContainerBackend, nil
+	default:
+		return "", fmt.Errorf("bad --container-backend '%s': only %s or %s supported", *cmdData.ContainerBackend, DockerContainerBackend, RuncContainerBackend)
+	}
+}
+
+// InitContainerBackend sets the container backend of the stapel stages, docker daemon should be initialized
+func InitContainerBackend(cmdData *CmdData) error {
+	backendName, err := GetContainerBackendName(cmdData)
+	if err != nil {
+		return err
+	}
+
+	switch backendName {
+	case RuncContainerBackend:
+		backend, err := image.NewDockerRuncBackend(filepath.Join(werf.GetHomeDir(), "runc"))
+		if err != nil {
+			return fmt.Errorf("unable to init %s container backend, which requires docker daemon: %s", RuncContainerBackend, err)
+		}
+		image.SetContainerBackend(backend)
+	default:
+		image.SetContainerBackend(&image.DockerBackend{})
+	}
+
+	return nil
+}
+
+func SetupReport(cmdData *CmdData, cmd *cobra.Command) {
+	cmdData.ReportPath = new(string)
+	cmdData.ReportFormat = new(string)
+
+	cmd.Flags().StringVarP(cmdData.ReportPath, "report-path", "", os.Getenv("WERF_REPORT_PATH"), "Write build report with stages signatures, cache usage, timings, sizes and published images into the specified file (default $WERF_REPORT_PATH)")
+
+	defaultReportFormat := os.Getenv("WERF_REPORT_FORMAT")
+	if defaultReportFormat == "" {
+		defaultReportFormat = string(build.ReportJSON)
+	}
+	cmd.Flags().StringVarP(cmdData.ReportFormat, "report-format", "", defaultReportFormat, fmt.Sprintf("Build report format: %s or %s (default $WERF_REPORT_FORMAT or %s)", build.ReportJSON, build.ReportJUnit, build.ReportJSON))
+}
+
+func GetReportOptions(cmdData *CmdData) (build.ReportOptions, error) {
+	switch format := build.ReportFormat(*cmdData.ReportFormat); format {
+	case build.ReportJSON, build.ReportJUnit:
+		return build.ReportOptions{Path: *cmdData.ReportPath, Format: format}, nil
+	default:
+		return build.ReportOptions{}, fmt.Errorf("bad --report-format '%s': only %s and %s supported", format, build.ReportJSON, build.ReportJUnit)
+	}
+}
+
+func SetupOtel(cmdData *CmdData, cmd *cobra.Command) {
+	cmdData.OtelEndpoint = new(string)
+	cmdData.OtelFile = new(string)
+
+	cmd.Flags().StringVarP(cmdData.OtelEndpoint, "otel-endpoint", "", os.Getenv("WERF_OTEL_ENDPOINT"), "Export OpenTelemetry traces of conveyor phases, stages, registry calls, git operations and helm deploy to the OTLP/HTTP collector endpoint, e.g. http://localhost:4318 (default $WERF_OTEL_ENDPOINT)")
+	cmd.Flags().StringVarP(cmdData.OtelFile, "otel-file", "", os.Getenv("WERF_OTEL_FILE"), "Write OpenTelemetry traces into the specified file in the OTLP JSON format for offline analysis (default $WERF_OTEL_FILE)")
+}
+
+// WithTracing runs command inside the root span when tracing is enabled, traces are exported after command is done
+func WithTracing(cmdData *CmdData, cmd *cobra.Command, f func() error) error {
+	if err := tracing.Init(tracing.Options{
+		Endpoint:    *cmdData.OtelEndpoint,
+		File:        *cmdData.OtelFile,
+		ServiceName: "werf",
+		Attributes:  map[string]string{"service.version": werf.Version},
+	}); err != nil {
+		return fmt.Errorf("tracing initialization failed: %s", err)
+	}
+
+	err := tracing.WithSpan(cmd.CommandPath(), nil, f)
+
+	if shutdownErr := tracing.Shutdown(); shutdownErr != nil {
+		logboek.LogWarnF("WARNING: traces export failed: %s\n", shutdownErr)
+	}
+
+	return err
+}
+
 func allStagesNames() []string {
 	var stageNames []string
 	for _, stageName := range stage.AllStages {
@@ -764,12 +943,69 @@ func GetImagesRepo(projectName string, cmdData *CmdData) (string, error) {
 }
 
 func GetImagesRepoMode(cmdData *CmdData) (string, error) {
+	if *cmdData.ImagesRepoTemplate != "" {
+		return TemplateImagesRepoMode, nil
+	}
+
 	switch *cmdData.ImagesRepoMode {
 	case MultirepoImagesRepoMode, MonorepoImagesRepoMode:
 		return *cmdData.ImagesRepoMode, nil
+	case TemplateImagesRepoMode:
+		return "", fmt.Errorf("--images-repo-template param required for %s images repo mode", TemplateImagesRepoMode)
 	default:
-		return "", fmt.Errorf("bad --images-repo-mode '%s': only %s or %s supported", *cmdData.ImagesRepoMode, MultirepoImagesRepoMode, MonorepoImagesRepoMode)
+		return "", fmt.Errorf("bad --images-repo-mode '%s': only %s, %s or %s supported", *cmdData.ImagesRepoMode, MultirepoImagesRepoMode, MonorepoImagesRepoMode, TemplateImagesRepoMode)
+	}
+}
+
+func GetImagesRepoManagerByCmdData(projectName, imagesRepo string, imagesNames []string, cmdData *CmdData) (*ImagesRepoManager, error) {
+	environment := os.Getenv("WERF_ENV")
+	if cmdData.Environment != nil {
+		environment = *cmdData.Environment
+	}
+
+	return GetImagesRepoManagerWithEnvironment(projectName, imagesRepo, environment, imagesNames, cmdData)
+}
+
+// GetImagesRepoManagerWithEnvironment creates images repo manager by the images repo mode, the environment is required in the template mode only if the template uses it.
+// The cleanup commands process the images repositories of all environments specified by --images-repo-template-envs instead.
+// The images names are required in the template mode to validate the rendered images repositories
+func GetImagesRepoManagerWithEnvironment(projectName, imagesRepo, environment string, imagesNames []string, cmdData *CmdData) (*ImagesRepoManager, error) {
+	imagesRepoMode, err := GetImagesRepoMode(cmdData)
+	if err != nil {
+		return nil, err
+	}
+
+	if imagesRepoMode != TemplateImagesRepoMode {
+		return GetImagesRepoManager(imagesRepo, imagesRepoMode)
+	}
+
+	data := ImagesRepoTemplateData{Env: environment, Project: projectName}
+	if cmdData.ImagesRepoTemplateEnvs != nil {
+		var environments []string
+		for _, env := range strings.Split(*cmdData.ImagesRepoTemplateEnvs, ",") {
+			if env = strings.TrimSpace(env); env != "" {
+				environments = append(environments, env)
+			}
+		}
+
+		return GetImagesRepoManagerByTemplateForEnvironments(imagesRepo, *cmdData.ImagesRepoTemplate, data, environments, imagesNames)
+	}
+
+	return GetImagesRepoManagerByTemplate(imagesRepo, *cmdData.ImagesRepoTemplate, data, imagesNames)
+}
+
+// GetWerfConfigImagesNames returns names of the images, which are published into the images repo
+func GetWerfConfigImagesNames(werfConfig *config.WerfConfig) []string {
+	var imagesNames []string
+	for _, image := range werfConfig.StapelImages {
+		imagesNames = append(imagesNames, image.Name)
+	}
+
+	for _, image := range werfConfig.ImagesFromDockerfile {
+		imagesNames = append(imagesNames, image.Name)
 	}
+
+	return imagesNames
 }
 
 func GetOptionalImagesRepo(projectName string, cmdData *CmdData) (string, error) {
@@ -789,6 +1025,22 @@ func GetOptionalImagesRepo(projectName string, cmdData *CmdData) (string, error)
 	return "", nil
 }
 
+func GetImageSigner(cmdData *CmdData) (*image_signing.Signer, error) {
+	if *cmdData.SignKey == "" {
+		return nil, nil
+	}
+
+	return image_signing.NewSigner(*cmdData.SignKey)
+}
+
+func GetImagesVerifier(cmdData *CmdData) (*image_signing.Verifier, error) {
+	if *cmdData.VerifyImagesKey == "" {
+		return nil, nil
+	}
+
+	return image_signing.NewVerifier(*cmdData.VerifyImagesKey)
+}
+
 func GetOptionalWerfConfig(projectDir string, logRenderedFilePath bool) (*config.WerfConfig, error) {
 	werfConfigPath, err := GetWerfConfigPath(projectDir, false)
 	if err != nil {
@@ -850,6 +1102,28 @@ func GetNamespace(namespaceOption string) string {
 }
 
 func GetIntrospectOptions(cmdData *CmdData, werfConfig *config.WerfConfig) (build.IntrospectOptions, error) {
+	targets, err := getImageStageTargets(*cmdData.StagesToIntrospect, werfConfig)
+	if err != nil {
+		return build.IntrospectOptions{}, err
+	}
+
+	return build.IntrospectOptions{Targets: targets}, nil
+}
+
+func GetReproducibilityOptions(cmdData *CmdData, werfConfig *config.WerfConfig) (build.ReproducibilityOptions, error) {
+	targets, err := getImageStageTargets(*cmdData.StagesToVerify, werfConfig)
+	if err != nil {
+		return build.ReproducibilityOptions{}, err
+	}
+
+	return build.ReproducibilityOptions{
+		Verify:             *cmdData.VerifyReproducible || len(targets) != 0,
+		Targets:            targets,
+		SetSourceDateEpoch: *cmdData.SetSourceDateEpoch,
+	}, nil
+}
+
+func getImageStageTargets(imagesAndStages []string, werfConfig *config.WerfConfig) ([]build.IntrospectTarget, error) {
 	isStageExist := func(sName string) bool {
 		for _, stageName := range allStagesNames() {
 			if sName == stageName {
@@ -860,8 +1134,8 @@ func GetIntrospectOptions(cmdData *CmdData, werfConfig *config.WerfConfig) (buil
 		return false
 	}
 
-	introspectOptions := build.IntrospectOptions{}
-	for _, imageAndStage := range *cmdData.StagesToIntrospect {
+	var targets []build.IntrospectTarget
+	for _, imageAndStage := range imagesAndStages {
 		var imageName, stageName string
 
 		parts := strings.SplitN(imageAndStage, "/", 2)
@@ -877,18 +1151,17 @@ func GetIntrospectOptions(cmdData *CmdData, werfConfig *config.WerfConfig) (buil
 		}
 
 		if imageName != "*" && !werfConfig.HasImageOrArtifact(imageName) {
-			return introspectOptions, fmt.Errorf("specified image %s (%s) is not defined in werf.yaml", imageName, imageAndStage)
+			return nil, fmt.Errorf("specified image %s (%s) is not defined in werf.yaml", imageName, imageAndStage)
 		}
 
 		if !isStageExist(stageName) {
-			return introspectOptions, fmt.Errorf("specified stage name %s (%s) is not exist", stageName, imageAndStage)
+			return nil, fmt.Errorf("specified stage name %s (%s) is not exist", stageName, imageAndStage)
 		}
 
-		introspectTarget := build.IntrospectTarget{ImageName: imageName, StageName: stageName}
-		introspectOptions.Targets = append(introspectOptions.Targets, introspectTarget)
+		targets = append(targets, build.IntrospectTarget{ImageName: imageName, StageName: stageName})
 	}
 
-	return introspectOptions, nil
+	return targets, nil
 }
 
 func LogKubeContext(kubeContext string) {
@@ -904,6 +1177,10 @@ func ProcessLogProjectDir(cmdData *CmdData, projectDir string) {
 }
 
 func ProcessLogOptions(cmdData *CmdData) error {
+	if err := ProcessLogFormat(cmdData); err != nil {
+		return err
+	}
+
 	if err := ProcessLogColorMode(cmdData); err != nil {
 		return err
 	}
@@ -927,6 +1204,20 @@ func ProcessLogOptions(cmdData *CmdData) error {
 	return nil
 }
 
+func ProcessLogFormat(cmdData *CmdData) error {
+	switch *cmdData.LogFormat {
+	case "text":
+	case "json":
+		if err := logging.EnableJsonFormat(); err != nil {
+			return err
+		}
+	default:
+		return fmt.Errorf("bad log format '%s': text and json formats are supported", *cmdData.LogFormat)
+	}
+
+	return nil
+}
+
 func ProcessLogColorMode(cmdData *CmdData) error {
 	logColorMode := *cmdData.LogColorMode
 
@@ -1022,5 +1313,6 @@ func TerminateWithError(errMsg string, exitCode int) {
 	msg = strings.TrimSuffix(msg, "\n")
 
 	logboek.LogErrorLn(msg)
+	logging.Close()
 	os.Exit(exitCode)
 }
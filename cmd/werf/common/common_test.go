@@ -0,0 +1,19 @@
+package common
+
+import "testing"
+
+func TestGetContainerBackendName(t *testing.T) {
+	for _, backendName := range []string{DockerContainerBackend, RuncContainerBackend} {
+		name, err := GetContainerBackendName(&CmdData{ContainerBackend: &backendName})
+		if err != nil {
+			t.Errorf("unexpected error for %s backend: %s", backendName, err)
+		} else if name != backendName {
+			t.Errorf("expected %s backend, got %s", backendName, name)
+		}
+	}
+
+	backendName := "podman"
+	if _, err := GetContainerBackendName(&CmdData{ContainerBackend: &backendName}); err == nil {
+		t.Errorf("expected error for %s backend", backendName)
+	}
+}
@@ -16,6 +16,11 @@ const (
 	WerfDebugAnsibleArgs Env = "WERF_DEBUG_ANSIBLE_ARGS"
 	WerfSecretKey        Env = "WERF_SECRET_KEY"
 	WerfOldSecretKey     Env = "WERF_OLD_SECRET_KEY"
+
+	WerfSecretRecipients    Env = "WERF_SECRET_RECIPIENTS"
+	WerfSecretAgeIdentity   Env = "WERF_SECRET_AGE_IDENTITY"
+	WerfSecretPgpKey        Env = "WERF_SECRET_PGP_KEY"
+	WerfSecretPgpPassphrase Env = "WERF_SECRET_PGP_PASSPHRASE"
 )
 
 var envDescription = map[Env]string{
@@ -24,8 +29,26 @@ var envDescription = map[Env]string{
 
 Secret key also can be defined in files:
 * ~/.werf/global_secret_key (globally),
-* .werf_secret_key (per project)`,
+* .werf_secret_key (per project).
+
+Environment secret values and secret files can be encrypted by the environment key, which is defined in $WERF_SECRET_KEY_<ENV> (e.g. $WERF_SECRET_KEY_PRODUCTION), ~/.werf/global_secret_key.<env> or .werf_secret_key.<env> file`,
 	WerfOldSecretKey: "Use specified old secret key to rotate secrets",
+	WerfSecretRecipients: `Encrypt secrets for specified recipients instead of the secret key: age X25519 public keys (age1...), paths to the armored OpenPGP public keys (pgp:PATH) or kms key (vault:[MOUNT/]KEY for Vault transit with $VAULT_ADDR and $VAULT_TOKEN, kms:URL for generic KMS HTTP API with $WERF_SECRET_KMS_TOKEN), separated by comma or newline.
+
+Recipients also can be defined in files:
+* ~/.werf/global_secret_recipients (globally),
+* .werf_secret_recipients (per project)`,
+	WerfSecretAgeIdentity: `Use specified age identities (AGE-SECRET-KEY-1...) to extract secrets encrypted for age recipients.
+
+Identities also can be defined in files:
+* ~/.werf/global_secret_age_identity (globally),
+* .werf_secret_age_identity (per project)`,
+	WerfSecretPgpKey: `Use specified armored OpenPGP private key to extract secrets encrypted for OpenPGP recipients.
+
+Private key also can be defined in files:
+* ~/.werf/global_secret_pgp_key (globally),
+* .werf_secret_pgp_key (per project)`,
+	WerfSecretPgpPassphrase: "Use specified passphrase to decrypt OpenPGP private key",
 }
 
 func EnvsDescription(envs ...Env) string {
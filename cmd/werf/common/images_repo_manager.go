@@ -1,27 +1,44 @@
 package common
 
 import (
+	"bytes"
 	"fmt"
 	"strings"
+	"text/template"
+
+	"github.com/google/go-containerregistry/pkg/name"
+
+	"github.com/flant/werf/pkg/util"
 )
 
 const (
 	MultirepoImagesRepoMode   = "multirepo"
 	MonorepoImagesRepoMode    = "monorepo"
+	TemplateImagesRepoMode    = "template"
 	MonorepoTagPartsSeparator = "-"
 )
 
+// ImagesRepoTemplateData is available in the images repo template, e.g. {{.Repo}}/{{.ImageName}}/{{.Env}}
+type ImagesRepoTemplateData struct {
+	Repo      string
+	ImageName string
+	Env       string
+	Project   string
+}
+
 type ImagesRepoManager struct {
 	imagesRepo            string
 	namelessImageRepoFunc func(imagesRepo string) string
-	imageRepoFunc         func(imagesRepo, imageName string) string
+	imageRepoFunc         func(imagesRepo, imageName string) (string, error)
 	imageRepoTagFunc      func(imageName, tag string) string
+	// imageReposFunc returns the image repositories of all environments processed by the cleanup commands in the template images repo mode
+	imageReposFunc func(imageName string) ([]string, error)
 }
 
 func newImagesRepoManager(
 	imagesRepo string,
 	namelessImageRepoFunc func(imagesRepo string) string,
-	imageRepoFunc func(imagesRepo, imageName string) string,
+	imageRepoFunc func(imagesRepo, imageName string) (string, error),
 	imageRepoTagFunc func(imageName, tag string) string) *ImagesRepoManager {
 
 	formattedImagesRepo := strings.TrimRight(imagesRepo, "/")
@@ -38,32 +55,50 @@ func (m *ImagesRepoManager) ImagesRepo() string {
 	return m.imagesRepo
 }
 
-func (m *ImagesRepoManager) ImageRepo(imageName string) string {
-	var repo string
+func (m *ImagesRepoManager) ImageRepo(imageName string) (string, error) {
 	if imageName == "" {
-		repo = m.namelessImageRepoFunc(m.imagesRepo)
-	} else {
-		repo = m.imageRepoFunc(m.imagesRepo, imageName)
+		return m.namelessImageRepoFunc(m.imagesRepo), nil
 	}
 
-	return repo
+	return m.imageRepoFunc(m.imagesRepo, imageName)
+}
+
+// ImageRepos returns the image repositories of all processed environments, there is only the image repository unless the images repo template uses the environment
+func (m *ImagesRepoManager) ImageRepos(imageName string) ([]string, error) {
+	if m.imageReposFunc != nil {
+		return m.imageReposFunc(imageName)
+	}
+
+	repo, err := m.ImageRepo(imageName)
+	if err != nil {
+		return nil, err
+	}
+
+	return []string{repo}, nil
 }
 
 func (m *ImagesRepoManager) ImageRepoTag(imageName, tag string) string {
 	return m.imageRepoTagFunc(imageName, tag)
 }
 
-func (m *ImagesRepoManager) ImageRepoWithTag(imageName, tag string) string {
-	return strings.Join([]string{m.ImageRepo(imageName), m.ImageRepoTag(imageName, tag)}, ":")
+func (m *ImagesRepoManager) ImageRepoWithTag(imageName, tag string) (string, error) {
+	repo, err := m.ImageRepo(imageName)
+	if err != nil {
+		return "", err
+	}
+
+	return strings.Join([]string{repo, m.ImageRepoTag(imageName, tag)}, ":"), nil
 }
 
+// IsMonorepo checks the repository of the image, which is always available (the template mode manager renders it in advance)
 func (m *ImagesRepoManager) IsMonorepo() bool {
-	return m.ImagesRepo() == m.ImageRepo("image")
+	repo, err := m.ImageRepo("image")
+	return err == nil && m.ImagesRepo() == repo
 }
 
 func GetImagesRepoManager(imagesRepo, imagesRepoMode string) (*ImagesRepoManager, error) {
 	var namelessImageRepoFunc func(imagesRepo string) string
-	var imageRepoFunc func(imagesRepo, imageName string) string
+	var imageRepoFunc func(imagesRepo, imageName string) (string, error)
 	var imageRepoTagFunc func(imageName, tag string) string
 
 	switch imagesRepoMode {
@@ -72,8 +107,8 @@ func GetImagesRepoManager(imagesRepo, imagesRepoMode string) (*ImagesRepoManager
 			return imagesRepo
 		}
 
-		imageRepoFunc = func(imagesRepo, imageName string) string {
-			return strings.Join([]string{imagesRepo, imageName}, "/")
+		imageRepoFunc = func(imagesRepo, imageName string) (string, error) {
+			return strings.Join([]string{imagesRepo, imageName}, "/"), nil
 		}
 
 		imageRepoTagFunc = func(_, tag string) string {
@@ -84,8 +119,8 @@ func GetImagesRepoManager(imagesRepo, imagesRepoMode string) (*ImagesRepoManager
 			return imagesRepo
 		}
 
-		imageRepoFunc = func(imagesRepo, _ string) string {
-			return imagesRepo
+		imageRepoFunc = func(imagesRepo, _ string) (string, error) {
+			return imagesRepo, nil
 		}
 
 		imageRepoTagFunc = func(imageName, tag string) string {
@@ -96,7 +131,7 @@ func GetImagesRepoManager(imagesRepo, imagesRepoMode string) (*ImagesRepoManager
 			return tag
 		}
 	default:
-		return nil, fmt.Errorf("bad images repo mode '%s': only %s and %s supported", imagesRepoMode, MultirepoImagesRepoMode, MonorepoImagesRepoMode)
+		return nil, fmt.Errorf("bad images repo mode '%s': only %s and %s supported, use GetImagesRepoManagerByTemplate for %s mode", imagesRepoMode, MultirepoImagesRepoMode, MonorepoImagesRepoMode, TemplateImagesRepoMode)
 	}
 
 	return newImagesRepoManager(
@@ -106,3 +141,174 @@ func GetImagesRepoManager(imagesRepo, imagesRepoMode string) (*ImagesRepoManager
 		imageRepoTagFunc,
 	), nil
 }
+
+type imagesRepoTemplate struct {
+	text string
+	tmpl *template.Template
+}
+
+func parseImagesRepoTemplate(text string) (*imagesRepoTemplate, error) {
+	tmpl, err := template.New("images-repo-template").Option("missingkey=error").Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("bad images repo template %q: %s", text, err)
+	}
+
+	return &imagesRepoTemplate{text: text, tmpl: tmpl}, nil
+}
+
+// render renders and validates the image repository, the empty path parts are omitted
+func (t *imagesRepoTemplate) render(data ImagesRepoTemplateData) (string, error) {
+	buf := bytes.NewBuffer(nil)
+	if err := t.tmpl.Execute(buf, data); err != nil {
+		return "", fmt.Errorf("bad images repo template %q: %s", t.text, err)
+	}
+
+	var parts []string
+	for _, part := range strings.Split(strings.TrimSpace(buf.String()), "/") {
+		if part != "" {
+			parts = append(parts, part)
+		}
+	}
+	repo := strings.Join(parts, "/")
+
+	if _, err := name.NewRepository(repo, name.WeakValidation); err != nil {
+		return "", fmt.Errorf("bad images repo template %q: rendered repository %q is not valid: %s", t.text, repo, err)
+	}
+
+	return repo, nil
+}
+
+func (t *imagesRepoTemplate) usesEnv(data ImagesRepoTemplateData) (bool, error) {
+	data.ImageName = "image"
+
+	data.Env = ""
+	repo, err := t.render(data)
+	if err != nil {
+		return false, err
+	}
+
+	data.Env = "env"
+	envRepo, err := t.render(data)
+	if err != nil {
+		return false, err
+	}
+
+	return repo != envRepo, nil
+}
+
+// GetImagesRepoManagerByTemplate creates manager, which stores each image in the separate repository rendered by the template.
+// The repositories of the nameless image and the specified images are rendered and validated in advance, other images are not allowed
+// and cause the error.
+// The empty path parts are omitted, so that the nameless image repository is rendered without the image name
+func GetImagesRepoManagerByTemplate(imagesRepo, imagesRepoTemplate string, data ImagesRepoTemplateData, imagesNames []string) (*ImagesRepoManager, error) {
+	t, err := parseImagesRepoTemplate(imagesRepoTemplate)
+	if err != nil {
+		return nil, err
+	}
+
+	data.Repo = strings.TrimRight(imagesRepo, "/")
+
+	if data.Env == "" {
+		if usesEnv, err := t.usesEnv(data); err != nil {
+			return nil, err
+		} else if usesEnv {
+			return nil, fmt.Errorf("images repo template %q requires environment: --env option or $WERF_ENV should be specified", imagesRepoTemplate)
+		}
+	}
+
+	// the image and image-b are used to check that each image is stored in the separate repository, the image is also used by IsMonorepo
+	reposByImageName := map[string]string{}
+	for _, imageName := range append([]string{"", "image", "image-b"}, imagesNames...) {
+		imageData := data
+		imageData.ImageName = imageName
+
+		repo, err := t.render(imageData)
+		if err != nil {
+			if imageName != "" {
+				return nil, fmt.Errorf("image %q: %s", imageName, err)
+			}
+			return nil, err
+		}
+
+		reposByImageName[imageName] = repo
+	}
+
+	if reposByImageName["image"] == reposByImageName["image-b"] {
+		return nil, fmt.Errorf("bad images repo template %q: {{.ImageName}} is required to store images in the separate repositories", imagesRepoTemplate)
+	}
+
+	imageRepoFunc := func(_, imageName string) (string, error) {
+		repo, ok := reposByImageName[imageName]
+		if !ok {
+			return "", fmt.Errorf("repository of image %q is not rendered by images repo template %q: image is not defined in werf.yaml", imageName, imagesRepoTemplate)
+		}
+
+		return repo, nil
+	}
+
+	return newImagesRepoManager(
+		imagesRepo,
+		func(_ string) string {
+			return reposByImageName[""]
+		},
+		imageRepoFunc,
+		func(_, tag string) string {
+			return tag
+		},
+	), nil
+}
+
+// GetImagesRepoManagerByTemplateForEnvironments creates manager for the cleanup commands: if the template uses the environment,
+// the images repositories of all specified environments are processed, otherwise the environments are ignored
+func GetImagesRepoManagerByTemplateForEnvironments(imagesRepo, imagesRepoTemplate string, data ImagesRepoTemplateData, environments, imagesNames []string) (*ImagesRepoManager, error) {
+	t, err := parseImagesRepoTemplate(imagesRepoTemplate)
+	if err != nil {
+		return nil, err
+	}
+
+	data.Repo = strings.TrimRight(imagesRepo, "/")
+	data.Env = ""
+
+	usesEnv, err := t.usesEnv(data)
+	if err != nil {
+		return nil, err
+	}
+
+	if !usesEnv {
+		return GetImagesRepoManagerByTemplate(imagesRepo, imagesRepoTemplate, data, imagesNames)
+	}
+
+	if len(environments) == 0 {
+		return nil, fmt.Errorf("images repo template %q uses environment: all environments of the project should be specified by --images-repo-template-envs option or $WERF_IMAGES_REPO_TEMPLATE_ENVS to process the images repositories of all environments", imagesRepoTemplate)
+	}
+
+	var envManagers []*ImagesRepoManager
+	for _, environment := range environments {
+		envData := data
+		envData.Env = environment
+
+		envManager, err := GetImagesRepoManagerByTemplate(imagesRepo, imagesRepoTemplate, envData, imagesNames)
+		if err != nil {
+			return nil, fmt.Errorf("environment %q: %s", environment, err)
+		}
+
+		envManagers = append(envManagers, envManager)
+	}
+
+	m := *envManagers[0]
+	m.imageReposFunc = func(imageName string) ([]string, error) {
+		var repos []string
+		for _, envManager := range envManagers {
+			repo, err := envManager.ImageRepo(imageName)
+			if err != nil {
+				return nil, err
+			}
+
+			repos = append(repos, repo)
+		}
+
+		return util.UniqStrings(repos), nil
+	}
+
+	return &m, nil
+}
@@ -2,6 +2,7 @@ package common
 
 import (
 	"fmt"
+	"strings"
 	"testing"
 )
 
@@ -36,22 +37,34 @@ func TestGetImagesRepoManager(t *testing.T) {
 				t.Error(err)
 			}
 
-			namelessImageRepo := m.ImageRepo("")
+			namelessImageRepo, err := m.ImageRepo("")
+			if err != nil {
+				t.Fatal(err)
+			}
 			if expected.namelessImageRepo != namelessImageRepo {
 				t.Errorf("\n[EXPECTED]: %q\n[GOT]: %q", expected.namelessImageRepo, namelessImageRepo)
 			}
 
-			namelessImageRepoWithTag := m.ImageRepoWithTag("", "tag")
+			namelessImageRepoWithTag, err := m.ImageRepoWithTag("", "tag")
+			if err != nil {
+				t.Fatal(err)
+			}
 			if expected.namelessImageRepoWithTag != namelessImageRepoWithTag {
 				t.Errorf("\n[EXPECTED]: %q\n[GOT]: %q", expected.namelessImageRepoWithTag, namelessImageRepoWithTag)
 			}
 
-			imageRepo := m.ImageRepo("image")
+			imageRepo, err := m.ImageRepo("image")
+			if err != nil {
+				t.Fatal(err)
+			}
 			if expected.imageRepo != imageRepo {
 				t.Errorf("\n[EXPECTED]: %q\n[GOT]: %q", expected.imageRepo, imageRepo)
 			}
 
-			imageRepoWithTag := m.ImageRepoWithTag("image", "tag")
+			imageRepoWithTag, err := m.ImageRepoWithTag("image", "tag")
+			if err != nil {
+				t.Fatal(err)
+			}
 			if expected.imageRepoWithTag != imageRepoWithTag {
 				t.Errorf("\n[EXPECTED]: %q\n[GOT]: %q", expected.imageRepoWithTag, imageRepoWithTag)
 			}
@@ -63,3 +76,103 @@ func TestGetImagesRepoManager(t *testing.T) {
 		})
 	}
 }
+
+func TestGetImagesRepoManagerByTemplate(t *testing.T) {
+	m, err := GetImagesRepoManagerByTemplate("registry.example.com/group", "{{.Repo}}/{{.ImageName}}/{{.Env}}", ImagesRepoTemplateData{Env: "production", Project: "project"}, []string{"image"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	mustRepo := func(repo string, err error) string {
+		if err != nil {
+			t.Fatal(err)
+		}
+		return repo
+	}
+
+	for _, tt := range []struct {
+		got, expected string
+	}{
+		{m.ImagesRepo(), "registry.example.com/group"},
+		{mustRepo(m.ImageRepo("")), "registry.example.com/group/production"},
+		{mustRepo(m.ImageRepoWithTag("", "tag")), "registry.example.com/group/production:tag"},
+		{mustRepo(m.ImageRepo("image")), "registry.example.com/group/image/production"},
+		{mustRepo(m.ImageRepoWithTag("image", "tag")), "registry.example.com/group/image/production:tag"},
+	} {
+		if tt.expected != tt.got {
+			t.Errorf("\n[EXPECTED]: %q\n[GOT]: %q", tt.expected, tt.got)
+		}
+	}
+
+	if m.IsMonorepo() {
+		t.Errorf("template images repo mode should not be treated as monorepo")
+	}
+
+	if _, err := m.ImageRepo("unknown"); err == nil {
+		t.Errorf("repository of the image, which is not rendered in advance, should not be returned")
+	}
+
+	for _, tt := range []struct {
+		template string
+		data     ImagesRepoTemplateData
+	}{
+		{template: "{{.Repo}}/{{.ImageName", data: ImagesRepoTemplateData{}},
+		{template: "{{.Repo}}/{{.Env}}", data: ImagesRepoTemplateData{Env: "production"}},
+		{template: "{{.Repo}}/{{.ImageName}}/{{.Env}}", data: ImagesRepoTemplateData{}},
+		{template: "{{.Repo}}/{{.ImageName}}/{{.Unknown}}", data: ImagesRepoTemplateData{}},
+		{template: "{{.Repo}}/{{.ImageName}}/UPPER", data: ImagesRepoTemplateData{}},
+	} {
+		if _, err := GetImagesRepoManagerByTemplate("repo", tt.template, tt.data, nil); err == nil {
+			t.Errorf("template %q with %+v should not be accepted", tt.template, tt.data)
+		}
+	}
+}
+
+func TestGetImagesRepoManagerByTemplate_ImagesNames(t *testing.T) {
+	template := `{{.Repo}}/{{if eq .ImageName "broken"}}{{.Unknown}}{{else}}{{.ImageName}}{{end}}`
+
+	m, err := GetImagesRepoManagerByTemplate("repo", template, ImagesRepoTemplateData{}, []string{"backend", "frontend"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if repos, _ := m.ImageRepos("backend"); len(repos) != 1 || repos[0] != "repo/backend" {
+		t.Errorf("\n[EXPECTED]: %q\n[GOT]: %q", []string{"repo/backend"}, repos)
+	}
+
+	for _, imagesNames := range [][]string{{"backend", "broken"}, {"backend", "Frontend"}} {
+		if _, err := GetImagesRepoManagerByTemplate("repo", template, ImagesRepoTemplateData{}, imagesNames); err == nil {
+			t.Errorf("template %q with images %v should not be accepted", template, imagesNames)
+		}
+	}
+}
+
+func TestGetImagesRepoManagerByTemplateForEnvironments(t *testing.T) {
+	m, err := GetImagesRepoManagerByTemplateForEnvironments("repo", "{{.Repo}}/{{.ImageName}}/{{.Env}}", ImagesRepoTemplateData{}, []string{"production", "staging"}, []string{"backend"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expectedRepos := []string{"repo/backend/production", "repo/backend/staging"}
+	if repos, _ := m.ImageRepos("backend"); strings.Join(repos, ",") != strings.Join(expectedRepos, ",") {
+		t.Errorf("\n[EXPECTED]: %q\n[GOT]: %q", expectedRepos, repos)
+	}
+
+	expectedRepos = []string{"repo/production", "repo/staging"}
+	if repos, _ := m.ImageRepos(""); strings.Join(repos, ",") != strings.Join(expectedRepos, ",") {
+		t.Errorf("\n[EXPECTED]: %q\n[GOT]: %q", expectedRepos, repos)
+	}
+
+	if _, err := GetImagesRepoManagerByTemplateForEnvironments("repo", "{{.Repo}}/{{.ImageName}}/{{.Env}}", ImagesRepoTemplateData{Env: "production"}, nil, []string{"backend"}); err == nil {
+		t.Errorf("all environments should be required for the template using environment")
+	}
+
+	m, err = GetImagesRepoManagerByTemplateForEnvironments("repo", "{{.Repo}}/{{.ImageName}}", ImagesRepoTemplateData{}, nil, []string{"backend"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if repos, _ := m.ImageRepos("backend"); len(repos) != 1 || repos[0] != "repo/backend" {
+		t.Errorf("\n[EXPECTED]: %q\n[GOT]: %q", []string{"repo/backend"}, repos)
+	}
+}
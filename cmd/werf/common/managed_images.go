@@ -3,7 +3,9 @@ package common
 import (
 	"fmt"
 	"sort"
+	"time"
 
+	cleanup "github.com/flant/werf/pkg/cleaning"
 	"github.com/flant/werf/pkg/config"
 	"github.com/flant/werf/pkg/storage"
 
@@ -23,7 +25,9 @@ func GetManagedImagesNames(projectName string, stagesStorage storage.StagesStora
 	if managedImages, err := stagesStorage.GetManagedImages(projectName); err != nil {
 		return nil, fmt.Errorf("unable to get managed images for project %q: %s", projectName, err)
 	} else {
-		imagesNames = append(imagesNames, managedImages...)
+		for _, managedImage := range managedImages {
+			imagesNames = append(imagesNames, managedImage.Name)
+		}
 	}
 	for _, image := range werfConfig.StapelImages {
 		imagesNames = append(imagesNames, image.Name)
@@ -36,3 +40,45 @@ func GetManagedImagesNames(projectName string, stagesStorage storage.StagesStora
 
 	return uniqImagesNames, nil
 }
+
+// GetManagedImagesCleanupPolicies returns the cleanup policies of the managed images with the cleanup settings, the settings override the specified policies
+func GetManagedImagesCleanupPolicies(projectName string, stagesStorage storage.StagesStorage, policies cleanup.ImagesCleanupPolicies) (map[string]cleanup.ImagesCleanupPolicies, error) {
+	managedImages, err := stagesStorage.GetManagedImages(projectName)
+	if err != nil {
+		return nil, fmt.Errorf("unable to get managed images for project %q: %s", projectName, err)
+	}
+
+	res := map[string]cleanup.ImagesCleanupPolicies{}
+	for _, managedImage := range managedImages {
+		if managedImage.Cleanup == (storage.ManagedImageCleanupSettings{}) {
+			continue
+		}
+
+		res[managedImage.Name] = ApplyManagedImageCleanupSettings(policies, managedImage.Cleanup)
+	}
+
+	return res, nil
+}
+
+func ApplyManagedImageCleanupSettings(policies cleanup.ImagesCleanupPolicies, settings storage.ManagedImageCleanupSettings) cleanup.ImagesCleanupPolicies {
+	policies.KeepForever = settings.KeepForever
+
+	if settings.GitTagStrategyLimit != nil {
+		policies.GitTagStrategyHasLimit = *settings.GitTagStrategyLimit >= 0
+		policies.GitTagStrategyLimit = *settings.GitTagStrategyLimit
+	}
+	if settings.GitTagStrategyExpiryDays != nil {
+		policies.GitTagStrategyHasExpiryPeriod = *settings.GitTagStrategyExpiryDays >= 0
+		policies.GitTagStrategyExpiryPeriod = time.Hour * 24 * time.Duration(*settings.GitTagStrategyExpiryDays)
+	}
+	if settings.GitCommitStrategyLimit != nil {
+		policies.GitCommitStrategyHasLimit = *settings.GitCommitStrategyLimit >= 0
+		policies.GitCommitStrategyLimit = *settings.GitCommitStrategyLimit
+	}
+	if settings.GitCommitStrategyExpiryDays != nil {
+		policies.GitCommitStrategyHasExpiryPeriod = *settings.GitCommitStrategyExpiryDays >= 0
+		policies.GitCommitStrategyExpiryPeriod = time.Hour * 24 * time.Duration(*settings.GitCommitStrategyExpiryDays)
+	}
+
+	return policies
+}
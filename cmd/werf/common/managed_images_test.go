@@ -0,0 +1,76 @@
+package common
+
+import (
+	"testing"
+	"time"
+
+	cleanup "github.com/flant/werf/pkg/cleaning"
+	"github.com/flant/werf/pkg/storage"
+)
+
+func TestApplyManagedImageCleanupSettings(t *testing.T) {
+	int64Ptr := func(v int64) *int64 { return &v }
+
+	policies := cleanup.ImagesCleanupPolicies{
+		GitTagStrategyHasLimit:           true,
+		GitTagStrategyLimit:              10,
+		GitTagStrategyHasExpiryPeriod:    true,
+		GitTagStrategyExpiryPeriod:       time.Hour * 24 * 30,
+		GitCommitStrategyHasLimit:        true,
+		GitCommitStrategyLimit:           50,
+		GitCommitStrategyHasExpiryPeriod: true,
+		GitCommitStrategyExpiryPeriod:    time.Hour * 24 * 30,
+	}
+
+	for _, tt := range []struct {
+		name     string
+		settings storage.ManagedImageCleanupSettings
+		expected cleanup.ImagesCleanupPolicies
+	}{
+		{
+			name:     "empty settings keep policies",
+			settings: storage.ManagedImageCleanupSettings{},
+			expected: policies,
+		},
+		{
+			name:     "settings override only specified policies",
+			settings: storage.ManagedImageCleanupSettings{GitTagStrategyLimit: int64Ptr(5), GitCommitStrategyExpiryDays: int64Ptr(7)},
+			expected: func() cleanup.ImagesCleanupPolicies {
+				p := policies
+				p.GitTagStrategyLimit = 5
+				p.GitCommitStrategyExpiryPeriod = time.Hour * 24 * 7
+				return p
+			}(),
+		},
+		{
+			name: "-1 disables limits",
+			settings: storage.ManagedImageCleanupSettings{
+				GitTagStrategyLimit:         int64Ptr(-1),
+				GitTagStrategyExpiryDays:    int64Ptr(-1),
+				GitCommitStrategyLimit:      int64Ptr(-1),
+				GitCommitStrategyExpiryDays: int64Ptr(-1),
+			},
+			expected: cleanup.ImagesCleanupPolicies{
+				GitTagStrategyLimit:           -1,
+				GitTagStrategyExpiryPeriod:    -time.Hour * 24,
+				GitCommitStrategyLimit:        -1,
+				GitCommitStrategyExpiryPeriod: -time.Hour * 24,
+			},
+		},
+		{
+			name:     "keep forever",
+			settings: storage.ManagedImageCleanupSettings{KeepForever: true},
+			expected: func() cleanup.ImagesCleanupPolicies {
+				p := policies
+				p.KeepForever = true
+				return p
+			}(),
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ApplyManagedImageCleanupSettings(policies, tt.settings); got != tt.expected {
+				t.Errorf("\n[EXPECTED]: %+v\n[GOT]: %+v", tt.expected, got)
+			}
+		})
+	}
+}
@@ -27,6 +27,9 @@ func GetDeployTag(cmdData *CmdData, opts TagOptionsGetterOptions) (string, tag_s
 	if *cmdData.TagGitCommit != "" {
 		optionsCount++
 	}
+	if *cmdData.TagSemver != "" {
+		optionsCount++
+	}
 	if *cmdData.TagByStagesSignature {
 		optionsCount++
 	}
@@ -48,6 +51,8 @@ func GetDeployTag(cmdData *CmdData, opts TagOptionsGetterOptions) (string, tag_s
 		return tagOpts.TagsByGitBranch[0], tag_strategy.GitBranch, nil
 	} else if len(tagOpts.TagsByGitTag) > 0 {
 		return tagOpts.TagsByGitTag[0], tag_strategy.GitTag, nil
+	} else if len(tagOpts.TagsBySemver) > 0 {
+		return tagOpts.TagsBySemver[0], tag_strategy.Semver, nil
 	} else if len(tagOpts.TagsByGitCommit) > 0 {
 		return tagOpts.TagsByGitCommit[0], tag_strategy.GitCommit, nil
 	}
@@ -104,13 +109,27 @@ func GetTagOptions(cmdData *CmdData, opts TagOptionsGetterOptions) (build.TagOpt
 		emptyTags = false
 	}
 
+	if tag := *cmdData.TagSemver; tag != "" {
+		err := slug.ValidateDockerTag(tag)
+		if err != nil {
+			return build.TagOptions{}, fmt.Errorf("bad --tag-semver parameter '%s' specified: %s", tag, err)
+		}
+
+		if _, err := tag_strategy.ParseSemverRelease(tag); err != nil {
+			return build.TagOptions{}, fmt.Errorf("bad --tag-semver parameter '%s' specified: %s", tag, err)
+		}
+
+		res.TagsBySemver = append(res.TagsBySemver, tag)
+		emptyTags = false
+	}
+
 	if *cmdData.TagByStagesSignature {
 		res.TagByStagesSignature = true
 		emptyTags = false
 	}
 
 	if emptyTags && !opts.Optional {
-		return build.TagOptions{}, fmt.Errorf("tag should be specified with --tag-by-stages-signature, --tag-custom, --tag-git-tag, --tag-git-branch or --tag-git-commit options")
+		return build.TagOptions{}, fmt.Errorf("tag should be specified with --tag-by-stages-signature, --tag-custom, --tag-git-tag, --tag-git-branch, --tag-git-commit or --tag-semver options")
 	}
 
 	return res, nil
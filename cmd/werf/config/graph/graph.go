@@ -0,0 +1,243 @@
+package graph
+
+import (
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/flant/logboek"
+	"github.com/flant/shluz"
+
+	"github.com/flant/werf/cmd/werf/common"
+	"github.com/flant/werf/pkg/config"
+	"github.com/flant/werf/pkg/tmp_manager"
+	"github.com/flant/werf/pkg/werf"
+)
+
+var cmdData struct {
+	Format string
+}
+
+var commonCmdData common.CmdData
+
+func NewCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:                   "graph [IMAGE_NAME...]",
+		DisableFlagsInUseLine: true,
+		Short:                 "Print dependency graph of images and artifacts defined in werf.yaml",
+		Long: `Print dependency graph of images and artifacts defined in werf.yaml.
+
+Edges are directed from the dependent image to its dependency and labeled by relation type: fromImage, fromImageArtifact or import. Images, which share the same build_dir mount, are linked by undirected mount edge.
+
+Images, which are built when IMAGE_NAME arguments are specified (all images by default), are marked as selected with the build order.
+
+Infinite loops between images are printed with explanation and command exits with error`,
+		Example: `  # Render graph as png with graphviz
+  $ werf config graph | dot -Tpng > graph.png
+
+  # Print images, which are built with image backend
+  $ werf config graph --format json backend | jq -r '.nodes[] | select(.selected) | .name'`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := common.ProcessLogOptions(&commonCmdData); err != nil {
+				common.PrintHelp(cmd)
+				return err
+			}
+
+			return run(args)
+		},
+	}
+
+	common.SetupDir(&commonCmdData, cmd)
+	common.SetupTmpDir(&commonCmdData, cmd)
+	common.SetupHomeDir(&commonCmdData, cmd)
+
+	common.SetupLogOptions(&commonCmdData, cmd)
+
+	cmd.Flags().StringVarP(&cmdData.Format, "format", "", "dot", "Output format: dot, json or mermaid")
+
+	return cmd
+}
+
+func run(imagesToProcess []string) error {
+	var render func(g *config.ImagesGraph) (string, error)
+	switch cmdData.Format {
+	case "dot":
+		render = renderDot
+	case "json":
+		render = renderJson
+	case "mermaid":
+		render = renderMermaid
+	default:
+		return fmt.Errorf("bad --format '%s': dot, json or mermaid expected", cmdData.Format)
+	}
+
+	logboek.MuteOut()
+
+	if err := werf.Init(*commonCmdData.TmpDir, *commonCmdData.HomeDir); err != nil {
+		return fmt.Errorf("initialization error: %s", err)
+	}
+
+	if err := shluz.Init(filepath.Join(werf.GetServiceDir(), "locks")); err != nil {
+		return err
+	}
+
+	tmp_manager.AutoGCEnabled = false
+
+	projectDir, err := common.GetProjectDir(&commonCmdData)
+	if err != nil {
+		return fmt.Errorf("getting project dir failed: %s", err)
+	}
+
+	werfConfigPath, err := common.GetWerfConfigPath(projectDir, true)
+	if err != nil {
+		return err
+	}
+
+	g, err := config.GetImagesGraph(werfConfigPath, imagesToProcess)
+	if err != nil {
+		return err
+	}
+
+	output, err := render(g)
+	if err != nil {
+		return err
+	}
+
+	fmt.Print(output)
+
+	if len(g.Cycles) != 0 {
+		for _, cycle := range g.Cycles {
+			logboek.LogErrorF("Infinite loop detected: %s\n", cycle.String())
+			logboek.LogErrorF("  %s\n", cycle.Explanation())
+		}
+
+		return fmt.Errorf("%d infinite loop(s) detected between images", len(g.Cycles))
+	}
+
+	return nil
+}
+
+func renderJson(g *config.ImagesGraph) (string, error) {
+	data, err := json.MarshalIndent(g, "", "  ")
+	if err != nil {
+		return "", err
+	}
+
+	return string(data) + "\n", nil
+}
+
+func renderDot(g *config.ImagesGraph) (string, error) {
+	var lines []string
+	lines = append(lines, "digraph werf {")
+
+	for _, node := range g.Nodes {
+		attrs := []string{fmt.Sprintf("label=%q", nodeLabel(node))}
+
+		switch node.Type {
+		case "artifact":
+			attrs = append(attrs, "shape=ellipse")
+		case "dockerfileImage":
+			attrs = append(attrs, "shape=note")
+		default:
+			attrs = append(attrs, "shape=box")
+		}
+
+		if node.Selected {
+			attrs = append(attrs, "style=bold")
+		} else {
+			attrs = append(attrs, "style=dashed")
+		}
+
+		lines = append(lines, fmt.Sprintf("  %q [%s];", config.ImagesGraphNodeName(node.Name), strings.Join(attrs, ", ")))
+	}
+
+	for _, edge := range g.Edges {
+		attrs := []string{fmt.Sprintf("label=%q", edgeLabel(edge))}
+
+		if edge.Type == config.ImagesGraphEdgeMount {
+			attrs = append(attrs, "style=dashed", "dir=none")
+		}
+
+		if g.IsCycleEdge(edge) {
+			attrs = append(attrs, "color=red")
+		}
+
+		lines = append(lines, fmt.Sprintf("  %q -> %q [%s];", config.ImagesGraphNodeName(edge.From), config.ImagesGraphNodeName(edge.To), strings.Join(attrs, ", ")))
+	}
+
+	lines = append(lines, "}")
+
+	return strings.Join(lines, "\n") + "\n", nil
+}
+
+func renderMermaid(g *config.ImagesGraph) (string, error) {
+	nodeIds := map[string]string{}
+
+	var lines []string
+	lines = append(lines, "graph TD")
+
+	var selectedIds []string
+	for ind, node := range g.Nodes {
+		id := fmt.Sprintf("n%d", ind)
+		nodeIds[node.Name] = id
+
+		label := strings.Replace(nodeLabel(node), `"`, "#quot;", -1)
+		switch node.Type {
+		case "artifact":
+			lines = append(lines, fmt.Sprintf(`  %s(["%s"])`, id, label))
+		default:
+			lines = append(lines, fmt.Sprintf(`  %s["%s"]`, id, label))
+		}
+
+		if node.Selected {
+			selectedIds = append(selectedIds, id)
+		}
+	}
+
+	var cycleEdgeIndexes []string
+	for ind, edge := range g.Edges {
+		label := strings.Replace(edgeLabel(edge), `"`, "#quot;", -1)
+
+		arrow := "-->"
+		if edge.Type == config.ImagesGraphEdgeMount {
+			arrow = "-.-"
+		}
+
+		lines = append(lines, fmt.Sprintf(`  %s %s|"%s"| %s`, nodeIds[edge.From], arrow, label, nodeIds[edge.To]))
+
+		if g.IsCycleEdge(edge) {
+			cycleEdgeIndexes = append(cycleEdgeIndexes, fmt.Sprintf("%d", ind))
+		}
+	}
+
+	if len(selectedIds) != 0 {
+		lines = append(lines, "  classDef selected stroke-width:3px;")
+		lines = append(lines, fmt.Sprintf("  class %s selected;", strings.Join(selectedIds, ",")))
+	}
+
+	if len(cycleEdgeIndexes) != 0 {
+		lines = append(lines, fmt.Sprintf("  linkStyle %s stroke:red;", strings.Join(cycleEdgeIndexes, ",")))
+	}
+
+	return strings.Join(lines, "\n") + "\n", nil
+}
+
+func nodeLabel(node *config.ImagesGraphNode) string {
+	label := fmt.Sprintf("%s (%s)", config.ImagesGraphNodeName(node.Name), node.Type)
+	if node.BuildOrder != 0 {
+		label = fmt.Sprintf("%d. %s", node.BuildOrder, label)
+	}
+
+	return label
+}
+
+func edgeLabel(edge *config.ImagesGraphEdge) string {
+	if edge.Detail != "" {
+		return fmt.Sprintf("%s: %s", edge.Type, edge.Detail)
+	}
+
+	return edge.Type
+}
@@ -0,0 +1,84 @@
+package lint
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"github.com/spf13/cobra"
+
+	"github.com/flant/logboek"
+	"github.com/flant/shluz"
+
+	"github.com/flant/werf/cmd/werf/common"
+	"github.com/flant/werf/pkg/config"
+	"github.com/flant/werf/pkg/tmp_manager"
+	"github.com/flant/werf/pkg/werf"
+)
+
+var commonCmdData common.CmdData
+
+func NewCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:                   "lint",
+		DisableFlagsInUseLine: true,
+		Short:                 "Validate werf.yaml and report all errors",
+		Long: `Validate each config section of rendered werf.yaml against config JSON Schema (see werf config schema) and report all found errors with line numbers of rendered config.
+
+Directives of config sections that conform to the schema are validated by the config parser. Relations between images (names conflicts, imports, fromImage) are validated only if there are no errors in config sections`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := common.ProcessLogOptions(&commonCmdData); err != nil {
+				common.PrintHelp(cmd)
+				return err
+			}
+
+			return run()
+		},
+	}
+
+	common.SetupDir(&commonCmdData, cmd)
+	common.SetupTmpDir(&commonCmdData, cmd)
+	common.SetupHomeDir(&commonCmdData, cmd)
+
+	common.SetupLogOptions(&commonCmdData, cmd)
+
+	return cmd
+}
+
+func run() error {
+	if err := werf.Init(*commonCmdData.TmpDir, *commonCmdData.HomeDir); err != nil {
+		return fmt.Errorf("initialization error: %s", err)
+	}
+
+	if err := shluz.Init(filepath.Join(werf.GetServiceDir(), "locks")); err != nil {
+		return err
+	}
+
+	tmp_manager.AutoGCEnabled = false
+
+	projectDir, err := common.GetProjectDir(&commonCmdData)
+	if err != nil {
+		return fmt.Errorf("getting project dir failed: %s", err)
+	}
+
+	werfConfigPath, err := common.GetWerfConfigPath(projectDir, true)
+	if err != nil {
+		return err
+	}
+
+	lintErrors, err := config.LintWerfConfig(werfConfigPath)
+	if err != nil {
+		return err
+	}
+
+	for _, lintError := range lintErrors {
+		logboek.LogErrorLn(lintError.Error())
+	}
+
+	if len(lintErrors) != 0 {
+		return fmt.Errorf("%d error(s) found in %s", len(lintErrors), werfConfigPath)
+	}
+
+	logboek.LogLn("werf.yaml is valid")
+
+	return nil
+}
@@ -2,9 +2,13 @@ package render
 
 import (
 	"fmt"
+	"path/filepath"
 
 	"github.com/spf13/cobra"
 
+	"github.com/flant/logboek"
+	"github.com/flant/shluz"
+
 	"github.com/flant/werf/cmd/werf/common"
 	"github.com/flant/werf/pkg/config"
 	"github.com/flant/werf/pkg/tmp_manager"
@@ -24,10 +28,16 @@ func NewCmd() *cobra.Command {
 				return err
 			}
 
+			logboek.MuteOut()
+
 			if err := werf.Init(*commonCmdData.TmpDir, *commonCmdData.HomeDir); err != nil {
 				return fmt.Errorf("initialization error: %s", err)
 			}
 
+			if err := shluz.Init(filepath.Join(werf.GetServiceDir(), "locks")); err != nil {
+				return err
+			}
+
 			tmp_manager.AutoGCEnabled = false
 
 			projectDir, err := common.GetProjectDir(&commonCmdData)
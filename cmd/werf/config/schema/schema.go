@@ -0,0 +1,32 @@
+package schema
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/flant/werf/pkg/config"
+)
+
+func NewCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:                   "schema",
+		DisableFlagsInUseLine: true,
+		Short:                 "Print JSON Schema of werf.yaml config section",
+		Long: `Print JSON Schema of werf.yaml config section (part of YAML stream separated by three hyphens).
+
+The schema can be used by editors and linters to validate and autocomplete werf.yaml`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			schema, err := config.GetWerfConfigJsonSchema()
+			if err != nil {
+				return err
+			}
+
+			fmt.Println(string(schema))
+
+			return nil
+		},
+	}
+
+	return cmd
+}
@@ -54,7 +54,7 @@ Read more info about Helm chart structure, Helm Release name, Kubernetes Namespa
   $ werf deploy --stages-storage :local --release myrelease --namespace myns --images-repo registry.mydomain.com/myproject --tag-custom myversion`,
 		DisableFlagsInUseLine: true,
 		Annotations: map[string]string{
-			common.CmdEnvAnno: common.EnvsDescription(common.WerfSecretKey),
+			common.CmdEnvAnno: common.EnvsDescription(common.WerfSecretKey, common.WerfSecretAgeIdentity, common.WerfSecretPgpKey, common.WerfSecretPgpPassphrase),
 		},
 		RunE: func(cmd *cobra.Command, args []string) error {
 			if err := common.ProcessLogOptions(&commonCmdData); err != nil {
@@ -64,7 +64,9 @@ Read more info about Helm chart structure, Helm Release name, Kubernetes Namespa
 			common.LogVersion()
 
 			return common.LogRunningTime(func() error {
-				return runDeploy()
+				return common.WithTracing(&commonCmdData, cmd, func() error {
+					return runDeploy()
+				})
 			})
 		},
 	}
@@ -96,6 +98,8 @@ Read more info about Helm chart structure, Helm Release name, Kubernetes Namespa
 	common.SetupDockerConfig(&commonCmdData, cmd, "Command needs granted permissions to read and pull images from the specified stages storage and images repo")
 	common.SetupInsecureRegistry(&commonCmdData, cmd)
 	common.SetupSkipTlsVerifyRegistry(&commonCmdData, cmd)
+	common.SetupVerifyImagesKey(&commonCmdData, cmd)
+	common.SetupOtel(&commonCmdData, cmd)
 
 	common.SetupLogOptions(&commonCmdData, cmd)
 	common.SetupLogProjectDir(&commonCmdData, cmd)
@@ -136,6 +140,11 @@ func runDeploy() error {
 		return err
 	}
 
+	imagesVerifier, err := common.GetImagesVerifier(&commonCmdData)
+	if err != nil {
+		return err
+	}
+
 	deployInitOptions := deploy.InitOptions{
 		HelmInitOptions: helm.InitOptions{
 			KubeConfig:                  *commonCmdData.KubeConfig,
@@ -208,12 +217,7 @@ func runDeploy() error {
 			return err
 		}
 
-		imagesRepoMode, err := common.GetImagesRepoMode(&commonCmdData)
-		if err != nil {
-			return err
-		}
-
-		imagesRepoManager, err = common.GetImagesRepoManager(imagesRepo, imagesRepoMode)
+		imagesRepoManager, err = common.GetImagesRepoManagerByCmdData(werfConfig.Meta.Project, imagesRepo, common.GetWerfConfigImagesNames(werfConfig), &commonCmdData)
 		if err != nil {
 			return err
 		}
@@ -280,5 +284,6 @@ func runDeploy() error {
 		UserExtraLabels:      userExtraLabels,
 		IgnoreSecretKey:      *commonCmdData.IgnoreSecretKey,
 		ThreeWayMergeMode:    threeWayMergeMode,
+		ImagesVerifier:       imagesVerifier,
 	})
 }
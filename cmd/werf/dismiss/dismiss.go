@@ -14,6 +14,7 @@ import (
 	"github.com/flant/werf/pkg/deploy"
 	"github.com/flant/werf/pkg/deploy/helm"
 	"github.com/flant/werf/pkg/docker"
+	"github.com/flant/werf/pkg/logging"
 	"github.com/flant/werf/pkg/werf"
 )
 
@@ -147,7 +148,7 @@ func runDismiss() error {
 		return err
 	}
 
-	if err := logboek.Default.LogBlock("Deploy options", logboek.LevelLogBlockOptions{}, func() error {
+	if err := logging.LevelLogBlock(logboek.Default, "Deploy options", logboek.LevelLogBlockOptions{}, func() error {
 		logboek.LogF("Kubernetes namespace: %s\n", namespace)
 		logboek.LogF("Helm release storage namespace: %s\n", *commonCmdData.HelmReleaseStorageNamespace)
 		logboek.LogF("Helm release storage type: %s\n", helmReleaseStorageType)
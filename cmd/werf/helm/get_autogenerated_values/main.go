@@ -32,7 +32,7 @@ func NewCmd() *cobra.Command {
 These values includes project name, docker images ids and other`),
 		DisableFlagsInUseLine: true,
 		Annotations: map[string]string{
-			common.CmdEnvAnno: common.EnvsDescription(common.WerfSecretKey),
+			common.CmdEnvAnno: common.EnvsDescription(common.WerfSecretKey, common.WerfSecretAgeIdentity, common.WerfSecretPgpKey, common.WerfSecretPgpPassphrase),
 		},
 		RunE: func(cmd *cobra.Command, args []string) error {
 			if err := common.ProcessLogOptions(&commonCmdData); err != nil {
@@ -115,12 +115,7 @@ func runGetServiceValues() error {
 
 	imagesRepo = helm_common.GetImagesRepoOrStub(imagesRepo)
 
-	imagesRepoMode, err := common.GetImagesRepoMode(&commonCmdData)
-	if err != nil {
-		return err
-	}
-
-	imagesRepoManager, err := common.GetImagesRepoManager(imagesRepo, imagesRepoMode)
+	imagesRepoManager, err := common.GetImagesRepoManagerWithEnvironment(werfConfig.Meta.Project, imagesRepo, helm_common.GetEnvironmentOrStub(*commonCmdData.Environment), common.GetWerfConfigImagesNames(werfConfig), &commonCmdData)
 	if err != nil {
 		return err
 	}
@@ -27,7 +27,7 @@ func NewCmd() *cobra.Command {
 		Short:                 "Run lint procedure for the werf chart",
 		DisableFlagsInUseLine: true,
 		Annotations: map[string]string{
-			common.CmdEnvAnno: common.EnvsDescription(common.WerfSecretKey),
+			common.CmdEnvAnno: common.EnvsDescription(common.WerfSecretKey, common.WerfSecretAgeIdentity, common.WerfSecretPgpKey, common.WerfSecretPgpPassphrase),
 		},
 		RunE: func(cmd *cobra.Command, args []string) error {
 			if err := common.ProcessLogOptions(&commonCmdData); err != nil {
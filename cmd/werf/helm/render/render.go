@@ -33,7 +33,7 @@ func NewCmd() *cobra.Command {
 		Short:                 "Render werf chart templates to stdout",
 		DisableFlagsInUseLine: true,
 		Annotations: map[string]string{
-			common.CmdEnvAnno: common.EnvsDescription(common.WerfSecretKey),
+			common.CmdEnvAnno: common.EnvsDescription(common.WerfSecretKey, common.WerfSecretAgeIdentity, common.WerfSecretPgpKey, common.WerfSecretPgpPassphrase),
 		},
 		RunE: func(cmd *cobra.Command, args []string) error {
 			if err := common.ProcessLogOptions(&commonCmdData); err != nil {
@@ -118,12 +118,7 @@ func runRender(outputFilePath string) error {
 
 	imagesRepo := helm_common.GetImagesRepoOrStub(optionalImagesRepo)
 
-	imagesRepoMode, err := common.GetImagesRepoMode(&commonCmdData)
-	if err != nil {
-		return err
-	}
-
-	imagesRepoManager, err := common.GetImagesRepoManager(imagesRepo, imagesRepoMode)
+	imagesRepoManager, err := common.GetImagesRepoManagerWithEnvironment(werfConfig.Meta.Project, imagesRepo, helm_common.GetEnvironmentOrStub(*commonCmdData.Environment), common.GetWerfConfigImagesNames(werfConfig), &commonCmdData)
 	if err != nil {
 		return err
 	}
@@ -28,6 +28,11 @@ func SecretEdit(m secret.Manager, filePath string, values bool) error {
 		return err
 	}
 
+	metadata, err := secret.ReadMetadata(encodedData)
+	if err != nil {
+		return err
+	}
+
 	tmpFilePath := filepath.Join(werf.GetTmpDir(), fmt.Sprintf("werf-edit-secret-%s.yaml", uuid.NewV4().String()))
 	defer os.RemoveAll(tmpFilePath)
 
@@ -63,7 +68,7 @@ func SecretEdit(m secret.Manager, filePath string, values bool) error {
 				return err
 			}
 		} else {
-			newEncodedData, err = m.Encrypt(newData)
+			newEncodedData, err = m.EncryptFileData(newData)
 			if err != nil {
 				return err
 			}
@@ -71,8 +76,16 @@ func SecretEdit(m secret.Manager, filePath string, values bool) error {
 			newEncodedData = append(newEncodedData, []byte("\n")...)
 		}
 
-		if !bytes.Equal(data, newData) {
-			if values {
+		newMetadata, err := m.GetMetadata()
+		if err != nil {
+			return err
+		}
+
+		// re-encrypt all data if recipients changed
+		isMetadataChanged := !metadata.Equal(newMetadata)
+
+		if !bytes.Equal(data, newData) || isMetadataChanged {
+			if values && !isMetadataChanged {
 				newEncodedData, err = prepareResultValuesData(data, encodedData, newData, newEncodedData)
 				if err != nil {
 					return err
@@ -225,11 +238,32 @@ func prepareResultValuesData(data, encodedData, newData, newEncodedData []byte)
 		return nil, err
 	}
 
+	_, encodeDataConfig, err = secret.SplitYamlMetadata(encodeDataConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	var newEncodedMetadataConfig yaml.MapSlice
+	for _, item := range newEncodedDataConfig {
+		if item.Key == secret.MetadataYamlKey {
+			newEncodedMetadataConfig = append(newEncodedMetadataConfig, item)
+		}
+	}
+
+	_, newEncodedDataConfig, err = secret.SplitYamlMetadata(newEncodedDataConfig)
+	if err != nil {
+		return nil, err
+	}
+
 	resultEncodedDataConfig, err := mergeYamlEncodedData(dataConfig, encodeDataConfig, newDataConfig, newEncodedDataConfig)
 	if err != nil {
 		return nil, err
 	}
 
+	if len(newEncodedMetadataConfig) != 0 {
+		resultEncodedDataConfig = append(newEncodedMetadataConfig, resultEncodedDataConfig.(yaml.MapSlice)...)
+	}
+
 	resultEncodedData, err := yaml.Marshal(&resultEncodedDataConfig)
 	if err != nil {
 		return nil, err
@@ -59,7 +59,7 @@ func secretEncrypt(m secret.Manager, options *GenerateOptions) error {
 			return err
 		}
 	} else {
-		encodedData, err = m.Encrypt(data)
+		encodedData, err = m.EncryptFileData(data)
 		if err != nil {
 			return err
 		}
@@ -0,0 +1,307 @@
+package secret
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"reflect"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+
+	"github.com/flant/logboek"
+	"github.com/flant/werf/pkg/deploy/secret"
+)
+
+// SecretGitTextconv prints decrypted secret values file or secret file for git diff.
+// Data that cannot be decrypted is printed as is, so that git diff works without the encryption key.
+func SecretGitTextconv(m secret.Manager, filePath string) error {
+	encodedData, err := ReadFileData(filePath)
+	if err != nil {
+		return err
+	}
+
+	encodedData = bytes.TrimSpace(encodedData)
+
+	data, err := decryptGitData(m, encodedData, isSecretValuesData(encodedData))
+	if err != nil {
+		logboek.LogErrorF("WARNING: %s\n", err)
+		data = encodedData
+	}
+
+	if len(data) != 0 && !bytes.HasSuffix(data, []byte("\n")) {
+		data = append(data, []byte("\n")...)
+	}
+
+	_, err = os.Stdout.Write(data)
+
+	return err
+}
+
+// SecretGitMergeDriver merges the current (result is written in currentFilePath), ancestor and other versions of the secret values file or secret file.
+// Secret values are merged key by key: only changed values are re-encrypted, unchanged values keep their ciphertext.
+// Secret files are merged as a whole. On conflict the encrypted current and other versions are written with conflict markers and error is returned.
+func SecretGitMergeDriver(m secret.Manager, ancestorFilePath, currentFilePath, otherFilePath, path string) error {
+	var encodedDataList [][]byte
+	for _, filePath := range []string{ancestorFilePath, currentFilePath, otherFilePath} {
+		encodedData, err := ioutil.ReadFile(filePath)
+		if err != nil {
+			return err
+		}
+
+		encodedDataList = append(encodedDataList, bytes.TrimSpace(encodedData))
+	}
+	currentEncodedData, otherEncodedData := encodedDataList[1], encodedDataList[2]
+
+	values := isSecretValuesData(currentEncodedData) || isSecretValuesData(otherEncodedData)
+
+	var dataList [][]byte
+	for ind, encodedData := range encodedDataList {
+		data, err := decryptGitData(m, encodedData, values)
+		if err != nil {
+			return fmt.Errorf("unable to decrypt %s version of %s: %s", []string{"ancestor", "current", "other"}[ind], path, err)
+		}
+
+		dataList = append(dataList, data)
+	}
+	ancestorData, currentData, otherData := dataList[0], dataList[1], dataList[2]
+
+	if !values {
+		switch {
+		case bytes.Equal(currentData, otherData), bytes.Equal(ancestorData, otherData):
+			return nil
+		case bytes.Equal(ancestorData, currentData):
+			return SaveGeneratedData(currentFilePath, append(otherEncodedData, []byte("\n")...))
+		default:
+			if err := SaveGeneratedData(currentFilePath, gitConflictData(currentEncodedData, otherEncodedData)); err != nil {
+				return err
+			}
+
+			return fmt.Errorf("merge conflict in %s: secret file changed in both versions: resolve the conflict by choosing one of the versions", path)
+		}
+	}
+
+	var configList []yaml.MapSlice
+	for _, data := range dataList {
+		config, err := unmarshalYaml(data)
+		if err != nil {
+			return err
+		}
+
+		configList = append(configList, config)
+	}
+
+	current := secretValuesVersion{data: currentData, encodedData: currentEncodedData}
+	other := secretValuesVersion{data: otherData, encodedData: otherEncodedData}
+
+	var conflicts []string
+	currentResultConfig := mergeYamlData(configList[0], configList[1], configList[2], nil, &conflicts, false)
+	currentResult, err := encryptMergedValues(m, currentResultConfig, other, current)
+	if err != nil {
+		return err
+	}
+
+	if len(conflicts) == 0 {
+		return SaveGeneratedData(currentFilePath, append(currentResult.encodedData, []byte("\n")...))
+	}
+
+	// both results contain all non-conflicting changes and share ciphertext of the same values,
+	// so that conflict markers surround only the conflicting values
+	otherResultConfig := mergeYamlData(configList[0], configList[1], configList[2], nil, nil, true)
+	otherResult, err := encryptMergedValues(m, otherResultConfig, current, other, currentResult)
+	if err != nil {
+		return err
+	}
+
+	if err := SaveGeneratedData(currentFilePath, gitConflictData(currentResult.encodedData, otherResult.encodedData)); err != nil {
+		return err
+	}
+
+	return fmt.Errorf("merge conflict in %s: values changed in both versions: %s: resolve the conflict by choosing one of the versions", path, strings.Join(conflicts, ", "))
+}
+
+type secretValuesVersion struct {
+	data, encodedData []byte
+}
+
+// encryptMergedValues encrypts the merged values reusing encoded data of the version with the same values or ciphertext of the same values of the versions,
+// latter versions take precedence
+func encryptMergedValues(m secret.Manager, resultConfig interface{}, versions ...secretValuesVersion) (secretValuesVersion, error) {
+	resultData, err := yaml.Marshal(resultConfig)
+	if err != nil {
+		return secretValuesVersion{}, err
+	}
+
+	resultConfig, err = unmarshalYaml(resultData)
+	if err != nil {
+		return secretValuesVersion{}, err
+	}
+
+	for i := len(versions) - 1; i >= 0; i-- {
+		config, err := unmarshalYaml(versions[i].data)
+		if err != nil {
+			return secretValuesVersion{}, err
+		}
+
+		if reflect.DeepEqual(config, resultConfig) {
+			return versions[i], nil
+		}
+	}
+
+	resultEncodedData, err := m.EncryptYamlData(resultData)
+	if err != nil {
+		return secretValuesVersion{}, err
+	}
+
+	resultMetadata, err := m.GetMetadata()
+	if err != nil {
+		return secretValuesVersion{}, err
+	}
+
+	// ciphertext can only be reused if it is encrypted with the same recipients (and data key)
+	for _, version := range versions {
+		if len(version.encodedData) == 0 {
+			continue
+		}
+
+		metadata, err := secret.ReadMetadata(version.encodedData)
+		if err != nil {
+			return secretValuesVersion{}, err
+		}
+
+		if !metadata.Equal(resultMetadata) {
+			continue
+		}
+
+		resultEncodedData, err = prepareResultValuesData(version.data, version.encodedData, resultData, resultEncodedData)
+		if err != nil {
+			return secretValuesVersion{}, err
+		}
+	}
+
+	return secretValuesVersion{data: resultData, encodedData: bytes.TrimSpace(resultEncodedData)}, nil
+}
+
+// gitConflictData surrounds differing lines of the current and other data with git conflict markers
+func gitConflictData(currentData, otherData []byte) []byte {
+	currentLines := strings.SplitAfter(string(currentData)+"\n", "\n")
+	otherLines := strings.SplitAfter(string(otherData)+"\n", "\n")
+	currentLines, otherLines = currentLines[:len(currentLines)-1], otherLines[:len(otherLines)-1]
+
+	prefixLen := 0
+	for prefixLen < len(currentLines) && prefixLen < len(otherLines) && currentLines[prefixLen] == otherLines[prefixLen] {
+		prefixLen++
+	}
+
+	suffixLen := 0
+	for suffixLen < len(currentLines)-prefixLen && suffixLen < len(otherLines)-prefixLen &&
+		currentLines[len(currentLines)-1-suffixLen] == otherLines[len(otherLines)-1-suffixLen] {
+		suffixLen++
+	}
+
+	var result []string
+	result = append(result, currentLines[:prefixLen]...)
+	result = append(result, "<<<<<<< current\n")
+	result = append(result, currentLines[prefixLen:len(currentLines)-suffixLen]...)
+	result = append(result, "=======\n")
+	result = append(result, otherLines[prefixLen:len(otherLines)-suffixLen]...)
+	result = append(result, ">>>>>>> other\n")
+	result = append(result, currentLines[len(currentLines)-suffixLen:]...)
+
+	return []byte(strings.Join(result, ""))
+}
+
+// missingYamlValue represents a key missing in the version, unlike the key explicitly set to null
+type missingYamlValue struct{}
+
+// mergeYamlData does three-way merge of the decrypted values, missing value is represented by missingYamlValue.
+// Conflicting values are taken from the current version or from the other version if keepOther is set
+func mergeYamlData(ancestor, current, other interface{}, keyPath []string, conflicts *[]string, keepOther bool) interface{} {
+	switch {
+	case reflect.DeepEqual(current, other), reflect.DeepEqual(ancestor, other):
+		return current
+	case reflect.DeepEqual(ancestor, current):
+		return other
+	}
+
+	currentMapSlice, isCurrentMapSlice := current.(yaml.MapSlice)
+	otherMapSlice, isOtherMapSlice := other.(yaml.MapSlice)
+	if !isCurrentMapSlice || !isOtherMapSlice {
+		if conflicts != nil {
+			*conflicts = append(*conflicts, strings.Join(keyPath, "."))
+		}
+
+		if keepOther {
+			return other
+		}
+
+		return current
+	}
+
+	ancestorMapSlice, _ := ancestor.(yaml.MapSlice)
+
+	keys := yaml.MapSlice{}
+	for _, mapSlice := range []yaml.MapSlice{currentMapSlice, otherMapSlice} {
+		for _, item := range mapSlice {
+			if _, ok := getYamlMapSliceValue(keys, item.Key); !ok {
+				keys = append(keys, yaml.MapItem{Key: item.Key})
+			}
+		}
+	}
+
+	result := yaml.MapSlice{}
+	for _, keyItem := range keys {
+		key := keyItem.Key
+		ancestorValue := getYamlMapSliceValueOrMissing(ancestorMapSlice, key)
+		currentValue := getYamlMapSliceValueOrMissing(currentMapSlice, key)
+		otherValue := getYamlMapSliceValueOrMissing(otherMapSlice, key)
+
+		value := mergeYamlData(ancestorValue, currentValue, otherValue, append(keyPath, fmt.Sprintf("%v", key)), conflicts, keepOther)
+		if _, isMissing := value.(missingYamlValue); !isMissing {
+			result = append(result, yaml.MapItem{Key: key, Value: value})
+		}
+	}
+
+	return result
+}
+
+func getYamlMapSliceValueOrMissing(mapSlice yaml.MapSlice, key interface{}) interface{} {
+	if value, ok := getYamlMapSliceValue(mapSlice, key); ok {
+		return value
+	}
+
+	return missingYamlValue{}
+}
+
+func getYamlMapSliceValue(mapSlice yaml.MapSlice, key interface{}) (interface{}, bool) {
+	for _, item := range mapSlice {
+		if item.Key == key {
+			return item.Value, true
+		}
+	}
+
+	return nil, false
+}
+
+func decryptGitData(m secret.Manager, encodedData []byte, values bool) ([]byte, error) {
+	if len(encodedData) == 0 {
+		return nil, nil
+	}
+
+	if values {
+		return m.DecryptYamlData(encodedData)
+	}
+
+	return m.Decrypt(encodedData)
+}
+
+// isSecretValuesData distinguishes secret values yaml from the hex or base64 encoded secret file data
+func isSecretValuesData(encodedData []byte) bool {
+	if len(encodedData) == 0 {
+		return false
+	}
+
+	config, err := unmarshalYaml(encodedData)
+	return err == nil && len(config) != 0
+}
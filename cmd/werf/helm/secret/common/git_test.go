@@ -0,0 +1,218 @@
+package secret
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"gopkg.in/yaml.v2"
+
+	"github.com/flant/werf/pkg/deploy/secret"
+)
+
+func TestMergeYamlData(t *testing.T) {
+	tests := []struct {
+		name                            string
+		ancestor, current, other        yaml.MapSlice
+		expected, expectedWithKeepOther yaml.MapSlice
+		expectedConflicts               []string
+	}{
+		{
+			name:     "nested keys",
+			ancestor: yaml.MapSlice{{Key: "a", Value: "1"}, {Key: "b", Value: yaml.MapSlice{{Key: "c", Value: "2"}, {Key: "d", Value: yaml.MapSlice{{Key: "e", Value: "3"}}}}}},
+			current:  yaml.MapSlice{{Key: "a", Value: "10"}, {Key: "b", Value: yaml.MapSlice{{Key: "c", Value: "2"}, {Key: "d", Value: yaml.MapSlice{{Key: "e", Value: "3"}, {Key: "f", Value: "4"}}}}}},
+			other:    yaml.MapSlice{{Key: "a", Value: "1"}, {Key: "b", Value: yaml.MapSlice{{Key: "c", Value: "20"}, {Key: "d", Value: yaml.MapSlice{{Key: "e", Value: "30"}}}}}},
+			expected: yaml.MapSlice{{Key: "a", Value: "10"}, {Key: "b", Value: yaml.MapSlice{{Key: "c", Value: "20"}, {Key: "d", Value: yaml.MapSlice{{Key: "e", Value: "30"}, {Key: "f", Value: "4"}}}}}},
+		},
+		{
+			name:     "deletion",
+			ancestor: yaml.MapSlice{{Key: "a", Value: "1"}, {Key: "b", Value: yaml.MapSlice{{Key: "c", Value: "2"}}}, {Key: "d", Value: "3"}},
+			current:  yaml.MapSlice{{Key: "b", Value: yaml.MapSlice{{Key: "c", Value: "2"}}}, {Key: "d", Value: "3"}},
+			other:    yaml.MapSlice{{Key: "a", Value: "1"}, {Key: "d", Value: "3"}, {Key: "e", Value: "4"}},
+			expected: yaml.MapSlice{{Key: "d", Value: "3"}, {Key: "e", Value: "4"}},
+		},
+		{
+			name:                  "conflict",
+			ancestor:              yaml.MapSlice{{Key: "a", Value: "1"}, {Key: "b", Value: yaml.MapSlice{{Key: "c", Value: "2"}, {Key: "d", Value: "3"}}}, {Key: "e", Value: "4"}},
+			current:               yaml.MapSlice{{Key: "a", Value: "10"}, {Key: "b", Value: yaml.MapSlice{{Key: "c", Value: "2"}, {Key: "d", Value: "30"}}}, {Key: "e", Value: "4"}},
+			other:                 yaml.MapSlice{{Key: "a", Value: "1"}, {Key: "b", Value: yaml.MapSlice{{Key: "c", Value: "20"}, {Key: "d", Value: "300"}}}, {Key: "f", Value: "5"}},
+			expected:              yaml.MapSlice{{Key: "a", Value: "10"}, {Key: "b", Value: yaml.MapSlice{{Key: "c", Value: "20"}, {Key: "d", Value: "30"}}}, {Key: "f", Value: "5"}},
+			expectedWithKeepOther: yaml.MapSlice{{Key: "a", Value: "10"}, {Key: "b", Value: yaml.MapSlice{{Key: "c", Value: "20"}, {Key: "d", Value: "300"}}}, {Key: "f", Value: "5"}},
+			expectedConflicts:     []string{"b.d"},
+		},
+		{
+			name:                  "deletion conflict",
+			ancestor:              yaml.MapSlice{{Key: "a", Value: yaml.MapSlice{{Key: "b", Value: "1"}}}, {Key: "c", Value: "2"}},
+			current:               yaml.MapSlice{{Key: "c", Value: "2"}},
+			other:                 yaml.MapSlice{{Key: "a", Value: yaml.MapSlice{{Key: "b", Value: "10"}}}, {Key: "c", Value: "2"}},
+			expected:              yaml.MapSlice{{Key: "c", Value: "2"}},
+			expectedWithKeepOther: yaml.MapSlice{{Key: "c", Value: "2"}, {Key: "a", Value: yaml.MapSlice{{Key: "b", Value: "10"}}}},
+			expectedConflicts:     []string{"a"},
+		},
+		{
+			name:     "null values",
+			ancestor: yaml.MapSlice{{Key: "a", Value: "1"}, {Key: "b", Value: "2"}, {Key: "c", Value: nil}},
+			current:  yaml.MapSlice{{Key: "a", Value: nil}, {Key: "b", Value: "2"}, {Key: "c", Value: nil}},
+			other:    yaml.MapSlice{{Key: "a", Value: "1"}, {Key: "b", Value: nil}, {Key: "c", Value: nil}, {Key: "d", Value: nil}},
+			expected: yaml.MapSlice{{Key: "a", Value: nil}, {Key: "b", Value: nil}, {Key: "c", Value: nil}, {Key: "d", Value: nil}},
+		},
+		{
+			name:                  "null value deletion conflict",
+			ancestor:              yaml.MapSlice{{Key: "a", Value: "1"}},
+			current:               yaml.MapSlice{},
+			other:                 yaml.MapSlice{{Key: "a", Value: nil}},
+			expected:              yaml.MapSlice{},
+			expectedWithKeepOther: yaml.MapSlice{{Key: "a", Value: nil}},
+			expectedConflicts:     []string{"a"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var conflicts []string
+			result := mergeYamlData(test.ancestor, test.current, test.other, nil, &conflicts, false)
+			if !reflect.DeepEqual(result, test.expected) {
+				t.Errorf("\n[EXPECTED]: %v\n[GOT]: %v", test.expected, result)
+			}
+
+			if !reflect.DeepEqual(conflicts, test.expectedConflicts) {
+				t.Errorf("\n[EXPECTED]: %q conflicts\n[GOT]: %q", test.expectedConflicts, conflicts)
+			}
+
+			expectedWithKeepOther := test.expectedWithKeepOther
+			if expectedWithKeepOther == nil {
+				expectedWithKeepOther = test.expected
+			}
+
+			result = mergeYamlData(test.ancestor, test.current, test.other, nil, nil, true)
+			if !reflect.DeepEqual(result, expectedWithKeepOther) {
+				t.Errorf("\n[EXPECTED]: %v\n[GOT]: %v", expectedWithKeepOther, result)
+			}
+		})
+	}
+}
+
+func TestGitConflictData(t *testing.T) {
+	result := string(gitConflictData([]byte("a: 1\nb: 2\nc: 3"), []byte("a: 1\nb: 20\nb2: 21\nc: 3")))
+	expected := "a: 1\n<<<<<<< current\nb: 2\n=======\nb: 20\nb2: 21\n>>>>>>> other\nc: 3\n"
+	if result != expected {
+		t.Errorf("\n[EXPECTED]: %q\n[GOT]: %q", expected, result)
+	}
+}
+
+func TestSecretGitMergeDriver(t *testing.T) {
+	dir, err := ioutil.TempDir("", "werf-secret-merge")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	key, err := secret.GenerateSecretKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	m, err := secret.NewManager(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	writeValues := func(name, data string) string {
+		encodedData, err := m.EncryptYamlData([]byte(data))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		path := filepath.Join(dir, name)
+		if err := ioutil.WriteFile(path, encodedData, 0644); err != nil {
+			t.Fatal(err)
+		}
+
+		return path
+	}
+
+	readValues := func(path string) string {
+		encodedData, err := ioutil.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		data, err := m.DecryptYamlData(encodedData)
+		if err != nil {
+			t.Fatalf("unable to decrypt %q: %s", encodedData, err)
+		}
+
+		return string(data)
+	}
+
+	t.Run("merge", func(t *testing.T) {
+		ancestor := writeValues("ancestor", "a: a\nb: b\n")
+		current := writeValues("current", "a: aa\nb: b\n")
+		other := writeValues("other", "a: a\nb: bb\n")
+
+		if err := SecretGitMergeDriver(m, ancestor, current, other, "secret-values.yaml"); err != nil {
+			t.Fatal(err)
+		}
+
+		if expected, result := "a: aa\nb: bb\n", readValues(current); result != expected {
+			t.Errorf("\n[EXPECTED]: %q\n[GOT]: %q", expected, result)
+		}
+	})
+
+	t.Run("conflict", func(t *testing.T) {
+		ancestor := writeValues("ancestor", "a: a\nb: b\nc: c\n")
+		current := writeValues("current", "a: aa\nb: b\nc: cc\n")
+		other := writeValues("other", "a: a\nb: bb\nc: ccc\n")
+
+		err := SecretGitMergeDriver(m, ancestor, current, other, "secret-values.yaml")
+		if err == nil || !strings.Contains(err.Error(), "values changed in both versions: c:") {
+			t.Fatalf("conflict error expected, got %v", err)
+		}
+
+		data, err := ioutil.ReadFile(current)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if strings.Count(string(data), "<<<<<<< current\n") != 1 || strings.Count(string(data), "\nc: ") != 2 {
+			t.Fatalf("conflict markers expected around the conflicting value only:\n%s", data)
+		}
+
+		for _, test := range []struct {
+			keepOther bool
+			expected  string
+		}{{false, "a: aa\nb: bb\nc: cc\n"}, {true, "a: aa\nb: bb\nc: ccc\n"}} {
+			resolvedPath := filepath.Join(dir, "resolved")
+			if err := ioutil.WriteFile(resolvedPath, resolveGitConflict(data, test.keepOther), 0644); err != nil {
+				t.Fatal(err)
+			}
+
+			if result := readValues(resolvedPath); result != test.expected {
+				t.Errorf("\n[EXPECTED]: %q\n[GOT]: %q", test.expected, result)
+			}
+		}
+	})
+}
+
+func resolveGitConflict(data []byte, keepOther bool) []byte {
+	var result []string
+	var inCurrent, inOther bool
+	for _, line := range strings.SplitAfter(string(data), "\n") {
+		switch {
+		case strings.HasPrefix(line, "<<<<<<< "):
+			inCurrent = true
+		case line == "=======\n":
+			inCurrent, inOther = false, true
+		case strings.HasPrefix(line, ">>>>>>> "):
+			inOther = false
+		case inCurrent && keepOther, inOther && !keepOther:
+		default:
+			result = append(result, line)
+		}
+	}
+
+	return []byte(strings.Join(result, ""))
+}
@@ -26,7 +26,8 @@ func NewCmd() *cobra.Command {
 		DisableFlagsInUseLine: true,
 		Short:                 "Encrypt data",
 		Long: common.GetLongCommandDescription(`Encrypt data from standard input.
-Encryption key should be in $WERF_SECRET_KEY or .werf_secret_key file`),
+Encryption key should be in $WERF_SECRET_KEY or .werf_secret_key file.
+If recipients are configured in $WERF_SECRET_RECIPIENTS or .werf_secret_recipients file, data is encrypted for these recipients`),
 		Example: `  # Encrypt data in interactive mode
   $ werf helm secret encrypt
   Enter secret:
@@ -35,7 +36,7 @@ Encryption key should be in $WERF_SECRET_KEY or .werf_secret_key file`),
   # Encrypt from a pipe and save result in file
   $ date | werf helm secret encrypt -o .helm/secret/date`,
 		Annotations: map[string]string{
-			common.CmdEnvAnno: common.EnvsDescription(common.WerfSecretKey),
+			common.CmdEnvAnno: common.EnvsDescription(common.WerfSecretKey, common.WerfSecretRecipients),
 		},
 		RunE: func(cmd *cobra.Command, args []string) error {
 			if err := common.ProcessLogOptions(&commonCmdData); err != nil {
@@ -48,6 +49,7 @@ Encryption key should be in $WERF_SECRET_KEY or .werf_secret_key file`),
 	}
 
 	common.SetupDir(&commonCmdData, cmd)
+	common.SetupEnvironment(&commonCmdData, cmd)
 	common.SetupTmpDir(&commonCmdData, cmd)
 	common.SetupHomeDir(&commonCmdData, cmd)
 
@@ -68,11 +70,21 @@ func runSecretEncrypt() error {
 		return fmt.Errorf("getting project dir failed: %s", err)
 	}
 
-	m, err := secret.GetManager(projectDir)
+	m, err := secret.GetEnvManager(projectDir, *commonCmdData.Environment)
 	if err != nil {
 		return err
 	}
 
+	// the data key of the envelope encryption is recorded in the file metadata, which a single value does not have
+	metadata, err := m.GetMetadata()
+	if err != nil {
+		return err
+	}
+
+	if metadata != nil && metadata.DataKey != "" {
+		return fmt.Errorf("single value cannot be encrypted for kms recipient: use werf helm secret values edit or werf helm secret values encrypt")
+	}
+
 	return secretEncrypt(m)
 }
 
@@ -32,7 +32,7 @@ Encryption key should be in $WERF_SECRET_KEY or .werf_secret_key file`),
   $ cat .helm/secret/date | werf helm secret decrypt
   Tue Jun 26 09:58:10 PDT 1990`,
 		Annotations: map[string]string{
-			common.CmdEnvAnno: common.EnvsDescription(common.WerfSecretKey),
+			common.CmdEnvAnno: common.EnvsDescription(common.WerfSecretKey, common.WerfSecretAgeIdentity, common.WerfSecretPgpKey, common.WerfSecretPgpPassphrase),
 		},
 		RunE: func(cmd *cobra.Command, args []string) error {
 			if err := common.ProcessLogOptions(&commonCmdData); err != nil {
@@ -59,6 +59,7 @@ Encryption key should be in $WERF_SECRET_KEY or .werf_secret_key file`),
 	}
 
 	common.SetupDir(&commonCmdData, cmd)
+	common.SetupEnvironment(&commonCmdData, cmd)
 	common.SetupTmpDir(&commonCmdData, cmd)
 	common.SetupHomeDir(&commonCmdData, cmd)
 
@@ -79,7 +80,7 @@ func runSecretDecrypt(filePath string) error {
 		return fmt.Errorf("getting project dir failed: %s", err)
 	}
 
-	m, err := secret.GetManager(projectDir)
+	m, err := secret.GetEnvManager(projectDir, *commonCmdData.Environment)
 	if err != nil {
 		return err
 	}
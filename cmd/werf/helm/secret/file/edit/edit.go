@@ -19,11 +19,16 @@ func NewCmd() *cobra.Command {
 		DisableFlagsInUseLine: true,
 		Short:                 "Edit or create new secret file",
 		Long: common.GetLongCommandDescription(`Edit or create new secret file.
-Encryption key should be in $WERF_SECRET_KEY or .werf_secret_key file`),
+Encryption key should be in $WERF_SECRET_KEY or .werf_secret_key file.
+If recipients are configured in $WERF_SECRET_RECIPIENTS or .werf_secret_recipients file,
+or recorded in the existing file, the file is re-encrypted for all these recipients`),
 		Example: `  # Create/edit existing secret file
-  $ werf helm secret file edit .helm/secret/privacy`,
+  $ werf helm secret file edit .helm/secret/privacy
+
+  # Create/edit secret file of the production environment encrypted by the production key
+  $ werf helm secret file edit .helm/secret/production/privacy --env production`,
 		Annotations: map[string]string{
-			common.CmdEnvAnno: common.EnvsDescription(common.WerfSecretKey),
+			common.CmdEnvAnno: common.EnvsDescription(common.WerfSecretKey, common.WerfSecretRecipients, common.WerfSecretAgeIdentity, common.WerfSecretPgpKey, common.WerfSecretPgpPassphrase),
 		},
 		RunE: func(cmd *cobra.Command, args []string) error {
 			if err := common.ProcessLogOptions(&commonCmdData); err != nil {
@@ -40,6 +45,7 @@ Encryption key should be in $WERF_SECRET_KEY or .werf_secret_key file`),
 	}
 
 	common.SetupDir(&commonCmdData, cmd)
+	common.SetupEnvironment(&commonCmdData, cmd)
 	common.SetupTmpDir(&commonCmdData, cmd)
 	common.SetupHomeDir(&commonCmdData, cmd)
 
@@ -58,7 +64,7 @@ func runSecretEdit(filepPath string) error {
 		return fmt.Errorf("getting project dir failed: %s", err)
 	}
 
-	m, err := secret.GetManager(projectDir)
+	m, err := secret.GetManagerForFile(projectDir, *commonCmdData.Environment, filepPath)
 	if err != nil {
 		return err
 	}
@@ -24,11 +24,12 @@ func NewCmd() *cobra.Command {
 		DisableFlagsInUseLine: true,
 		Short:                 "Encrypt file data",
 		Long: common.GetLongCommandDescription(`Encrypt data from FILE_PATH or pipe.
-Encryption key should be in $WERF_SECRET_KEY or .werf_secret_key file`),
+Encryption key should be in $WERF_SECRET_KEY or .werf_secret_key file.
+If recipients are configured in $WERF_SECRET_RECIPIENTS or .werf_secret_recipients file, data is encrypted for these recipients`),
 		Example: `  # Encrypt and save result in file
   $ werf helm secret file encrypt tls.crt -o .helm/secret/tls.crt`,
 		Annotations: map[string]string{
-			common.CmdEnvAnno: common.EnvsDescription(common.WerfSecretKey),
+			common.CmdEnvAnno: common.EnvsDescription(common.WerfSecretKey, common.WerfSecretRecipients),
 		},
 		RunE: func(cmd *cobra.Command, args []string) error {
 			if err := common.ProcessLogOptions(&commonCmdData); err != nil {
@@ -55,6 +56,7 @@ Encryption key should be in $WERF_SECRET_KEY or .werf_secret_key file`),
 	}
 
 	common.SetupDir(&commonCmdData, cmd)
+	common.SetupEnvironment(&commonCmdData, cmd)
 	common.SetupTmpDir(&commonCmdData, cmd)
 	common.SetupHomeDir(&commonCmdData, cmd)
 
@@ -75,7 +77,7 @@ func runSecretEncrypt(filePath string) error {
 		return fmt.Errorf("getting project dir failed: %s", err)
 	}
 
-	m, err := secret.GetManager(projectDir)
+	m, err := secret.GetEnvManager(projectDir, *commonCmdData.Environment)
 	if err != nil {
 		return err
 	}
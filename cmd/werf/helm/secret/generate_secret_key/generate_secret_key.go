@@ -9,6 +9,10 @@ import (
 	"github.com/flant/werf/pkg/deploy/secret"
 )
 
+var cmdData struct {
+	Age bool
+}
+
 var commonCmdData common.CmdData
 
 func NewCmd() *cobra.Command {
@@ -22,7 +26,11 @@ For further usage, the encryption key should be saved in $WERF_SECRET_KEY or .we
   $ export WERF_SECRET_KEY=$(werf helm secret generate-secret-key)
 
   # Save encryption key in .werf_secret_key file
-  $ werf helm secret generate-secret-key > .werf_secret_key`,
+  $ werf helm secret generate-secret-key > .werf_secret_key
+
+  # Generate age identity and add its public key to the project recipients
+  $ werf helm secret generate-secret-key --age > ~/.werf/global_secret_age_identity
+  $ grep "public key" ~/.werf/global_secret_age_identity | cut -d' ' -f4 >> .werf_secret_recipients`,
 		RunE: func(cmd *cobra.Command, args []string) error {
 			if err := common.ProcessLogOptions(&commonCmdData); err != nil {
 				common.PrintHelp(cmd)
@@ -33,12 +41,25 @@ For further usage, the encryption key should be saved in $WERF_SECRET_KEY or .we
 		},
 	}
 
+	cmd.Flags().BoolVarP(&cmdData.Age, "age", "", false, "Generate age X25519 identity (AGE-SECRET-KEY-1...) with its public key (age1...) in comment instead of hex encryption key")
+
 	common.SetupLogOptions(&commonCmdData, cmd)
 
 	return cmd
 }
 
 func runGenerateSecretKey() error {
+	if cmdData.Age {
+		identity, recipient, err := secret.GenerateAgeIdentity()
+		if err != nil {
+			return err
+		}
+
+		fmt.Printf("# public key: %s\n%s\n", recipient, identity)
+
+		return nil
+	}
+
 	key, err := secret.GenerateSecretKey()
 	if err != nil {
 		return err
@@ -0,0 +1,91 @@
+package secret
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/flant/werf/cmd/werf/common"
+	secret_common "github.com/flant/werf/cmd/werf/helm/secret/common"
+	"github.com/flant/werf/pkg/deploy/secret"
+	"github.com/flant/werf/pkg/werf"
+)
+
+var commonCmdData common.CmdData
+
+func NewCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:                   "git-merge-driver ANCESTOR_FILE_PATH CURRENT_FILE_PATH OTHER_FILE_PATH [PATH]",
+		DisableFlagsInUseLine: true,
+		Short:                 "Merge secret values file or secret file versions for git merge",
+		Long: common.GetLongCommandDescription(`Merge secret values file or secret file versions for git merge (git merge driver).
+
+Secret values are decrypted and merged key by key: only changed values are re-encrypted, ciphertext of unchanged values is kept.
+Secret file is merged as a whole. The result is written to CURRENT_FILE_PATH.
+If the same value is changed in both versions, the encrypted current and other versions are written with conflict markers and the command fails, so that git reports the conflict. Resolve the conflict by choosing one of the versions and edit the result with the edit command if needed.
+
+To merge secrets, add the following lines to the .gitattributes:
+
+  .helm/secret-values*.yaml merge=werf-secret
+  .helm/secret/** merge=werf-secret
+
+and configure the merge driver:
+
+  git config merge.werf-secret.driver "werf helm secret git-merge-driver %O %A %B %P"`),
+		Example: `  # Configure merge driver
+  $ git config merge.werf-secret.name "werf secrets merge driver"
+  $ git config merge.werf-secret.driver "werf helm secret git-merge-driver %O %A %B %P"
+
+  # Configure merge driver for the production environment secrets
+  $ git config merge.werf-secret-production.driver "werf helm secret git-merge-driver --env production %O %A %B %P"`,
+		Annotations: map[string]string{
+			common.CmdEnvAnno: common.EnvsDescription(common.WerfSecretKey, common.WerfSecretRecipients, common.WerfSecretAgeIdentity, common.WerfSecretPgpKey, common.WerfSecretPgpPassphrase),
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := common.ProcessLogOptions(&commonCmdData); err != nil {
+				common.PrintHelp(cmd)
+				return err
+			}
+
+			if len(args) < 3 || len(args) > 4 {
+				common.PrintHelp(cmd)
+				return fmt.Errorf("accepts 3 or 4 position arguments, received %d", len(args))
+			}
+
+			path := args[1]
+			if len(args) == 4 {
+				path = args[3]
+			}
+
+			return runSecretGitMergeDriver(args[0], args[1], args[2], path)
+		},
+	}
+
+	common.SetupDir(&commonCmdData, cmd)
+	common.SetupEnvironment(&commonCmdData, cmd)
+	common.SetupTmpDir(&commonCmdData, cmd)
+	common.SetupHomeDir(&commonCmdData, cmd)
+
+	common.SetupLogOptions(&commonCmdData, cmd)
+
+	return cmd
+}
+
+func runSecretGitMergeDriver(ancestorFilePath, currentFilePath, otherFilePath, path string) error {
+	if err := werf.Init(*commonCmdData.TmpDir, *commonCmdData.HomeDir); err != nil {
+		return fmt.Errorf("initialization error: %s", err)
+	}
+
+	projectDir, err := common.GetProjectDir(&commonCmdData)
+	if err != nil {
+		return fmt.Errorf("getting project dir failed: %s", err)
+	}
+
+	// merged values are encrypted for the recipients (and with the data key) recorded in the current version
+	m, err := secret.GetManagerForFile(projectDir, *commonCmdData.Environment, currentFilePath)
+	if err != nil {
+		return err
+	}
+
+	return secret_common.SecretGitMergeDriver(m, ancestorFilePath, currentFilePath, otherFilePath, path)
+}
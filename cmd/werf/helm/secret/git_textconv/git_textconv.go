@@ -0,0 +1,81 @@
+package secret
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/flant/werf/cmd/werf/common"
+	secret_common "github.com/flant/werf/cmd/werf/helm/secret/common"
+	"github.com/flant/werf/pkg/deploy/secret"
+	"github.com/flant/werf/pkg/werf"
+)
+
+var commonCmdData common.CmdData
+
+func NewCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:                   "git-textconv FILE_PATH",
+		DisableFlagsInUseLine: true,
+		Short:                 "Decrypt secret values file or secret file for git diff",
+		Long: common.GetLongCommandDescription(`Decrypt secret values file or secret file for git diff (git textconv).
+Data that cannot be decrypted is printed as is.
+
+To show decrypted secrets in git diff, add the following lines to the .gitattributes:
+
+  .helm/secret-values*.yaml diff=werf-secret
+  .helm/secret/** diff=werf-secret
+
+and configure the diff driver:
+
+  git config diff.werf-secret.textconv "werf helm secret git-textconv"`),
+		Example: `  # Configure diff driver
+  $ git config diff.werf-secret.textconv "werf helm secret git-textconv"
+
+  # Configure diff driver for the production environment secrets
+  $ git config diff.werf-secret-production.textconv "werf helm secret git-textconv --env production"`,
+		Annotations: map[string]string{
+			common.CmdEnvAnno: common.EnvsDescription(common.WerfSecretKey, common.WerfSecretAgeIdentity, common.WerfSecretPgpKey, common.WerfSecretPgpPassphrase),
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := common.ProcessLogOptions(&commonCmdData); err != nil {
+				common.PrintHelp(cmd)
+				return err
+			}
+
+			if len(args) != 1 {
+				common.PrintHelp(cmd)
+				return fmt.Errorf("accepts 1 position argument, received %d", len(args))
+			}
+
+			return runSecretGitTextconv(args[0])
+		},
+	}
+
+	common.SetupDir(&commonCmdData, cmd)
+	common.SetupEnvironment(&commonCmdData, cmd)
+	common.SetupTmpDir(&commonCmdData, cmd)
+	common.SetupHomeDir(&commonCmdData, cmd)
+
+	common.SetupLogOptions(&commonCmdData, cmd)
+
+	return cmd
+}
+
+func runSecretGitTextconv(filePath string) error {
+	if err := werf.Init(*commonCmdData.TmpDir, *commonCmdData.HomeDir); err != nil {
+		return fmt.Errorf("initialization error: %s", err)
+	}
+
+	projectDir, err := common.GetProjectDir(&commonCmdData)
+	if err != nil {
+		return fmt.Errorf("getting project dir failed: %s", err)
+	}
+
+	m, err := secret.GetEnvManager(projectDir, *commonCmdData.Environment)
+	if err != nil {
+		return err
+	}
+
+	return secret_common.SecretGitTextconv(m, filePath)
+}
@@ -13,6 +13,7 @@ import (
 
 	"github.com/flant/werf/cmd/werf/common"
 	"github.com/flant/werf/pkg/deploy/secret"
+	"github.com/flant/werf/pkg/logging"
 	"github.com/flant/werf/pkg/util"
 	"github.com/flant/werf/pkg/werf"
 )
@@ -152,7 +153,7 @@ func secretsRegenerate(newManager, oldManager secret.Manager, projectPath string
 		return err
 	}
 
-	if err := regenerateSecrets(secretFilesData, regeneratedFilesData, oldManager.Decrypt, newManager.Encrypt); err != nil {
+	if err := regenerateSecrets(secretFilesData, regeneratedFilesData, oldManager.Decrypt, newManager.EncryptFileData); err != nil {
 		return err
 	}
 
@@ -161,7 +162,7 @@ func secretsRegenerate(newManager, oldManager secret.Manager, projectPath string
 	}
 
 	for filePath, fileData := range regeneratedFilesData {
-		err := logboek.LogProcess(fmt.Sprintf("Saving file '%s'", filePath), logboek.LogProcessOptions{}, func() error {
+		err := logging.LogProcess(fmt.Sprintf("Saving file '%s'", filePath), logboek.LogProcessOptions{}, func() error {
 			fileData = append(bytes.TrimSpace(fileData), []byte("\n")...)
 			return ioutil.WriteFile(filePath, fileData, 0644)
 		})
@@ -176,7 +177,7 @@ func secretsRegenerate(newManager, oldManager secret.Manager, projectPath string
 
 func regenerateSecrets(filesData, regeneratedFilesData map[string][]byte, decodeFunc, encodeFunc func([]byte) ([]byte, error)) error {
 	for filePath, fileData := range filesData {
-		err := logboek.LogProcess(fmt.Sprintf("Regenerating file '%s'", filePath), logboek.LogProcessOptions{}, func() error {
+		err := logging.LogProcess(fmt.Sprintf("Regenerating file '%s'", filePath), logboek.LogProcessOptions{}, func() error {
 			data, err := decodeFunc(fileData)
 			if err != nil {
 				return fmt.Errorf("check old encryption key and file data: %s", err)
@@ -24,9 +24,10 @@ func NewCmd() *cobra.Command {
 		DisableFlagsInUseLine: true,
 		Short:                 "Encrypt values file data",
 		Long: common.GetLongCommandDescription(`Encrypt data from FILE_PATH or pipe.
-Encryption key should be in $WERF_SECRET_KEY or .werf_secret_key file`),
+Encryption key should be in $WERF_SECRET_KEY or .werf_secret_key file.
+If recipients are configured in $WERF_SECRET_RECIPIENTS or .werf_secret_recipients file, data is encrypted for these recipients`),
 		Annotations: map[string]string{
-			common.CmdEnvAnno: common.EnvsDescription(common.WerfSecretKey),
+			common.CmdEnvAnno: common.EnvsDescription(common.WerfSecretKey, common.WerfSecretRecipients),
 		},
 		Example: `  # Encrypt and save result in file
   $ werf helm secret values encrypt test.yaml -o .helm/secret-values.yaml`,
@@ -55,6 +56,7 @@ Encryption key should be in $WERF_SECRET_KEY or .werf_secret_key file`),
 	}
 
 	common.SetupDir(&commonCmdData, cmd)
+	common.SetupEnvironment(&commonCmdData, cmd)
 	common.SetupTmpDir(&commonCmdData, cmd)
 	common.SetupHomeDir(&commonCmdData, cmd)
 
@@ -75,7 +77,7 @@ func runSecretEncrypt(filePath string) error {
 		return fmt.Errorf("getting project dir failed: %s", err)
 	}
 
-	m, err := secret.GetManager(projectDir)
+	m, err := secret.GetEnvManager(projectDir, *commonCmdData.Environment)
 	if err != nil {
 		return err
 	}
@@ -13,6 +13,7 @@ import (
 	"github.com/flant/werf/pkg/cleaning"
 	"github.com/flant/werf/pkg/docker"
 	"github.com/flant/werf/pkg/docker_registry"
+	"github.com/flant/werf/pkg/logging"
 	"github.com/flant/werf/pkg/werf"
 )
 
@@ -82,7 +83,7 @@ func run(projectNames ...string) error {
 
 	for _, projectName := range projectNames {
 		logProcessOptions := logboek.LevelLogProcessOptions{Style: logboek.HighlightStyle()}
-		if err := logboek.Default.LogProcess("Project "+projectName, logProcessOptions, func() error {
+		if err := logging.LevelLogProcess(logboek.Default, "Project "+projectName, logProcessOptions, func() error {
 			stagesPurgeOptions := cleaning.StagesPurgeOptions{
 				ProjectName:                   projectName,
 				RmContainersThatUseWerfImages: cmdData.Force,
@@ -41,7 +41,9 @@ If one or more IMAGE_NAME parameters specified, werf will publish only these ima
 				}
 				common.LogVersion()
 
-				return runImagesPublish(commonCmdData, args)
+				return common.WithTracing(commonCmdData, cmd, func() error {
+					return runImagesPublish(commonCmdData, args)
+				})
 			})
 		},
 	}
@@ -60,6 +62,9 @@ If one or more IMAGE_NAME parameters specified, werf will publish only these ima
 	common.SetupDockerConfig(commonCmdData, cmd, "Command needs granted permissions to read and pull images from the specified stages storage and push images into images repo")
 	common.SetupInsecureRegistry(commonCmdData, cmd)
 	common.SetupSkipTlsVerifyRegistry(commonCmdData, cmd)
+	common.SetupSignKey(commonCmdData, cmd)
+	common.SetupDaemonlessPublish(commonCmdData, cmd)
+	common.SetupOtel(commonCmdData, cmd)
 
 	common.SetupLogOptions(commonCmdData, cmd)
 	common.SetupLogProjectDir(commonCmdData, cmd)
@@ -131,17 +136,17 @@ func runImagesPublish(commonCmdData *common.CmdData, imagesToProcess []string) e
 		return err
 	}
 
-	imagesRepoMode, err := common.GetImagesRepoMode(commonCmdData)
+	imagesRepoManager, err := common.GetImagesRepoManagerByCmdData(projectName, imagesRepo, common.GetWerfConfigImagesNames(werfConfig), commonCmdData)
 	if err != nil {
 		return err
 	}
 
-	imagesRepoManager, err := common.GetImagesRepoManager(imagesRepo, imagesRepoMode)
+	tagOpts, err := common.GetTagOptions(commonCmdData, common.TagOptionsGetterOptions{})
 	if err != nil {
 		return err
 	}
 
-	tagOpts, err := common.GetTagOptions(commonCmdData, common.TagOptionsGetterOptions{})
+	signer, err := common.GetImageSigner(commonCmdData)
 	if err != nil {
 		return err
 	}
@@ -157,8 +162,10 @@ func runImagesPublish(commonCmdData *common.CmdData, imagesToProcess []string) e
 	}()
 
 	opts := build.PublishImagesOptions{
-		ImagesToPublish: imagesToProcess,
-		TagOptions:      tagOpts,
+		ImagesToPublish:   imagesToProcess,
+		TagOptions:        tagOpts,
+		Signer:            signer,
+		DaemonlessPublish: *commonCmdData.DaemonlessPublish,
 	}
 
 	c := build.NewConveyor(werfConfig, imagesToProcess, projectDir, projectTmpDir, ssh_agent.SSHAuthSock)
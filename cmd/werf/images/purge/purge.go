@@ -42,9 +42,11 @@ func NewCmd() *cobra.Command {
 
 	common.SetupImagesRepo(&commonCmdData, cmd)
 	common.SetupImagesRepoMode(&commonCmdData, cmd)
+	common.SetupImagesRepoTemplateEnvs(&commonCmdData, cmd)
 	common.SetupDockerConfig(&commonCmdData, cmd, "Command needs granted permissions to delete images from the specified images repo")
 	common.SetupInsecureRegistry(&commonCmdData, cmd)
 	common.SetupSkipTlsVerifyRegistry(&commonCmdData, cmd)
+	common.SetupRepoImplementation(&commonCmdData, cmd)
 
 	common.SetupLogOptions(&commonCmdData, cmd)
 	common.SetupLogProjectDir(&commonCmdData, cmd)
@@ -63,7 +65,7 @@ func runPurge() error {
 		return err
 	}
 
-	if err := docker_registry.Init(docker_registry.Options{InsecureRegistry: *commonCmdData.InsecureRegistry, SkipTlsVerifyRegistry: *commonCmdData.SkipTlsVerifyRegistry}); err != nil {
+	if err := docker_registry.Init(docker_registry.Options{InsecureRegistry: *commonCmdData.InsecureRegistry, SkipTlsVerifyRegistry: *commonCmdData.SkipTlsVerifyRegistry, RepoImplementation: *commonCmdData.RepoImplementation}); err != nil {
 		return err
 	}
 
@@ -92,25 +94,13 @@ func runPurge() error {
 		return err
 	}
 
-	imagesRepoMode, err := common.GetImagesRepoMode(&commonCmdData)
-	if err != nil {
-		return err
-	}
+	imageNames := common.GetWerfConfigImagesNames(werfConfig)
 
-	imagesRepoManager, err := common.GetImagesRepoManager(imagesRepo, imagesRepoMode)
+	imagesRepoManager, err := common.GetImagesRepoManagerByCmdData(projectName, imagesRepo, imageNames, &commonCmdData)
 	if err != nil {
 		return err
 	}
 
-	var imageNames []string
-	for _, image := range werfConfig.StapelImages {
-		imageNames = append(imageNames, image.Name)
-	}
-
-	for _, image := range werfConfig.ImagesFromDockerfile {
-		imageNames = append(imageNames, image.Name)
-	}
-
 	imagesPurgeOptions := cleaning.ImagesPurgeOptions{
 		ImagesRepoManager: imagesRepoManager,
 		ImagesNames:       imageNames,
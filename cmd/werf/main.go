@@ -20,6 +20,8 @@ import (
 	helm_secret_file_edit "github.com/flant/werf/cmd/werf/helm/secret/file/edit"
 	helm_secret_file_encrypt "github.com/flant/werf/cmd/werf/helm/secret/file/encrypt"
 	helm_secret_generate_secret_key "github.com/flant/werf/cmd/werf/helm/secret/generate_secret_key"
+	helm_secret_git_merge_driver "github.com/flant/werf/cmd/werf/helm/secret/git_merge_driver"
+	helm_secret_git_textconv "github.com/flant/werf/cmd/werf/helm/secret/git_textconv"
 	helm_secret_rotate_secret_key "github.com/flant/werf/cmd/werf/helm/secret/rotate_secret_key"
 	helm_secret_values_decrypt "github.com/flant/werf/cmd/werf/helm/secret/values/decrypt"
 	helm_secret_values_edit "github.com/flant/werf/cmd/werf/helm/secret/values/edit"
@@ -61,8 +63,11 @@ import (
 	helm_repo "github.com/flant/werf/cmd/werf/helm/repo"
 	helm_rollback "github.com/flant/werf/cmd/werf/helm/rollback"
 
+	config_graph "github.com/flant/werf/cmd/werf/config/graph"
+	config_lint "github.com/flant/werf/cmd/werf/config/lint"
 	config_list "github.com/flant/werf/cmd/werf/config/list"
 	config_render "github.com/flant/werf/cmd/werf/config/render"
+	config_schema "github.com/flant/werf/cmd/werf/config/schema"
 
 	"github.com/flant/werf/cmd/werf/completion"
 	"github.com/flant/werf/cmd/werf/docs"
@@ -142,6 +147,8 @@ Find more information at https://werf.io`),
 	if err := rootCmd.Execute(); err != nil {
 		common.TerminateWithError(err.Error(), 1)
 	}
+
+	logging.Close()
 }
 
 func configCmd() *cobra.Command {
@@ -152,6 +159,9 @@ func configCmd() *cobra.Command {
 	cmd.AddCommand(
 		config_render.NewCmd(),
 		config_list.NewCmd(),
+		config_lint.NewCmd(),
+		config_schema.NewCmd(),
+		config_graph.NewCmd(),
 	)
 
 	return cmd
@@ -296,6 +306,8 @@ func secretCmd() *cobra.Command {
 		helm_secret_encrypt.NewCmd(),
 		helm_secret_decrypt.NewCmd(),
 		helm_secret_rotate_secret_key.NewCmd(),
+		helm_secret_git_textconv.NewCmd(),
+		helm_secret_git_merge_driver.NewCmd(),
 	)
 
 	return cmd
@@ -15,6 +15,15 @@ import (
 	"github.com/spf13/cobra"
 )
 
+var cmdData struct {
+	Description                 string
+	KeepForever                 bool
+	GitTagStrategyLimit         int64
+	GitTagStrategyExpiryDays    int64
+	GitCommitStrategyLimit      int64
+	GitCommitStrategyExpiryDays int64
+}
+
 var commonCmdData common.CmdData
 
 func NewCmd() *cobra.Command {
@@ -22,6 +31,9 @@ func NewCmd() *cobra.Command {
 		Use:                   "add",
 		DisableFlagsInUseLine: true,
 		Short:                 "Add image record to the list of managed images which will be preserved during cleanup procedure",
+		Long: common.GetLongCommandDescription(`Add image record to the list of managed images which will be preserved during cleanup procedure.
+
+The record keeps who and when added the image, the description and the cleanup settings, which override the images cleanup policies for the image. The existing record is replaced`),
 		RunE: func(cmd *cobra.Command, args []string) error {
 			if err := common.ProcessLogOptions(&commonCmdData); err != nil {
 				common.PrintHelp(cmd)
@@ -31,7 +43,7 @@ func NewCmd() *cobra.Command {
 			if err := common.ValidateArgumentCount(1, args, cmd); err != nil {
 				return err
 			}
-			return run(args[0])
+			return run(cmd, args[0])
 		},
 	}
 
@@ -52,10 +64,40 @@ func NewCmd() *cobra.Command {
 	common.SetupLogOptions(&commonCmdData, cmd)
 	common.SetupLogProjectDir(&commonCmdData, cmd)
 
+	cmd.Flags().StringVarP(&cmdData.Description, "description", "", "", "Description of the managed image")
+	cmd.Flags().BoolVarP(&cmdData.KeepForever, "keep-forever", "", false, "Skip the image during images cleanup")
+	cmd.Flags().Int64VarP(&cmdData.GitTagStrategyLimit, "git-tag-strategy-limit", "", -1, "Override --git-tag-strategy-limit cleanup policy for the image, -1 disables the limit")
+	cmd.Flags().Int64VarP(&cmdData.GitTagStrategyExpiryDays, "git-tag-strategy-expiry-days", "", -1, "Override --git-tag-strategy-expiry-days cleanup policy for the image, -1 disables the limit")
+	cmd.Flags().Int64VarP(&cmdData.GitCommitStrategyLimit, "git-commit-strategy-limit", "", -1, "Override --git-commit-strategy-limit cleanup policy for the image, -1 disables the limit")
+	cmd.Flags().Int64VarP(&cmdData.GitCommitStrategyExpiryDays, "git-commit-strategy-expiry-days", "", -1, "Override --git-commit-strategy-expiry-days cleanup policy for the image, -1 disables the limit")
+
 	return cmd
 }
 
-func run(imageName string) error {
+func newManagedImageRecord(cmd *cobra.Command, imageName string) *storage.ManagedImageRecord {
+	record := storage.NewManagedImageRecord(imageName)
+	record.Description = cmdData.Description
+	record.Cleanup.KeepForever = cmdData.KeepForever
+
+	for flagName, setting := range map[string]struct {
+		value *int64
+		field **int64
+	}{
+		"git-tag-strategy-limit":          {&cmdData.GitTagStrategyLimit, &record.Cleanup.GitTagStrategyLimit},
+		"git-tag-strategy-expiry-days":    {&cmdData.GitTagStrategyExpiryDays, &record.Cleanup.GitTagStrategyExpiryDays},
+		"git-commit-strategy-limit":       {&cmdData.GitCommitStrategyLimit, &record.Cleanup.GitCommitStrategyLimit},
+		"git-commit-strategy-expiry-days": {&cmdData.GitCommitStrategyExpiryDays, &record.Cleanup.GitCommitStrategyExpiryDays},
+	} {
+		// Policy is not overridden by default
+		if cmd.Flags().Changed(flagName) {
+			*setting.field = setting.value
+		}
+	}
+
+	return record
+}
+
+func run(cmd *cobra.Command, imageName string) error {
 	if err := werf.Init(*commonCmdData.TmpDir, *commonCmdData.HomeDir); err != nil {
 		return fmt.Errorf("initialization error: %s", err)
 	}
@@ -106,7 +148,7 @@ func run(imageName string) error {
 
 	stagesStorage := &storage.LocalStagesStorage{}
 
-	if err := stagesStorage.AddManagedImage(projectName, common.GetManagedImageName(imageName)); err != nil {
+	if err := stagesStorage.SetManagedImage(projectName, newManagedImageRecord(cmd, common.GetManagedImageName(imageName))); err != nil {
 		return fmt.Errorf("unable to add managed image %q for project %q: %s", imageName, projectName, err)
 	}
 
@@ -1,8 +1,13 @@
 package ls
 
 import (
+	"encoding/json"
 	"fmt"
+	"os"
 	"path/filepath"
+	"sort"
+	"strings"
+	"text/tabwriter"
 
 	"github.com/flant/werf/pkg/storage"
 
@@ -15,6 +20,10 @@ import (
 	"github.com/spf13/cobra"
 )
 
+var cmdData struct {
+	Format string
+}
+
 var commonCmdData common.CmdData
 
 func NewCmd() *cobra.Command {
@@ -49,6 +58,8 @@ func NewCmd() *cobra.Command {
 	common.SetupLogOptions(&commonCmdData, cmd)
 	common.SetupLogProjectDir(&commonCmdData, cmd)
 
+	cmd.Flags().StringVarP(&cmdData.Format, "format", "", "table", "Output format: table or json")
+
 	return cmd
 }
 
@@ -102,17 +113,78 @@ func run() error {
 	}
 
 	stagesStorage := &storage.LocalStagesStorage{}
-	if images, err := stagesStorage.GetManagedImages(projectName); err != nil {
+	records, err := stagesStorage.GetManagedImages(projectName)
+	if err != nil {
 		return fmt.Errorf("unable to list known config image names for project %q: %s", projectName, err)
-	} else {
-		for _, imgName := range images {
-			if imgName == "" {
-				fmt.Println("~")
-			} else {
-				fmt.Printf("%s\n", imgName)
-			}
+	}
+
+	sort.Slice(records, func(i, j int) bool {
+		return records[i].Name < records[j].Name
+	})
+
+	switch cmdData.Format {
+	case "table":
+		printTable(records)
+	case "json":
+		data, err := json.MarshalIndent(records, "", "  ")
+		if err != nil {
+			return err
 		}
+		fmt.Println(string(data))
+	default:
+		return fmt.Errorf("bad --format %q: only table or json supported", cmdData.Format)
 	}
 
 	return nil
 }
+
+func printTable(records []*storage.ManagedImageRecord) {
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "NAME\tADDED BY\tADDED AT\tCLEANUP\tDESCRIPTION")
+
+	for _, record := range records {
+		name := record.Name
+		if name == "" {
+			name = "~"
+		}
+
+		var addedAt string
+		if !record.AddedAt.IsZero() {
+			addedAt = record.AddedAt.Local().Format("2006-01-02T15:04:05-0700")
+		}
+
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", name, tableValue(record.AddedBy), tableValue(addedAt), tableValue(cleanupSettingsDescription(record.Cleanup)), record.Description)
+	}
+
+	_ = w.Flush()
+}
+
+func cleanupSettingsDescription(settings storage.ManagedImageCleanupSettings) string {
+	if settings.KeepForever {
+		return "keep forever"
+	}
+
+	var parts []string
+	for _, setting := range []struct {
+		name  string
+		value *int64
+	}{
+		{"git-tag-strategy-limit", settings.GitTagStrategyLimit},
+		{"git-tag-strategy-expiry-days", settings.GitTagStrategyExpiryDays},
+		{"git-commit-strategy-limit", settings.GitCommitStrategyLimit},
+		{"git-commit-strategy-expiry-days", settings.GitCommitStrategyExpiryDays},
+	} {
+		if setting.value != nil {
+			parts = append(parts, fmt.Sprintf("%s=%d", setting.name, *setting.value))
+		}
+	}
+
+	return strings.Join(parts, ",")
+}
+
+func tableValue(value string) string {
+	if value == "" {
+		return "-"
+	}
+	return value
+}
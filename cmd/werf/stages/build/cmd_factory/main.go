@@ -42,6 +42,9 @@ func NewCmdWithData(cmdData *CmdData, commonCmdData *common.CmdData) *cobra.Comm
   # Build and enable drop-in shell session in the failed assembly container in the case when an error occurred
   $ werf build --stages-storage :local --introspect-error
 
+  # Build and verify that stages of image 'backend' are reproducible: each stage is rebuilt from the same parent image and files are compared
+  $ werf build --stages-storage :local --verify-reproducible backend
+
   # Set --stages-storage default value using $WERF_STAGES_STORAGE param
   $ export WERF_STAGES_STORAGE=:local
   $ werf build`,
@@ -63,7 +66,9 @@ If one or more IMAGE_NAME parameters specified, werf will build only these image
 			common.LogVersion()
 
 			return common.LogRunningTime(func() error {
-				return runStagesBuild(cmdData, commonCmdData, args)
+				return common.WithTracing(commonCmdData, cmd, func() error {
+					return runStagesBuild(cmdData, commonCmdData, args)
+				})
 			})
 		},
 	}
@@ -80,6 +85,10 @@ If one or more IMAGE_NAME parameters specified, werf will build only these image
 	common.SetupSkipTlsVerifyRegistry(commonCmdData, cmd)
 
 	common.SetupIntrospectStage(commonCmdData, cmd)
+	common.SetupReproducibility(commonCmdData, cmd)
+	common.SetupContainerBackend(commonCmdData, cmd)
+	common.SetupReport(commonCmdData, cmd)
+	common.SetupOtel(commonCmdData, cmd)
 
 	common.SetupLogOptions(commonCmdData, cmd)
 	common.SetupLogProjectDir(commonCmdData, cmd)
@@ -111,6 +120,10 @@ func runStagesBuild(cmdData *CmdData, commonCmdData *common.CmdData, imagesToPro
 		return err
 	}
 
+	if err := common.InitContainerBackend(commonCmdData); err != nil {
+		return err
+	}
+
 	projectDir, err := common.GetProjectDir(commonCmdData)
 	if err != nil {
 		return fmt.Errorf("getting project dir failed: %s", err)
@@ -160,12 +173,24 @@ func runStagesBuild(cmdData *CmdData, commonCmdData *common.CmdData, imagesToPro
 		return err
 	}
 
+	reproducibilityOptions, err := common.GetReproducibilityOptions(commonCmdData, werfConfig)
+	if err != nil {
+		return err
+	}
+
+	reportOptions, err := common.GetReportOptions(commonCmdData)
+	if err != nil {
+		return err
+	}
+
 	opts := build.BuildStagesOptions{
 		ImageBuildOptions: image.BuildOptions{
 			IntrospectAfterError:  cmdData.IntrospectAfterError,
 			IntrospectBeforeError: cmdData.IntrospectBeforeError,
 		},
-		IntrospectOptions: introspectOptions,
+		IntrospectOptions:      introspectOptions,
+		ReproducibilityOptions: reproducibilityOptions,
+		ReportOptions:          reportOptions,
 	}
 
 	logboek.LogOptionalLn()
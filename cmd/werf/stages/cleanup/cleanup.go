@@ -49,9 +49,11 @@ func NewCmd() *cobra.Command {
 	common.SetupSynchronization(&commonCmdData, cmd)
 	common.SetupImagesRepo(&commonCmdData, cmd)
 	common.SetupImagesRepoMode(&commonCmdData, cmd)
+	common.SetupImagesRepoTemplateEnvs(&commonCmdData, cmd)
 	common.SetupDockerConfig(&commonCmdData, cmd, "Command needs granted permissions to read, pull and delete images from the specified stages storage, read images from the specified images repo")
 	common.SetupInsecureRegistry(&commonCmdData, cmd)
 	common.SetupSkipTlsVerifyRegistry(&commonCmdData, cmd)
+	common.SetupRepoImplementation(&commonCmdData, cmd)
 
 	common.SetupLogOptions(&commonCmdData, cmd)
 	common.SetupLogProjectDir(&commonCmdData, cmd)
@@ -70,7 +72,7 @@ func runSync() error {
 		return err
 	}
 
-	if err := docker_registry.Init(docker_registry.Options{InsecureRegistry: *commonCmdData.InsecureRegistry, SkipTlsVerifyRegistry: *commonCmdData.SkipTlsVerifyRegistry}); err != nil {
+	if err := docker_registry.Init(docker_registry.Options{InsecureRegistry: *commonCmdData.InsecureRegistry, SkipTlsVerifyRegistry: *commonCmdData.SkipTlsVerifyRegistry, RepoImplementation: *commonCmdData.RepoImplementation}); err != nil {
 		return err
 	}
 
@@ -105,16 +107,6 @@ func runSync() error {
 		return err
 	}
 
-	imagesRepoMode, err := common.GetImagesRepoMode(&commonCmdData)
-	if err != nil {
-		return err
-	}
-
-	imagesRepoManager, err := common.GetImagesRepoManager(imagesRepo, imagesRepoMode)
-	if err != nil {
-		return err
-	}
-
 	if _, err := common.GetStagesStorage(&commonCmdData); err != nil {
 		return err
 	}
@@ -129,6 +121,11 @@ func runSync() error {
 	}
 	logboek.Debug.LogF("Managed images names: %v\n", imagesNames)
 
+	imagesRepoManager, err := common.GetImagesRepoManagerByCmdData(projectName, imagesRepo, imagesNames, &commonCmdData)
+	if err != nil {
+		return err
+	}
+
 	stagesCleanupOptions := cleaning.StagesCleanupOptions{
 		ProjectName:       projectName,
 		ImagesRepoManager: imagesRepoManager,
@@ -0,0 +1,211 @@
+package ansible_galaxy
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/flant/logboek"
+	"gopkg.in/yaml.v2"
+
+	"github.com/flant/werf/pkg/logging"
+	"github.com/flant/werf/pkg/werf"
+)
+
+// Requirement is a role of requirements file, which is pinned by tarball checksum:
+//
+//   - name: geerlingguy.nginx
+//     src: https://github.com/geerlingguy/ansible-role-nginx/archive/2.8.0.tar.gz
+//     sha256: 5b4b9e3c...
+type Requirement struct {
+	Name   string `yaml:"name"`
+	Src    string `yaml:"src"`
+	Sha256 string `yaml:"sha256"`
+}
+
+func GetRolesCacheDir() string {
+	return filepath.Join(werf.GetLocalCacheDir(), "ansible_galaxy", "roles")
+}
+
+func ParseRequirements(data []byte) ([]*Requirement, error) {
+	var requirements []*Requirement
+	if err := yaml.UnmarshalStrict(data, &requirements); err != nil {
+		return nil, err
+	}
+
+	names := map[string]bool{}
+	for _, r := range requirements {
+		if err := r.validate(); err != nil {
+			return nil, err
+		}
+
+		if names[r.Name] {
+			return nil, fmt.Errorf("duplicate role %s", r.Name)
+		}
+		names[r.Name] = true
+	}
+
+	return requirements, nil
+}
+
+func (r *Requirement) validate() error {
+	if r.Name == "" {
+		return fmt.Errorf("role name required: %s", r.Src)
+	}
+
+	if u, err := url.Parse(r.Src); err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+		return fmt.Errorf("role %s: http or https tarball url src required", r.Name)
+	}
+
+	if len(r.Sha256) != sha256.Size*2 {
+		return fmt.Errorf("role %s: sha256 checksum of the tarball required", r.Name)
+	}
+
+	return nil
+}
+
+// Fetch returns role files by paths relative to the role dir,
+// the tarball is downloaded once and then used from the werf cache
+func (r *Requirement) Fetch() (map[string][]byte, map[string]os.FileMode, error) {
+	tarballPath := filepath.Join(GetRolesCacheDir(), fmt.Sprintf("%s.tar.gz", strings.ToLower(r.Sha256)))
+
+	if _, err := os.Stat(tarballPath); os.IsNotExist(err) {
+		if err := logging.LevelLogProcess(logboek.Info, fmt.Sprintf("Downloading role %s", r.Name), logboek.LevelLogProcessOptions{}, func() error {
+			return r.download(tarballPath)
+		}); err != nil {
+			return nil, nil, fmt.Errorf("unable to download role %s: %s", r.Name, err)
+		}
+	} else if err != nil {
+		return nil, nil, err
+	}
+
+	files, modes, err := readRoleTarball(tarballPath)
+	if err != nil {
+		return nil, nil, fmt.Errorf("unable to read role %s tarball %s: %s", r.Name, tarballPath, err)
+	}
+
+	return files, modes, nil
+}
+
+func (r *Requirement) download(tarballPath string) error {
+	if err := os.MkdirAll(filepath.Dir(tarballPath), os.ModePerm); err != nil {
+		return err
+	}
+
+	f, err := ioutil.TempFile(filepath.Dir(tarballPath), "tmp-")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+
+	client := &http.Client{Timeout: 10 * time.Minute}
+	resp, err := client.Get(r.Src)
+	if err != nil {
+		f.Close()
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		f.Close()
+		return fmt.Errorf("%s: unexpected status %s", r.Src, resp.Status)
+	}
+
+	hash := sha256.New()
+	if _, err := io.Copy(io.MultiWriter(f, hash), resp.Body); err != nil {
+		f.Close()
+		return err
+	}
+
+	if err := f.Close(); err != nil {
+		return err
+	}
+
+	if checksum := hex.EncodeToString(hash.Sum(nil)); checksum != strings.ToLower(r.Sha256) {
+		return fmt.Errorf("downloaded tarball checksum %s mismatch", checksum)
+	}
+
+	return os.Rename(f.Name(), tarballPath)
+}
+
+// readRoleTarball strips the single top-level directory of the archive, if any (e.g. github archives)
+func readRoleTarball(tarballPath string) (map[string][]byte, map[string]os.FileMode, error) {
+	f, err := os.Open(tarballPath)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer f.Close()
+
+	gzipReader, err := gzip.NewReader(f)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer gzipReader.Close()
+
+	files := map[string][]byte{}
+	modes := map[string]os.FileMode{}
+	tarReader := tar.NewReader(gzipReader)
+	for {
+		header, err := tarReader.Next()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, nil, err
+		}
+
+		if header.Typeflag != tar.TypeReg && header.Typeflag != tar.TypeRegA {
+			continue
+		}
+
+		name := path.Clean(strings.TrimPrefix(header.Name, "./"))
+		if name == ".." || strings.HasPrefix(name, "../") || path.IsAbs(name) {
+			return nil, nil, fmt.Errorf("bad file path %s", header.Name)
+		}
+
+		data, err := ioutil.ReadAll(tarReader)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		files[name] = data
+		modes[name] = header.FileInfo().Mode()
+	}
+
+	files, modes = stripTopLevelDir(files, modes)
+
+	return files, modes, nil
+}
+
+func stripTopLevelDir(files map[string][]byte, modes map[string]os.FileMode) (map[string][]byte, map[string]os.FileMode) {
+	var topLevelDir string
+	for name := range files {
+		parts := strings.SplitN(name, "/", 2)
+		if len(parts) == 1 || (topLevelDir != "" && parts[0] != topLevelDir) {
+			return files, modes
+		}
+		topLevelDir = parts[0]
+	}
+
+	resFiles := map[string][]byte{}
+	for name, data := range files {
+		resFiles[strings.TrimPrefix(name, topLevelDir+"/")] = data
+	}
+
+	resModes := map[string]os.FileMode{}
+	for name, mode := range modes {
+		resModes[strings.TrimPrefix(name, topLevelDir+"/")] = mode
+	}
+
+	return resFiles, resModes
+}
@@ -0,0 +1,67 @@
+package ansible_galaxy
+
+import (
+	"os"
+	"reflect"
+	"testing"
+)
+
+func TestParseRequirements(t *testing.T) {
+	sha := "5b4b9e3c4f5b8a0c2c3d7e9f1a2b3c4d5e6f7a8b9c0d1e2f3a4b5c6d7e8f9a0b"
+
+	tests := []struct {
+		name    string
+		data    string
+		wantErr bool
+	}{
+		{
+			name: "pinned tarball",
+			data: "- name: nginx\n  src: https://example.com/nginx.tar.gz\n  sha256: " + sha + "\n",
+		},
+		{
+			name:    "no checksum",
+			data:    "- name: nginx\n  src: https://example.com/nginx.tar.gz\n",
+			wantErr: true,
+		},
+		{
+			name:    "galaxy name src",
+			data:    "- name: nginx\n  src: geerlingguy.nginx\n  sha256: " + sha + "\n",
+			wantErr: true,
+		},
+		{
+			name:    "unknown field",
+			data:    "- name: nginx\n  src: https://example.com/nginx.tar.gz\n  sha256: " + sha + "\n  version: 1.0\n",
+			wantErr: true,
+		},
+		{
+			name:    "duplicate role",
+			data:    "- name: nginx\n  src: https://example.com/a.tar.gz\n  sha256: " + sha + "\n- name: nginx\n  src: https://example.com/b.tar.gz\n  sha256: " + sha + "\n",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseRequirements([]byte(tt.data))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("unexpected error: %v", err)
+			}
+		})
+	}
+}
+
+func TestStripTopLevelDir(t *testing.T) {
+	files := map[string][]byte{"role-1.0/tasks/main.yml": nil, "role-1.0/files/run.sh": nil}
+	modes := map[string]os.FileMode{"role-1.0/tasks/main.yml": 0644, "role-1.0/files/run.sh": 0755}
+	expectedFiles := map[string][]byte{"tasks/main.yml": nil, "files/run.sh": nil}
+	expectedModes := map[string]os.FileMode{"tasks/main.yml": 0644, "files/run.sh": 0755}
+	if resFiles, resModes := stripTopLevelDir(files, modes); !reflect.DeepEqual(resFiles, expectedFiles) || !reflect.DeepEqual(resModes, expectedModes) {
+		t.Fatalf("unexpected files: %v %v", resFiles, resModes)
+	}
+
+	files = map[string][]byte{"tasks/main.yml": nil, "README.md": nil}
+	modes = map[string]os.FileMode{"tasks/main.yml": 0644, "README.md": 0644}
+	if resFiles, resModes := stripTopLevelDir(files, modes); !reflect.DeepEqual(resFiles, files) || !reflect.DeepEqual(resModes, modes) {
+		t.Fatalf("unexpected files: %v %v", resFiles, resModes)
+	}
+}
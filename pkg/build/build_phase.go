@@ -16,6 +16,7 @@ import (
 	"github.com/flant/werf/pkg/build/stage"
 	"github.com/flant/werf/pkg/image"
 	imagePkg "github.com/flant/werf/pkg/image"
+	"github.com/flant/werf/pkg/logging"
 	"github.com/flant/werf/pkg/storage"
 	"github.com/flant/werf/pkg/util"
 	"github.com/flant/werf/pkg/werf"
@@ -26,14 +27,17 @@ const (
 )
 
 type BuildPhaseOptions struct {
-	SignaturesOnly    bool
-	ImageBuildOptions imagePkg.BuildOptions
-	IntrospectOptions IntrospectOptions
+	SignaturesOnly     bool
+	ImageBuildOptions  imagePkg.BuildOptions
+	IntrospectOptions  IntrospectOptions
+	SetSourceDateEpoch bool
 }
 
 type BuildStagesOptions struct {
 	ImageBuildOptions image.BuildOptions
 	IntrospectOptions
+	ReproducibilityOptions ReproducibilityOptions
+	ReportOptions          ReportOptions
 }
 
 type IntrospectOptions struct {
@@ -120,6 +124,15 @@ func (phase *BuildPhase) AfterImageStages(img *Image) error {
 	}
 	img.SetStagesSignature(stagesSig)
 
+	if !phase.SignaturesOnly {
+		imageReport := phase.Conveyor.GetReport().GetImageReport(img)
+		imageReport.StagesSignature = stagesSig
+		if lastStageImage := img.GetLastNonEmptyStage().GetImage(); lastStageImage.IsExists() {
+			imageReport.DockerImageName = lastStageImage.Name()
+			imageReport.DockerImageId = lastStageImage.ID()
+		}
+	}
+
 	return nil
 }
 
@@ -200,10 +213,19 @@ func (phase *BuildPhase) OnImageStage(img *Image, stg stage.Interface) (bool, er
 	if phase.SignaturesOnly {
 		return true, nil
 	}
-	if err := phase.prepareStage(img, stg); err != nil {
-		return false, err
+
+	isUsingCache := stg.GetImage().IsExists()
+	prevNonEmptyStageImageSize := phase.PrevNonEmptyStageImageSize
+	buildStartTime := time.Now()
+
+	err = phase.prepareStage(img, stg)
+	if err == nil {
+		err = phase.buildStage(img, stg)
 	}
-	if err := phase.buildStage(img, stg); err != nil {
+
+	phase.Conveyor.GetReport().GetImageReport(img).AddStage(stg, phase.Conveyor.StagesStorage.String(), isUsingCache, prevNonEmptyStageImageSize, time.Since(buildStartTime), err)
+
+	if err != nil {
 		return false, err
 	}
 
@@ -224,7 +246,7 @@ func calculateSignature(stageName, stageDependencies string, prevNonEmptyStage s
 	signature := util.Sha3_224Hash(checksumArgs...)
 
 	blockMsg := fmt.Sprintf("Stage %s signature %s", stageName, signature)
-	_ = logboek.Debug.LogBlock(blockMsg, logboek.LevelLogBlockOptions{}, func() error {
+	_ = logging.LevelLogBlock(logboek.Debug, blockMsg, logboek.LevelLogBlockOptions{}, func() error {
 		checksumArgsNames := []string{
 			"BuildCacheVersion",
 			"stageName",
@@ -323,7 +345,7 @@ func (phase *BuildPhase) selectSuitableStagesStorageImage(stg stage.Interface, i
 	}
 
 	var imgInfo *storage.ImageInfo
-	if err := logboek.Info.LogProcess(
+	if err := logging.LevelLogProcess(logboek.Info,
 		fmt.Sprintf("Selecting suitable image for stage %s by signature %s", stg.Name(), stg.GetSignature()),
 		logboek.LevelLogProcessOptions{},
 		func() error {
@@ -343,7 +365,7 @@ func (phase *BuildPhase) selectSuitableStagesStorageImage(stg stage.Interface, i
 		panic(err)
 	}
 
-	_ = logboek.Debug.LogBlock("Selected cache image", logboek.LevelLogBlockOptions{Style: logboek.HighlightStyle()}, func() error {
+	_ = logging.LevelLogBlock(logboek.Debug, "Selected cache image", logboek.LevelLogBlockOptions{Style: logboek.HighlightStyle()}, func() error {
 		logboek.Debug.LogF(string(imgInfoData))
 		return nil
 	})
@@ -351,7 +373,7 @@ func (phase *BuildPhase) selectSuitableStagesStorageImage(stg stage.Interface, i
 	i := phase.Conveyor.GetOrCreateStageImage(phase.PrevImage, imgInfo.ImageName)
 	stg.SetImage(i)
 
-	if err := logboek.Info.LogProcess(
+	if err := logging.LevelLogProcess(logboek.Info,
 		fmt.Sprintf("Sync stage %s signature %s image %s from stages storage", stg.Name(), stg.GetSignature(), i.Name()),
 		logboek.LevelLogProcessOptions{},
 		func() error {
@@ -371,7 +393,7 @@ func (phase *BuildPhase) getImagesBySignatureFromCache(stageName, stageSig strin
 	var cacheExists bool
 	var cacheImagesDescs []*storage.ImageInfo
 
-	err := logboek.Info.LogProcess(
+	err := logging.LevelLogProcess(logboek.Info,
 		fmt.Sprintf("Getting stage %s images by signature %s from stages storage cache", stageName, stageSig),
 		logboek.LevelLogProcessOptions{},
 		func() error {
@@ -395,7 +417,7 @@ func (phase *BuildPhase) atomicGetImagesBySignatureFromStagesStorageWithCacheRes
 
 	var originImagesDescs []*storage.ImageInfo
 	var err error
-	if err := logboek.Info.LogProcess(
+	if err := logging.LevelLogProcess(logboek.Info,
 		fmt.Sprintf("Getting stage %s images by signature %s from stages storage", stageName, stageSig),
 		logboek.LevelLogProcessOptions{},
 		func() error {
@@ -410,7 +432,7 @@ func (phase *BuildPhase) atomicGetImagesBySignatureFromStagesStorageWithCacheRes
 		return nil, err
 	}
 
-	if err := logboek.Info.LogProcess(
+	if err := logging.LevelLogProcess(logboek.Info,
 		fmt.Sprintf("Storing stage %s images by signature %s into stages storage cache", stageName, stageSig),
 		logboek.LevelLogProcessOptions{},
 		func() error {
@@ -432,7 +454,7 @@ func (phase *BuildPhase) atomicStoreStageCache(stageName, stageSig string, image
 	}
 	defer phase.Conveyor.StorageLockManager.UnlockStageCache(phase.Conveyor.projectName(), stageSig)
 
-	return logboek.Info.LogProcess(
+	return logging.LevelLogProcess(logboek.Info,
 		fmt.Sprintf("Storing stage %q images by signature %s into stages storage cache", stageName, stageSig),
 		logboek.LevelLogProcessOptions{},
 		func() error {
@@ -488,11 +510,7 @@ func (phase *BuildPhase) prepareStage(img *Image, stg stage.Interface) error {
 		imageServiceCommitChangeOptions := stageImage.Container().ServiceCommitChangeOptions()
 		imageServiceCommitChangeOptions.AddLabel(serviceLabels)
 
-		if phase.Conveyor.sshAuthSock != "" {
-			imageRunOptions := stageImage.Container().RunOptions()
-			imageRunOptions.AddVolume(fmt.Sprintf("%s:/.werf/tmp/ssh-auth-sock", phase.Conveyor.sshAuthSock))
-			imageRunOptions.AddEnv(map[string]string{"SSH_AUTH_SOCK": "/.werf/tmp/ssh-auth-sock"})
-		}
+		phase.Conveyor.prepareStageImageRunOptions(phase.Conveyor.GetStageImage(stageImage.Name()), phase.SetSourceDateEpoch)
 	}
 
 	err := stg.PrepareImage(phase.Conveyor, phase.PrevBuiltImage, stageImage)
@@ -542,7 +560,7 @@ func (phase *BuildPhase) buildStage(img *Image, stg stage.Interface) error {
 		logImageInfo(stg.GetImage(), phase.PrevNonEmptyStageImageSize, isUsingCache)
 	}
 
-	if err := logboek.Default.LogProcess(
+	if err := logging.LevelLogProcess(logboek.Default,
 		fmt.Sprintf("Building %s", stg.LogDetailedName()),
 		logboek.LevelLogProcessOptions{
 			InfoSectionFunc: infoSectionFunc,
@@ -591,7 +609,7 @@ func (phase *BuildPhase) atomicBuildStageImage(img *Image, stg stage.Interface)
 
 	if len(imagesDescs) > 0 {
 		var imgInfo *storage.ImageInfo
-		if err := logboek.Info.LogProcess(
+		if err := logging.LevelLogProcess(logboek.Info,
 			fmt.Sprintf("Selecting suitable image for stage %q by signature %s", stg.Name(), stg.GetSignature()),
 			logboek.LevelLogProcessOptions{},
 			func() error {
@@ -610,7 +628,7 @@ func (phase *BuildPhase) atomicBuildStageImage(img *Image, stg stage.Interface)
 			i := phase.Conveyor.GetOrCreateStageImage(phase.PrevImage, imgInfo.ImageName)
 			stg.SetImage(i)
 
-			if err := logboek.Info.LogProcess(
+			if err := logging.LevelLogProcess(logboek.Info,
 				fmt.Sprintf("Sync stage %q signature %s image %s from stages storage", stg.Name(), stg.GetSignature(), i.Name()),
 				logboek.LevelLogProcessOptions{},
 				func() error {
@@ -635,7 +653,7 @@ func (phase *BuildPhase) atomicBuildStageImage(img *Image, stg stage.Interface)
 	stageImageObj.SetName(newStageImageName)
 	phase.Conveyor.SetStageImage(stageImageObj)
 
-	if err := logboek.Info.LogProcess(
+	if err := logging.LevelLogProcess(logboek.Info,
 		fmt.Sprintf("Store stage %q signature %s image %s into stages storage", stageImage.Name(), stg.GetSignature(), stageImage.Name()),
 		logboek.LevelLogProcessOptions{},
 		func() error {
@@ -676,7 +694,7 @@ func (phase *BuildPhase) generateUniqStageImageName(signature string, imagesDesc
 }
 
 func introspectStage(s stage.Interface) error {
-	return logboek.Info.LogProcess(
+	return logging.LevelLogProcess(logboek.Info,
 		fmt.Sprintf("Introspecting stage %q", s.Name()),
 		logboek.LevelLogProcessOptions{Style: logboek.HighlightStyle()},
 		func() error {
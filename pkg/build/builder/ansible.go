@@ -28,6 +28,10 @@ type Ansible struct {
 type Extra struct {
 	ContainerWerfPath string
 	TmpPath           string
+	// ShellScriptsFiles by user stage names
+	ShellScriptsFiles map[string]*ShellScriptFile
+	AnsibleRoles      []*AnsibleRole
+	AnsibleLibrary    *AnsibleLibrary
 }
 
 func NewAnsibleBuilder(config *config.Ansible, extra *Extra) *Ansible {
@@ -128,6 +132,16 @@ func (b *Ansible) stageChecksum(userStageName string) string {
 		checksumArgs = append(checksumArgs, string(jsonOutput))
 	}
 
+	if len(checksumArgs) != 0 {
+		for _, role := range b.stageRoles(userStageName) {
+			checksumArgs = append(checksumArgs, role.Name, role.Checksum())
+		}
+
+		if b.extra.AnsibleLibrary != nil {
+			checksumArgs = append(checksumArgs, b.extra.AnsibleLibrary.Checksum())
+		}
+	}
+
 	if debugUserStageChecksum() {
 		logboek.Debug.LogFHighlight("DEBUG: %s stage tasks checksum dependencies %v\n", userStageName, checksumArgs)
 	}
@@ -37,6 +37,19 @@ func (b *Ansible) createStageWorkDirStructure(userStageName string) error {
 	// generate ansible config for solo mode
 	writeFile(filepath.Join(stageWorkDir, "ansible.cfg"), b.assetsAnsibleCfg())
 
+	// roles used by the stage and custom modules
+	for _, role := range b.stageRoles(userStageName) {
+		if err := writeFiles(filepath.Join(stageWorkDir, "roles", role.Name), role.Files, role.Modes); err != nil {
+			return err
+		}
+	}
+
+	if b.extra.AnsibleLibrary != nil {
+		if err := writeFiles(filepath.Join(stageWorkDir, "library"), b.extra.AnsibleLibrary.Files, b.extra.AnsibleLibrary.Modes); err != nil {
+			return err
+		}
+	}
+
 	// save config dump for pretty errors
 	stageConfig, err := b.stageConfig(userStageName)
 	if err != nil {
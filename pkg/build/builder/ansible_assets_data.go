@@ -29,7 +29,7 @@ force_color = 1
 module_compression = 'ZIP_STORED'
 local_tmp = %[3]s
 remote_tmp = %[4]s
-; keep ansiballz for debug
+%[6]s; keep ansiballz for debug
 ;keep_remote_files = 1
 [privilege_escalation]
 become = yes
@@ -37,7 +37,15 @@ become_method = sudo
 become_exe = %[5]s
 become_flags = -E -H`
 
-	return fmt.Sprintf(format, hostsPath, callbackPluginsPath, localTmpDirPath, remoteTmpDirPath, sudoBinPath)
+	var extraDefaults string
+	if len(b.extra.AnsibleRoles) != 0 {
+		extraDefaults += fmt.Sprintf("roles_path = %s\n", path.Join(b.containerWorkDir(), "roles"))
+	}
+	if b.extra.AnsibleLibrary != nil {
+		extraDefaults += fmt.Sprintf("library = %s\n", path.Join(b.containerWorkDir(), "library"))
+	}
+
+	return fmt.Sprintf(format, hostsPath, callbackPluginsPath, localTmpDirPath, remoteTmpDirPath, sudoBinPath, extraDefaults)
 }
 
 func (b *Ansible) assetsHosts() string {
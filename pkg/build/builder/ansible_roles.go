@@ -0,0 +1,257 @@
+package builder
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+
+	"github.com/flant/werf/pkg/util"
+)
+
+// AnsibleRole is a role from the project git repository or from the requirements roles cache
+type AnsibleRole struct {
+	Name string
+	// Files and Modes by paths relative to the role dir
+	Files map[string][]byte
+	Modes map[string]os.FileMode
+}
+
+func (r *AnsibleRole) Checksum() string {
+	return filesChecksum(r.Files, r.Modes)
+}
+
+// AnsibleLibrary is a custom modules library from the project git repository
+type AnsibleLibrary struct {
+	// Files and Modes by paths relative to the library dir
+	Files map[string][]byte
+	Modes map[string]os.FileMode
+}
+
+func (l *AnsibleLibrary) Checksum() string {
+	return filesChecksum(l.Files, l.Modes)
+}
+
+// ModulesNames returns names of the custom modules, which are the library files names without extensions
+func (l *AnsibleLibrary) ModulesNames() []string {
+	var names []string
+	for _, filePath := range sortedFilesPaths(l.Files) {
+		name := path.Base(filePath)
+		name = strings.TrimSuffix(name, path.Ext(name))
+		if name == "" || strings.HasPrefix(name, "_") || strings.HasPrefix(name, ".") || util.IsStringsContainValue(names, name) {
+			continue
+		}
+
+		names = append(names, name)
+	}
+
+	return names
+}
+
+// usedRolesNames returns roles included into the role tasks and the role meta dependencies
+func (r *AnsibleRole) usedRolesNames() ([]string, bool) {
+	var names []string
+	dynamic := false
+
+	for _, filePath := range sortedFilesPaths(r.Files) {
+		if path.Ext(filePath) != ".yml" && path.Ext(filePath) != ".yaml" {
+			continue
+		}
+
+		switch strings.SplitN(filePath, "/", 2)[0] {
+		case "tasks", "handlers":
+			var tasks []interface{}
+			if err := yaml.Unmarshal(r.Files[filePath], &tasks); err != nil {
+				continue
+			}
+
+			tasksRolesNames, tasksDynamic := tasksRolesNames(tasks)
+			names = append(names, tasksRolesNames...)
+			dynamic = dynamic || tasksDynamic
+		case "meta":
+			var meta struct {
+				Dependencies []interface{} `yaml:"dependencies"`
+			}
+			if err := yaml.Unmarshal(r.Files[filePath], &meta); err != nil {
+				continue
+			}
+
+			for _, dependency := range meta.Dependencies {
+				name := roleDependencyName(dependency)
+				if name == "" || isDynamicRoleName(name) {
+					dynamic = true
+				} else {
+					names = append(names, name)
+				}
+			}
+		}
+	}
+
+	return names, dynamic
+}
+
+// roleDependencyName: `- nginx`, `- role: nginx` or `- name: nginx`
+func roleDependencyName(dependency interface{}) string {
+	switch value := dependency.(type) {
+	case string:
+		return value
+	case map[interface{}]interface{}:
+		for _, key := range []string{"role", "name"} {
+			if name, ok := value[key].(string); ok {
+				return name
+			}
+		}
+	}
+
+	return ""
+}
+
+// tasksRolesNames returns names of roles of include_role and import_role tasks,
+// dynamic is true if some role name cannot be determined without templating
+func tasksRolesNames(tasks []interface{}) ([]string, bool) {
+	var names []string
+	dynamic := false
+
+	for _, task := range tasks {
+		taskMap, err := util.InterfaceToMapStringInterface(task)
+		if err != nil {
+			continue
+		}
+
+		for _, blockName := range []string{"block", "rescue", "always"} {
+			if blockTasks, ok := taskMap[blockName].([]interface{}); ok {
+				blockNames, blockDynamic := tasksRolesNames(blockTasks)
+				names = append(names, blockNames...)
+				dynamic = dynamic || blockDynamic
+			}
+		}
+
+		for _, module := range []string{"include_role", "import_role"} {
+			if _, ok := taskMap[module]; !ok {
+				continue
+			}
+
+			args, err := util.InterfaceToMapStringInterface(taskMap[module])
+			if err != nil {
+				dynamic = true
+				continue
+			}
+
+			name, ok := args["name"].(string)
+			if !ok || isDynamicRoleName(name) {
+				dynamic = true
+				continue
+			}
+
+			names = append(names, name)
+		}
+	}
+
+	return names, dynamic
+}
+
+func isDynamicRoleName(name string) bool {
+	return strings.Contains(name, "{{")
+}
+
+// stageRoles returns roles, which are used by the stage tasks, all roles if some of them cannot be determined
+func (b *Ansible) stageRoles(userStageName string) []*AnsibleRole {
+	if len(b.extra.AnsibleRoles) == 0 {
+		return nil
+	}
+
+	var tasks []interface{}
+	for _, task := range b.stageTasks(userStageName) {
+		tasks = append(tasks, task.Config)
+	}
+
+	names, dynamic := tasksRolesNames(tasks)
+	if dynamic {
+		return b.extra.AnsibleRoles
+	}
+
+	rolesByName := map[string]*AnsibleRole{}
+	for _, role := range b.extra.AnsibleRoles {
+		rolesByName[role.Name] = role
+	}
+
+	usedRoles := map[string]*AnsibleRole{}
+	for len(names) != 0 {
+		name := names[0]
+		names = names[1:]
+
+		role, ok := rolesByName[name]
+		if !ok || usedRoles[name] != nil {
+			continue
+		}
+		usedRoles[name] = role
+
+		roleNames, roleDynamic := role.usedRolesNames()
+		if roleDynamic {
+			return b.extra.AnsibleRoles
+		}
+		names = append(names, roleNames...)
+	}
+
+	var res []*AnsibleRole
+	for _, role := range b.extra.AnsibleRoles {
+		if usedRoles[role.Name] != nil {
+			res = append(res, role)
+		}
+	}
+
+	return res
+}
+
+// writeFiles writes files keeping executable bit of scripts and modules
+func writeFiles(dir string, files map[string][]byte, modes map[string]os.FileMode) error {
+	for filePath, data := range files {
+		p := filepath.Join(dir, filepath.FromSlash(filePath))
+		if err := mkdirP(filepath.Dir(p)); err != nil {
+			return err
+		}
+
+		if err := writeFile(p, string(data)); err != nil {
+			return fmt.Errorf("unable to write %s: %s", p, err)
+		}
+
+		if isExecutableFile(modes, filePath) {
+			if err := os.Chmod(p, os.FileMode(0775)); err != nil {
+				return fmt.Errorf("unable to chmod %s: %s", p, err)
+			}
+		}
+	}
+
+	return nil
+}
+
+func isExecutableFile(modes map[string]os.FileMode, filePath string) bool {
+	return modes[filePath]&0111 != 0
+}
+
+// filesChecksum depends on executable bit of files, checksum of non-executable files is the same as of their contents only
+func filesChecksum(files map[string][]byte, modes map[string]os.FileMode) string {
+	var args []string
+	for _, filePath := range sortedFilesPaths(files) {
+		args = append(args, filePath, string(files[filePath]))
+		if isExecutableFile(modes, filePath) {
+			args = append(args, "executable")
+		}
+	}
+
+	return util.Sha256Hash(args...)
+}
+
+func sortedFilesPaths(files map[string][]byte) []string {
+	var paths []string
+	for p := range files {
+		paths = append(paths, p)
+	}
+	sort.Strings(paths)
+
+	return paths
+}
@@ -0,0 +1,63 @@
+package builder
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestWriteFiles(t *testing.T) {
+	dir, err := ioutil.TempDir("", "werf-ansible-files-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	files := map[string][]byte{"tasks/main.yml": []byte("- shell: files/run.sh\n"), "files/run.sh": []byte("#!/bin/sh\n")}
+	modes := map[string]os.FileMode{"tasks/main.yml": 0644, "files/run.sh": 0755}
+	if err := writeFiles(dir, files, modes); err != nil {
+		t.Fatal(err)
+	}
+
+	for filePath, expectedExecutable := range map[string]bool{"tasks/main.yml": false, "files/run.sh": true} {
+		fi, err := os.Stat(filepath.Join(dir, filePath))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if executable := fi.Mode()&0100 != 0; executable != expectedExecutable {
+			t.Errorf("%s: unexpected mode %s", filePath, fi.Mode())
+		}
+	}
+}
+
+func TestFilesChecksum(t *testing.T) {
+	files := map[string][]byte{"custom.py": []byte("#!/usr/bin/python\n")}
+
+	checksum := filesChecksum(files, nil)
+	if filesChecksum(files, map[string]os.FileMode{"custom.py": 0644}) != checksum {
+		t.Errorf("checksum of non-executable files should not depend on modes")
+	}
+
+	if filesChecksum(files, map[string]os.FileMode{"custom.py": 0755}) == checksum {
+		t.Errorf("checksum should depend on executable bit")
+	}
+}
+
+func TestAnsibleLibrary_ModulesNames(t *testing.T) {
+	library := &AnsibleLibrary{Files: map[string][]byte{
+		"custom.py":         nil,
+		"cloud/deploy.ps1":  nil,
+		"binary_module":     nil,
+		"__init__.py":       nil,
+		".gitignore":        nil,
+		"another/custom.sh": nil,
+	}}
+
+	expected := []string{"custom", "binary_module", "deploy"}
+	if names := library.ModulesNames(); !reflect.DeepEqual(names, expected) {
+		t.Errorf("unexpected modules names: %v", names)
+	}
+}
@@ -2,10 +2,12 @@ package builder
 
 import (
 	"fmt"
+	"io/ioutil"
 	"path"
 	"path/filepath"
 	"strings"
 
+	"github.com/alessio/shellescape"
 	"gopkg.in/oleiade/reflections.v1"
 
 	"github.com/flant/logboek"
@@ -15,7 +17,17 @@ import (
 	"github.com/flant/werf/pkg/util"
 )
 
-const scriptFileName = "script.sh"
+const (
+	scriptFileName    = "script.sh"
+	userScriptDirName = "file"
+)
+
+// ShellScriptFile is the user stage script with the file content from the project git repository
+type ShellScriptFile struct {
+	*config.ShellScript
+	Content  []byte
+	BlobHash string
+}
 
 type Shell struct {
 	config *config.Shell
@@ -58,7 +70,17 @@ func (b *Shell) stage(userStageName string, container Container) error {
 	stageHostTmpScriptFilePath := filepath.Join(stageHostTmpDir, scriptFileName)
 	containerTmpScriptFilePath := path.Join(b.containerTmpDir(), scriptFileName)
 
-	if err := stapel.CreateScript(stageHostTmpScriptFilePath, b.stageCommands(userStageName)); err != nil {
+	commands := b.stageCommands(userStageName)
+	if scriptFile := b.stageScriptFile(userStageName); scriptFile != nil {
+		command, err := b.prepareStageScriptFile(scriptFile, stageHostTmpDir)
+		if err != nil {
+			return err
+		}
+
+		commands = append(commands, command)
+	}
+
+	if err := stapel.CreateScript(stageHostTmpScriptFilePath, commands); err != nil {
 		return err
 	}
 
@@ -71,6 +93,12 @@ func (b *Shell) stageChecksum(userStageName string) string {
 
 	checksumArgs = append(checksumArgs, b.stageCommands(userStageName)...)
 
+	if scriptFile := b.stageScriptFile(userStageName); scriptFile != nil {
+		// script content is tracked by git blob hash
+		checksumArgs = append(checksumArgs, scriptFile.BlobHash)
+		checksumArgs = append(checksumArgs, scriptFile.Args...)
+	}
+
 	if debugUserStageChecksum() {
 		logboek.Debug.LogFHighlight("DEBUG: %s stage tasks checksum dependencies %v\n", userStageName, checksumArgs)
 	}
@@ -129,6 +157,31 @@ func (b *Shell) stageCommands(userStageName string) []string {
 	return commands
 }
 
+func (b *Shell) stageScriptFile(userStageName string) *ShellScriptFile {
+	return b.extra.ShellScriptsFiles[userStageName]
+}
+
+// prepareStageScriptFile writes the script into the stage tmp dir, which is mounted into the container, and returns the command to run it
+func (b *Shell) prepareStageScriptFile(scriptFile *ShellScriptFile, stageHostTmpDir string) (string, error) {
+	fileName := path.Base(scriptFile.File)
+
+	hostScriptDir := filepath.Join(stageHostTmpDir, userScriptDirName)
+	if err := mkdirP(hostScriptDir); err != nil {
+		return "", err
+	}
+
+	if err := ioutil.WriteFile(filepath.Join(hostScriptDir, fileName), scriptFile.Content, 0755); err != nil {
+		return "", fmt.Errorf("unable to write script %s: %s", scriptFile.File, err)
+	}
+
+	commandParts := []string{shellescape.Quote(path.Join(b.containerTmpDir(), userScriptDirName, fileName))}
+	for _, arg := range scriptFile.Args {
+		commandParts = append(commandParts, shellescape.Quote(arg))
+	}
+
+	return strings.Join(commandParts, " "), nil
+}
+
 func (b *Shell) configFieldValue(fieldName string) interface{} {
 	value, err := reflections.GetField(b.config, fieldName)
 	if err != nil {
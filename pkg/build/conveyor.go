@@ -20,16 +20,20 @@ import (
 
 	"github.com/flant/logboek"
 
+	"github.com/flant/werf/pkg/ansible_galaxy"
+	"github.com/flant/werf/pkg/build/builder"
 	"github.com/flant/werf/pkg/build/import_server"
 	"github.com/flant/werf/pkg/build/stage"
 	"github.com/flant/werf/pkg/config"
 	"github.com/flant/werf/pkg/git_repo"
 	"github.com/flant/werf/pkg/image"
+	"github.com/flant/werf/pkg/image_signing"
 	"github.com/flant/werf/pkg/images_manager"
 	"github.com/flant/werf/pkg/logging"
 	"github.com/flant/werf/pkg/path_matcher"
 	"github.com/flant/werf/pkg/storage"
 	"github.com/flant/werf/pkg/tag_strategy"
+	"github.com/flant/werf/pkg/tracing"
 	"github.com/flant/werf/pkg/util"
 	"github.com/flant/werf/pkg/werf"
 )
@@ -65,6 +69,8 @@ type Conveyor struct {
 
 	onTerminateFuncs []func() error
 	importServers    map[string]import_server.ImportServer
+
+	report *Report
 }
 
 func NewConveyor(werfConfig *config.WerfConfig, imageNamesToProcess []string, projectDir, baseTmpDir, sshAuthSock string) *Conveyor {
@@ -88,6 +94,7 @@ func NewConveyor(werfConfig *config.WerfConfig, imageNamesToProcess []string, pr
 		remoteGitRepos:                  make(map[string]*git_repo.Remote),
 		tmpDir:                          filepath.Join(baseTmpDir, string(util.GenerateConsistentRandomString(10))),
 		importServers:                   make(map[string]import_server.ImportServer),
+		report:                          NewReport(),
 
 		StagesStorage:      &storage.LocalStagesStorage{},
 		StorageLockManager: &storage.FileLockManager{},
@@ -104,7 +111,7 @@ func (c *Conveyor) GetImportServer(imageName string) (import_server.ImportServer
 
 	var srv *import_server.RsyncServer
 
-	if err := logboek.Info.LogProcess(fmt.Sprintf("Firing up import rsync server for image %s", imageName), logboek.LevelLogProcessOptions{}, func() error {
+	if err := logging.LevelLogProcess(logboek.Info, fmt.Sprintf("Firing up import rsync server for image %s", imageName), logboek.LevelLogProcessOptions{}, func() error {
 		tmpDir := path.Join(c.tmpDir, "import-server", imageName)
 		if err := os.MkdirAll(tmpDir, os.ModePerm); err != nil {
 			return fmt.Errorf("unable to create dir %s: %s", tmpDir, err)
@@ -134,6 +141,10 @@ func (c *Conveyor) GetImportServer(imageName string) (import_server.ImportServer
 	return srv, nil
 }
 
+func (c *Conveyor) GetReport() *Report {
+	return c.report
+}
+
 func (c *Conveyor) AppendOnTerminateFunc(f func() error) {
 	c.onTerminateFuncs = append(c.onTerminateFuncs, f)
 }
@@ -193,14 +204,15 @@ type TagOptions struct {
 	TagsByGitTag         []string
 	TagsByGitBranch      []string
 	TagsByGitCommit      []string
+	TagsBySemver         []string
 	TagByStagesSignature bool
 }
 
 type ImagesRepoManager interface {
 	ImagesRepo() string
-	ImageRepo(imageName string) string
+	ImageRepo(imageName string) (string, error)
 	ImageRepoTag(imageName, tag string) string
-	ImageRepoWithTag(imageName, tag string) string
+	ImageRepoWithTag(imageName, tag string) (string, error)
 }
 
 func (c *Conveyor) ShouldBeBuilt() error {
@@ -266,16 +278,23 @@ func (c *Conveyor) BuildStages(stageRepo string, opts BuildStagesOptions) error
 	}
 
 	phases := []Phase{NewBuildPhase(c, BuildPhaseOptions{
-		IntrospectOptions: opts.IntrospectOptions,
-		ImageBuildOptions: opts.ImageBuildOptions,
+		IntrospectOptions:  opts.IntrospectOptions,
+		ImageBuildOptions:  opts.ImageBuildOptions,
+		SetSourceDateEpoch: opts.ReproducibilityOptions.SetSourceDateEpoch,
 	})}
 
-	return c.runPhases(phases, true)
+	if opts.ReproducibilityOptions.Verify {
+		phases = append(phases, NewVerifyReproducibilityPhase(c, opts.ReproducibilityOptions))
+	}
+
+	return c.runPhasesAndWriteReport(phases, opts.ReportOptions)
 }
 
 type PublishImagesOptions struct {
 	ImagesToPublish []string
 	TagOptions
+	Signer            *image_signing.Signer
+	DaemonlessPublish bool
 }
 
 func (c *Conveyor) PublishImages(imagesRepoManager ImagesRepoManager, opts PublishImagesOptions) error {
@@ -319,12 +338,19 @@ func (c *Conveyor) BuildAndPublish(stagesRepo string, imagesRepoManager ImagesRe
 	}
 
 	phases := []Phase{
-		NewBuildPhase(c, BuildPhaseOptions{ImageBuildOptions: opts.ImageBuildOptions, IntrospectOptions: opts.IntrospectOptions}),
+		NewBuildPhase(c, BuildPhaseOptions{ImageBuildOptions: opts.ImageBuildOptions, IntrospectOptions: opts.IntrospectOptions, SetSourceDateEpoch: opts.ReproducibilityOptions.SetSourceDateEpoch}),
+	}
+
+	if opts.ReproducibilityOptions.Verify {
+		phases = append(phases, NewVerifyReproducibilityPhase(c, opts.ReproducibilityOptions))
+	}
+
+	phases = append(phases,
 		NewShouldBeBuiltPhase(c),
 		NewPublishImagesPhase(c, imagesRepoManager, opts.PublishImagesOptions),
-	}
+	)
 
-	return c.runPhases(phases, true)
+	return c.runPhasesAndWriteReport(phases, opts.ReportOptions)
 
 	/*
 		var phases []Phase
@@ -344,7 +370,7 @@ func (c *Conveyor) BuildAndPublish(stagesRepo string, imagesRepoManager ImagesRe
 }
 
 func (c *Conveyor) determineStages() error {
-	return logboek.Info.LogProcess(
+	return logging.LevelLogProcess(logboek.Info,
 		"Determining of stages",
 		logboek.LevelLogProcessOptions{Style: logboek.HighlightStyle()},
 		func() error {
@@ -369,7 +395,7 @@ func (c *Conveyor) doDetermineStages() error {
 			style = ImageLogProcessStyle(false)
 		}
 
-		err := logboek.Info.LogProcess(imageLogName, logboek.LevelLogProcessOptions{Style: style}, func() error {
+		err := logging.LevelLogProcess(logboek.Info, imageLogName, logboek.LevelLogProcessOptions{Style: style}, func() error {
 			var err error
 
 			switch imageConfig := imageInterfaceConfig.(type) {
@@ -440,55 +466,38 @@ func (c *Conveyor) runPhases(phases []Phase, logImages bool) error {
 	}
 
 	for _, img := range c.imagesInOrder {
-		if err := imagesLogger.LogProcess(img.LogDetailedName(), logboek.LevelLogProcessOptions{Style: img.LogProcessStyle()}, func() error {
-			for _, phase := range phases {
-				logProcessMsg := fmt.Sprintf("Phase %s -- BeforeImageStages()", phase.Name())
-				logboek.Debug.LogProcessStart(logProcessMsg, logboek.LevelLogProcessStartOptions{})
-				if err := phase.BeforeImageStages(img); err != nil {
-					logboek.Debug.LogProcessFail(logboek.LevelLogProcessFailOptions{})
-					return fmt.Errorf("phase %s before image %s stages handler failed: %s", phase.Name(), img.GetLogName(), err)
-				}
-				logboek.Debug.LogProcessEnd(logboek.LevelLogProcessEndOptions{})
-
-				logProcessMsg = fmt.Sprintf("Phase %s -- OnImageStage()", phase.Name())
-				logboek.Debug.LogProcessStart(logProcessMsg, logboek.LevelLogProcessStartOptions{})
-				var newStages []stage.Interface
-				for _, stg := range img.GetStages() {
-					if keepStage, err := phase.OnImageStage(img, stg); err != nil {
-						logboek.Debug.LogProcessFail(logboek.LevelLogProcessFailOptions{})
-						return fmt.Errorf("phase %s on image %s stage %s handler failed: %s", phase.Name(), img.GetLogName(), stg.Name(), err)
-					} else if keepStage {
-						newStages = append(newStages, stg)
+		imageSpan := tracing.StartSpan(fmt.Sprintf("image %s", img.GetLogName()), map[string]string{"werf.image.name": img.GetName()})
+		err := logging.WithContext(logging.Context{Image: img.GetLogName()}, func() error {
+			return logging.LevelLogProcess(imagesLogger, img.LogDetailedName(), logboek.LevelLogProcessOptions{Style: img.LogProcessStyle()}, func() error {
+				for _, phase := range phases {
+					var shouldBeStopped bool
+					if err := tracing.WithSpan(fmt.Sprintf("phase %s", phase.Name()), nil, func() error {
+						return logging.WithContext(logging.Context{Phase: phase.Name()}, func() error {
+							var err error
+							shouldBeStopped, err = c.runImagePhase(img, phase)
+							return err
+						})
+					}); err != nil {
+						return err
 					}
-				}
-				img.SetStages(newStages)
-				logboek.Debug.LogProcessEnd(logboek.LevelLogProcessEndOptions{})
-
-				logProcessMsg = fmt.Sprintf("Phase %s -- AfterImageStages()", phase.Name())
-				logboek.Debug.LogProcessStart(logProcessMsg, logboek.LevelLogProcessStartOptions{})
-				if err := phase.AfterImageStages(img); err != nil {
-					logboek.Debug.LogProcessFail(logboek.LevelLogProcessFailOptions{})
-					return fmt.Errorf("phase %s after image %s stages handler failed: %s", phase.Name(), img.GetLogName(), err)
-				}
-				logboek.Debug.LogProcessEnd(logboek.LevelLogProcessEndOptions{})
 
-				logProcessMsg = fmt.Sprintf("Phase %s -- ImageProcessingShouldBeStopped()", phase.Name())
-				logboek.Debug.LogProcessStart(logProcessMsg, logboek.LevelLogProcessStartOptions{})
-				if phase.ImageProcessingShouldBeStopped(img) {
-					logboek.Debug.LogProcessEnd(logboek.LevelLogProcessEndOptions{})
-					return nil
+					if shouldBeStopped {
+						return nil
+					}
 				}
-				logboek.Debug.LogProcessEnd(logboek.LevelLogProcessEndOptions{})
-			}
 
-			return nil
-		}); err != nil {
+				return nil
+			})
+		})
+		imageSpan.End(err)
+
+		if err != nil {
 			return err
 		}
 	}
 
 	for _, phase := range phases {
-		if err := logboek.Debug.LogProcess(fmt.Sprintf("Phase %s -- AfterImages()", phase.Name()), logboek.LevelLogProcessOptions{}, func() error {
+		if err := logging.LevelLogProcess(logboek.Debug, fmt.Sprintf("Phase %s -- AfterImages()", phase.Name()), logboek.LevelLogProcessOptions{}, func() error {
 			if err := phase.AfterImages(); err != nil {
 				return fmt.Errorf("phase %s after images handler failed: %s", phase.Name(), err)
 			}
@@ -502,6 +511,75 @@ func (c *Conveyor) runPhases(phases []Phase, logImages bool) error {
 	return nil
 }
 
+// runPhasesAndWriteReport writes the build report even if phases failed: report points to the failed stage
+func (c *Conveyor) runPhasesAndWriteReport(phases []Phase, opts ReportOptions) error {
+	runErr := c.runPhases(phases, true)
+	if opts.Path == "" {
+		return runErr
+	}
+
+	c.report.finish(runErr)
+	if err := c.report.Write(opts.Path, opts.Format); err != nil {
+		if runErr != nil {
+			logboek.LogErrorF("%s\n", err)
+			return runErr
+		}
+
+		return err
+	}
+
+	return runErr
+}
+
+func (c *Conveyor) runImagePhase(img *Image, phase Phase) (bool, error) {
+	logProcessMsg := fmt.Sprintf("Phase %s -- BeforeImageStages()", phase.Name())
+	logboek.Debug.LogProcessStart(logProcessMsg, logboek.LevelLogProcessStartOptions{})
+	if err := phase.BeforeImageStages(img); err != nil {
+		logboek.Debug.LogProcessFail(logboek.LevelLogProcessFailOptions{})
+		return false, fmt.Errorf("phase %s before image %s stages handler failed: %s", phase.Name(), img.GetLogName(), err)
+	}
+	logboek.Debug.LogProcessEnd(logboek.LevelLogProcessEndOptions{})
+
+	logProcessMsg = fmt.Sprintf("Phase %s -- OnImageStage()", phase.Name())
+	logboek.Debug.LogProcessStart(logProcessMsg, logboek.LevelLogProcessStartOptions{})
+	var newStages []stage.Interface
+	for _, stg := range img.GetStages() {
+		stageSpan := tracing.StartSpan(fmt.Sprintf("stage %s", stg.Name()), map[string]string{"werf.phase": phase.Name()})
+		var keepStage bool
+		err := logging.WithContext(logging.Context{Stage: string(stg.Name())}, func() error {
+			var err error
+			keepStage, err = phase.OnImageStage(img, stg)
+			return err
+		})
+		stageSpan.SetAttribute("werf.stage.signature", stg.GetSignature())
+		stageSpan.End(err)
+
+		if err != nil {
+			logboek.Debug.LogProcessFail(logboek.LevelLogProcessFailOptions{})
+			return false, fmt.Errorf("phase %s on image %s stage %s handler failed: %s", phase.Name(), img.GetLogName(), stg.Name(), err)
+		} else if keepStage {
+			newStages = append(newStages, stg)
+		}
+	}
+	img.SetStages(newStages)
+	logboek.Debug.LogProcessEnd(logboek.LevelLogProcessEndOptions{})
+
+	logProcessMsg = fmt.Sprintf("Phase %s -- AfterImageStages()", phase.Name())
+	logboek.Debug.LogProcessStart(logProcessMsg, logboek.LevelLogProcessStartOptions{})
+	if err := phase.AfterImageStages(img); err != nil {
+		logboek.Debug.LogProcessFail(logboek.LevelLogProcessFailOptions{})
+		return false, fmt.Errorf("phase %s after image %s stages handler failed: %s", phase.Name(), img.GetLogName(), err)
+	}
+	logboek.Debug.LogProcessEnd(logboek.LevelLogProcessEndOptions{})
+
+	logProcessMsg = fmt.Sprintf("Phase %s -- ImageProcessingShouldBeStopped()", phase.Name())
+	logboek.Debug.LogProcessStart(logProcessMsg, logboek.LevelLogProcessStartOptions{})
+	shouldBeStopped := phase.ImageProcessingShouldBeStopped(img)
+	logboek.Debug.LogProcessEnd(logboek.LevelLogProcessEndOptions{})
+
+	return shouldBeStopped, nil
+}
+
 /*
 TODO: locks and log
 func (c *Conveyor) runPhases(phases []Phase) error {
@@ -545,6 +623,23 @@ func (c *Conveyor) GetOrCreateStageImage(fromImage *image.StageImage, name strin
 	return img
 }
 
+// prepareStageImageRunOptions adds common run options of stapel stage container,
+// SOURCE_DATE_EPOCH is the creation time of the stage base image to keep the stage timestamps stable between runners
+func (c *Conveyor) prepareStageImageRunOptions(stageImage *image.StageImage, setSourceDateEpoch bool) {
+	imageRunOptions := stageImage.Container().RunOptions()
+
+	if c.sshAuthSock != "" {
+		imageRunOptions.AddVolume(fmt.Sprintf("%s:/.werf/tmp/ssh-auth-sock", c.sshAuthSock))
+		imageRunOptions.AddEnv(map[string]string{"SSH_AUTH_SOCK": "/.werf/tmp/ssh-auth-sock"})
+	}
+
+	if setSourceDateEpoch {
+		if fromImage := stageImage.FromImage(); fromImage != nil && fromImage.IsExists() {
+			imageRunOptions.AddEnv(map[string]string{"SOURCE_DATE_EPOCH": strconv.FormatInt(fromImage.CreatedAtUnixNano()/1e9, 10)})
+		}
+	}
+}
+
 // imagesBySignature needed only for Build phase to detect that image object has already been prepared
 // with build instructions. Image should never be prepared multiple times.
 func (c *Conveyor) GetImageBySignature(signature string) image.ImageInterface {
@@ -651,25 +746,7 @@ func getFromFields(imageBaseConfig *config.StapelImageBase) (string, string, boo
 }
 
 func getImageConfigsInOrder(c *Conveyor) []config.ImageInterface {
-	var images []config.ImageInterface
-	for _, imageInterf := range getImageConfigsToProcess(c) {
-		var imagesInBuildOrder []config.ImageInterface
-
-		switch image := imageInterf.(type) {
-		case *config.StapelImage:
-			imagesInBuildOrder = c.werfConfig.ImageTree(image)
-		case *config.ImageFromDockerfile:
-			imagesInBuildOrder = append(imagesInBuildOrder, image)
-		}
-
-		for i := 0; i < len(imagesInBuildOrder); i++ {
-			if isNotInArr(images, imagesInBuildOrder[i]) {
-				images = append(images, imagesInBuildOrder[i])
-			}
-		}
-	}
-
-	return images
+	return c.werfConfig.ImagesInBuildOrder(getImageConfigsToProcess(c))
 }
 
 func getImageConfigsToProcess(c *Conveyor) []config.ImageInterface {
@@ -691,16 +768,6 @@ func getImageConfigsToProcess(c *Conveyor) []config.ImageInterface {
 	return imageConfigsToProcess
 }
 
-func isNotInArr(arr []config.ImageInterface, obj config.ImageInterface) bool {
-	for _, elm := range arr {
-		if reflect.DeepEqual(elm, obj) {
-			return false
-		}
-	}
-
-	return true
-}
-
 func initStages(image *Image, imageInterfaceConfig config.StapelImageInterface, c *Conveyor) error {
 	var stages []stage.Interface
 
@@ -708,12 +775,26 @@ func initStages(image *Image, imageInterfaceConfig config.StapelImageInterface,
 	imageName := imageBaseConfig.Name
 	imageArtifact := imageInterfaceConfig.IsArtifact()
 
+	shellScriptsFiles, err := getShellScriptsFiles(imageBaseConfig, c)
+	if err != nil {
+		return err
+	}
+
+	ansibleRoles, ansibleLibrary, err := getAnsibleRolesAndLibrary(imageBaseConfig, c)
+	if err != nil {
+		return err
+	}
+
 	baseStageOptions := &stage.NewBaseStageOptions{
-		ImageName:        imageName,
-		ConfigMounts:     imageBaseConfig.Mount,
-		ImageTmpDir:      c.GetImageTmpDir(imageBaseConfig.Name),
-		ContainerWerfDir: c.containerWerfDir,
-		ProjectName:      c.werfConfig.Meta.Project,
+		ImageName:         imageName,
+		ConfigMounts:      imageBaseConfig.Mount,
+		StageContainer:    imageBaseConfig.StageContainer,
+		ImageTmpDir:       c.GetImageTmpDir(imageBaseConfig.Name),
+		ContainerWerfDir:  c.containerWerfDir,
+		ProjectName:       c.werfConfig.Meta.Project,
+		ShellScriptsFiles: shellScriptsFiles,
+		AnsibleRoles:      ansibleRoles,
+		AnsibleLibrary:    ansibleLibrary,
 	}
 
 	gitArchiveStageOptions := &stage.NewGitArchiveStageOptions{
@@ -776,6 +857,146 @@ func initStages(image *Image, imageInterfaceConfig config.StapelImageInterface,
 	return nil
 }
 
+// getShellScriptsFiles reads user stages scripts from HEAD commit of the project git repository
+func getShellScriptsFiles(imageBaseConfig *config.StapelImageBase, c *Conveyor) (map[string]*builder.ShellScriptFile, error) {
+	if imageBaseConfig.Shell == nil {
+		return nil, nil
+	}
+
+	scripts := imageBaseConfig.Shell.Scripts()
+	if len(scripts) == 0 {
+		return nil, nil
+	}
+
+	localGitRepo, headCommit, err := getProjectGitRepoHeadCommit(c, "shell script file")
+	if err != nil {
+		return nil, err
+	}
+
+	shellScriptsFiles := map[string]*builder.ShellScriptFile{}
+	for userStageName, script := range scripts {
+		content, blobHash, err := localGitRepo.ReadCommitFile(headCommit, script.File)
+		if err != nil {
+			return nil, fmt.Errorf("unable to read shell script file %s: %s", script.File, err)
+		}
+
+		shellScriptsFiles[userStageName] = &builder.ShellScriptFile{
+			ShellScript: script,
+			Content:     content,
+			BlobHash:    blobHash,
+		}
+	}
+
+	return shellScriptsFiles, nil
+}
+
+// getAnsibleRolesAndLibrary reads roles and custom modules from HEAD commit of the project git repository
+// and requirements roles from the roles cache
+func getAnsibleRolesAndLibrary(imageBaseConfig *config.StapelImageBase, c *Conveyor) ([]*builder.AnsibleRole, *builder.AnsibleLibrary, error) {
+	ansibleConfig := imageBaseConfig.Ansible
+	if ansibleConfig == nil || (len(ansibleConfig.Roles) == 0 && ansibleConfig.Requirements == "" && ansibleConfig.Library == "") {
+		return nil, nil, nil
+	}
+
+	localGitRepo, headCommit, err := getProjectGitRepoHeadCommit(c, "ansible roles or library")
+	if err != nil {
+		return nil, nil, err
+	}
+
+	allFiles := func(string) bool { return true }
+
+	var roles []*builder.AnsibleRole
+	for _, rolePath := range ansibleConfig.Roles {
+		files, err := localGitRepo.ReadCommitFiles(headCommit, rolePath, allFiles)
+		if err != nil {
+			return nil, nil, fmt.Errorf("unable to read ansible role %s: %s", rolePath, err)
+		}
+
+		if len(files) == 0 {
+			return nil, nil, fmt.Errorf("ansible role %s is empty", rolePath)
+		}
+
+		modes, err := localGitRepo.ReadCommitFilesModes(headCommit, rolePath, allFiles)
+		if err != nil {
+			return nil, nil, fmt.Errorf("unable to read ansible role %s: %s", rolePath, err)
+		}
+
+		roles = append(roles, &builder.AnsibleRole{Name: path.Base(rolePath), Files: files, Modes: modes})
+	}
+
+	if ansibleConfig.Requirements != "" {
+		data, _, err := localGitRepo.ReadCommitFile(headCommit, ansibleConfig.Requirements)
+		if err != nil {
+			return nil, nil, fmt.Errorf("unable to read ansible requirements %s: %s", ansibleConfig.Requirements, err)
+		}
+
+		requirements, err := ansible_galaxy.ParseRequirements(data)
+		if err != nil {
+			return nil, nil, fmt.Errorf("bad ansible requirements %s: %s", ansibleConfig.Requirements, err)
+		}
+
+		for _, requirement := range requirements {
+			for _, role := range roles {
+				if role.Name == requirement.Name {
+					return nil, nil, fmt.Errorf("ansible role %s is defined both in roles and in requirements %s", role.Name, ansibleConfig.Requirements)
+				}
+			}
+
+			files, modes, err := requirement.Fetch()
+			if err != nil {
+				return nil, nil, err
+			}
+
+			roles = append(roles, &builder.AnsibleRole{Name: requirement.Name, Files: files, Modes: modes})
+		}
+	}
+
+	var library *builder.AnsibleLibrary
+	if ansibleConfig.Library != "" {
+		files, err := localGitRepo.ReadCommitFiles(headCommit, ansibleConfig.Library, allFiles)
+		if err != nil {
+			return nil, nil, fmt.Errorf("unable to read ansible library %s: %s", ansibleConfig.Library, err)
+		}
+
+		modes, err := localGitRepo.ReadCommitFilesModes(headCommit, ansibleConfig.Library, allFiles)
+		if err != nil {
+			return nil, nil, fmt.Errorf("unable to read ansible library %s: %s", ansibleConfig.Library, err)
+		}
+
+		library = &builder.AnsibleLibrary{Files: files, Modes: modes}
+
+		// tasks with custom modules can be validated only with the library files
+		if err := ansibleConfig.ValidateLibraryModules(library.ModulesNames()); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	return roles, library, nil
+}
+
+func getProjectGitRepoHeadCommit(c *Conveyor, usage string) (*git_repo.Local, string, error) {
+	if c.GetLocalGitRepo() == nil {
+		localGitRepo, err := git_repo.OpenLocalRepo("own", c.projectDir)
+		if err != nil {
+			return nil, "", fmt.Errorf("unable to open local repo %s: %s", c.projectDir, err)
+		}
+
+		if localGitRepo == nil {
+			return nil, "", fmt.Errorf("%s is used but project git repository is not found", usage)
+		}
+
+		c.SetLocalGitRepo(localGitRepo)
+	}
+
+	localGitRepo := c.GetLocalGitRepo()
+	headCommit, err := localGitRepo.HeadCommit()
+	if err != nil {
+		return nil, "", err
+	}
+
+	return localGitRepo, headCommit, nil
+}
+
 func generateGitMappings(imageBaseConfig *config.StapelImageBase, c *Conveyor) ([]*stage.GitMapping, error) {
 	var gitMappings []*stage.GitMapping
 
@@ -800,20 +1021,37 @@ func generateGitMappings(imageBaseConfig *config.StapelImageBase, c *Conveyor) (
 	}
 
 	for _, remoteGitMappingConfig := range imageBaseConfig.Git.Remote {
-		remoteGitRepo, exist := c.remoteGitRepos[remoteGitMappingConfig.Name]
+		remoteGitRepoKey := remoteGitRepoCacheKey(remoteGitMappingConfig)
+		remoteGitRepo, exist := c.remoteGitRepos[remoteGitRepoKey]
 		if !exist {
 			remoteGitRepo = &git_repo.Remote{
-				Base: git_repo.Base{Name: remoteGitMappingConfig.Name},
-				Url:  remoteGitMappingConfig.Url,
+				Base:        git_repo.Base{Name: remoteGitMappingConfig.Name},
+				Url:         remoteGitMappingConfig.Url,
+				SSHAuthSock: c.sshAuthSock,
+			}
+
+			if auth := remoteGitMappingConfig.Auth; auth != nil {
+				remoteGitRepo.Auth = &git_repo.RemoteAuth{
+					Username:    auth.Username,
+					PasswordEnv: auth.PasswordEnv,
+					KnownHosts:  auth.KnownHosts,
+				}
 			}
 
-			if err := logboek.Info.LogProcess(fmt.Sprintf("Refreshing %s repository", remoteGitMappingConfig.Name), logboek.LevelLogProcessOptions{}, func() error {
+			if clone := remoteGitMappingConfig.Clone; clone != nil {
+				remoteGitRepo.CloneOptions = &git_repo.RemoteCloneOptions{
+					Depth:  clone.Depth,
+					Filter: clone.Filter,
+				}
+			}
+
+			if err := logging.LevelLogProcess(logboek.Info, fmt.Sprintf("Refreshing %s repository", remoteGitMappingConfig.Name), logboek.LevelLogProcessOptions{}, func() error {
 				return remoteGitRepo.CloneAndFetch()
 			}); err != nil {
 				return nil, err
 			}
 
-			c.remoteGitRepos[remoteGitMappingConfig.Name] = remoteGitRepo
+			c.remoteGitRepos[remoteGitRepoKey] = remoteGitRepo
 		}
 
 		gitMappings = append(gitMappings, gitRemoteArtifactInit(remoteGitMappingConfig, remoteGitRepo, imageBaseConfig.Name, c))
@@ -822,7 +1060,7 @@ func generateGitMappings(imageBaseConfig *config.StapelImageBase, c *Conveyor) (
 	var res []*stage.GitMapping
 
 	if len(gitMappings) != 0 {
-		err := logboek.Info.LogProcess(fmt.Sprintf("Initializing git mappings"), logboek.LevelLogProcessOptions{}, func() error {
+		err := logging.LevelLogProcess(logboek.Info, fmt.Sprintf("Initializing git mappings"), logboek.LevelLogProcessOptions{}, func() error {
 			resGitMappings, err := filterAndLogGitMappings(gitMappings)
 			if err != nil {
 				return err
@@ -841,11 +1079,26 @@ func generateGitMappings(imageBaseConfig *config.StapelImageBase, c *Conveyor) (
 	return res, nil
 }
 
+// remoteGitRepoCacheKey allows to share remote git repo between git mappings with the same url, credentials and clone options
+func remoteGitRepoCacheKey(remoteGitMappingConfig *config.GitRemote) string {
+	key := remoteGitMappingConfig.Url
+
+	if auth := remoteGitMappingConfig.Auth; auth != nil {
+		key += fmt.Sprintf(" auth:%q:%q:%q", auth.Username, auth.PasswordEnv, auth.KnownHosts)
+	}
+
+	if clone := remoteGitMappingConfig.Clone; clone != nil {
+		key += fmt.Sprintf(" clone:%d:%q", clone.Depth, clone.Filter)
+	}
+
+	return key
+}
+
 func filterAndLogGitMappings(gitMappings []*stage.GitMapping) ([]*stage.GitMapping, error) {
 	var res []*stage.GitMapping
 
 	for ind, gitMapping := range gitMappings {
-		if err := logboek.Info.LogProcess(fmt.Sprintf("[%d] git mapping from %s repository", ind, gitMapping.Name), logboek.LevelLogProcessOptions{}, func() error {
+		if err := logging.LevelLogProcess(logboek.Info, fmt.Sprintf("[%d] git mapping from %s repository", ind, gitMapping.Name), logboek.LevelLogProcessOptions{}, func() error {
 			withTripleIndent := func(f func()) {
 				if logboek.Info.IsAccepted() {
 					logboek.IndentUp()
@@ -890,6 +1143,10 @@ func filterAndLogGitMappings(gitMappings []*stage.GitMapping) ([]*stage.GitMappi
 					logboek.Info.LogFDetails("group: %s\n", gitMapping.Group)
 				}
 
+				if gitMapping.Lfs != nil {
+					logboek.Info.LogLnDetails("lfs: enabled")
+				}
+
 				if len(gitMapping.StagesDependencies) != 0 {
 					logboek.Info.LogLnDetails("stageDependencies:")
 
@@ -973,6 +1230,10 @@ func baseGitMappingInit(local *config.GitLocalExport, imageName string, c *Conve
 		StagesDependencies: stageDependencies,
 	}
 
+	if local.Lfs != nil && local.Lfs.Enabled {
+		gitMapping.Lfs = &git_repo.LfsOptions{Url: local.Lfs.Url}
+	}
+
 	return gitMapping
 }
 
@@ -1158,6 +1419,7 @@ func prepareImageBasedOnImageFromDockerfile(imageFromDockerfileConfig *config.Im
 		),
 		stage.NewDockerStages(dockerStages, dockerArgsHash, dockerTargetIndex),
 		stage.NewContextChecksum(c.projectDir, dockerignorePathMatcher, localGitRepo),
+		imageFromDockerfileConfig.TemplatesCommits,
 		baseStageOptions,
 	)
 
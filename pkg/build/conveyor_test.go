@@ -0,0 +1,35 @@
+package build
+
+import (
+	"testing"
+
+	"github.com/flant/werf/pkg/config"
+)
+
+func TestRemoteGitRepoCacheKey(t *testing.T) {
+	const url = "https://github.com/flant/werf.git"
+
+	configs := []*config.GitRemote{
+		{Url: url},
+		{Url: "https://github.com/flant/logboek.git"},
+		{Url: url, Auth: &config.GitRemoteAuth{PasswordEnv: "TOKEN"}},
+		{Url: url, Auth: &config.GitRemoteAuth{Username: "werf", PasswordEnv: "TOKEN"}},
+		{Url: url, Clone: &config.GitRemoteClone{Depth: 1}},
+		{Url: url, Clone: &config.GitRemoteClone{Depth: 1, Filter: "blob:none"}},
+	}
+
+	keys := map[string]int{}
+	for ind, c := range configs {
+		key := remoteGitRepoCacheKey(c)
+		if prevInd, exist := keys[key]; exist {
+			t.Errorf("git remote %d and %d configs should not share the same repo: %q", prevInd, ind, key)
+		}
+		keys[key] = ind
+	}
+
+	sameConfig := &config.GitRemote{Url: url, Auth: &config.GitRemoteAuth{PasswordEnv: "TOKEN"}}
+	sameConfig.Name = "another-name"
+	if key := remoteGitRepoCacheKey(sameConfig); keys[key] != 2 {
+		t.Errorf("git remote configs with the same url, auth and clone options should share the same repo: %q", key)
+	}
+}
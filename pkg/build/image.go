@@ -142,7 +142,7 @@ func (i *Image) PrepareBaseImage(c *Conveyor) error {
 	}
 
 	logProcessOptions := logboek.LevelLogProcessOptions{Style: logboek.HighlightStyle()}
-	return logboek.Default.LogProcess("Pulling base image", logProcessOptions, func() error {
+	return logging.LevelLogProcess(logboek.Default, "Pulling base image", logProcessOptions, func() error {
 		if err := i.baseImage.Pull(); err != nil {
 			return err
 		}
@@ -167,7 +167,7 @@ func (i *Image) getFromBaseImageIdFromRegistry(c *Conveyor, baseImageName string
 
 	var fetchedBaseImageRepoId string
 	processMsg := fmt.Sprintf("Trying to get from base image id from registry (%s)", baseImageName)
-	if err := logboek.Info.LogProcessInline(processMsg, logboek.LevelLogProcessInlineOptions{}, func() error {
+	if err := logging.LevelLogProcessInline(logboek.Info, processMsg, logboek.LevelLogProcessInlineOptions{}, func() error {
 		var fetchImageIdErr error
 		fetchedBaseImageRepoId, fetchImageIdErr = docker_registry.ImageId(baseImageName)
 		if fetchImageIdErr != nil {
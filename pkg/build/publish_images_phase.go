@@ -4,11 +4,15 @@ import (
 	"fmt"
 	"strings"
 
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+
 	"github.com/flant/logboek"
 
 	"github.com/flant/werf/pkg/build/stage"
 	"github.com/flant/werf/pkg/docker_registry"
 	"github.com/flant/werf/pkg/image"
+	"github.com/flant/werf/pkg/image_signing"
+	"github.com/flant/werf/pkg/logging"
 	"github.com/flant/werf/pkg/tag_strategy"
 	"github.com/flant/werf/pkg/util"
 )
@@ -19,6 +23,7 @@ func NewPublishImagesPhase(c *Conveyor, imagesRepoManager ImagesRepoManager, opt
 		tag_strategy.GitBranch: opts.TagsByGitBranch,
 		tag_strategy.GitTag:    opts.TagsByGitTag,
 		tag_strategy.GitCommit: opts.TagsByGitCommit,
+		tag_strategy.Semver:    opts.TagsBySemver,
 	}
 	return &PublishImagesPhase{
 		BasePhase:            BasePhase{c},
@@ -26,6 +31,8 @@ func NewPublishImagesPhase(c *Conveyor, imagesRepoManager ImagesRepoManager, opt
 		TagsByScheme:         tagsByScheme,
 		TagByStagesSignature: opts.TagByStagesSignature,
 		ImageRepoManager:     imagesRepoManager,
+		Signer:               opts.Signer,
+		DaemonlessPublish:    opts.DaemonlessPublish,
 	}
 }
 
@@ -35,6 +42,8 @@ type PublishImagesPhase struct {
 	TagsByScheme         map[tag_strategy.TagStrategy][]string
 	TagByStagesSignature bool
 	ImageRepoManager     ImagesRepoManager
+	Signer               *image_signing.Signer
+	DaemonlessPublish    bool
 }
 
 func (phase *PublishImagesPhase) Name() string {
@@ -187,9 +196,14 @@ func (p *PublishImagesPhase) run(c *Conveyor) error {
 */
 
 func (phase *PublishImagesPhase) publishImage(img *Image) error {
-	existingTags, err := phase.fetchExistingTags(phase.ImageRepoManager.ImageRepo(img.GetName()))
+	imageRepository, err := phase.ImageRepoManager.ImageRepo(img.GetName())
+	if err != nil {
+		return err
+	}
+
+	existingTags, err := phase.fetchExistingTags(imageRepository)
 	if err != nil {
-		return fmt.Errorf("error fetching existing tags from image repository %s: %s", phase.ImageRepoManager.ImageRepo(img.GetName()), err)
+		return fmt.Errorf("error fetching existing tags from image repository %s: %s", imageRepository, err)
 	}
 
 	var nonEmptySchemeInOrder []tag_strategy.TagStrategy
@@ -204,14 +218,20 @@ func (phase *PublishImagesPhase) publishImage(img *Image) error {
 	for _, strategy := range nonEmptySchemeInOrder {
 		imageMetaTags := phase.TagsByScheme[strategy]
 
-		if err := logboek.Info.LogProcess(
+		if err := logging.LevelLogProcess(logboek.Info,
 			fmt.Sprintf("%s tagging strategy", string(strategy)),
 			logboek.LevelLogProcessOptions{Style: logboek.HighlightStyle()},
 			func() error {
 				for _, imageMetaTag := range imageMetaTags {
-					if err := phase.publishImageByTag(img, imageMetaTag, strategy, existingTags); err != nil {
+					if err := phase.publishImageByTag(img, imageMetaTag, strategy, existingTags, nil); err != nil {
 						return fmt.Errorf("error publishing image %s by tag %s: %s", img.GetName(), imageMetaTag, err)
 					}
+
+					if strategy == tag_strategy.Semver {
+						if err := phase.publishImageSemverAliases(img, imageMetaTag, existingTags); err != nil {
+							return fmt.Errorf("error publishing image %s semver aliases of %s: %s", img.GetName(), imageMetaTag, err)
+						}
+					}
 				}
 
 				return nil
@@ -222,12 +242,12 @@ func (phase *PublishImagesPhase) publishImage(img *Image) error {
 	}
 
 	if phase.TagByStagesSignature {
-		if err := logboek.Info.LogProcess(
+		if err := logging.LevelLogProcess(logboek.Info,
 			fmt.Sprintf("%s tagging strategy", tag_strategy.StagesSignature),
 			logboek.LevelLogProcessOptions{Style: logboek.HighlightStyle()},
 			func() error {
 
-				if err := phase.publishImageByTag(img, img.GetStagesSignature(), tag_strategy.StagesSignature, existingTags); err != nil {
+				if err := phase.publishImageByTag(img, img.GetStagesSignature(), tag_strategy.StagesSignature, existingTags, nil); err != nil {
 					return fmt.Errorf("error publishing image %s by image signature %s: %s", img.GetName(), img.GetStagesSignature(), err)
 				}
 
@@ -243,7 +263,7 @@ func (phase *PublishImagesPhase) publishImage(img *Image) error {
 
 func (phase *PublishImagesPhase) fetchExistingTags(imageRepository string) (existingTags []string, err error) {
 	logProcessMsg := fmt.Sprintf("Fetching existing repo tags")
-	_ = logboek.Info.LogProcessInline(logProcessMsg, logboek.LevelLogProcessInlineOptions{}, func() error {
+	_ = logging.LevelLogProcessInline(logboek.Info, logProcessMsg, logboek.LevelLogProcessInlineOptions{}, func() error {
 		existingTags, err = docker_registry.Tags(imageRepository)
 		return nil
 	})
@@ -252,13 +272,51 @@ func (phase *PublishImagesPhase) fetchExistingTags(imageRepository string) (exis
 	return existingTags, err
 }
 
-func (phase *PublishImagesPhase) publishImageByTag(img *Image, imageMetaTag string, tagStrategy tag_strategy.TagStrategy, initialExistingTagsList []string) error {
-	imageRepository := phase.ImageRepoManager.ImageRepo(img.GetName())
+// publishImageSemverAliases moves floating semver aliases (e.g. 1, 1.4 and latest) to the release image.
+// Each alias records the release it points to, so images cleanup will not remove the alias target.
+func (phase *PublishImagesPhase) publishImageSemverAliases(img *Image, release string, existingTags []string) error {
+	// in the monorepo mode tags of the image are prefixed with the image name
+	repoTagPrefix := strings.TrimSuffix(phase.ImageRepoManager.ImageRepoTag(img.GetName(), release), release)
+
+	var existingReleases []string
+	for _, tag := range existingTags {
+		if strings.HasPrefix(tag, repoTagPrefix) {
+			existingReleases = append(existingReleases, strings.TrimPrefix(tag, repoTagPrefix))
+		}
+	}
+
+	aliases, err := tag_strategy.SemverAliases(release, existingReleases)
+	if err != nil {
+		return err
+	}
+
+	if len(aliases) == 0 {
+		logboek.Default.LogFDetails("Release %s is not the highest one: semver aliases are not moved\n", release)
+		return nil
+	}
+
+	for _, alias := range aliases {
+		if err := phase.publishImageByTag(img, alias, tag_strategy.Semver, existingTags, map[string]string{image.WerfTagAliasTargetLabel: release}); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (phase *PublishImagesPhase) publishImageByTag(img *Image, imageMetaTag string, tagStrategy tag_strategy.TagStrategy, initialExistingTagsList []string, extraLabels map[string]string) error {
+	imageRepository, err := phase.ImageRepoManager.ImageRepo(img.GetName())
+	if err != nil {
+		return err
+	}
+	imageName, err := phase.ImageRepoManager.ImageRepoWithTag(img.GetName(), imageMetaTag)
+	if err != nil {
+		return err
+	}
 	lastStageImage := img.GetLastNonEmptyStage().GetImage()
-	imageName := phase.ImageRepoManager.ImageRepoWithTag(img.GetName(), imageMetaTag)
 	imageTag := phase.ImageRepoManager.ImageRepoTag(img.GetName(), imageMetaTag)
 
-	alreadyExists, err := phase.checkImageAlreadyExists(initialExistingTagsList, imageName, imageTag, lastStageImage)
+	alreadyExists, err := phase.checkImageAlreadyExists(initialExistingTagsList, imageName, imageTag, lastStageImage, extraLabels)
 	if err != nil {
 		return fmt.Errorf("error checking image %s already exists in the images repo: %s", img.GetName(), err)
 	}
@@ -274,18 +332,19 @@ func (phase *PublishImagesPhase) publishImageByTag(img *Image, imageMetaTag stri
 
 		logboek.LogOptionalLn()
 
-		return nil
+		return phase.afterImagePublished(img, imageName)
 	}
 
-	publishImage := image.NewImage(phase.Conveyor.GetStageImage(lastStageImage.Name()), imageName)
-
-	publishImage.Container().ServiceCommitChangeOptions().AddLabel(map[string]string{
+	labels := map[string]string{
 		image.WerfDockerImageName:  imageName,
 		image.WerfTagStrategyLabel: string(tagStrategy),
 		image.WerfImageLabel:       "true",
 		image.WerfImageNameLabel:   img.GetName(),
 		image.WerfImageTagLabel:    imageMetaTag,
-	})
+	}
+	for k, v := range extraLabels {
+		labels[k] = v
+	}
 
 	successInfoSectionFunc := func() {
 		_ = logboek.WithIndent(func() error {
@@ -296,15 +355,29 @@ func (phase *PublishImagesPhase) publishImageByTag(img *Image, imageMetaTag stri
 	}
 
 	publishingFunc := func() error {
-		if err := logboek.Info.LogProcess("Building final image with meta information", logboek.LevelLogProcessOptions{}, func() error {
-			if err := publishImage.Build(image.BuildOptions{}); err != nil {
-				return fmt.Errorf("error building %s with tagging strategy '%s': %s", imageName, tagStrategy, err)
+		var baseImage v1.Image
+		if phase.DaemonlessPublish {
+			var err error
+			if baseImage, err = phase.findPublishedBaseImage(imageRepository, initialExistingTagsList, lastStageImage); err != nil {
+				return err
 			}
+		}
 
-			return nil
-		},
-		); err != nil {
-			return err
+		var publishImage *image.Image
+		if baseImage == nil {
+			publishImage = image.NewImage(phase.Conveyor.GetStageImage(lastStageImage.Name()), imageName)
+			publishImage.Container().ServiceCommitChangeOptions().AddLabel(labels)
+
+			if err := logging.LevelLogProcess(logboek.Info, "Building final image with meta information", logboek.LevelLogProcessOptions{}, func() error {
+				if err := publishImage.Build(image.BuildOptions{}); err != nil {
+					return fmt.Errorf("error building %s with tagging strategy '%s': %s", imageName, tagStrategy, err)
+				}
+
+				return nil
+			},
+			); err != nil {
+				return err
+			}
 		}
 
 		if err := phase.Conveyor.StorageLockManager.LockImage(imageName); err != nil {
@@ -312,12 +385,12 @@ func (phase *PublishImagesPhase) publishImageByTag(img *Image, imageMetaTag stri
 		}
 		defer phase.Conveyor.StorageLockManager.UnlockImage(imageName)
 
-		existingTags, err := phase.fetchExistingTags(phase.ImageRepoManager.ImageRepo(img.GetName()))
+		existingTags, err := phase.fetchExistingTags(imageRepository)
 		if err != nil {
-			return fmt.Errorf("error fetching existing tags from image repository %s: %s", phase.ImageRepoManager.ImageRepo(img.GetName()), err)
+			return fmt.Errorf("error fetching existing tags from image repository %s: %s", imageRepository, err)
 		}
 
-		alreadyExists, err := phase.checkImageAlreadyExists(existingTags, imageName, imageTag, lastStageImage)
+		alreadyExists, err := phase.checkImageAlreadyExists(existingTags, imageName, imageTag, lastStageImage, extraLabels)
 		if err != nil {
 			return fmt.Errorf("error checking image %s already exists in the images repo: %s", img.GetName(), err)
 		}
@@ -325,7 +398,9 @@ func (phase *PublishImagesPhase) publishImageByTag(img *Image, imageMetaTag stri
 		if alreadyExists {
 			logboek.Default.LogFHighlight("%s tag %s is up-to-date\n", strings.Title(string(tagStrategy)), imageTag)
 			_ = logboek.WithIndent(func() error {
-				logboek.Info.LogFDetails("discarding newly built image %s\n", publishImage.MustGetBuiltId())
+				if publishImage != nil {
+					logboek.Info.LogFDetails("discarding newly built image %s\n", publishImage.MustGetBuiltId())
+				}
 				logboek.Default.LogFDetails("images-repo: %s\n", imageRepository)
 				logboek.Default.LogFDetails("      image: %s\n", imageName)
 
@@ -334,17 +409,25 @@ func (phase *PublishImagesPhase) publishImageByTag(img *Image, imageMetaTag stri
 
 			logboek.LogOptionalLn()
 
-			return nil
+			return phase.afterImagePublished(img, imageName)
 		}
 
-		if err := publishImage.Export(); err != nil {
-			return fmt.Errorf("error pushing %s: %s", imageName, err)
+		if publishImage != nil {
+			if err := publishImage.Export(); err != nil {
+				return fmt.Errorf("error pushing %s: %s", imageName, err)
+			}
+		} else {
+			if err := logging.LevelLogProcess(logboek.Info, "Pushing image config and manifest", logboek.LevelLogProcessOptions{}, func() error {
+				return docker_registry.PushImageWithLabels(baseImage, imageName, lastStageImage.ID(), labels)
+			}); err != nil {
+				return fmt.Errorf("error pushing %s: %s", imageName, err)
+			}
 		}
 
-		return nil
+		return phase.afterImagePublished(img, imageName)
 	}
 
-	return logboek.Default.LogProcess(
+	return logging.LevelLogProcess(logboek.Default,
 		fmt.Sprintf("Publishing image %s by %s tag %s", img.LogName(), tagStrategy, imageTag),
 		logboek.LevelLogProcessOptions{
 			SuccessInfoSectionFunc: successInfoSectionFunc,
@@ -353,23 +436,79 @@ func (phase *PublishImagesPhase) publishImageByTag(img *Image, imageMetaTag stri
 		publishingFunc)
 }
 
-func (phase *PublishImagesPhase) checkImageAlreadyExists(existingTags []string, imageName, imageTag string, lastStageImage image.ImageInterface) (bool, error) {
+// findPublishedBaseImage returns the image of the images repo with the layers of the last stage image, so that only the new config and manifest are pushed.
+// The layers of the local stage image are published only by docker, so the daemonless publish fails when there is no such image
+func (phase *PublishImagesPhase) findPublishedBaseImage(imageRepository string, existingTags []string, lastStageImage image.ImageInterface) (v1.Image, error) {
+	var baseImage v1.Image
+	var baseImageName string
+	if err := logging.LevelLogProcessInline(logboek.Info, "Searching for already published layers", logboek.LevelLogProcessInlineOptions{}, func() error {
+		var err error
+		baseImage, baseImageName, err = docker_registry.FindPublishedImageByParentId(imageRepository, existingTags, lastStageImage.ID())
+		return err
+	}); err != nil {
+		return nil, err
+	}
+
+	if baseImage == nil {
+		return nil, fmt.Errorf("daemonless publish failed: layers of stage %s are not published into images repo %s: the image should be published from this stage without --daemonless-publish option at least once", lastStageImage.Name(), imageRepository)
+	}
+
+	logboek.Info.LogFDetails("Using layers of %s\n", baseImageName)
+
+	return baseImage, nil
+}
+
+func (phase *PublishImagesPhase) afterImagePublished(img *Image, imageName string) error {
+	imageReport := phase.Conveyor.GetReport().GetImageReport(img)
+	imageReport.PublishedImages = append(imageReport.PublishedImages, imageName)
+
+	return phase.signImage(imageName)
+}
+
+func (phase *PublishImagesPhase) signImage(imageName string) error {
+	if phase.Signer == nil {
+		return nil
+	}
+
+	var signed bool
+	if err := logging.LevelLogProcessInline(logboek.Info, fmt.Sprintf("Signing image %s", imageName), logboek.LevelLogProcessInlineOptions{}, func() error {
+		var err error
+		signed, err = phase.Signer.SignImage(imageName)
+		return err
+	}); err != nil {
+		return err
+	}
+
+	if !signed {
+		logboek.Info.LogFDetails("Image %s signature is up-to-date\n", imageName)
+	}
+
+	return nil
+}
+
+func (phase *PublishImagesPhase) checkImageAlreadyExists(existingTags []string, imageName, imageTag string, lastStageImage image.ImageInterface, expectedLabels map[string]string) (bool, error) {
 	if !util.IsStringsContainValue(existingTags, imageTag) {
 		return false, nil
 	}
 
-	var parentID string
+	var configFile v1.ConfigFile
 	var err error
-	getImageParentIDFunc := func() error {
-		parentID, err = docker_registry.ImageParentId(imageName)
+	getImageConfigFunc := func() error {
+		configFile, err = docker_registry.ImageConfigFile(imageName)
 		return err
 	}
 
 	logProcessMsg := fmt.Sprintf("Getting existing tag %s parent id", imageTag)
-	err = logboek.Info.LogProcessInline(logProcessMsg, logboek.LevelLogProcessInlineOptions{}, getImageParentIDFunc)
+	err = logging.LevelLogProcessInline(logboek.Info, logProcessMsg, logboek.LevelLogProcessInlineOptions{}, getImageConfigFunc)
 	if err != nil {
 		return false, fmt.Errorf("unable to get image %s parent id: %s", imageName, err)
 	}
 
-	return lastStageImage.ID() == parentID, nil
+	for k, v := range expectedLabels {
+		if configFile.Config.Labels[k] != v {
+			return false, nil
+		}
+	}
+
+	return lastStageImage.ID() == configFile.Config.Image, nil
 }
@@ -0,0 +1,276 @@
+package build
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/flant/werf/pkg/build/stage"
+)
+
+type ReportFormat string
+
+const (
+	ReportJSON  ReportFormat = "json"
+	ReportJUnit ReportFormat = "junit"
+)
+
+type ReportOptions struct {
+	Path   string
+	Format ReportFormat
+}
+
+type Report struct {
+	Succeeded       bool           `json:"succeeded"`
+	Error           string         `json:"error,omitempty"`
+	DurationSeconds float64        `json:"durationSeconds"`
+	Images          []*ImageReport `json:"images"`
+
+	startTime time.Time
+}
+
+type ImageReport struct {
+	Name            string         `json:"name"`
+	IsArtifact      bool           `json:"isArtifact"`
+	StagesSignature string         `json:"stagesSignature,omitempty"`
+	DockerImageName string         `json:"dockerImageName,omitempty"`
+	DockerImageId   string         `json:"dockerImageId,omitempty"`
+	PublishedImages []string       `json:"publishedImages,omitempty"`
+	Stages          []*StageReport `json:"stages"`
+}
+
+type StageReport struct {
+	Name            string  `json:"name"`
+	Signature       string  `json:"signature"`
+	UsedCache       bool    `json:"usedCache"`
+	StagesStorage   string  `json:"stagesStorage"`
+	DockerImageName string  `json:"dockerImageName,omitempty"`
+	DockerImageId   string  `json:"dockerImageId,omitempty"`
+	Size            int64   `json:"size"`
+	SizeDiff        int64   `json:"sizeDiff"`
+	DurationSeconds float64 `json:"durationSeconds"`
+	Error           string  `json:"error,omitempty"`
+
+	NonReproducibleFiles []string `json:"nonReproducibleFiles,omitempty"`
+}
+
+func NewReport() *Report {
+	return &Report{startTime: time.Now()}
+}
+
+func (r *Report) GetImageReport(img *Image) *ImageReport {
+	for _, imageReport := range r.Images {
+		if imageReport.Name == img.GetName() && imageReport.IsArtifact == img.isArtifact {
+			return imageReport
+		}
+	}
+
+	imageReport := &ImageReport{Name: img.GetName(), IsArtifact: img.isArtifact}
+	r.Images = append(r.Images, imageReport)
+
+	return imageReport
+}
+
+func (r *ImageReport) AddStage(stg stage.Interface, stagesStorage string, usedCache bool, prevStageImageSize int64, duration time.Duration, err error) {
+	stageReport := &StageReport{
+		Name:            string(stg.Name()),
+		Signature:       stg.GetSignature(),
+		UsedCache:       usedCache,
+		StagesStorage:   stagesStorage,
+		DurationSeconds: duration.Seconds(),
+	}
+
+	if stageImage := stg.GetImage(); stageImage != nil && stageImage.IsExists() {
+		stageReport.DockerImageName = stageImage.Name()
+		stageReport.DockerImageId = stageImage.ID()
+		stageReport.Size = stageImage.Inspect().Size
+		stageReport.SizeDiff = stageReport.Size - prevStageImageSize
+	}
+
+	if err != nil {
+		stageReport.Error = err.Error()
+	}
+
+	r.Stages = append(r.Stages, stageReport)
+}
+
+func (r *ImageReport) GetStageReport(stg stage.Interface) *StageReport {
+	for _, stageReport := range r.Stages {
+		if stageReport.Name == string(stg.Name()) {
+			return stageReport
+		}
+	}
+
+	return nil
+}
+
+func (r *Report) Write(path string, format ReportFormat) error {
+	if err := os.MkdirAll(filepath.Dir(path), os.ModePerm); err != nil {
+		return fmt.Errorf("unable to create dir %s: %s", filepath.Dir(path), err)
+	}
+
+	f, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("unable to create report file %s: %s", path, err)
+	}
+	defer f.Close()
+
+	switch format {
+	case ReportJSON:
+		err = r.writeJSON(f)
+	case ReportJUnit:
+		err = r.writeJUnit(f)
+	default:
+		err = fmt.Errorf("unknown report format %q", format)
+	}
+
+	if err != nil {
+		return fmt.Errorf("unable to write report %s: %s", path, err)
+	}
+
+	return nil
+}
+
+func (r *Report) finish(err error) {
+	r.DurationSeconds = time.Since(r.startTime).Seconds()
+	r.Succeeded = err == nil
+	if err != nil {
+		r.Error = err.Error()
+	}
+}
+
+func (r *Report) writeJSON(w io.Writer) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(r)
+}
+
+type junitTestSuites struct {
+	XMLName    xml.Name         `xml:"testsuites"`
+	Name       string           `xml:"name,attr"`
+	Tests      int              `xml:"tests,attr"`
+	Failures   int              `xml:"failures,attr"`
+	Time       string           `xml:"time,attr"`
+	TestSuites []junitTestSuite `xml:"testsuite"`
+}
+
+type junitTestSuite struct {
+	Name       string          `xml:"name,attr"`
+	Tests      int             `xml:"tests,attr"`
+	Failures   int             `xml:"failures,attr"`
+	Skipped    int             `xml:"skipped,attr"`
+	Time       string          `xml:"time,attr"`
+	Properties []junitProperty `xml:"properties>property,omitempty"`
+	TestCases  []junitTestCase `xml:"testcase"`
+}
+
+type junitProperty struct {
+	Name  string `xml:"name,attr"`
+	Value string `xml:"value,attr"`
+}
+
+type junitTestCase struct {
+	Name      string        `xml:"name,attr"`
+	ClassName string        `xml:"classname,attr"`
+	Time      string        `xml:"time,attr"`
+	Failure   *junitFailure `xml:"failure,omitempty"`
+	Skipped   *junitSkipped `xml:"skipped,omitempty"`
+	SystemOut string        `xml:"system-out,omitempty"`
+}
+
+type junitFailure struct {
+	Message string `xml:"message,attr"`
+}
+
+type junitSkipped struct {
+	Message string `xml:"message,attr"`
+}
+
+// writeJUnit represents each image as a test suite and each stage as a test case.
+// Stages taken from cache are counted as skipped to make cache busting visible in CI.
+func (r *Report) writeJUnit(w io.Writer) error {
+	suites := junitTestSuites{Name: "werf build", Time: junitTime(r.DurationSeconds)}
+
+	for _, imageReport := range r.Images {
+		suiteName := imageReport.Name
+		if suiteName == "" {
+			suiteName = "~"
+		}
+		if imageReport.IsArtifact {
+			suiteName = fmt.Sprintf("artifact %s", suiteName)
+		}
+
+		suite := junitTestSuite{Name: suiteName}
+
+		for _, property := range []junitProperty{
+			{Name: "stagesSignature", Value: imageReport.StagesSignature},
+			{Name: "dockerImageName", Value: imageReport.DockerImageName},
+			{Name: "dockerImageId", Value: imageReport.DockerImageId},
+			{Name: "publishedImages", Value: strings.Join(imageReport.PublishedImages, ",")},
+		} {
+			if property.Value != "" {
+				suite.Properties = append(suite.Properties, property)
+			}
+		}
+
+		var suiteDuration float64
+		for _, stageReport := range imageReport.Stages {
+			testCase := junitTestCase{
+				Name:      stageReport.Name,
+				ClassName: suiteName,
+				Time:      junitTime(stageReport.DurationSeconds),
+				SystemOut: fmt.Sprintf(
+					"signature: %s\nusedCache: %v\nstagesStorage: %s\ndockerImageName: %s\ndockerImageId: %s\nsize: %d\nsizeDiff: %d\n",
+					stageReport.Signature, stageReport.UsedCache, stageReport.StagesStorage, stageReport.DockerImageName, stageReport.DockerImageId, stageReport.Size, stageReport.SizeDiff,
+				),
+			}
+
+			if len(stageReport.NonReproducibleFiles) != 0 {
+				testCase.SystemOut += fmt.Sprintf("nonReproducibleFiles:\n%s\n", strings.Join(stageReport.NonReproducibleFiles, "\n"))
+			}
+
+			if stageReport.Error != "" {
+				testCase.Failure = &junitFailure{Message: stageReport.Error}
+				suite.Failures++
+			} else if len(stageReport.NonReproducibleFiles) != 0 {
+				testCase.Failure = &junitFailure{Message: fmt.Sprintf("stage is not reproducible: %d files differ", len(stageReport.NonReproducibleFiles))}
+				suite.Failures++
+			} else if stageReport.UsedCache {
+				testCase.Skipped = &junitSkipped{Message: "cache image used"}
+				suite.Skipped++
+			}
+
+			suite.TestCases = append(suite.TestCases, testCase)
+			suite.Tests++
+			suiteDuration += stageReport.DurationSeconds
+		}
+
+		suite.Time = junitTime(suiteDuration)
+
+		suites.TestSuites = append(suites.TestSuites, suite)
+		suites.Tests += suite.Tests
+		suites.Failures += suite.Failures
+	}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+
+	encoder := xml.NewEncoder(w)
+	encoder.Indent("", "  ")
+	if err := encoder.Encode(suites); err != nil {
+		return err
+	}
+
+	_, err := io.WriteString(w, "\n")
+	return err
+}
+
+func junitTime(seconds float64) string {
+	return fmt.Sprintf("%.3f", seconds)
+}
@@ -0,0 +1,177 @@
+package build
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/flant/werf/pkg/build/stage"
+	"github.com/flant/werf/pkg/image"
+)
+
+type reportTestStage struct {
+	stage.Interface
+
+	name      stage.StageName
+	signature string
+}
+
+func (s *reportTestStage) Name() stage.StageName {
+	return s.name
+}
+
+func (s *reportTestStage) GetSignature() string {
+	return s.signature
+}
+
+func (s *reportTestStage) GetImage() image.ImageInterface {
+	return nil
+}
+
+func newTestReport() *Report {
+	report := NewReport()
+
+	app := report.GetImageReport(&Image{name: "app"})
+	app.StagesSignature = "app-signature"
+	app.DockerImageName = "werf-stages-storage/project:app-signature"
+	app.PublishedImages = []string{"registry.example.com/project/app:v1"}
+	app.AddStage(&reportTestStage{name: "from", signature: "from-signature"}, ":local", true, 0, 1500*time.Millisecond, nil)
+	app.AddStage(&reportTestStage{name: "install", signature: "install-signature"}, ":local", false, 0, 2*time.Second, errors.New("install failed"))
+
+	artifact := report.GetImageReport(&Image{name: "app", isArtifact: true})
+	artifact.AddStage(&reportTestStage{name: "from", signature: "artifact-from-signature"}, ":local", false, 0, time.Second, nil)
+	artifact.GetStageReport(&reportTestStage{name: "from"}).NonReproducibleFiles = []string{"/app/build.log"}
+
+	report.finish(errors.New("build failed"))
+
+	return report
+}
+
+func TestReport_GetImageReport(t *testing.T) {
+	report := newTestReport()
+
+	if len(report.Images) != 2 {
+		t.Fatalf("\n[EXPECTED]: 2 image reports\n[GOT]: %d", len(report.Images))
+	}
+
+	if report.GetImageReport(&Image{name: "app"}) != report.Images[0] {
+		t.Errorf("image report should be reused for the same image")
+	}
+
+	if report.GetImageReport(&Image{name: "app", isArtifact: true}) != report.Images[1] {
+		t.Errorf("artifact report should be separated from the image with the same name")
+	}
+
+	if stageReport := report.Images[0].GetStageReport(&reportTestStage{name: "setup"}); stageReport != nil {
+		t.Errorf("\n[EXPECTED]: nil\n[GOT]: %+v", stageReport)
+	}
+}
+
+func TestReport_WriteJSON(t *testing.T) {
+	dir, err := ioutil.TempDir("", "werf-build-report")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	report := newTestReport()
+	path := filepath.Join(dir, "reports", "build.json")
+	if err := report.Write(path, ReportJSON); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var got map[string]interface{}
+	if err := json.Unmarshal(data, &got); err != nil {
+		t.Fatalf("bad report json: %s\n%s", err, data)
+	}
+
+	if got["succeeded"] != false || got["error"] != "build failed" {
+		t.Errorf("unexpected report status: succeeded=%v error=%v", got["succeeded"], got["error"])
+	}
+
+	if _, ok := got["durationSeconds"].(float64); !ok {
+		t.Errorf("report duration expected, got %v", got["durationSeconds"])
+	}
+
+	images := got["images"].([]interface{})
+	app := images[0].(map[string]interface{})
+	expectedApp := map[string]interface{}{
+		"name":            "app",
+		"isArtifact":      false,
+		"stagesSignature": "app-signature",
+		"dockerImageName": "werf-stages-storage/project:app-signature",
+		"publishedImages": []interface{}{"registry.example.com/project/app:v1"},
+		"stages": []interface{}{
+			map[string]interface{}{
+				"name":            "from",
+				"signature":       "from-signature",
+				"usedCache":       true,
+				"stagesStorage":   ":local",
+				"size":            float64(0),
+				"sizeDiff":        float64(0),
+				"durationSeconds": 1.5,
+			},
+			map[string]interface{}{
+				"name":            "install",
+				"signature":       "install-signature",
+				"usedCache":       false,
+				"stagesStorage":   ":local",
+				"size":            float64(0),
+				"sizeDiff":        float64(0),
+				"durationSeconds": float64(2),
+				"error":           "install failed",
+			},
+		},
+	}
+
+	if !reflect.DeepEqual(expectedApp, app) {
+		t.Errorf("\n[EXPECTED]: %#v\n[GOT]: %#v", expectedApp, app)
+	}
+
+	artifactStage := images[1].(map[string]interface{})["stages"].([]interface{})[0].(map[string]interface{})
+	if !reflect.DeepEqual([]interface{}{"/app/build.log"}, artifactStage["nonReproducibleFiles"]) {
+		t.Errorf("unexpected non reproducible files %v", artifactStage["nonReproducibleFiles"])
+	}
+}
+
+func TestReport_WriteJUnit(t *testing.T) {
+	buf := bytes.NewBuffer(nil)
+	if err := newTestReport().writeJUnit(buf); err != nil {
+		t.Fatal(err)
+	}
+
+	var suites junitTestSuites
+	if err := xml.Unmarshal(buf.Bytes(), &suites); err != nil {
+		t.Fatalf("bad junit report: %s\n%s", err, buf.String())
+	}
+
+	if suites.Tests != 3 || suites.Failures != 2 {
+		t.Errorf("\n[EXPECTED]: 3 tests, 2 failures\n[GOT]: %d tests, %d failures", suites.Tests, suites.Failures)
+	}
+
+	app := suites.TestSuites[0]
+	if app.Name != "app" || app.Skipped != 1 || app.Failures != 1 || app.Time != "3.500" {
+		t.Errorf("unexpected image test suite %+v", app)
+	}
+
+	if app.TestCases[0].Skipped == nil || app.TestCases[1].Failure == nil || app.TestCases[1].Failure.Message != "install failed" {
+		t.Errorf("unexpected image test cases %+v", app.TestCases)
+	}
+
+	artifact := suites.TestSuites[1]
+	if artifact.Name != "artifact app" || artifact.TestCases[0].Failure == nil {
+		t.Errorf("non reproducible artifact stage should be reported as failure: %+v", artifact)
+	}
+}
@@ -5,6 +5,7 @@ import (
 
 	"github.com/flant/logboek"
 	"github.com/flant/werf/pkg/build/stage"
+	"github.com/flant/werf/pkg/logging"
 )
 
 type ShouldBeBuiltPhase struct {
@@ -60,7 +61,7 @@ func (phase *ShouldBeBuiltPhase) AfterImages() error {
 	}
 
 	logProcessOptions := logboek.LevelLogProcessOptions{Style: logboek.HighlightStyle()}
-	return logboek.Default.LogProcess("Built stages cache check", logProcessOptions, func() error {
+	return logging.LevelLogProcess(logboek.Default, "Built stages cache check", logProcessOptions, func() error {
 		for _, img := range phase.BadImages {
 			for _, stg := range phase.BadStagesByImage[img.GetName()] {
 				if logboek.Info.IsAccepted() {
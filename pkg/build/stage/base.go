@@ -11,6 +11,7 @@ import (
 	"github.com/flant/werf/pkg/storage"
 
 	"github.com/flant/logboek"
+	"github.com/flant/werf/pkg/build/builder"
 	"github.com/flant/werf/pkg/config"
 	imagePkg "github.com/flant/werf/pkg/image"
 	"github.com/flant/werf/pkg/slug"
@@ -61,9 +62,14 @@ var (
 type NewBaseStageOptions struct {
 	ImageName        string
 	ConfigMounts     []*config.Mount
+	StageContainer   *config.StageContainer
 	ImageTmpDir      string
 	ContainerWerfDir string
 	ProjectName      string
+	// ShellScriptsFiles are read from the project git repository by user stage names
+	ShellScriptsFiles map[string]*builder.ShellScriptFile
+	AnsibleRoles      []*builder.AnsibleRole
+	AnsibleLibrary    *builder.AnsibleLibrary
 }
 
 func newBaseStage(name StageName, options *NewBaseStageOptions) *BaseStage {
@@ -71,6 +77,7 @@ func newBaseStage(name StageName, options *NewBaseStageOptions) *BaseStage {
 	s.name = name
 	s.imageName = options.ImageName
 	s.configMounts = options.ConfigMounts
+	s.stageContainer = options.StageContainer
 	s.imageTmpDir = options.ImageTmpDir
 	s.containerWerfDir = options.ContainerWerfDir
 	s.projectName = options.ProjectName
@@ -86,6 +93,7 @@ type BaseStage struct {
 	imageTmpDir      string
 	containerWerfDir string
 	configMounts     []*config.Mount
+	stageContainer   *config.StageContainer
 	projectName      string
 }
 
@@ -232,9 +240,24 @@ func (s *BaseStage) PrepareImage(_ Conveyor, prevBuiltImage, image imagePkg.Imag
 		return fmt.Errorf("error adding mounts volumes: %s", err)
 	}
 
+	s.addStageContainerOptions(image)
+
 	return nil
 }
 
+func (s *BaseStage) addStageContainerOptions(image imagePkg.ImageInterface) {
+	if s.stageContainer == nil {
+		return
+	}
+
+	options := s.stageContainer.StageOptions(string(s.name))
+	runOptions := image.Container().RunOptions()
+	runOptions.AddCpus(options.Cpus)
+	runOptions.AddMemory(options.Memory)
+	runOptions.AddNetwork(options.Network)
+	runOptions.AddTimeout(options.Timeout)
+}
+
 func (s *BaseStage) AfterImageSyncDockerStateHook(_ Conveyor) error {
 	return nil
 }
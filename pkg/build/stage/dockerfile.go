@@ -16,21 +16,23 @@ import (
 	"github.com/flant/werf/pkg/git_repo/ls_tree"
 	"github.com/flant/werf/pkg/git_repo/status"
 	"github.com/flant/werf/pkg/image"
+	"github.com/flant/werf/pkg/logging"
 	"github.com/flant/werf/pkg/path_matcher"
 	"github.com/flant/werf/pkg/util"
 
 	"github.com/flant/logboek"
 )
 
-func GenerateDockerfileStage(dockerRunArgs *DockerRunArgs, dockerStages *DockerStages, contextChecksum *ContextChecksum, baseStageOptions *NewBaseStageOptions) *DockerfileStage {
-	return newDockerfileStage(dockerRunArgs, dockerStages, contextChecksum, baseStageOptions)
+func GenerateDockerfileStage(dockerRunArgs *DockerRunArgs, dockerStages *DockerStages, contextChecksum *ContextChecksum, templatesCommits []string, baseStageOptions *NewBaseStageOptions) *DockerfileStage {
+	return newDockerfileStage(dockerRunArgs, dockerStages, contextChecksum, templatesCommits, baseStageOptions)
 }
 
-func newDockerfileStage(dockerRunArgs *DockerRunArgs, dockerStages *DockerStages, contextChecksum *ContextChecksum, baseStageOptions *NewBaseStageOptions) *DockerfileStage {
+func newDockerfileStage(dockerRunArgs *DockerRunArgs, dockerStages *DockerStages, contextChecksum *ContextChecksum, templatesCommits []string, baseStageOptions *NewBaseStageOptions) *DockerfileStage {
 	s := &DockerfileStage{}
 	s.DockerRunArgs = dockerRunArgs
 	s.DockerStages = dockerStages
 	s.ContextChecksum = contextChecksum
+	s.templatesCommits = templatesCommits
 	s.BaseStage = newBaseStage(Dockerfile, baseStageOptions)
 
 	return s
@@ -41,6 +43,8 @@ type DockerfileStage struct {
 	*DockerStages
 	*ContextChecksum
 	*BaseStage
+
+	templatesCommits []string
 }
 
 func NewDockerRunArgs(dockerfilePath, target, context string, buildArgs map[string]interface{}, addHost []string) *DockerRunArgs {
@@ -178,7 +182,7 @@ func (s *DockerfileStage) GetDependencies(_ Conveyor, _, _ image.ImageInterface)
 		}
 	}
 
-	return util.Sha256Hash(stagesDependencies[s.dockerTargetStageIndex]...), nil
+	return util.Sha256Hash(append(stagesDependencies[s.dockerTargetStageIndex], s.templatesCommits...)...), nil
 }
 
 func (s *DockerfileStage) PrepareImage(c Conveyor, prevBuiltImage, img image.ImageInterface) error {
@@ -277,7 +281,7 @@ entryNotFoundInGitRepository:
 
 		if !lsTreeResult.IsEmpty() {
 			blockMsg = fmt.Sprintf("ls-tree result checksum (%s)", wildcardsPathMatcher.String())
-			_ = logboek.Debug.LogBlock(blockMsg, logboek.LevelLogBlockOptions{}, func() error {
+			_ = logging.LevelLogBlock(logboek.Debug, blockMsg, logboek.LevelLogBlockOptions{}, func() error {
 				lsTreeResultChecksum = lsTreeResult.Checksum()
 				logboek.Debug.LogLn()
 				logboek.Debug.LogLn(lsTreeResultChecksum)
@@ -312,7 +316,7 @@ entryNotFoundInGitRepository:
 	var statusResultChecksum string
 	if !statusResult.IsEmpty() {
 		blockMsg = fmt.Sprintf("Status result checksum (%s)", wildcardsPathMatcher.String())
-		_ = logboek.Debug.LogBlock(blockMsg, logboek.LevelLogBlockOptions{}, func() error {
+		_ = logging.LevelLogBlock(logboek.Debug, blockMsg, logboek.LevelLogBlockOptions{}, func() error {
 			statusResultChecksum = statusResult.Checksum()
 			logboek.Debug.LogLn()
 			logboek.Debug.LogLn(statusResultChecksum)
@@ -25,12 +25,13 @@ func GenerateFromStage(imageBaseConfig *config.StapelImageBase, baseImageRepoId
 		fromImageOrArtifactImageName = imageBaseConfig.FromImageArtifactName
 	}
 
-	return newFromStage(fromImageOrArtifactImageName, baseImageRepoIdOrNone, imageBaseConfig.FromCacheVersion, baseStageOptions)
+	return newFromStage(fromImageOrArtifactImageName, baseImageRepoIdOrNone, imageBaseConfig.FromCacheVersion, imageBaseConfig.TemplatesCommits, baseStageOptions)
 }
 
-func newFromStage(fromImageOrArtifactImageName, baseImageRepoIdOrNone, cacheVersion string, baseStageOptions *NewBaseStageOptions) *FromStage {
+func newFromStage(fromImageOrArtifactImageName, baseImageRepoIdOrNone, cacheVersion string, templatesCommits []string, baseStageOptions *NewBaseStageOptions) *FromStage {
 	s := &FromStage{}
 	s.cacheVersion = cacheVersion
+	s.templatesCommits = templatesCommits
 	s.fromImageOrArtifactImageName = fromImageOrArtifactImageName
 	s.baseImageRepoIdOrNone = baseImageRepoIdOrNone
 	s.BaseStage = newBaseStage(From, baseStageOptions)
@@ -43,6 +44,7 @@ type FromStage struct {
 	fromImageOrArtifactImageName string
 	baseImageRepoIdOrNone        string
 	cacheVersion                 string
+	templatesCommits             []string
 }
 
 func (s *FromStage) GetDependencies(c Conveyor, prevImage, _ image.ImageInterface) (string, error) {
@@ -56,6 +58,8 @@ func (s *FromStage) GetDependencies(c Conveyor, prevImage, _ image.ImageInterfac
 		args = append(args, s.baseImageRepoIdOrNone)
 	}
 
+	args = append(args, s.templatesCommits...)
+
 	for _, mount := range s.configMounts {
 		args = append(args, filepath.ToSlash(filepath.Clean(mount.From)), path.Clean(mount.To), mount.Type)
 	}
@@ -85,5 +89,7 @@ func (s *FromStage) PrepareImage(_ Conveyor, prevBuiltImage, image image.ImageIn
 		image.Container().AddServiceRunCommands(fmt.Sprintf("%s -rf %s", stapel.RmBinPath(), mountpointsStr))
 	}
 
+	s.addStageContainerOptions(image)
+
 	return nil
 }
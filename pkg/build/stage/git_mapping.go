@@ -16,6 +16,7 @@ import (
 
 	"github.com/flant/werf/pkg/git_repo"
 	"github.com/flant/werf/pkg/image"
+	"github.com/flant/werf/pkg/logging"
 	"github.com/flant/werf/pkg/stapel"
 	"github.com/flant/werf/pkg/util"
 )
@@ -62,6 +63,7 @@ type GitMapping struct {
 	IncludePaths       []string
 	ExcludePaths       []string
 	StagesDependencies map[StageName][]string
+	Lfs                *git_repo.LfsOptions
 
 	PatchesDir           string
 	ContainerPatchesDir  string
@@ -127,7 +129,7 @@ func (gm *GitMapping) getOrCreateArchive(opts git_repo.ArchiveOptions) (git_repo
 func (gm *GitMapping) createArchive(opts git_repo.ArchiveOptions) (git_repo.Archive, error) {
 	var res git_repo.Archive
 
-	err := logboek.Info.LogProcess(fmt.Sprintf("Creating archive for commit %s of %s git mapping %s", opts.Commit, gm.GitRepo().GetName(), gm.Add), logboek.LevelLogProcessOptions{}, func() error {
+	err := logging.LevelLogProcess(logboek.Info, fmt.Sprintf("Creating archive for commit %s of %s git mapping %s", opts.Commit, gm.GitRepo().GetName(), gm.Add), logboek.LevelLogProcessOptions{}, func() error {
 		archive, err := gm.GitRepo().CreateArchive(opts)
 		if err != nil {
 			return err
@@ -160,7 +162,7 @@ func (gm *GitMapping) createPatch(opts git_repo.PatchOptions) (git_repo.Patch, e
 	var res git_repo.Patch
 
 	logProcessMsg := fmt.Sprintf("Creating patch %s..%s for %s git mapping %s", opts.FromCommit, opts.ToCommit, gm.GitRepo().GetName(), gm.Add)
-	err := logboek.Info.LogProcess(logProcessMsg, logboek.LevelLogProcessOptions{}, func() error {
+	err := logging.LevelLogProcess(logboek.Info, logProcessMsg, logboek.LevelLogProcessOptions{}, func() error {
 		patch, err := gm.GitRepo().CreatePatch(opts)
 		if err != nil {
 			return err
@@ -320,7 +322,8 @@ func (gm *GitMapping) baseApplyPatchCommand(fromCommit, toCommit string, prevBui
 		return nil, nil
 	}
 
-	if patch.HasBinary() {
+	// lfs pointers are replaced with the objects content only in the archive
+	if patch.HasBinary() || (gm.Lfs != nil && patch.HasLfs()) {
 		pathsListFile, err := gm.preparePatchPathsListFile(patchOpts, patch)
 		if err != nil {
 			return nil, fmt.Errorf("cannot create patch paths list file: %s", err)
@@ -385,6 +388,7 @@ func (gm *GitMapping) baseApplyPatchCommand(fromCommit, toCommit string, prevBui
 		archiveOpts := git_repo.ArchiveOptions{
 			FilterOptions: gm.getRepoFilterOptions(),
 			Commit:        toCommit,
+			Lfs:           gm.Lfs,
 		}
 
 		archive, err := gm.getOrCreateArchive(archiveOpts)
@@ -506,6 +510,7 @@ func (gm *GitMapping) baseApplyArchiveCommand(commit string, image image.ImageIn
 	archiveOpts := git_repo.ArchiveOptions{
 		FilterOptions: gm.getRepoFilterOptions(),
 		Commit:        commit,
+		Lfs:           gm.Lfs,
 	}
 
 	archive, err := gm.getOrCreateArchive(archiveOpts)
@@ -594,7 +599,12 @@ func (gm *GitMapping) PatchSize(fromCommit string) (int64, error) {
 		return 0, fmt.Errorf("unable to stat temporary patch file `%s`: %s", patch.GetFilePath(), err)
 	}
 
-	return fileInfo.Size(), nil
+	size := fileInfo.Size()
+	if gm.Lfs != nil {
+		size += patch.GetLfsSize()
+	}
+
+	return size, nil
 }
 
 func (gm *GitMapping) GetFullName() string {
@@ -630,6 +640,12 @@ func (gm *GitMapping) GetParamshash() string {
 	parts = append(parts, ":::")
 	parts = append(parts, gm.Commit)
 
+	// archive with the lfs objects content differs from the archive with the lfs pointers
+	if gm.Lfs != nil {
+		parts = append(parts, ":::")
+		parts = append(parts, "lfs")
+	}
+
 	for _, part := range parts {
 		_, err = hash.Write([]byte(part))
 		if err != nil {
@@ -650,12 +666,39 @@ func formatParamshashPaths(paths ...string) []string {
 }
 
 func (gm *GitMapping) GetPatchContent(prevBuiltImage image.ImageInterface) (string, error) {
+	patch, err := gm.getPatchToLatestCommit(prevBuiltImage)
+	if err != nil || patch == nil {
+		return "", err
+	}
+
+	data, err := ioutil.ReadFile(patch.GetFilePath())
+	if err != nil {
+		return "", fmt.Errorf("error reading patch file %s: %s", patch.GetFilePath(), err)
+	}
+	return string(data), nil
+}
+
+// GetPatchLfsOids returns oids of the changed lfs objects, which content is not in the patch
+func (gm *GitMapping) GetPatchLfsOids(prevBuiltImage image.ImageInterface) ([]string, error) {
+	if gm.Lfs == nil {
+		return nil, nil
+	}
+
+	patch, err := gm.getPatchToLatestCommit(prevBuiltImage)
+	if err != nil || patch == nil {
+		return nil, err
+	}
+
+	return patch.GetLfsOids(), nil
+}
+
+func (gm *GitMapping) getPatchToLatestCommit(prevBuiltImage image.ImageInterface) (git_repo.Patch, error) {
 	fromCommit, toCommit, err := gm.GetCommitsToPatch(prevBuiltImage)
 	if err != nil {
-		return "", err
+		return nil, err
 	}
 	if fromCommit == toCommit {
-		return "", nil
+		return nil, nil
 	}
 
 	patchOpts := git_repo.PatchOptions{
@@ -663,16 +706,8 @@ func (gm *GitMapping) GetPatchContent(prevBuiltImage image.ImageInterface) (stri
 		FromCommit:    fromCommit,
 		ToCommit:      toCommit,
 	}
-	patch, err := gm.getOrCreatePatch(patchOpts)
-	if err != nil {
-		return "", err
-	}
 
-	data, err := ioutil.ReadFile(patch.GetFilePath())
-	if err != nil {
-		return "", fmt.Errorf("error reading patch file %s: %s", patch.GetFilePath(), err)
-	}
-	return string(data), nil
+	return gm.getOrCreatePatch(patchOpts)
 }
 
 func (gm *GitMapping) IsPatchEmpty(prevBuiltImage image.ImageInterface) (bool, error) {
@@ -712,6 +747,7 @@ func (gm *GitMapping) IsEmpty() (bool, error) {
 	archiveOpts := git_repo.ArchiveOptions{
 		FilterOptions: gm.getRepoFilterOptions(),
 		Commit:        commit,
+		Lfs:           gm.Lfs,
 	}
 
 	archive, err := gm.getOrCreateArchive(archiveOpts)
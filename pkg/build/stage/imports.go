@@ -60,6 +60,8 @@ func (s *ImportsStage) GetDependencies(c Conveyor, _, _ imagePkg.ImageInterface)
 }
 
 func (s *ImportsStage) PrepareImage(c Conveyor, _, image imagePkg.ImageInterface) error {
+	s.addStageContainerOptions(image)
+
 	for _, elm := range s.imports {
 		var importImage string
 		if elm.ImageName != "" {
@@ -0,0 +1,195 @@
+package build
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/google/uuid"
+
+	"github.com/flant/logboek"
+
+	"github.com/flant/werf/pkg/build/stage"
+	"github.com/flant/werf/pkg/docker"
+	"github.com/flant/werf/pkg/image"
+	"github.com/flant/werf/pkg/layer_diff"
+	"github.com/flant/werf/pkg/logging"
+)
+
+type ReproducibilityOptions struct {
+	Verify bool
+	// Targets limit verified stages, all stapel stages are verified by default
+	Targets            []IntrospectTarget
+	SetSourceDateEpoch bool
+}
+
+func (opts *ReproducibilityOptions) ImageStageShouldBeVerified(imageName, stageName string) bool {
+	if !opts.Verify {
+		return false
+	}
+
+	if len(opts.Targets) == 0 {
+		return true
+	}
+
+	targets := IntrospectOptions{Targets: opts.Targets}
+	return targets.ImageStageShouldBeIntrospected(imageName, stageName)
+}
+
+func NewVerifyReproducibilityPhase(c *Conveyor, opts ReproducibilityOptions) *VerifyReproducibilityPhase {
+	return &VerifyReproducibilityPhase{BasePhase: BasePhase{c}, ReproducibilityOptions: opts}
+}
+
+// VerifyReproducibilityPhase rebuilds stages from the same parent images and compares files of the stages layers
+type VerifyReproducibilityPhase struct {
+	BasePhase
+
+	PrevImage      *image.StageImage
+	PrevBuiltImage image.ImageInterface
+
+	nonReproducibleStages []string
+
+	ReproducibilityOptions
+}
+
+func (phase *VerifyReproducibilityPhase) Name() string {
+	return "verifyReproducibility"
+}
+
+func (phase *VerifyReproducibilityPhase) BeforeImages() error {
+	return nil
+}
+
+func (phase *VerifyReproducibilityPhase) AfterImages() error {
+	return nil
+}
+
+func (phase *VerifyReproducibilityPhase) ImageProcessingShouldBeStopped(_ *Image) bool {
+	return false
+}
+
+func (phase *VerifyReproducibilityPhase) BeforeImageStages(img *Image) error {
+	phase.PrevImage = img.GetBaseImage()
+	phase.PrevBuiltImage = nil
+	phase.nonReproducibleStages = nil
+
+	return nil
+}
+
+// AfterImageStages fails before the next phases, so that non-reproducible image would not be published
+func (phase *VerifyReproducibilityPhase) AfterImageStages(_ *Image) error {
+	if len(phase.nonReproducibleStages) != 0 {
+		return fmt.Errorf("non-reproducible stages found: %s", strings.Join(phase.nonReproducibleStages, ", "))
+	}
+
+	return nil
+}
+
+func (phase *VerifyReproducibilityPhase) OnImageStage(img *Image, stg stage.Interface) (bool, error) {
+	defer func() {
+		phase.PrevImage = phase.Conveyor.GetStageImage(stg.GetImage().Name())
+		phase.PrevBuiltImage = stg.GetImage()
+	}()
+
+	if img.isDockerfileImage || !phase.ImageStageShouldBeVerified(img.GetName(), string(stg.Name())) {
+		return true, nil
+	}
+
+	differences, err := phase.verifyStage(img, stg)
+	if err != nil {
+		return false, fmt.Errorf("unable to verify stage %s reproducibility: %s", stg.Name(), err)
+	}
+
+	var nonReproducibleFiles []string
+	for _, d := range differences {
+		nonReproducibleFiles = append(nonReproducibleFiles, d.String())
+	}
+
+	if stageReport := phase.Conveyor.GetReport().GetImageReport(img).GetStageReport(stg); stageReport != nil {
+		stageReport.NonReproducibleFiles = nonReproducibleFiles
+	}
+
+	if len(nonReproducibleFiles) == 0 {
+		logboek.Default.LogFDetails("Stage %s is reproducible\n", stg.Name())
+		logboek.LogOptionalLn()
+		return true, nil
+	}
+
+	phase.nonReproducibleStages = append(phase.nonReproducibleStages, string(stg.Name()))
+
+	_ = logging.LevelLogBlock(logboek.Default, fmt.Sprintf("Stage %s is not reproducible", stg.Name()), logboek.LevelLogBlockOptions{Style: logboek.HighlightStyle()}, func() error {
+		for _, file := range nonReproducibleFiles {
+			logboek.Default.LogLn(file)
+		}
+		return nil
+	})
+	logboek.LogOptionalLn()
+
+	return true, nil
+}
+
+func (phase *VerifyReproducibilityPhase) verifyStage(img *Image, stg stage.Interface) ([]layer_diff.Difference, error) {
+	stageImage := stg.GetImage()
+	verifyImage := image.NewStageImage(phase.PrevImage, uuid.New().String())
+
+	phase.Conveyor.prepareStageImageRunOptions(verifyImage, phase.SetSourceDateEpoch)
+
+	if err := stg.PrepareImage(phase.Conveyor, phase.PrevBuiltImage, verifyImage); err != nil {
+		return nil, fmt.Errorf("error preparing stage: %s", err)
+	}
+
+	if err := logging.LevelLogProcess(logboek.Default,
+		fmt.Sprintf("Rebuilding %s", stg.LogDetailedName()),
+		logboek.LevelLogProcessOptions{Style: logboek.HighlightStyle()},
+		func() error {
+			if err := stg.PreRunHook(phase.Conveyor); err != nil {
+				return fmt.Errorf("%s preRunHook failed: %s", stg.LogDetailedName(), err)
+			}
+
+			return logboek.WithTag(string(stg.Name()), img.LogTagStyle(), func() error {
+				return verifyImage.Build(image.BuildOptions{})
+			})
+		},
+	); err != nil {
+		return nil, err
+	}
+
+	builtId, err := verifyImage.GetBuiltId()
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if err := docker.CliRmi(builtId); err != nil {
+			logboek.LogWarnF("WARNING: unable to remove image %s: %s\n", builtId, err)
+		}
+	}()
+
+	var differences []layer_diff.Difference
+	if err := logging.LevelLogProcess(logboek.Info,
+		fmt.Sprintf("Comparing files of stage image %s and rebuilt image %s", stageImage.Name(), builtId),
+		logboek.LevelLogProcessOptions{},
+		func() error {
+			expectedFiles, err := layer_diff.ReadImageLastLayerFiles(func() (io.ReadCloser, error) {
+				return docker.ImageSave(stageImage.ID())
+			}, phase.Conveyor.baseTmpDir)
+			if err != nil {
+				return fmt.Errorf("unable to read stage image %s files: %s", stageImage.Name(), err)
+			}
+
+			actualFiles, err := layer_diff.ReadImageLastLayerFiles(func() (io.ReadCloser, error) {
+				return docker.ImageSave(builtId)
+			}, phase.Conveyor.baseTmpDir)
+			if err != nil {
+				return fmt.Errorf("unable to read rebuilt image %s files: %s", builtId, err)
+			}
+
+			differences = layer_diff.Compare(expectedFiles, actualFiles)
+
+			return nil
+		},
+	); err != nil {
+		return nil, err
+	}
+
+	return differences, nil
+}
@@ -1,12 +1,14 @@
 package cleaning
 
 import (
+	"fmt"
 	"strings"
 
 	"github.com/flant/logboek"
 
 	"github.com/flant/werf/pkg/docker_registry"
 	"github.com/flant/werf/pkg/image"
+	"github.com/flant/werf/pkg/logging"
 	"github.com/flant/werf/pkg/storage"
 )
 
@@ -19,8 +21,10 @@ type CommonRepoOptions struct {
 
 type ImagesRepoManager interface {
 	ImagesRepo() string
-	ImageRepo(imageName string) string
-	ImageRepoWithTag(imageName, tag string) string
+	ImageRepo(imageName string) (string, error)
+	// ImageRepos returns the image repositories of all processed environments in the template images repo mode
+	ImageRepos(imageName string) ([]string, error)
+	ImageRepoWithTag(imageName, tag string) (string, error)
 	IsMonorepo() bool
 }
 
@@ -38,7 +42,7 @@ func repoImages(options CommonRepoOptions) (repoImages []docker_registry.RepoIma
 }
 
 func repoImagesByImageName(options CommonRepoOptions) (repoImagesByImageName map[string][]docker_registry.RepoImage, err error) {
-	if err := logboek.LogProcess("Getting repo images", logboek.LogProcessOptions{}, func() error {
+	if err := logging.LogProcess("Getting repo images", logboek.LogProcessOptions{}, func() error {
 		if options.ImagesRepoManager.IsMonorepo() {
 			repoImagesByImageName, err = monorepoRepoImages(options)
 		} else {
@@ -59,7 +63,12 @@ func monorepoRepoImages(options CommonRepoOptions) (map[string][]docker_registry
 		repoImagesByImageName[imageName] = []docker_registry.RepoImage{}
 	}
 
-	repoImages, err := docker_registry.ImagesByWerfImageLabel(options.ImagesRepoManager.ImagesRepo(), "true")
+	dockerRegistry, err := docker_registry.NewDockerRegistry(options.ImagesRepoManager.ImagesRepo(), "")
+	if err != nil {
+		return nil, err
+	}
+
+	repoImages, err := dockerRegistry.ImagesByWerfImageLabel(options.ImagesRepoManager.ImagesRepo(), "true")
 	if err != nil {
 		return nil, err
 	}
@@ -92,76 +101,248 @@ func multirepoRepoImages(options CommonRepoOptions) (map[string][]docker_registr
 	for _, imageName := range options.ImagesNames {
 		repoImagesByImageName[imageName] = []docker_registry.RepoImage{}
 
-		imageRepo := options.ImagesRepoManager.ImageRepo(imageName)
-		images, err := docker_registry.ImagesByWerfImageLabel(imageRepo, "true")
+		imageRepos, err := options.ImagesRepoManager.ImageRepos(imageName)
 		if err != nil {
 			return nil, err
 		}
 
-		repoImagesByImageName[imageName] = append(repoImagesByImageName[imageName], images...)
+		for _, imageRepo := range imageRepos {
+			dockerRegistry, err := docker_registry.NewDockerRegistry(imageRepo, "")
+			if err != nil {
+				return nil, err
+			}
+
+			images, err := dockerRegistry.ImagesByWerfImageLabel(imageRepo, "true")
+			if err != nil {
+				return nil, err
+			}
+
+			repoImagesByImageName[imageName] = append(repoImagesByImageName[imageName], images...)
+		}
 	}
 
 	return repoImagesByImageName, nil
 }
 
 func repoImageStagesImages(options CommonRepoOptions) ([]docker_registry.RepoImage, error) {
-	return docker_registry.ImagesByWerfImageLabel(options.StagesStorage.String(), "false")
+	dockerRegistry, err := docker_registry.NewDockerRegistry(options.StagesStorage.String(), "")
+	if err != nil {
+		return nil, err
+	}
+
+	return dockerRegistry.ImagesByWerfImageLabel(options.StagesStorage.String(), "false")
 }
 
 func repoImagesRemove(images []docker_registry.RepoImage, options CommonRepoOptions) error {
-	isGCR, err := docker_registry.IsGCR(options.ImagesRepoManager.ImagesRepo())
-	if err != nil {
-		return err
+	var repositories []string
+	imagesByRepository := map[string][]docker_registry.RepoImage{}
+	for _, image := range images {
+		if _, ok := imagesByRepository[image.Repository]; !ok {
+			repositories = append(repositories, image.Repository)
+		}
+		imagesByRepository[image.Repository] = append(imagesByRepository[image.Repository], image)
 	}
 
-	for _, image := range images {
-		if isGCR {
-			if err := GCRImageRemove(image, options); err != nil {
-				return err
-			}
-		} else {
-			if err := repoImageRemove(image, options); err != nil {
-				return err
-			}
+	for _, repository := range repositories {
+		dockerRegistry, err := docker_registry.NewDockerRegistry(repository, "")
+		if err != nil {
+			return err
+		}
+
+		if err := repositoryImagesRemove(dockerRegistry, repository, imagesByRepository[repository], options); err != nil {
+			return err
 		}
 	}
 
 	return nil
 }
 
-func GCRImageRemove(image docker_registry.RepoImage, options CommonRepoOptions) error {
-	reference := strings.Join([]string{image.Repository, image.Tag}, ":")
-	if err := repoReferenceRemove(reference, options); err != nil {
+// repositoryImagesRemove deletes the images of the repository. If the registry deletes the manifest with all its tags,
+// the images, which manifests are also tagged by the tags to keep, are skipped and each manifest is deleted once
+func repositoryImagesRemove(dockerRegistry docker_registry.DockerRegistry, repository string, images []docker_registry.RepoImage, options CommonRepoOptions) error {
+	if dockerRegistry.DeletesOnlyTag() {
+		for _, image := range images {
+			if err := repoImageRemove(dockerRegistry, image, options); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	}
+
+	tags, err := dockerRegistry.Tags(repository)
+	if err != nil {
 		return err
 	}
 
-	return nil
-}
+	imagesTags := map[string]bool{}
+	for _, image := range images {
+		imagesTags[image.Tag] = true
+	}
+
+	var keptTags []string
+	for _, tag := range tags {
+		if !imagesTags[tag] {
+			keptTags = append(keptTags, tag)
+		}
+	}
 
-func repoImageRemove(image docker_registry.RepoImage, options CommonRepoOptions) error {
-	digest, err := image.Digest()
+	keptTagsByDigest, err := docker_registry.TagsByDigest(repository, keptTags)
 	if err != nil {
 		return err
 	}
 
-	reference := strings.Join([]string{image.Repository, digest.String()}, "@")
-	if err := repoReferenceRemove(reference, options); err != nil {
+	imagesToRemove, err := exceptRepoImagesWithKeptTags(images, keptTagsByDigest)
+	if err != nil {
 		return err
 	}
 
-	logboek.Default.LogFDetails("  tag: %s\n", image.Tag)
-	logboek.LogOptionalLn()
+	removedDigests := map[string]bool{}
+	for _, image := range imagesToRemove {
+		digest, err := image.Digest()
+		if err != nil {
+			return fmt.Errorf("getting image %s:%s digest: %s", image.Repository, image.Tag, err)
+		}
+
+		if removedDigests[digest.String()] {
+			logboek.LogLn(strings.Join([]string{image.Repository, image.Tag}, ":"))
+			continue
+		}
+		removedDigests[digest.String()] = true
+
+		if err := repoImageRemove(dockerRegistry, image, options); err != nil {
+			return err
+		}
+	}
 
 	return nil
 }
 
-func repoReferenceRemove(reference string, options CommonRepoOptions) error {
-	logboek.LogLn(reference)
-	if !options.DryRun {
-		err := docker_registry.ImageDelete(reference)
+func exceptRepoImagesWithKeptTags(images []docker_registry.RepoImage, keptTagsByDigest map[string][]string) ([]docker_registry.RepoImage, error) {
+	var result []docker_registry.RepoImage
+	for _, image := range images {
+		digest, err := image.Digest()
+		if err != nil {
+			return nil, fmt.Errorf("getting image %s:%s digest: %s", image.Repository, image.Tag, err)
+		}
+
+		if keptTags := keptTagsByDigest[digest.String()]; len(keptTags) != 0 {
+			logboek.LogWarnF("WARNING: Image %s:%s is skipped: manifest %s is also tagged by %s, which should be kept, and the registry deletes the manifest with all tags\n", image.Repository, image.Tag, digest, strings.Join(keptTags, ", "))
+			continue
+		}
+
+		result = append(result, image)
+	}
+
+	return result, nil
+}
+
+// repoImagesRemoveWithSignatures also removes the detached signatures of the removed manifests, so that the signatures are not orphaned
+func repoImagesRemoveWithSignatures(images []docker_registry.RepoImage, options CommonRepoOptions) error {
+	if err := repoImagesRemove(images, options); err != nil {
+		return err
+	}
+
+	imagesByRepository := map[string][]docker_registry.RepoImage{}
+	for _, image := range images {
+		imagesByRepository[image.Repository] = append(imagesByRepository[image.Repository], image)
+	}
+
+	var signatures []docker_registry.RepoImage
+	for repository, repositoryImages := range imagesByRepository {
+		dockerRegistry, err := docker_registry.NewDockerRegistry(repository, "")
+		if err != nil {
+			return err
+		}
+
+		tags, err := dockerRegistry.Tags(repository)
 		if err != nil {
 			return err
 		}
+
+		digests, err := repoImagesDigests(repositoryImages)
+		if err != nil {
+			return err
+		}
+
+		signatureTags, err := removedManifestsSignatureTags(digests, tags, func(digest string) (bool, error) {
+			// nothing is removed in dry run mode
+			if options.DryRun {
+				return false, nil
+			}
+
+			// some registries remove only the tag, the signature is still valid for the manifest with the other tags
+			return docker_registry.IsImageExist(strings.Join([]string{repository, digest}, "@"))
+		})
+		if err != nil {
+			return err
+		}
+
+		for _, tag := range signatureTags {
+			signature, err := docker_registry.RepoImageByTag(repository, tag)
+			if err != nil {
+				return err
+			}
+
+			signatures = append(signatures, signature)
+		}
+	}
+
+	return repoImagesRemove(signatures, options)
+}
+
+func repoImagesDigests(images []docker_registry.RepoImage) ([]string, error) {
+	var digests []string
+	for _, image := range images {
+		digest, err := image.Digest()
+		if err != nil {
+			return nil, fmt.Errorf("getting image %s:%s digest: %s", image.Repository, image.Tag, err)
+		}
+
+		digests = append(digests, digest.String())
+	}
+
+	return digests, nil
+}
+
+func removedManifestsSignatureTags(digests, tags []string, isManifestExist func(digest string) (bool, error)) ([]string, error) {
+	existingTags := map[string]bool{}
+	for _, tag := range tags {
+		existingTags[tag] = true
+	}
+
+	var signatureTags []string
+	processedDigests := map[string]bool{}
+	for _, digest := range digests {
+		if processedDigests[digest] {
+			continue
+		}
+		processedDigests[digest] = true
+
+		signatureTag := docker_registry.ImageSignatureTag(digest)
+		if !existingTags[signatureTag] {
+			continue
+		}
+
+		exist, err := isManifestExist(digest)
+		if err != nil {
+			return nil, err
+		}
+
+		if !exist {
+			signatureTags = append(signatureTags, signatureTag)
+		}
+	}
+
+	return signatureTags, nil
+}
+
+func repoImageRemove(dockerRegistry docker_registry.DockerRegistry, image docker_registry.RepoImage, options CommonRepoOptions) error {
+	logboek.LogLn(strings.Join([]string{image.Repository, image.Tag}, ":"))
+	if !options.DryRun {
+		if err := dockerRegistry.DeleteRepoImage(image); err != nil {
+			return err
+		}
 	}
 
 	return nil
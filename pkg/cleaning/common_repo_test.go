@@ -0,0 +1,68 @@
+package cleaning
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/google/go-containerregistry/pkg/v1/random"
+
+	"github.com/flant/werf/pkg/docker_registry"
+)
+
+func TestRemovedManifestsSignatureTags(t *testing.T) {
+	const (
+		removedDigest       = "sha256:1111"
+		unsignedDigest      = "sha256:2222"
+		stillTaggedDigest   = "sha256:3333"
+		removedDigestSigTag = "sha256-1111.sig"
+	)
+
+	tags := []string{"v1", "v2", "v3", removedDigestSigTag, "sha256-3333.sig", "sha256-4444.sig"}
+	digests := []string{removedDigest, removedDigest, unsignedDigest, stillTaggedDigest}
+
+	var checkedDigests []string
+	signatureTags, err := removedManifestsSignatureTags(digests, tags, func(digest string) (bool, error) {
+		checkedDigests = append(checkedDigests, digest)
+		return digest == stillTaggedDigest, nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if expected := []string{removedDigestSigTag}; !reflect.DeepEqual(expected, signatureTags) {
+		t.Errorf("\n[EXPECTED]: %q\n[GOT]: %q", expected, signatureTags)
+	}
+
+	if expected := []string{removedDigest, stillTaggedDigest}; !reflect.DeepEqual(expected, checkedDigests) {
+		t.Errorf("\n[EXPECTED]: %q\n[GOT]: %q", expected, checkedDigests)
+	}
+}
+
+func TestExceptRepoImagesWithKeptTags(t *testing.T) {
+	var images []docker_registry.RepoImage
+	var digests []string
+	for _, tag := range []string{"v1", "v2"} {
+		img, err := random.Image(64, 1)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		digest, err := img.Digest()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		images = append(images, docker_registry.RepoImage{Repository: "repo", Tag: tag, Image: img})
+		digests = append(digests, digest.String())
+	}
+
+	// v1 manifest is also tagged by the kept latest tag and should not be deleted
+	result, err := exceptRepoImagesWithKeptTags(images, map[string][]string{digests[0]: {"latest"}, "sha256:3333": {"v3"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(result) != 1 || result[0].Tag != "v2" {
+		t.Errorf("\n[EXPECTED]: [v2]\n[GOT]: %v", result)
+	}
+}
@@ -11,6 +11,7 @@ import (
 	"github.com/flant/logboek"
 	"github.com/flant/shluz"
 	"github.com/flant/werf/pkg/image"
+	"github.com/flant/werf/pkg/logging"
 	"github.com/flant/werf/pkg/tmp_manager"
 )
 
@@ -27,13 +28,13 @@ func HostCleanup(options HostCleanupOptions) error {
 	}
 
 	return shluz.WithLock("host-cleanup", shluz.LockOptions{Timeout: time.Second * 600}, func() error {
-		if err := logboek.LogProcess("Running cleanup for docker containers created by werf", logboek.LogProcessOptions{}, func() error {
+		if err := logging.LogProcess("Running cleanup for docker containers created by werf", logboek.LogProcessOptions{}, func() error {
 			return safeContainersCleanup(commonOptions)
 		}); err != nil {
 			return err
 		}
 
-		if err := logboek.LogProcess("Running cleanup for dangling docker images created by werf", logboek.LogProcessOptions{}, func() error {
+		if err := logging.LogProcess("Running cleanup for dangling docker images created by werf", logboek.LogProcessOptions{}, func() error {
 			return safeDanglingImagesCleanup(commonOptions)
 		}); err != nil {
 			return nil
@@ -9,6 +9,7 @@ import (
 
 	"github.com/flant/logboek"
 
+	"github.com/flant/werf/pkg/logging"
 	"github.com/flant/werf/pkg/stapel"
 	"github.com/flant/werf/pkg/tmp_manager"
 	"github.com/flant/werf/pkg/util"
@@ -28,7 +29,7 @@ func HostPurge(options HostPurgeOptions) error {
 		DryRun:                        options.DryRun,
 	}
 
-	if err := logboek.LogProcess("Running werf docker containers purge", logboek.LogProcessOptions{}, func() error {
+	if err := logging.LogProcess("Running werf docker containers purge", logboek.LogProcessOptions{}, func() error {
 		if err := werfContainersFlushByFilterSet(filters.NewArgs(), commonOptions); err != nil {
 			return err
 		}
@@ -38,7 +39,7 @@ func HostPurge(options HostPurgeOptions) error {
 		return err
 	}
 
-	if err := logboek.LogProcess("Running werf docker images purge", logboek.LogProcessOptions{}, func() error {
+	if err := logging.LogProcess("Running werf docker images purge", logboek.LogProcessOptions{}, func() error {
 		if err := werfImagesFlushByFilterSet(filters.NewArgs(), commonOptions); err != nil {
 			return err
 		}
@@ -52,13 +53,13 @@ func HostPurge(options HostPurgeOptions) error {
 		return fmt.Errorf("tmp files purge failed: %s", err)
 	}
 
-	if err := logboek.LogProcess("Running werf home data purge", logboek.LogProcessOptions{}, func() error {
+	if err := logging.LogProcess("Running werf home data purge", logboek.LogProcessOptions{}, func() error {
 		return purgeHomeWerfFiles(commonOptions.DryRun)
 	}); err != nil {
 		return err
 	}
 
-	if err := logboek.LogProcess("Deleting stapel", logboek.LogProcessOptions{}, func() error {
+	if err := logging.LogProcess("Deleting stapel", logboek.LogProcessOptions{}, func() error {
 		return deleteStapel(commonOptions.DryRun)
 	}); err != nil {
 		return fmt.Errorf("stapel delete failed: %s", err)
@@ -32,6 +32,8 @@ type ImagesCleanupPolicies struct {
 
 	GitCommitStrategyHasExpiryPeriod bool // No expiration by default!
 	GitCommitStrategyExpiryPeriod    time.Duration
+
+	KeepForever bool // Skip cleanup of the image
 }
 
 type ImagesCleanupOptions struct {
@@ -40,10 +42,19 @@ type ImagesCleanupOptions struct {
 	KubernetesContextsClients map[string]kubernetes.Interface
 	WithoutKube               bool
 	Policies                  ImagesCleanupPolicies
+	ImagesPolicies            map[string]ImagesCleanupPolicies // Overrides Policies for the specified images
+}
+
+func (options ImagesCleanupOptions) imagePolicies(imageName string) ImagesCleanupPolicies {
+	if policies, ok := options.ImagesPolicies[imageName]; ok {
+		return policies
+	}
+
+	return options.Policies
 }
 
 func ImagesCleanup(options ImagesCleanupOptions) error {
-	return logboek.Default.LogProcess(
+	return logging.LevelLogProcess(logboek.Default,
 		"Running images cleanup",
 		logboek.LevelLogProcessOptions{Style: logboek.HighlightStyle()},
 		func() error {
@@ -62,7 +73,7 @@ func imagesCleanup(options ImagesCleanupOptions) error {
 
 		if options.LocalGit != nil {
 			if !options.WithoutKube {
-				if err := logboek.LogProcess("Skipping repo images that are being used in Kubernetes", logboek.LogProcessOptions{}, func() error {
+				if err := logging.LogProcess("Skipping repo images that are being used in Kubernetes", logboek.LogProcessOptions{}, func() error {
 					repoImagesByImageName, err = exceptRepoImagesByWhitelist(repoImagesByImageName, options.KubernetesContextsClients)
 					return err
 				}); err != nil {
@@ -70,29 +81,8 @@ func imagesCleanup(options ImagesCleanupOptions) error {
 				}
 			}
 
-			for imageName, repoImages := range repoImagesByImageName {
-				logProcessMessage := fmt.Sprintf("Processing image %s", logging.ImageLogName(imageName, false))
-				if err := logboek.Default.LogProcess(
-					logProcessMessage,
-					logboek.LevelLogProcessOptions{Style: logboek.HighlightStyle()},
-					func() error {
-						repoImages, err = repoImagesCleanupByNonexistentGitPrimitive(repoImages, options)
-						if err != nil {
-							return err
-						}
-
-						repoImages, err = repoImagesCleanupByPolicies(repoImages, options)
-						if err != nil {
-							return err
-						}
-
-						repoImagesByImageName[imageName] = repoImages
-
-						return nil
-					},
-				); err != nil {
-					return err
-				}
+			if err := repoImagesCleanupByImagesPolicies(repoImagesByImageName, options); err != nil {
+				return err
 			}
 		}
 
@@ -100,10 +90,48 @@ func imagesCleanup(options ImagesCleanupOptions) error {
 	})
 }
 
+// repoImagesCleanupByImagesPolicies cleans up images by the policies of each image, the images kept forever are skipped
+func repoImagesCleanupByImagesPolicies(repoImagesByImageName map[string][]docker_registry.RepoImage, options ImagesCleanupOptions) error {
+	for imageName, repoImages := range repoImagesByImageName {
+		policies := options.imagePolicies(imageName)
+		if policies.KeepForever {
+			logboek.Default.LogFDetails("Skipping image %s: kept forever by managed image settings\n", logging.ImageLogName(imageName, false))
+			continue
+		}
+
+		imageName, repoImages := imageName, repoImages
+		logProcessMessage := fmt.Sprintf("Processing image %s", logging.ImageLogName(imageName, false))
+		if err := logging.LevelLogProcess(logboek.Default,
+			logProcessMessage,
+			logboek.LevelLogProcessOptions{Style: logboek.HighlightStyle()},
+			func() error {
+				var err error
+				repoImages, err = repoImagesCleanupByNonexistentGitPrimitive(repoImages, options)
+				if err != nil {
+					return err
+				}
+
+				repoImages, err = repoImagesCleanupByPolicies(repoImages, policies, options)
+				if err != nil {
+					return err
+				}
+
+				repoImagesByImageName[imageName] = repoImages
+
+				return nil
+			},
+		); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
 func exceptRepoImagesByWhitelist(repoImagesByImageName map[string][]docker_registry.RepoImage, kubernetesContextsClients map[string]kubernetes.Interface) (map[string][]docker_registry.RepoImage, error) {
 	var deployedDockerImagesNames []string
 	for contextName, kubernetesClient := range kubernetesContextsClients {
-		if err := logboek.LogProcessInline(fmt.Sprintf("Getting deployed docker images (context %s)", contextName), logboek.LogProcessInlineOptions{}, func() error {
+		if err := logging.LogProcessInline(fmt.Sprintf("Getting deployed docker images (context %s)", contextName), logboek.LogProcessInlineOptions{}, func() error {
 			kubernetesClientDeployedDockerImagesNames, err := deployedDockerImages(kubernetesClient)
 			if err != nil {
 				return fmt.Errorf("cannot get deployed images: %s", err)
@@ -158,6 +186,11 @@ func repoImagesCleanupByNonexistentGitPrimitive(repoImages []docker_registry.Rep
 		}
 	}
 
+	aliasTargets, err := repoImagesAliasTargets(repoImages)
+	if err != nil {
+		return nil, err
+	}
+
 Loop:
 	for _, repoImage := range repoImages {
 		labels, err := repoImageLabels(repoImage)
@@ -177,6 +210,16 @@ Loop:
 
 		switch strategy {
 		case string(tag_strategy.GitTag):
+			if repoImageMetaTagMatch(repoImageMetaTag, gitTags...) {
+				continue Loop
+			} else {
+				nonexistentGitTagRepoImages = append(nonexistentGitTagRepoImages, repoImage)
+			}
+		case string(tag_strategy.Semver):
+			if _, isAlias := labels[image.WerfTagAliasTargetLabel]; isAlias || aliasTargets[repoImageMetaTag] {
+				continue Loop
+			}
+
 			if repoImageMetaTagMatch(repoImageMetaTag, gitTags...) {
 				continue Loop
 			} else {
@@ -211,11 +254,11 @@ Loop:
 	}
 
 	if len(nonexistentGitTagRepoImages) != 0 {
-		if err := logboek.Default.LogBlock(
+		if err := logging.LevelLogBlock(logboek.Default,
 			"Removed tags by nonexistent git-tag policy",
 			logboek.LevelLogBlockOptions{},
 			func() error {
-				return repoImagesRemove(nonexistentGitTagRepoImages, options.CommonRepoOptions)
+				return repoImagesRemoveWithSignatures(nonexistentGitTagRepoImages, options.CommonRepoOptions)
 			},
 		); err != nil {
 			return nil, err
@@ -225,11 +268,11 @@ Loop:
 	}
 
 	if len(nonexistentGitBranchRepoImages) != 0 {
-		if err := logboek.Default.LogBlock(
+		if err := logging.LevelLogBlock(logboek.Default,
 			"Removed tags by nonexistent git-branch policy",
 			logboek.LevelLogBlockOptions{},
 			func() error {
-				return repoImagesRemove(nonexistentGitBranchRepoImages, options.CommonRepoOptions)
+				return repoImagesRemoveWithSignatures(nonexistentGitBranchRepoImages, options.CommonRepoOptions)
 			},
 		); err != nil {
 			return nil, err
@@ -239,11 +282,11 @@ Loop:
 	}
 
 	if len(nonexistentGitCommitRepoImages) != 0 {
-		if err := logboek.Default.LogBlock(
+		if err := logging.LevelLogBlock(logboek.Default,
 			"Removed tags by nonexistent git-commit policy",
 			logboek.LevelLogBlockOptions{},
 			func() error {
-				return repoImagesRemove(nonexistentGitCommitRepoImages, options.CommonRepoOptions)
+				return repoImagesRemoveWithSignatures(nonexistentGitCommitRepoImages, options.CommonRepoOptions)
 			},
 		); err != nil {
 			return nil, err
@@ -265,9 +308,31 @@ func repoImageMetaTagMatch(imageMetaTag string, matches ...string) bool {
 	return false
 }
 
-func repoImagesCleanupByPolicies(repoImages []docker_registry.RepoImage, options ImagesCleanupOptions) ([]docker_registry.RepoImage, error) {
+// repoImagesAliasTargets returns meta tags of the images pointed by the floating aliases (e.g. semver 1, 1.4 and latest)
+func repoImagesAliasTargets(repoImages []docker_registry.RepoImage) (map[string]bool, error) {
+	aliasTargets := map[string]bool{}
+	for _, repoImage := range repoImages {
+		labels, err := repoImageLabels(repoImage)
+		if err != nil {
+			return nil, err
+		}
+
+		if target, ok := labels[image.WerfTagAliasTargetLabel]; ok {
+			aliasTargets[target] = true
+		}
+	}
+
+	return aliasTargets, nil
+}
+
+func repoImagesCleanupByPolicies(repoImages []docker_registry.RepoImage, policies ImagesCleanupPolicies, options ImagesCleanupOptions) ([]docker_registry.RepoImage, error) {
 	var repoImagesWithGitTagScheme, repoImagesWithGitCommitScheme []docker_registry.RepoImage
 
+	aliasTargets, err := repoImagesAliasTargets(repoImages)
+	if err != nil {
+		return nil, err
+	}
+
 	for _, repoImage := range repoImages {
 		labels, err := repoImageLabels(repoImage)
 		if err != nil {
@@ -281,6 +346,13 @@ func repoImagesCleanupByPolicies(repoImages []docker_registry.RepoImage, options
 
 		switch strategy {
 		case string(tag_strategy.GitTag):
+			repoImagesWithGitTagScheme = append(repoImagesWithGitTagScheme, repoImage)
+		case string(tag_strategy.Semver):
+			// aliases are moved on publish and alias targets are kept while aliases exist
+			if _, isAlias := labels[image.WerfTagAliasTargetLabel]; isAlias || aliasTargets[labels[image.WerfImageTagLabel]] {
+				continue
+			}
+
 			repoImagesWithGitTagScheme = append(repoImagesWithGitTagScheme, repoImage)
 		case string(tag_strategy.GitCommit):
 			repoImagesWithGitCommitScheme = append(repoImagesWithGitCommitScheme, repoImage)
@@ -288,25 +360,24 @@ func repoImagesCleanupByPolicies(repoImages []docker_registry.RepoImage, options
 	}
 
 	cleanupByPolicyOptions := repoImagesCleanupByPolicyOptions{
-		hasLimit:          options.Policies.GitTagStrategyHasLimit,
-		limit:             options.Policies.GitTagStrategyLimit,
-		hasExpiryPeriod:   options.Policies.GitTagStrategyHasExpiryPeriod,
-		expiryPeriod:      options.Policies.GitTagStrategyExpiryPeriod,
+		hasLimit:          policies.GitTagStrategyHasLimit,
+		limit:             policies.GitTagStrategyLimit,
+		hasExpiryPeriod:   policies.GitTagStrategyHasExpiryPeriod,
+		expiryPeriod:      policies.GitTagStrategyExpiryPeriod,
 		gitPrimitive:      "tag",
 		commonRepoOptions: options.CommonRepoOptions,
 	}
 
-	var err error
 	repoImages, err = repoImagesCleanupByPolicy(repoImages, repoImagesWithGitTagScheme, cleanupByPolicyOptions)
 	if err != nil {
 		return nil, err
 	}
 
 	cleanupByPolicyOptions = repoImagesCleanupByPolicyOptions{
-		hasLimit:          options.Policies.GitCommitStrategyHasLimit,
-		limit:             options.Policies.GitCommitStrategyLimit,
-		hasExpiryPeriod:   options.Policies.GitCommitStrategyHasExpiryPeriod,
-		expiryPeriod:      options.Policies.GitCommitStrategyExpiryPeriod,
+		hasLimit:          policies.GitCommitStrategyHasLimit,
+		limit:             policies.GitCommitStrategyLimit,
+		hasExpiryPeriod:   policies.GitCommitStrategyHasExpiryPeriod,
+		expiryPeriod:      policies.GitCommitStrategyExpiryPeriod,
 		gitPrimitive:      "commit",
 		commonRepoOptions: options.CommonRepoOptions,
 	}
@@ -365,11 +436,11 @@ func repoImagesCleanupByPolicy(repoImages, repoImagesWithScheme []docker_registr
 
 	if len(expiredRepoImages) != 0 {
 		logBlockMessage := fmt.Sprintf("Removed tags by git-%s date policy (created before %s)", options.gitPrimitive, expiryTime.Format("2006-01-02T15:04:05-0700"))
-		if err := logboek.Default.LogBlock(
+		if err := logging.LevelLogBlock(logboek.Default,
 			logBlockMessage,
 			logboek.LevelLogBlockOptions{},
 			func() error {
-				return repoImagesRemove(expiredRepoImages, options.commonRepoOptions)
+				return repoImagesRemoveWithSignatures(expiredRepoImages, options.commonRepoOptions)
 			},
 		); err != nil {
 			return nil, err
@@ -382,11 +453,11 @@ func repoImagesCleanupByPolicy(repoImages, repoImagesWithScheme []docker_registr
 		excessImagesByLimit := notExpiredRepoImages[:int64(len(notExpiredRepoImages))-options.limit]
 
 		logBlockMessage := fmt.Sprintf("Removed tags by git-%s limit policy (> %d)", options.gitPrimitive, options.limit)
-		if err := logboek.Default.LogBlock(
+		if err := logging.LevelLogBlock(logboek.Default,
 			logBlockMessage,
 			logboek.LevelLogBlockOptions{},
 			func() error {
-				return repoImagesRemove(excessImagesByLimit, options.commonRepoOptions)
+				return repoImagesRemoveWithSignatures(excessImagesByLimit, options.commonRepoOptions)
 			},
 		); err != nil {
 			return nil, err
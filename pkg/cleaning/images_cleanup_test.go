@@ -0,0 +1,60 @@
+package cleaning
+
+import (
+	"testing"
+
+	"github.com/flant/werf/pkg/docker_registry"
+)
+
+type gitRepoStub struct {
+	tagsListCalls int
+}
+
+func (r *gitRepoStub) IsCommitExists(_ string) (bool, error) { return true, nil }
+func (r *gitRepoStub) TagsList() ([]string, error) {
+	r.tagsListCalls++
+	return nil, nil
+}
+func (r *gitRepoStub) RemoteBranchesList() ([]string, error) { return nil, nil }
+
+func TestImagesCleanupOptions_imagePolicies(t *testing.T) {
+	options := ImagesCleanupOptions{
+		Policies:       ImagesCleanupPolicies{GitTagStrategyHasLimit: true, GitTagStrategyLimit: 10},
+		ImagesPolicies: map[string]ImagesCleanupPolicies{"backend": {KeepForever: true}},
+	}
+
+	if policies := options.imagePolicies("backend"); policies != options.ImagesPolicies["backend"] {
+		t.Errorf("\n[EXPECTED]: %+v\n[GOT]: %+v", options.ImagesPolicies["backend"], policies)
+	}
+
+	if policies := options.imagePolicies("frontend"); policies != options.Policies {
+		t.Errorf("\n[EXPECTED]: %+v\n[GOT]: %+v", options.Policies, policies)
+	}
+}
+
+func TestRepoImagesCleanupByImagesPolicies_KeepForever(t *testing.T) {
+	keptRepoImages := []docker_registry.RepoImage{{Repository: "repo/backend", Tag: "v1"}}
+	repoImagesByImageName := map[string][]docker_registry.RepoImage{
+		"backend":  keptRepoImages,
+		"frontend": {},
+	}
+
+	gitRepo := &gitRepoStub{}
+	options := ImagesCleanupOptions{
+		LocalGit:       gitRepo,
+		ImagesPolicies: map[string]ImagesCleanupPolicies{"backend": {KeepForever: true}},
+	}
+
+	if err := repoImagesCleanupByImagesPolicies(repoImagesByImageName, options); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(repoImagesByImageName["backend"]) != 1 || repoImagesByImageName["backend"][0] != keptRepoImages[0] {
+		t.Errorf("kept forever image should not be processed, got %+v", repoImagesByImageName["backend"])
+	}
+
+	// only the frontend image is processed
+	if gitRepo.tagsListCalls != 1 {
+		t.Errorf("expected 1 processed image, got %d", gitRepo.tagsListCalls)
+	}
+}
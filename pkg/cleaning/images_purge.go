@@ -2,6 +2,7 @@ package cleaning
 
 import (
 	"github.com/flant/logboek"
+	"github.com/flant/werf/pkg/logging"
 )
 
 type ImagesPurgeOptions struct {
@@ -11,7 +12,7 @@ type ImagesPurgeOptions struct {
 }
 
 func ImagesPurge(options ImagesPurgeOptions) error {
-	return logboek.Default.LogProcess(
+	return logging.LevelLogProcess(logboek.Default,
 		"Running images purge",
 		logboek.LevelLogProcessOptions{Style: logboek.HighlightStyle()},
 		func() error {
@@ -32,7 +33,7 @@ func imagesPurge(options ImagesPurgeOptions) error {
 		return err
 	}
 
-	err = repoImagesRemove(imageImages, commonRepoOptions)
+	err = repoImagesRemoveWithSignatures(imageImages, commonRepoOptions)
 	if err != nil {
 		return err
 	}
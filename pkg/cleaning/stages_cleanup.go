@@ -6,6 +6,7 @@ import (
 	"strings"
 	"time"
 
+	"github.com/flant/werf/pkg/logging"
 	"github.com/flant/werf/pkg/storage"
 
 	"github.com/docker/docker/api/types"
@@ -28,7 +29,7 @@ type StagesCleanupOptions struct {
 }
 
 func StagesCleanup(options StagesCleanupOptions) error {
-	return logboek.Default.LogProcess(
+	return logging.LevelLogProcess(logboek.Default,
 		"Running stages cleanup",
 		logboek.LevelLogProcessOptions{Style: logboek.HighlightStyle()},
 		func() error {
@@ -9,6 +9,7 @@ import (
 	"github.com/flant/logboek"
 	"github.com/flant/werf/pkg/docker"
 	"github.com/flant/werf/pkg/image"
+	"github.com/flant/werf/pkg/logging"
 )
 
 type StagesPurgeOptions struct {
@@ -18,7 +19,7 @@ type StagesPurgeOptions struct {
 }
 
 func StagesPurge(options StagesPurgeOptions) error {
-	return logboek.Default.LogProcess(
+	return logging.LevelLogProcess(logboek.Default,
 		"Running stages purge",
 		logboek.LevelLogProcessOptions{Style: logboek.HighlightStyle()},
 		func() error {
@@ -1,5 +1,10 @@
 package config
 
+import (
+	"fmt"
+	"path"
+)
+
 type Ansible struct {
 	BeforeInstall             []*AnsibleTask
 	Install                   []*AnsibleTask
@@ -10,6 +15,10 @@ type Ansible struct {
 	InstallCacheVersion       string
 	BeforeSetupCacheVersion   string
 	SetupCacheVersion         string
+	// Roles, Requirements and Library are paths in the project git repository
+	Roles        []string
+	Requirements string
+	Library      string
 
 	raw *rawAnsible
 }
@@ -18,6 +27,34 @@ func (c *Ansible) GetDumpConfigSection() string {
 	return dumpConfigDoc(c.raw.rawImage.doc)
 }
 
+// ValidateLibraryModules checks that the tasks use the supported modules or the custom modules of the library
+func (c *Ansible) ValidateLibraryModules(libraryModules []string) error {
+	return c.raw.validateTasksModules(append(c.raw.modules(), libraryModules...), false)
+}
+
 func (c *Ansible) validate() error {
+	for _, role := range c.Roles {
+		if !isProjectRepositoryRelativePath(role) {
+			return newDetailedConfigError(fmt.Sprintf("invalid role `%s`: relative path to the role directory in the project git repository required!", role), c.raw, c.raw.rawImage.doc)
+		}
+	}
+
+	if c.Requirements != "" && !isProjectRepositoryRelativePath(c.Requirements) {
+		return newDetailedConfigError("`requirements: PATH` relative path in the project git repository required!", c.raw, c.raw.rawImage.doc)
+	}
+
+	if c.Library != "" && !isProjectRepositoryRelativePath(c.Library) {
+		return newDetailedConfigError("`library: PATH` relative path in the project git repository required!", c.raw, c.raw.rawImage.doc)
+	}
+
+	roleNames := map[string]bool{}
+	for _, role := range c.Roles {
+		roleName := path.Base(role)
+		if roleNames[roleName] {
+			return newDetailedConfigError(fmt.Sprintf("duplicate role name `%s`: role name is the base name of the role directory!", roleName), c.raw, c.raw.rawImage.doc)
+		}
+		roleNames[roleName] = true
+	}
+
 	return nil
 }
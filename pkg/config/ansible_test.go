@@ -0,0 +1,42 @@
+package config
+
+import (
+	. "github.com/onsi/ginkgo/extensions/table"
+	. "github.com/onsi/gomega"
+)
+
+var _ = DescribeTable("ansible custom modules", func(library, task string, libraryModules []string, expectedErr string) {
+	docs, err := splitByDocs(`configVersion: 1
+project: demo
+---
+image: ~
+from: alpine
+ansible:
+`+library+`
+  install:
+  - `+task+"\n", "werf.yaml")
+	Ω(err).ShouldNot(HaveOccurred())
+
+	_, rawStapelImages, _, err := splitByMetaAndRawImages(docs)
+	if err == nil {
+		var images []*StapelImage
+		images, err = rawStapelImages[0].toStapelImageDirectives()
+		if err == nil {
+			err = images[0].Ansible.ValidateLibraryModules(libraryModules)
+		}
+	}
+
+	if expectedErr != "" {
+		Ω(err).Should(HaveOccurred())
+		Ω(err.Error()).Should(ContainSubstring(expectedErr))
+		return
+	}
+	Ω(err).ShouldNot(HaveOccurred())
+},
+	Entry("supported module without library", ``, `shell: echo`, nil, ""),
+	Entry("custom module without library", ``, `custom: {}`, nil, "unsupported ansible task"),
+	Entry("custom module of library", `  library: ansible/library`, `custom: {}`, []string{"custom"}, ""),
+	Entry("custom module, which is not in library", `  library: ansible/library`, `unknown: {}`, []string{"custom"}, "unsupported ansible task"),
+	Entry("custom module with empty library", `  library: ansible/library`, `custom: {}`, nil, "unsupported ansible task"),
+	Entry("supported and custom modules in one task", `  library: ansible/library`, `{shell: echo, custom: {}}`, []string{"custom"}, "invalid ansible task"),
+)
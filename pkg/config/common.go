@@ -3,6 +3,7 @@ package config
 import (
 	"fmt"
 	"path"
+	"sort"
 	"strings"
 
 	"github.com/flant/werf/pkg/util"
@@ -17,6 +18,8 @@ type doc struct {
 	Content        []byte
 	Line           int
 	RenderFilePath string
+	// TemplatesCommits of remote templates libraries included into the doc
+	TemplatesCommits []string
 }
 
 func checkOverflow(m map[string]interface{}, configSection interface{}, doc *doc) error {
@@ -25,6 +28,7 @@ func checkOverflow(m map[string]interface{}, configSection interface{}, doc *doc
 		for k := range m {
 			keys = append(keys, k)
 		}
+		sort.Strings(keys)
 
 		message := fmt.Sprintf("unknown fields: `%s`!", strings.Join(keys, "`, `"))
 		if configSection == nil {
@@ -53,6 +57,16 @@ func isAbsolutePath(p string) bool {
 	return path.IsAbs(p)
 }
 
+// isProjectRepositoryRelativePath checks that the path is relative and does not go outside the project git repository
+func isProjectRepositoryRelativePath(p string) bool {
+	if p == "" || isAbsolutePath(p) {
+		return false
+	}
+
+	cleanPath := path.Clean(p)
+	return cleanPath != ".." && !strings.HasPrefix(cleanPath, "../")
+}
+
 func oneOrNone(conditions []bool) bool {
 	if len(conditions) == 0 {
 		return true
@@ -11,6 +11,9 @@ import (
 
 type configError struct {
 	s string
+
+	message string
+	doc     *doc
 }
 
 func (e *configError) Error() string {
@@ -18,7 +21,7 @@ func (e *configError) Error() string {
 }
 
 func newConfigError(message string) error {
-	return &configError{message}
+	return &configError{s: message}
 }
 
 func newDetailedConfigError(message string, configSection interface{}, configDoc *doc) error {
@@ -28,7 +31,18 @@ func newDetailedConfigError(message string, configSection interface{}, configDoc
 	} else {
 		errorString = fmt.Sprintf("%s\n\n%s", message, dumpConfigDoc(configDoc))
 	}
-	return newConfigError(errorString)
+	return &configError{s: errorString, message: message, doc: configDoc}
+}
+
+// newDocsConfigError is the error of relation between config sections, the error is related to the last doc
+func newDocsConfigError(message string, docs ...*doc) error {
+	errorString := fmt.Sprintf("%s\n\n", message)
+	for _, d := range docs {
+		errorString += dumpConfigDoc(d)
+	}
+	errorString += "\n"
+
+	return &configError{s: errorString, message: message, doc: docs[len(docs)-1]}
 }
 
 func getLines(data []byte) [][]byte {
@@ -8,6 +8,7 @@ import (
 type GitExportBase struct {
 	*GitExport
 	StageDependencies *StageDependencies
+	Lfs               *GitLfs
 }
 
 func (c *ExportBase) GitMappingAdd() string {
@@ -0,0 +1,24 @@
+package config
+
+import "net/url"
+
+type GitLfs struct {
+	Enabled bool
+	Url     string
+
+	raw *rawGitLfs
+}
+
+func (c *GitLfs) validate() error {
+	if c.Url != "" && !c.Enabled {
+		return newDetailedConfigError("`url: URL` requires `enabled: true`!", c.raw, c.raw.rawGit.rawStapelImage.doc)
+	}
+
+	if c.Url != "" {
+		if u, err := url.Parse(c.Url); err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+			return newDetailedConfigError("`url: URL` should be http(s) url of git lfs server!", c.raw, c.raw.rawGit.rawStapelImage.doc)
+		}
+	}
+
+	return nil
+}
@@ -2,8 +2,10 @@ package config
 
 type GitRemote struct {
 	*GitRemoteExport
-	Name string
-	Url  string
+	Name  string
+	Url   string
+	Auth  *GitRemoteAuth
+	Clone *GitRemoteClone
 
 	raw *rawGit
 }
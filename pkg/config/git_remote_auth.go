@@ -0,0 +1,32 @@
+package config
+
+import (
+	"regexp"
+	"strings"
+)
+
+var envNameRegexp = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+type GitRemoteAuth struct {
+	Username    string
+	PasswordEnv string
+	KnownHosts  string
+
+	raw *rawGitAuth
+}
+
+func (c *GitRemoteAuth) validate() error {
+	if c.Username != "" && c.PasswordEnv == "" {
+		return newDetailedConfigError("`passwordEnv: ENV_NAME` with the password or access token is required for `username: USERNAME`!", c.raw, c.raw.rawGit.rawStapelImage.doc)
+	}
+
+	if c.PasswordEnv != "" && !envNameRegexp.MatchString(c.PasswordEnv) {
+		return newDetailedConfigError("`passwordEnv: ENV_NAME` should be a name of environment variable, not the password itself!", c.raw, c.raw.rawGit.rawStapelImage.doc)
+	}
+
+	if strings.ContainsAny(c.Username, "'\n") {
+		return newDetailedConfigError("`username: USERNAME` should not contain quotes and newlines!", c.raw, c.raw.rawGit.rawStapelImage.doc)
+	}
+
+	return nil
+}
@@ -0,0 +1,24 @@
+package config
+
+import "regexp"
+
+var gitCloneFilterRegexp = regexp.MustCompile(`^(blob:none|blob:limit=[0-9]+[kmg]?)$`)
+
+type GitRemoteClone struct {
+	Depth  int
+	Filter string
+
+	raw *rawGitClone
+}
+
+func (c *GitRemoteClone) validate() error {
+	if c.Depth < 0 {
+		return newDetailedConfigError("`depth: DEPTH` should be positive number!", c.raw, c.raw.rawGit.rawStapelImage.doc)
+	}
+
+	if c.Filter != "" && !gitCloneFilterRegexp.MatchString(c.Filter) {
+		return newDetailedConfigError("`filter: FILTER` should be `blob:none` or `blob:limit=SIZE`!", c.raw, c.raw.rawGit.rawStapelImage.doc)
+	}
+
+	return nil
+}
@@ -0,0 +1,16 @@
+package config
+
+import (
+	. "github.com/onsi/ginkgo/extensions/table"
+	. "github.com/onsi/gomega"
+)
+
+var _ = DescribeTable("validating git clone filter", func(filter string, expectedMatch bool) {
+	Ω(gitCloneFilterRegexp.MatchString(filter)).Should(Equal(expectedMatch))
+},
+	Entry("blob:none", "blob:none", true),
+	Entry("blob:limit", "blob:limit=1024", true),
+	Entry("blob:limit with unit", "blob:limit=1m", true),
+	Entry("blob:limit without size", "blob:limit=", false),
+	Entry("tree filter", "tree:0", false),
+	Entry("option injection", "blob:none --upload-pack=sh", false))
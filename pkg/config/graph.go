@@ -0,0 +1,311 @@
+package config
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+const (
+	ImagesGraphEdgeFromImage         = "fromImage"
+	ImagesGraphEdgeFromImageArtifact = "fromImageArtifact"
+	ImagesGraphEdgeImport            = "import"
+	ImagesGraphEdgeMount             = "mount"
+)
+
+type ImagesGraph struct {
+	Nodes  []*ImagesGraphNode  `json:"nodes"`
+	Edges  []*ImagesGraphEdge  `json:"edges"`
+	Cycles []*ImagesGraphCycle `json:"cycles,omitempty"`
+}
+
+type ImagesGraphNode struct {
+	Name string `json:"name"`
+	Type string `json:"type"` // image, artifact or dockerfileImage
+	// Selected node is built when images to process are specified (all images are processed by default)
+	Selected bool `json:"selected"`
+	// BuildOrder of the selected node, 0 if not selected or there are cycles in the graph
+	BuildOrder int `json:"buildOrder,omitempty"`
+
+	imageInterface ImageInterface
+}
+
+// ImagesGraphEdge directed from dependent image (From) to its dependency (To).
+// Mount edge links images, which share the same build_dir mount, and does not affect build order
+type ImagesGraphEdge struct {
+	From   string `json:"from"`
+	To     string `json:"to"`
+	Type   string `json:"type"`
+	Detail string `json:"detail,omitempty"`
+}
+
+type ImagesGraphCycle struct {
+	Edges []*ImagesGraphEdge `json:"edges"`
+}
+
+func (c *ImagesGraphCycle) String() string {
+	var parts []string
+	for _, edge := range c.Edges {
+		parts = append(parts, fmt.Sprintf("%s -(%s)->", ImagesGraphNodeName(edge.From), edge.Type))
+	}
+	parts = append(parts, ImagesGraphNodeName(c.Edges[0].From))
+
+	return strings.Join(parts, " ")
+}
+
+func (c *ImagesGraphCycle) Explanation() string {
+	var lines []string
+	for _, edge := range c.Edges {
+		var relation string
+		switch edge.Type {
+		case ImagesGraphEdgeFromImage:
+			relation = "is based on image"
+		case ImagesGraphEdgeFromImageArtifact:
+			relation = "is based on artifact"
+		case ImagesGraphEdgeImport:
+			relation = "imports files from"
+		}
+
+		lines = append(lines, fmt.Sprintf("`%s` %s `%s`", ImagesGraphNodeName(edge.From), relation, ImagesGraphNodeName(edge.To)))
+	}
+
+	return strings.Join(lines, ", ")
+}
+
+// GetImagesGraph loads werf.yaml without loops validation and returns relations between images and artifacts
+func GetImagesGraph(werfConfigPath string, imageNamesToProcess []string) (*ImagesGraph, error) {
+	werfConfig, err := getWerfConfig(werfConfigPath, false, false)
+	if err != nil {
+		return nil, err
+	}
+
+	return werfConfig.ImagesGraph(imageNamesToProcess)
+}
+
+func (c *WerfConfig) ImagesGraph(imageNamesToProcess []string) (*ImagesGraph, error) {
+	g := &ImagesGraph{}
+
+	for _, image := range c.StapelImages {
+		g.Nodes = append(g.Nodes, &ImagesGraphNode{Name: image.Name, Type: "image", imageInterface: image})
+	}
+
+	for _, image := range c.ImagesFromDockerfile {
+		g.Nodes = append(g.Nodes, &ImagesGraphNode{Name: image.Name, Type: "dockerfileImage", imageInterface: image})
+	}
+
+	for _, artifact := range c.Artifacts {
+		g.Nodes = append(g.Nodes, &ImagesGraphNode{Name: artifact.Name, Type: "artifact", imageInterface: artifact})
+	}
+
+	buildDirMountUsers := map[string][]string{}
+	for _, node := range g.Nodes {
+		image, ok := node.imageInterface.(StapelImageInterface)
+		if !ok {
+			continue
+		}
+
+		imageBaseConfig := image.ImageBaseConfig()
+		if imageBaseConfig.FromImageName != "" {
+			g.addEdge(node.Name, imageBaseConfig.FromImageName, ImagesGraphEdgeFromImage, "")
+		}
+
+		if imageBaseConfig.FromImageArtifactName != "" {
+			g.addEdge(node.Name, imageBaseConfig.FromImageArtifactName, ImagesGraphEdgeFromImageArtifact, "")
+		}
+
+		for _, imp := range image.imports() {
+			detail := fmt.Sprintf("%s -> %s", imp.Add, imp.To)
+			if imp.Before != "" {
+				detail += fmt.Sprintf(" before %s", imp.Before)
+			} else if imp.After != "" {
+				detail += fmt.Sprintf(" after %s", imp.After)
+			}
+
+			if imp.ImageName != "" {
+				g.addEdge(node.Name, imp.ImageName, ImagesGraphEdgeImport, detail)
+			} else if imp.ArtifactName != "" {
+				g.addEdge(node.Name, imp.ArtifactName, ImagesGraphEdgeImport, detail)
+			}
+		}
+
+		for _, mount := range imageBaseConfig.Mount {
+			if mount.Type == "build_dir" {
+				buildDirMountUsers[mount.To] = append(buildDirMountUsers[mount.To], node.Name)
+			}
+		}
+	}
+
+	for _, to := range sortedKeys(buildDirMountUsers) {
+		users := buildDirMountUsers[to]
+		for i := 1; i < len(users); i++ {
+			if users[i] != users[0] {
+				g.addEdge(users[0], users[i], ImagesGraphEdgeMount, fmt.Sprintf("build_dir %s", to))
+			}
+		}
+	}
+
+	g.Cycles = g.findCycles()
+
+	imagesToProcess, err := c.imagesToProcess(imageNamesToProcess)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(g.Cycles) == 0 {
+		// the same resolution as the conveyor does
+		for ind, image := range c.ImagesInBuildOrder(imagesToProcess) {
+			node := g.node(image.GetName())
+			node.Selected = true
+			node.BuildOrder = ind + 1
+		}
+	} else {
+		for _, image := range imagesToProcess {
+			g.selectWithDependencies(g.node(image.GetName()))
+		}
+	}
+
+	return g, nil
+}
+
+func (c *WerfConfig) imagesToProcess(imageNamesToProcess []string) ([]ImageInterface, error) {
+	if len(imageNamesToProcess) == 0 {
+		return c.GetAllImages(), nil
+	}
+
+	var images []ImageInterface
+	for _, imageName := range imageNamesToProcess {
+		image := c.GetImage(imageName)
+		if image == nil {
+			return nil, fmt.Errorf("specified image %s is not defined in werf.yaml", ImagesGraphNodeName(imageName))
+		}
+
+		images = append(images, image)
+	}
+
+	return images, nil
+}
+
+func (g *ImagesGraph) addEdge(from, to, edgeType, detail string) {
+	for _, edge := range g.Edges {
+		if edge.From == from && edge.To == to && edge.Type == edgeType {
+			return
+		}
+	}
+
+	g.Edges = append(g.Edges, &ImagesGraphEdge{From: from, To: to, Type: edgeType, Detail: detail})
+}
+
+// node finds image or artifact by name, the names are unique within werf.yaml
+func (g *ImagesGraph) node(name string) *ImagesGraphNode {
+	for _, node := range g.Nodes {
+		if node.Name == name {
+			return node
+		}
+	}
+
+	return nil
+}
+
+func (g *ImagesGraph) dependencyEdges(node *ImagesGraphNode) []*ImagesGraphEdge {
+	var edges []*ImagesGraphEdge
+	for _, edge := range g.Edges {
+		if edge.From == node.Name && edge.Type != ImagesGraphEdgeMount {
+			edges = append(edges, edge)
+		}
+	}
+
+	return edges
+}
+
+func (g *ImagesGraph) selectWithDependencies(node *ImagesGraphNode) {
+	if node == nil || node.Selected {
+		return
+	}
+
+	node.Selected = true
+	for _, edge := range g.dependencyEdges(node) {
+		g.selectWithDependencies(g.node(edge.To))
+	}
+}
+
+func (g *ImagesGraph) findCycles() []*ImagesGraphCycle {
+	const (
+		notVisited = iota
+		inProgress
+		done
+	)
+
+	var cycles []*ImagesGraphCycle
+	state := map[*ImagesGraphNode]int{}
+	var pathEdges []*ImagesGraphEdge
+	var pathNodes []*ImagesGraphNode
+
+	var visit func(node *ImagesGraphNode)
+	visit = func(node *ImagesGraphNode) {
+		state[node] = inProgress
+		pathNodes = append(pathNodes, node)
+
+		for _, edge := range g.dependencyEdges(node) {
+			target := g.node(edge.To)
+			if target == nil {
+				continue
+			}
+
+			switch state[target] {
+			case notVisited:
+				pathEdges = append(pathEdges, edge)
+				visit(target)
+				pathEdges = pathEdges[:len(pathEdges)-1]
+			case inProgress:
+				for ind, pathNode := range pathNodes {
+					if pathNode == target {
+						cycleEdges := append([]*ImagesGraphEdge{}, pathEdges[ind:]...)
+						cycles = append(cycles, &ImagesGraphCycle{Edges: append(cycleEdges, edge)})
+						break
+					}
+				}
+			}
+		}
+
+		pathNodes = pathNodes[:len(pathNodes)-1]
+		state[node] = done
+	}
+
+	for _, node := range g.Nodes {
+		if state[node] == notVisited {
+			visit(node)
+		}
+	}
+
+	return cycles
+}
+
+func (g *ImagesGraph) IsCycleEdge(edge *ImagesGraphEdge) bool {
+	for _, cycle := range g.Cycles {
+		for _, cycleEdge := range cycle.Edges {
+			if cycleEdge == edge {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+func ImagesGraphNodeName(name string) string {
+	if name == "" {
+		return "~"
+	}
+
+	return name
+}
+
+func sortedKeys(m map[string][]string) []string {
+	var keys []string
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	return keys
+}
@@ -0,0 +1,68 @@
+package config
+
+import (
+	. "github.com/onsi/ginkgo/extensions/table"
+	. "github.com/onsi/gomega"
+)
+
+var _ = DescribeTable("images graph", func(content string, imagesToProcess []string, expectedSelected []string, expectedCycles []string) {
+	docs, err := splitByDocs(content, "werf.yaml")
+	Ω(err).ShouldNot(HaveOccurred())
+
+	meta, rawStapelImages, rawImagesFromDockerfile, err := splitByMetaAndRawImages(docs)
+	Ω(err).ShouldNot(HaveOccurred())
+
+	werfConfig, err := prepareWerfConfig(rawStapelImages, rawImagesFromDockerfile, meta, false)
+	Ω(err).ShouldNot(HaveOccurred())
+
+	g, err := werfConfig.ImagesGraph(imagesToProcess)
+	Ω(err).ShouldNot(HaveOccurred())
+
+	var selected []string
+	for _, node := range g.Nodes {
+		if node.Selected {
+			selected = append(selected, node.Name)
+		}
+	}
+	Ω(selected).Should(Equal(expectedSelected))
+
+	var cycles []string
+	for _, cycle := range g.Cycles {
+		cycles = append(cycles, cycle.String())
+	}
+	Ω(cycles).Should(Equal(expectedCycles))
+},
+	Entry("selection pulls in dependencies", `configVersion: 1
+project: demo
+---
+artifact: builder
+from: golang
+---
+image: base
+from: alpine
+---
+image: app
+fromImage: base
+import:
+- artifact: builder
+  add: /app
+  to: /app
+  after: install
+---
+image: other
+from: alpine
+`, []string{"app"}, []string{"base", "app", "builder"}, []string(nil)),
+	Entry("infinite loop", `configVersion: 1
+project: demo
+---
+artifact: builder
+fromImage: app
+---
+image: app
+from: alpine
+import:
+- artifact: builder
+  add: /app
+  to: /app
+  after: install
+`, []string(nil), []string{"app", "builder"}, []string{"app -(import)-> builder -(fromImage)-> app"}))
@@ -7,6 +7,8 @@ type ImageFromDockerfile struct {
 	Target     string
 	Args       map[string]interface{}
 	AddHost    []string
+	// TemplatesCommits of remote templates libraries, which templates are included into the image config section
+	TemplatesCommits []string
 
 	raw *rawImageFromDockerfile
 }
@@ -0,0 +1,151 @@
+package config
+
+import (
+	"fmt"
+	"io/ioutil"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/flant/werf/pkg/tmp_manager"
+)
+
+type LintError struct {
+	FilePath string
+	Line     int // 0 if error is not related to particular line
+	Message  string
+}
+
+func (e *LintError) Error() string {
+	if e.Line == 0 {
+		return fmt.Sprintf("%s: %s", e.FilePath, e.Message)
+	}
+
+	return fmt.Sprintf("%s:%d: %s", e.FilePath, e.Line, e.Message)
+}
+
+// LintWerfConfig validates each doc of the rendered config against the config schema and by the config parser and returns all found errors,
+// relations between images are validated only if there are no errors in docs
+func LintWerfConfig(werfConfigPath string) ([]*LintError, error) {
+	werfConfigRenderContent, err := parseWerfConfigYaml(werfConfigPath)
+	if err != nil {
+		return nil, fmt.Errorf("cannot parse config: %s", err)
+	}
+
+	werfConfigRenderPath, err := tmp_manager.CreateWerfConfigRender()
+	if err != nil {
+		return nil, err
+	}
+
+	err = writeWerfConfigRender(werfConfigRenderContent, werfConfigRenderPath)
+	if err != nil {
+		return nil, fmt.Errorf("unable to write rendered config to %s: %s", werfConfigRenderPath, err)
+	}
+
+	docs, err := splitByDocs(werfConfigRenderContent, werfConfigRenderPath)
+	if err != nil {
+		return nil, err
+	}
+
+	// lines of not templated config are the same as lines of rendered one
+	lintFilePath := werfConfigRenderPath
+	if data, err := ioutil.ReadFile(werfConfigPath); err == nil && string(data) == werfConfigRenderContent {
+		lintFilePath = werfConfigPath
+	}
+
+	return lintDocs(docs, lintFilePath), nil
+}
+
+func lintDocs(docs []*doc, lintFilePath string) []*LintError {
+	var lintErrors []*LintError
+	var meta *Meta
+	var rawStapelImages []*rawStapelImage
+	var rawImagesFromDockerfile []*rawImageFromDockerfile
+
+	for _, d := range docs {
+		// docs, which do not match the schema, are not parsed, parser stops at the first error
+		if schemaLintErrors := lintDocSchema(d, lintFilePath); len(schemaLintErrors) != 0 {
+			lintErrors = append(lintErrors, schemaLintErrors...)
+			continue
+		}
+
+		docMeta, docRawStapelImages, docRawImagesFromDockerfile, err := splitByMetaAndRawImages([]*doc{d})
+		if err != nil {
+			lintErrors = append(lintErrors, newLintErrors(err, lintFilePath)...)
+			continue
+		}
+
+		if docMeta != nil {
+			if meta != nil {
+				lintErrors = append(lintErrors, newLintErrors(newDetailedConfigError("duplicate meta config section definition", nil, d), lintFilePath)...)
+			} else {
+				meta = docMeta
+			}
+		}
+
+		for _, rawImage := range docRawStapelImages {
+			if rawImage.stapelImageType() == "images" {
+				_, err = rawImage.toStapelImageDirectives()
+			} else {
+				_, err = rawImage.toStapelImageArtifactDirectives()
+			}
+
+			if err != nil {
+				lintErrors = append(lintErrors, newLintErrors(err, lintFilePath)...)
+			} else {
+				rawStapelImages = append(rawStapelImages, rawImage)
+			}
+		}
+
+		for _, rawImageFromDockerfile := range docRawImagesFromDockerfile {
+			if _, err := rawImageFromDockerfile.toImageFromDockerfileDirectives(); err != nil {
+				lintErrors = append(lintErrors, newLintErrors(err, lintFilePath)...)
+			} else {
+				rawImagesFromDockerfile = append(rawImagesFromDockerfile, rawImageFromDockerfile)
+			}
+		}
+	}
+
+	if meta == nil && len(lintErrors) == 0 {
+		lintErrors = append(lintErrors, &LintError{FilePath: lintFilePath, Line: 1, Message: "meta config section with `configVersion: 1` and `project: PROJECT_NAME` is not defined"})
+	}
+
+	if len(lintErrors) == 0 {
+		if _, err := prepareWerfConfig(rawStapelImages, rawImagesFromDockerfile, meta, true); err != nil {
+			lintErrors = append(lintErrors, newLintErrors(err, lintFilePath)...)
+		}
+	}
+
+	return lintErrors
+}
+
+var lintYamlErrorLineRegexp = regexp.MustCompile(`line ([0-9]+): (.*)$`)
+
+func newLintErrors(err error, filePath string) []*LintError {
+	configErr, ok := err.(*configError)
+	if !ok || configErr.doc == nil {
+		return []*LintError{{FilePath: filePath, Message: strings.TrimSpace(err.Error())}}
+	}
+
+	// yaml errors already contain lines of the rendered config, there may be several errors in one
+	var lintErrors []*LintError
+	for _, messageLine := range strings.Split(configErr.message, "\n") {
+		res := lintYamlErrorLineRegexp.FindStringSubmatch(messageLine)
+		if len(res) != 3 {
+			continue
+		}
+
+		line, err := strconv.Atoi(res[1])
+		if err != nil {
+			continue
+		}
+
+		lintErrors = append(lintErrors, &LintError{FilePath: filePath, Line: line, Message: res[2]})
+	}
+
+	if len(lintErrors) != 0 {
+		return lintErrors
+	}
+
+	return []*LintError{{FilePath: filePath, Line: configErr.doc.Line + 1, Message: configErr.message}}
+}
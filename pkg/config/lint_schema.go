@@ -0,0 +1,383 @@
+package config
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	yaml_v3 "gopkg.in/yaml.v3"
+)
+
+var yamlV3ErrorLineRegexp = regexp.MustCompile(`line ([0-9]+): (.*)$`)
+
+// yaml 1.1 booleans, which are decoded as booleans by the config parser
+var yamlBoolValues = []string{"y", "yes", "n", "no", "true", "false", "on", "off"}
+
+type schemaLintError struct {
+	line    int
+	message string
+}
+
+type schemaLinter struct {
+	definitions map[string]interface{}
+	// tasks with custom modules of ansible library are not described by the schema
+	withCustomModules bool
+}
+
+// lintDocSchema validates the doc node tree against the werf config JSON Schema and returns all errors with lines of the rendered config
+func lintDocSchema(d *doc, lintFilePath string) []*LintError {
+	var lintErrors []*LintError
+	for _, err := range lintDocContentSchema(d.Content) {
+		lintErrors = append(lintErrors, &LintError{FilePath: lintFilePath, Line: d.Line + err.line, Message: err.message})
+	}
+
+	return lintErrors
+}
+
+func lintDocContentSchema(content []byte) []*schemaLintError {
+	var root yaml_v3.Node
+	if err := yaml_v3.Unmarshal(content, &root); err != nil {
+		return yamlV3SyntaxErrors(err)
+	}
+
+	if root.Kind != yaml_v3.DocumentNode || len(root.Content) == 0 {
+		return nil
+	}
+
+	node := resolveYamlAlias(root.Content[0])
+	if isYamlNull(node) {
+		return nil
+	}
+
+	if node.Kind != yaml_v3.MappingNode {
+		return []*schemaLintError{{line: node.Line, message: fmt.Sprintf("config section should be a mapping, got %s", yamlNodeTypeName(node))}}
+	}
+
+	schema := werfConfigJsonSchema()
+	l := &schemaLinter{definitions: schema["definitions"].(map[string]interface{})}
+
+	// config section type is recognized by the keys, the same way as the config parser does
+	var definitionName string
+	switch keys := yamlMappingKeys(node); {
+	case keys["configVersion"]:
+		definitionName = "meta"
+	case keys["dockerfile"]:
+		definitionName = "dockerfileImage"
+	case keys["artifact"]:
+		definitionName = "artifact"
+	case keys["image"]:
+		definitionName = "image"
+	default:
+		return []*schemaLintError{{line: node.Line, message: "cannot recognize type of config section: `configVersion` required for meta config section, `image` for the image config sections, `artifact` for the artifact config sections"}}
+	}
+
+	for _, pair := range yamlMappingPairs(node) {
+		if pair.key.Value == "ansible" && pair.value.Kind == yaml_v3.MappingNode && yamlMappingKeys(pair.value)["library"] {
+			l.withCustomModules = true
+		}
+	}
+
+	errs := l.validate(node, l.definitions[definitionName].(map[string]interface{}), "")
+	sort.SliceStable(errs, func(i, j int) bool { return errs[i].line < errs[j].line })
+
+	return errs
+}
+
+func yamlV3SyntaxErrors(err error) []*schemaLintError {
+	var errs []*schemaLintError
+	for _, messageLine := range strings.Split(err.Error(), "\n") {
+		res := yamlV3ErrorLineRegexp.FindStringSubmatch(messageLine)
+		if len(res) != 3 {
+			continue
+		}
+
+		line, err := strconv.Atoi(res[1])
+		if err != nil {
+			continue
+		}
+
+		errs = append(errs, &schemaLintError{line: line, message: res[2]})
+	}
+
+	if len(errs) == 0 {
+		errs = append(errs, &schemaLintError{line: 1, message: strings.TrimPrefix(err.Error(), "yaml: ")})
+	}
+
+	return errs
+}
+
+func (l *schemaLinter) validate(node *yaml_v3.Node, schema map[string]interface{}, path string) []*schemaLintError {
+	node = resolveYamlAlias(node)
+
+	if ref, ok := schema["$ref"].(string); ok {
+		return l.validate(node, l.resolveRef(ref), path)
+	}
+
+	var errs []*schemaLintError
+
+	if anyOf, ok := schema["anyOf"].([]interface{}); ok {
+		if anyOfErrs := l.validateAnyOf(node, anyOf, path); len(anyOfErrs) != 0 {
+			return anyOfErrs
+		}
+	}
+
+	// null is decoded into the zero value by the config parser
+	if isYamlNull(node) {
+		return nil
+	}
+
+	if schemaType, ok := schema["type"].(string); ok && !isYamlNodeOfType(node, schemaType) {
+		return []*schemaLintError{{line: node.Line, message: schemaLintMessage(path, fmt.Sprintf("%s expected, got %s", schemaType, yamlNodeTypeName(node)))}}
+	}
+
+	if enum := schemaEnum(schema); enum != nil && node.Kind == yaml_v3.ScalarNode {
+		var isValid bool
+		for _, value := range enum {
+			if node.Value == value {
+				isValid = true
+			}
+		}
+
+		if !isValid {
+			errs = append(errs, &schemaLintError{line: node.Line, message: schemaLintMessage(path, fmt.Sprintf("one of `%s` expected, got `%s`", strings.Join(enum, "`, `"), node.Value))})
+		}
+	}
+
+	switch node.Kind {
+	case yaml_v3.MappingNode:
+		errs = append(errs, l.validateMapping(node, schema, path)...)
+	case yaml_v3.SequenceNode:
+		if items, ok := schema["items"].(map[string]interface{}); ok {
+			for ind, item := range node.Content {
+				errs = append(errs, l.validate(item, items, fmt.Sprintf("%s[%d]", path, ind))...)
+			}
+		}
+	}
+
+	return errs
+}
+
+func (l *schemaLinter) validateMapping(node *yaml_v3.Node, schema map[string]interface{}, path string) []*schemaLintError {
+	var errs []*schemaLintError
+
+	properties, _ := schema["properties"].(map[string]interface{})
+	keyNodes := map[string]*yaml_v3.Node{}
+	for _, pair := range yamlMappingPairs(node) {
+		keyNode, valueNode := pair.key, pair.value
+		key := keyNode.Value
+		keyPath := key
+		if path != "" {
+			keyPath = path + "." + key
+		}
+
+		// merged fields are overridden by the mapping fields
+		if _, ok := keyNodes[key]; ok {
+			if !pair.isMerged {
+				errs = append(errs, &schemaLintError{line: keyNode.Line, message: schemaLintMessage(path, fmt.Sprintf("duplicate field `%s`", key))})
+			}
+			continue
+		}
+		keyNodes[key] = keyNode
+
+		if propertySchema, ok := properties[key]; ok {
+			errs = append(errs, l.validate(valueNode, propertySchema.(map[string]interface{}), keyPath)...)
+			continue
+		}
+
+		switch additionalProperties := schema["additionalProperties"].(type) {
+		case bool:
+			if !additionalProperties {
+				errs = append(errs, &schemaLintError{line: keyNode.Line, message: schemaLintMessage(path, fmt.Sprintf("unknown field `%s`", key))})
+			}
+		case map[string]interface{}:
+			errs = append(errs, l.validate(valueNode, additionalProperties, keyPath)...)
+		}
+	}
+
+	if required, ok := schema["required"].([]string); ok {
+		for _, key := range required {
+			if _, ok := keyNodes[key]; !ok {
+				errs = append(errs, &schemaLintError{line: node.Line, message: schemaLintMessage(path, fmt.Sprintf("field `%s` required", key))})
+			}
+		}
+	}
+
+	return errs
+}
+
+// validateAnyOf returns errors of the most suitable schema if the node does not match any schema
+func (l *schemaLinter) validateAnyOf(node *yaml_v3.Node, anyOf []interface{}, path string) []*schemaLintError {
+	var bestErrs []*schemaLintError
+	var expectedTypes []string
+	isRequiredOnly := true
+
+	for _, item := range anyOf {
+		schema := item.(map[string]interface{})
+		if ref, ok := schema["$ref"].(string); ok {
+			schema = l.resolveRef(ref)
+		}
+
+		errs := l.validate(node, schema, path)
+		if len(errs) == 0 {
+			return nil
+		}
+
+		schemaType, hasType := schema["type"].(string)
+		if hasType || len(schema) != 1 || schema["required"] == nil {
+			isRequiredOnly = false
+		}
+
+		if !hasType {
+			continue
+		}
+
+		if schemaType == "array" {
+			if items, ok := schema["items"].(map[string]interface{}); ok && items["type"] != nil {
+				schemaType = fmt.Sprintf("array of %ss", items["type"])
+			}
+		}
+		expectedTypes = append(expectedTypes, schemaType)
+
+		if isYamlNodeOfType(node, schema["type"].(string)) && (bestErrs == nil || len(errs) < len(bestErrs)) {
+			bestErrs = errs
+		}
+	}
+
+	if isRequiredOnly {
+		if l.withCustomModules {
+			return nil
+		}
+
+		return []*schemaLintError{{line: node.Line, message: schemaLintMessage(path, "one of the supported modules required (see `werf config schema`)")}}
+	}
+
+	if bestErrs != nil {
+		return bestErrs
+	}
+
+	return []*schemaLintError{{line: node.Line, message: schemaLintMessage(path, fmt.Sprintf("%s expected, got %s", strings.Join(expectedTypes, " or "), yamlNodeTypeName(node)))}}
+}
+
+func schemaEnum(schema map[string]interface{}) []string {
+	switch enum := schema["enum"].(type) {
+	case []string:
+		return enum
+	case []int:
+		var res []string
+		for _, value := range enum {
+			res = append(res, strconv.Itoa(value))
+		}
+		return res
+	}
+
+	return nil
+}
+
+func (l *schemaLinter) resolveRef(ref string) map[string]interface{} {
+	return l.definitions[strings.TrimPrefix(ref, "#/definitions/")].(map[string]interface{})
+}
+
+func schemaLintMessage(path, message string) string {
+	if path == "" {
+		return message
+	}
+
+	return fmt.Sprintf("`%s`: %s", path, message)
+}
+
+type yamlMappingPair struct {
+	key, value *yaml_v3.Node
+	isMerged   bool
+}
+
+// yamlMappingPairs returns key and value nodes of the mapping followed by the pairs of the merged (<<) mappings
+func yamlMappingPairs(node *yaml_v3.Node) []*yamlMappingPair {
+	var pairs, mergedPairs []*yamlMappingPair
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		keyNode, valueNode := node.Content[i], resolveYamlAlias(node.Content[i+1])
+		if keyNode.Tag != "!!merge" {
+			pairs = append(pairs, &yamlMappingPair{key: keyNode, value: valueNode})
+			continue
+		}
+
+		mergedNodes := []*yaml_v3.Node{valueNode}
+		if valueNode.Kind == yaml_v3.SequenceNode {
+			mergedNodes = valueNode.Content
+		}
+
+		for _, mergedNode := range mergedNodes {
+			if mergedNode = resolveYamlAlias(mergedNode); mergedNode.Kind == yaml_v3.MappingNode {
+				for _, pair := range yamlMappingPairs(mergedNode) {
+					mergedPairs = append(mergedPairs, &yamlMappingPair{key: pair.key, value: pair.value, isMerged: true})
+				}
+			}
+		}
+	}
+
+	return append(pairs, mergedPairs...)
+}
+
+func yamlMappingKeys(node *yaml_v3.Node) map[string]bool {
+	keys := map[string]bool{}
+	for _, pair := range yamlMappingPairs(node) {
+		keys[pair.key.Value] = true
+	}
+
+	return keys
+}
+
+func resolveYamlAlias(node *yaml_v3.Node) *yaml_v3.Node {
+	for node.Kind == yaml_v3.AliasNode && node.Alias != nil {
+		node = node.Alias
+	}
+
+	return node
+}
+
+func isYamlNull(node *yaml_v3.Node) bool {
+	return node.Kind == yaml_v3.ScalarNode && node.Tag == "!!null"
+}
+
+func isYamlNodeOfType(node *yaml_v3.Node, schemaType string) bool {
+	switch schemaType {
+	case "object":
+		return node.Kind == yaml_v3.MappingNode
+	case "array":
+		return node.Kind == yaml_v3.SequenceNode
+	case "null":
+		return isYamlNull(node)
+	case "string":
+		return node.Kind == yaml_v3.ScalarNode && !isYamlNull(node)
+	case "integer":
+		return node.Kind == yaml_v3.ScalarNode && node.Tag == "!!int"
+	case "boolean":
+		if node.Kind != yaml_v3.ScalarNode {
+			return false
+		}
+
+		for _, value := range yamlBoolValues {
+			if strings.ToLower(node.Value) == value {
+				return true
+			}
+		}
+
+		return false
+	}
+
+	return true
+}
+
+func yamlNodeTypeName(node *yaml_v3.Node) string {
+	switch node.Kind {
+	case yaml_v3.MappingNode:
+		return "object"
+	case yaml_v3.SequenceNode:
+		return "array"
+	case yaml_v3.ScalarNode:
+		return fmt.Sprintf("`%s`", node.Value)
+	}
+
+	return "unknown value"
+}
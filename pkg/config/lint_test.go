@@ -0,0 +1,91 @@
+package config
+
+import (
+	. "github.com/onsi/ginkgo/extensions/table"
+	. "github.com/onsi/gomega"
+)
+
+var _ = DescribeTable("linting config", func(content string, expectedLines []int) {
+	docs, err := splitByDocs(content, "werf.yaml")
+	Ω(err).ShouldNot(HaveOccurred())
+
+	var lines []int
+	for _, lintError := range lintDocs(docs, "werf.yaml") {
+		lines = append(lines, lintError.Line)
+	}
+
+	Ω(lines).Should(Equal(expectedLines))
+},
+	Entry("valid config", `configVersion: 1
+project: demo
+---
+image: ~
+from: alpine
+`, []int(nil)),
+	Entry("errors in several docs", `configVersion: 1
+project: demo
+---
+image: a
+from: alpine
+git:
+- add: /
+  to: /app
+  unknown: field
+---
+image: b
+from: alpine
+docker:
+  WORKDIR: [1, 2]
+---
+artifact: c
+from: alpine
+docker:
+  WORKDIR: /app
+`, []int{9, 14, 18}),
+	Entry("several errors in one doc", `configVersion: 1
+project: demo
+---
+image: a
+from: alpine
+unknown: field
+git:
+- add: /
+  to: /app
+  unknown: field
+shell:
+  install: [1, [2]]
+mount:
+- from: unknown_dir
+  to: /cache
+`, []int{6, 10, 12, 14}),
+	Entry("image directive in artifact", `configVersion: 1
+project: demo
+---
+artifact: a
+image: b
+from: alpine
+`, []int{5}),
+	Entry("yaml syntax error", `configVersion: 1
+project: demo
+---
+image: a
+from: alpine
+git: [
+---
+image: b
+from: alpine
+docker:
+  WORKDIR: [1, 2]
+`, []int{6, 11}),
+	Entry("missing meta", `image: ~
+from: alpine
+`, []int{1}),
+	Entry("conflict between images names", `configVersion: 1
+project: demo
+---
+image: a
+from: alpine
+---
+image: a
+from: alpine
+`, []int{7}))
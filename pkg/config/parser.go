@@ -8,9 +8,11 @@ import (
 	"os"
 	"path/filepath"
 	"regexp"
+	"sort"
 	"strconv"
 	"strings"
 	"text/template"
+	"text/template/parse"
 
 	"github.com/Masterminds/sprig"
 	"gopkg.in/src-d/go-git.v4/plumbing/transport"
@@ -62,7 +64,12 @@ func RenderWerfConfig(werfConfigPath string, imagesToProcess []string) error {
 }
 
 func GetWerfConfig(werfConfigPath string, logRenderedFilePath bool) (*WerfConfig, error) {
-	werfConfigRenderContent, err := parseWerfConfigYaml(werfConfigPath)
+	return getWerfConfig(werfConfigPath, logRenderedFilePath, true)
+}
+
+// getWerfConfig without loops validation allows to inspect relations between images with infinite loop
+func getWerfConfig(werfConfigPath string, logRenderedFilePath bool, validateInfiniteLoops bool) (*WerfConfig, error) {
+	werfConfigRenderContent, templatesLibrariesUsages, err := renderWerfConfigYaml(werfConfigPath)
 	if err != nil {
 		return nil, fmt.Errorf("cannot parse config: %s", err)
 	}
@@ -86,6 +93,8 @@ func GetWerfConfig(werfConfigPath string, logRenderedFilePath bool) (*WerfConfig
 		return nil, err
 	}
 
+	setDocsTemplatesCommits(docs, werfConfigRenderContent, templatesLibrariesUsages)
+
 	meta, rawStapelImages, rawImagesFromDockerfile, err := splitByMetaAndRawImages(docs)
 	if err != nil {
 		return nil, err
@@ -113,7 +122,7 @@ func GetWerfConfig(werfConfigPath string, logRenderedFilePath bool) (*WerfConfig
 		return nil, fmt.Errorf(format, defaultProjectName)
 	}
 
-	werfConfig, err := prepareWerfConfig(rawStapelImages, rawImagesFromDockerfile, meta)
+	werfConfig, err := prepareWerfConfig(rawStapelImages, rawImagesFromDockerfile, meta, validateInfiniteLoops)
 	if err != nil {
 		return nil, err
 	}
@@ -202,15 +211,178 @@ func splitByDocs(werfConfigRenderContent string, werfConfigRenderPath string) ([
 	return docs, nil
 }
 
+func setDocsTemplatesCommits(docs []*doc, werfConfigRenderContent string, templatesLibrariesUsages []*templatesLibraryUsage) {
+	for _, usage := range templatesLibrariesUsages {
+		startLine := strings.Count(werfConfigRenderContent[:usage.Offset], "\n")
+		endLine := startLine
+		if usage.End > usage.Offset {
+			endLine = strings.Count(werfConfigRenderContent[:usage.End-1], "\n")
+		}
+
+		// the commit is attributed to every doc the library template output lands in
+		for _, d := range docs {
+			if endLine < d.Line || startLine >= d.Line+len(getLines(d.Content)) {
+				continue
+			}
+
+			if !util.IsStringsContainValue(d.TemplatesCommits, usage.Commit) {
+				d.TemplatesCommits = append(d.TemplatesCommits, usage.Commit)
+			}
+		}
+	}
+}
+
 func parseWerfConfigYaml(werfConfigPath string) (string, error) {
+	werfConfigRenderContent, _, err := renderWerfConfigYaml(werfConfigPath)
+	return werfConfigRenderContent, err
+}
+
+// templatesLibraryUsage is an output of remote templates library template in rendered config
+type templatesLibraryUsage struct {
+	Offset int
+	End    int
+	Commit string
+}
+
+// renderWerfConfigYaml returns rendered config and usages of remote templates libraries in it
+func renderWerfConfigYaml(werfConfigPath string) (string, []*templatesLibraryUsage, error) {
+	templatesLibraries, err := getTemplatesLibraries(werfConfigPath)
+	if err != nil {
+		return "", nil, err
+	}
+
+	r := newWerfConfigRenderer(false)
+
+	projectDir := filepath.Dir(werfConfigPath)
+	for _, templatesLibrary := range templatesLibraries {
+		loadedTemplatesLibrary, err := templatesLibrary.load(projectDir)
+		if err != nil {
+			return "", nil, err
+		}
+
+		for _, templateName := range sortedTemplatesNames(loadedTemplatesLibrary.Templates) {
+			if err := r.parseLibraryTemplate(templateName, loadedTemplatesLibrary.Templates[templateName], loadedTemplatesLibrary.Commit); err != nil {
+				return "", nil, fmt.Errorf("templates library %s: %s", templatesLibrary, err)
+			}
+		}
+	}
+
+	werfConfigRenderContent, err := r.render(werfConfigPath)
+	if err != nil {
+		return "", nil, err
+	}
+
+	return werfConfigRenderContent, r.templatesLibrariesUsages, nil
+}
+
+// getTemplatesLibraries renders config without templates libraries to get meta config section,
+// thus `templates` directive cannot be generated with the library templates
+func getTemplatesLibraries(werfConfigPath string) ([]*TemplatesLibrary, error) {
+	werfConfigRenderContent, err := newWerfConfigRenderer(true).render(werfConfigPath)
+	if err != nil {
+		// the error will be reported by the regular rendering
+		return nil, nil
+	}
+
+	docs, err := splitByDocs(werfConfigRenderContent, "")
+	if err != nil {
+		return nil, nil
+	}
+
+	parentStack = util.NewStack()
+	for _, doc := range docs {
+		var raw map[string]interface{}
+		if err := yaml.Unmarshal(doc.Content, &raw); err != nil || !isMetaDoc(raw) {
+			continue
+		}
+
+		if _, ok := raw["templates"]; !ok {
+			return nil, nil
+		}
+
+		rawMeta := &rawMeta{doc: doc}
+		if err := yaml.UnmarshalStrict(doc.Content, &rawMeta); err != nil {
+			return nil, newYamlUnmarshalError(err, doc)
+		}
+
+		return rawMeta.toMeta().Templates, nil
+	}
+
+	return nil, nil
+}
+
+type werfConfigRenderer struct {
+	tmpl *template.Template
+	// lenient renderer includes not defined templates as empty strings
+	lenient bool
+
+	// output of werf.yaml rendering to get offsets of library templates usages
+	out                      *werfConfigRenderOutput
+	templatesLibrariesTrees  map[*parse.Tree]string
+	templatesLibrariesUsages []*templatesLibraryUsage
+}
+
+// werfConfigRenderOutput ends pending usages with the next write, that is the output of the action which uses library template
+type werfConfigRenderOutput struct {
+	bytes.Buffer
+	pendingUsages []*templatesLibraryUsage
+}
+
+func (o *werfConfigRenderOutput) Write(p []byte) (int, error) {
+	n, err := o.Buffer.Write(p)
+	o.endPendingUsages()
+	return n, err
+}
+
+func (o *werfConfigRenderOutput) endPendingUsages() {
+	for _, usage := range o.pendingUsages {
+		usage.End = o.Len()
+	}
+	o.pendingUsages = nil
+}
+
+func newWerfConfigRenderer(lenient bool) *werfConfigRenderer {
+	r := &werfConfigRenderer{
+		lenient:                 lenient,
+		templatesLibrariesTrees: map[*parse.Tree]string{},
+	}
+
+	r.tmpl = template.New("werfConfig")
+	r.tmpl.Funcs(r.funcMap())
+
+	return r
+}
+
+// parseLibraryTemplate adds all templates defined in the library file, project templates with the same names override them
+func (r *werfConfigRenderer) parseLibraryTemplate(name string, data []byte, commit string) error {
+	libraryTemplate, err := template.New(name).Funcs(r.funcMap()).Parse(string(data))
+	if err != nil {
+		return err
+	}
+
+	for _, t := range libraryTemplate.Templates() {
+		if t.Tree == nil {
+			continue
+		}
+
+		if _, err := r.tmpl.AddParseTree(t.Name(), t.Tree); err != nil {
+			return err
+		}
+
+		if commit != "" {
+			r.templatesLibrariesTrees[t.Tree] = commit
+		}
+	}
+
+	return nil
+}
+
+func (r *werfConfigRenderer) render(werfConfigPath string) (string, error) {
 	data, err := ioutil.ReadFile(werfConfigPath)
 	if err != nil {
 		return "", err
 	}
 
-	tmpl := template.New("werfConfig")
-	tmpl.Funcs(funcMap(tmpl))
-
 	projectDir := filepath.Dir(werfConfigPath)
 	werfConfigsDir := filepath.Join(projectDir, ".werf")
 	werfConfigsTemplates, err := getWerfConfigsTemplates(werfConfigsDir)
@@ -225,7 +397,7 @@ func parseWerfConfigYaml(werfConfigPath string) (string, error) {
 				return "", err
 			}
 
-			extraTemplate := tmpl.New(templateName)
+			extraTemplate := r.tmpl.New(templateName)
 
 			var filePathData []byte
 			if filePathData, err = ioutil.ReadFile(templatePath); err != nil {
@@ -238,14 +410,91 @@ func parseWerfConfigYaml(werfConfigPath string) (string, error) {
 		}
 	}
 
-	if _, err := tmpl.Parse(string(data)); err != nil {
+	if _, err := r.tmpl.Parse(string(data)); err != nil {
 		return "", err
 	}
 
 	files := files{filepath.Dir(werfConfigPath)}
-	config, err := executeTemplate(tmpl, "werfConfig", map[string]interface{}{"Files": files})
 
-	return config, err
+	return r.execute(map[string]interface{}{"Files": files})
+}
+
+func (r *werfConfigRenderer) execute(data interface{}) (string, error) {
+	for _, t := range r.tmpl.Templates() {
+		if t.Tree == nil {
+			continue
+		}
+
+		if err := r.replaceTemplateActions(t.Tree.Root); err != nil {
+			return "", err
+		}
+	}
+
+	r.out = &werfConfigRenderOutput{}
+	if err := r.tmpl.ExecuteTemplate(r.out, "werfConfig", data); err != nil {
+		return "", err
+	}
+	r.out.endPendingUsages()
+
+	return r.out.String(), nil
+}
+
+// replaceTemplateActions replaces {{ template "name" pipeline }} actions with {{ include "name" pipeline }} ones,
+// thus usages of library templates by the template action are tracked the same way as by include
+func (r *werfConfigRenderer) replaceTemplateActions(node parse.Node) error {
+	switch n := node.(type) {
+	case *parse.ListNode:
+		if n == nil {
+			return nil
+		}
+
+		for ind, listNode := range n.Nodes {
+			templateNode, ok := listNode.(*parse.TemplateNode)
+			if !ok {
+				if err := r.replaceTemplateActions(listNode); err != nil {
+					return err
+				}
+				continue
+			}
+
+			actionNode, err := r.includeActionNode(templateNode)
+			if err != nil {
+				return err
+			}
+			n.Nodes[ind] = actionNode
+		}
+	case *parse.IfNode:
+		return r.replaceBranchTemplateActions(&n.BranchNode)
+	case *parse.RangeNode:
+		return r.replaceBranchTemplateActions(&n.BranchNode)
+	case *parse.WithNode:
+		return r.replaceBranchTemplateActions(&n.BranchNode)
+	}
+
+	return nil
+}
+
+func (r *werfConfigRenderer) replaceBranchTemplateActions(n *parse.BranchNode) error {
+	if err := r.replaceTemplateActions(n.List); err != nil {
+		return err
+	}
+
+	return r.replaceTemplateActions(n.ElseList)
+}
+
+func (r *werfConfigRenderer) includeActionNode(templateNode *parse.TemplateNode) (*parse.ActionNode, error) {
+	trees, err := parse.Parse("include", fmt.Sprintf("{{ include %q nil }}", templateNode.Name), "", "", map[string]interface{}(r.funcMap()))
+	if err != nil {
+		return nil, err
+	}
+
+	actionNode := trees["include"].Root.Nodes[0].(*parse.ActionNode)
+	actionNode.Pos, actionNode.Line = templateNode.Pos, templateNode.Line
+	if templateNode.Pipe != nil {
+		actionNode.Pipe.Cmds[0].Args[2] = templateNode.Pipe
+	}
+
+	return actionNode, nil
 }
 
 func getWerfConfigsTemplates(path string) ([]string, error) {
@@ -282,14 +531,32 @@ func getWerfConfigsTemplates(path string) ([]string, error) {
 	return templates, nil
 }
 
-func funcMap(tmpl *template.Template) template.FuncMap {
+func (r *werfConfigRenderer) funcMap() template.FuncMap {
 	funcMap := sprig.TxtFuncMap()
 	funcMap["include"] = func(name string, data interface{}) (string, error) {
-		return executeTemplate(tmpl, name, data)
+		return r.include(name, data)
 	}
 	return funcMap
 }
 
+func (r *werfConfigRenderer) include(name string, data interface{}) (string, error) {
+	t := r.tmpl.Lookup(name)
+	if t == nil && r.lenient {
+		return "", nil
+	}
+
+	if t != nil {
+		if commit, ok := r.templatesLibrariesTrees[t.Tree]; ok && r.out != nil {
+			// included content is written into the output after the current offset with the action output
+			usage := &templatesLibraryUsage{Offset: r.out.Len(), Commit: commit}
+			r.templatesLibrariesUsages = append(r.templatesLibrariesUsages, usage)
+			r.out.pendingUsages = append(r.out.pendingUsages, usage)
+		}
+	}
+
+	return executeTemplate(r.tmpl, name, data)
+}
+
 func executeTemplate(tmpl *template.Template, name string, data interface{}) (string, error) {
 	buf := bytes.NewBuffer(nil)
 	if err := tmpl.ExecuteTemplate(buf, name, data); err != nil {
@@ -298,6 +565,16 @@ func executeTemplate(tmpl *template.Template, name string, data interface{}) (st
 	return buf.String(), nil
 }
 
+func sortedTemplatesNames(templates map[string][]byte) []string {
+	var names []string
+	for name := range templates {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return names
+}
+
 type files struct {
 	HomePath string
 }
@@ -434,7 +711,7 @@ func emptyDocContent(content []byte) bool {
 	return true
 }
 
-func prepareWerfConfig(rawImages []*rawStapelImage, rawImagesFromDockerfile []*rawImageFromDockerfile, meta *Meta) (*WerfConfig, error) {
+func prepareWerfConfig(rawImages []*rawStapelImage, rawImagesFromDockerfile []*rawImageFromDockerfile, meta *Meta, validateInfiniteLoops bool) (*WerfConfig, error) {
 	var stapelImages []*StapelImage
 	var imagesFromDockerfile []*ImageFromDockerfile
 	var artifacts []*StapelImageArtifact
@@ -486,8 +763,10 @@ func prepareWerfConfig(rawImages []*rawStapelImage, rawImagesFromDockerfile []*r
 		return nil, err
 	}
 
-	if err := werfConfig.validateInfiniteLoopBetweenRelatedImages(); err != nil {
-		return nil, err
+	if validateInfiniteLoops {
+		if err := werfConfig.validateInfiniteLoopBetweenRelatedImages(); err != nil {
+			return nil, err
+		}
 	}
 
 	return werfConfig, nil
@@ -573,22 +852,16 @@ func newYamlUnmarshalError(err error, doc *doc) error {
 	case *configError:
 		return err
 	default:
-		message := err.Error()
-		reg, err := regexp.Compile("line ([0-9]+)")
-		if err != nil {
-			return err
-		}
-
-		res := reg.FindStringSubmatch(message)
-
-		if len(res) == 2 {
-			line, err := strconv.Atoi(res[1])
+		reg := regexp.MustCompile("line ([0-9]+)")
+		message := reg.ReplaceAllStringFunc(err.Error(), func(lineMatch string) string {
+			line, err := strconv.Atoi(reg.FindStringSubmatch(lineMatch)[1])
 			if err != nil {
-				return err
+				return lineMatch
 			}
 
-			message = reg.ReplaceAllString(message, fmt.Sprintf("line %d", line+doc.Line))
-		}
+			return fmt.Sprintf("line %d", line+doc.Line)
+		})
+
 		return newDetailedConfigError(message, nil, doc)
 	}
 }
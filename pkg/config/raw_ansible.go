@@ -10,6 +10,9 @@ type rawAnsible struct {
 	InstallCacheVersion       string           `yaml:"installCacheVersion,omitempty"`
 	BeforeSetupCacheVersion   string           `yaml:"beforeSetupCacheVersion,omitempty"`
 	SetupCacheVersion         string           `yaml:"setupCacheVersion,omitempty"`
+	Roles                     []string         `yaml:"roles,omitempty"`
+	Requirements              string           `yaml:"requirements,omitempty"`
+	Library                   string           `yaml:"library,omitempty"`
 
 	rawImage *rawStapelImage `yaml:"-"` // parent
 
@@ -33,6 +36,32 @@ func (c *rawAnsible) UnmarshalYAML(unmarshal func(interface{}) error) error {
 		return err
 	}
 
+	// tasks with custom modules are validated when the library is read from the project git repository
+	if err := c.validateTasksModules(c.modules(), c.Library != ""); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (c *rawAnsible) modules() []string {
+	modules := supportedModules()
+	if len(c.Roles) != 0 || c.Requirements != "" {
+		modules = append(modules, rolesModules()...)
+	}
+
+	return modules
+}
+
+func (c *rawAnsible) validateTasksModules(modules []string, withCustomModules bool) error {
+	for _, tasks := range [][]rawAnsibleTask{c.BeforeInstall, c.Install, c.BeforeSetup, c.Setup} {
+		for ind := range tasks {
+			if err := tasks[ind].validateModules(modules, withCustomModules); err != nil {
+				return err
+			}
+		}
+	}
+
 	return nil
 }
 
@@ -44,6 +73,9 @@ func (c *rawAnsible) toDirective() (ansible *Ansible, err error) {
 	ansible.InstallCacheVersion = c.InstallCacheVersion
 	ansible.BeforeSetupCacheVersion = c.BeforeSetupCacheVersion
 	ansible.SetupCacheVersion = c.SetupCacheVersion
+	ansible.Roles = c.Roles
+	ansible.Requirements = c.Requirements
+	ansible.Library = c.Library
 
 	for ind := range c.BeforeInstall {
 		if ansibleTask, err := c.BeforeInstall[ind].toDirective(); err != nil {
@@ -30,24 +30,44 @@ func (c *rawAnsibleTask) UnmarshalYAML(unmarshal func(interface{}) error) error
 		return err
 	}
 
-	if !c.blockDefined() {
-		check := false
-		for _, supportedModule := range supportedModules() {
-			if c.Fields[supportedModule] != nil {
-				if check {
-					return newDetailedConfigError("invalid ansible task!", c, c.rawAnsible.rawImage.doc)
-				} else {
-					check = true
+	return nil
+}
+
+// validateModules checks that the task and nested block tasks use one of the allowed modules,
+// a task without allowed modules is accepted if it can use a custom module, which is not known yet
+func (c *rawAnsibleTask) validateModules(modules []string, withCustomModules bool) error {
+	if c.blockDefined() {
+		for _, tasks := range [][]rawAnsibleTask{c.Block, c.Rescue, c.Always} {
+			for ind := range tasks {
+				if err := tasks[ind].validateModules(modules, withCustomModules); err != nil {
+					return err
 				}
 			}
 		}
 
-		if !check {
-			var supportedModulesString string
-			for _, supportedModule := range supportedModules() {
-				supportedModulesString += fmt.Sprintf("* %s\n", supportedModule)
+		return nil
+	}
+
+	check := false
+	for _, module := range modules {
+		if c.Fields[module] != nil {
+			if check {
+				return newDetailedConfigError("invalid ansible task!", c, c.rawAnsible.rawImage.doc)
+			} else {
+				check = true
 			}
-			return newConfigError(fmt.Sprintf("unsupported ansible task!\n\n%s\nSupported modules list:\n%s\n%s", dumpConfigSection(c), supportedModulesString, dumpConfigDoc(c.rawAnsible.rawImage.doc)))
+		}
+	}
+
+	if !check && !withCustomModules {
+		var supportedModulesString string
+		for _, supportedModule := range modules {
+			supportedModulesString += fmt.Sprintf("* %s\n", supportedModule)
+		}
+		return &configError{
+			s:       fmt.Sprintf("unsupported ansible task!\n\n%s\nSupported modules list:\n%s\n%s", dumpConfigSection(c), supportedModulesString, dumpConfigDoc(c.rawAnsible.rawImage.doc)),
+			message: "unsupported ansible task: one of the supported modules required (see `werf config schema`)!",
+			doc:     c.rawAnsible.rawImage.doc,
 		}
 	}
 
@@ -151,6 +171,11 @@ func supportedModules() []string {
 	return modules
 }
 
+// rolesModules are supported if ansible roles are defined
+func rolesModules() []string {
+	return []string{"include_role", "import_role"}
+}
+
 func (c *rawAnsibleTask) toDirective() (*AnsibleTask, error) {
 	ansibleTask := &AnsibleTask{}
 
@@ -12,6 +12,9 @@ type rawGit struct {
 	Commit                                          string                `yaml:"commit,omitempty"`
 	RawStageDependencies                            *rawStageDependencies `yaml:"stageDependencies,omitempty"`
 	HerebyIAdmitThatBranchMightBreakReproducibility bool                  `yaml:"herebyIAdmitThatBranchMightBreakReproducibility,omitempty"`
+	RawAuth                                         *rawGitAuth           `yaml:"auth,omitempty"`
+	RawClone                                        *rawGitClone          `yaml:"clone,omitempty"`
+	RawLfs                                          *rawGitLfs            `yaml:"lfs,omitempty"`
 
 	rawStapelImage *rawStapelImage `yaml:"-"` // parent
 
@@ -79,6 +82,10 @@ func (c *rawGit) validateGitLocalDirective(gitLocal *GitLocal) (err error) {
 		return newDetailedConfigError("specify `branch: BRANCH`, `tag: TAG` and `commit: COMMIT` only for remote git!", nil, c.rawStapelImage.doc)
 	}
 
+	if c.RawAuth != nil || c.RawClone != nil {
+		return newDetailedConfigError("specify `auth` and `clone` only for remote git!", nil, c.rawStapelImage.doc)
+	}
+
 	if err := gitLocal.validate(); err != nil {
 		return err
 	}
@@ -104,6 +111,12 @@ func (c *rawGit) toGitLocalExportDirective() (gitLocalExport *GitLocalExport, er
 		}
 	}
 
+	if c.RawLfs != nil {
+		if gitLocalExport.Lfs, err = c.RawLfs.toDirective(); err != nil {
+			return nil, err
+		}
+	}
+
 	gitLocalExport.raw = c
 
 	if err := c.validateGitLocalExportDirective(gitLocalExport); err != nil {
@@ -132,6 +145,18 @@ func (c *rawGit) toGitRemoteDirective() (gitRemote *GitRemote, err error) {
 
 	gitRemote.Url = c.Url
 	gitRemote.Name = getRepositoryID(c.Url)
+
+	if c.RawAuth != nil {
+		if gitRemote.Auth, err = c.RawAuth.toDirective(); err != nil {
+			return nil, err
+		}
+	}
+
+	if c.RawClone != nil {
+		if gitRemote.Clone, err = c.RawClone.toDirective(); err != nil {
+			return nil, err
+		}
+	}
 	gitRemote.raw = c
 
 	if err := c.validateGitRemoteDirective(gitRemote); err != nil {
@@ -0,0 +1,42 @@
+package config
+
+type rawGitAuth struct {
+	Username    string `yaml:"username,omitempty"`
+	PasswordEnv string `yaml:"passwordEnv,omitempty"`
+	KnownHosts  string `yaml:"knownHosts,omitempty"`
+
+	rawGit *rawGit `yaml:"-"` // parent
+
+	UnsupportedAttributes map[string]interface{} `yaml:",inline"`
+}
+
+func (c *rawGitAuth) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	if parent, ok := parentStack.Peek().(*rawGit); ok {
+		c.rawGit = parent
+	}
+
+	type plain rawGitAuth
+	if err := unmarshal((*plain)(c)); err != nil {
+		return err
+	}
+
+	if err := checkOverflow(c.UnsupportedAttributes, c, c.rawGit.rawStapelImage.doc); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (c *rawGitAuth) toDirective() (gitAuth *GitRemoteAuth, err error) {
+	gitAuth = &GitRemoteAuth{}
+	gitAuth.Username = c.Username
+	gitAuth.PasswordEnv = c.PasswordEnv
+	gitAuth.KnownHosts = c.KnownHosts
+	gitAuth.raw = c
+
+	if err := gitAuth.validate(); err != nil {
+		return nil, err
+	}
+
+	return gitAuth, nil
+}
@@ -0,0 +1,40 @@
+package config
+
+type rawGitClone struct {
+	Depth  int    `yaml:"depth,omitempty"`
+	Filter string `yaml:"filter,omitempty"`
+
+	rawGit *rawGit `yaml:"-"` // parent
+
+	UnsupportedAttributes map[string]interface{} `yaml:",inline"`
+}
+
+func (c *rawGitClone) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	if parent, ok := parentStack.Peek().(*rawGit); ok {
+		c.rawGit = parent
+	}
+
+	type plain rawGitClone
+	if err := unmarshal((*plain)(c)); err != nil {
+		return err
+	}
+
+	if err := checkOverflow(c.UnsupportedAttributes, c, c.rawGit.rawStapelImage.doc); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (c *rawGitClone) toDirective() (gitClone *GitRemoteClone, err error) {
+	gitClone = &GitRemoteClone{}
+	gitClone.Depth = c.Depth
+	gitClone.Filter = c.Filter
+	gitClone.raw = c
+
+	if err := gitClone.validate(); err != nil {
+		return nil, err
+	}
+
+	return gitClone, nil
+}
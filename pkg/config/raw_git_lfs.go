@@ -0,0 +1,40 @@
+package config
+
+type rawGitLfs struct {
+	Enabled bool   `yaml:"enabled,omitempty"`
+	Url     string `yaml:"url,omitempty"`
+
+	rawGit *rawGit `yaml:"-"` // parent
+
+	UnsupportedAttributes map[string]interface{} `yaml:",inline"`
+}
+
+func (c *rawGitLfs) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	if parent, ok := parentStack.Peek().(*rawGit); ok {
+		c.rawGit = parent
+	}
+
+	type plain rawGitLfs
+	if err := unmarshal((*plain)(c)); err != nil {
+		return err
+	}
+
+	if err := checkOverflow(c.UnsupportedAttributes, c, c.rawGit.rawStapelImage.doc); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (c *rawGitLfs) toDirective() (gitLfs *GitLfs, err error) {
+	gitLfs = &GitLfs{}
+	gitLfs.Enabled = c.Enabled
+	gitLfs.Url = c.Url
+	gitLfs.raw = c
+
+	if err := gitLfs.validate(); err != nil {
+		return nil, err
+	}
+
+	return gitLfs, nil
+}
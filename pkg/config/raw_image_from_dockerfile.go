@@ -81,6 +81,7 @@ func (c *rawImageFromDockerfile) toImageFromDockerfileDirective(imageName string
 	image.Context = filepath.FromSlash(c.Context)
 	image.Target = c.Target
 	image.Args = c.Args
+	image.TemplatesCommits = c.doc.TemplatesCommits
 
 	if addHost, err := InterfaceToStringArray(c.AddHost, c, c.doc); err != nil {
 		return nil, err
@@ -8,9 +8,10 @@ import (
 )
 
 type rawMeta struct {
-	ConfigVersion   *int               `yaml:"configVersion,omitempty"`
-	Project         *string            `yaml:"project,omitempty"`
-	DeployTemplates rawDeployTemplates `yaml:"deploy,omitempty"`
+	ConfigVersion   *int                   `yaml:"configVersion,omitempty"`
+	Project         *string                `yaml:"project,omitempty"`
+	DeployTemplates rawDeployTemplates     `yaml:"deploy,omitempty"`
+	RawTemplates    []*rawTemplatesLibrary `yaml:"templates,omitempty"`
 
 	doc *doc `yaml:"-"` // parent
 
@@ -63,5 +64,9 @@ func (c *rawMeta) toMeta() *Meta {
 
 	meta.DeployTemplates = c.DeployTemplates.toDeployTemplates()
 
+	for _, rawTemplatesLibrary := range c.RawTemplates {
+		meta.Templates = append(meta.Templates, rawTemplatesLibrary.toDirective())
+	}
+
 	return meta
 }
@@ -1,5 +1,11 @@
 package config
 
+import (
+	"fmt"
+
+	"gopkg.in/yaml.v2"
+)
+
 type rawShell struct {
 	BeforeInstall             interface{} `yaml:"beforeInstall,omitempty"`
 	Install                   interface{} `yaml:"install,omitempty"`
@@ -41,28 +47,20 @@ func (c *rawShell) toDirective() (shell *Shell, err error) {
 	shell.BeforeSetupCacheVersion = c.BeforeSetupCacheVersion
 	shell.SetupCacheVersion = c.SetupCacheVersion
 
-	if beforeInstall, err := InterfaceToStringArray(c.BeforeInstall, c, c.rawStapelImage.doc); err != nil {
+	if shell.BeforeInstall, shell.BeforeInstallScript, err = c.toStageDirective(c.BeforeInstall); err != nil {
 		return nil, err
-	} else {
-		shell.BeforeInstall = beforeInstall
 	}
 
-	if install, err := InterfaceToStringArray(c.Install, c, c.rawStapelImage.doc); err != nil {
+	if shell.Install, shell.InstallScript, err = c.toStageDirective(c.Install); err != nil {
 		return nil, err
-	} else {
-		shell.Install = install
 	}
 
-	if beforeSetup, err := InterfaceToStringArray(c.BeforeSetup, c, c.rawStapelImage.doc); err != nil {
+	if shell.BeforeSetup, shell.BeforeSetupScript, err = c.toStageDirective(c.BeforeSetup); err != nil {
 		return nil, err
-	} else {
-		shell.BeforeSetup = beforeSetup
 	}
 
-	if setup, err := InterfaceToStringArray(c.Setup, c, c.rawStapelImage.doc); err != nil {
+	if shell.Setup, shell.SetupScript, err = c.toStageDirective(c.Setup); err != nil {
 		return nil, err
-	} else {
-		shell.Setup = setup
 	}
 
 	shell.raw = c
@@ -81,3 +79,28 @@ func (c *rawShell) validateDirective(shell *Shell) error {
 
 	return nil
 }
+
+// toStageDirective returns user stage commands or script: `install: {file: PATH, args: [ARG, ...]}`
+func (c *rawShell) toStageDirective(value interface{}) ([]string, *ShellScript, error) {
+	if _, ok := value.(map[interface{}]interface{}); !ok {
+		commands, err := InterfaceToStringArray(value, c, c.rawStapelImage.doc)
+		return commands, nil, err
+	}
+
+	data, err := yaml.Marshal(value)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	rawScript := &rawShellScript{rawShell: c}
+	if err := yaml.UnmarshalStrict(data, rawScript); err != nil {
+		return nil, nil, newDetailedConfigError(fmt.Sprintf("single string, array of strings or script `file: PATH` with `args: [ARG, ...]` expected, got `%v`: %s", value, err), c, c.rawStapelImage.doc)
+	}
+
+	script := rawScript.toDirective()
+	if err := script.validate(); err != nil {
+		return nil, nil, err
+	}
+
+	return []string{}, script, nil
+}
@@ -0,0 +1,16 @@
+package config
+
+type rawShellScript struct {
+	File string   `yaml:"file,omitempty"`
+	Args []string `yaml:"args,omitempty"`
+
+	rawShell *rawShell `yaml:"-"` // parent
+}
+
+func (c *rawShellScript) toDirective() *ShellScript {
+	return &ShellScript{
+		File: c.File,
+		Args: c.Args,
+		raw:  c,
+	}
+}
@@ -0,0 +1,87 @@
+package config
+
+type rawStageContainer struct {
+	Cpus    string                             `yaml:"cpus,omitempty"`
+	Memory  string                             `yaml:"memory,omitempty"`
+	Timeout string                             `yaml:"timeout,omitempty"`
+	Network string                             `yaml:"network,omitempty"`
+	Stages  map[string]*rawStageContainerStage `yaml:"stages,omitempty"`
+
+	rawStapelImage *rawStapelImage `yaml:"-"` // parent
+
+	UnsupportedAttributes map[string]interface{} `yaml:",inline"`
+}
+
+type rawStageContainerStage struct {
+	Cpus    string `yaml:"cpus,omitempty"`
+	Memory  string `yaml:"memory,omitempty"`
+	Timeout string `yaml:"timeout,omitempty"`
+	Network string `yaml:"network,omitempty"`
+
+	rawStageContainer *rawStageContainer `yaml:"-"` // parent
+
+	UnsupportedAttributes map[string]interface{} `yaml:",inline"`
+}
+
+func (c *rawStageContainer) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	if parent, ok := parentStack.Peek().(*rawStapelImage); ok {
+		c.rawStapelImage = parent
+	}
+
+	parentStack.Push(c)
+	type plain rawStageContainer
+	err := unmarshal((*plain)(c))
+	parentStack.Pop()
+	if err != nil {
+		return err
+	}
+
+	if err := checkOverflow(c.UnsupportedAttributes, c, c.rawStapelImage.doc); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (c *rawStageContainerStage) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	if parent, ok := parentStack.Peek().(*rawStageContainer); ok {
+		c.rawStageContainer = parent
+	}
+
+	type plain rawStageContainerStage
+	if err := unmarshal((*plain)(c)); err != nil {
+		return err
+	}
+
+	if err := checkOverflow(c.UnsupportedAttributes, c, c.rawStageContainer.rawStapelImage.doc); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (c *rawStageContainer) toDirective() (*StageContainer, error) {
+	stageContainer := &StageContainer{Stages: map[string]*StageContainerOptions{}}
+
+	options, err := newStageContainerOptions(c.Cpus, c.Memory, c.Timeout, c.Network, c, c.rawStapelImage.doc)
+	if err != nil {
+		return nil, err
+	}
+	stageContainer.StageContainerOptions = *options
+
+	for stageName, rawStage := range c.Stages {
+		stageOptions, err := newStageContainerOptions(rawStage.Cpus, rawStage.Memory, rawStage.Timeout, rawStage.Network, rawStage, c.rawStapelImage.doc)
+		if err != nil {
+			return nil, err
+		}
+		stageContainer.Stages[stageName] = stageOptions
+	}
+
+	stageContainer.raw = c
+
+	if err := stageContainer.validate(); err != nil {
+		return nil, err
+	}
+
+	return stageContainer, nil
+}
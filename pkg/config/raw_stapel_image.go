@@ -6,21 +6,22 @@ import (
 )
 
 type rawStapelImage struct {
-	Images                                              []string     `yaml:"-"`
-	Artifact                                            string       `yaml:"artifact,omitempty"`
-	From                                                string       `yaml:"from,omitempty"`
-	FromLatest                                          bool         `yaml:"fromLatest,omitempty"`
-	HerebyIAdmitThatFromLatestMightBreakReproducibility bool         `yaml:"herebyIAdmitThatFromLatestMightBreakReproducibility,omitempty"`
-	FromCacheVersion                                    string       `yaml:"fromCacheVersion,omitempty"`
-	FromImage                                           string       `yaml:"fromImage,omitempty"`
-	FromImageArtifact                                   string       `yaml:"fromImageArtifact,omitempty"`
-	RawGit                                              []*rawGit    `yaml:"git,omitempty"`
-	RawShell                                            *rawShell    `yaml:"shell,omitempty"`
-	RawAnsible                                          *rawAnsible  `yaml:"ansible,omitempty"`
-	RawMount                                            []*rawMount  `yaml:"mount,omitempty"`
-	RawDocker                                           *rawDocker   `yaml:"docker,omitempty"`
-	RawImport                                           []*rawImport `yaml:"import,omitempty"`
-	AsLayers                                            bool         `yaml:"asLayers,omitempty"`
+	Images                                              []string           `yaml:"-"`
+	Artifact                                            string             `yaml:"artifact,omitempty"`
+	From                                                string             `yaml:"from,omitempty"`
+	FromLatest                                          bool               `yaml:"fromLatest,omitempty"`
+	HerebyIAdmitThatFromLatestMightBreakReproducibility bool               `yaml:"herebyIAdmitThatFromLatestMightBreakReproducibility,omitempty"`
+	FromCacheVersion                                    string             `yaml:"fromCacheVersion,omitempty"`
+	FromImage                                           string             `yaml:"fromImage,omitempty"`
+	FromImageArtifact                                   string             `yaml:"fromImageArtifact,omitempty"`
+	RawGit                                              []*rawGit          `yaml:"git,omitempty"`
+	RawShell                                            *rawShell          `yaml:"shell,omitempty"`
+	RawAnsible                                          *rawAnsible        `yaml:"ansible,omitempty"`
+	RawMount                                            []*rawMount        `yaml:"mount,omitempty"`
+	RawDocker                                           *rawDocker         `yaml:"docker,omitempty"`
+	RawImport                                           []*rawImport       `yaml:"import,omitempty"`
+	AsLayers                                            bool               `yaml:"asLayers,omitempty"`
+	RawStageContainer                                   *rawStageContainer `yaml:"stageContainer,omitempty"`
 
 	doc *doc `yaml:"-"` // parent
 
@@ -605,6 +606,7 @@ func (c *rawStapelImage) toStapelImageBaseDirective(name string) (imageBase *Sta
 	imageBase.FromLatest = c.FromLatest
 	imageBase.HerebyIAdmitThatFromLatestMightBreakReproducibility = c.HerebyIAdmitThatFromLatestMightBreakReproducibility
 	imageBase.FromCacheVersion = c.FromCacheVersion
+	imageBase.TemplatesCommits = c.doc.TemplatesCommits
 
 	for _, git := range c.RawGit {
 		if git.gitType() == "local" {
@@ -673,6 +675,12 @@ func (c *rawStapelImage) toBaseStapelImageBaseDirective(name string) (imageBase
 		}
 	}
 
+	if c.RawStageContainer != nil {
+		if imageBase.StageContainer, err = c.RawStageContainer.toDirective(); err != nil {
+			return nil, err
+		}
+	}
+
 	imageBase.Git = &GitManager{}
 
 	imageBase.raw = c
@@ -0,0 +1,45 @@
+package config
+
+type rawTemplatesLibrary struct {
+	Url    string `yaml:"url,omitempty"`
+	Tag    string `yaml:"tag,omitempty"`
+	Commit string `yaml:"commit,omitempty"`
+	Add    string `yaml:"add,omitempty"`
+	Path   string `yaml:"path,omitempty"`
+
+	rawMeta *rawMeta `yaml:"-"` // parent
+
+	UnsupportedAttributes map[string]interface{} `yaml:",inline"`
+}
+
+func (c *rawTemplatesLibrary) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	if parent, ok := parentStack.Peek().(*rawMeta); ok {
+		c.rawMeta = parent
+	}
+
+	type plain rawTemplatesLibrary
+	if err := unmarshal((*plain)(c)); err != nil {
+		return err
+	}
+
+	if err := checkOverflow(c.UnsupportedAttributes, c, c.rawMeta.doc); err != nil {
+		return err
+	}
+
+	if err := c.toDirective().validate(); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (c *rawTemplatesLibrary) toDirective() *TemplatesLibrary {
+	return &TemplatesLibrary{
+		Url:    c.Url,
+		Tag:    c.Tag,
+		Commit: c.Commit,
+		Add:    c.Add,
+		Path:   c.Path,
+		raw:    c,
+	}
+}
@@ -0,0 +1,244 @@
+package config
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"unicode"
+)
+
+const jsonSchemaDraft = "http://json-schema.org/draft-07/schema#"
+
+// schemaEnums restricts string fields, which values are validated by directives
+var schemaEnums = map[string][]string{
+	"mount.from":    {"build_dir", "tmp_dir"},
+	"import.before": {"install", "setup"},
+	"import.after":  {"install", "setup"},
+}
+
+// schemaStringOrArray describes interface{} raw fields: a single string or a list of strings
+var schemaStringOrArray = map[string]interface{}{
+	"anyOf": []interface{}{
+		map[string]interface{}{"type": "string"},
+		map[string]interface{}{"type": "array", "items": map[string]interface{}{"type": "string"}},
+	},
+}
+
+// GetWerfConfigJsonSchema returns JSON Schema of werf.yaml doc (part of YAML stream separated by three hyphens),
+// the schema is derived from the raw config structs
+func GetWerfConfigJsonSchema() ([]byte, error) {
+	return json.MarshalIndent(werfConfigJsonSchema(), "", "  ")
+}
+
+func werfConfigJsonSchema() map[string]interface{} {
+	definitions := map[string]interface{}{}
+	g := &schemaGenerator{definitions: definitions}
+
+	meta := g.structSchema(reflect.TypeOf(rawMeta{}))
+	meta["required"] = []string{"configVersion", "project"}
+	setSchemaProperty(meta, "configVersion", map[string]interface{}{"type": "integer", "enum": []int{1}})
+	definitions["meta"] = meta
+
+	image := g.structSchema(reflect.TypeOf(rawStapelImage{}))
+	deleteSchemaProperty(image, "artifact")
+	setSchemaProperty(image, "image", imageNameSchema())
+	image["required"] = []string{"image"}
+	definitions["image"] = image
+
+	artifact := g.structSchema(reflect.TypeOf(rawStapelImage{}))
+	deleteSchemaProperty(artifact, "image")
+	deleteSchemaProperty(artifact, "docker")
+	artifact["required"] = []string{"artifact"}
+	definitions["artifact"] = artifact
+
+	// shell user stage is commands or script file
+	shellScript := g.structSchema(reflect.TypeOf(rawShellScript{}))
+	shellScript["required"] = []string{"file"}
+	definitions["shellScript"] = shellScript
+	for _, userStageName := range []string{"beforeInstall", "install", "beforeSetup", "setup"} {
+		setSchemaProperty(definitions["shell"].(map[string]interface{}), userStageName, map[string]interface{}{
+			"anyOf": append(append([]interface{}{}, schemaStringOrArray["anyOf"].([]interface{})...), schemaRef("shellScript")),
+		})
+	}
+
+	dockerfileImage := g.structSchema(reflect.TypeOf(rawImageFromDockerfile{}))
+	setSchemaProperty(dockerfileImage, "image", imageNameSchema())
+	dockerfileImage["required"] = []string{"image", "dockerfile"}
+	definitions["dockerfileImage"] = dockerfileImage
+
+	return map[string]interface{}{
+		"$schema":     jsonSchemaDraft,
+		"title":       "werf.yaml",
+		"description": "werf config section (part of YAML stream separated by three hyphens): meta, image, artifact or image from Dockerfile",
+		"anyOf": []interface{}{
+			schemaRef("meta"),
+			schemaRef("image"),
+			schemaRef("artifact"),
+			schemaRef("dockerfileImage"),
+		},
+		"definitions": definitions,
+	}
+}
+
+type schemaGenerator struct {
+	definitions map[string]interface{}
+}
+
+func (g *schemaGenerator) structSchema(t reflect.Type) map[string]interface{} {
+	properties := map[string]interface{}{}
+	g.collectStructProperties(t, properties)
+
+	return map[string]interface{}{
+		"type":                 "object",
+		"properties":           properties,
+		"additionalProperties": false,
+	}
+}
+
+func (g *schemaGenerator) collectStructProperties(t reflect.Type, properties map[string]interface{}) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+
+		tag := field.Tag.Get("yaml")
+		if tag == "-" {
+			continue
+		}
+
+		tagParts := strings.Split(tag, ",")
+		name := tagParts[0]
+		inline := false
+		for _, option := range tagParts[1:] {
+			if option == "inline" {
+				inline = true
+			}
+		}
+
+		if inline {
+			if field.Type.Kind() == reflect.Struct {
+				g.collectStructProperties(field.Type, properties)
+			}
+			// inline map collects unsupported attributes
+			continue
+		}
+
+		if name == "" || field.PkgPath != "" {
+			continue
+		}
+
+		propertySchema := g.typeSchema(field.Type)
+		if enum, ok := schemaEnums[schemaDefinitionName(t)+"."+name]; ok {
+			propertySchema["enum"] = enum
+		}
+
+		properties[name] = propertySchema
+	}
+}
+
+func (g *schemaGenerator) typeSchema(t reflect.Type) map[string]interface{} {
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	switch t.Kind() {
+	case reflect.String:
+		return map[string]interface{}{"type": "string"}
+	case reflect.Bool:
+		return map[string]interface{}{"type": "boolean"}
+	case reflect.Int, reflect.Int32, reflect.Int64:
+		return map[string]interface{}{"type": "integer"}
+	case reflect.Slice:
+		return map[string]interface{}{"type": "array", "items": g.typeSchema(t.Elem())}
+	case reflect.Map:
+		if t.Elem().Kind() == reflect.Interface {
+			return map[string]interface{}{"type": "object"}
+		}
+		return map[string]interface{}{"type": "object", "additionalProperties": g.typeSchema(t.Elem())}
+	case reflect.Interface:
+		return copySchema(schemaStringOrArray)
+	case reflect.Struct:
+		name := schemaDefinitionName(t)
+		if _, ok := g.definitions[name]; !ok {
+			if t == reflect.TypeOf(rawAnsibleTask{}) {
+				g.definitions[name] = ansibleTaskSchema()
+			} else {
+				g.definitions[name] = g.structSchema(t)
+			}
+		}
+		return schemaRef(name)
+	default:
+		return map[string]interface{}{}
+	}
+}
+
+func ansibleTaskSchema() map[string]interface{} {
+	properties := map[string]interface{}{}
+	for _, blockName := range []string{"block", "rescue", "always"} {
+		properties[blockName] = map[string]interface{}{"type": "array", "items": schemaRef("ansibleTask")}
+	}
+
+	var requiredAnyOf []interface{}
+	for _, blockName := range []string{"block", "rescue", "always"} {
+		requiredAnyOf = append(requiredAnyOf, map[string]interface{}{"required": []string{blockName}})
+	}
+
+	for _, module := range append(supportedModules(), rolesModules()...) {
+		properties[module] = map[string]interface{}{}
+		requiredAnyOf = append(requiredAnyOf, map[string]interface{}{"required": []string{module}})
+	}
+
+	return map[string]interface{}{
+		"type":        "object",
+		"description": "ansible task with one of the supported modules or block, custom modules of `ansible.library` are not described",
+		"properties":  properties,
+		"anyOf":       requiredAnyOf,
+	}
+}
+
+func imageNameSchema() map[string]interface{} {
+	return map[string]interface{}{
+		"anyOf": []interface{}{
+			map[string]interface{}{"type": "null"},
+			map[string]interface{}{"type": "string"},
+			map[string]interface{}{"type": "array", "items": map[string]interface{}{"type": "string"}},
+		},
+	}
+}
+
+// schemaDefinitionName: rawGitAuth -> gitAuth, rawStapelImage -> image
+func schemaDefinitionName(t reflect.Type) string {
+	switch t {
+	case reflect.TypeOf(rawStapelImage{}):
+		return "image"
+	case reflect.TypeOf(rawImageFromDockerfile{}):
+		return "dockerfileImage"
+	case reflect.TypeOf(rawDeployTemplates{}):
+		return "deploy"
+	}
+
+	name := []rune(strings.TrimPrefix(t.Name(), "raw"))
+	if len(name) != 0 {
+		name[0] = unicode.ToLower(name[0])
+	}
+
+	return string(name)
+}
+
+func schemaRef(name string) map[string]interface{} {
+	return map[string]interface{}{"$ref": "#/definitions/" + name}
+}
+
+func setSchemaProperty(schema map[string]interface{}, name string, propertySchema interface{}) {
+	schema["properties"].(map[string]interface{})[name] = propertySchema
+}
+
+func deleteSchemaProperty(schema map[string]interface{}, name string) {
+	delete(schema["properties"].(map[string]interface{}), name)
+}
+
+func copySchema(schema map[string]interface{}) map[string]interface{} {
+	res := map[string]interface{}{}
+	for k, v := range schema {
+		res[k] = v
+	}
+	return res
+}
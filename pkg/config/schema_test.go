@@ -0,0 +1,210 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/ginkgo/extensions/table"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("werf config json schema", func() {
+	var schema map[string]interface{}
+	var definitions map[string]interface{}
+
+	BeforeEach(func() {
+		data, err := GetWerfConfigJsonSchema()
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(json.Unmarshal(data, &schema)).Should(Succeed())
+
+		definitions = schema["definitions"].(map[string]interface{})
+	})
+
+	definitionProperties := func(name string) map[string]interface{} {
+		return definitions[name].(map[string]interface{})["properties"].(map[string]interface{})
+	}
+
+	It("resolves all references", func() {
+		var refs []string
+		var collectRefs func(value interface{})
+		collectRefs = func(value interface{}) {
+			switch v := value.(type) {
+			case map[string]interface{}:
+				if ref, ok := v["$ref"].(string); ok {
+					refs = append(refs, ref)
+				}
+				for _, item := range v {
+					collectRefs(item)
+				}
+			case []interface{}:
+				for _, item := range v {
+					collectRefs(item)
+				}
+			}
+		}
+		collectRefs(schema)
+
+		Ω(refs).ShouldNot(BeEmpty())
+		for _, ref := range refs {
+			Ω(definitions).Should(HaveKey(strings.TrimPrefix(ref, "#/definitions/")))
+		}
+	})
+
+	It("separates image and artifact directives", func() {
+		Ω(definitionProperties("image")).Should(HaveKey("image"))
+		Ω(definitionProperties("image")).Should(HaveKey("docker"))
+		Ω(definitionProperties("image")).ShouldNot(HaveKey("artifact"))
+
+		Ω(definitionProperties("artifact")).Should(HaveKey("artifact"))
+		Ω(definitionProperties("artifact")).ShouldNot(HaveKey("image"))
+		Ω(definitionProperties("artifact")).ShouldNot(HaveKey("docker"))
+	})
+
+	It("describes supported ansible modules", func() {
+		for _, module := range supportedModules() {
+			Ω(definitionProperties("ansibleTask")).Should(HaveKey(module))
+		}
+	})
+})
+
+var _ = DescribeTable("linting config section by json schema", func(content string, expectedErrors []string) {
+	var errors []string
+	for _, err := range lintDocContentSchema([]byte(content)) {
+		errors = append(errors, fmt.Sprintf("%d: %s", err.line, err.message))
+	}
+
+	Ω(errors).Should(Equal(expectedErrors))
+},
+	Entry("meta", `configVersion: 1
+project: demo
+deploy:
+  helmRelease: "[[ project ]]-[[ env ]]"
+`, []string(nil)),
+	Entry("meta with unsupported config version", `configVersion: 2
+project: demo
+`, []string{"1: `configVersion`: one of `1` expected, got `2`"}),
+	Entry("stapel image", `image: [a, b]
+from: alpine
+fromCacheVersion: 1
+git:
+- add: /
+  to: /app
+  stageDependencies:
+    install: ["*.lock"]
+- url: https://github.com/flant/werf.git
+  to: /werf
+shell:
+  beforeInstall: apt-get update
+  install:
+  - make install
+  setup:
+    file: scripts/setup.sh
+mount:
+- from: tmp_dir
+  to: /var/cache
+import:
+- artifact: assets
+  add: /assets
+  to: /app/assets
+  before: setup
+docker:
+  WORKDIR: /app
+  ENV:
+    PORT: 8080
+  EXPOSE: ["8080"]
+`, []string(nil)),
+	Entry("nameless image", `image: ~
+from: alpine
+`, []string(nil)),
+	Entry("ansible tasks", `artifact: assets
+from: node
+ansible:
+  install:
+  - name: build
+    shell: npm run build
+    args:
+      chdir: /app
+  - block:
+    - command: ls
+    rescue:
+    - debug:
+        msg: failed
+`, []string(nil)),
+	Entry("dockerfile image", `image: backend
+dockerfile: Dockerfile
+context: backend
+args:
+  VERSION: 1
+`, []string(nil)),
+	Entry("anchors and merge keys", `image: a
+from: alpine
+git:
+- &git
+  add: /src
+  to: /app
+- <<: *git
+  add: /assets
+`, []string(nil)),
+	Entry("errors of nested sections with the same key", `image: a
+from: alpine
+unknown: 1
+git:
+- add: /
+  to: /app
+  unknown: 1
+  stageDependencies:
+    unknown: []
+`, []string{
+		"3: unknown field `unknown`",
+		"7: `git[0]`: unknown field `unknown`",
+		"9: `git[0].stageDependencies`: unknown field `unknown`",
+	}),
+	Entry("types mismatch", `image: a
+from: [alpine]
+shell:
+  install: {}
+mount:
+- from: build_dir
+  to: /cache
+  unknown: 1
+docker:
+  EXPOSE:
+    port: 80
+`, []string{
+		"2: `from`: string expected, got array",
+		"4: `shell.install`: field `file` required",
+		"8: `mount[0]`: unknown field `unknown`",
+		"11: `docker.EXPOSE`: string or array of strings expected, got object",
+	}),
+	Entry("unsupported ansible module", `image: a
+from: alpine
+ansible:
+  install:
+  - name: unsupported
+    unsupported_module: {}
+`, []string{"5: `ansible.install[0]`: one of the supported modules required (see `werf config schema`)"}),
+	Entry("custom ansible module", `image: a
+from: alpine
+ansible:
+  library: ansible/library
+  install:
+  - name: custom
+    custom_module: {}
+`, []string(nil)),
+	Entry("image directive in artifact", `artifact: a
+image: b
+from: alpine
+`, []string{"2: unknown field `image`"}),
+	Entry("required directive", `image: a
+dockerfile: ~
+unknown: 1
+`, []string{"3: unknown field `unknown`"}),
+	Entry("duplicate directive", `image: a
+from: alpine
+from: ubuntu
+`, []string{"3: duplicate field `from`"}),
+	Entry("unrecognized config section", `from: alpine
+`, []string{"1: cannot recognize type of config section: `configVersion` required for meta config section, `image` for the image config sections, `artifact` for the artifact config sections"}),
+)
@@ -11,6 +11,11 @@ type Shell struct {
 	BeforeSetupCacheVersion   string
 	SetupCacheVersion         string
 
+	BeforeInstallScript *ShellScript
+	InstallScript       *ShellScript
+	BeforeSetupScript   *ShellScript
+	SetupScript         *ShellScript
+
 	raw *rawShell
 }
 
@@ -21,3 +26,20 @@ func (c *Shell) GetDumpConfigSection() string {
 func (c *Shell) validate() error {
 	return nil
 }
+
+// Scripts returns scripts of user stages by stage names
+func (c *Shell) Scripts() map[string]*ShellScript {
+	scripts := map[string]*ShellScript{}
+	for stageName, script := range map[string]*ShellScript{
+		"BeforeInstall": c.BeforeInstallScript,
+		"Install":       c.InstallScript,
+		"BeforeSetup":   c.BeforeSetupScript,
+		"Setup":         c.SetupScript,
+	} {
+		if script != nil {
+			scripts[stageName] = script
+		}
+	}
+
+	return scripts
+}
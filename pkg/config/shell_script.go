@@ -0,0 +1,17 @@
+package config
+
+// ShellScript is a file from the project git repository, which is executed instead of the user stage commands
+type ShellScript struct {
+	File string
+	Args []string
+
+	raw *rawShellScript
+}
+
+func (c *ShellScript) validate() error {
+	if c.File == "" || !isProjectRepositoryRelativePath(c.File) {
+		return newDetailedConfigError("`file: PATH` relative path in the project git repository required for shell script!", c.raw.rawShell, c.raw.rawShell.rawStapelImage.doc)
+	}
+
+	return nil
+}
@@ -0,0 +1,44 @@
+package config
+
+import (
+	. "github.com/onsi/ginkgo/extensions/table"
+	. "github.com/onsi/gomega"
+)
+
+var _ = DescribeTable("shell stage script", func(install string, expectedScript *ShellScript, expectedErr string) {
+	docs, err := splitByDocs(`configVersion: 1
+project: demo
+---
+image: ~
+from: alpine
+shell:
+  install: `+install+"\n", "werf.yaml")
+	Ω(err).ShouldNot(HaveOccurred())
+
+	_, rawStapelImages, _, err := splitByMetaAndRawImages(docs)
+	Ω(err).ShouldNot(HaveOccurred())
+
+	images, err := rawStapelImages[0].toStapelImageDirectives()
+	if expectedErr != "" {
+		Ω(err).Should(HaveOccurred())
+		Ω(err.Error()).Should(ContainSubstring(expectedErr))
+		return
+	}
+	Ω(err).ShouldNot(HaveOccurred())
+
+	script := images[0].Shell.InstallScript
+	if expectedScript == nil {
+		Ω(script).Should(BeNil())
+		return
+	}
+
+	Ω(script.File).Should(Equal(expectedScript.File))
+	Ω(script.Args).Should(Equal(expectedScript.Args))
+	Ω(images[0].Shell.Install).Should(BeEmpty())
+},
+	Entry("commands", `[echo 1]`, nil, ""),
+	Entry("script with args", `{file: scripts/install.sh, args: [--prod, 1]}`, &ShellScript{File: "scripts/install.sh", Args: []string{"--prod", "1"}}, ""),
+	Entry("unknown field", `{file: scripts/install.sh, env: []}`, nil, "script `file: PATH`"),
+	Entry("absolute path", `{file: /scripts/install.sh}`, nil, "relative path"),
+	Entry("path outside repository", `{file: ../install.sh}`, nil, "relative path in the project git repository required"),
+)
@@ -0,0 +1,109 @@
+package config
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/docker/go-units"
+
+	"github.com/flant/werf/pkg/util"
+)
+
+// StageContainer contains options of stapel stages containers: resources limits, timeout and network,
+// the options do not affect stages signatures
+type StageContainer struct {
+	StageContainerOptions
+	// Stages options override image ones by stage names
+	Stages map[string]*StageContainerOptions
+
+	raw *rawStageContainer
+}
+
+type StageContainerOptions struct {
+	Cpus    string
+	Memory  string
+	Timeout time.Duration
+	Network string
+}
+
+var stageContainerStagesNames = []string{
+	"from",
+	"beforeInstall",
+	"importsBeforeInstall",
+	"gitArchive",
+	"install",
+	"importsAfterInstall",
+	"beforeSetup",
+	"importsBeforeSetup",
+	"setup",
+	"importsAfterSetup",
+	"gitCache",
+	"gitLatestPatch",
+	"dockerInstructions",
+}
+
+func newStageContainerOptions(cpus, memory, timeout, network string, configSection interface{}, doc *doc) (*StageContainerOptions, error) {
+	options := &StageContainerOptions{Cpus: cpus, Memory: memory, Network: network}
+
+	if cpus != "" {
+		if value, err := strconv.ParseFloat(cpus, 64); err != nil || value <= 0 {
+			return nil, newDetailedConfigError(fmt.Sprintf("invalid `cpus: %s`: positive number of CPUs expected!", cpus), configSection, doc)
+		}
+	}
+
+	if memory != "" {
+		if _, err := units.RAMInBytes(memory); err != nil {
+			return nil, newDetailedConfigError(fmt.Sprintf("invalid `memory: %s`: memory limit with unit suffix expected, e.g. `512m` or `2g`!", memory), configSection, doc)
+		}
+	}
+
+	if timeout != "" {
+		value, err := time.ParseDuration(timeout)
+		if err != nil || value <= 0 {
+			return nil, newDetailedConfigError(fmt.Sprintf("invalid `timeout: %s`: positive duration expected, e.g. `30m` or `1h30m`!", timeout), configSection, doc)
+		}
+		options.Timeout = value
+	}
+
+	return options, nil
+}
+
+func (c *StageContainer) validate() error {
+	for stageName := range c.Stages {
+		if !util.IsStringsContainValue(stageContainerStagesNames, stageName) {
+			return newDetailedConfigError(fmt.Sprintf("invalid stage `%s` in `stageContainer.stages`: expected one of %s!", stageName, strings.Join(stageContainerStagesNames, ", ")), c.raw, c.raw.rawStapelImage.doc)
+		}
+	}
+
+	return nil
+}
+
+// StageOptions returns image options merged with the stage ones
+func (c *StageContainer) StageOptions(stageName string) *StageContainerOptions {
+	options := c.StageContainerOptions
+
+	stageOptions, ok := c.Stages[stageName]
+	if !ok {
+		return &options
+	}
+
+	if stageOptions.Cpus != "" {
+		options.Cpus = stageOptions.Cpus
+	}
+
+	if stageOptions.Memory != "" {
+		options.Memory = stageOptions.Memory
+	}
+
+	if stageOptions.Timeout != 0 {
+		options.Timeout = stageOptions.Timeout
+	}
+
+	if stageOptions.Network != "" {
+		options.Network = stageOptions.Network
+	}
+
+	return &options
+}
@@ -0,0 +1,46 @@
+package config
+
+import (
+	"time"
+
+	. "github.com/onsi/ginkgo/extensions/table"
+	. "github.com/onsi/gomega"
+)
+
+var _ = DescribeTable("stage container", func(stageContainer, stageName string, expectedOptions *StageContainerOptions, expectedErr string) {
+	docs, err := splitByDocs(`configVersion: 1
+project: demo
+---
+image: ~
+from: alpine
+stageContainer: `+stageContainer+"\n", "werf.yaml")
+	Ω(err).ShouldNot(HaveOccurred())
+
+	var images []*StapelImage
+	_, rawStapelImages, _, err := splitByMetaAndRawImages(docs)
+	if err == nil {
+		images, err = rawStapelImages[0].toStapelImageDirectives()
+	}
+
+	if expectedErr != "" {
+		Ω(err).Should(HaveOccurred())
+		Ω(err.Error()).Should(ContainSubstring(expectedErr))
+		return
+	}
+	Ω(err).ShouldNot(HaveOccurred())
+
+	Ω(images[0].StageContainer.StageOptions(stageName)).Should(Equal(expectedOptions))
+},
+	Entry("image options", `{cpus: 1.5, memory: 512m, timeout: 30m, network: none}`, "install",
+		&StageContainerOptions{Cpus: "1.5", Memory: "512m", Timeout: 30 * time.Minute, Network: "none"}, ""),
+	Entry("stage options override image ones", `{memory: 512m, timeout: 30m, network: none, stages: {install: {cpus: 2, timeout: 1h, network: bridge}}}`, "install",
+		&StageContainerOptions{Cpus: "2", Memory: "512m", Timeout: time.Hour, Network: "bridge"}, ""),
+	Entry("other stage options", `{network: none, stages: {install: {network: bridge}}}`, "setup",
+		&StageContainerOptions{Network: "none"}, ""),
+	Entry("invalid cpus", `{cpus: -1}`, "", nil, "invalid `cpus: -1`"),
+	Entry("invalid memory", `{memory: much}`, "", nil, "invalid `memory: much`"),
+	Entry("invalid timeout", `{timeout: 30}`, "", nil, "invalid `timeout: 30`"),
+	Entry("invalid stage timeout", `{stages: {setup: {timeout: 0s}}}`, "", nil, "invalid `timeout: 0s`"),
+	Entry("unknown stage", `{stages: {build: {memory: 1g}}}`, "", nil, "invalid stage `build`"),
+	Entry("unknown field", `{swap: 1g}`, "", nil, "swap"),
+)
@@ -12,10 +12,12 @@ type StapelImageBase struct {
 	FromImageName                                       string
 	FromImageArtifactName                               string
 	FromCacheVersion                                    string
+	TemplatesCommits                                    []string
 	Git                                                 *GitManager
 	Shell                                               *Shell
 	Ansible                                             *Ansible
 	Mount                                               []*Mount
+	StageContainer                                      *StageContainer
 	Import                                              []*Import
 
 	raw *rawStapelImage
@@ -0,0 +1,152 @@
+package config
+
+import (
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+
+	"github.com/flant/logboek"
+
+	"github.com/flant/werf/pkg/git_repo"
+	"github.com/flant/werf/pkg/logging"
+)
+
+type TemplatesLibrary struct {
+	Url    string
+	Tag    string
+	Commit string
+	Add    string
+	Path   string
+
+	raw *rawTemplatesLibrary
+}
+
+func (c *TemplatesLibrary) validate() error {
+	if (c.Url == "") == (c.Path == "") {
+		return newDetailedConfigError("one of `url: URL` or `path: PATH` required for templates library!", c.raw, c.raw.rawMeta.doc)
+	}
+
+	if c.Path != "" {
+		if c.Tag != "" || c.Commit != "" || c.Add != "" {
+			return newDetailedConfigError("specify `tag: TAG`, `commit: COMMIT` and `add: PATH` only for remote templates library!", c.raw, c.raw.rawMeta.doc)
+		}
+
+		return nil
+	}
+
+	if (c.Tag == "") == (c.Commit == "") {
+		return newDetailedConfigError("remote templates library should be pinned: one of `tag: TAG` or `commit: COMMIT` required!", c.raw, c.raw.rawMeta.doc)
+	}
+
+	if c.Add != "" && !isAbsolutePath(c.Add) {
+		return newDetailedConfigError("`add: PATH` absolute path in git repository required!", c.raw, c.raw.rawMeta.doc)
+	}
+
+	return nil
+}
+
+func (c *TemplatesLibrary) String() string {
+	if c.Path != "" {
+		return c.Path
+	}
+
+	return getRepositoryID(c.Url)
+}
+
+// loadedTemplatesLibrary contains *.tmpl files by names relative to the library root
+type loadedTemplatesLibrary struct {
+	Templates map[string][]byte
+	// Commit of remote library, which affects signatures of images using the library templates
+	Commit string
+}
+
+func (c *TemplatesLibrary) load(projectDir string) (*loadedTemplatesLibrary, error) {
+	if c.Path != "" {
+		return c.loadLocal(projectDir)
+	}
+
+	return c.loadRemote()
+}
+
+func (c *TemplatesLibrary) loadLocal(projectDir string) (*loadedTemplatesLibrary, error) {
+	libraryDir := c.Path
+	if !filepath.IsAbs(libraryDir) {
+		libraryDir = filepath.Join(projectDir, libraryDir)
+	}
+
+	templatesPaths, err := getWerfConfigsTemplates(libraryDir)
+	if err != nil {
+		return nil, err
+	}
+
+	res := &loadedTemplatesLibrary{Templates: map[string][]byte{}}
+	for _, templatePath := range templatesPaths {
+		templateName, err := filepath.Rel(libraryDir, templatePath)
+		if err != nil {
+			return nil, err
+		}
+
+		data, err := ioutil.ReadFile(templatePath)
+		if err != nil {
+			return nil, err
+		}
+
+		res.Templates[filepath.ToSlash(templateName)] = data
+	}
+
+	return res, nil
+}
+
+func (c *TemplatesLibrary) loadRemote() (*loadedTemplatesLibrary, error) {
+	remoteRepo := &git_repo.Remote{
+		Base: git_repo.Base{Name: getRepositoryID(c.Url)},
+		Url:  c.Url,
+	}
+
+	var commit string
+	if err := logging.LevelLogProcess(logboek.Info, fmt.Sprintf("Refreshing %s templates repository", remoteRepo.GetName()), logboek.LevelLogProcessOptions{}, func() error {
+		isCloned, err := remoteRepo.Clone()
+		if err != nil {
+			return err
+		}
+
+		// pinned commit or tag is fetched only if it is not in the repo cache yet
+		commit, err = c.resolveCommit(remoteRepo)
+		if err == nil || isCloned {
+			return err
+		}
+
+		if err := remoteRepo.Fetch(); err != nil {
+			return err
+		}
+
+		commit, err = c.resolveCommit(remoteRepo)
+		return err
+	}); err != nil {
+		return nil, fmt.Errorf("unable to get templates library %s: %s", c.Url, err)
+	}
+
+	templates, err := remoteRepo.ReadCommitFiles(commit, c.Add, func(relPath string) bool {
+		return strings.HasSuffix(relPath, ".tmpl")
+	})
+	if err != nil {
+		return nil, fmt.Errorf("unable to read templates library %s: %s", c.Url, err)
+	}
+
+	return &loadedTemplatesLibrary{Templates: templates, Commit: commit}, nil
+}
+
+func (c *TemplatesLibrary) resolveCommit(remoteRepo *git_repo.Remote) (string, error) {
+	if c.Tag != "" {
+		return remoteRepo.ResolveTag(c.Tag)
+	}
+
+	if exist, err := remoteRepo.IsCommitExists(c.Commit); err != nil {
+		return "", err
+	} else if !exist {
+		return "", fmt.Errorf("commit %s not found", c.Commit)
+	}
+
+	return c.Commit, nil
+}
@@ -0,0 +1,113 @@
+package config
+
+import (
+	. "github.com/onsi/ginkgo/extensions/table"
+	. "github.com/onsi/gomega"
+)
+
+var _ = DescribeTable("templates libraries usages", func(libraryTemplate, content string, expectedContent string, expectedTemplatesCommits [][]string) {
+	r := newWerfConfigRenderer(false)
+	Ω(r.parseLibraryTemplate("lib.tmpl", []byte(libraryTemplate), "COMMIT")).Should(Succeed())
+	_, err := r.tmpl.Parse(content)
+	Ω(err).ShouldNot(HaveOccurred())
+
+	Ω(r.execute(nil)).Should(Equal(expectedContent))
+
+	docs, err := splitByDocs(expectedContent, "werf.yaml")
+	Ω(err).ShouldNot(HaveOccurred())
+
+	setDocsTemplatesCommits(docs, expectedContent, r.templatesLibrariesUsages)
+
+	var templatesCommits [][]string
+	for _, d := range docs {
+		templatesCommits = append(templatesCommits, d.TemplatesCommits)
+	}
+	Ω(templatesCommits).Should(Equal(expectedTemplatesCommits))
+},
+	Entry("include marks only the doc with library template", `{{ define "from" }}from: alpine{{ end }}`, `configVersion: 1
+project: demo
+---
+image: a
+{{ include "from" . }}
+---
+image: b
+from: alpine
+`, `configVersion: 1
+project: demo
+---
+image: a
+from: alpine
+---
+image: b
+from: alpine
+`, [][]string{nil, {"COMMIT"}, nil}),
+	Entry("project template overrides library one", `{{ define "from" }}from: alpine{{ end }}`, `{{ define "from" }}from: ubuntu{{ end }}configVersion: 1
+project: demo
+---
+image: a
+{{ include "from" . }}
+`, `configVersion: 1
+project: demo
+---
+image: a
+from: ubuntu
+`, [][]string{nil, nil}),
+	Entry("template action marks the doc with library template", `{{ define "from" }}from: alpine{{ end }}`, `configVersion: 1
+project: demo
+---
+image: a
+{{- if true }}
+{{ template "from" . }}
+{{- end }}
+---
+image: b
+from: alpine
+`, `configVersion: 1
+project: demo
+---
+image: a
+from: alpine
+---
+image: b
+from: alpine
+`, [][]string{nil, {"COMMIT"}, nil}),
+	Entry("output spanning several docs marks all of them", `{{ define "images" }}image: a
+from: alpine
+---
+image: b
+from: alpine{{ end }}`, `configVersion: 1
+project: demo
+---
+{{ include "images" . }}
+---
+image: c
+from: alpine
+`, `configVersion: 1
+project: demo
+---
+image: a
+from: alpine
+---
+image: b
+from: alpine
+---
+image: c
+from: alpine
+`, [][]string{nil, {"COMMIT"}, {"COMMIT"}, nil}),
+	Entry("nested library templates", `{{ define "from" }}from: alpine{{ end }}{{ define "image" }}image: {{ .name }}
+{{ template "from" }}{{ end }}`, `configVersion: 1
+project: demo
+---
+{{ include "image" (dict "name" "a") }}
+---
+{{ template "image" (dict "name" "b") }}
+`, `configVersion: 1
+project: demo
+---
+image: a
+from: alpine
+---
+image: b
+from: alpine
+`, [][]string{nil, {"COMMIT"}, {"COMMIT"}}),
+)
@@ -3,6 +3,7 @@ package config
 import (
 	"errors"
 	"fmt"
+	"reflect"
 	"strings"
 )
 
@@ -99,11 +100,11 @@ func (c *WerfConfig) validateImagesNames() error {
 		name := image.Name
 
 		if name == "" && (len(c.StapelImages) > 1 || len(c.ImagesFromDockerfile) > 1) {
-			return newConfigError(fmt.Sprintf("conflict between images names: a nameless image cannot be specified in the config with multiple images!\n\n%s\n", dumpConfigDoc(image.raw.doc)))
+			return newDocsConfigError("conflict between images names: a nameless image cannot be specified in the config with multiple images!", image.raw.doc)
 		}
 
 		if d, ok := imageByName[name]; ok {
-			return newConfigError(fmt.Sprintf("conflict between images names!\n\n%s%s\n", dumpConfigDoc(d.(*StapelImage).raw.doc), dumpConfigDoc(image.raw.doc)))
+			return newDocsConfigError("conflict between images names!", d.(*StapelImage).raw.doc, image.raw.doc)
 		} else {
 			imageByName[name] = image
 		}
@@ -113,19 +114,11 @@ func (c *WerfConfig) validateImagesNames() error {
 		name := image.Name
 
 		if name == "" && (len(c.StapelImages) > 1 || len(c.ImagesFromDockerfile) > 1) {
-			return newConfigError(fmt.Sprintf("conflict between images names: a nameless image cannot be specified in the config with multiple images!\n\n%s\n", dumpConfigDoc(image.raw.doc)))
+			return newDocsConfigError("conflict between images names: a nameless image cannot be specified in the config with multiple images!", image.raw.doc)
 		}
 
 		if d, ok := imageByName[name]; ok {
-			var doc string
-			switch i := d.(type) {
-			case *StapelImage:
-				doc = dumpConfigDoc(i.raw.doc)
-			case *ImageFromDockerfile:
-				doc = dumpConfigDoc(i.raw.doc)
-			}
-
-			return newConfigError(fmt.Sprintf("conflict between images names!\n\n%s%s\n", doc, dumpConfigDoc(image.raw.doc)))
+			return newDocsConfigError("conflict between images names!", imageInterfaceDoc(d), image.raw.doc)
 		} else {
 			imageByName[name] = image
 		}
@@ -136,20 +129,13 @@ func (c *WerfConfig) validateImagesNames() error {
 		name := artifact.Name
 
 		if a, ok := imageArtifactByName[name]; ok {
-			return newConfigError(fmt.Sprintf("conflict between artifacts names!\n\n%s%s\n", dumpConfigDoc(a.raw.doc), dumpConfigDoc(artifact.raw.doc)))
+			return newDocsConfigError("conflict between artifacts names!", a.raw.doc, artifact.raw.doc)
 		} else {
 			imageArtifactByName[name] = artifact
 		}
 
 		if iInterface, exist := imageByName[name]; exist {
-			var doc string
-			switch i := iInterface.(type) {
-			case *StapelImage:
-				doc = dumpConfigDoc(i.raw.doc)
-			case *ImageFromDockerfile:
-				doc = dumpConfigDoc(i.raw.doc)
-			}
-			return newConfigError(fmt.Sprintf("conflict between image and artifact names!\n\n%s%s\n", doc, dumpConfigDoc(artifact.raw.doc)))
+			return newDocsConfigError("conflict between image and artifact names!", imageInterfaceDoc(iInterface), artifact.raw.doc)
 		} else {
 			imageArtifactByName[name] = artifact
 		}
@@ -158,6 +144,17 @@ func (c *WerfConfig) validateImagesNames() error {
 	return nil
 }
 
+func imageInterfaceDoc(image ImageInterface) *doc {
+	switch i := image.(type) {
+	case *StapelImage:
+		return i.raw.doc
+	case *ImageFromDockerfile:
+		return i.raw.doc
+	}
+
+	return nil
+}
+
 func (c *WerfConfig) associateImportsArtifacts() error {
 	var relatedImageImages []ImageInterface
 	var artifactImports []*Import
@@ -255,18 +252,22 @@ func (c *WerfConfig) validateImageFrom(i *StapelImageBase) error {
 
 func (c *WerfConfig) validateInfiniteLoopBetweenRelatedImages() error {
 	var imageAndArtifactNames []string
+	var imageAndArtifactDocs []*doc
 
 	for _, image := range c.StapelImages {
 		imageAndArtifactNames = append(imageAndArtifactNames, image.Name)
+		imageAndArtifactDocs = append(imageAndArtifactDocs, image.raw.doc)
 	}
 
 	for _, artifact := range c.Artifacts {
 		imageAndArtifactNames = append(imageAndArtifactNames, artifact.Name)
+		imageAndArtifactDocs = append(imageAndArtifactDocs, artifact.raw.doc)
 	}
 
-	for _, imageOrArtifactName := range imageAndArtifactNames {
+	for ind, imageOrArtifactName := range imageAndArtifactNames {
 		if err, errImagesStack := c.validateImageInfiniteLoop(imageOrArtifactName, []string{}); err != nil {
-			return fmt.Errorf("%s: %s", err, strings.Join(errImagesStack, " -> "))
+			message := fmt.Sprintf("%s: %s", err, strings.Join(errImagesStack, " -> "))
+			return &configError{s: message, message: message, doc: imageAndArtifactDocs[ind]}
 		}
 	}
 
@@ -297,21 +298,63 @@ func (c *WerfConfig) ImageTree(interf ImageInterface) (tree []ImageInterface) {
 	return append(tree, interf)
 }
 
-func (c *WerfConfig) relatedImageImages(interf ImageInterface) (images []ImageInterface) {
+// ImagesInBuildOrder returns images to process with all related images and artifacts in build order
+func (c *WerfConfig) ImagesInBuildOrder(imagesToProcess []ImageInterface) []ImageInterface {
+	var images []ImageInterface
+	for _, imageInterf := range imagesToProcess {
+		var imagesInBuildOrder []ImageInterface
+
+		switch image := imageInterf.(type) {
+		case *StapelImage:
+			imagesInBuildOrder = c.ImageTree(image)
+		case *ImageFromDockerfile:
+			imagesInBuildOrder = append(imagesInBuildOrder, image)
+		}
+
+		for i := 0; i < len(imagesInBuildOrder); i++ {
+			if isNotInArr(images, imagesInBuildOrder[i]) {
+				images = append(images, imagesInBuildOrder[i])
+			}
+		}
+	}
+
+	return images
+}
+
+func isNotInArr(arr []ImageInterface, obj ImageInterface) bool {
+	for _, elm := range arr {
+		if reflect.DeepEqual(elm, obj) {
+			return false
+		}
+	}
+
+	return true
+}
+
+func (c *WerfConfig) relatedImageImages(interf ImageInterface) []ImageInterface {
+	return c.appendRelatedImageImages(nil, interf)
+}
+
+// appendRelatedImageImages skips already collected images, so there is no infinite recursion before loops validation
+func (c *WerfConfig) appendRelatedImageImages(images []ImageInterface, interf ImageInterface) []ImageInterface {
+	if !isNotInArr(images, interf) {
+		return images
+	}
+
 	images = append(images, interf)
 	switch i := interf.(type) {
 	case StapelImageInterface:
 		if i.ImageBaseConfig().FromImageName != "" {
-			images = append(images, c.relatedImageImages(c.GetImage(i.ImageBaseConfig().FromImageName))...)
+			images = c.appendRelatedImageImages(images, c.GetImage(i.ImageBaseConfig().FromImageName))
 		}
 
 		if i.ImageBaseConfig().FromImageArtifactName != "" {
-			images = append(images, c.relatedImageImages(c.GetArtifact(i.ImageBaseConfig().FromImageArtifactName))...)
+			images = c.appendRelatedImageImages(images, c.GetArtifact(i.ImageBaseConfig().FromImageArtifactName))
 		}
 	case *ImageFromDockerfile:
 	}
 
-	return
+	return images
 }
 
 func (c *WerfConfig) validateImageInfiniteLoop(imageOrArtifactName string, imageNameStack []string) (error, []string) {
@@ -8,6 +8,7 @@ import (
 
 	"github.com/flant/werf/pkg/images_manager"
 
+	"github.com/flant/werf/pkg/logging"
 	"github.com/flant/werf/pkg/util/secretvalues"
 
 	"github.com/ghodss/yaml"
@@ -21,6 +22,7 @@ import (
 	"github.com/flant/werf/pkg/config"
 	"github.com/flant/werf/pkg/deploy/helm"
 	"github.com/flant/werf/pkg/deploy/werf_chart"
+	"github.com/flant/werf/pkg/image_signing"
 	"github.com/flant/werf/pkg/tag_strategy"
 )
 
@@ -35,12 +37,19 @@ type DeployOptions struct {
 	UserExtraLabels      map[string]string
 	IgnoreSecretKey      bool
 	ThreeWayMergeMode    helm.ThreeWayMergeModeType
+	ImagesVerifier       *image_signing.Verifier
 }
 
 func Deploy(projectDir string, imagesRepoManager images_manager.ImagesRepoManager, images []images_manager.ImageInfoGetter, release, namespace, commonTag string, tagStrategy tag_strategy.TagStrategy, werfConfig *config.WerfConfig, helmReleaseStorageNamespace, helmReleaseStorageType string, opts DeployOptions) error {
 	var werfChart *werf_chart.WerfChart
 
-	if err := logboek.Default.LogBlock("Deploy options", logboek.LevelLogBlockOptions{}, func() error {
+	if opts.ImagesVerifier != nil {
+		if err := verifyImagesSignatures(opts.ImagesVerifier, images); err != nil {
+			return err
+		}
+	}
+
+	if err := logging.LevelLogBlock(logboek.Default, "Deploy options", logboek.LevelLogBlockOptions{}, func() error {
 		if kube.Context != "" {
 			logboek.LogF("Kube-config context: %s\n", kube.Context)
 		}
@@ -54,6 +63,11 @@ func Deploy(projectDir string, imagesRepoManager images_manager.ImagesRepoManage
 			return err
 		}
 
+		envM, err := GetSafeEnvSecretManager(projectDir, opts.Env, opts.IgnoreSecretKey)
+		if err != nil {
+			return err
+		}
+
 		serviceValues, err := GetServiceValues(werfConfig.Meta.Project, imagesRepoManager, namespace, commonTag, tagStrategy, images, ServiceValuesOptions{Env: opts.Env})
 		if err != nil {
 			return fmt.Errorf("error creating service values: %s", err)
@@ -61,13 +75,13 @@ func Deploy(projectDir string, imagesRepoManager images_manager.ImagesRepoManage
 
 		serviceValuesRaw, _ := yaml.Marshal(serviceValues)
 		serviceValuesRawStr := strings.TrimRight(string(serviceValuesRaw), "\n")
-		_ = logboek.Info.LogBlock(fmt.Sprintf("Service values"), logboek.LevelLogBlockOptions{}, func() error {
+		_ = logging.LevelLogBlock(logboek.Info, fmt.Sprintf("Service values"), logboek.LevelLogBlockOptions{}, func() error {
 			logboek.Info.LogLn(serviceValuesRawStr)
 			return nil
 		})
 
 		projectChartDir := filepath.Join(projectDir, werf_chart.ProjectHelmChartDirName)
-		werfChart, err = PrepareWerfChart(werfConfig.Meta.Project, projectChartDir, opts.Env, m, opts.SecretValues, serviceValues)
+		werfChart, err = PrepareWerfChart(werfConfig.Meta.Project, projectChartDir, opts.Env, m, envM, opts.SecretValues, serviceValues)
 		if err != nil {
 			return err
 		}
@@ -108,6 +122,25 @@ func Deploy(projectDir string, imagesRepoManager images_manager.ImagesRepoManage
 	return nil
 }
 
+func verifyImagesSignatures(verifier *image_signing.Verifier, images []images_manager.ImageInfoGetter) error {
+	return logging.LevelLogProcess(logboek.Default, "Verifying images signatures", logboek.LevelLogProcessOptions{}, func() error {
+		for _, img := range images {
+			imageName, err := img.GetImageName()
+			if err != nil {
+				return err
+			}
+
+			if err := verifier.VerifyImage(imageName); err != nil {
+				return fmt.Errorf("images signatures verification failed: %s", err)
+			}
+
+			logboek.Info.LogFDetails("%s: signature is valid\n", imageName)
+		}
+
+		return nil
+	})
+}
+
 func patchLoadChartfile(chartName string) {
 	boundedFunc := helm.LoadChartfileFunc
 	helm.LoadChartfileFunc = func(chartPath string) (*chart.Chart, error) {
@@ -16,6 +16,8 @@ import (
 	"github.com/flant/kubedog/pkg/kube"
 	"github.com/flant/logboek"
 	"github.com/flant/shluz"
+	"github.com/flant/werf/pkg/logging"
+	"github.com/flant/werf/pkg/tracing"
 	"github.com/flant/werf/pkg/util"
 	"github.com/flant/werf/pkg/werf"
 )
@@ -66,7 +68,7 @@ func PurgeHelmRelease(releaseName, namespace string, withNamespace, withHooks bo
 }
 
 func doPurgeHelmRelease(releaseName, namespace string, withNamespace, withHooks bool) error {
-	if err := logboek.Info.LogProcess("Checking release existence", logboek.LevelLogProcessOptions{}, func() error {
+	if err := logging.LevelLogProcess(logboek.Info, "Checking release existence", logboek.LevelLogProcessOptions{}, func() error {
 		_, err := releaseStatus(releaseName, releaseStatusOptions{})
 		if err != nil {
 			if isReleaseNotFoundError(err) {
@@ -98,7 +100,7 @@ func doPurgeHelmRelease(releaseName, namespace string, withNamespace, withHooks
 
 		deletedHooks := map[string]bool{}
 		msg := fmt.Sprintf("Deleting helm hooks from all existing release revisions (%d)", len(resp.Releases))
-		if err := logboek.LogProcess(msg, logboek.LogProcessOptions{}, func() error {
+		if err := logging.LogProcess(msg, logboek.LogProcessOptions{}, func() error {
 			for _, rev := range resp.Releases {
 				revHooksToDelete := map[string]Template{}
 				for _, h := range rev.Hooks {
@@ -118,7 +120,7 @@ func doPurgeHelmRelease(releaseName, namespace string, withNamespace, withHooks
 
 				if len(revHooksToDelete) != 0 {
 					msg := fmt.Sprintf("Processing release %s revision %d", releaseName, rev.Version)
-					_ = logboek.Info.LogProcess(msg, logboek.LevelLogProcessOptions{}, func() error {
+					_ = logging.LevelLogProcess(logboek.Info, msg, logboek.LevelLogProcessOptions{}, func() error {
 						for hookId, hookTemplate := range revHooksToDelete {
 							deletedHooks[hookId] = true
 
@@ -138,7 +140,7 @@ func doPurgeHelmRelease(releaseName, namespace string, withNamespace, withHooks
 		}
 	}
 
-	if err := logboek.LogProcess("Deleting release", logboek.LogProcessOptions{}, func() error {
+	if err := logging.LogProcess("Deleting release", logboek.LogProcessOptions{}, func() error {
 		return releaseDelete(releaseName, releaseDeleteOptions{Purge: true})
 	}); err != nil {
 		return fmt.Errorf("release delete failed: %s", err)
@@ -177,8 +179,10 @@ func withLockedHelmRelease(releaseName string, f func() error) error {
 }
 
 func DeployHelmChart(chartPath, releaseName, namespace string, opts ChartOptions) error {
-	return withLockedHelmRelease(releaseName, func() error {
-		return doDeployHelmChart(chartPath, releaseName, namespace, opts)
+	return tracing.WithSpan("helm deploy", map[string]string{"werf.helm.release": releaseName, "werf.helm.namespace": namespace}, func() error {
+		return withLockedHelmRelease(releaseName, func() error {
+			return doDeployHelmChart(chartPath, releaseName, namespace, opts)
+		})
 	})
 }
 
@@ -219,7 +223,7 @@ func doDeployHelmChart(chartPath, releaseName, namespace string, opts ChartOptio
 			},
 		}
 
-		if err := logboek.Info.LogProcess("Checking release", logProcessOptions, func() error {
+		if err := logging.LevelLogProcess(logboek.Info, "Checking release", logProcessOptions, func() error {
 			resp, err := releaseHistory(releaseName, releaseHistoryOptions{Max: 1})
 			if err != nil && !isReleaseNotFoundError(err) {
 				return fmt.Errorf("get release history failed: %s", err)
@@ -286,7 +290,7 @@ func doDeployHelmChart(chartPath, releaseName, namespace string, opts ChartOptio
 		}
 
 		if releaseShouldBeDeleted {
-			if err := logboek.LogProcess("Deleting release", logboek.LogProcessOptions{}, func() error {
+			if err := logging.LogProcess("Deleting release", logboek.LogProcessOptions{}, func() error {
 				return releaseDelete(releaseName, releaseDeleteOptions{Purge: true})
 			}); err != nil {
 				return fmt.Errorf("release delete failed: %s", err)
@@ -309,7 +313,7 @@ func doDeployHelmChart(chartPath, releaseName, namespace string, opts ChartOptio
 					}
 				},
 			}
-			if err := logboek.Default.LogProcess(logProcessMsg, logProcessOptions, func() error {
+			if err := logging.LevelLogProcess(logboek.Default, logProcessMsg, logProcessOptions, func() error {
 				var latestSuccessfullyDeployedReleaseRevisionErr error
 
 				logProcessOptions := logboek.LevelLogProcessOptions{
@@ -321,7 +325,7 @@ func doDeployHelmChart(chartPath, releaseName, namespace string, opts ChartOptio
 						}
 					},
 				}
-				if err := logboek.Info.LogProcess(
+				if err := logging.LevelLogProcess(logboek.Info,
 					"Getting the latest successfully deployed release revision",
 					logProcessOptions,
 					func() error {
@@ -344,7 +348,7 @@ func doDeployHelmChart(chartPath, releaseName, namespace string, opts ChartOptio
 
 				var templatesFromRevision ChartTemplates
 				logProcessMsg := fmt.Sprintf("Getting templates from release revision %d", latestSuccessfullyDeployedRevision)
-				if err := logboek.Info.LogProcessInline(logProcessMsg, logboek.LevelLogProcessInlineOptions{}, func() error {
+				if err := logging.LevelLogProcessInline(logboek.Info, logProcessMsg, logboek.LevelLogProcessInlineOptions{}, func() error {
 					templatesFromRevision, latestSuccessfullyDeployedReleaseRevisionErr = GetTemplatesFromReleaseRevision(releaseName, latestSuccessfullyDeployedRevision)
 					return latestSuccessfullyDeployedReleaseRevisionErr
 				}); err != nil {
@@ -392,7 +396,7 @@ func doDeployHelmChart(chartPath, releaseName, namespace string, opts ChartOptio
 		return nil
 	}
 
-	if err := logboek.Info.LogProcess("Running pre-deploy", logboek.LevelLogProcessOptions{}, func() error {
+	if err := logging.LevelLogProcess(logboek.Info, "Running pre-deploy", logboek.LevelLogProcessOptions{}, func() error {
 		return preDeployFunc()
 	}); err != nil {
 		return err
@@ -479,7 +483,7 @@ func doDeployHelmChart(chartPath, releaseName, namespace string, opts ChartOptio
 
 	var templatesFromChart ChartTemplates
 
-	if err := logboek.Info.LogProcessInline("Getting chart templates", logboek.LevelLogProcessInlineOptions{}, func() error {
+	if err := logging.LevelLogProcessInline(logboek.Info, "Getting chart templates", logboek.LevelLogProcessInlineOptions{}, func() error {
 		templatesFromChart, err = GetTemplatesFromChart(chartPath, releaseName, namespace, opts.Values, opts.SecretValues, opts.Set, opts.SetString)
 		return err
 	}); err != nil {
@@ -590,7 +594,7 @@ func removeResource(name, kind, namespace string) error {
 		logProcessMsg = fmt.Sprintf("Deleting %s/%s", groupVersionResource.Resource, name)
 	}
 
-	return logboek.LogProcessInline(logProcessMsg,
+	return logging.LogProcessInline(logProcessMsg,
 		logboek.LogProcessInlineOptions{
 			LevelLogProcessInlineOptions: logboek.LevelLogProcessInlineOptions{Style: logboek.DetailsStyle()},
 		},
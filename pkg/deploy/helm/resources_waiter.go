@@ -24,6 +24,9 @@ import (
 	"k8s.io/cli-runtime/pkg/resource"
 	"k8s.io/client-go/kubernetes/scheme"
 	helmKube "k8s.io/helm/pkg/kube"
+
+	"github.com/flant/werf/pkg/logging"
+	"github.com/flant/werf/pkg/tracing"
 )
 
 type ResourcesWaiter struct {
@@ -149,13 +152,15 @@ func (waiter *ResourcesWaiter) WaitForResources(timeout time.Duration, created h
 	}
 
 	logboek.LogOptionalLn()
-	return logboek.LogProcess("Waiting for release resources to become ready", logboek.LogProcessOptions{}, func() error {
-		return multitrack.Multitrack(kube.Kubernetes, specs, multitrack.MultitrackOptions{
-			StatusProgressPeriod: waiter.StatusProgressPeriod,
-			Options: tracker.Options{
-				Timeout:      timeout,
-				LogsFromTime: waiter.LogsFromTime,
-			},
+	return logging.LogProcess("Waiting for release resources to become ready", logboek.LogProcessOptions{}, func() error {
+		return tracing.WithSpan("helm track resources", nil, func() error {
+			return multitrack.Multitrack(kube.Kubernetes, specs, multitrack.MultitrackOptions{
+				StatusProgressPeriod: waiter.StatusProgressPeriod,
+				Options: tracker.Options{
+					Timeout:      timeout,
+					LogsFromTime: waiter.LogsFromTime,
+				},
+			})
 		})
 	})
 }
@@ -328,7 +333,7 @@ func (waiter *ResourcesWaiter) WatchUntilReady(namespace string, reader io.Reade
 				specs.Jobs = append(specs.Jobs, *spec)
 			}
 
-			return logboek.LogProcess(fmt.Sprintf("Waiting for helm hook job/%s termination", name), logboek.LogProcessOptions{}, func() error {
+			return logging.LogProcess(fmt.Sprintf("Waiting for helm hook job/%s termination", name), logboek.LogProcessOptions{}, func() error {
 				return multitrack.Multitrack(kube.Kubernetes, specs, multitrack.MultitrackOptions{
 					StatusProgressPeriod: waiter.HooksStatusProgressPeriod,
 					Options: tracker.Options{
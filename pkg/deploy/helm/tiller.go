@@ -9,6 +9,7 @@ import (
 	"text/tabwriter"
 	"time"
 
+	"github.com/flant/werf/pkg/logging"
 	"github.com/flant/werf/pkg/util/secretvalues"
 
 	"github.com/gosuri/uitable"
@@ -327,7 +328,7 @@ func ReleaseInstall(chartPath, releaseName, namespace string, values []string, s
 		return err
 	}
 
-	return logboek.LogBlock(fmt.Sprintf("Deployed release info"), logboek.LogBlockOptions{}, func() error {
+	return logging.LogBlock(fmt.Sprintf("Deployed release info"), logboek.LogBlockOptions{}, func() error {
 		return fprintReleaseStatus(logboek.GetOutStream(), releaseName)
 	})
 }
@@ -363,7 +364,7 @@ func ReleaseUpdate(chartPath, releaseName string, values []string, secretValues
 		return err
 	}
 
-	return logboek.LogBlock(fmt.Sprintf("Deployed release info"), logboek.LogBlockOptions{}, func() error {
+	return logging.LogBlock(fmt.Sprintf("Deployed release info"), logboek.LogBlockOptions{}, func() error {
 		return fprintReleaseStatus(logboek.GetOutStream(), releaseName)
 	})
 }
@@ -529,7 +530,7 @@ func releaseRollback(releaseName string, revision int32, userSpecifiedThreeWayMe
 
 func displayReleaseLogMessages() {
 	logboek.LogOptionalLn()
-	_ = logboek.Default.LogBlock("Debug info", logboek.LevelLogBlockOptions{}, func() error {
+	_ = logging.LevelLogBlock(logboek.Default, "Debug info", logboek.LevelLogBlockOptions{}, func() error {
 		for _, msg := range releaseLogMessages {
 			_, _ = logboek.OutF("%s\n", logboek.DetailsStyle().Colorize(secretvalues.MaskSecretValuesInString(releaseLogSecretValuesToMask, msg)))
 		}
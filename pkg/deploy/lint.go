@@ -32,6 +32,11 @@ func RunLint(projectDir string, werfConfig *config.WerfConfig, imagesRepoManager
 		return err
 	}
 
+	envM, err := GetSafeEnvSecretManager(projectDir, opts.Env, opts.IgnoreSecretKey)
+	if err != nil {
+		return err
+	}
+
 	namespace := "NAMESPACE"
 
 	serviceValues, err := GetServiceValues(werfConfig.Meta.Project, imagesRepoManager, namespace, commonTag, tagStrategy, images, ServiceValuesOptions{Env: opts.Env})
@@ -40,7 +45,7 @@ func RunLint(projectDir string, werfConfig *config.WerfConfig, imagesRepoManager
 	}
 
 	projectChartDir := filepath.Join(projectDir, werf_chart.ProjectHelmChartDirName)
-	werfChart, err := PrepareWerfChart(werfConfig.Meta.Project, projectChartDir, opts.Env, m, opts.SecretValues, serviceValues)
+	werfChart, err := PrepareWerfChart(werfConfig.Meta.Project, projectChartDir, opts.Env, m, envM, opts.SecretValues, serviceValues)
 	if err != nil {
 		return err
 	}
@@ -35,13 +35,18 @@ func RunRender(out io.Writer, projectDir string, werfConfig *config.WerfConfig,
 		return err
 	}
 
+	envM, err := GetSafeEnvSecretManager(projectDir, opts.Env, opts.IgnoreSecretKey)
+	if err != nil {
+		return err
+	}
+
 	serviceValues, err := GetServiceValues(werfConfig.Meta.Project, imagesRepoManager, opts.Namespace, commonTag, tagStrategy, images, ServiceValuesOptions{Env: opts.Env})
 	if err != nil {
 		return err
 	}
 
 	projectChartDir := filepath.Join(projectDir, werf_chart.ProjectHelmChartDirName)
-	werfChart, err := PrepareWerfChart(werfConfig.Meta.Project, projectChartDir, opts.Env, m, opts.SecretValues, serviceValues)
+	werfChart, err := PrepareWerfChart(werfConfig.Meta.Project, projectChartDir, opts.Env, m, envM, opts.SecretValues, serviceValues)
 	if err != nil {
 		return err
 	}
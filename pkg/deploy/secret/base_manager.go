@@ -11,6 +11,16 @@ import (
 type BaseManager struct {
 	generateFunc func([]byte) ([]byte, error)
 	extractFunc  func([]byte) ([]byte, error)
+
+	metadataSecret metadataSecret
+}
+
+// metadataSecret is implemented by secrets which record metadata in the encrypted files
+type metadataSecret interface {
+	// GetMetadata returns metadata of the encryption, nil metadata means the symmetric encryption
+	GetMetadata() (*Metadata, error)
+	// ForMetadata returns secret to decrypt data of the file with metadata (e.g. by the data key of the envelope encryption)
+	ForMetadata(metadata *Metadata) (secret.Secret, error)
 }
 
 func newBaseManager(ss secret.Secret) (Manager, error) {
@@ -19,6 +29,10 @@ func newBaseManager(ss secret.Secret) (Manager, error) {
 	if ss != nil {
 		s.generateFunc = ss.Encrypt
 		s.extractFunc = ss.Decrypt
+
+		if ms, ok := ss.(metadataSecret); ok {
+			s.metadataSecret = ms
+		}
 	} else {
 		s.generateFunc = doNothing
 		s.extractFunc = doNothing
@@ -38,8 +52,39 @@ func (s *BaseManager) Encrypt(data []byte) ([]byte, error) {
 	return resultData, nil
 }
 
+func (s *BaseManager) EncryptFileData(data []byte) ([]byte, error) {
+	metadata, err := s.GetMetadata()
+	if err != nil {
+		return nil, fmt.Errorf("encryption failed: %s", err)
+	}
+
+	resultData, err := s.Encrypt(data)
+	if err != nil {
+		return nil, err
+	}
+
+	if metadata != nil {
+		resultData = append(metadata.fileHeader(), resultData...)
+	}
+
+	return resultData, nil
+}
+
+func (s *BaseManager) GetMetadata() (*Metadata, error) {
+	if s.metadataSecret == nil {
+		return nil, nil
+	}
+
+	return s.metadataSecret.GetMetadata()
+}
+
 func (s *BaseManager) EncryptYamlData(data []byte) ([]byte, error) {
-	resultData, err := doYamlData(s.generateFunc, data)
+	metadata, err := s.GetMetadata()
+	if err != nil {
+		return nil, fmt.Errorf("encryption failed: %s", err)
+	}
+
+	resultData, err := doYamlData(s.generateFunc, data, metadata)
 	if err != nil {
 		return nil, fmt.Errorf("encryption failed: check encryption key and data: %s", err)
 	}
@@ -48,7 +93,17 @@ func (s *BaseManager) EncryptYamlData(data []byte) ([]byte, error) {
 }
 
 func (s *BaseManager) Decrypt(data []byte) ([]byte, error) {
-	resultData, err := s.extractFunc(data)
+	metadata, data, err := SplitFileMetadata(data)
+	if err != nil {
+		return nil, err
+	}
+
+	extractFunc, err := s.extractFuncByMetadata(metadata)
+	if err != nil {
+		return nil, fmt.Errorf("decryption failed: %s", err)
+	}
+
+	resultData, err := extractFunc(data)
 	if err != nil {
 		if secret.IsExtractDataError(err) {
 			return nil, fmt.Errorf("decryption failed: check data `%s`: %s", string(data), err)
@@ -61,7 +116,17 @@ func (s *BaseManager) Decrypt(data []byte) ([]byte, error) {
 }
 
 func (s *BaseManager) DecryptYamlData(data []byte) ([]byte, error) {
-	resultData, err := doYamlData(s.extractFunc, data)
+	metadata, err := ReadMetadata(data)
+	if err != nil {
+		return nil, err
+	}
+
+	extractFunc, err := s.extractFuncByMetadata(metadata)
+	if err != nil {
+		return nil, fmt.Errorf("decryption failed: %s", err)
+	}
+
+	resultData, err := doYamlData(extractFunc, data, nil)
 	if err != nil {
 		if secret.IsExtractDataError(err) {
 			return nil, fmt.Errorf("decryption failed: check data `%s`: %s", string(data), err)
@@ -73,18 +138,40 @@ func (s *BaseManager) DecryptYamlData(data []byte) ([]byte, error) {
 	return resultData, nil
 }
 
-func doYamlData(doFunc func([]byte) ([]byte, error), data []byte) ([]byte, error) {
+func (s *BaseManager) extractFuncByMetadata(metadata *Metadata) (func([]byte) ([]byte, error), error) {
+	if s.metadataSecret == nil || metadata == nil {
+		return s.extractFunc, nil
+	}
+
+	ss, err := s.metadataSecret.ForMetadata(metadata)
+	if err != nil {
+		return nil, err
+	}
+
+	return ss.Decrypt, nil
+}
+
+func doYamlData(doFunc func([]byte) ([]byte, error), data []byte, metadata *Metadata) ([]byte, error) {
 	config := make(yaml.MapSlice, 0)
 	err := yaml.UnmarshalStrict(data, &config)
 	if err != nil {
 		return nil, err
 	}
 
+	_, config, err = SplitYamlMetadata(config)
+	if err != nil {
+		return nil, err
+	}
+
 	resultConfig, err := doYamlValueSecret(doFunc, config)
 	if err != nil {
 		return nil, err
 	}
 
+	if metadata != nil {
+		resultConfig = append(yaml.MapSlice{metadata.yamlItem()}, resultConfig.(yaml.MapSlice)...)
+	}
+
 	resultData, err := yaml.Marshal(resultConfig)
 	if err != nil {
 		return nil, err
@@ -1,91 +1,99 @@
 package secret
 
 import (
+	"bytes"
 	"fmt"
 	"io/ioutil"
 	"os"
 	"path/filepath"
+	"regexp"
 	"strings"
+	"unicode"
 
 	"github.com/flant/werf/pkg/secret"
 	"github.com/flant/werf/pkg/util"
 	"github.com/flant/werf/pkg/werf"
 )
 
+var envNameInvalidCharsRegexp = regexp.MustCompile(`[^a-zA-Z0-9]`)
+
 type Manager interface {
 	secret.Secret
 
 	EncryptYamlData(data []byte) ([]byte, error)
 	DecryptYamlData(encodedData []byte) ([]byte, error)
+
+	// EncryptFileData encrypts secret file data and records metadata of the asymmetric encryption
+	EncryptFileData(data []byte) ([]byte, error)
+	GetMetadata() (*Metadata, error)
 }
 
 func GenerateSecretKey() ([]byte, error) {
 	return secret.GenerateAexSecretKey()
 }
 
+func GenerateAgeIdentity() (string, string, error) {
+	return secret.GenerateAgeIdentity()
+}
+
 func GetManager(projectDir string) (Manager, error) {
-	var m Manager
-	var key []byte
-	var err error
+	return GetEnvManager(projectDir, "")
+}
 
-	key, err = GetSecretKey(projectDir)
+// GetEnvManager creates manager which uses the encryption key of the environment (see GetEnvSecretKey)
+func GetEnvManager(projectDir, env string) (Manager, error) {
+	return GetManagerWithMetadata(projectDir, env, nil)
+}
+
+// GetManagerWithMetadata creates manager which encrypts data for the project recipients ($WERF_SECRET_RECIPIENTS or .werf_secret_recipients)
+// or, if the project recipients are not configured, for the recipients of the default metadata (e.g. recorded in the edited file).
+// Without recipients the encryption key is used. Data is decrypted by the backend the data was encrypted with.
+func GetManagerWithMetadata(projectDir, env string, defaultMetadata *Metadata) (Manager, error) {
+	recipients, err := GetSecretRecipients(projectDir)
 	if err != nil {
 		return nil, err
 	}
 
-	m, err = NewManager(key)
+	if len(recipients) == 0 && defaultMetadata != nil {
+		recipients = defaultMetadata.Recipients
+	}
+
+	ss, err := newProjectSecret(projectDir, env, recipients, defaultMetadata)
 	if err != nil {
 		return nil, err
 	}
 
-	return m, nil
+	return newBaseManager(ss)
 }
 
-func GetSecretKey(projectDir string) ([]byte, error) {
-	var secretKey []byte
-	var werfSecretKeyPaths []string
-	var notFoundIn []string
-
-	secretKey = []byte(os.Getenv("WERF_SECRET_KEY"))
-	if len(secretKey) == 0 {
-		notFoundIn = append(notFoundIn, "$WERF_SECRET_KEY")
+// GetManagerForFile creates manager which re-encrypts the existing file for the recipients recorded in the file
+func GetManagerForFile(projectDir, env, filePath string) (Manager, error) {
+	var metadata *Metadata
 
-		var werfSecretKeyPath string
+	exist, err := util.FileExists(filePath)
+	if err != nil {
+		return nil, err
+	}
 
-		projectWerfSecretKeyPath, err := filepath.Abs(filepath.Join(projectDir, ".werf_secret_key"))
+	if exist {
+		data, err := ioutil.ReadFile(filePath)
 		if err != nil {
 			return nil, err
 		}
 
-		homeWerfSecretKeyPath := filepath.Join(werf.GetHomeDir(), "global_secret_key")
-
-		werfSecretKeyPaths = []string{
-			projectWerfSecretKeyPath,
-			homeWerfSecretKeyPath,
-		}
-
-		for _, path := range werfSecretKeyPaths {
-			exist, err := util.FileExists(path)
-			if err != nil {
-				return nil, err
-			}
-
-			if exist {
-				werfSecretKeyPath = path
-				break
-			} else {
-				notFoundIn = append(notFoundIn, path)
-			}
+		metadata, err = ReadMetadata(bytes.TrimSpace(data))
+		if err != nil {
+			return nil, fmt.Errorf("unable to read secret file %s metadata: %s", filePath, err)
 		}
+	}
 
-		if werfSecretKeyPath != "" {
-			data, err := ioutil.ReadFile(werfSecretKeyPath)
-			if err != nil {
-				return nil, err
-			}
+	return GetManagerWithMetadata(projectDir, env, metadata)
+}
 
-			secretKey = []byte(strings.TrimSpace(string(data)))
-		}
+func GetSecretKey(projectDir string) ([]byte, error) {
+	secretKey, notFoundIn, err := readSecretKeyData(projectDir, "WERF_SECRET_KEY", ".werf_secret_key", "global_secret_key")
+	if err != nil {
+		return nil, err
 	}
 
 	if len(secretKey) == 0 {
@@ -95,6 +103,117 @@ func GetSecretKey(projectDir string) ([]byte, error) {
 	return secretKey, nil
 }
 
+// GetEnvSecretKey returns the encryption key of the environment ($WERF_SECRET_KEY_<ENV> or .werf_secret_key.<env>)
+// or, if the environment key is not found, the common encryption key
+func GetEnvSecretKey(projectDir, env string) ([]byte, error) {
+	if env == "" {
+		return GetSecretKey(projectDir)
+	}
+
+	secretKey, _, err := readSecretKeyData(projectDir, EnvSecretKeyEnvName(env), ".werf_secret_key."+env, "global_secret_key."+env)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(secretKey) != 0 {
+		return secretKey, nil
+	}
+
+	return GetSecretKey(projectDir)
+}
+
+// EnvSecretKeyEnvName returns name of the environment variable with the encryption key of the environment: WERF_SECRET_KEY_PRODUCTION for production
+func EnvSecretKeyEnvName(env string) string {
+	return "WERF_SECRET_KEY_" + strings.ToUpper(envNameInvalidCharsRegexp.ReplaceAllString(env, "_"))
+}
+
+// GetSecretRecipients returns age X25519 public keys (age1...) and paths to the armored OpenPGP public keys (pgp:PATH relative to the project dir)
+func GetSecretRecipients(projectDir string) ([]string, error) {
+	data, _, err := readSecretKeyData(projectDir, "WERF_SECRET_RECIPIENTS", ".werf_secret_recipients", "global_secret_recipients")
+	if err != nil {
+		return nil, err
+	}
+
+	var recipients []string
+	for _, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		for _, recipient := range strings.FieldsFunc(line, func(r rune) bool { return r == ',' || unicode.IsSpace(r) }) {
+			recipients = append(recipients, recipient)
+		}
+	}
+
+	return recipients, nil
+}
+
+func GetAgeIdentities(projectDir string) ([]byte, error) {
+	data, notFoundIn, err := readSecretKeyData(projectDir, "WERF_SECRET_AGE_IDENTITY", ".werf_secret_age_identity", "global_secret_age_identity")
+	if err != nil {
+		return nil, err
+	}
+
+	if len(data) == 0 {
+		return nil, fmt.Errorf("age identity not found in: '%s'", strings.Join(notFoundIn, "', '"))
+	}
+
+	return data, nil
+}
+
+func GetPgpPrivateKey(projectDir string) ([]byte, error) {
+	data, notFoundIn, err := readSecretKeyData(projectDir, "WERF_SECRET_PGP_KEY", ".werf_secret_pgp_key", "global_secret_pgp_key")
+	if err != nil {
+		return nil, err
+	}
+
+	if len(data) == 0 {
+		return nil, fmt.Errorf("OpenPGP private key not found in: '%s'", strings.Join(notFoundIn, "', '"))
+	}
+
+	return data, nil
+}
+
+// readSecretKeyData reads data from the environment variable, project file or werf home file (the first found)
+func readSecretKeyData(projectDir, envName, projectFileName, homeFileName string) ([]byte, []string, error) {
+	var notFoundIn []string
+
+	data := []byte(os.Getenv(envName))
+	if len(data) != 0 {
+		return bytes.TrimSpace(data), nil, nil
+	}
+	notFoundIn = append(notFoundIn, "$"+envName)
+
+	projectFilePath, err := filepath.Abs(filepath.Join(projectDir, projectFileName))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	homeFilePath := filepath.Join(werf.GetHomeDir(), homeFileName)
+
+	for _, path := range []string{projectFilePath, homeFilePath} {
+		exist, err := util.FileExists(path)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		if !exist {
+			notFoundIn = append(notFoundIn, path)
+			continue
+		}
+
+		data, err := ioutil.ReadFile(path)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		return bytes.TrimSpace(data), nil, nil
+	}
+
+	return nil, notFoundIn, nil
+}
+
 func NewManager(key []byte) (Manager, error) {
 	ss, err := secret.NewSecret(key)
 	if err != nil {
@@ -0,0 +1,48 @@
+package secret
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/flant/werf/pkg/werf"
+)
+
+func TestGetEnvSecretKey(t *testing.T) {
+	projectDir, err := ioutil.TempDir("", "werf-secret-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(projectDir)
+
+	if err := werf.Init(projectDir, projectDir); err != nil {
+		t.Fatal(err)
+	}
+
+	os.Setenv("WERF_SECRET_KEY", "common")
+	defer os.Unsetenv("WERF_SECRET_KEY")
+
+	if err := ioutil.WriteFile(filepath.Join(projectDir, ".werf_secret_key.production"), []byte("production\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	os.Setenv("WERF_SECRET_KEY_REVIEW_1", "review")
+	defer os.Unsetenv("WERF_SECRET_KEY_REVIEW_1")
+
+	for env, expectedKey := range map[string]string{
+		"":           "common",
+		"staging":    "common",
+		"production": "production",
+		"review-1":   "review",
+	} {
+		key, err := GetEnvSecretKey(projectDir, env)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if string(key) != expectedKey {
+			t.Fatalf("env %q: expected key %q, got %q", env, expectedKey, key)
+		}
+	}
+}
@@ -0,0 +1,142 @@
+package secret
+
+import (
+	"bytes"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+
+	"github.com/flant/werf/pkg/secret"
+)
+
+// Metadata records backend and recipients of the encrypted file to re-encrypt it for the same recipients on edit.
+// Secret values file stores metadata in the top level MetadataYamlKey, which is removed on decryption,
+// secret file stores metadata in the first line: # werf-secret backend=age recipients=age1...,age1... [dataKey=...]
+type Metadata struct {
+	Backend    secret.Backend `yaml:"backend"`
+	Recipients []string       `yaml:"recipients"`
+	// DataKey is the data key of the envelope encryption encrypted by kms
+	DataKey string `yaml:"dataKey,omitempty"`
+}
+
+const (
+	MetadataYamlKey = "_werf_secret"
+
+	fileMetadataPrefix = "# werf-secret "
+)
+
+func NewMetadata(recipients []string) (*Metadata, error) {
+	backend, err := secret.RecipientsBackend(recipients)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Metadata{Backend: backend, Recipients: recipients}, nil
+}
+
+func (m *Metadata) Equal(other *Metadata) bool {
+	return reflect.DeepEqual(m, other)
+}
+
+// ReadMetadata reads metadata of encrypted secret values or secret file data, nil metadata means symmetric encryption
+func ReadMetadata(encodedData []byte) (*Metadata, error) {
+	if metadata, _, err := SplitFileMetadata(encodedData); err != nil || metadata != nil {
+		return metadata, err
+	}
+
+	config := make(yaml.MapSlice, 0)
+	if err := yaml.Unmarshal(encodedData, &config); err != nil {
+		return nil, nil
+	}
+
+	metadata, _, err := SplitYamlMetadata(config)
+	return metadata, err
+}
+
+func SplitFileMetadata(encodedData []byte) (*Metadata, []byte, error) {
+	if !bytes.HasPrefix(encodedData, []byte(fileMetadataPrefix)) {
+		return nil, encodedData, nil
+	}
+
+	parts := bytes.SplitN(encodedData, []byte("\n"), 2)
+	header := strings.TrimPrefix(string(parts[0]), fileMetadataPrefix)
+
+	var data []byte
+	if len(parts) == 2 {
+		data = bytes.TrimSpace(parts[1])
+	}
+
+	metadata := &Metadata{}
+	for _, field := range strings.Fields(header) {
+		kv := strings.SplitN(field, "=", 2)
+		if len(kv) != 2 {
+			return nil, nil, fmt.Errorf("invalid secret file metadata %q", header)
+		}
+
+		switch kv[0] {
+		case "backend":
+			metadata.Backend = secret.Backend(kv[1])
+		case "recipients":
+			metadata.Recipients = strings.Split(kv[1], ",")
+		case "dataKey":
+			metadata.DataKey = kv[1]
+		}
+	}
+
+	return metadata, data, nil
+}
+
+func SplitYamlMetadata(config yaml.MapSlice) (*Metadata, yaml.MapSlice, error) {
+	for ind, item := range config {
+		if item.Key != MetadataYamlKey {
+			continue
+		}
+
+		data, err := yaml.Marshal(item.Value)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		metadata := &Metadata{}
+		if err := yaml.UnmarshalStrict(data, metadata); err != nil {
+			return nil, nil, fmt.Errorf("invalid secret values metadata %s: %s", MetadataYamlKey, err)
+		}
+
+		var rest yaml.MapSlice
+		rest = append(rest, config[:ind]...)
+		rest = append(rest, config[ind+1:]...)
+
+		return metadata, rest, nil
+	}
+
+	return nil, config, nil
+}
+
+func (m *Metadata) fileHeader() []byte {
+	header := fmt.Sprintf("%sbackend=%s recipients=%s", fileMetadataPrefix, m.Backend, strings.Join(m.Recipients, ","))
+	if m.DataKey != "" {
+		header += fmt.Sprintf(" dataKey=%s", m.DataKey)
+	}
+
+	return []byte(header + "\n")
+}
+
+func (m *Metadata) yamlItem() yaml.MapItem {
+	var recipients []interface{}
+	for _, recipient := range m.Recipients {
+		recipients = append(recipients, recipient)
+	}
+
+	value := yaml.MapSlice{
+		{Key: "backend", Value: string(m.Backend)},
+		{Key: "recipients", Value: recipients},
+	}
+
+	if m.DataKey != "" {
+		value = append(value, yaml.MapItem{Key: "dataKey", Value: m.DataKey})
+	}
+
+	return yaml.MapItem{Key: MetadataYamlKey, Value: value}
+}
@@ -0,0 +1,187 @@
+package secret
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/flant/werf/pkg/secret"
+	"github.com/flant/werf/pkg/werf"
+)
+
+func TestManagerWithRecipients(t *testing.T) {
+	projectDir, err := ioutil.TempDir("", "werf-secret-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(projectDir)
+
+	if err := werf.Init(projectDir, projectDir); err != nil {
+		t.Fatal(err)
+	}
+
+	identity, recipient, err := secret.GenerateAgeIdentity()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	os.Setenv("WERF_SECRET_AGE_IDENTITY", identity)
+	defer os.Unsetenv("WERF_SECRET_AGE_IDENTITY")
+
+	m, err := GetManagerWithMetadata(projectDir, "", &Metadata{Backend: secret.AgeBackend, Recipients: []string{recipient}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expectedMetadata, err := m.GetMetadata()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	valuesData := []byte("a:\n  b: password\nc: \"\"\n")
+
+	encodedValuesData, err := m.EncryptYamlData(valuesData)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !strings.HasPrefix(string(encodedValuesData), MetadataYamlKey+":") {
+		t.Fatalf("secret values metadata expected in the beginning of data:\n%s", encodedValuesData)
+	}
+
+	metadata, err := ReadMetadata(encodedValuesData)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !metadata.Equal(expectedMetadata) || metadata.Backend != secret.AgeBackend {
+		t.Fatalf("unexpected metadata %+v", metadata)
+	}
+
+	resultValuesData, err := m.DecryptYamlData(encodedValuesData)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !bytes.Equal(valuesData, resultValuesData) {
+		t.Fatalf("\n[EXPECTED]\n%s\n[GOT]\n%s\n", valuesData, resultValuesData)
+	}
+
+	fileData := []byte("-----BEGIN CERTIFICATE-----\n")
+
+	encodedFileData, err := m.EncryptFileData(fileData)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if metadata, err := ReadMetadata(encodedFileData); err != nil || !metadata.Equal(expectedMetadata) {
+		t.Fatalf("unexpected file metadata %+v: %v", metadata, err)
+	}
+
+	resultFileData, err := m.Decrypt(encodedFileData)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !bytes.Equal(fileData, resultFileData) {
+		t.Fatalf("expected %q, got %q", fileData, resultFileData)
+	}
+
+	// data encrypted with the secret key cannot be decrypted without the key
+	if _, err := m.Decrypt([]byte("1000ab")); err == nil || !strings.Contains(err.Error(), "encryption key not found") {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestManagerWithKmsRecipient(t *testing.T) {
+	projectDir, err := ioutil.TempDir("", "werf-secret-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(projectDir)
+
+	if err := werf.Init(projectDir, projectDir); err != nil {
+		t.Fatal(err)
+	}
+
+	var requests int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+
+		var request map[string]string
+		_ = json.NewDecoder(r.Body).Decode(&request)
+
+		switch r.URL.Path {
+		case "/v1/transit/encrypt/werf":
+			_ = json.NewEncoder(w).Encode(map[string]interface{}{"data": map[string]string{"ciphertext": "vault:v1:" + request["plaintext"]}})
+		case "/v1/transit/decrypt/werf":
+			_ = json.NewEncoder(w).Encode(map[string]interface{}{"data": map[string]string{"plaintext": strings.TrimPrefix(request["ciphertext"], "vault:v1:")}})
+		}
+	}))
+	defer server.Close()
+
+	os.Setenv("VAULT_ADDR", server.URL)
+	os.Setenv("VAULT_TOKEN", "token")
+	defer os.Unsetenv("VAULT_ADDR")
+	defer os.Unsetenv("VAULT_TOKEN")
+
+	m, err := GetManagerWithMetadata(projectDir, "", &Metadata{Recipients: []string{"vault:werf"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	valuesData := []byte("a: password\nb: login\n")
+	encodedValuesData, err := m.EncryptYamlData(valuesData)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	metadata, err := ReadMetadata(encodedValuesData)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if metadata == nil || metadata.Backend != secret.KmsBackend || !strings.HasPrefix(metadata.DataKey, "vault:v1:") {
+		t.Fatalf("unexpected metadata %+v", metadata)
+	}
+
+	// deploy decrypts by kms without any key on disk
+	deployManager, err := GetManager(projectDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	requests = 0
+	resultValuesData, err := deployManager.DecryptYamlData(encodedValuesData)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !bytes.Equal(valuesData, resultValuesData) {
+		t.Fatalf("\n[EXPECTED]\n%s\n[GOT]\n%s\n", valuesData, resultValuesData)
+	}
+
+	if requests != 1 {
+		t.Fatalf("expected data key to be decrypted once, got %d kms requests", requests)
+	}
+
+	// edit keeps the data key of the file
+	editManager, err := GetManagerWithMetadata(projectDir, "", metadata)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	editMetadata, err := editManager.GetMetadata()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !editMetadata.Equal(metadata) {
+		t.Fatalf("expected metadata %+v, got %+v", metadata, editMetadata)
+	}
+}
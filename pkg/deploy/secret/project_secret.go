@@ -0,0 +1,215 @@
+package secret
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+
+	"github.com/flant/werf/pkg/secret"
+)
+
+// projectSecret encrypts data by the backend of recipients (or the encryption key if there are no recipients)
+// and decrypts data by the backend detected from the data itself.
+// Keys are read lazily, so that only keys of the used backends are required.
+type projectSecret struct {
+	projectDir        string
+	env               string
+	recipients        []string
+	encryptionBackend secret.Backend
+	// defaultMetadata allows to reuse the data key of the edited file
+	defaultMetadata *Metadata
+
+	encrypters    map[secret.Backend]secret.Secret
+	decrypters    map[secret.Backend]secret.Secret
+	kmsDecrypters map[string]secret.Secret
+}
+
+func newProjectSecret(projectDir, env string, recipients []string, defaultMetadata *Metadata) (*projectSecret, error) {
+	s := &projectSecret{
+		projectDir:        projectDir,
+		env:               env,
+		recipients:        recipients,
+		encryptionBackend: secret.AesBackend,
+		defaultMetadata:   defaultMetadata,
+		encrypters:        map[secret.Backend]secret.Secret{},
+		decrypters:        map[secret.Backend]secret.Secret{},
+		kmsDecrypters:     map[string]secret.Secret{},
+	}
+
+	if len(recipients) != 0 {
+		backend, err := secret.RecipientsBackend(recipients)
+		if err != nil {
+			return nil, err
+		}
+		s.encryptionBackend = backend
+	}
+
+	return s, nil
+}
+
+func (s *projectSecret) Encrypt(data []byte) ([]byte, error) {
+	ss, err := s.getEncrypter()
+	if err != nil {
+		return nil, err
+	}
+
+	return ss.Encrypt(data)
+}
+
+func (s *projectSecret) getEncrypter() (secret.Secret, error) {
+	ss, ok := s.encrypters[s.encryptionBackend]
+	if !ok {
+		var err error
+		if ss, err = s.newEncrypter(s.encryptionBackend); err != nil {
+			return nil, err
+		}
+		s.encrypters[s.encryptionBackend] = ss
+	}
+
+	return ss, nil
+}
+
+func (s *projectSecret) GetMetadata() (*Metadata, error) {
+	if len(s.recipients) == 0 {
+		return nil, nil
+	}
+
+	metadata, err := NewMetadata(s.recipients)
+	if err != nil {
+		return nil, err
+	}
+
+	if s.encryptionBackend == secret.KmsBackend {
+		ss, err := s.getEncrypter()
+		if err != nil {
+			return nil, err
+		}
+
+		metadata.DataKey = ss.(*secret.KmsSecret).GetEncryptedDataKey()
+	}
+
+	return metadata, nil
+}
+
+func (s *projectSecret) ForMetadata(metadata *Metadata) (secret.Secret, error) {
+	if metadata.Backend != secret.KmsBackend {
+		return s, nil
+	}
+
+	if metadata.DataKey == "" || len(metadata.Recipients) != 1 {
+		return nil, fmt.Errorf("invalid kms secret metadata: data key and one recipient expected")
+	}
+
+	if ss, ok := s.kmsDecrypters[metadata.DataKey]; ok {
+		return ss, nil
+	}
+
+	kms, err := secret.NewKms(metadata.Recipients[0])
+	if err != nil {
+		return nil, err
+	}
+
+	ss, err := secret.NewKmsSecretWithDataKey(kms, metadata.DataKey)
+	if err != nil {
+		return nil, err
+	}
+	s.kmsDecrypters[metadata.DataKey] = ss
+
+	return ss, nil
+}
+
+func (s *projectSecret) Decrypt(encodedData []byte) ([]byte, error) {
+	if len(encodedData) == 0 {
+		return encodedData, nil
+	}
+
+	backend := secret.DetectBackend(encodedData)
+
+	ss, ok := s.decrypters[backend]
+	if !ok {
+		var err error
+		if ss, err = s.newDecrypter(backend); err != nil {
+			return nil, err
+		}
+		s.decrypters[backend] = ss
+	}
+
+	return ss.Decrypt(encodedData)
+}
+
+func (s *projectSecret) newEncrypter(backend secret.Backend) (secret.Secret, error) {
+	switch backend {
+	case secret.AgeBackend:
+		return secret.NewAgeSecret(s.recipients, nil)
+	case secret.PgpBackend:
+		var publicKeys [][]byte
+		for _, recipient := range s.recipients {
+			path := strings.TrimPrefix(recipient, string(secret.PgpBackend)+":")
+			if !filepath.IsAbs(path) {
+				path = filepath.Join(s.projectDir, path)
+			}
+
+			data, err := ioutil.ReadFile(path)
+			if err != nil {
+				return nil, fmt.Errorf("unable to read OpenPGP public key of recipient %s: %s", recipient, err)
+			}
+			publicKeys = append(publicKeys, data)
+		}
+
+		return secret.NewPgpSecret(publicKeys, nil, nil)
+	case secret.KmsBackend:
+		// reuse the data key of the edited file to keep unchanged values
+		if m := s.defaultMetadata; m != nil && m.DataKey != "" && reflect.DeepEqual(m.Recipients, s.recipients) {
+			return s.ForMetadata(m)
+		}
+
+		kms, err := secret.NewKms(s.recipients[0])
+		if err != nil {
+			return nil, err
+		}
+
+		return secret.NewKmsSecret(kms)
+	default:
+		return s.newAesSecret()
+	}
+}
+
+func (s *projectSecret) newDecrypter(backend secret.Backend) (secret.Secret, error) {
+	switch backend {
+	case secret.AgeBackend:
+		identities, err := GetAgeIdentities(s.projectDir)
+		if err != nil {
+			return nil, err
+		}
+
+		return secret.NewAgeSecret(nil, identities)
+	case secret.PgpBackend:
+		privateKey, err := GetPgpPrivateKey(s.projectDir)
+		if err != nil {
+			return nil, err
+		}
+
+		return secret.NewPgpSecret(nil, [][]byte{privateKey}, []byte(os.Getenv("WERF_SECRET_PGP_PASSPHRASE")))
+	case secret.KmsBackend:
+		return nil, fmt.Errorf("data encrypted by kms can only be decrypted with the data key recorded in the secret file metadata")
+	default:
+		return s.newAesSecret()
+	}
+}
+
+func (s *projectSecret) newAesSecret() (secret.Secret, error) {
+	key, err := GetEnvSecretKey(s.projectDir, s.env)
+	if err != nil {
+		return nil, err
+	}
+
+	ss, err := secret.NewSecret(key)
+	if err != nil {
+		return nil, fmt.Errorf("check encryption key: %s", err)
+	}
+
+	return ss, nil
+}
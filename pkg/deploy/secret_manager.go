@@ -28,12 +28,27 @@ func GetSafeSecretManager(projectDir string, secretValues []string, ignoreSecret
 			return secret.NewSafeManager()
 		}
 
-		key, err := secret.GetSecretKey(projectDir)
-		if err != nil {
-			return nil, err
+		// backend is detected by encrypted data, keys of the used backends are read on decryption
+		return secret.GetManager(projectDir)
+	} else {
+		return secret.NewSafeManager()
+	}
+}
+
+// GetSafeEnvSecretManager returns manager for the environment secret values and secret files, which could be encrypted by the environment key
+func GetSafeEnvSecretManager(projectDir string, env string, ignoreSecretKey bool) (secret.Manager, error) {
+	isSecretsExists := false
+	if env != "" {
+		if _, err := os.Stat(filepath.Join(projectDir, werf_chart.ProjectHelmChartDirName, werf_chart.SecretDirName, env)); !os.IsNotExist(err) {
+			isSecretsExists = true
 		}
+		if _, err := os.Stat(filepath.Join(projectDir, werf_chart.ProjectHelmChartDirName, werf_chart.EnvSecretValuesFileName(env))); !os.IsNotExist(err) {
+			isSecretsExists = true
+		}
+	}
 
-		return secret.NewManager(key)
+	if isSecretsExists && !ignoreSecretKey {
+		return secret.GetEnvManager(projectDir, env)
 	} else {
 		return secret.NewSafeManager()
 	}
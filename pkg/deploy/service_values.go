@@ -47,7 +47,7 @@ func GetServiceValues(projectName string, imagesRepoManager images_manager.Image
 	res["global"] = globalInfo
 
 	switch tagStrategy {
-	case tag_strategy.GitTag:
+	case tag_strategy.GitTag, tag_strategy.Semver:
 		ciInfo["tag"] = commonTag
 		ciInfo["ref"] = commonTag
 		ciInfo["is_tag"] = true
@@ -77,7 +77,12 @@ func GetServiceValues(projectName string, imagesRepoManager images_manager.Image
 			imagesInfo[image.GetName()] = imageData
 		}
 
-		imageData["docker_image"] = image.GetImageName()
+		imageName, err := image.GetImageName()
+		if err != nil {
+			return nil, err
+		}
+
+		imageData["docker_image"] = imageName
 		imageData["docker_tag"] = image.GetImageTag()
 
 		if tagStrategy == tag_strategy.GitBranch || tagStrategy == tag_strategy.Custom {
@@ -87,7 +92,7 @@ func GetServiceValues(projectName string, imagesRepoManager images_manager.Image
 				} else {
 					imageData[key] = value
 				}
-				logboek.Debug.LogF("ServiceValues: %s.%s=%s", image.GetImageName(), key, value)
+				logboek.Debug.LogF("ServiceValues: %s.%s=%s", imageName, key, value)
 			}
 
 			imageID, err := image.GetImageId()
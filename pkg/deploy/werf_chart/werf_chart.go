@@ -13,6 +13,7 @@ import (
 	"github.com/flant/logboek"
 	"github.com/flant/werf/pkg/deploy/helm"
 	"github.com/flant/werf/pkg/deploy/secret"
+	"github.com/flant/werf/pkg/logging"
 	"github.com/flant/werf/pkg/util"
 	"github.com/flant/werf/pkg/util/secretvalues"
 	"github.com/flant/werf/pkg/werf"
@@ -25,6 +26,30 @@ const (
 	SecretDirName               = "secret"
 )
 
+// EnvSecretValuesFileName returns name of the environment secret values file, which is merged over the default secret values
+func EnvSecretValuesFileName(env string) string {
+	return fmt.Sprintf("secret-values.%s.yaml", env)
+}
+
+// GetSecretEnvironments returns environments, which have secret values file in the chart dir.
+// Subdirectories of the secret dir with the same names are the environment secret files overlays.
+func GetSecretEnvironments(chartDir string) ([]string, error) {
+	paths, err := filepath.Glob(filepath.Join(chartDir, EnvSecretValuesFileName("*")))
+	if err != nil {
+		return nil, err
+	}
+
+	var envs []string
+	for _, path := range paths {
+		env := strings.TrimSuffix(strings.TrimPrefix(filepath.Base(path), "secret-values."), ".yaml")
+		if env != "" {
+			envs = append(envs, env)
+		}
+	}
+
+	return envs, nil
+}
+
 type WerfChart struct {
 	Name             string
 	ChartDir         string
@@ -65,7 +90,7 @@ func (chart *WerfChart) SetSecretValuesFile(path string, m secret.Manager) error
 		return fmt.Errorf("cannot unmarshal secret values file %s: %s", path, err)
 	}
 	chart.SecretValues = append(chart.SecretValues, values)
-	chart.SecretValuesToMask = secretvalues.ExtractSecretValuesFromMap(values)
+	chart.SecretValuesToMask = append(chart.SecretValuesToMask, secretvalues.ExtractSecretValuesFromMap(values)...)
 
 	return nil
 }
@@ -128,7 +153,7 @@ func (chart *WerfChart) LogExtraAnnotations() {
 	res, _ := yaml.Marshal(chart.ExtraAnnotations)
 
 	annotations := strings.TrimRight(string(res), "\n")
-	logboek.LogBlock(fmt.Sprintf("Extra annotations"), logboek.LogBlockOptions{}, func() error {
+	logging.LogBlock(fmt.Sprintf("Extra annotations"), logboek.LogBlockOptions{}, func() error {
 		logboek.LogLn(annotations)
 		return nil
 	})
@@ -142,7 +167,7 @@ func (chart *WerfChart) LogExtraLabels() {
 	res, _ := yaml.Marshal(chart.ExtraLabels)
 
 	labels := strings.TrimRight(string(res), "\n")
-	logboek.LogBlock(fmt.Sprintf("Extra labels"), logboek.LogBlockOptions{}, func() error {
+	logging.LogBlock(fmt.Sprintf("Extra labels"), logboek.LogBlockOptions{}, func() error {
 		logboek.LogLn(labels)
 		return nil
 	})
@@ -152,7 +177,9 @@ type ChartConfig struct {
 	Name string `json:"name"`
 }
 
-func InitWerfChart(projectName, chartDir string, env string, m secret.Manager) (*WerfChart, error) {
+// InitWerfChart loads default secret values and secret files decrypted by m,
+// then environment secret values and secret files overlay decrypted by envM
+func InitWerfChart(projectName, chartDir string, env string, m, envM secret.Manager) (*WerfChart, error) {
 	werfChart := &WerfChart{}
 	werfChart.Name = projectName
 	werfChart.ChartDir = chartDir
@@ -182,40 +209,75 @@ func InitWerfChart(projectName, chartDir string, env string, m secret.Manager) (
 		}
 	}
 
+	secretEnvs, err := GetSecretEnvironments(chartDir)
+	if err != nil {
+		return nil, err
+	}
+
+	if env != "" {
+		secretEnvs = append(secretEnvs, env)
+	}
+
 	secretDir := filepath.Join(chartDir, SecretDirName)
-	if _, err := os.Stat(secretDir); !os.IsNotExist(err) {
-		if err := filepath.Walk(secretDir, func(path string, info os.FileInfo, accessErr error) error {
-			if accessErr != nil {
-				return fmt.Errorf("error accessing file %s: %s", path, accessErr)
-			}
+	if err := werfChart.setSecretFiles(secretDir, secretEnvs, m); err != nil {
+		return nil, err
+	}
 
-			if info.Mode().IsDir() {
-				return nil
+	if env != "" {
+		envSecretValues := filepath.Join(chartDir, EnvSecretValuesFileName(env))
+		if _, err := os.Stat(envSecretValues); !os.IsNotExist(err) {
+			if err := werfChart.SetSecretValuesFile(envSecretValues, envM); err != nil {
+				return nil, err
 			}
+		}
 
-			data, err := ioutil.ReadFile(path)
-			if err != nil {
-				return fmt.Errorf("error reading file %s: %s", path, err)
-			}
+		if err := werfChart.setSecretFiles(filepath.Join(secretDir, env), nil, envM); err != nil {
+			return nil, err
+		}
+	}
 
-			decodedData, err := m.Decrypt([]byte(strings.TrimRightFunc(string(data), unicode.IsSpace)))
-			if err != nil {
-				return fmt.Errorf("error decoding %s: %s", path, err)
-			}
+	return werfChart, nil
+}
 
-			relativePath, err := filepath.Rel(secretDir, path)
-			if err != nil {
-				panic(err)
-			}
+// setSecretFiles decrypts files of the secret dir except the environments overlays, files override already set files with the same relative path
+func (chart *WerfChart) setSecretFiles(secretDir string, skipEnvs []string, m secret.Manager) error {
+	if _, err := os.Stat(secretDir); os.IsNotExist(err) {
+		return nil
+	}
+
+	return filepath.Walk(secretDir, func(path string, info os.FileInfo, accessErr error) error {
+		if accessErr != nil {
+			return fmt.Errorf("error accessing file %s: %s", path, accessErr)
+		}
+
+		relativePath, err := filepath.Rel(secretDir, path)
+		if err != nil {
+			panic(err)
+		}
 
-			werfChart.DecodedSecretFilesData[filepath.ToSlash(relativePath)] = string(decodedData)
-			werfChart.SecretValuesToMask = append(werfChart.SecretValuesToMask, string(decodedData))
+		if info.Mode().IsDir() {
+			for _, env := range skipEnvs {
+				if relativePath == env {
+					return filepath.SkipDir
+				}
+			}
 
 			return nil
-		}); err != nil {
-			return nil, err
 		}
-	}
 
-	return werfChart, nil
+		data, err := ioutil.ReadFile(path)
+		if err != nil {
+			return fmt.Errorf("error reading file %s: %s", path, err)
+		}
+
+		decodedData, err := m.Decrypt([]byte(strings.TrimRightFunc(string(data), unicode.IsSpace)))
+		if err != nil {
+			return fmt.Errorf("error decoding %s: %s", path, err)
+		}
+
+		chart.DecodedSecretFilesData[filepath.ToSlash(relativePath)] = string(decodedData)
+		chart.SecretValuesToMask = append(chart.SecretValuesToMask, string(decodedData))
+
+		return nil
+	})
 }
@@ -0,0 +1,86 @@
+package werf_chart
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/flant/werf/pkg/deploy/secret"
+)
+
+func TestInitWerfChart_SecretFiles(t *testing.T) {
+	chartDir, err := ioutil.TempDir("", "werf-chart")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(chartDir)
+
+	newManager := func() secret.Manager {
+		key, err := secret.GenerateSecretKey()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		m, err := secret.NewManager(key)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		return m
+	}
+	m, envM := newManager(), newManager()
+
+	writeSecretFile := func(m secret.Manager, relPath, data string) {
+		encryptedData, err := m.Encrypt([]byte(data))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		path := filepath.Join(chartDir, relPath)
+		if err := os.MkdirAll(filepath.Dir(path), os.ModePerm); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := ioutil.WriteFile(path, append(encryptedData, '\n'), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	writeSecretFile(m, "secret/tls.key", "default tls key")
+	writeSecretFile(m, "secret/backend-saml/tls.key", "saml tls key")
+	writeSecretFile(m, "secret/production-like/config", "default config")
+	writeSecretFile(envM, "secret/production/tls.key", "production tls key")
+	writeSecretFile(envM, "secret/staging/tls.key", "staging tls key")
+
+	for _, env := range []string{"production", "staging"} {
+		if err := ioutil.WriteFile(filepath.Join(chartDir, EnvSecretValuesFileName(env)), nil, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	chart, err := InitWerfChart("project", chartDir, "", m, envM)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := map[string]string{
+		"tls.key":                "default tls key",
+		"backend-saml/tls.key":   "saml tls key",
+		"production-like/config": "default config",
+	}
+	if !reflect.DeepEqual(expected, chart.DecodedSecretFilesData) {
+		t.Errorf("\n[EXPECTED]: %v\n[GOT]: %v", expected, chart.DecodedSecretFilesData)
+	}
+
+	chart, err = InitWerfChart("project", chartDir, "production", m, envM)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected["tls.key"] = "production tls key"
+	if !reflect.DeepEqual(expected, chart.DecodedSecretFilesData) {
+		t.Errorf("\n[EXPECTED]: %v\n[GOT]: %v", expected, chart.DecodedSecretFilesData)
+	}
+}
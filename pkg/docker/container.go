@@ -38,6 +38,11 @@ func ContainerCommit(ref string, commitOptions types.ContainerCommitOptions) (st
 	return response.ID, nil
 }
 
+func ContainerKill(ref string, signal string) error {
+	ctx := context.Background()
+	return apiClient.ContainerKill(ctx, ref, signal)
+}
+
 func ContainerRemove(ref string, options types.ContainerRemoveOptions) error {
 	ctx := context.Background()
 	err := apiClient.ContainerRemove(ctx, ref, options)
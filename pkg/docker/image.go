@@ -1,6 +1,10 @@
 package docker
 
 import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"sort"
 	"strings"
 	"time"
 
@@ -9,16 +13,29 @@ import (
 	"github.com/docker/cli/cli/command/image"
 	"github.com/docker/docker/api/types"
 	"github.com/docker/docker/client"
+	"github.com/docker/docker/pkg/jsonmessage"
 	"github.com/flant/logboek"
 	"golang.org/x/net/context"
 )
 
-func CreateImage(ref string) error {
+func CreateImage(ref string, labels map[string]string) error {
+	var changes []string
+	for key, value := range labels {
+		changes = append(changes, fmt.Sprintf("LABEL %s=%s", key, quoteDockerfileWord(value)))
+	}
+	sort.Strings(changes)
+
 	ctx := context.Background()
-	_, err := apiClient.ImageImport(ctx, types.ImageImportSource{SourceName: "-"}, ref, types.ImageImportOptions{})
+	_, err := apiClient.ImageImport(ctx, types.ImageImportSource{SourceName: "-"}, ref, types.ImageImportOptions{Changes: changes})
 	return err
 }
 
+// quoteDockerfileWord quotes the value of the dockerfile instruction, so that the value is not splitted or expanded
+func quoteDockerfileWord(value string) string {
+	replacer := strings.NewReplacer(`\`, `\\`, `"`, `\"`, "$", `\$`, "\n", " ")
+	return fmt.Sprintf(`"%s"`, replacer.Replace(value))
+}
+
 func Images(options types.ImageListOptions) ([]types.ImageSummary, error) {
 	ctx := context.Background()
 	images, err := apiClient.ImageList(ctx, options)
@@ -49,6 +66,23 @@ func ImageInspect(ref string) (*types.ImageInspect, error) {
 	return &inspect, nil
 }
 
+func ImageSave(ref string) (io.ReadCloser, error) {
+	ctx := context.Background()
+	return apiClient.ImageSave(ctx, []string{ref})
+}
+
+// ImageLoad loads images from the docker save archive
+func ImageLoad(r io.Reader) error {
+	ctx := context.Background()
+	resp, err := apiClient.ImageLoad(ctx, r, true)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	return jsonmessage.DisplayJSONMessagesStream(resp.Body, ioutil.Discard, 0, false, nil)
+}
+
 func doCliPull(c *command.DockerCli, args ...string) error {
 	return prepareCliCmd(image.NewPullCommand(c), args...).Execute()
 }
@@ -0,0 +1,56 @@
+package docker_registry
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/google/go-containerregistry/pkg/authn"
+	"github.com/google/go-containerregistry/pkg/v1/remote/transport"
+)
+
+// acr deletes the tag with ACR API, the manifest deletion by digest would remove all tags of the image
+type acr struct {
+	*defaultImplementation
+}
+
+func newAcr() *acr {
+	return &acr{defaultImplementation: newDefaultImplementation()}
+}
+
+func (r *acr) DeleteRepoImage(repoImage RepoImage) error {
+	repo, err := parseRepoImageRepository(repoImage)
+	if err != nil {
+		return err
+	}
+
+	auth, err := authn.DefaultKeychain.Resolve(repo.Registry)
+	if err != nil {
+		return fmt.Errorf("getting creds for %q: %v", repo, err)
+	}
+
+	tr, err := transport.New(repo.Registry, auth, getHttpTransport(), []string{repo.Scope("delete")})
+	if err != nil {
+		return err
+	}
+
+	u := fmt.Sprintf("%s://%s/acr/v1/%s/_tags/%s", repo.Registry.Scheme(), repo.RegistryStr(), repo.RepositoryStr(), repoImage.Tag)
+
+	req, err := http.NewRequest(http.MethodDelete, u, nil)
+	if err != nil {
+		return err
+	}
+
+	if _, err := doApiRequest(&http.Client{Transport: tr}, req, http.StatusOK, http.StatusAccepted); err != nil {
+		return fmt.Errorf("deleting image %q: %v", repoImageTagReference(repoImage), err)
+	}
+
+	return nil
+}
+
+func (r *acr) DeletesOnlyTag() bool {
+	return true
+}
+
+func (r *acr) String() string {
+	return AcrImplementationName
+}
@@ -0,0 +1,64 @@
+package docker_registry
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+)
+
+const ConfigCacheVersion = "1"
+
+// imageConfigFile returns the config of the image, the config blob is fetched from the registry only if it is not cached by digest.
+// The manifests are not cached, because tags are mutable
+func imageConfigFile(img v1.Image) (*v1.ConfigFile, error) {
+	if ConfigCacheDir == "" {
+		return img.ConfigFile()
+	}
+
+	manifest, err := img.Manifest()
+	if err != nil {
+		return nil, err
+	}
+
+	digest := manifest.Config.Digest
+	cachePath := filepath.Join(ConfigCacheDir, digest.Algorithm, digest.Hex)
+
+	if data, err := ioutil.ReadFile(cachePath); err == nil && digest.Algorithm == "sha256" && sha256Hex(data) == digest.Hex {
+		return v1.ParseConfigFile(bytes.NewReader(data))
+	}
+
+	rawConfig, err := img.RawConfigFile()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := writeCacheFile(cachePath, rawConfig); err != nil {
+		return nil, err
+	}
+
+	return v1.ParseConfigFile(bytes.NewReader(rawConfig))
+}
+
+func writeCacheFile(cachePath string, data []byte) error {
+	if err := os.MkdirAll(filepath.Dir(cachePath), 0755); err != nil {
+		return err
+	}
+
+	// The file is renamed to be complete for the concurrent readers
+	tmpFile, err := ioutil.TempFile(filepath.Dir(cachePath), "tmp-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmpFile.Name())
+
+	_, err = tmpFile.Write(data)
+	tmpFile.Close()
+	if err != nil {
+		return err
+	}
+
+	return os.Rename(tmpFile.Name(), cachePath)
+}
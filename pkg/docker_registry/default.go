@@ -0,0 +1,114 @@
+package docker_registry
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+
+	"github.com/google/go-containerregistry/pkg/authn"
+	"github.com/google/go-containerregistry/pkg/name"
+
+	"github.com/flant/werf/pkg/tracing"
+)
+
+// defaultImplementation deletes the image manifest by digest with the plain v2 DELETE
+type defaultImplementation struct{}
+
+func newDefaultImplementation() *defaultImplementation {
+	return &defaultImplementation{}
+}
+
+func (r *defaultImplementation) Tags(reference string) ([]string, error) {
+	return Tags(reference)
+}
+
+func (r *defaultImplementation) ImagesByWerfImageLabel(reference, labelValue string) ([]RepoImage, error) {
+	return ImagesByWerfImageLabel(reference, labelValue)
+}
+
+func (r *defaultImplementation) DeleteRepoImage(repoImage RepoImage) error {
+	reference, err := repoImageDigestReference(repoImage)
+	if err != nil {
+		return err
+	}
+
+	return ImageDelete(reference)
+}
+
+func (r *defaultImplementation) DeletesOnlyTag() bool {
+	return false
+}
+
+func (r *defaultImplementation) String() string {
+	return DefaultImplementationName
+}
+
+func repoImageTagReference(repoImage RepoImage) string {
+	return strings.Join([]string{repoImage.Repository, repoImage.Tag}, ":")
+}
+
+func repoImageDigestReference(repoImage RepoImage) (string, error) {
+	digest, err := repoImageDigest(repoImage)
+	if err != nil {
+		return "", err
+	}
+
+	return strings.Join([]string{repoImage.Repository, digest}, "@"), nil
+}
+
+func repoImageDigest(repoImage RepoImage) (string, error) {
+	digest, err := repoImage.Digest()
+	if err != nil {
+		return "", fmt.Errorf("getting image %q digest: %v", repoImageTagReference(repoImage), err)
+	}
+
+	return digest.String(), nil
+}
+
+func parseRepoImageRepository(repoImage RepoImage) (name.Repository, error) {
+	repo, err := name.NewRepository(repoImage.Repository, newRepositoryOptions()...)
+	if err != nil {
+		return name.Repository{}, fmt.Errorf("parsing repo %q: %v", repoImage.Repository, err)
+	}
+
+	return repo, nil
+}
+
+func registryAuthConfig(registry name.Registry) (*authn.AuthConfig, error) {
+	auth, err := authn.DefaultKeychain.Resolve(registry)
+	if err != nil {
+		return nil, fmt.Errorf("getting creds for %q: %v", registry, err)
+	}
+
+	authConfig, err := auth.Authorization()
+	if err != nil {
+		return nil, fmt.Errorf("getting creds for %q: %v", registry, err)
+	}
+
+	return authConfig, nil
+}
+
+// doApiRequest performs the registry API request, the response body is returned for the successful status codes
+func doApiRequest(client *http.Client, req *http.Request, acceptedStatusCodes ...int) ([]byte, error) {
+	span := tracing.StartSpan("docker_registry api request", map[string]string{"werf.registry.method": req.Method, "werf.registry.url": req.URL.String()})
+	resp, err := client.Do(req)
+	span.End(err)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, code := range acceptedStatusCodes {
+		if resp.StatusCode == code {
+			return body, nil
+		}
+	}
+
+	return nil, fmt.Errorf("unexpected status code during %s %s: %v; %v", req.Method, req.URL, resp.Status, string(body))
+}
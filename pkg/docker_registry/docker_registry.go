@@ -0,0 +1,130 @@
+package docker_registry
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/google/go-containerregistry/pkg/name"
+)
+
+const (
+	DefaultImplementationName   = "default"
+	AcrImplementationName       = "acr"
+	DockerHubImplementationName = "dockerhub"
+	EcrImplementationName       = "ecr"
+	GcrImplementationName       = "gcr"
+	GitLabImplementationName    = "gitlab"
+	HarborImplementationName    = "harbor"
+	QuayImplementationName      = "quay"
+)
+
+// DockerRegistry hides the differences of the registries API: most registries reject or implement differently the plain v2 DELETE.
+// DeleteRepoImage of some implementations deletes only the tag (see DeletesOnlyTag), the others delete the manifest by digest
+// with all its tags, so the caller should not delete the manifests, which are also tagged by the tags to keep
+type DockerRegistry interface {
+	Tags(reference string) ([]string, error)
+	ImagesByWerfImageLabel(reference, labelValue string) ([]RepoImage, error)
+	DeleteRepoImage(repoImage RepoImage) error
+	DeletesOnlyTag() bool
+	String() string
+}
+
+var (
+	implementationHostPatterns = []struct {
+		implementationName string
+		patterns           []string
+	}{
+		{AcrImplementationName, []string{"^.*\\.azurecr\\.io$"}},
+		{DockerHubImplementationName, []string{"^index\\.docker\\.io$", "^registry-1\\.docker\\.io$", "^docker\\.io$"}},
+		{EcrImplementationName, []string{"^.*\\.dkr\\.ecr\\..*\\.amazonaws\\.com(\\.cn)?$"}},
+		{GcrImplementationName, GCRUrlPatterns},
+		{GitLabImplementationName, []string{"^registry\\.gitlab\\.com$"}},
+		{QuayImplementationName, []string{"^quay\\.io$"}},
+	}
+)
+
+func ImplementationNames() []string {
+	return []string{
+		DefaultImplementationName,
+		AcrImplementationName,
+		DockerHubImplementationName,
+		EcrImplementationName,
+		GcrImplementationName,
+		GitLabImplementationName,
+		HarborImplementationName,
+		QuayImplementationName,
+	}
+}
+
+func ValidateImplementationName(implementationName string) error {
+	if implementationName == "" {
+		return nil
+	}
+
+	for _, n := range ImplementationNames() {
+		if n == implementationName {
+			return nil
+		}
+	}
+
+	return fmt.Errorf("unknown repo implementation %q: expected one of %s", implementationName, strings.Join(ImplementationNames(), ", "))
+}
+
+// DetectImplementation selects the implementation by the registry hostname, default implementation is used for unknown registries
+func DetectImplementation(repository string) (string, error) {
+	repo, err := name.NewRepository(repository, newRepositoryOptions()...)
+	if err != nil {
+		return "", fmt.Errorf("parsing repo %q: %v", repository, err)
+	}
+
+	for _, hostPatterns := range implementationHostPatterns {
+		for _, pattern := range hostPatterns.patterns {
+			matched, err := regexp.MatchString(pattern, repo.RegistryStr())
+			if err != nil {
+				return "", err
+			}
+
+			if matched {
+				return hostPatterns.implementationName, nil
+			}
+		}
+	}
+
+	return DefaultImplementationName, nil
+}
+
+// NewDockerRegistry creates the implementation by name, RepoImplementation or the registry hostname are used when the name is not specified
+func NewDockerRegistry(repository, implementationName string) (DockerRegistry, error) {
+	if implementationName == "" {
+		implementationName = RepoImplementation
+	}
+
+	if implementationName == "" {
+		var err error
+		if implementationName, err = DetectImplementation(repository); err != nil {
+			return nil, err
+		}
+	}
+
+	switch implementationName {
+	case DefaultImplementationName:
+		return newDefaultImplementation(), nil
+	case AcrImplementationName:
+		return newAcr(), nil
+	case DockerHubImplementationName:
+		return newDockerHub(), nil
+	case EcrImplementationName:
+		return newEcr(), nil
+	case GcrImplementationName:
+		return newGcr(), nil
+	case GitLabImplementationName:
+		return newGitLab(), nil
+	case HarborImplementationName:
+		return newHarbor(), nil
+	case QuayImplementationName:
+		return newQuay(), nil
+	default:
+		return nil, ValidateImplementationName(implementationName)
+	}
+}
@@ -0,0 +1,380 @@
+package docker_registry
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-containerregistry/pkg/authn"
+	"github.com/google/go-containerregistry/pkg/v1/random"
+)
+
+func setupDockerConfig(t *testing.T, registry, username, password string) func() {
+	dir, err := ioutil.TempDir("", "docker-config")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	auth := base64.StdEncoding.EncodeToString([]byte(fmt.Sprintf("%s:%s", username, password)))
+	config := fmt.Sprintf(`{"auths": {%q: {"auth": %q}}}`, registry, auth)
+	if err := ioutil.WriteFile(filepath.Join(dir, "config.json"), []byte(config), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	oldDockerConfig := os.Getenv("DOCKER_CONFIG")
+	os.Setenv("DOCKER_CONFIG", dir)
+
+	return func() {
+		os.Setenv("DOCKER_CONFIG", oldDockerConfig)
+		os.RemoveAll(dir)
+	}
+}
+
+func newTestRepoImage(t *testing.T, repository, tag string) (RepoImage, string) {
+	img, err := random.Image(1024, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	digest, err := img.Digest()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return RepoImage{Repository: repository, Tag: tag, Image: img}, digest.String()
+}
+
+type recordedRequest struct {
+	method        string
+	path          string
+	authorization string
+	header        http.Header
+	body          []byte
+}
+
+type testApi struct {
+	// responses by the method and path, e.g. "DELETE /v2/app/manifests/v1": "202 body"
+	responses map[string]string
+	requests  []recordedRequest
+}
+
+// newTestServer answers the v2 ping and records other requests
+func newTestServer(responses map[string]string) (*httptest.Server, *testApi) {
+	api := &testApi{responses: responses}
+	if api.responses == nil {
+		api.responses = map[string]string{}
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/v2/" {
+			w.WriteHeader(http.StatusOK)
+			return
+		}
+
+		body, _ := ioutil.ReadAll(r.Body)
+		api.requests = append(api.requests, recordedRequest{
+			method:        r.Method,
+			path:          r.URL.EscapedPath(),
+			authorization: r.Header.Get("Authorization"),
+			header:        r.Header,
+			body:          body,
+		})
+
+		response, ok := api.responses[fmt.Sprintf("%s %s", r.Method, r.URL.EscapedPath())]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+
+		parts := strings.SplitN(response, " ", 2)
+		code, _ := strconv.Atoi(parts[0])
+		w.WriteHeader(code)
+		if len(parts) == 2 {
+			w.Write([]byte(parts[1]))
+		}
+	}))
+
+	return server, api
+}
+
+func basicAuthorization(username, password string) string {
+	return "Basic " + base64.StdEncoding.EncodeToString([]byte(fmt.Sprintf("%s:%s", username, password)))
+}
+
+func TestDetectImplementation(t *testing.T) {
+	for _, tt := range []struct {
+		repository         string
+		implementationName string
+	}{
+		{"registry.example.com/project/app", DefaultImplementationName},
+		{"localhost:5000/app", DefaultImplementationName},
+		{"myregistry.azurecr.io/app", AcrImplementationName},
+		{"project/app", DockerHubImplementationName},
+		{"index.docker.io/project/app", DockerHubImplementationName},
+		{"123456789012.dkr.ecr.eu-west-1.amazonaws.com/app", EcrImplementationName},
+		{"gcr.io/project/app", GcrImplementationName},
+		{"eu.gcr.io/project/app", GcrImplementationName},
+		{"registry.gitlab.com/group/project", GitLabImplementationName},
+		{"quay.io/project/app", QuayImplementationName},
+	} {
+		t.Run(tt.repository, func(t *testing.T) {
+			implementationName, err := DetectImplementation(tt.repository)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if implementationName != tt.implementationName {
+				t.Errorf("DetectImplementation(%q) = %q, want %q", tt.repository, implementationName, tt.implementationName)
+			}
+		})
+	}
+}
+
+func TestNewDockerRegistry(t *testing.T) {
+	dockerRegistry, err := NewDockerRegistry("registry.example.com/project/app", HarborImplementationName)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if dockerRegistry.String() != HarborImplementationName {
+		t.Errorf("NewDockerRegistry() = %s, want %s", dockerRegistry, HarborImplementationName)
+	}
+
+	for _, implementationName := range ImplementationNames() {
+		dockerRegistry, err := NewDockerRegistry("registry.example.com/project/app", implementationName)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		// these registries delete only the tag, the others delete the manifest with all its tags
+		expectedDeletesOnlyTag := map[string]bool{AcrImplementationName: true, DockerHubImplementationName: true, GcrImplementationName: true, QuayImplementationName: true}[implementationName]
+		if dockerRegistry.DeletesOnlyTag() != expectedDeletesOnlyTag {
+			t.Errorf("%s DeletesOnlyTag() = %v, want %v", implementationName, dockerRegistry.DeletesOnlyTag(), expectedDeletesOnlyTag)
+		}
+	}
+
+	if _, err := NewDockerRegistry("registry.example.com/project/app", "nexus"); err == nil || !strings.Contains(err.Error(), "unknown repo implementation") {
+		t.Errorf("NewDockerRegistry() with unknown implementation error = %v, want unknown repo implementation", err)
+	}
+}
+
+func checkRequests(t *testing.T, requests []recordedRequest, expected ...string) {
+	var actual []string
+	for _, r := range requests {
+		actual = append(actual, fmt.Sprintf("%s %s", r.method, r.path))
+	}
+
+	if strings.Join(actual, "\n") != strings.Join(expected, "\n") {
+		t.Errorf("requests = %v, want %v", actual, expected)
+	}
+}
+
+func TestDefaultImplementation_DeleteRepoImage(t *testing.T) {
+	server, api := newTestServer(nil)
+	defer server.Close()
+	host := strings.TrimPrefix(server.URL, "http://")
+
+	repoImage, digest := newTestRepoImage(t, host+"/project/app", "v1")
+	deletePath := fmt.Sprintf("DELETE /v2/project/app/manifests/%s", digest)
+
+	api.responses[deletePath] = "202"
+	if err := newDefaultImplementation().DeleteRepoImage(repoImage); err != nil {
+		t.Fatal(err)
+	}
+
+	checkRequests(t, api.requests, deletePath)
+}
+
+func TestGcr_DeleteRepoImage(t *testing.T) {
+	server, api := newTestServer(map[string]string{"DELETE /v2/project/app/manifests/v1": "202"})
+	defer server.Close()
+	host := strings.TrimPrefix(server.URL, "http://")
+
+	repoImage, _ := newTestRepoImage(t, host+"/project/app", "v1")
+	if err := newGcr().DeleteRepoImage(repoImage); err != nil {
+		t.Fatal(err)
+	}
+
+	checkRequests(t, api.requests, "DELETE /v2/project/app/manifests/v1")
+}
+
+func TestGitLab_DeleteRepoImage(t *testing.T) {
+	server, api := newTestServer(nil)
+	defer server.Close()
+	host := strings.TrimPrefix(server.URL, "http://")
+
+	repoImage, digest := newTestRepoImage(t, host+"/group/project", "v1")
+	deletePath := fmt.Sprintf("DELETE /v2/group/project/manifests/%s", digest)
+
+	api.responses[deletePath] = "200"
+	if err := newGitLab().DeleteRepoImage(repoImage); err != nil {
+		t.Fatal(err)
+	}
+
+	checkRequests(t, api.requests, deletePath)
+}
+
+func TestHarbor_DeleteRepoImage(t *testing.T) {
+	server, api := newTestServer(nil)
+	defer server.Close()
+	host := strings.TrimPrefix(server.URL, "http://")
+	defer setupDockerConfig(t, host, "robot", "secret")()
+
+	repoImage, digest := newTestRepoImage(t, host+"/project/group/app", "v1")
+	deletePath := fmt.Sprintf("DELETE /api/v2.0/projects/project/repositories/group%%252Fapp/artifacts/%s", digest)
+
+	api.responses[deletePath] = "200"
+	if err := newHarbor().DeleteRepoImage(repoImage); err != nil {
+		t.Fatal(err)
+	}
+
+	checkRequests(t, api.requests, deletePath)
+	if auth := api.requests[0].authorization; auth != basicAuthorization("robot", "secret") {
+		t.Errorf("authorization = %q, want basic auth of docker config credentials", auth)
+	}
+
+	api.responses[deletePath] = `403 {"errors":[{"code":"FORBIDDEN"}]}`
+	if err := newHarbor().DeleteRepoImage(repoImage); err == nil || !strings.Contains(err.Error(), "FORBIDDEN") {
+		t.Errorf("DeleteRepoImage() error = %v, want FORBIDDEN", err)
+	}
+}
+
+func TestQuay_DeleteRepoImage(t *testing.T) {
+	server, api := newTestServer(map[string]string{"DELETE /api/v1/repository/project/app/tag/v1": "204"})
+	defer server.Close()
+	host := strings.TrimPrefix(server.URL, "http://")
+
+	repoImage, _ := newTestRepoImage(t, host+"/project/app", "v1")
+
+	os.Unsetenv("WERF_QUAY_TOKEN")
+	if err := newQuay().DeleteRepoImage(repoImage); err == nil || !strings.Contains(err.Error(), "WERF_QUAY_TOKEN") {
+		t.Errorf("DeleteRepoImage() without token error = %v, want $WERF_QUAY_TOKEN required", err)
+	}
+
+	os.Setenv("WERF_QUAY_TOKEN", "oauth-token")
+	defer os.Unsetenv("WERF_QUAY_TOKEN")
+
+	if err := newQuay().DeleteRepoImage(repoImage); err != nil {
+		t.Fatal(err)
+	}
+
+	checkRequests(t, api.requests, "DELETE /api/v1/repository/project/app/tag/v1")
+	if auth := api.requests[0].authorization; auth != "Bearer oauth-token" {
+		t.Errorf("authorization = %q, want Bearer oauth-token", auth)
+	}
+}
+
+func TestDockerHub_DeleteRepoImage(t *testing.T) {
+	server, api := newTestServer(map[string]string{
+		"POST /v2/users/login/":                        `200 {"token": "jwt-token"}`,
+		"DELETE /v2/repositories/project/app/tags/v1/": "204",
+		"DELETE /v2/repositories/project/app/tags/v2/": "204",
+	})
+	defer server.Close()
+	defer setupDockerConfig(t, authn.DefaultAuthKey, "user", "password")()
+
+	dockerHub := newDockerHub()
+	dockerHub.apiUrl = server.URL
+
+	for _, tag := range []string{"v1", "v2"} {
+		repoImage, _ := newTestRepoImage(t, "project/app", tag)
+		if err := dockerHub.DeleteRepoImage(repoImage); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	checkRequests(t, api.requests,
+		"POST /v2/users/login/",
+		"DELETE /v2/repositories/project/app/tags/v1/",
+		"DELETE /v2/repositories/project/app/tags/v2/",
+	)
+
+	var credentials map[string]string
+	if err := json.Unmarshal(api.requests[0].body, &credentials); err != nil {
+		t.Fatal(err)
+	}
+	if credentials["username"] != "user" || credentials["password"] != "password" {
+		t.Errorf("login credentials = %v, want docker config credentials", credentials)
+	}
+
+	for _, r := range api.requests[1:] {
+		if r.authorization != "JWT jwt-token" {
+			t.Errorf("authorization = %q, want JWT jwt-token", r.authorization)
+		}
+	}
+}
+
+func TestEcr_DeleteRepoImage(t *testing.T) {
+	server, api := newTestServer(map[string]string{"POST /": `200 {"failures": []}`})
+	defer server.Close()
+
+	os.Setenv("AWS_ACCESS_KEY_ID", "AKID")
+	os.Setenv("AWS_SECRET_ACCESS_KEY", "SECRET")
+	defer os.Unsetenv("AWS_ACCESS_KEY_ID")
+	defer os.Unsetenv("AWS_SECRET_ACCESS_KEY")
+
+	ecr := newEcr()
+	ecr.apiUrl = server.URL
+	ecr.now = func() time.Time { return time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC) }
+
+	repoImage, digest := newTestRepoImage(t, "123456789012.dkr.ecr.eu-west-1.amazonaws.com/project/app", "v1")
+	if err := ecr.DeleteRepoImage(repoImage); err != nil {
+		t.Fatal(err)
+	}
+
+	checkRequests(t, api.requests, "POST /")
+
+	r := api.requests[0]
+	if target := r.header.Get("X-Amz-Target"); target != "AmazonEC2ContainerRegistry_V20150921.BatchDeleteImage" {
+		t.Errorf("X-Amz-Target = %q, want BatchDeleteImage", target)
+	}
+	if !strings.HasPrefix(r.authorization, "AWS4-HMAC-SHA256 Credential=AKID/20200101/eu-west-1/ecr/aws4_request, ") {
+		t.Errorf("authorization = %q, want signature of ecr service in eu-west-1", r.authorization)
+	}
+
+	expectedBody := fmt.Sprintf(`{"imageIds":[{"imageDigest":%q}],"registryId":"123456789012","repositoryName":"project/app"}`, digest)
+	if string(r.body) != expectedBody {
+		t.Errorf("body = %s, want %s", r.body, expectedBody)
+	}
+
+	api.responses["POST /"] = `200 {"failures": [{"failureCode": "InvalidImageDigest", "failureReason": "bad digest"}]}`
+	if err := ecr.DeleteRepoImage(repoImage); err == nil || !strings.Contains(err.Error(), "InvalidImageDigest") {
+		t.Errorf("DeleteRepoImage() error = %v, want InvalidImageDigest failure", err)
+	}
+}
+
+// Test case get-vanilla of AWS Signature Version 4 test suite
+func TestSignAwsRequest(t *testing.T) {
+	req, err := http.NewRequest(http.MethodGet, "https://example.amazonaws.com/", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	signAwsRequest(req, nil, "us-east-1", "service", "AKIDEXAMPLE", "wJalrXUtnFEMI/K7MDENG+bPxRfiCYEXAMPLEKEY", time.Date(2015, 8, 30, 12, 36, 0, 0, time.UTC))
+
+	expected := "AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/20150830/us-east-1/service/aws4_request, SignedHeaders=host;x-amz-date, Signature=5fa00fa31553b73ebf1942676e86291e8372ff2a2260956d9b8aae1d763fbf31"
+	if auth := req.Header.Get("Authorization"); auth != expected {
+		t.Errorf("Authorization = %q, want %q", auth, expected)
+	}
+}
+
+func TestAcr_DeleteRepoImage(t *testing.T) {
+	server, api := newTestServer(map[string]string{"DELETE /acr/v1/project/app/_tags/v1": "202"})
+	defer server.Close()
+	host := strings.TrimPrefix(server.URL, "http://")
+
+	repoImage, _ := newTestRepoImage(t, host+"/project/app", "v1")
+	if err := newAcr().DeleteRepoImage(repoImage); err != nil {
+		t.Fatal(err)
+	}
+
+	checkRequests(t, api.requests, "DELETE /acr/v1/project/app/_tags/v1")
+}
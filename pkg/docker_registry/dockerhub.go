@@ -0,0 +1,108 @@
+package docker_registry
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+)
+
+const dockerHubApiUrl = "https://hub.docker.com"
+
+// dockerHub deletes the tag with Docker Hub API, the plain v2 DELETE is not supported by Docker Hub.
+// The API token is requested by the docker login credentials
+type dockerHub struct {
+	*defaultImplementation
+
+	apiUrl string
+
+	tokenMutex sync.Mutex
+	token      string
+}
+
+func newDockerHub() *dockerHub {
+	return &dockerHub{defaultImplementation: newDefaultImplementation(), apiUrl: dockerHubApiUrl}
+}
+
+func (r *dockerHub) DeleteRepoImage(repoImage RepoImage) error {
+	repo, err := parseRepoImageRepository(repoImage)
+	if err != nil {
+		return err
+	}
+
+	token, err := r.getToken(repoImage)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest(http.MethodDelete, fmt.Sprintf("%s/v2/repositories/%s/tags/%s/", r.apiUrl, repo.RepositoryStr(), repoImage.Tag), nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", fmt.Sprintf("JWT %s", token))
+
+	if _, err := doApiRequest(&http.Client{Transport: getHttpTransport()}, req, http.StatusNoContent, http.StatusOK); err != nil {
+		return fmt.Errorf("deleting image %q: %v", repoImageTagReference(repoImage), err)
+	}
+
+	return nil
+}
+
+func (r *dockerHub) getToken(repoImage RepoImage) (string, error) {
+	r.tokenMutex.Lock()
+	defer r.tokenMutex.Unlock()
+
+	if r.token != "" {
+		return r.token, nil
+	}
+
+	repo, err := parseRepoImageRepository(repoImage)
+	if err != nil {
+		return "", err
+	}
+
+	authConfig, err := registryAuthConfig(repo.Registry)
+	if err != nil {
+		return "", err
+	}
+
+	if authConfig.Username == "" || authConfig.Password == "" {
+		return "", fmt.Errorf("docker hub credentials not found: docker login required")
+	}
+
+	body, err := json.Marshal(map[string]string{"username": authConfig.Username, "password": authConfig.Password})
+	if err != nil {
+		return "", err
+	}
+
+	req, err := http.NewRequest(http.MethodPost, fmt.Sprintf("%s/v2/users/login/", r.apiUrl), bytes.NewReader(body))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	respBody, err := doApiRequest(&http.Client{Transport: getHttpTransport()}, req, http.StatusOK)
+	if err != nil {
+		return "", fmt.Errorf("docker hub login failed: %v", err)
+	}
+
+	var resp struct {
+		Token string `json:"token"`
+	}
+	if err := json.Unmarshal(respBody, &resp); err != nil {
+		return "", fmt.Errorf("docker hub login failed: bad response: %v", err)
+	}
+
+	r.token = resp.Token
+
+	return r.token, nil
+}
+
+func (r *dockerHub) DeletesOnlyTag() bool {
+	return true
+}
+
+func (r *dockerHub) String() string {
+	return DockerHubImplementationName
+}
@@ -0,0 +1,164 @@
+package docker_registry
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+var ecrHostRegexp = regexp.MustCompile(`^(\d+)\.dkr\.ecr\.([^.]+)\.amazonaws\.com(\.cn)?$`)
+
+// ecr deletes the image with ECR BatchDeleteImage API, the plain v2 DELETE is not supported by ECR.
+// The request is signed by AWS credentials from $AWS_ACCESS_KEY_ID, $AWS_SECRET_ACCESS_KEY and $AWS_SESSION_TOKEN
+type ecr struct {
+	*defaultImplementation
+
+	// apiUrl overrides the regional API endpoint
+	apiUrl string
+	now    func() time.Time
+}
+
+func newEcr() *ecr {
+	return &ecr{defaultImplementation: newDefaultImplementation(), now: time.Now}
+}
+
+func (r *ecr) DeleteRepoImage(repoImage RepoImage) error {
+	repo, err := parseRepoImageRepository(repoImage)
+	if err != nil {
+		return err
+	}
+
+	matches := ecrHostRegexp.FindStringSubmatch(repo.RegistryStr())
+	if matches == nil {
+		return fmt.Errorf("bad ecr registry %q: expected <account>.dkr.ecr.<region>.amazonaws.com", repo.RegistryStr())
+	}
+	registryId, region := matches[1], matches[2]
+
+	accessKeyId, secretAccessKey := os.Getenv("AWS_ACCESS_KEY_ID"), os.Getenv("AWS_SECRET_ACCESS_KEY")
+	if accessKeyId == "" || secretAccessKey == "" {
+		return fmt.Errorf("deleting image %q: AWS credentials should be specified by $AWS_ACCESS_KEY_ID and $AWS_SECRET_ACCESS_KEY", repoImageTagReference(repoImage))
+	}
+
+	digest, err := repoImageDigest(repoImage)
+	if err != nil {
+		return err
+	}
+
+	body, err := json.Marshal(map[string]interface{}{
+		"registryId":     registryId,
+		"repositoryName": repo.RepositoryStr(),
+		"imageIds":       []map[string]string{{"imageDigest": digest}},
+	})
+	if err != nil {
+		return err
+	}
+
+	apiUrl := r.apiUrl
+	if apiUrl == "" {
+		apiUrl = fmt.Sprintf("https://api.ecr.%s.amazonaws.com%s", region, matches[3])
+	}
+
+	req, err := http.NewRequest(http.MethodPost, apiUrl+"/", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-amz-json-1.1")
+	req.Header.Set("X-Amz-Target", "AmazonEC2ContainerRegistry_V20150921.BatchDeleteImage")
+	if sessionToken := os.Getenv("AWS_SESSION_TOKEN"); sessionToken != "" {
+		req.Header.Set("X-Amz-Security-Token", sessionToken)
+	}
+
+	signAwsRequest(req, body, region, "ecr", accessKeyId, secretAccessKey, r.now())
+
+	respBody, err := doApiRequest(&http.Client{Transport: getHttpTransport()}, req, http.StatusOK)
+	if err != nil {
+		return fmt.Errorf("deleting image %q: %v", repoImageTagReference(repoImage), err)
+	}
+
+	var resp struct {
+		Failures []struct {
+			FailureCode   string `json:"failureCode"`
+			FailureReason string `json:"failureReason"`
+		} `json:"failures"`
+	}
+	if err := json.Unmarshal(respBody, &resp); err != nil {
+		return fmt.Errorf("deleting image %q: bad response: %v", repoImageTagReference(repoImage), err)
+	}
+
+	for _, failure := range resp.Failures {
+		if failure.FailureCode == "ImageNotFound" {
+			continue
+		}
+		return fmt.Errorf("deleting image %q: %s: %s", repoImageTagReference(repoImage), failure.FailureCode, failure.FailureReason)
+	}
+
+	return nil
+}
+
+func (r *ecr) String() string {
+	return EcrImplementationName
+}
+
+// signAwsRequest signs the request without query by AWS Signature Version 4
+func signAwsRequest(req *http.Request, body []byte, region, service, accessKeyId, secretAccessKey string, t time.Time) {
+	t = t.UTC()
+	amzDate := t.Format("20060102T150405Z")
+	date := t.Format("20060102")
+
+	req.Header.Set("X-Amz-Date", amzDate)
+
+	headers := map[string]string{"host": req.URL.Host}
+	for key, values := range req.Header {
+		headers[strings.ToLower(key)] = strings.TrimSpace(strings.Join(values, ","))
+	}
+
+	var headerNames []string
+	for key := range headers {
+		headerNames = append(headerNames, key)
+	}
+	sort.Strings(headerNames)
+
+	var canonicalHeaders string
+	for _, key := range headerNames {
+		canonicalHeaders += fmt.Sprintf("%s:%s\n", key, headers[key])
+	}
+	signedHeaders := strings.Join(headerNames, ";")
+
+	canonicalUri := req.URL.EscapedPath()
+	if canonicalUri == "" {
+		canonicalUri = "/"
+	}
+
+	canonicalRequest := strings.Join([]string{req.Method, canonicalUri, req.URL.RawQuery, canonicalHeaders, signedHeaders, sha256Hex(body)}, "\n")
+
+	scope := strings.Join([]string{date, region, service, "aws4_request"}, "/")
+	stringToSign := strings.Join([]string{"AWS4-HMAC-SHA256", amzDate, scope, sha256Hex([]byte(canonicalRequest))}, "\n")
+
+	signingKey := hmacSha256([]byte("AWS4"+secretAccessKey), date)
+	for _, part := range []string{region, service, "aws4_request"} {
+		signingKey = hmacSha256(signingKey, part)
+	}
+	signature := hex.EncodeToString(hmacSha256(signingKey, stringToSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s", accessKeyId, scope, signedHeaders, signature))
+}
+
+func sha256Hex(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+func hmacSha256(key []byte, data string) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write([]byte(data))
+	return h.Sum(nil)
+}
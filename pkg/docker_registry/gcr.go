@@ -0,0 +1,22 @@
+package docker_registry
+
+// gcr deletes the tag reference, GCR rejects deletion of the manifest by digest while it is tagged
+type gcr struct {
+	*defaultImplementation
+}
+
+func newGcr() *gcr {
+	return &gcr{defaultImplementation: newDefaultImplementation()}
+}
+
+func (r *gcr) DeleteRepoImage(repoImage RepoImage) error {
+	return ImageDelete(repoImageTagReference(repoImage))
+}
+
+func (r *gcr) DeletesOnlyTag() bool {
+	return true
+}
+
+func (r *gcr) String() string {
+	return GcrImplementationName
+}
@@ -0,0 +1,44 @@
+package docker_registry
+
+import (
+	"fmt"
+
+	"github.com/google/go-containerregistry/pkg/authn"
+	"github.com/google/go-containerregistry/pkg/name"
+)
+
+// gitLab requests the token with the wildcard scope, the delete scope is not granted by GitLab
+type gitLab struct {
+	*defaultImplementation
+}
+
+func newGitLab() *gitLab {
+	return &gitLab{defaultImplementation: newDefaultImplementation()}
+}
+
+func (r *gitLab) DeleteRepoImage(repoImage RepoImage) error {
+	reference, err := repoImageDigestReference(repoImage)
+	if err != nil {
+		return err
+	}
+
+	ref, err := name.ParseReference(reference, parseReferenceOptions()...)
+	if err != nil {
+		return fmt.Errorf("parsing reference %q: %v", reference, err)
+	}
+
+	auth, err := authn.DefaultKeychain.Resolve(ref.Context().Registry)
+	if err != nil {
+		return fmt.Errorf("getting creds for %q: %v", ref, err)
+	}
+
+	if err := GitlabRegistryDelete(ref, auth, getHttpTransport()); err != nil {
+		return fmt.Errorf("deleting image %q: %v", ref, err)
+	}
+
+	return nil
+}
+
+func (r *gitLab) String() string {
+	return GitLabImplementationName
+}
@@ -0,0 +1,60 @@
+package docker_registry
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// harbor deletes the artifact with Harbor API v2.0, the plain v2 DELETE is disabled in Harbor by default
+type harbor struct {
+	*defaultImplementation
+}
+
+func newHarbor() *harbor {
+	return &harbor{defaultImplementation: newDefaultImplementation()}
+}
+
+func (r *harbor) DeleteRepoImage(repoImage RepoImage) error {
+	repo, err := parseRepoImageRepository(repoImage)
+	if err != nil {
+		return err
+	}
+
+	parts := strings.SplitN(repo.RepositoryStr(), "/", 2)
+	if len(parts) != 2 {
+		return fmt.Errorf("bad harbor repository %q: expected project/repository", repoImage.Repository)
+	}
+	project, repository := parts[0], parts[1]
+
+	digest, err := repoImageDigest(repoImage)
+	if err != nil {
+		return err
+	}
+
+	// Slashes in the repository name must be encoded twice
+	u := fmt.Sprintf("%s://%s/api/v2.0/projects/%s/repositories/%s/artifacts/%s",
+		repo.Registry.Scheme(), repo.RegistryStr(), url.PathEscape(project), url.PathEscape(url.PathEscape(repository)), digest)
+
+	req, err := http.NewRequest(http.MethodDelete, u, nil)
+	if err != nil {
+		return err
+	}
+
+	authConfig, err := registryAuthConfig(repo.Registry)
+	if err != nil {
+		return err
+	}
+	req.SetBasicAuth(authConfig.Username, authConfig.Password)
+
+	if _, err := doApiRequest(&http.Client{Transport: getHttpTransport()}, req, http.StatusOK); err != nil {
+		return fmt.Errorf("deleting image %q: %v", repoImageTagReference(repoImage), err)
+	}
+
+	return nil
+}
+
+func (r *harbor) String() string {
+	return HarborImplementationName
+}
@@ -1,13 +1,13 @@
 package docker_registry
 
 import (
-	"crypto/tls"
 	"fmt"
 	"io/ioutil"
 	"net/http"
 	"net/url"
-	"regexp"
+	"path/filepath"
 	"strings"
+	"sync"
 
 	"github.com/google/go-containerregistry/pkg/authn"
 	"github.com/google/go-containerregistry/pkg/logs"
@@ -19,12 +19,20 @@ import (
 	"github.com/flant/logboek"
 
 	imagePkg "github.com/flant/werf/pkg/image"
+	"github.com/flant/werf/pkg/tracing"
+	"github.com/flant/werf/pkg/werf"
 )
 
 var (
 	InsecureRegistry      = false
 	SkipTlsVerifyRegistry = false
+	RepoImplementation    = ""
 	GCRUrlPatterns        = []string{"^container\\.cloud\\.google\\.com", "^gcr\\.io", "^.*\\.gcr\\.io"}
+
+	// ConfigCacheDir stores image config blobs by digest, the cache is disabled when empty
+	ConfigCacheDir = ""
+	// Concurrency limits the number of images fetched at the same time
+	Concurrency = 10
 )
 
 type RepoImage struct {
@@ -36,12 +44,21 @@ type RepoImage struct {
 type Options struct {
 	InsecureRegistry      bool
 	SkipTlsVerifyRegistry bool
+	RepoImplementation    string
 }
 
 func Init(opts Options) error {
 	InsecureRegistry = opts.InsecureRegistry
 	SkipTlsVerifyRegistry = opts.SkipTlsVerifyRegistry
 
+	if err := ValidateImplementationName(opts.RepoImplementation); err != nil {
+		return err
+	}
+	RepoImplementation = opts.RepoImplementation
+	ConfigCacheDir = filepath.Join(werf.GetLocalCacheDir(), "docker_registry", "image_configs", ConfigCacheVersion)
+
+	resetHttpTransport()
+
 	if logboek.Debug.IsAccepted() {
 		logs.Progress.SetOutput(logboek.GetOutStream())
 		logs.Warn.SetOutput(logboek.GetErrStream())
@@ -55,72 +72,158 @@ func Init(opts Options) error {
 	return nil
 }
 
-func IsGCR(reference string) (bool, error) {
-	u, err := url.Parse(fmt.Sprintf("scheme://%s", reference))
-	if err != nil {
-		return false, err
-	}
-
-	for _, pattern := range GCRUrlPatterns {
-		matched, err := regexp.MatchString(pattern, u.Host)
-		if err != nil {
-			return false, err
-		}
-
-		if matched {
-			return true, nil
-		}
-	}
+func ImagesByWerfImageLabel(reference, labelValue string) ([]RepoImage, error) {
+	span := tracing.StartSpan("docker_registry images by label", map[string]string{"werf.registry.repository": reference, "werf.registry.label_value": labelValue})
+	repoImages, err := imagesByWerfImageLabel(span, reference, labelValue)
+	span.End(err)
 
-	return false, nil
+	return repoImages, err
 }
 
-func ImagesByWerfImageLabel(reference, labelValue string) ([]RepoImage, error) {
-	var repoImages []RepoImage
-
+// imagesByWerfImageLabel gets the images concurrently, so the images spans are started with the explicit parent span
+func imagesByWerfImageLabel(parentSpan *tracing.Span, reference, labelValue string) ([]RepoImage, error) {
 	tags, err := Tags(reference)
 	if err != nil {
 		return nil, err
 	}
 
-	for _, tag := range tags {
-		tagReference := strings.Join([]string{reference, tag}, ":")
-		v1Image, _, err := image(tagReference)
+	repoImagesByTagIndex := make([]*RepoImage, len(tags))
+	if err := doConcurrently(len(tags), Concurrency, func(i int) error {
+		tagReference := strings.Join([]string{reference, tags[i]}, ":")
+		v1Image, _, err := imageWithParentSpan(parentSpan, tagReference)
 		if err != nil {
 			if strings.Contains(err.Error(), "MANIFEST_UNKNOWN") {
 				logboek.LogWarnF("WARNING: Broken tag %s was skipped: %s\n", tagReference, err)
-				continue
+				return nil
 			}
 
 			if strings.Contains(err.Error(), "BLOB_UNKNOWN") {
 				logboek.LogWarnF("WARNING: Broken tag %s was skipped: %s\n", tagReference, err)
-				continue
+				return nil
 			}
-			return nil, err
+			return err
 		}
 
-		configFile, err := v1Image.ConfigFile()
+		configFile, err := imageConfigFile(v1Image)
 		if err != nil {
-			return nil, err
+			if strings.Contains(err.Error(), "BLOB_UNKNOWN") {
+				logboek.LogWarnF("WARNING: Broken tag %s was skipped: %s\n", tagReference, err)
+				return nil
+			}
+			return err
 		}
 
-		for k, v := range configFile.Config.Labels {
-			if k == imagePkg.WerfImageLabel && v == labelValue {
-				repoImage := RepoImage{
-					Repository: reference,
-					Tag:        tag,
-					Image:      v1Image,
-				}
-
-				repoImages = append(repoImages, repoImage)
-				break
+		if v, ok := configFile.Config.Labels[imagePkg.WerfImageLabel]; ok && v == labelValue {
+			repoImagesByTagIndex[i] = &RepoImage{
+				Repository: reference,
+				Tag:        tags[i],
+				Image:      v1Image,
 			}
 		}
+
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+
+	var repoImages []RepoImage
+	for _, repoImage := range repoImagesByTagIndex {
+		if repoImage != nil {
+			repoImages = append(repoImages, *repoImage)
+		}
 	}
 
 	return repoImages, nil
 }
 
+// TagsByDigest groups the tags of the repository by the manifest digest, the tags removed in the meantime are skipped
+func TagsByDigest(repository string, tags []string) (map[string][]string, error) {
+	span := tracing.StartSpan("docker_registry tags by digest", map[string]string{"werf.registry.repository": repository})
+
+	digestsByTagIndex := make([]string, len(tags))
+	err := doConcurrently(len(tags), Concurrency, func(i int) error {
+		v1Image, _, err := imageWithParentSpan(span, strings.Join([]string{repository, tags[i]}, ":"))
+		if err != nil {
+			if isNotFoundErr(err) {
+				return nil
+			}
+			return err
+		}
+
+		digest, err := v1Image.Digest()
+		if err != nil {
+			if isNotFoundErr(err) {
+				return nil
+			}
+			return err
+		}
+
+		digestsByTagIndex[i] = digest.String()
+
+		return nil
+	})
+	span.End(err)
+	if err != nil {
+		return nil, err
+	}
+
+	tagsByDigest := map[string][]string{}
+	for i, digest := range digestsByTagIndex {
+		if digest != "" {
+			tagsByDigest[digest] = append(tagsByDigest[digest], tags[i])
+		}
+	}
+
+	return tagsByDigest, nil
+}
+
+// doConcurrently calls f for each index by the limited number of goroutines, the first error stops processing
+func doConcurrently(n, concurrency int, f func(i int) error) error {
+	if concurrency < 1 {
+		concurrency = 1
+	}
+
+	var wg sync.WaitGroup
+	var mutex sync.Mutex
+	var firstErr error
+
+	semaphore := make(chan struct{}, concurrency)
+	for i := 0; i < n; i++ {
+		semaphore <- struct{}{}
+
+		// the error is checked after waiting for a free slot, so no new calls are started after the failed call is done
+		mutex.Lock()
+		failed := firstErr != nil
+		mutex.Unlock()
+
+		if failed {
+			<-semaphore
+			break
+		}
+
+		wg.Add(1)
+
+		go func(i int) {
+			defer func() {
+				<-semaphore
+				wg.Done()
+			}()
+
+			if err := f(i); err != nil {
+				mutex.Lock()
+				if firstErr == nil {
+					firstErr = err
+				}
+				mutex.Unlock()
+			}
+		}(i)
+	}
+
+	wg.Wait()
+
+	return firstErr
+}
+
 func Tags(reference string) ([]string, error) {
 	tags, err := list(reference)
 	if err != nil {
@@ -139,7 +242,9 @@ func list(reference string) ([]string, error) {
 		return nil, fmt.Errorf("parsing repo %q: %v", reference, err)
 	}
 
+	span := tracing.StartSpan("docker_registry list tags", map[string]string{"werf.registry.repository": reference})
 	tags, err := remote.List(repo, remote.WithAuthFromKeychain(authn.DefaultKeychain), remote.WithTransport(getHttpTransport()))
+	span.End(err)
 	if err != nil {
 		return nil, fmt.Errorf("reading tags for %q: %v", repo, err)
 	}
@@ -176,7 +281,7 @@ func ImageConfigFile(reference string) (v1.ConfigFile, error) {
 		return v1.ConfigFile{}, err
 	}
 
-	configFile, err := i.ConfigFile()
+	configFile, err := imageConfigFile(i)
 	if err != nil {
 		return v1.ConfigFile{}, err
 	}
@@ -190,7 +295,11 @@ func ImageDelete(reference string) error {
 		return fmt.Errorf("parsing reference %q: %v", reference, err)
 	}
 
-	if deleteErr := remote.Delete(r, remote.WithAuthFromKeychain(authn.DefaultKeychain), remote.WithTransport(getHttpTransport())); deleteErr != nil {
+	span := tracing.StartSpan("docker_registry delete image", map[string]string{"werf.registry.reference": reference})
+	deleteErr := remote.Delete(r, remote.WithAuthFromKeychain(authn.DefaultKeychain), remote.WithTransport(getHttpTransport()))
+	span.End(deleteErr)
+
+	if deleteErr != nil {
 		if strings.Contains(deleteErr.Error(), "UNAUTHORIZED") {
 			auth, authErr := authn.DefaultKeychain.Resolve(r.Context().Registry)
 			if authErr != nil {
@@ -249,6 +358,26 @@ func GitlabRegistryDelete(ref name.Reference, auth authn.Authenticator, t http.R
 	}
 }
 
+func IsImageExist(reference string) (bool, error) {
+	if _, _, err := image(reference); err != nil {
+		if isNotFoundErr(err) {
+			return false, nil
+		}
+		return false, err
+	}
+
+	return true, nil
+}
+
+func RepoImageByTag(repository, tag string) (RepoImage, error) {
+	v1Image, _, err := image(strings.Join([]string{repository, tag}, ":"))
+	if err != nil {
+		return RepoImage{}, err
+	}
+
+	return RepoImage{Repository: repository, Tag: tag, Image: v1Image}, nil
+}
+
 func ImageDigest(reference string) (string, error) {
 	i, _, err := image(reference)
 	if err != nil {
@@ -264,18 +393,18 @@ func ImageDigest(reference string) (string, error) {
 }
 
 func image(reference string) (v1.Image, name.Reference, error) {
+	return imageWithParentSpan(tracing.CurrentSpan(), reference)
+}
+
+func imageWithParentSpan(parentSpan *tracing.Span, reference string) (v1.Image, name.Reference, error) {
 	ref, err := name.ParseReference(reference, parseReferenceOptions()...)
 	if err != nil {
 		return nil, nil, fmt.Errorf("parsing reference %q: %v", reference, err)
 	}
 
-	// FIXME: Hack for the go-containerregistry library,
-	// FIXME: that uses default transport without options to change transport to custom.
-	// FIXME: Needed for the insecure https registry to work.
-	oldDefaultTransport := http.DefaultTransport
-	http.DefaultTransport = getHttpTransport()
-	img, err := remote.Image(ref, remote.WithAuthFromKeychain(authn.DefaultKeychain))
-	http.DefaultTransport = oldDefaultTransport
+	span := tracing.StartChildSpan(parentSpan, "docker_registry get image", map[string]string{"werf.registry.reference": reference})
+	img, err := remote.Image(ref, remote.WithAuthFromKeychain(authn.DefaultKeychain), remote.WithTransport(getHttpTransport()))
+	span.End(err)
 
 	if err != nil {
 		return nil, nil, fmt.Errorf("reading image %q: %v", ref, err)
@@ -298,26 +427,3 @@ func parseReferenceOptions() []name.Option {
 
 	return options
 }
-
-func getHttpTransport() (transport http.RoundTripper) {
-	transport = http.DefaultTransport
-
-	if SkipTlsVerifyRegistry {
-		defaultTransport := http.DefaultTransport.(*http.Transport)
-
-		newTransport := &http.Transport{
-			Proxy:                 defaultTransport.Proxy,
-			DialContext:           defaultTransport.DialContext,
-			MaxIdleConns:          defaultTransport.MaxIdleConns,
-			IdleConnTimeout:       defaultTransport.IdleConnTimeout,
-			TLSHandshakeTimeout:   defaultTransport.TLSHandshakeTimeout,
-			ExpectContinueTimeout: defaultTransport.ExpectContinueTimeout,
-			TLSClientConfig:       &tls.Config{InsecureSkipVerify: true},
-			TLSNextProto:          make(map[string]func(authority string, c *tls.Conn) http.RoundTripper),
-		}
-
-		transport = newTransport
-	}
-
-	return
-}
@@ -0,0 +1,227 @@
+package docker_registry
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/google/go-containerregistry/pkg/name"
+	"github.com/google/go-containerregistry/pkg/registry"
+	"github.com/google/go-containerregistry/pkg/v1/mutate"
+	"github.com/google/go-containerregistry/pkg/v1/random"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+
+	imagePkg "github.com/flant/werf/pkg/image"
+)
+
+func TestImagesByWerfImageLabel(t *testing.T) {
+	var tags []string
+	registryHandler := registry.New()
+
+	// The tags list is not supported by the in-memory registry
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/v2/project/app/tags/list" {
+			_ = json.NewEncoder(w).Encode(map[string]interface{}{"name": "project/app", "tags": tags})
+			return
+		}
+		registryHandler.ServeHTTP(w, r)
+	}))
+	defer server.Close()
+
+	cacheDir, err := ioutil.TempDir("", "docker-registry-cache")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(cacheDir)
+
+	oldConfigCacheDir, oldConcurrency := ConfigCacheDir, Concurrency
+	ConfigCacheDir, Concurrency = cacheDir, 3
+	defer func() { ConfigCacheDir, Concurrency = oldConfigCacheDir, oldConcurrency }()
+
+	repository := strings.TrimPrefix(server.URL, "http://") + "/project/app"
+
+	var expectedTags []string
+	for i := 0; i < 10; i++ {
+		img, err := random.Image(64, 1)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		configFile, err := img.ConfigFile()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		labelValue := "false"
+		if i%2 == 0 {
+			labelValue = "true"
+			expectedTags = append(expectedTags, fmt.Sprintf("tag-%d", i))
+		}
+		configFile.Config.Labels = map[string]string{imagePkg.WerfImageLabel: labelValue}
+
+		if img, err = mutate.ConfigFile(img, configFile); err != nil {
+			t.Fatal(err)
+		}
+
+		ref, err := name.ParseReference(fmt.Sprintf("%s:tag-%d", repository, i), name.WeakValidation)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if err := remote.Write(ref, img); err != nil {
+			t.Fatal(err)
+		}
+		tags = append(tags, ref.Identifier())
+	}
+
+	for run := 0; run < 2; run++ {
+		repoImages, err := ImagesByWerfImageLabel(repository, "true")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var labelledTags []string
+		for _, repoImage := range repoImages {
+			labelledTags = append(labelledTags, repoImage.Tag)
+		}
+
+		if strings.Join(labelledTags, ",") != strings.Join(expectedTags, ",") {
+			t.Errorf("run %d: tags = %v, want %v", run, labelledTags, expectedTags)
+		}
+	}
+
+	cachedConfigs, err := filepath.Glob(filepath.Join(cacheDir, "sha256", "*"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(cachedConfigs) != 10 {
+		t.Errorf("cached configs = %d, want 10", len(cachedConfigs))
+	}
+}
+
+func TestDoConcurrently(t *testing.T) {
+	var mutex sync.Mutex
+	var processed []int
+	var running, maxRunning int
+
+	if err := doConcurrently(20, 3, func(i int) error {
+		mutex.Lock()
+		processed = append(processed, i)
+		running++
+		if running > maxRunning {
+			maxRunning = running
+		}
+		mutex.Unlock()
+
+		time.Sleep(time.Millisecond)
+
+		mutex.Lock()
+		running--
+		mutex.Unlock()
+
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(processed) != 20 {
+		t.Errorf("processed = %d, want 20", len(processed))
+	}
+
+	if maxRunning > 3 {
+		t.Errorf("max running = %d, want not more than 3", maxRunning)
+	}
+}
+
+func TestDoConcurrently_StopsAfterError(t *testing.T) {
+	var calls int32
+
+	err := doConcurrently(20, 1, func(i int) error {
+		atomic.AddInt32(&calls, 1)
+		return fmt.Errorf("failed %d", i)
+	})
+
+	if err == nil || err.Error() != "failed 0" {
+		t.Errorf("err = %v, want failed 0", err)
+	}
+
+	if calls != 1 {
+		t.Errorf("calls = %d, want 1", calls)
+	}
+
+	calls = 0
+	err = doConcurrently(20, 3, func(i int) error {
+		atomic.AddInt32(&calls, 1)
+		if i == 0 {
+			return errors.New("failed")
+		}
+
+		time.Sleep(10 * time.Millisecond)
+		return nil
+	})
+
+	if err == nil {
+		t.Error("error expected")
+	}
+
+	// the calls started before the failed call is done are finished, no calls are started after
+	if calls > 3 {
+		t.Errorf("calls = %d, want not more than 3", calls)
+	}
+}
+
+func TestTagsByDigest(t *testing.T) {
+	server := httptest.NewServer(registry.New())
+	defer server.Close()
+
+	repository := strings.TrimPrefix(server.URL, "http://") + "/project/app"
+
+	digestByTag := map[string]string{}
+	for _, tags := range [][]string{{"v1", "latest"}, {"v2"}} {
+		img, err := random.Image(64, 1)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		digest, err := img.Digest()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		for _, tag := range tags {
+			ref, err := name.ParseReference(fmt.Sprintf("%s:%s", repository, tag), name.WeakValidation)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if err := remote.Write(ref, img); err != nil {
+				t.Fatal(err)
+			}
+			digestByTag[tag] = digest.String()
+		}
+	}
+
+	tagsByDigest, err := TagsByDigest(repository, []string{"v1", "v2", "removed", "latest"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := map[string][]string{
+		digestByTag["v1"]: {"v1", "latest"},
+		digestByTag["v2"]: {"v2"},
+	}
+	if fmt.Sprint(tagsByDigest) != fmt.Sprint(expected) {
+		t.Errorf("TagsByDigest() = %v, want %v", tagsByDigest, expected)
+	}
+}
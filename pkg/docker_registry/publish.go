@@ -0,0 +1,178 @@
+package docker_registry
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/google/go-containerregistry/pkg/authn"
+	"github.com/google/go-containerregistry/pkg/name"
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/mutate"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+	"github.com/google/go-containerregistry/pkg/v1/remote/transport"
+
+	"github.com/flant/werf/pkg/tracing"
+)
+
+// FindPublishedImageByParentId returns the image of the repository, that was published by werf from the image with the specified id,
+// so that its layers are reused by PushImageWithLabels. The tags are inspected concurrently, the configs are cached by digest.
+// The layers blobs of the found image are checked by digest in the repository, the image with the missing blobs is skipped
+func FindPublishedImageByParentId(repository string, tags []string, parentId string) (v1.Image, string, error) {
+	repo, err := name.NewRepository(repository, newRepositoryOptions()...)
+	if err != nil {
+		return nil, "", fmt.Errorf("parsing repo %q: %v", repository, err)
+	}
+
+	span := tracing.StartSpan("docker_registry find published image", map[string]string{"werf.registry.repository": repository})
+	defer span.End(nil)
+
+	imagesByTagIndex := make([]v1.Image, len(tags))
+	if err := doConcurrently(len(tags), Concurrency, func(i int) error {
+		candidate, _, err := imageWithParentSpan(span, strings.Join([]string{repository, tags[i]}, ":"))
+		if err != nil {
+			if isNotFoundErr(err) || strings.Contains(err.Error(), "BLOB_UNKNOWN") {
+				return nil
+			}
+			return err
+		}
+
+		configFile, err := imageConfigFile(candidate)
+		if err != nil {
+			if isNotFoundErr(err) || strings.Contains(err.Error(), "BLOB_UNKNOWN") {
+				return nil
+			}
+			return err
+		}
+
+		if configFile.Config.Image == parentId {
+			imagesByTagIndex[i] = candidate
+		}
+
+		return nil
+	}); err != nil {
+		return nil, "", err
+	}
+
+	var blobsTransport http.RoundTripper
+	for i, img := range imagesByTagIndex {
+		if img == nil {
+			continue
+		}
+
+		if blobsTransport == nil {
+			if blobsTransport, err = newRepositoryTransport(repo, transport.PullScope); err != nil {
+				return nil, "", err
+			}
+		}
+
+		reference := strings.Join([]string{repository, tags[i]}, ":")
+		if exist, err := layersBlobsExist(blobsTransport, repo, img); err != nil {
+			return nil, "", fmt.Errorf("checking layers of image %q: %v", reference, err)
+		} else if exist {
+			return img, reference, nil
+		}
+	}
+
+	return nil, "", nil
+}
+
+func layersBlobsExist(t http.RoundTripper, repo name.Repository, img v1.Image) (bool, error) {
+	layers, err := img.Layers()
+	if err != nil {
+		return false, err
+	}
+
+	for _, layer := range layers {
+		digest, err := layer.Digest()
+		if err != nil {
+			return false, err
+		}
+
+		if exist, err := blobExists(t, repo, digest); err != nil || !exist {
+			return false, err
+		}
+	}
+
+	return true, nil
+}
+
+// blobExists checks the blob by digest with HEAD request
+func blobExists(t http.RoundTripper, repo name.Repository, digest v1.Hash) (bool, error) {
+	u := url.URL{
+		Scheme: repo.Registry.Scheme(),
+		Host:   repo.RegistryStr(),
+		Path:   fmt.Sprintf("/v2/%s/blobs/%s", repo.RepositoryStr(), digest),
+	}
+
+	req, err := http.NewRequest(http.MethodHead, u.String(), nil)
+	if err != nil {
+		return false, err
+	}
+
+	resp, err := (&http.Client{Transport: t}).Do(req)
+	if err != nil {
+		return false, err
+	}
+	resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return true, nil
+	case http.StatusNotFound:
+		return false, nil
+	default:
+		return false, fmt.Errorf("unexpected status code during HEAD %s: %v", u.String(), resp.Status)
+	}
+}
+
+func newRepositoryTransport(repo name.Repository, scope string) (http.RoundTripper, error) {
+	auth, err := authn.DefaultKeychain.Resolve(repo.Registry)
+	if err != nil {
+		return nil, fmt.Errorf("getting creds for %q: %v", repo, err)
+	}
+
+	return transport.New(repo.Registry, auth, getHttpTransport(), []string{repo.Scope(scope)})
+}
+
+// PushImageWithLabels mutates only the config of the base image and pushes the result.
+// Layers that already exist in the target repository are not uploaded (each blob is checked by digest with HEAD request),
+// layers of the remote base image from another repository of the same registry are mounted.
+func PushImageWithLabels(baseImage v1.Image, reference, parentId string, labels map[string]string) error {
+	ref, err := name.ParseReference(reference, parseReferenceOptions()...)
+	if err != nil {
+		return fmt.Errorf("parsing reference %q: %v", reference, err)
+	}
+
+	baseConfigFile, err := baseImage.ConfigFile()
+	if err != nil {
+		return err
+	}
+
+	configFile := baseConfigFile.DeepCopy()
+	if configFile.Config.Labels == nil {
+		configFile.Config.Labels = map[string]string{}
+	}
+
+	for k, v := range labels {
+		configFile.Config.Labels[k] = v
+	}
+
+	configFile.Config.Image = parentId
+
+	newImage, err := mutate.ConfigFile(baseImage, configFile)
+	if err != nil {
+		return err
+	}
+
+	span := tracing.StartSpan("docker_registry push image", map[string]string{"werf.registry.reference": reference})
+	err = remote.Write(ref, newImage, remote.WithAuthFromKeychain(authn.DefaultKeychain), remote.WithTransport(getHttpTransport()))
+	span.End(err)
+
+	if err != nil {
+		return fmt.Errorf("writing image %q: %v", ref, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,138 @@
+package docker_registry
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/google/go-containerregistry/pkg/name"
+	"github.com/google/go-containerregistry/pkg/registry"
+	"github.com/google/go-containerregistry/pkg/v1/mutate"
+	"github.com/google/go-containerregistry/pkg/v1/random"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+)
+
+func TestPushImageWithLabels(t *testing.T) {
+	server := httptest.NewServer(registry.New())
+	defer server.Close()
+
+	registryHost := strings.TrimPrefix(server.URL, "http://")
+	parentId := "sha256:0123456789abcdef"
+
+	img, err := random.Image(1024, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	configFile, err := img.ConfigFile()
+	if err != nil {
+		t.Fatal(err)
+	}
+	configFile.Config.Image = parentId
+
+	img, err = mutate.ConfigFile(img, configFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ref, err := name.ParseReference(registryHost+"/project/app:v1", name.WeakValidation)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := remote.Write(ref, img); err != nil {
+		t.Fatal(err)
+	}
+
+	otherImg, err := random.Image(1024, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	otherRef, err := name.ParseReference(registryHost+"/project/app:other", name.WeakValidation)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := remote.Write(otherRef, otherImg); err != nil {
+		t.Fatal(err)
+	}
+
+	repository := registryHost + "/project/app"
+	tags := []string{"other", "removed", "v1"}
+
+	if baseImage, _, err := FindPublishedImageByParentId(repository, tags, "sha256:unknown"); err != nil {
+		t.Fatal(err)
+	} else if baseImage != nil {
+		t.Fatal("no image expected to be found")
+	}
+
+	baseImage, baseImageName, err := FindPublishedImageByParentId(repository, tags, parentId)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if baseImage == nil || baseImageName != repository+":v1" {
+		t.Fatalf("image %s:v1 expected to be found, got %q", repository, baseImageName)
+	}
+
+	targetImageName := registryHost + "/project/other:v2"
+	if err := PushImageWithLabels(baseImage, targetImageName, parentId, map[string]string{"werf-image-tag": "v2"}); err != nil {
+		t.Fatal(err)
+	}
+
+	pushedConfigFile, err := ImageConfigFile(targetImageName)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if pushedConfigFile.Config.Labels["werf-image-tag"] != "v2" {
+		t.Fatalf("unexpected labels %v", pushedConfigFile.Config.Labels)
+	}
+
+	if pushedConfigFile.Config.Image != parentId {
+		t.Fatalf("unexpected parent id %s", pushedConfigFile.Config.Image)
+	}
+
+	if len(pushedConfigFile.RootFS.DiffIDs) != 3 {
+		t.Fatalf("unexpected layers %v", pushedConfigFile.RootFS.DiffIDs)
+	}
+
+	// the image with the missing layers blobs should not be reused
+	layers, err := img.Layers()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	layerDigest, err := layers[0].Digest()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	blobsHandler := registry.New()
+	blobsServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodHead && strings.HasSuffix(r.URL.Path, "/blobs/"+layerDigest.String()) {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		blobsHandler.ServeHTTP(w, r)
+	}))
+	defer blobsServer.Close()
+
+	brokenRepository := strings.TrimPrefix(blobsServer.URL, "http://") + "/project/app"
+	brokenRef, err := name.ParseReference(brokenRepository+":v1", name.WeakValidation)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := remote.Write(brokenRef, img); err != nil {
+		t.Fatal(err)
+	}
+
+	if baseImage, _, err := FindPublishedImageByParentId(brokenRepository, []string{"v1"}, parentId); err != nil {
+		t.Fatal(err)
+	} else if baseImage != nil {
+		t.Fatal("no image expected to be found when the layers blobs are missing")
+	}
+}
@@ -0,0 +1,50 @@
+package docker_registry
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+)
+
+// quay deletes the tag with Quay API, the API requires OAuth token of the application, which is specified by $WERF_QUAY_TOKEN
+type quay struct {
+	*defaultImplementation
+}
+
+func newQuay() *quay {
+	return &quay{defaultImplementation: newDefaultImplementation()}
+}
+
+func (r *quay) DeleteRepoImage(repoImage RepoImage) error {
+	token := os.Getenv("WERF_QUAY_TOKEN")
+	if token == "" {
+		return fmt.Errorf("deleting image %q: Quay API OAuth token should be specified by $WERF_QUAY_TOKEN", repoImageTagReference(repoImage))
+	}
+
+	repo, err := parseRepoImageRepository(repoImage)
+	if err != nil {
+		return err
+	}
+
+	u := fmt.Sprintf("%s://%s/api/v1/repository/%s/tag/%s", repo.Registry.Scheme(), repo.RegistryStr(), repo.RepositoryStr(), repoImage.Tag)
+
+	req, err := http.NewRequest(http.MethodDelete, u, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", token))
+
+	if _, err := doApiRequest(&http.Client{Transport: getHttpTransport()}, req, http.StatusNoContent, http.StatusOK); err != nil {
+		return fmt.Errorf("deleting image %q: %v", repoImageTagReference(repoImage), err)
+	}
+
+	return nil
+}
+
+func (r *quay) DeletesOnlyTag() bool {
+	return true
+}
+
+func (r *quay) String() string {
+	return QuayImplementationName
+}
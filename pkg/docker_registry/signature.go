@@ -0,0 +1,185 @@
+package docker_registry
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"strings"
+
+	"github.com/google/go-containerregistry/pkg/authn"
+	"github.com/google/go-containerregistry/pkg/name"
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/empty"
+	"github.com/google/go-containerregistry/pkg/v1/mutate"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+	"github.com/google/go-containerregistry/pkg/v1/types"
+
+	"github.com/flant/werf/pkg/tracing"
+)
+
+const (
+	ImageSignatureTagSuffix       = ".sig"
+	ImageSignatureAnnotation      = "dev.cosignproject.cosign/signature"
+	ImageSignaturePayloadMimeType = "application/vnd.dev.cosign.simplesigning.v1+json"
+)
+
+type ImageSignature struct {
+	Payload   []byte
+	Signature string
+}
+
+// ImageSignatureTag returns cosign-compatible tag of the detached signatures image for the specified manifest digest
+func ImageSignatureTag(digest string) string {
+	return strings.Replace(digest, ":", "-", 1) + ImageSignatureTagSuffix
+}
+
+func ImageRepositoryAndDigest(reference string) (string, string, error) {
+	ref, err := name.ParseReference(reference, parseReferenceOptions()...)
+	if err != nil {
+		return "", "", fmt.Errorf("parsing reference %q: %v", reference, err)
+	}
+
+	digest, err := ImageDigest(reference)
+	if err != nil {
+		return "", "", err
+	}
+
+	return ref.Context().Name(), digest, nil
+}
+
+func ImageSignatures(repository, digest string) ([]ImageSignature, error) {
+	signaturesReference := strings.Join([]string{repository, ImageSignatureTag(digest)}, ":")
+
+	i, _, err := image(signaturesReference)
+	if err != nil {
+		if isNotFoundErr(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	manifest, err := i.Manifest()
+	if err != nil {
+		return nil, err
+	}
+
+	var signatures []ImageSignature
+	for _, desc := range manifest.Layers {
+		signature, hasSignature := desc.Annotations[ImageSignatureAnnotation]
+		if !hasSignature {
+			continue
+		}
+
+		layer, err := i.LayerByDigest(desc.Digest)
+		if err != nil {
+			return nil, err
+		}
+
+		payload, err := readLayerBlob(layer)
+		if err != nil {
+			return nil, fmt.Errorf("reading signature payload %s: %s", desc.Digest, err)
+		}
+
+		signatures = append(signatures, ImageSignature{Payload: payload, Signature: signature})
+	}
+
+	return signatures, nil
+}
+
+func PushImageSignature(repository, digest string, signature ImageSignature) error {
+	signaturesReference := strings.Join([]string{repository, ImageSignatureTag(digest)}, ":")
+
+	ref, err := name.ParseReference(signaturesReference, parseReferenceOptions()...)
+	if err != nil {
+		return fmt.Errorf("parsing reference %q: %v", signaturesReference, err)
+	}
+
+	base := empty.Image
+	existing, _, err := image(signaturesReference)
+	if err != nil {
+		if !isNotFoundErr(err) {
+			return err
+		}
+	} else {
+		base = existing
+	}
+
+	signaturesImage, err := mutate.Append(base, mutate.Addendum{
+		Layer:       newSignaturePayloadLayer(signature.Payload),
+		Annotations: map[string]string{ImageSignatureAnnotation: signature.Signature},
+	})
+	if err != nil {
+		return err
+	}
+
+	span := tracing.StartSpan("docker_registry push image signature", map[string]string{"werf.registry.reference": signaturesReference})
+	err = remote.Write(ref, signaturesImage, remote.WithAuthFromKeychain(authn.DefaultKeychain), remote.WithTransport(getHttpTransport()))
+	span.End(err)
+
+	if err != nil {
+		return fmt.Errorf("writing image %q: %v", ref, err)
+	}
+
+	return nil
+}
+
+func isNotFoundErr(err error) bool {
+	for _, code := range []string{"MANIFEST_UNKNOWN", "NAME_UNKNOWN", "NOT_FOUND"} {
+		if strings.Contains(err.Error(), code) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func readLayerBlob(layer v1.Layer) ([]byte, error) {
+	rc, err := layer.Compressed()
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+
+	return ioutil.ReadAll(rc)
+}
+
+// signaturePayloadLayer is an uncompressed blob layer holding the simple signing payload as is
+type signaturePayloadLayer struct {
+	payload []byte
+	hash    v1.Hash
+}
+
+func newSignaturePayloadLayer(payload []byte) *signaturePayloadLayer {
+	sum := sha256.Sum256(payload)
+	return &signaturePayloadLayer{
+		payload: payload,
+		hash:    v1.Hash{Algorithm: "sha256", Hex: hex.EncodeToString(sum[:])},
+	}
+}
+
+func (l *signaturePayloadLayer) Digest() (v1.Hash, error) {
+	return l.hash, nil
+}
+
+func (l *signaturePayloadLayer) DiffID() (v1.Hash, error) {
+	return l.hash, nil
+}
+
+func (l *signaturePayloadLayer) Compressed() (io.ReadCloser, error) {
+	return ioutil.NopCloser(bytes.NewReader(l.payload)), nil
+}
+
+func (l *signaturePayloadLayer) Uncompressed() (io.ReadCloser, error) {
+	return ioutil.NopCloser(bytes.NewReader(l.payload)), nil
+}
+
+func (l *signaturePayloadLayer) Size() (int64, error) {
+	return int64(len(l.payload)), nil
+}
+
+func (l *signaturePayloadLayer) MediaType() (types.MediaType, error) {
+	return ImageSignaturePayloadMimeType, nil
+}
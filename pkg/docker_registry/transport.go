@@ -0,0 +1,335 @@
+package docker_registry
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"math"
+	"math/rand"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/flant/logboek"
+)
+
+var (
+	httpTransportMutex sync.Mutex
+	httpTransport      http.RoundTripper
+)
+
+// getHttpTransport returns the shared transport, so that the auth tokens are reused across the calls
+func getHttpTransport() http.RoundTripper {
+	httpTransportMutex.Lock()
+	defer httpTransportMutex.Unlock()
+
+	if httpTransport == nil {
+		httpTransport = newTokenCacheTransport(newRetryTransport(newBaseHttpTransport()))
+	}
+
+	return httpTransport
+}
+
+func resetHttpTransport() {
+	httpTransportMutex.Lock()
+	defer httpTransportMutex.Unlock()
+
+	httpTransport = nil
+}
+
+func newBaseHttpTransport() http.RoundTripper {
+	if !SkipTlsVerifyRegistry {
+		return http.DefaultTransport
+	}
+
+	defaultTransport := http.DefaultTransport.(*http.Transport)
+
+	return &http.Transport{
+		Proxy:                 defaultTransport.Proxy,
+		DialContext:           defaultTransport.DialContext,
+		MaxIdleConns:          defaultTransport.MaxIdleConns,
+		IdleConnTimeout:       defaultTransport.IdleConnTimeout,
+		TLSHandshakeTimeout:   defaultTransport.TLSHandshakeTimeout,
+		ExpectContinueTimeout: defaultTransport.ExpectContinueTimeout,
+		TLSClientConfig:       &tls.Config{InsecureSkipVerify: true},
+		TLSNextProto:          make(map[string]func(authority string, c *tls.Conn) http.RoundTripper),
+	}
+}
+
+// retryTransport retries the requests failed by the network errors, rate limits and unavailability of the registry
+// with exponential backoff, Retry-After header of the response is honoured up to the max delay
+type retryTransport struct {
+	inner      http.RoundTripper
+	maxRetries int
+	baseDelay  time.Duration
+	maxDelay   time.Duration
+	sleep      func(ctx context.Context, d time.Duration) error
+}
+
+func newRetryTransport(inner http.RoundTripper) *retryTransport {
+	return &retryTransport{
+		inner:      inner,
+		maxRetries: 6,
+		baseDelay:  500 * time.Millisecond,
+		maxDelay:   time.Minute,
+		sleep:      sleepWithContext,
+	}
+}
+
+func (t *retryTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	for attempt := 0; ; attempt++ {
+		if attempt > 0 && req.GetBody != nil {
+			body, err := req.GetBody()
+			if err != nil {
+				return nil, err
+			}
+
+			req = req.Clone(req.Context())
+			req.Body = body
+		}
+
+		resp, err := t.inner.RoundTrip(req)
+		if attempt == t.maxRetries || !canReplayRequest(req) || !shouldRetry(resp, err) {
+			return resp, err
+		}
+
+		delay := t.backoff(attempt)
+
+		var reason string
+		if err != nil {
+			reason = err.Error()
+		} else {
+			reason = resp.Status
+			if retryAfter, ok := parseRetryAfter(resp.Header.Get("Retry-After")); ok {
+				delay = retryAfter
+				if delay > t.maxDelay {
+					delay = t.maxDelay
+				}
+			}
+
+			_, _ = io.Copy(ioutil.Discard, resp.Body)
+			resp.Body.Close()
+		}
+
+		logboek.LogWarnF("WARNING: %s %s failed: %s, retrying in %s\n", req.Method, req.URL, reason, delay)
+
+		if err := t.sleep(req.Context(), delay); err != nil {
+			return nil, err
+		}
+	}
+}
+
+func (t *retryTransport) backoff(attempt int) time.Duration {
+	delay := t.baseDelay << uint(attempt)
+	if delay <= 0 || delay > t.maxDelay {
+		delay = t.maxDelay
+	}
+
+	// Jitter spreads the retries of the concurrent requests limited at the same time
+	return delay + time.Duration(rand.Int63n(int64(delay)/5+1))
+}
+
+// canReplayRequest checks the request body, the streamed bodies such as blobs uploads cannot be sent again
+func canReplayRequest(req *http.Request) bool {
+	return req.Body == nil || req.Body == http.NoBody || req.GetBody != nil
+}
+
+func shouldRetry(resp *http.Response, err error) bool {
+	if err != nil {
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			return true
+		}
+
+		if netErr, ok := err.(net.Error); ok && (netErr.Timeout() || netErr.Temporary()) {
+			return true
+		}
+
+		return strings.Contains(err.Error(), "connection reset by peer")
+	}
+
+	switch resp.StatusCode {
+	case http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	default:
+		return false
+	}
+}
+
+// parseRetryAfter parses delay seconds or HTTP-date value of the Retry-After header,
+// negative delays, dates in the past and unparseable values are ignored
+func parseRetryAfter(value string) (time.Duration, bool) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return 0, false
+	}
+
+	if seconds, err := strconv.ParseInt(value, 10, 64); err == nil {
+		if seconds < 0 {
+			return 0, false
+		} else if seconds > int64(math.MaxInt64/time.Second) {
+			return math.MaxInt64, true
+		}
+		return time.Duration(seconds) * time.Second, true
+	}
+
+	if date, err := http.ParseTime(value); err == nil {
+		if delay := time.Until(date); delay >= 0 {
+			return delay, true
+		}
+	}
+
+	return 0, false
+}
+
+func sleepWithContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// tokenCacheTransport caches the bearer tokens responses of the registries auth services by the token request and credentials.
+// The auth services are learnt from the challenges of the registries responses
+type tokenCacheTransport struct {
+	inner http.RoundTripper
+	now   func() time.Time
+
+	mutex  sync.Mutex
+	realms map[string]bool
+	tokens map[string]*cachedToken
+}
+
+type cachedToken struct {
+	body      []byte
+	header    http.Header
+	expiresAt time.Time
+}
+
+// tokens are requested again before the expiration, so that the token would not expire during the request
+const tokenExpirationMargin = 10 * time.Second
+
+func newTokenCacheTransport(inner http.RoundTripper) *tokenCacheTransport {
+	return &tokenCacheTransport{
+		inner:  inner,
+		now:    time.Now,
+		realms: map[string]bool{},
+		tokens: map[string]*cachedToken{},
+	}
+}
+
+func (t *tokenCacheTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	isTokenRequest := req.Method == http.MethodGet && t.isRealm(realmOfUrl(req))
+	key := strings.Join([]string{req.URL.String(), req.Header.Get("Authorization")}, "\n")
+
+	if isTokenRequest {
+		if token := t.getToken(key); token != nil {
+			return &http.Response{
+				Status:        "200 OK",
+				StatusCode:    http.StatusOK,
+				Proto:         "HTTP/1.1",
+				ProtoMajor:    1,
+				ProtoMinor:    1,
+				Header:        token.header.Clone(),
+				Body:          ioutil.NopCloser(bytes.NewReader(token.body)),
+				ContentLength: int64(len(token.body)),
+				Request:       req,
+			}, nil
+		}
+	}
+
+	resp, err := t.inner.RoundTrip(req)
+	if err != nil {
+		return resp, err
+	}
+
+	if resp.StatusCode == http.StatusUnauthorized {
+		if realm := parseBearerRealm(resp.Header.Get("WWW-Authenticate")); realm != "" {
+			t.mutex.Lock()
+			t.realms[realm] = true
+			t.mutex.Unlock()
+		}
+	}
+
+	if isTokenRequest && resp.StatusCode == http.StatusOK {
+		body, err := ioutil.ReadAll(resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		resp.Body = ioutil.NopCloser(bytes.NewReader(body))
+
+		var token struct {
+			ExpiresIn int `json:"expires_in"`
+		}
+		if err := json.Unmarshal(body, &token); err == nil {
+			// Token lifetime defaults to 60 seconds by the Docker Registry token specification
+			expiresIn := 60 * time.Second
+			if token.ExpiresIn > 0 {
+				expiresIn = time.Duration(token.ExpiresIn) * time.Second
+			}
+
+			t.mutex.Lock()
+			t.tokens[key] = &cachedToken{body: body, header: resp.Header.Clone(), expiresAt: t.now().Add(expiresIn - tokenExpirationMargin)}
+			t.mutex.Unlock()
+		}
+	}
+
+	return resp, nil
+}
+
+func (t *tokenCacheTransport) isRealm(realm string) bool {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	return t.realms[realm]
+}
+
+func (t *tokenCacheTransport) getToken(key string) *cachedToken {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	token, ok := t.tokens[key]
+	if !ok {
+		return nil
+	}
+
+	if t.now().After(token.expiresAt) {
+		delete(t.tokens, key)
+		return nil
+	}
+
+	return token
+}
+
+func realmOfUrl(req *http.Request) string {
+	u := *req.URL
+	u.RawQuery = ""
+	u.Fragment = ""
+	return u.String()
+}
+
+func parseBearerRealm(challenge string) string {
+	if !strings.HasPrefix(strings.ToLower(challenge), "bearer ") {
+		return ""
+	}
+
+	for _, param := range strings.Split(challenge[len("bearer "):], ",") {
+		parts := strings.SplitN(strings.TrimSpace(param), "=", 2)
+		if len(parts) == 2 && strings.ToLower(parts[0]) == "realm" {
+			return strings.Trim(parts[1], `"`)
+		}
+	}
+
+	return ""
+}
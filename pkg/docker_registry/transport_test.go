@@ -0,0 +1,228 @@
+package docker_registry
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func newTestRetryTransport(delays *[]time.Duration) *retryTransport {
+	t := newRetryTransport(http.DefaultTransport)
+	t.maxRetries = 3
+	t.sleep = func(_ context.Context, d time.Duration) error {
+		*delays = append(*delays, d)
+		return nil
+	}
+	return t
+}
+
+func TestRetryTransport(t *testing.T) {
+	var requests int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+
+		switch n := atomic.AddInt32(&requests, 1); n {
+		case 1:
+			w.Header().Set("Retry-After", "7")
+			w.WriteHeader(http.StatusTooManyRequests)
+		case 2:
+			w.WriteHeader(http.StatusServiceUnavailable)
+		default:
+			fmt.Fprintf(w, "%s", body)
+		}
+	}))
+	defer server.Close()
+
+	var delays []time.Duration
+	client := &http.Client{Transport: newTestRetryTransport(&delays)}
+
+	resp, err := client.Post(server.URL, "text/plain", strings.NewReader("payload"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	body, _ := ioutil.ReadAll(resp.Body)
+	if resp.StatusCode != http.StatusOK || string(body) != "payload" {
+		t.Errorf("response = %s %q, want 200 with replayed payload", resp.Status, body)
+	}
+
+	if len(delays) != 2 {
+		t.Fatalf("retries = %d, want 2", len(delays))
+	}
+	if delays[0] != 7*time.Second {
+		t.Errorf("first delay = %s, want 7s of Retry-After", delays[0])
+	}
+	if delays[1] < time.Second || delays[1] > 1200*time.Millisecond {
+		t.Errorf("second delay = %s, want exponential backoff 1s with jitter", delays[1])
+	}
+}
+
+func TestRetryTransport_Limits(t *testing.T) {
+	var requests int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		if r.URL.Path == "/missing" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.WriteHeader(http.StatusTooManyRequests)
+	}))
+	defer server.Close()
+
+	for _, tt := range []struct {
+		name             string
+		do               func(client *http.Client) (*http.Response, error)
+		expectedStatus   int
+		expectedRequests int32
+	}{
+		{
+			name:             "not retryable status",
+			do:               func(client *http.Client) (*http.Response, error) { return client.Get(server.URL + "/missing") },
+			expectedStatus:   http.StatusNotFound,
+			expectedRequests: 1,
+		},
+		{
+			name:             "max retries",
+			do:               func(client *http.Client) (*http.Response, error) { return client.Get(server.URL) },
+			expectedStatus:   http.StatusTooManyRequests,
+			expectedRequests: 4,
+		},
+		{
+			name: "streamed body",
+			do: func(client *http.Client) (*http.Response, error) {
+				return client.Post(server.URL, "application/octet-stream", ioutil.NopCloser(strings.NewReader("blob")))
+			},
+			expectedStatus:   http.StatusTooManyRequests,
+			expectedRequests: 1,
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			atomic.StoreInt32(&requests, 0)
+
+			var delays []time.Duration
+			resp, err := tt.do(&http.Client{Transport: newTestRetryTransport(&delays)})
+			if err != nil {
+				t.Fatal(err)
+			}
+			resp.Body.Close()
+
+			if resp.StatusCode != tt.expectedStatus {
+				t.Errorf("status = %d, want %d", resp.StatusCode, tt.expectedStatus)
+			}
+			if n := atomic.LoadInt32(&requests); n != tt.expectedRequests {
+				t.Errorf("requests = %d, want %d", n, tt.expectedRequests)
+			}
+		})
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	if d, ok := parseRetryAfter("120"); !ok || d != 2*time.Minute {
+		t.Errorf("parseRetryAfter(120) = %s, %v, want 2m", d, ok)
+	}
+
+	date := time.Now().Add(time.Hour).UTC().Format(http.TimeFormat)
+	if d, ok := parseRetryAfter(date); !ok || d < 59*time.Minute || d > time.Hour {
+		t.Errorf("parseRetryAfter(%s) = %s, %v, want about 1h", date, d, ok)
+	}
+
+	for _, value := range []string{"soon", "-5", "1.5", time.Now().Add(-time.Hour).UTC().Format(http.TimeFormat)} {
+		if _, ok := parseRetryAfter(value); ok {
+			t.Errorf("parseRetryAfter(%s) should be ignored", value)
+		}
+	}
+}
+
+func TestRetryTransport_RetryAfterIsClamped(t *testing.T) {
+	var requests int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch atomic.AddInt32(&requests, 1) {
+		case 1:
+			w.Header().Set("Retry-After", "86400")
+			w.WriteHeader(http.StatusTooManyRequests)
+		case 2:
+			w.Header().Set("Retry-After", "-1")
+			w.WriteHeader(http.StatusTooManyRequests)
+		}
+	}))
+	defer server.Close()
+
+	var delays []time.Duration
+	transport := newTestRetryTransport(&delays)
+	transport.maxDelay = 10 * time.Second
+
+	resp, err := (&http.Client{Transport: transport}).Get(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+
+	if len(delays) != 2 {
+		t.Fatalf("retries = %d, want 2", len(delays))
+	}
+	if delays[0] != transport.maxDelay {
+		t.Errorf("first delay = %s, want Retry-After clamped to %s", delays[0], transport.maxDelay)
+	}
+	if delays[1] < time.Second || delays[1] > 1200*time.Millisecond {
+		t.Errorf("second delay = %s, want exponential backoff 1s with jitter instead of negative Retry-After", delays[1])
+	}
+}
+
+func TestTokenCacheTransport(t *testing.T) {
+	var tokenRequests int32
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/token":
+			atomic.AddInt32(&tokenRequests, 1)
+			fmt.Fprintf(w, `{"token": "token-%s", "expires_in": 300}`, r.URL.Query().Get("scope"))
+		default:
+			w.Header().Set("WWW-Authenticate", fmt.Sprintf(`Bearer realm="%s/token",service="registry"`, server.URL))
+			w.WriteHeader(http.StatusUnauthorized)
+		}
+	}))
+	defer server.Close()
+
+	now := time.Now()
+	transport := newTokenCacheTransport(http.DefaultTransport)
+	transport.now = func() time.Time { return now }
+	client := &http.Client{Transport: transport}
+
+	get := func(url string) string {
+		resp, err := client.Get(url)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+
+		body, _ := ioutil.ReadAll(resp.Body)
+		return string(body)
+	}
+
+	get(server.URL + "/v2/")
+
+	for i := 0; i < 3; i++ {
+		if body := get(server.URL + "/token?scope=pull"); !strings.Contains(body, "token-pull") {
+			t.Fatalf("token response = %s, want token-pull", body)
+		}
+	}
+	get(server.URL + "/token?scope=push")
+
+	if n := atomic.LoadInt32(&tokenRequests); n != 2 {
+		t.Errorf("token requests = %d, want 2: one per scope", n)
+	}
+
+	now = now.Add(5 * time.Minute)
+	get(server.URL + "/token?scope=pull")
+
+	if n := atomic.LoadInt32(&tokenRequests); n != 3 {
+		t.Errorf("token requests = %d, want 3: expired token should be requested again", n)
+	}
+}
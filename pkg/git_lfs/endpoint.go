@@ -0,0 +1,92 @@
+package git_lfs
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os/exec"
+	"regexp"
+	"strings"
+)
+
+var scpLikeUrlRegexp = regexp.MustCompile(`^(?:([^@/]+)@)?([^:/]+):(.+)$`)
+
+// endpoint is the lfs server api url with additional headers (e.g. authorization of ssh git-lfs-authenticate)
+type endpoint struct {
+	Href   string            `json:"href"`
+	Header map[string]string `json:"header"`
+}
+
+type sshRemote struct {
+	User, Host, Port, Path string
+}
+
+// getEndpoint derives lfs server url from the git remote url as git lfs does:
+// https://HOST/PATH.git => https://HOST/PATH.git/info/lfs, git@HOST:PATH.git => https://HOST/PATH.git/info/lfs
+func getEndpoint(remoteUrl string) (string, *sshRemote, error) {
+	if u, err := url.Parse(remoteUrl); err == nil && u.Scheme != "" && u.Host != "" {
+		switch u.Scheme {
+		case "http", "https":
+			u.User = nil
+			return lfsUrl(u.Scheme, u.Host, u.Path), nil, nil
+		case "ssh", "git+ssh", "ssh+git":
+			remote := &sshRemote{Host: u.Hostname(), Port: u.Port(), Path: strings.TrimPrefix(u.Path, "/")}
+			if u.User != nil {
+				remote.User = u.User.Username()
+			}
+			return lfsUrl("https", u.Hostname(), u.Path), remote, nil
+		default:
+			return "", nil, fmt.Errorf("unsupported git remote url scheme %q: specify the lfs server url explicitly", u.Scheme)
+		}
+	}
+
+	if m := scpLikeUrlRegexp.FindStringSubmatch(remoteUrl); m != nil {
+		remote := &sshRemote{User: m[1], Host: m[2], Path: m[3]}
+		return lfsUrl("https", remote.Host, remote.Path), remote, nil
+	}
+
+	return "", nil, fmt.Errorf("unable to derive lfs server url from git remote url %q: specify the lfs server url explicitly", remoteUrl)
+}
+
+func lfsUrl(scheme, host, path string) string {
+	path = strings.Trim(path, "/")
+	if !strings.HasSuffix(path, ".git") {
+		path += ".git"
+	}
+
+	return fmt.Sprintf("%s://%s/%s/info/lfs", scheme, host, path)
+}
+
+// sshAuthenticate requests the lfs server url and authorization header by the ssh git-lfs-authenticate command, which is supported by GitHub, GitLab and Bitbucket
+func sshAuthenticate(remote *sshRemote, sshAuthSock string) (*endpoint, error) {
+	args := []string{"-o", "BatchMode=yes"}
+	if sshAuthSock != "" {
+		args = append(args, "-o", fmt.Sprintf("IdentityAgent=%s", sshAuthSock))
+	}
+	if remote.Port != "" {
+		args = append(args, "-p", remote.Port)
+	}
+
+	host := remote.Host
+	if remote.User != "" {
+		host = fmt.Sprintf("%s@%s", remote.User, remote.Host)
+	}
+	args = append(args, host, "git-lfs-authenticate", remote.Path, "download")
+
+	var stderr bytes.Buffer
+	cmd := exec.Command("ssh", args...)
+	cmd.Stderr = &stderr
+
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("ssh %s failed: %s\n%s", strings.Join(args, " "), err, stderr.String())
+	}
+
+	e := &endpoint{}
+	if err := json.Unmarshal(output, e); err != nil {
+		return nil, fmt.Errorf("unexpected git-lfs-authenticate output: %s", err)
+	}
+
+	return e, nil
+}
@@ -0,0 +1,66 @@
+package git_lfs
+
+import (
+	"bytes"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+const (
+	PointerVersion = "https://git-lfs.github.com/spec/v1"
+	// MaxPointerSize limits the size of files that are checked for the pointer
+	MaxPointerSize = 1024
+)
+
+var oidRegexp = regexp.MustCompile(`^[0-9a-f]{64}$`)
+
+// Pointer is the git lfs pointer file, which is stored in git instead of the file content
+type Pointer struct {
+	Oid  string
+	Size int64
+}
+
+// ParsePointer parses the pointer file data: version line goes first, keys are sorted
+//
+//	version https://git-lfs.github.com/spec/v1
+//	oid sha256:4d7a214614ab2935c943f9e0ff69d22eadbb8f32b1258daaa5e2ca24d17e2393
+//	size 12345
+func ParsePointer(data []byte) (*Pointer, bool) {
+	if len(data) > MaxPointerSize || !bytes.HasPrefix(data, []byte("version "+PointerVersion+"\n")) {
+		return nil, false
+	}
+
+	p := &Pointer{Size: -1}
+	for _, line := range strings.Split(strings.TrimSuffix(string(data), "\n"), "\n")[1:] {
+		parts := strings.SplitN(line, " ", 2)
+		if len(parts) != 2 {
+			return nil, false
+		}
+
+		switch parts[0] {
+		case "oid":
+			oid := strings.TrimPrefix(parts[1], "sha256:")
+			if !oidRegexp.MatchString(oid) {
+				return nil, false
+			}
+			p.Oid = oid
+		case "size":
+			size, err := strconv.ParseInt(parts[1], 10, 64)
+			if err != nil || size < 0 {
+				return nil, false
+			}
+			p.Size = size
+		}
+	}
+
+	if p.Oid == "" || p.Size == -1 {
+		return nil, false
+	}
+
+	return p, true
+}
+
+func (p *Pointer) objectRelPath() string {
+	return strings.Join([]string{p.Oid[0:2], p.Oid[2:4], p.Oid}, "/")
+}
@@ -0,0 +1,49 @@
+package git_lfs
+
+import "testing"
+
+func TestParsePointer(t *testing.T) {
+	oid := "4d7a214614ab2935c943f9e0ff69d22eadbb8f32b1258daaa5e2ca24d17e2393"
+
+	tests := []struct {
+		name    string
+		data    string
+		pointer *Pointer
+	}{
+		{
+			name:    "pointer",
+			data:    "version https://git-lfs.github.com/spec/v1\noid sha256:" + oid + "\nsize 12345\n",
+			pointer: &Pointer{Oid: oid, Size: 12345},
+		},
+		{
+			name:    "pointer with extension",
+			data:    "version https://git-lfs.github.com/spec/v1\next-0-foo sha256:" + oid + "\noid sha256:" + oid + "\nsize 0\n",
+			pointer: &Pointer{Oid: oid, Size: 0},
+		},
+		{
+			name: "text file",
+			data: "version 1\n",
+		},
+		{
+			name: "pointer without size",
+			data: "version https://git-lfs.github.com/spec/v1\noid sha256:" + oid + "\n",
+		},
+		{
+			name: "pointer with bad oid",
+			data: "version https://git-lfs.github.com/spec/v1\noid sha256:1234\nsize 1\n",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			pointer, ok := ParsePointer([]byte(test.data))
+			if ok != (test.pointer != nil) {
+				t.Fatalf("expected pointer %v, got %v", test.pointer, pointer)
+			}
+
+			if ok && *pointer != *test.pointer {
+				t.Fatalf("expected pointer %v, got %v", test.pointer, pointer)
+			}
+		})
+	}
+}
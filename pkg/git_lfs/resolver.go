@@ -0,0 +1,365 @@
+package git_lfs
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/flant/logboek"
+
+	"github.com/flant/werf/pkg/werf"
+)
+
+const lfsMediaType = "application/vnd.git-lfs+json"
+
+// Resolver finds lfs objects in the local lfs store of the repository (git lfs fetch) and in the werf cache,
+// missing objects are downloaded from the lfs server by the batch api into the werf cache
+type Resolver struct {
+	// GitDir is the repository git dir with lfs/objects store
+	GitDir string
+	// RemoteUrl is the git remote url to derive the lfs server url
+	RemoteUrl string
+	// Url is the lfs server url, which overrides url derived from RemoteUrl
+	Url string
+
+	Username    string
+	PasswordEnv string
+	SSHAuthSock string
+
+	endpoint *endpoint
+}
+
+func GetObjectsCacheDir() string {
+	return filepath.Join(werf.GetLocalCacheDir(), "git_lfs", "objects")
+}
+
+// FetchObjects returns paths of the objects files by oid
+func (r *Resolver) FetchObjects(pointers []*Pointer) (map[string]string, error) {
+	res := map[string]string{}
+
+	var missingPointers []*Pointer
+	for _, p := range pointers {
+		if _, ok := res[p.Oid]; ok {
+			continue
+		}
+
+		path, err := r.findObject(p)
+		if err != nil {
+			return nil, err
+		}
+
+		if path != "" {
+			res[p.Oid] = path
+		} else {
+			res[p.Oid] = ""
+			missingPointers = append(missingPointers, p)
+		}
+	}
+
+	if len(missingPointers) != 0 {
+		if err := r.download(missingPointers); err != nil {
+			return nil, err
+		}
+
+		for _, p := range missingPointers {
+			res[p.Oid] = filepath.Join(GetObjectsCacheDir(), p.objectRelPath())
+		}
+	}
+
+	return res, nil
+}
+
+func (r *Resolver) objectsDirs() []string {
+	var dirs []string
+	if r.GitDir != "" {
+		dirs = append(dirs, filepath.Join(r.GitDir, "lfs", "objects"))
+	}
+
+	// objects of the local repository are available without the lfs server
+	if u, err := url.Parse(r.RemoteUrl); err == nil && u.Scheme == "file" {
+		dirs = append(dirs, filepath.Join(u.Path, "lfs", "objects"), filepath.Join(u.Path, ".git", "lfs", "objects"))
+	}
+
+	return append(dirs, GetObjectsCacheDir())
+}
+
+func (r *Resolver) findObject(p *Pointer) (string, error) {
+	for _, dir := range r.objectsDirs() {
+		path := filepath.Join(dir, p.objectRelPath())
+
+		info, err := os.Stat(path)
+		if os.IsNotExist(err) {
+			continue
+		} else if err != nil {
+			return "", fmt.Errorf("unable to stat lfs object %s: %s", path, err)
+		}
+
+		if info.Size() != p.Size {
+			continue
+		}
+
+		// the object may be truncated or corrupted in the store, which is modified outside of werf
+		if oid, err := fileSha256(path); err != nil {
+			return "", fmt.Errorf("unable to read lfs object %s: %s", path, err)
+		} else if oid != p.Oid {
+			logboek.Default.LogFDetails("Lfs object %s checksum %s mismatch\n", path, oid)
+			continue
+		}
+
+		return path, nil
+	}
+
+	return "", nil
+}
+
+func fileSha256(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	hash := sha256.New()
+	if _, err := io.Copy(hash, f); err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+type batchRequest struct {
+	Operation string         `json:"operation"`
+	Transfers []string       `json:"transfers"`
+	Objects   []*batchObject `json:"objects"`
+}
+
+type batchObject struct {
+	Oid     string               `json:"oid"`
+	Size    int64                `json:"size"`
+	Actions map[string]*endpoint `json:"actions,omitempty"`
+	Error   *struct {
+		Code    int    `json:"code"`
+		Message string `json:"message"`
+	} `json:"error,omitempty"`
+}
+
+type batchResponse struct {
+	Objects []*batchObject `json:"objects"`
+}
+
+func (r *Resolver) download(pointers []*Pointer) error {
+	request := &batchRequest{Operation: "download", Transfers: []string{"basic"}}
+	for _, p := range pointers {
+		request.Objects = append(request.Objects, &batchObject{Oid: p.Oid, Size: p.Size})
+	}
+
+	body, err := json.Marshal(request)
+	if err != nil {
+		return err
+	}
+
+	e, err := r.getEndpoint()
+	if err != nil {
+		return err
+	}
+
+	logboek.Default.LogFDetails("Download %d lfs objects from %s\n", len(pointers), e.Href)
+
+	response := &batchResponse{}
+	if err := r.doRequest(http.MethodPost, e.Href+"/objects/batch", e.Header, true, body, func(resp *http.Response) error {
+		return json.NewDecoder(resp.Body).Decode(response)
+	}); err != nil {
+		return fmt.Errorf("lfs batch request failed: %s", err)
+	}
+
+	requestedPointers := map[string]*Pointer{}
+	for _, p := range pointers {
+		requestedPointers[p.Oid] = p
+	}
+
+	for _, object := range response.Objects {
+		p, ok := requestedPointers[object.Oid]
+		if !ok {
+			continue
+		}
+		delete(requestedPointers, object.Oid)
+
+		if object.Error != nil {
+			return fmt.Errorf("unable to download lfs object %s: %d %s", object.Oid, object.Error.Code, object.Error.Message)
+		}
+
+		action, ok := object.Actions["download"]
+		if !ok {
+			return fmt.Errorf("unable to download lfs object %s: no download action in lfs server response", object.Oid)
+		}
+
+		if err := r.downloadObject(p, action); err != nil {
+			return fmt.Errorf("unable to download lfs object %s: %s", object.Oid, err)
+		}
+	}
+
+	for _, p := range pointers {
+		if _, ok := requestedPointers[p.Oid]; ok {
+			return fmt.Errorf("unable to download lfs object %s: no object in lfs server response", p.Oid)
+		}
+	}
+
+	return nil
+}
+
+func (r *Resolver) downloadObject(p *Pointer, action *endpoint) error {
+	path := filepath.Join(GetObjectsCacheDir(), p.objectRelPath())
+	if err := os.MkdirAll(filepath.Dir(path), os.ModePerm); err != nil {
+		return err
+	}
+
+	f, err := ioutil.TempFile(filepath.Dir(path), "tmp-")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+
+	hash := sha256.New()
+	if err := r.doRequest(http.MethodGet, action.Href, action.Header, false, nil, func(resp *http.Response) error {
+		_, err := io.Copy(io.MultiWriter(f, hash), resp.Body)
+		return err
+	}); err != nil {
+		f.Close()
+		return err
+	}
+
+	if err := f.Close(); err != nil {
+		return err
+	}
+
+	if oid := hex.EncodeToString(hash.Sum(nil)); oid != p.Oid {
+		return fmt.Errorf("downloaded object checksum %s mismatch", oid)
+	}
+
+	return os.Rename(f.Name(), path)
+}
+
+func (r *Resolver) getEndpoint() (*endpoint, error) {
+	if r.endpoint != nil {
+		return r.endpoint, nil
+	}
+
+	if r.Url != "" {
+		r.endpoint = &endpoint{Href: strings.TrimSuffix(r.Url, "/")}
+		return r.endpoint, nil
+	}
+
+	href, sshRemote, err := getEndpoint(r.RemoteUrl)
+	if err != nil {
+		return nil, err
+	}
+
+	r.endpoint = &endpoint{Href: href}
+
+	if sshRemote != nil && r.PasswordEnv == "" {
+		if e, err := sshAuthenticate(sshRemote, r.SSHAuthSock); err != nil {
+			logboek.LogErrorF("WARNING: ssh git-lfs-authenticate failed, %s is used without authorization: %s\n", href, err)
+		} else {
+			r.endpoint = e
+		}
+	}
+
+	return r.endpoint, nil
+}
+
+// doRequest retries request unauthorized by the lfs server with credentials of the git credential helper.
+// Credentials are sent only to the lfs server endpoint (withCredentials), the actions hrefs may point to the other hosts
+// (e.g. object storage) and are authorized only by the action header returned by the lfs server
+func (r *Resolver) doRequest(method, href string, header map[string]string, withCredentials bool, body []byte, handleResponse func(resp *http.Response) error) error {
+	var username, password string
+	if withCredentials && r.PasswordEnv != "" {
+		username, password = r.Username, os.Getenv(r.PasswordEnv)
+	}
+
+	client := &http.Client{Timeout: 30 * time.Minute}
+
+	for attempt := 0; ; attempt++ {
+		req, err := http.NewRequest(method, href, bytes.NewReader(body))
+		if err != nil {
+			return err
+		}
+
+		req.Header.Set("Accept", lfsMediaType)
+		if body != nil {
+			req.Header.Set("Content-Type", lfsMediaType)
+		}
+		for key, value := range header {
+			req.Header.Set(key, value)
+		}
+		if password != "" {
+			req.SetBasicAuth(username, password)
+		}
+
+		resp, err := client.Do(req)
+		if err != nil {
+			return err
+		}
+
+		if resp.StatusCode == http.StatusUnauthorized && withCredentials && attempt == 0 && password == "" && header["Authorization"] == "" {
+			resp.Body.Close()
+
+			if username, password, err = gitCredentialFill(href); err != nil {
+				return err
+			}
+
+			continue
+		}
+
+		err = func() error {
+			defer resp.Body.Close()
+
+			if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+				data, _ := ioutil.ReadAll(io.LimitReader(resp.Body, 4096))
+				return fmt.Errorf("%s %s: unexpected response %s: %s", method, href, resp.Status, strings.TrimSpace(string(data)))
+			}
+
+			return handleResponse(resp)
+		}()
+
+		return err
+	}
+}
+
+func gitCredentialFill(href string) (string, string, error) {
+	u, err := url.Parse(href)
+	if err != nil {
+		return "", "", err
+	}
+
+	cmd := exec.Command("git", "credential", "fill")
+	cmd.Env = append(os.Environ(), "GIT_TERMINAL_PROMPT=0")
+	cmd.Stdin = strings.NewReader(fmt.Sprintf("protocol=%s\nhost=%s\npath=%s\n\n", u.Scheme, u.Host, strings.TrimPrefix(u.Path, "/")))
+
+	output, err := cmd.Output()
+	if err != nil {
+		return "", "", fmt.Errorf("no credentials for %s: git credential fill failed: %s", u.Host, err)
+	}
+
+	var username, password string
+	for _, line := range strings.Split(string(output), "\n") {
+		switch {
+		case strings.HasPrefix(line, "username="):
+			username = strings.TrimPrefix(line, "username=")
+		case strings.HasPrefix(line, "password="):
+			password = strings.TrimPrefix(line, "password=")
+		}
+	}
+
+	return username, password, nil
+}
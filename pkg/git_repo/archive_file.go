@@ -30,3 +30,7 @@ func (a *ArchiveFile) GetType() ArchiveType {
 func (a *ArchiveFile) IsEmpty() bool {
 	return a.Descriptor.IsEmpty
 }
+
+func (a *ArchiveFile) GetLfsOids() []string {
+	return a.Descriptor.LfsOids
+}
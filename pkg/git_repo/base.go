@@ -18,8 +18,11 @@ import (
 	"gopkg.in/src-d/go-git.v4/storage/filesystem"
 
 	"github.com/flant/logboek"
+	"github.com/flant/werf/pkg/git_lfs"
 	"github.com/flant/werf/pkg/git_repo/ls_tree"
+	"github.com/flant/werf/pkg/logging"
 	"github.com/flant/werf/pkg/path_matcher"
+	"github.com/flant/werf/pkg/tracing"
 	"github.com/flant/werf/pkg/true_git"
 )
 
@@ -212,12 +215,14 @@ func (repo *Base) createPatch(repoPath, gitDir, workTreeCacheDir string, opts Pa
 		WithBinary:            opts.WithBinary,
 	}
 
+	span := tracing.StartSpan("git patch", map[string]string{"werf.git.repo": repo.Name, "werf.git.from_commit": opts.FromCommit, "werf.git.to_commit": opts.ToCommit})
 	var desc *true_git.PatchDescriptor
 	if hasSubmodules {
 		desc, err = true_git.PatchWithSubmodules(fileHandler, gitDir, workTreeCacheDir, patchOpts)
 	} else {
 		desc, err = true_git.Patch(fileHandler, gitDir, patchOpts)
 	}
+	span.End(err)
 
 	if err != nil {
 		return nil, fmt.Errorf("error creating patch between `%s` and `%s` commits: %s", opts.FromCommit, opts.ToCommit, err)
@@ -244,7 +249,7 @@ func HasSubmodulesInCommit(commit *object.Commit) (bool, error) {
 	return true, nil
 }
 
-func (repo *Base) createArchive(repoPath, gitDir, workTreeCacheDir string, opts ArchiveOptions) (Archive, error) {
+func (repo *Base) createArchive(repoPath, gitDir, workTreeCacheDir string, opts ArchiveOptions, lfsResolver *git_lfs.Resolver) (Archive, error) {
 	repository, err := git.PlainOpen(repoPath)
 	if err != nil {
 		return nil, fmt.Errorf("cannot open repo `%s`: %s", repoPath, err)
@@ -282,12 +287,18 @@ func (repo *Base) createArchive(repoPath, gitDir, workTreeCacheDir string, opts
 		),
 	}
 
+	if lfsResolver != nil {
+		archiveOpts.LfsResolver = lfsResolver
+	}
+
+	span := tracing.StartSpan("git archive", map[string]string{"werf.git.repo": repo.Name, "werf.git.commit": opts.Commit})
 	var desc *true_git.ArchiveDescriptor
 	if hasSubmodules {
 		desc, err = true_git.ArchiveWithSubmodules(fileHandler, gitDir, workTreeCacheDir, archiveOpts)
 	} else {
 		desc, err = true_git.Archive(fileHandler, gitDir, workTreeCacheDir, archiveOpts)
 	}
+	span.End(err)
 
 	if err != nil {
 		return nil, fmt.Errorf("error creating archive for commit `%s`: %s", opts.Commit, err)
@@ -320,6 +331,111 @@ func (repo *Base) isCommitExists(repoPath,
 	return true, nil
 }
 
+func (repo *Base) commitTree(repoPath, commit string) (*object.Tree, error) {
+	repository, err := git.PlainOpen(repoPath)
+	if err != nil {
+		return nil, fmt.Errorf("cannot open repo `%s`: %s", repoPath, err)
+	}
+
+	commitHash, err := newHash(commit)
+	if err != nil {
+		return nil, fmt.Errorf("bad commit hash `%s`: %s", commit, err)
+	}
+
+	commitObj, err := repository.CommitObject(commitHash)
+	if err != nil {
+		return nil, fmt.Errorf("bad commit `%s`: %s", commit, err)
+	}
+
+	tree, err := commitObj.Tree()
+	if err != nil {
+		return nil, fmt.Errorf("cannot get commit `%s` tree: %s", commit, err)
+	}
+
+	return tree, nil
+}
+
+// readCommitFile returns content and blob hash of the commit file
+func (repo *Base) readCommitFile(repoPath, commit, filePath string) ([]byte, string, error) {
+	tree, err := repo.commitTree(repoPath, commit)
+	if err != nil {
+		return nil, "", err
+	}
+
+	file, err := tree.File(strings.Trim(filePath, "/"))
+	if err != nil {
+		return nil, "", fmt.Errorf("cannot get file `%s` of commit `%s`: %s", filePath, commit, err)
+	}
+
+	content, err := file.Contents()
+	if err != nil {
+		return nil, "", fmt.Errorf("cannot read file `%s` of commit `%s`: %s", filePath, commit, err)
+	}
+
+	return []byte(content), file.Hash.String(), nil
+}
+
+func (repo *Base) readCommitFiles(repoPath, commit, basePath string, filter func(relPath string) bool) (map[string][]byte, error) {
+	res := map[string][]byte{}
+	err := repo.forEachCommitFile(repoPath, commit, basePath, filter, func(file *object.File) error {
+		content, err := file.Contents()
+		if err != nil {
+			return fmt.Errorf("cannot read file `%s` of commit `%s`: %s", file.Name, commit, err)
+		}
+
+		res[file.Name] = []byte(content)
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return res, nil
+}
+
+func (repo *Base) readCommitFilesModes(repoPath, commit, basePath string, filter func(relPath string) bool) (map[string]os.FileMode, error) {
+	res := map[string]os.FileMode{}
+	err := repo.forEachCommitFile(repoPath, commit, basePath, filter, func(file *object.File) error {
+		mode, err := file.Mode.ToOSFileMode()
+		if err != nil {
+			return fmt.Errorf("bad mode of file `%s` of commit `%s`: %s", file.Name, commit, err)
+		}
+
+		res[file.Name] = mode
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return res, nil
+}
+
+func (repo *Base) forEachCommitFile(repoPath, commit, basePath string, filter func(relPath string) bool, f func(file *object.File) error) error {
+	tree, err := repo.commitTree(repoPath, commit)
+	if err != nil {
+		return err
+	}
+
+	basePath = strings.Trim(basePath, "/")
+	if basePath != "" {
+		tree, err = tree.Tree(basePath)
+		if err != nil {
+			return fmt.Errorf("cannot get path `%s` of commit `%s`: %s", basePath, commit, err)
+		}
+	}
+
+	return tree.Files().ForEach(func(file *object.File) error {
+		if !file.Mode.IsFile() || !filter(file.Name) {
+			return nil
+		}
+
+		return f(file)
+	})
+}
+
 func (repo *Base) tagsList(repoPath string) ([]string, error) {
 	repository, err := git.PlainOpen(repoPath)
 	if err != nil {
@@ -425,7 +541,7 @@ func (repo *Base) checksumWithLsTree(repoPath, gitDir, workTreeCacheDir string,
 
 		var mainLsTreeResult *ls_tree.Result
 		processMsg := fmt.Sprintf("ls-tree (%s)", pathMatcher.String())
-		if err := logboek.Debug.LogProcess(
+		if err := logging.LevelLogProcess(logboek.Debug,
 			processMsg,
 			logboek.LevelLogProcessOptions{},
 			func() error {
@@ -456,7 +572,7 @@ func (repo *Base) checksumWithLsTree(repoPath, gitDir, workTreeCacheDir string,
 			var pathChecksum string
 			if !pathLsTreeResult.IsEmpty() {
 				blockMsg := fmt.Sprintf("ls-tree result checksum (%s)", pathMatcher.String())
-				_ = logboek.Debug.LogBlock(blockMsg, logboek.LevelLogBlockOptions{}, func() error {
+				_ = logging.LevelLogBlock(logboek.Debug, blockMsg, logboek.LevelLogBlockOptions{}, func() error {
 					pathChecksum = pathLsTreeResult.Checksum()
 					logboek.Debug.LogLn()
 					logboek.Debug.LogLn(pathChecksum)
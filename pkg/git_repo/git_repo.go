@@ -19,6 +19,13 @@ type PatchOptions struct {
 type ArchiveOptions struct {
 	FilterOptions
 	Commit string
+	// Lfs enables replacing of git lfs pointers with the objects content
+	Lfs *LfsOptions
+}
+
+type LfsOptions struct {
+	// Url of the lfs server, by default it is derived from the remote url
+	Url string
 }
 
 type ChecksumOptions struct {
@@ -64,12 +71,16 @@ type Patch interface {
 	HasBinary() bool
 	GetPaths() []string
 	GetBinaryPaths() []string
+	HasLfs() bool
+	GetLfsOids() []string
+	GetLfsSize() int64
 }
 
 type Archive interface {
 	GetFilePath() string
 	GetType() ArchiveType
 	IsEmpty() bool
+	GetLfsOids() []string
 }
 
 type Checksum interface {
@@ -2,6 +2,7 @@ package git_repo
 
 import (
 	"fmt"
+	"os"
 	"path/filepath"
 	"strings"
 
@@ -11,9 +12,12 @@ import (
 
 	"github.com/flant/logboek"
 
+	"github.com/flant/werf/pkg/git_lfs"
 	"github.com/flant/werf/pkg/git_repo/ls_tree"
 	"github.com/flant/werf/pkg/git_repo/status"
+	"github.com/flant/werf/pkg/logging"
 	"github.com/flant/werf/pkg/path_matcher"
+	"github.com/flant/werf/pkg/ssh_agent"
 	"github.com/flant/werf/pkg/true_git"
 	"github.com/flant/werf/pkg/util"
 )
@@ -110,11 +114,27 @@ func (repo *Local) CreatePatch(opts PatchOptions) (Patch, error) {
 }
 
 func (repo *Local) CreateArchive(opts ArchiveOptions) (Archive, error) {
-	return repo.createArchive(repo.Path, repo.GitDir, repo.getRepoWorkTreeCacheDir(), opts)
+	return repo.createArchive(repo.Path, repo.GitDir, repo.getRepoWorkTreeCacheDir(), opts, repo.getLfsResolver(opts.Lfs))
+}
+
+func (repo *Local) getLfsResolver(opts *LfsOptions) *git_lfs.Resolver {
+	if opts == nil {
+		return nil
+	}
+
+	// objects are fetched by git lfs into the local store or downloaded from the origin lfs server
+	remoteUrl, _ := repo.RemoteOriginUrl()
+
+	return &git_lfs.Resolver{
+		GitDir:      repo.GitDir,
+		RemoteUrl:   remoteUrl,
+		Url:         opts.Url,
+		SSHAuthSock: ssh_agent.SSHAuthSock,
+	}
 }
 
 func (repo *Local) Checksum(opts ChecksumOptions) (checksum Checksum, err error) {
-	_ = logboek.Debug.LogProcess(
+	_ = logging.LevelLogProcess(logboek.Debug,
 		"Calculating checksum",
 		logboek.LevelLogProcessOptions{},
 		func() error {
@@ -130,6 +150,21 @@ func (repo *Local) IsCommitExists(commit string) (bool, error) {
 	return repo.isCommitExists(repo.Path, repo.GitDir, commit)
 }
 
+// ReadCommitFile returns content and blob hash of the file by path relative to the repo root
+func (repo *Local) ReadCommitFile(commit, filePath string) ([]byte, string, error) {
+	return repo.readCommitFile(repo.Path, commit, filePath)
+}
+
+// ReadCommitFiles returns content of the commit files under basePath, which match filter, by paths relative to basePath
+func (repo *Local) ReadCommitFiles(commit, basePath string, filter func(relPath string) bool) (map[string][]byte, error) {
+	return repo.readCommitFiles(repo.Path, commit, basePath, filter)
+}
+
+// ReadCommitFilesModes returns modes of the commit files under basePath, which match filter, by paths relative to basePath
+func (repo *Local) ReadCommitFilesModes(commit, basePath string, filter func(relPath string) bool) (map[string]os.FileMode, error) {
+	return repo.readCommitFilesModes(repo.Path, commit, basePath, filter)
+}
+
 func (repo *Local) TagsList() ([]string, error) {
 	return repo.tagsList(repo.Path)
 }
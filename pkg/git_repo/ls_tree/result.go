@@ -11,6 +11,7 @@ import (
 
 	"github.com/flant/logboek"
 
+	"github.com/flant/werf/pkg/logging"
 	"github.com/flant/werf/pkg/path_matcher"
 )
 
@@ -146,7 +147,7 @@ func (r *Result) Checksum() string {
 		var submoduleChecksum string
 		if !submoduleResult.IsEmpty() {
 			blockMsg := fmt.Sprintf("submodule %s", submoduleResult.treeFilepath)
-			_ = logboek.Debug.LogBlock(blockMsg, logboek.LevelLogBlockOptions{}, func() error {
+			_ = logging.LevelLogBlock(logboek.Debug, blockMsg, logboek.LevelLogBlockOptions{}, func() error {
 				submoduleChecksum = submoduleResult.Checksum()
 				logboek.Debug.LogLn()
 				logboek.Debug.LogLn(submoduleChecksum)
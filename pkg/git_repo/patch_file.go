@@ -38,3 +38,15 @@ func (p *PatchFile) GetPaths() []string {
 func (p *PatchFile) GetBinaryPaths() []string {
 	return p.Descriptor.BinaryPaths
 }
+
+func (p *PatchFile) HasLfs() bool {
+	return len(p.Descriptor.LfsPaths) > 0
+}
+
+func (p *PatchFile) GetLfsOids() []string {
+	return p.Descriptor.LfsOids
+}
+
+func (p *PatchFile) GetLfsSize() int64 {
+	return p.Descriptor.LfsSize
+}
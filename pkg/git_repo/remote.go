@@ -4,11 +4,13 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"sync"
 	"time"
 
-	"github.com/flant/werf/pkg/true_git"
-
+	"github.com/flant/werf/pkg/git_lfs"
+	"github.com/flant/werf/pkg/logging"
 	"github.com/flant/werf/pkg/slug"
+	"github.com/flant/werf/pkg/true_git"
 
 	"gopkg.in/ini.v1"
 	"gopkg.in/src-d/go-git.v4"
@@ -24,10 +26,24 @@ type Remote struct {
 	Base
 	Url      string
 	IsDryRun bool
+
+	// Auth and CloneOptions switch cloning and fetching to git cli
+	Auth         *RemoteAuth
+	CloneOptions *RemoteCloneOptions
+	SSHAuthSock  string
+
+	fetchedRefs      map[string]bool
+	fetchedRefsMutex sync.Mutex
 }
 
 func (repo *Remote) GetClonePath() string {
-	return filepath.Join(GetGitRepoCacheDir(), "remote", slug.Slug(repo.Url))
+	name := repo.Url
+	// shallow and partial clones are not compatible with the full clone of the same repo
+	if repo.CloneOptions != nil {
+		name = fmt.Sprintf("%s-depth-%d-filter-%s", name, repo.CloneOptions.Depth, repo.CloneOptions.Filter)
+	}
+
+	return filepath.Join(GetGitRepoCacheDir(), "remote", slug.Slug(name))
 }
 
 func (repo *Remote) RemoteOriginUrl() (string, error) {
@@ -47,7 +63,12 @@ func (repo *Remote) IsEmpty() (bool, error) {
 }
 
 func (repo *Remote) IsAncestor(ancestorCommit, descendantCommit string) (bool, error) {
-	return true_git.IsAncestor(ancestorCommit, descendantCommit, repo.GetClonePath())
+	isAncestor, err := true_git.IsAncestor(ancestorCommit, descendantCommit, repo.GetClonePath())
+	if err != nil || isAncestor || !repo.isShallowMode() || repo.IsDryRun {
+		return isAncestor, err
+	}
+
+	return repo.deepenUntilAncestor(ancestorCommit, descendantCommit)
 }
 
 func (repo *Remote) CloneAndFetch() error {
@@ -113,12 +134,18 @@ func (repo *Remote) Clone() (bool, error) {
 		// Ensure cleanup on failure
 		defer os.RemoveAll(tmpPath)
 
-		_, err = git.PlainClone(tmpPath, true, &git.CloneOptions{
-			URL:               repo.Url,
-			RecurseSubmodules: git.DefaultSubmoduleRecursionDepth,
-		})
-		if err != nil {
-			return err
+		if repo.isCliMode() {
+			if err := repo.cliClone(tmpPath); err != nil {
+				return err
+			}
+		} else {
+			_, err = git.PlainClone(tmpPath, true, &git.CloneOptions{
+				URL:               repo.Url,
+				RecurseSubmodules: git.DefaultSubmoduleRecursionDepth,
+			})
+			if err != nil {
+				return err
+			}
 		}
 
 		if err := os.Rename(tmpPath, repo.GetClonePath()); err != nil {
@@ -134,6 +161,12 @@ func (repo *Remote) Fetch() error {
 		return nil
 	}
 
+	if repo.isCliMode() {
+		return repo.withRemoteRepoLock(func() error {
+			return repo.cliFetch(repo.GetClonePath())
+		})
+	}
+
 	cfgPath := filepath.Join(repo.GetClonePath(), "config")
 
 	cfg, err := ini.Load(cfgPath)
@@ -182,6 +215,10 @@ func (repo *Remote) HeadCommit() (string, error) {
 		return "", fmt.Errorf("cannot detect head branch name of repo `%s`: %s", repoPath, err)
 	}
 
+	if err := repo.fetchRefOnDemand(fmt.Sprintf("+refs/heads/%s:refs/remotes/origin/%s", branch, branch)); err != nil {
+		return "", err
+	}
+
 	refName := plumbing.ReferenceName(fmt.Sprintf("refs/remotes/origin/%s", branch))
 
 	ref, err := repository.Reference(refName, true)
@@ -193,6 +230,10 @@ func (repo *Remote) HeadCommit() (string, error) {
 }
 
 func (repo *Remote) HeadBranchName() (string, error) {
+	if repo.isCliMode() {
+		return repo.cliHeadBranchName()
+	}
+
 	return repo.getHeadBranchName(repo.GetClonePath())
 }
 
@@ -222,6 +263,10 @@ func (repo *Remote) findReference(rawRepo *git.Repository, reference string) (st
 func (repo *Remote) LatestBranchCommit(branch string) (string, error) {
 	var err error
 
+	if err := repo.fetchRefOnDemand(fmt.Sprintf("+refs/heads/%s:refs/remotes/origin/%s", branch, branch)); err != nil {
+		return "", err
+	}
+
 	rawRepo, err := git.PlainOpen(repo.GetClonePath())
 	if err != nil {
 		return "", fmt.Errorf("cannot open repo: %s", err)
@@ -241,8 +286,24 @@ func (repo *Remote) LatestBranchCommit(branch string) (string, error) {
 }
 
 func (repo *Remote) TagCommit(tag string) (string, error) {
+	res, err := repo.ResolveTag(tag)
+	if err != nil {
+		return "", err
+	}
+
+	logboek.LogF("Using commit '%s' of repo '%s' tag '%s'\n", res, repo.String(), tag)
+
+	return res, nil
+}
+
+// ResolveTag returns commit of the tag without logging
+func (repo *Remote) ResolveTag(tag string) (string, error) {
 	var err error
 
+	if err := repo.fetchRefOnDemand(fmt.Sprintf("+refs/tags/%s:refs/tags/%s", tag, tag)); err != nil {
+		return "", err
+	}
+
 	rawRepo, err := git.PlainOpen(repo.GetClonePath())
 	if err != nil {
 		return "", fmt.Errorf("cannot open repo: %s", err)
@@ -266,8 +327,6 @@ func (repo *Remote) TagCommit(tag string) (string, error) {
 		return "", fmt.Errorf("bad tag '%s' of repo %s: %s", tag, repo.String(), err)
 	}
 
-	logboek.LogF("Using commit '%s' of repo '%s' tag '%s'\n", res, repo.String(), tag)
-
 	return res, nil
 }
 
@@ -276,6 +335,14 @@ func (repo *Remote) CreatePatch(opts PatchOptions) (Patch, error) {
 	if err != nil {
 		return nil, err
 	}
+
+	for _, commit := range []string{opts.FromCommit, opts.ToCommit} {
+		if err := repo.fetchCommitOnDemand(commit); err != nil {
+			return nil, err
+		}
+	}
+	repo.prefetchSubmodulesFile(opts.ToCommit)
+
 	return repo.createPatch(repo.GetClonePath(), repo.GetClonePath(), workTreeDir, opts)
 }
 
@@ -284,7 +351,36 @@ func (repo *Remote) CreateArchive(opts ArchiveOptions) (Archive, error) {
 	if err != nil {
 		return nil, err
 	}
-	return repo.createArchive(repo.GetClonePath(), repo.GetClonePath(), workTreeDir, opts)
+
+	if err := repo.fetchCommitOnDemand(opts.Commit); err != nil {
+		return nil, err
+	}
+	repo.prefetchSubmodulesFile(opts.Commit)
+
+	return repo.createArchive(repo.GetClonePath(), repo.GetClonePath(), workTreeDir, opts, repo.getLfsResolver(opts.Lfs))
+}
+
+func (repo *Remote) getLfsResolver(opts *LfsOptions) *git_lfs.Resolver {
+	if opts == nil {
+		return nil
+	}
+
+	resolver := &git_lfs.Resolver{
+		GitDir:      repo.GetClonePath(),
+		RemoteUrl:   repo.Url,
+		Url:         opts.Url,
+		SSHAuthSock: repo.SSHAuthSock,
+	}
+
+	if repo.Auth != nil && repo.Auth.PasswordEnv != "" {
+		resolver.Username = repo.Auth.Username
+		if resolver.Username == "" {
+			resolver.Username = defaultRemoteAuthUsername
+		}
+		resolver.PasswordEnv = repo.Auth.PasswordEnv
+	}
+
+	return resolver
 }
 
 func (repo *Remote) Checksum(opts ChecksumOptions) (checksum Checksum, err error) {
@@ -293,7 +389,12 @@ func (repo *Remote) Checksum(opts ChecksumOptions) (checksum Checksum, err error
 		return nil, err
 	}
 
-	_ = logboek.Debug.LogProcess(
+	if err := repo.fetchCommitOnDemand(opts.Commit); err != nil {
+		return nil, err
+	}
+	repo.prefetchSubmodulesFile(opts.Commit)
+
+	_ = logging.LevelLogProcess(logboek.Debug,
 		"Calculating checksum",
 		logboek.LevelLogProcessOptions{},
 		func() error {
@@ -306,20 +407,40 @@ func (repo *Remote) Checksum(opts ChecksumOptions) (checksum Checksum, err error
 }
 
 func (repo *Remote) IsCommitExists(commit string) (bool, error) {
+	if err := repo.fetchCommitOnDemand(commit); err != nil {
+		// commit may be unreachable on the remote
+		logboek.LogErrorF("WARNING: %s\n", err)
+	}
+
 	return repo.isCommitExists(repo.GetClonePath(), repo.GetClonePath(), commit)
 }
 
+// ReadCommitFiles returns content of the commit files under basePath, which match filter, by paths relative to basePath
+func (repo *Remote) ReadCommitFiles(commit, basePath string, filter func(relPath string) bool) (map[string][]byte, error) {
+	if err := repo.fetchCommitOnDemand(commit); err != nil {
+		return nil, err
+	}
+
+	return repo.readCommitFiles(repo.GetClonePath(), commit, basePath, filter)
+}
+
 func (repo *Remote) getWorkTreeDir() (string, error) {
 	ep, err := transport.NewEndpoint(repo.Url)
 	if err != nil {
 		return "", fmt.Errorf("bad endpoint url `%s`: %s", repo.Url, err)
 	}
 
-	return filepath.Join(GetWorkTreeCacheDir(), "remote", ep.Host, ep.Path), nil
+	workTreeDir := filepath.Join(GetWorkTreeCacheDir(), "remote", ep.Host, ep.Path)
+	if repo.CloneOptions != nil {
+		workTreeDir = fmt.Sprintf("%s-depth-%d-filter-%s", workTreeDir, repo.CloneOptions.Depth, slug.Slug(repo.CloneOptions.Filter))
+	}
+
+	return workTreeDir, nil
 }
 
+// withRemoteRepoLock locks the clone, which is shared by repos with the same url and clone options
 func (repo *Remote) withRemoteRepoLock(f func() error) error {
-	lockName := fmt.Sprintf("remote_git_mapping.%s", repo.Name)
+	lockName := fmt.Sprintf("remote_git_mapping.%s", filepath.Base(repo.GetClonePath()))
 	return shluz.WithLock(lockName, shluz.LockOptions{Timeout: 600 * time.Second}, f)
 }
 
@@ -0,0 +1,280 @@
+package git_repo
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/flant/logboek"
+
+	"github.com/flant/werf/pkg/true_git"
+)
+
+// RemoteAuth configures credentials of the remote repository, which is cloned and fetched with git cli
+type RemoteAuth struct {
+	Username string
+	// PasswordEnv is the name of the environment variable with the password or access token for http(s) url.
+	// Credentials are passed to git by the credential helper, so they are not stored in the clone
+	PasswordEnv string
+	// KnownHosts is the path to the known_hosts file for ssh url, ~/.ssh/known_hosts by default
+	KnownHosts string
+}
+
+// RemoteCloneOptions configures shallow (Depth) and partial (Filter, e.g. blob:none) clone of the remote repository.
+// Shallow clone fetches only used branches, tags and commits and deepens history on demand
+type RemoteCloneOptions struct {
+	Depth  int
+	Filter string
+}
+
+const defaultRemoteAuthUsername = "oauth2"
+
+func (repo *Remote) isCliMode() bool {
+	return repo.Auth != nil || repo.CloneOptions != nil
+}
+
+func (repo *Remote) isShallowMode() bool {
+	return repo.CloneOptions != nil && repo.CloneOptions.Depth > 0
+}
+
+func (repo *Remote) isPartialMode() bool {
+	return repo.CloneOptions != nil && repo.CloneOptions.Filter != ""
+}
+
+func (repo *Remote) cliClone(repoPath string) error {
+	if _, err := repo.runGit("", "init", "--bare", repoPath); err != nil {
+		return err
+	}
+
+	return repo.cliFetch(repoPath)
+}
+
+func (repo *Remote) cliFetch(repoPath string) error {
+	if err := repo.configureClone(repoPath); err != nil {
+		return err
+	}
+
+	if err := repo.setHeadFromRemote(repoPath); err != nil {
+		return err
+	}
+
+	// shallow clone fetches used refs on demand
+	if repo.isShallowMode() {
+		return nil
+	}
+
+	logboek.Default.LogFDetails("Fetch remote origin of %s\n", repo.Url)
+
+	_, err := repo.runGit(repoPath, "fetch", "--force", "--tags", "origin")
+	return err
+}
+
+// configureClone is performed on each fetch, because url and credentials of the remote may change
+func (repo *Remote) configureClone(repoPath string) error {
+	configs := [][]string{
+		{"remote.origin.url", repo.Url},
+		{"remote.origin.fetch", "+refs/heads/*:refs/remotes/origin/*"},
+	}
+
+	if repo.isPartialMode() {
+		configs = append(configs, [][]string{
+			{"core.repositoryformatversion", "1"},
+			{"extensions.partialClone", "origin"},
+			{"remote.origin.promisor", "true"},
+			{"remote.origin.partialclonefilter", repo.CloneOptions.Filter},
+		}...)
+	}
+
+	configs = append(configs, []string{"core.sshCommand", repo.sshCommand()})
+
+	for _, config := range configs {
+		if _, err := repo.runGit(repoPath, "config", config[0], config[1]); err != nil {
+			return err
+		}
+	}
+
+	// empty helper resets helpers of the global config
+	if _, err := repo.runGit(repoPath, "config", "--replace-all", "credential.helper", ""); err != nil {
+		return err
+	}
+
+	if repo.Auth != nil && repo.Auth.PasswordEnv != "" {
+		if _, err := repo.runGit(repoPath, "config", "--add", "credential.helper", repo.credentialHelper()); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// credentialHelper passes password from the environment variable of werf process (and git lazy fetches in the partial clone),
+// only the name of the variable is stored in the clone config
+func (repo *Remote) credentialHelper() string {
+	username := repo.Auth.Username
+	if username == "" {
+		username = defaultRemoteAuthUsername
+	}
+
+	return fmt.Sprintf(`!f() { test "$1" = get && echo 'username=%s' && echo "password=$%s"; }; f`, username, repo.Auth.PasswordEnv)
+}
+
+// sshCommand is run by shell, option values are quoted for ssh (paths may contain spaces) and then the whole option is quoted for shell
+func (repo *Remote) sshCommand() string {
+	args := []string{"ssh", "-o BatchMode=yes", "-o StrictHostKeyChecking=yes"}
+
+	if repo.Auth != nil && repo.Auth.KnownHosts != "" {
+		knownHosts := repo.Auth.KnownHosts
+		if strings.HasPrefix(knownHosts, "~/") {
+			knownHosts = filepath.Join(os.Getenv("HOME"), knownHosts[2:])
+		}
+
+		args = append(args, sshOption("UserKnownHostsFile", knownHosts))
+	}
+
+	if repo.SSHAuthSock != "" {
+		args = append(args, sshOption("IdentityAgent", repo.SSHAuthSock))
+	}
+
+	return strings.Join(args, " ")
+}
+
+func sshOption(name, value string) string {
+	value = strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(value)
+	return fmt.Sprintf("-o %s", shellQuote(fmt.Sprintf(`%s="%s"`, name, value)))
+}
+
+func shellQuote(value string) string {
+	return fmt.Sprintf("'%s'", strings.Replace(value, "'", `'\''`, -1))
+}
+
+func (repo *Remote) setHeadFromRemote(repoPath string) error {
+	output, err := repo.runGit(repoPath, "ls-remote", "--symref", "origin", "HEAD")
+	if err != nil {
+		return err
+	}
+
+	for _, line := range strings.Split(output, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) == 3 && fields[0] == "ref:" && fields[2] == "HEAD" {
+			_, err := repo.runGit(repoPath, "symbolic-ref", "HEAD", fields[1])
+			return err
+		}
+	}
+
+	return nil
+}
+
+// cliHeadBranchName does not resolve HEAD, because git cli clone has only remote branches
+func (repo *Remote) cliHeadBranchName() (string, error) {
+	output, err := repo.runGit(repo.GetClonePath(), "symbolic-ref", "HEAD")
+	if err != nil {
+		return "", err
+	}
+
+	ref := strings.TrimSpace(output)
+	if !strings.HasPrefix(ref, "refs/heads/") {
+		return "", errNotABranch
+	}
+
+	return strings.TrimPrefix(ref, "refs/heads/"), nil
+}
+
+// fetchRefOnDemand fetches branch or tag of the shallow clone once
+func (repo *Remote) fetchRefOnDemand(refSpec string) error {
+	if !repo.isShallowMode() || repo.IsDryRun {
+		return nil
+	}
+
+	repo.fetchedRefsMutex.Lock()
+	defer repo.fetchedRefsMutex.Unlock()
+
+	if repo.fetchedRefs[refSpec] {
+		return nil
+	}
+
+	if err := repo.withRemoteRepoLock(func() error {
+		logboek.Default.LogFDetails("Fetch %s of %s with depth %d\n", refSpec, repo.Url, repo.CloneOptions.Depth)
+		_, err := repo.runGit(repo.GetClonePath(), "fetch", "--force", "--depth", strconv.Itoa(repo.CloneOptions.Depth), "origin", refSpec)
+		return err
+	}); err != nil {
+		return err
+	}
+
+	if repo.fetchedRefs == nil {
+		repo.fetchedRefs = map[string]bool{}
+	}
+	repo.fetchedRefs[refSpec] = true
+
+	return nil
+}
+
+// fetchCommitOnDemand fetches commit, which is missing in the shallow clone, the server should allow fetching commits by id
+func (repo *Remote) fetchCommitOnDemand(commit string) error {
+	if !repo.isShallowMode() || repo.IsDryRun {
+		return nil
+	}
+
+	exist, err := repo.isCommitExists(repo.GetClonePath(), repo.GetClonePath(), commit)
+	if err != nil || exist {
+		return err
+	}
+
+	return repo.fetchRefOnDemand(commit)
+}
+
+// deepenUntilAncestor doubles depth of the descendant commit history until ancestor commit is found or the whole history is fetched
+func (repo *Remote) deepenUntilAncestor(ancestorCommit, descendantCommit string) (bool, error) {
+	for depth := repo.CloneOptions.Depth * 2; ; depth *= 2 {
+		if err := repo.withRemoteRepoLock(func() error {
+			logboek.Default.LogFDetails("Deepen history of commit %s of %s to %d commits\n", descendantCommit, repo.Url, depth)
+			_, err := repo.runGit(repo.GetClonePath(), "fetch", "--depth", strconv.Itoa(depth), "origin", descendantCommit)
+			return err
+		}); err != nil {
+			return false, err
+		}
+
+		isAncestor, err := true_git.IsAncestor(ancestorCommit, descendantCommit, repo.GetClonePath())
+		if err != nil || isAncestor {
+			return isAncestor, err
+		}
+
+		output, err := repo.runGit(repo.GetClonePath(), "rev-list", "--count", descendantCommit)
+		if err != nil {
+			return false, err
+		}
+
+		if count, err := strconv.Atoi(strings.TrimSpace(output)); err != nil {
+			return false, fmt.Errorf("unexpected git rev-list output %q: %s", output, err)
+		} else if count < depth {
+			return false, nil
+		}
+	}
+}
+
+// prefetchSubmodulesFile fetches .gitmodules of the partial clone, which is read without git cli
+func (repo *Remote) prefetchSubmodulesFile(commit string) {
+	if !repo.isPartialMode() || repo.IsDryRun {
+		return
+	}
+
+	_, _ = repo.runGit(repo.GetClonePath(), "cat-file", "-e", fmt.Sprintf("%s:.gitmodules", commit))
+}
+
+func (repo *Remote) runGit(repoPath string, args ...string) (string, error) {
+	if repoPath != "" {
+		args = append([]string{"--git-dir", repoPath}, args...)
+	}
+
+	cmd := exec.Command("git", args...)
+	cmd.Env = append(os.Environ(), "GIT_TERMINAL_PROMPT=0")
+
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		return "", fmt.Errorf("git %s failed: %s\n%s", strings.Join(args, " "), err, output)
+	}
+
+	return string(output), nil
+}
@@ -0,0 +1,212 @@
+package git_repo
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/flant/shluz"
+
+	"github.com/flant/werf/pkg/werf"
+)
+
+func initRemoteTest(t *testing.T) (string, func()) {
+	dir, err := ioutil.TempDir("", "werf-git-repo")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := werf.Init(filepath.Join(dir, "tmp"), filepath.Join(dir, "home")); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := shluz.Init(filepath.Join(werf.GetServiceDir(), "locks")); err != nil {
+		t.Fatal(err)
+	}
+
+	return dir, func() { os.RemoveAll(dir) }
+}
+
+func runTestGit(t *testing.T, dir string, args ...string) string {
+	cmd := exec.Command("git", append([]string{"-C", dir}, args...)...)
+	cmd.Env = append(os.Environ(),
+		"GIT_AUTHOR_NAME=werf", "GIT_AUTHOR_EMAIL=werf@example.com",
+		"GIT_COMMITTER_NAME=werf", "GIT_COMMITTER_EMAIL=werf@example.com",
+	)
+
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		t.Fatalf("git %s failed: %s\n%s", strings.Join(args, " "), err, output)
+	}
+
+	return strings.TrimSpace(string(output))
+}
+
+// createOriginRepo creates bare repo with linear main branch history and commit of the other branch, returns commits of the main branch
+func createOriginRepo(t *testing.T, dir string, commitsNumber int) (string, []string, string) {
+	workTree := filepath.Join(dir, "work")
+	originPath := filepath.Join(dir, "origin.git")
+
+	runTestGit(t, dir, "init", "--quiet", workTree)
+	runTestGit(t, workTree, "symbolic-ref", "HEAD", "refs/heads/main")
+
+	var commits []string
+	for i := 0; i < commitsNumber; i++ {
+		if err := ioutil.WriteFile(filepath.Join(workTree, "file"), []byte(fmt.Sprintf("%d\n", i)), 0644); err != nil {
+			t.Fatal(err)
+		}
+
+		runTestGit(t, workTree, "add", "file")
+		runTestGit(t, workTree, "commit", "--quiet", "-m", fmt.Sprintf("commit %d", i))
+		commits = append(commits, runTestGit(t, workTree, "rev-parse", "HEAD"))
+	}
+
+	runTestGit(t, workTree, "checkout", "--quiet", "-b", "other", commits[0])
+	runTestGit(t, workTree, "commit", "--quiet", "--allow-empty", "-m", "other commit")
+	otherCommit := runTestGit(t, workTree, "rev-parse", "HEAD")
+	runTestGit(t, workTree, "checkout", "--quiet", "main")
+
+	runTestGit(t, dir, "clone", "--quiet", "--bare", workTree, originPath)
+	runTestGit(t, originPath, "config", "uploadpack.allowFilter", "true")
+	runTestGit(t, originPath, "config", "uploadpack.allowAnySHA1InWant", "true")
+
+	return "file://" + originPath, commits, otherCommit
+}
+
+func TestRemote_cliClone(t *testing.T) {
+	dir, cleanup := initRemoteTest(t)
+	defer cleanup()
+
+	url, commits, _ := createOriginRepo(t, dir, 3)
+
+	repo := &Remote{
+		Base:         Base{Name: "origin"},
+		Url:          url,
+		CloneOptions: &RemoteCloneOptions{Filter: "blob:none"},
+	}
+
+	if err := repo.CloneAndFetch(); err != nil {
+		t.Fatal(err)
+	}
+
+	clonePath := repo.GetClonePath()
+	if head := runTestGit(t, clonePath, "symbolic-ref", "HEAD"); head != "refs/heads/main" {
+		t.Errorf("\n[EXPECTED]: refs/heads/main\n[GOT]: %s", head)
+	}
+
+	if commit := runTestGit(t, clonePath, "rev-parse", "refs/remotes/origin/main"); commit != commits[2] {
+		t.Errorf("\n[EXPECTED]: %s\n[GOT]: %s", commits[2], commit)
+	}
+
+	if filter := runTestGit(t, clonePath, "config", "remote.origin.partialclonefilter"); filter != "blob:none" {
+		t.Errorf("\n[EXPECTED]: blob:none\n[GOT]: %s", filter)
+	}
+
+	if helpers := runTestGit(t, clonePath, "config", "--get-all", "credential.helper"); helpers != "" {
+		t.Errorf("credential helpers of the global config should be reset, got %q", helpers)
+	}
+}
+
+func TestRemote_deepenUntilAncestor(t *testing.T) {
+	dir, cleanup := initRemoteTest(t)
+	defer cleanup()
+
+	url, commits, otherCommit := createOriginRepo(t, dir, 5)
+
+	repo := &Remote{
+		Base:         Base{Name: "origin"},
+		Url:          url,
+		CloneOptions: &RemoteCloneOptions{Depth: 1},
+	}
+
+	if err := repo.CloneAndFetch(); err != nil {
+		t.Fatal(err)
+	}
+
+	headCommit, err := repo.HeadCommit()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if headCommit != commits[4] {
+		t.Fatalf("\n[EXPECTED]: %s\n[GOT]: %s", commits[4], headCommit)
+	}
+
+	clonePath := repo.GetClonePath()
+	if count := runTestGit(t, clonePath, "rev-list", "--count", headCommit); count != "1" {
+		t.Errorf("shallow clone with depth 1 expected, got %s commits", count)
+	}
+
+	isAncestor, err := repo.IsAncestor(commits[1], headCommit)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !isAncestor {
+		t.Errorf("commit %s should be ancestor of %s", commits[1], headCommit)
+	}
+
+	// depth is doubled: 2, 4
+	if count := runTestGit(t, clonePath, "rev-list", "--count", headCommit); count != "4" {
+		t.Errorf("history deepened to 4 commits expected, got %s commits", count)
+	}
+
+	if err := repo.fetchCommitOnDemand(otherCommit); err != nil {
+		t.Fatal(err)
+	}
+
+	isAncestor, err = repo.IsAncestor(otherCommit, headCommit)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if isAncestor {
+		t.Errorf("commit %s should not be ancestor of %s", otherCommit, headCommit)
+	}
+
+	if count := runTestGit(t, clonePath, "rev-list", "--count", headCommit); count != "5" {
+		t.Errorf("the whole history expected, got %s commits", count)
+	}
+}
+
+func TestRemote_credentialHelper(t *testing.T) {
+	repo := &Remote{Auth: &RemoteAuth{PasswordEnv: "WERF_TEST_GIT_PASSWORD"}}
+
+	cmd := exec.Command("git", "-c", "credential.helper=", "-c", fmt.Sprintf("credential.helper=%s", repo.credentialHelper()), "credential", "fill")
+	cmd.Env = append(os.Environ(), "WERF_TEST_GIT_PASSWORD=pass word$1", "GIT_TERMINAL_PROMPT=0")
+	cmd.Stdin = strings.NewReader("protocol=https\nhost=example.com\n\n")
+
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		t.Fatalf("git credential fill failed: %s\n%s", err, output)
+	}
+
+	expected := "protocol=https\nhost=example.com\nusername=oauth2\npassword=pass word$1\n"
+	if string(output) != expected {
+		t.Errorf("\n[EXPECTED]: %q\n[GOT]: %q", expected, output)
+	}
+}
+
+func TestRemote_sshCommand(t *testing.T) {
+	if _, err := exec.LookPath("ssh"); err != nil {
+		t.Skip("ssh is not available")
+	}
+
+	knownHosts := `/tmp/werf known_hosts/it's "quoted"`
+	repo := &Remote{Auth: &RemoteAuth{KnownHosts: knownHosts}, SSHAuthSock: "/tmp/ssh agent.sock"}
+
+	output, err := exec.Command("sh", "-c", repo.sshCommand()+" -G example.com").CombinedOutput()
+	if err != nil {
+		t.Fatalf("ssh -G failed: %s\n%s", err, output)
+	}
+
+	for _, expected := range []string{"userknownhostsfile " + knownHosts, "identityagent /tmp/ssh agent.sock", "stricthostkeychecking true", "batchmode yes"} {
+		if !strings.Contains(string(output), expected+"\n") {
+			t.Errorf("%q expected in ssh config:\n%s", expected, output)
+		}
+	}
+}
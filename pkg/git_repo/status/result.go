@@ -3,6 +3,7 @@ package status
 import (
 	"crypto/sha256"
 	"fmt"
+	"github.com/flant/werf/pkg/logging"
 	"github.com/flant/werf/pkg/path_matcher"
 	"io/ioutil"
 	"os"
@@ -242,7 +243,7 @@ func (r *Result) Checksum() string {
 
 	for _, sr := range r.submoduleResults {
 		logBlockMsg := fmt.Sprintf("submodule %s", sr.relToBaseRepositorySubmoduleFilepath)
-		_ = logboek.Debug.LogBlock(logBlockMsg, logboek.LevelLogBlockOptions{}, func() error {
+		_ = logging.LevelLogBlock(logboek.Debug, logBlockMsg, logboek.LevelLogBlockOptions{}, func() error {
 			var srChecksumArgs []string
 
 			srChecksumArgs = append(srChecksumArgs, sr.relToBaseRepositorySubmoduleFilepath)
@@ -16,7 +16,17 @@ const (
 
 	WerfImportLabelPrefix = "werf-import-"
 
-	WerfTagStrategyLabel = "werf-tag-strategy"
+	WerfTagStrategyLabel    = "werf-tag-strategy"
+	WerfTagAliasTargetLabel = "werf-tag-alias-target"
+
+	WerfManagedImageAddedByLabel                     = "werf-managed-image-added-by"
+	WerfManagedImageAddedAtLabel                     = "werf-managed-image-added-at"
+	WerfManagedImageDescriptionLabel                 = "werf-managed-image-description"
+	WerfManagedImageKeepForeverLabel                 = "werf-managed-image-keep-forever"
+	WerfManagedImageGitTagStrategyLimitLabel         = "werf-managed-image-git-tag-strategy-limit"
+	WerfManagedImageGitTagStrategyExpiryDaysLabel    = "werf-managed-image-git-tag-strategy-expiry-days"
+	WerfManagedImageGitCommitStrategyLimitLabel      = "werf-managed-image-git-commit-strategy-limit"
+	WerfManagedImageGitCommitStrategyExpiryDaysLabel = "werf-managed-image-git-commit-strategy-expiry-days"
 
 	BuildCacheVersion = "1.1"
 
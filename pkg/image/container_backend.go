@@ -0,0 +1,32 @@
+package image
+
+// ContainerBackend runs stapel stages containers and commits them into images.
+// DockerBackend is used by default, RuncBackend is an experimental implementation, which runs the containers without docker
+// but still requires docker daemon as the images source and target (see NewDockerRuncBackend)
+type ContainerBackend interface {
+	// Run runs the command in the container, the container is kept to be committed or removed
+	Run(spec ContainerSpec) error
+	// Introspect runs interactive shell in the temporary container
+	Introspect(spec ContainerSpec) error
+	Kill(containerName string) error
+	Commit(containerName string, options *StageImageContainerOptions) (string, error)
+	Remove(containerName string) error
+}
+
+// ContainerSpec describes the stage container, the stapel toolchain is provided by the backend
+type ContainerSpec struct {
+	Name    string
+	ImageId string
+	Options *StageImageContainerOptions
+	Command string
+}
+
+var containerBackend ContainerBackend = &DockerBackend{}
+
+func SetContainerBackend(backend ContainerBackend) {
+	containerBackend = backend
+}
+
+func GetContainerBackend() ContainerBackend {
+	return containerBackend
+}
@@ -0,0 +1,84 @@
+package image
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/docker/docker/api/types"
+
+	"github.com/flant/werf/pkg/docker"
+	"github.com/flant/werf/pkg/stapel"
+)
+
+type DockerBackend struct{}
+
+func (b *DockerBackend) Run(spec ContainerSpec) error {
+	runArgs, err := b.runArgs(spec)
+	if err != nil {
+		return err
+	}
+
+	if debugDockerRunCommand() {
+		fmt.Printf("Docker run command:\ndocker run %s\n", strings.Join(runArgs, " "))
+	}
+
+	return docker.CliRun_LiveOutput(runArgs...)
+}
+
+func (b *DockerBackend) Introspect(spec ContainerSpec) error {
+	runArgs, err := b.runArgs(spec)
+	if err != nil {
+		return err
+	}
+
+	args := append([]string{"-ti", "--rm"}, runArgs...)
+	if err := docker.CliRun_LiveOutput(args...); err != nil {
+		if !strings.Contains(err.Error(), "Code: ") || IsStartContainerErr(err) {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (b *DockerBackend) runArgs(spec ContainerSpec) ([]string, error) {
+	stapelContainerName, err := stapel.GetOrCreateContainer()
+	if err != nil {
+		return nil, err
+	}
+
+	options := newStageContainerOptions()
+	options.VolumesFrom = []string{stapelContainerName}
+	options = options.merge(spec.Options)
+
+	runArgs, err := options.toRunArgs()
+	if err != nil {
+		return nil, err
+	}
+
+	var args []string
+	if spec.Name != "" {
+		args = append(args, fmt.Sprintf("--name=%s", spec.Name))
+	}
+	args = append(args, runArgs...)
+	args = append(args, spec.ImageId, "-ec", spec.Command)
+
+	return args, nil
+}
+
+func (b *DockerBackend) Kill(containerName string) error {
+	return docker.ContainerKill(containerName, "KILL")
+}
+
+func (b *DockerBackend) Commit(containerName string, options *StageImageContainerOptions) (string, error) {
+	commitChanges, err := options.prepareCommitChanges()
+	if err != nil {
+		return "", err
+	}
+
+	return docker.ContainerCommit(containerName, types.ContainerCommitOptions{Changes: commitChanges})
+}
+
+func (b *DockerBackend) Remove(containerName string) error {
+	return docker.ContainerRemove(containerName, types.ContainerRemoveOptions{})
+}
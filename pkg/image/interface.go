@@ -1,6 +1,10 @@
 package image
 
-import "github.com/docker/docker/api/types"
+import (
+	"time"
+
+	"github.com/docker/docker/api/types"
+)
 
 type BuildOptions struct {
 	IntrospectBeforeError bool
@@ -71,4 +75,8 @@ type ContainerOptions interface {
 	AddUser(user string)
 	AddEntrypoint(entrypoint string)
 	AddHealthCheck(check string)
+	AddCpus(cpus string)
+	AddMemory(memory string)
+	AddNetwork(network string)
+	AddTimeout(timeout time.Duration)
 }
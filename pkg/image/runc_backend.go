@@ -0,0 +1,728 @@
+// +build linux
+
+package image
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/docker/go-units"
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/mutate"
+	"github.com/google/go-containerregistry/pkg/v1/tarball"
+	specs "github.com/opencontainers/runtime-spec/specs-go"
+
+	"github.com/flant/logboek"
+
+	"github.com/flant/werf/pkg/rootfs"
+	"github.com/flant/werf/pkg/stapel"
+)
+
+const runcStapelMountDir = "/.werf/stapel"
+
+var runcDefaultCapabilities = []string{
+	"CAP_CHOWN",
+	"CAP_DAC_OVERRIDE",
+	"CAP_FSETID",
+	"CAP_FOWNER",
+	"CAP_MKNOD",
+	"CAP_NET_RAW",
+	"CAP_SETGID",
+	"CAP_SETUID",
+	"CAP_SETFCAP",
+	"CAP_SETPCAP",
+	"CAP_NET_BIND_SERVICE",
+	"CAP_SYS_CHROOT",
+	"CAP_KILL",
+	"CAP_AUDIT_WRITE",
+}
+
+// RuncBackend runs stages containers with the OCI runtime on the unpacked rootfs copies instead of docker containers.
+// Each image of the StoreDir is a rootfs dir, OCI image config and the layer tarball of the stage.
+// The backend is experimental and is not daemonless: the images are received from ImageSource and committed into ImageTarget,
+// which are docker daemon for now, because stages storage, tagging and publishing require docker daemon
+type RuncBackend struct {
+	RuncBinPath string
+	StoreDir    string
+	// StapelDir is the host dir with the stapel toolchain, it is mounted read-only into /.werf/stapel
+	StapelDir string
+	Rootless  bool
+
+	// ImageSource provides the images, which are not in the store (stages base images), and the base images of the committed stages,
+	// the returned func releases the image data
+	ImageSource func(ref string) (v1.Image, func(), error)
+	// ImageTarget receives the committed images, e.g. docker daemon for the stages storage
+	ImageTarget func(img v1.Image) error
+
+	mutex      sync.Mutex
+	containers map[string]*runcContainer
+}
+
+type runcContainer struct {
+	bundleDir string
+	imageId   string
+	snapshot  rootfs.Snapshot
+}
+
+func NewRuncBackend(storeDir, stapelDir string) *RuncBackend {
+	return &RuncBackend{
+		RuncBinPath: "runc",
+		StoreDir:    storeDir,
+		StapelDir:   stapelDir,
+		Rootless:    os.Geteuid() != 0,
+		containers:  map[string]*runcContainer{},
+	}
+}
+
+func (b *RuncBackend) imageDir(imageId string) string {
+	return filepath.Join(b.StoreDir, "images", strings.TrimPrefix(imageId, "sha256:"))
+}
+
+func (b *RuncBackend) ImageRootfsDir(imageId string) string {
+	return filepath.Join(b.imageDir(imageId), "rootfs")
+}
+
+func (b *RuncBackend) ImageLayerPath(imageId string) string {
+	return filepath.Join(b.imageDir(imageId), "layer.tar")
+}
+
+func (b *RuncBackend) ImageConfig(imageId string) (*v1.ConfigFile, error) {
+	data, err := ioutil.ReadFile(filepath.Join(b.imageDir(imageId), "config.json"))
+	if os.IsNotExist(err) {
+		return nil, fmt.Errorf("image %s not found in runc backend store %s", imageId, b.StoreDir)
+	} else if err != nil {
+		return nil, err
+	}
+
+	config := &v1.ConfigFile{}
+	if err := json.Unmarshal(data, config); err != nil {
+		return nil, fmt.Errorf("bad image %s config: %s", imageId, err)
+	}
+
+	return config, nil
+}
+
+// ImportImage unpacks the image into the store, e.g. the base image pulled from the registry
+func (b *RuncBackend) ImportImage(img v1.Image) (string, error) {
+	configName, err := img.ConfigName()
+	if err != nil {
+		return "", err
+	}
+
+	imageId := configName.String()
+	imageDir := b.imageDir(imageId)
+	if _, err := os.Stat(imageDir); err == nil {
+		return imageId, nil
+	}
+
+	rawConfig, err := img.RawConfigFile()
+	if err != nil {
+		return "", err
+	}
+
+	tmpDir := imageDir + ".tmp"
+	if err := os.RemoveAll(tmpDir); err != nil {
+		return "", err
+	}
+	defer os.RemoveAll(tmpDir)
+
+	rc := mutate.Extract(img)
+	defer rc.Close()
+
+	if err := rootfs.ExtractLayer(rc, filepath.Join(tmpDir, "rootfs")); err != nil {
+		return "", fmt.Errorf("unable to extract image %s: %s", imageId, err)
+	}
+
+	if err := ioutil.WriteFile(filepath.Join(tmpDir, "config.json"), rawConfig, 0644); err != nil {
+		return "", err
+	}
+
+	if err := os.Rename(tmpDir, imageDir); err != nil {
+		return "", err
+	}
+
+	return imageId, nil
+}
+
+func (b *RuncBackend) Run(spec ContainerSpec) error {
+	container, err := b.createContainer(spec, false)
+	if err != nil {
+		return err
+	}
+
+	cmd := exec.Command(b.RuncBinPath, "run", "--bundle", container.bundleDir, spec.Name)
+	cmd.Stdout = logboek.GetOutStream()
+	cmd.Stderr = logboek.GetErrStream()
+
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("runc run failed: %s", err)
+	}
+
+	return nil
+}
+
+func (b *RuncBackend) Introspect(spec ContainerSpec) error {
+	spec.Name = fmt.Sprintf("werf.introspect.%d", time.Now().UnixNano())
+
+	container, err := b.createContainer(spec, true)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err := b.Remove(spec.Name); err != nil {
+			logboek.LogWarnF("WARNING: unable to remove container %s: %s\n", spec.Name, err)
+		}
+	}()
+
+	cmd := exec.Command(b.RuncBinPath, "run", "--bundle", container.bundleDir, spec.Name)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+
+	if err := cmd.Run(); err != nil {
+		if _, ok := err.(*exec.ExitError); !ok {
+			return fmt.Errorf("runc run failed: %s", err)
+		}
+	}
+
+	return nil
+}
+
+// importSourceImage imports the image from the image source unless it is in the store
+func (b *RuncBackend) importSourceImage(ref string) (string, error) {
+	if _, err := os.Stat(b.imageDir(ref)); err == nil {
+		return ref, nil
+	} else if !os.IsNotExist(err) {
+		return "", err
+	}
+
+	if b.ImageSource == nil {
+		return "", fmt.Errorf("image %s not found in runc backend store %s", ref, b.StoreDir)
+	}
+
+	img, release, err := b.ImageSource(ref)
+	if err != nil {
+		return "", fmt.Errorf("unable to get image %s: %s", ref, err)
+	}
+	defer release()
+
+	return b.ImportImage(img)
+}
+
+func (b *RuncBackend) createContainer(spec ContainerSpec, terminal bool) (*runcContainer, error) {
+	if importedImageId, err := b.importSourceImage(spec.ImageId); err != nil {
+		return nil, err
+	} else if importedImageId != spec.ImageId {
+		return nil, fmt.Errorf("unexpected id %s of imported image %s", importedImageId, spec.ImageId)
+	}
+
+	imageConfig, err := b.ImageConfig(spec.ImageId)
+	if err != nil {
+		return nil, err
+	}
+
+	bundleDir := filepath.Join(b.StoreDir, "containers", spec.Name)
+	rootfsDir := filepath.Join(bundleDir, "rootfs")
+
+	if err := rootfs.Copy(b.ImageRootfsDir(spec.ImageId), rootfsDir); err != nil {
+		return nil, fmt.Errorf("unable to prepare container %s rootfs: %s", spec.Name, err)
+	}
+
+	snapshot, err := rootfs.TakeSnapshot(rootfsDir)
+	if err != nil {
+		return nil, err
+	}
+
+	runtimeSpec, err := b.runtimeSpec(spec, imageConfig, rootfsDir, terminal)
+	if err != nil {
+		return nil, fmt.Errorf("unable to prepare container %s spec: %s", spec.Name, err)
+	}
+
+	data, err := json.MarshalIndent(runtimeSpec, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+
+	if err := ioutil.WriteFile(filepath.Join(bundleDir, "config.json"), data, 0644); err != nil {
+		return nil, err
+	}
+
+	container := &runcContainer{bundleDir: bundleDir, imageId: spec.ImageId, snapshot: snapshot}
+
+	b.mutex.Lock()
+	b.containers[spec.Name] = container
+	b.mutex.Unlock()
+
+	return container, nil
+}
+
+func (b *RuncBackend) runtimeSpec(spec ContainerSpec, imageConfig *v1.ConfigFile, rootfsDir string, terminal bool) (*specs.Spec, error) {
+	options := spec.Options
+	if options == nil {
+		options = newStageContainerOptions()
+	}
+
+	if len(options.VolumesFrom) != 0 {
+		return nil, fmt.Errorf("volumes from containers are not supported")
+	}
+
+	uid, gid, err := resolveRootfsUser(rootfsDir, options.User)
+	if err != nil {
+		return nil, err
+	}
+
+	entrypoint := options.Entrypoint
+	if entrypoint == "" {
+		entrypoint = stapel.BashBinPath()
+	}
+
+	cwd := options.Workdir
+	if cwd == "" {
+		cwd = "/"
+	}
+
+	linux := &specs.Linux{
+		Namespaces: []specs.LinuxNamespace{
+			{Type: specs.PIDNamespace},
+			{Type: specs.IPCNamespace},
+			{Type: specs.UTSNamespace},
+			{Type: specs.MountNamespace},
+		},
+		MaskedPaths: []string{
+			"/proc/kcore",
+			"/proc/latency_stats",
+			"/proc/timer_list",
+			"/proc/timer_stats",
+			"/proc/sched_debug",
+			"/sys/firmware",
+		},
+		ReadonlyPaths: []string{
+			"/proc/asound",
+			"/proc/bus",
+			"/proc/fs",
+			"/proc/irq",
+			"/proc/sys",
+			"/proc/sysrq-trigger",
+		},
+	}
+
+	mounts := []specs.Mount{
+		{Destination: "/proc", Type: "proc", Source: "proc"},
+		{Destination: "/dev", Type: "tmpfs", Source: "tmpfs", Options: []string{"nosuid", "strictatime", "mode=755", "size=65536k"}},
+		{Destination: "/dev/pts", Type: "devpts", Source: "devpts", Options: []string{"nosuid", "noexec", "newinstance", "ptmxmode=0666", "mode=0620"}},
+		{Destination: "/dev/shm", Type: "tmpfs", Source: "shm", Options: []string{"nosuid", "noexec", "nodev", "mode=1777", "size=65536k"}},
+		{Destination: "/dev/mqueue", Type: "mqueue", Source: "mqueue", Options: []string{"nosuid", "noexec", "nodev"}},
+		{Destination: "/sys", Type: "sysfs", Source: "sysfs", Options: []string{"nosuid", "noexec", "nodev", "ro"}},
+		{Destination: runcStapelMountDir, Type: "bind", Source: b.StapelDir, Options: []string{"rbind", "ro"}},
+	}
+
+	for _, volume := range options.Volume {
+		parts := strings.Split(volume, ":")
+		if len(parts) < 2 || len(parts) > 3 {
+			return nil, fmt.Errorf("bad volume %s: expected src:dst[:ro]", volume)
+		}
+
+		mountOptions := []string{"rbind"}
+		if len(parts) == 3 {
+			mountOptions = append(mountOptions, parts[2])
+		}
+
+		mounts = append(mounts, specs.Mount{Destination: parts[1], Type: "bind", Source: parts[0], Options: mountOptions})
+	}
+
+	switch options.Network {
+	case "", "host":
+		for _, p := range []string{"/etc/resolv.conf", "/etc/hosts"} {
+			mounts = append(mounts, specs.Mount{Destination: p, Type: "bind", Source: p, Options: []string{"rbind", "ro"}})
+		}
+	case "none":
+		linux.Namespaces = append(linux.Namespaces, specs.LinuxNamespace{Type: specs.NetworkNamespace})
+	default:
+		return nil, fmt.Errorf("network %s is not supported, only host and none networks can be used", options.Network)
+	}
+
+	if b.Rootless {
+		linux.Namespaces = append(linux.Namespaces, specs.LinuxNamespace{Type: specs.UserNamespace})
+		linux.UIDMappings = []specs.LinuxIDMapping{{ContainerID: 0, HostID: uint32(os.Geteuid()), Size: 1}}
+		linux.GIDMappings = []specs.LinuxIDMapping{{ContainerID: 0, HostID: uint32(os.Getegid()), Size: 1}}
+	} else {
+		resources, err := runcResources(options)
+		if err != nil {
+			return nil, err
+		}
+		linux.Resources = resources
+	}
+
+	return &specs.Spec{
+		Version: specs.Version,
+		Process: &specs.Process{
+			Terminal: terminal,
+			User:     specs.User{UID: uid, GID: gid},
+			Args:     []string{entrypoint, "-ec", spec.Command},
+			Env:      runcEnv(imageConfig.Config.Env, options.Env),
+			Cwd:      cwd,
+			Capabilities: &specs.LinuxCapabilities{
+				Bounding:  runcDefaultCapabilities,
+				Effective: runcDefaultCapabilities,
+				Permitted: runcDefaultCapabilities,
+			},
+			Rlimits: []specs.POSIXRlimit{{Type: "RLIMIT_NOFILE", Hard: 1048576, Soft: 1048576}},
+		},
+		Root:     &specs.Root{Path: "rootfs"},
+		Hostname: "werf",
+		Mounts:   mounts,
+		Linux:    linux,
+	}, nil
+}
+
+func runcResources(options *StageImageContainerOptions) (*specs.LinuxResources, error) {
+	resources := &specs.LinuxResources{}
+
+	if options.Memory != "" {
+		limit, err := units.RAMInBytes(options.Memory)
+		if err != nil {
+			return nil, fmt.Errorf("bad memory limit %s: %s", options.Memory, err)
+		}
+		resources.Memory = &specs.LinuxMemory{Limit: &limit}
+	}
+
+	if options.Cpus != "" {
+		cpus, err := strconv.ParseFloat(options.Cpus, 64)
+		if err != nil {
+			return nil, fmt.Errorf("bad cpus limit %s: %s", options.Cpus, err)
+		}
+
+		period := uint64(100000)
+		quota := int64(cpus * float64(period))
+		resources.CPU = &specs.LinuxCPU{Quota: &quota, Period: &period}
+	}
+
+	return resources, nil
+}
+
+func runcEnv(imageEnv []string, env map[string]string) []string {
+	envMap := map[string]string{"PATH": "/usr/local/sbin:/usr/local/bin:/usr/sbin:/usr/bin:/sbin:/bin"}
+	for _, e := range imageEnv {
+		parts := strings.SplitN(e, "=", 2)
+		if len(parts) == 2 {
+			envMap[parts[0]] = parts[1]
+		}
+	}
+
+	for key, value := range env {
+		envMap[key] = value
+	}
+
+	var result []string
+	for key, value := range envMap {
+		result = append(result, fmt.Sprintf("%s=%s", key, value))
+	}
+	sort.Strings(result)
+
+	return result
+}
+
+// resolveRootfsUser resolves user and group names of the user[:group] option by /etc/passwd and /etc/group of the rootfs
+func resolveRootfsUser(rootfsDir, user string) (uint32, uint32, error) {
+	if user == "" {
+		return 0, 0, nil
+	}
+
+	parts := strings.SplitN(user, ":", 2)
+
+	uid, gid, err := lookupRootfsId(filepath.Join(rootfsDir, "etc/passwd"), parts[0])
+	if err != nil {
+		return 0, 0, fmt.Errorf("unable to resolve user %s: %s", parts[0], err)
+	}
+
+	if len(parts) == 2 {
+		if gid, _, err = lookupRootfsId(filepath.Join(rootfsDir, "etc/group"), parts[1]); err != nil {
+			return 0, 0, fmt.Errorf("unable to resolve group %s: %s", parts[1], err)
+		}
+	}
+
+	return uid, gid, nil
+}
+
+// lookupRootfsId returns id and the next numeric field of the passwd or group file entry (gid for passwd)
+func lookupRootfsId(path, name string) (uint32, uint32, error) {
+	if id, err := strconv.ParseUint(name, 10, 32); err == nil {
+		return uint32(id), 0, nil
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return 0, 0, err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		fields := strings.Split(scanner.Text(), ":")
+		if len(fields) < 3 || fields[0] != name {
+			continue
+		}
+
+		id, err := strconv.ParseUint(fields[2], 10, 32)
+		if err != nil {
+			return 0, 0, fmt.Errorf("bad %s entry %s", path, fields[0])
+		}
+
+		var nextId uint64
+		if len(fields) > 3 {
+			nextId, _ = strconv.ParseUint(fields[3], 10, 32)
+		}
+
+		return uint32(id), uint32(nextId), nil
+	}
+
+	if err := scanner.Err(); err != nil {
+		return 0, 0, err
+	}
+
+	return 0, 0, fmt.Errorf("%s not found in %s", name, path)
+}
+
+func (b *RuncBackend) getContainer(containerName string) (*runcContainer, error) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	container, ok := b.containers[containerName]
+	if !ok {
+		return nil, fmt.Errorf("container %s not found", containerName)
+	}
+
+	return container, nil
+}
+
+func (b *RuncBackend) Kill(containerName string) error {
+	output, err := exec.Command(b.RuncBinPath, "kill", containerName, "KILL").CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("runc kill %s failed: %s\n%s", containerName, err, output)
+	}
+
+	return nil
+}
+
+func (b *RuncBackend) Commit(containerName string, options *StageImageContainerOptions) (string, error) {
+	container, err := b.getContainer(containerName)
+	if err != nil {
+		return "", err
+	}
+
+	if b.ImageSource == nil {
+		return "", fmt.Errorf("image source is required to commit container %s", containerName)
+	}
+
+	rootfsDir := filepath.Join(container.bundleDir, "rootfs")
+	layerPath := filepath.Join(container.bundleDir, "layer.tar")
+
+	layerFile, err := os.Create(layerPath)
+	if err != nil {
+		return "", err
+	}
+
+	err = rootfs.WriteLayer(rootfsDir, container.snapshot, layerFile)
+	layerFile.Close()
+	if err != nil {
+		return "", fmt.Errorf("unable to write container %s layer: %s", containerName, err)
+	}
+
+	config, err := b.ImageConfig(container.imageId)
+	if err != nil {
+		return "", err
+	}
+
+	if err := applyRuncCommitOptions(&config.Config, options); err != nil {
+		return "", err
+	}
+
+	baseImage, release, err := b.ImageSource(container.imageId)
+	if err != nil {
+		return "", fmt.Errorf("unable to get image %s: %s", container.imageId, err)
+	}
+	defer release()
+
+	img, err := runcCommitImage(baseImage, layerPath, config.Config, time.Now().UTC())
+	if err != nil {
+		return "", fmt.Errorf("unable to prepare container %s image: %s", containerName, err)
+	}
+
+	configName, err := img.ConfigName()
+	if err != nil {
+		return "", err
+	}
+	imageId := configName.String()
+
+	rawConfig, err := img.RawConfigFile()
+	if err != nil {
+		return "", err
+	}
+
+	if b.ImageTarget != nil {
+		if err := b.ImageTarget(img); err != nil {
+			return "", fmt.Errorf("unable to save image %s: %s", imageId, err)
+		}
+	}
+
+	imageDir := b.imageDir(imageId)
+	if err := os.MkdirAll(imageDir, 0755); err != nil {
+		return "", err
+	}
+
+	if err := ioutil.WriteFile(filepath.Join(imageDir, "config.json"), rawConfig, 0644); err != nil {
+		return "", err
+	}
+
+	if err := os.Rename(layerPath, b.ImageLayerPath(imageId)); err != nil {
+		return "", err
+	}
+
+	if err := os.Rename(rootfsDir, b.ImageRootfsDir(imageId)); err != nil {
+		return "", err
+	}
+
+	return imageId, nil
+}
+
+// runcCommitImage appends the stage layer to the base image with the stage container config
+func runcCommitImage(baseImage v1.Image, layerPath string, config v1.Config, created time.Time) (v1.Image, error) {
+	layer, err := tarball.LayerFromFile(layerPath)
+	if err != nil {
+		return nil, err
+	}
+
+	img, err := mutate.Append(baseImage, mutate.Addendum{
+		Layer:   layer,
+		History: v1.History{Created: v1.Time{Time: created}, CreatedBy: "werf stapel stage"},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if img, err = mutate.Config(img, config); err != nil {
+		return nil, err
+	}
+
+	return mutate.CreatedAt(img, v1.Time{Time: created})
+}
+
+func applyRuncCommitOptions(config *v1.Config, options *StageImageContainerOptions) error {
+	if options.HealthCheck != "" {
+		return fmt.Errorf("healthcheck is not supported by runc backend")
+	}
+
+	for _, volume := range options.Volume {
+		if config.Volumes == nil {
+			config.Volumes = map[string]struct{}{}
+		}
+		config.Volumes[volume] = struct{}{}
+	}
+
+	for _, port := range options.Expose {
+		if !strings.Contains(port, "/") {
+			port += "/tcp"
+		}
+		if config.ExposedPorts == nil {
+			config.ExposedPorts = map[string]struct{}{}
+		}
+		config.ExposedPorts[port] = struct{}{}
+	}
+
+	if len(options.Env) != 0 {
+		var env []string
+		for _, e := range config.Env {
+			if _, overridden := options.Env[strings.SplitN(e, "=", 2)[0]]; !overridden {
+				env = append(env, e)
+			}
+		}
+
+		var keys []string
+		for key := range options.Env {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+
+		for _, key := range keys {
+			env = append(env, fmt.Sprintf("%s=%s", key, options.Env[key]))
+		}
+
+		config.Env = env
+	}
+
+	for key, value := range options.Label {
+		if config.Labels == nil {
+			config.Labels = map[string]string{}
+		}
+		config.Labels[key] = value
+	}
+
+	if options.Workdir != "" {
+		config.WorkingDir = options.Workdir
+	}
+
+	if options.User != "" {
+		config.User = options.User
+	}
+
+	var err error
+	if config.Entrypoint, err = parseInstructionCommand(options.Entrypoint); err != nil {
+		return fmt.Errorf("bad entrypoint: %s", err)
+	}
+
+	if config.Cmd, err = parseInstructionCommand(options.Cmd); err != nil {
+		return fmt.Errorf("bad cmd: %s", err)
+	}
+
+	return nil
+}
+
+// parseInstructionCommand parses exec form (json array) or shell form of the CMD and ENTRYPOINT instructions
+func parseInstructionCommand(value string) ([]string, error) {
+	value = strings.TrimSpace(value)
+
+	if value == "" {
+		return nil, nil
+	}
+
+	if strings.HasPrefix(value, "[") {
+		var command []string
+		if err := json.Unmarshal([]byte(value), &command); err != nil {
+			return nil, err
+		}
+		return command, nil
+	}
+
+	return []string{"/bin/sh", "-c", value}, nil
+}
+
+func (b *RuncBackend) Remove(containerName string) error {
+	container, err := b.getContainer(containerName)
+	if err != nil {
+		return err
+	}
+
+	if output, err := exec.Command(b.RuncBinPath, "delete", "--force", containerName).CombinedOutput(); err != nil {
+		logboek.Debug.LogF("runc delete %s failed: %s\n%s", containerName, err, output)
+	}
+
+	if err := os.RemoveAll(container.bundleDir); err != nil {
+		return err
+	}
+
+	b.mutex.Lock()
+	delete(b.containers, containerName)
+	b.mutex.Unlock()
+
+	return nil
+}
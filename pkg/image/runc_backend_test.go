@@ -0,0 +1,133 @@
+// +build linux
+
+package image
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/random"
+)
+
+// fakeRuncScript writes the file into the container rootfs instead of running the command
+const fakeRuncScript = `#!/bin/sh
+if [ "$1" = "run" ]; then
+  mkdir -p "$3/rootfs/app" && echo built > "$3/rootfs/app/result"
+fi
+`
+
+func TestRuncBackend_RunAndCommit(t *testing.T) {
+	storeDir, err := ioutil.TempDir("", "werf-runc-backend-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(storeDir)
+
+	runcBinPath := filepath.Join(storeDir, "runc")
+	if err := ioutil.WriteFile(runcBinPath, []byte(fakeRuncScript), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	baseImage, err := random.Image(64, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	baseImageName, err := baseImage.ConfigName()
+	if err != nil {
+		t.Fatal(err)
+	}
+	baseImageId := baseImageName.String()
+
+	var sourceRefs []string
+	var targetImages []v1.Image
+
+	b := NewRuncBackend(filepath.Join(storeDir, "store"), filepath.Join(storeDir, "stapel"))
+	b.RuncBinPath = runcBinPath
+	b.ImageSource = func(ref string) (v1.Image, func(), error) {
+		sourceRefs = append(sourceRefs, ref)
+		if ref != baseImageId {
+			return nil, nil, fmt.Errorf("unexpected image %s", ref)
+		}
+		return baseImage, func() {}, nil
+	}
+	b.ImageTarget = func(img v1.Image) error {
+		targetImages = append(targetImages, img)
+		return nil
+	}
+
+	spec := ContainerSpec{Name: "stage", ImageId: baseImageId, Options: newStageContainerOptions(), Command: "true"}
+	if err := b.Run(spec); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := b.ImageConfig(baseImageId); err != nil {
+		t.Fatalf("base image is not imported: %s", err)
+	}
+
+	options := newStageContainerOptions()
+	options.AddLabel(map[string]string{"werf-stage": "test"})
+
+	imageId, err := b.Commit("stage", options)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(targetImages) != 1 {
+		t.Fatalf("expected 1 image saved into the target, got %d", len(targetImages))
+	}
+
+	targetImageName, err := targetImages[0].ConfigName()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if targetImageName.String() != imageId {
+		t.Errorf("expected target image %s, got %s", imageId, targetImageName)
+	}
+
+	layers, err := targetImages[0].Layers()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(layers) != 3 {
+		t.Errorf("expected 3 layers, got %d", len(layers))
+	}
+
+	config, err := b.ImageConfig(imageId)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if config.Config.Labels["werf-stage"] != "test" {
+		t.Errorf("expected label werf-stage=test, got %v", config.Config.Labels)
+	}
+	if len(config.RootFS.DiffIDs) != 3 {
+		t.Errorf("expected 3 diff ids, got %v", config.RootFS.DiffIDs)
+	}
+
+	data, err := ioutil.ReadFile(filepath.Join(b.ImageRootfsDir(imageId), "app", "result"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != "built\n" {
+		t.Errorf("unexpected committed file content %q", data)
+	}
+
+	if _, err := os.Stat(b.ImageLayerPath(imageId)); err != nil {
+		t.Errorf("stage layer is not saved: %s", err)
+	}
+
+	// the committed image is taken from the store by the next stage container
+	if err := b.Run(ContainerSpec{Name: "next-stage", ImageId: imageId, Options: newStageContainerOptions(), Command: "true"}); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, ref := range sourceRefs {
+		if ref != baseImageId {
+			t.Errorf("unexpected image %s requested from the source", ref)
+		}
+	}
+}
@@ -0,0 +1,109 @@
+// +build linux
+
+package image
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/google/go-containerregistry/pkg/name"
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/tarball"
+
+	"github.com/flant/werf/pkg/docker"
+	"github.com/flant/werf/pkg/stapel"
+	"github.com/flant/werf/pkg/werf"
+)
+
+// NewDockerRuncBackend creates the runc backend, which takes base images from docker daemon and loads the committed stages back,
+// so the stages storage, tagging and publishing work as with the docker backend. Docker daemon is required by this backend
+func NewDockerRuncBackend(storeDir string) (ContainerBackend, error) {
+	b := NewRuncBackend(storeDir, "")
+	b.ImageSource = dockerRuncImageSource
+	b.ImageTarget = dockerRuncImageTarget
+
+	stapelImageId, err := importDockerStapelImage(b)
+	if err != nil {
+		return nil, fmt.Errorf("unable to import stapel image: %s", err)
+	}
+	b.StapelDir = filepath.Join(b.ImageRootfsDir(stapelImageId), runcStapelMountDir)
+
+	return b, nil
+}
+
+func importDockerStapelImage(b *RuncBackend) (string, error) {
+	stapelImageName := stapel.ImageName()
+	if exist, err := docker.ImageExist(stapelImageName); err != nil {
+		return "", err
+	} else if !exist {
+		if err := docker.CliPullWithRetries(stapelImageName); err != nil {
+			return "", err
+		}
+	}
+
+	inspect, err := docker.ImageInspect(stapelImageName)
+	if err != nil {
+		return "", err
+	}
+
+	return b.importSourceImage(inspect.ID)
+}
+
+func dockerRuncImageSource(ref string) (v1.Image, func(), error) {
+	f, err := ioutil.TempFile(werf.GetTmpDir(), "runc-image-*.tar")
+	if err != nil {
+		return nil, nil, err
+	}
+	release := func() { os.Remove(f.Name()) }
+
+	err = func() error {
+		defer f.Close()
+
+		rc, err := docker.ImageSave(ref)
+		if err != nil {
+			return err
+		}
+		defer rc.Close()
+
+		_, err = io.Copy(f, rc)
+		return err
+	}()
+	if err != nil {
+		release()
+		return nil, nil, fmt.Errorf("unable to save docker image %s: %s", ref, err)
+	}
+
+	img, err := tarball.ImageFromPath(f.Name(), nil)
+	if err != nil {
+		release()
+		return nil, nil, err
+	}
+
+	return img, release, nil
+}
+
+// dockerRuncImageTarget loads the untagged image into docker daemon, the image is tagged by the stages storage as the docker backend images
+func dockerRuncImageTarget(img v1.Image) error {
+	configName, err := img.ConfigName()
+	if err != nil {
+		return err
+	}
+
+	ref, err := name.NewDigest(fmt.Sprintf("werf-stage@%s", configName))
+	if err != nil {
+		return err
+	}
+
+	pr, pw := io.Pipe()
+	go func() {
+		pw.CloseWithError(tarball.Write(ref, img, pw))
+	}()
+
+	err = docker.ImageLoad(pr)
+	pr.Close()
+
+	return err
+}
@@ -0,0 +1,12 @@
+// +build !linux
+
+package image
+
+import (
+	"fmt"
+	"runtime"
+)
+
+func NewDockerRuncBackend(_ string) (ContainerBackend, error) {
+	return nil, fmt.Errorf("runc container backend is not supported on %s", runtime.GOOS)
+}
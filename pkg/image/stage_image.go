@@ -11,6 +11,7 @@ import (
 
 	"github.com/flant/shluz"
 	"github.com/flant/werf/pkg/docker"
+	"github.com/flant/werf/pkg/logging"
 )
 
 type StageImage struct {
@@ -29,6 +30,10 @@ func NewStageImage(fromImage *StageImage, name string) *StageImage {
 	return stage
 }
 
+func (i *StageImage) FromImage() *StageImage {
+	return i.fromImage
+}
+
 func (i *StageImage) Inspect() *types.ImageInspect {
 	return i.inspect
 }
@@ -93,17 +98,8 @@ func (i *StageImage) Build(options BuildOptions) error {
 	}
 	defer shluz.Unlock(containerLockName)
 
-	if debugDockerRunCommand() {
-		runArgs, err := i.container.prepareRunArgs()
-		if err != nil {
-			return err
-		}
-
-		fmt.Printf("Docker run command:\ndocker run %s\n", strings.Join(runArgs, " "))
-
-		if len(i.container.prepareAllRunCommands()) != 0 {
-			fmt.Printf("Decoded command:\n%s\n", strings.Join(i.container.prepareAllRunCommands(), " && "))
-		}
+	if debugDockerRunCommand() && len(i.container.prepareAllRunCommands()) != 0 {
+		fmt.Printf("Decoded command:\n%s\n", strings.Join(i.container.prepareAllRunCommands(), " && "))
 	}
 
 	if containerRunErr := i.container.run(); containerRunErr != nil {
@@ -242,19 +238,19 @@ func (i *StageImage) Import(name string) error {
 }
 
 func (i *StageImage) Export(name string) error {
-	if err := logboek.Info.LogProcess(fmt.Sprintf("Tagging %s", name), logboek.LevelLogProcessOptions{}, func() error {
+	if err := logging.LevelLogProcess(logboek.Info, fmt.Sprintf("Tagging %s", name), logboek.LevelLogProcessOptions{}, func() error {
 		return i.Tag(name)
 	}); err != nil {
 		return err
 	}
 
-	if err := logboek.Info.LogProcess(fmt.Sprintf("Pushing %s", name), logboek.LevelLogProcessOptions{}, func() error {
+	if err := logging.LevelLogProcess(logboek.Info, fmt.Sprintf("Pushing %s", name), logboek.LevelLogProcessOptions{}, func() error {
 		return docker.CliPushWithRetries(name)
 	}); err != nil {
 		return err
 	}
 
-	if err := logboek.Info.LogProcess(fmt.Sprintf("Untagging %s", name), logboek.LevelLogProcessOptions{}, func() error {
+	if err := logging.LevelLogProcess(logboek.Info, fmt.Sprintf("Untagging %s", name), logboek.LevelLogProcessOptions{}, func() error {
 		return docker.CliRmi(name)
 	}); err != nil {
 		return err
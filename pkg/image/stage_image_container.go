@@ -4,11 +4,10 @@ import (
 	"encoding/base64"
 	"fmt"
 	"strings"
-
-	"github.com/docker/docker/api/types"
+	"sync/atomic"
+	"time"
 
 	"github.com/flant/logboek"
-	"github.com/flant/werf/pkg/docker"
 	"github.com/flant/werf/pkg/stapel"
 	"github.com/flant/werf/pkg/util"
 )
@@ -65,34 +64,20 @@ func (c *StageImageContainer) ServiceCommitChangeOptions() ContainerOptions {
 	return c.serviceCommitChangeOptions
 }
 
-func (c *StageImageContainer) prepareRunArgs() ([]string, error) {
-	var args []string
-	args = append(args, fmt.Sprintf("--name=%s", c.name))
-
+func (c *StageImageContainer) prepareRunSpec() (ContainerSpec, error) {
 	runOptions, err := c.prepareRunOptions()
 	if err != nil {
-		return nil, err
-	}
-
-	runArgs, err := runOptions.toRunArgs()
-	if err != nil {
-		return nil, err
+		return ContainerSpec{}, err
 	}
 
-	setColumnsEnv := fmt.Sprintf("--env=COLUMNS=%d", logboek.ContentWidth())
-	runArgs = append(runArgs, setColumnsEnv)
+	runOptions.Env["COLUMNS"] = fmt.Sprintf("%d", logboek.ContentWidth())
 
 	fromImageId, err := c.image.fromImage.MustGetId()
 	if err != nil {
-		return nil, err
+		return ContainerSpec{}, err
 	}
 
-	args = append(args, runArgs...)
-	args = append(args, fromImageId)
-	args = append(args, "-ec")
-	args = append(args, c.prepareRunCommand())
-
-	return args, nil
+	return ContainerSpec{Name: c.name, ImageId: fromImageId, Options: runOptions, Command: c.prepareRunCommand()}, nil
 }
 
 func (c *StageImageContainer) prepareRunCommand() string {
@@ -125,59 +110,32 @@ func ShelloutPack(command string) string {
 	return fmt.Sprintf("eval $(echo %s | %s --decode)", base64.StdEncoding.EncodeToString([]byte(command)), stapel.Base64BinPath())
 }
 
-func (c *StageImageContainer) prepareIntrospectBeforeArgs() ([]string, error) {
-	args, err := c.prepareIntrospectArgsBase()
+func (c *StageImageContainer) prepareIntrospectBeforeSpec() (ContainerSpec, error) {
+	runOptions, err := c.prepareIntrospectOptions()
 	if err != nil {
-		return nil, err
+		return ContainerSpec{}, err
 	}
 
 	fromImageId, err := c.image.fromImage.MustGetId()
 	if err != nil {
-		return nil, err
+		return ContainerSpec{}, err
 	}
 
-	args = append(args, fromImageId)
-	args = append(args, "-ec")
-	args = append(args, stapel.BashBinPath())
-
-	return args, nil
+	return ContainerSpec{ImageId: fromImageId, Options: runOptions, Command: stapel.BashBinPath()}, nil
 }
 
-func (c *StageImageContainer) prepareIntrospectArgs() ([]string, error) {
-	args, err := c.prepareIntrospectArgsBase()
-	if err != nil {
-		return nil, err
-	}
-
-	imageId, err := c.image.MustGetId()
-	if err != nil {
-		return nil, err
-	}
-
-	args = append(args, imageId)
-	args = append(args, "-ec")
-	args = append(args, stapel.BashBinPath())
-
-	return args, nil
-}
-
-func (c *StageImageContainer) prepareIntrospectArgsBase() ([]string, error) {
-	var args []string
-
+func (c *StageImageContainer) prepareIntrospectSpec() (ContainerSpec, error) {
 	runOptions, err := c.prepareIntrospectOptions()
 	if err != nil {
-		return nil, err
+		return ContainerSpec{}, err
 	}
 
-	runArgs, err := runOptions.toRunArgs()
+	imageId, err := c.image.MustGetId()
 	if err != nil {
-		return nil, err
+		return ContainerSpec{}, err
 	}
 
-	args = append(args, []string{"-ti", "--rm"}...)
-	args = append(args, runArgs...)
-
-	return args, nil
+	return ContainerSpec{ImageId: imageId, Options: runOptions, Command: stapel.BashBinPath()}, nil
 }
 
 func (c *StageImageContainer) prepareRunOptions() (*StageImageContainerOptions, error) {
@@ -194,13 +152,6 @@ func (c *StageImageContainer) prepareServiceRunOptions() (*StageImageContainerOp
 	serviceRunOptions.Entrypoint = stapel.BashBinPath()
 	serviceRunOptions.User = "0:0"
 
-	stapelContainerName, err := stapel.GetOrCreateContainer()
-	if err != nil {
-		return nil, err
-	}
-
-	serviceRunOptions.VolumesFrom = []string{stapelContainerName}
-
 	return serviceRunOptions, nil
 }
 
@@ -208,19 +159,6 @@ func (c *StageImageContainer) prepareIntrospectOptions() (*StageImageContainerOp
 	return c.prepareRunOptions()
 }
 
-func (c *StageImageContainer) prepareCommitChanges() ([]string, error) {
-	commitOptions, err := c.prepareCommitOptions()
-	if err != nil {
-		return nil, err
-	}
-
-	commitChanges, err := commitOptions.prepareCommitChanges()
-	if err != nil {
-		return nil, err
-	}
-	return commitChanges, nil
-}
-
 func (c *StageImageContainer) prepareCommitOptions() (*StageImageContainerOptions, error) {
 	inheritedCommitOptions, err := c.prepareInheritedCommitOptions()
 	if err != nil {
@@ -261,12 +199,27 @@ func (c *StageImageContainer) prepareInheritedCommitOptions() (*StageImageContai
 }
 
 func (c *StageImageContainer) run() error {
-	runArgs, err := c.prepareRunArgs()
+	spec, err := c.prepareRunSpec()
 	if err != nil {
 		return err
 	}
 
-	if err := docker.CliRun_LiveOutput(runArgs...); err != nil {
+	var timeoutExceeded int32
+	if timeout := c.runOptions.Timeout; timeout != 0 {
+		timer := time.AfterFunc(timeout, func() {
+			atomic.StoreInt32(&timeoutExceeded, 1)
+			if err := GetContainerBackend().Kill(c.name); err != nil {
+				logboek.LogErrorF("WARNING: unable to kill container %s: %s\n", c.name, err)
+			}
+		})
+		defer timer.Stop()
+	}
+
+	if err := GetContainerBackend().Run(spec); err != nil {
+		if atomic.LoadInt32(&timeoutExceeded) == 1 {
+			return fmt.Errorf("container run failed: timeout %s exceeded, container has been killed", c.runOptions.Timeout)
+		}
+
 		return fmt.Errorf("container run failed: %s", err.Error())
 	}
 
@@ -274,33 +227,21 @@ func (c *StageImageContainer) run() error {
 }
 
 func (c *StageImageContainer) introspect() error {
-	runArgs, err := c.prepareIntrospectArgs()
+	spec, err := c.prepareIntrospectSpec()
 	if err != nil {
 		return err
 	}
 
-	if err := docker.CliRun_LiveOutput(runArgs...); err != nil {
-		if !strings.Contains(err.Error(), "Code: ") || IsStartContainerErr(err) {
-			return err
-		}
-	}
-
-	return nil
+	return GetContainerBackend().Introspect(spec)
 }
 
 func (c *StageImageContainer) introspectBefore() error {
-	runArgs, err := c.prepareIntrospectBeforeArgs()
+	spec, err := c.prepareIntrospectBeforeSpec()
 	if err != nil {
 		return err
 	}
 
-	if err := docker.CliRun_LiveOutput(runArgs...); err != nil {
-		if !strings.Contains(err.Error(), "Code: ") || IsStartContainerErr(err) {
-			return err
-		}
-	}
-
-	return nil
+	return GetContainerBackend().Introspect(spec)
 }
 
 // https://docs.docker.com/engine/reference/run/#exit-status
@@ -315,20 +256,14 @@ func IsStartContainerErr(err error) bool {
 }
 
 func (c *StageImageContainer) commit() (string, error) {
-	commitChanges, err := c.prepareCommitChanges()
-	if err != nil {
-		return "", err
-	}
-
-	commitOptions := types.ContainerCommitOptions{Changes: commitChanges}
-	id, err := docker.ContainerCommit(c.name, commitOptions)
+	commitOptions, err := c.prepareCommitOptions()
 	if err != nil {
 		return "", err
 	}
 
-	return id, nil
+	return GetContainerBackend().Commit(c.name, commitOptions)
 }
 
 func (c *StageImageContainer) rm() error {
-	return docker.ContainerRemove(c.name, types.ContainerRemoveOptions{})
+	return GetContainerBackend().Remove(c.name)
 }
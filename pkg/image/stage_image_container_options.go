@@ -2,6 +2,7 @@ package image
 
 import (
 	"fmt"
+	"time"
 
 	"github.com/hashicorp/go-version"
 
@@ -19,6 +20,11 @@ type StageImageContainerOptions struct {
 	User        string
 	Entrypoint  string
 	HealthCheck string
+	Cpus        string
+	Memory      string
+	Network     string
+	// Timeout of the container run, the container is killed when exceeded
+	Timeout time.Duration
 }
 
 func newStageContainerOptions() *StageImageContainerOptions {
@@ -72,6 +78,22 @@ func (co *StageImageContainerOptions) AddEntrypoint(entrypoint string) {
 	co.Entrypoint = entrypoint
 }
 
+func (co *StageImageContainerOptions) AddCpus(cpus string) {
+	co.Cpus = cpus
+}
+
+func (co *StageImageContainerOptions) AddMemory(memory string) {
+	co.Memory = memory
+}
+
+func (co *StageImageContainerOptions) AddNetwork(network string) {
+	co.Network = network
+}
+
+func (co *StageImageContainerOptions) AddTimeout(timeout time.Duration) {
+	co.Timeout = timeout
+}
+
 func (co *StageImageContainerOptions) merge(co2 *StageImageContainerOptions) *StageImageContainerOptions {
 	mergedCo := newStageContainerOptions()
 	mergedCo.Volume = append(co.Volume, co2.Volume...)
@@ -122,6 +144,30 @@ func (co *StageImageContainerOptions) merge(co2 *StageImageContainerOptions) *St
 		mergedCo.HealthCheck = co2.HealthCheck
 	}
 
+	if co2.Cpus == "" {
+		mergedCo.Cpus = co.Cpus
+	} else {
+		mergedCo.Cpus = co2.Cpus
+	}
+
+	if co2.Memory == "" {
+		mergedCo.Memory = co.Memory
+	} else {
+		mergedCo.Memory = co2.Memory
+	}
+
+	if co2.Network == "" {
+		mergedCo.Network = co.Network
+	} else {
+		mergedCo.Network = co2.Network
+	}
+
+	if co2.Timeout == 0 {
+		mergedCo.Timeout = co.Timeout
+	} else {
+		mergedCo.Timeout = co2.Timeout
+	}
+
 	return mergedCo
 }
 
@@ -156,6 +202,18 @@ func (co *StageImageContainerOptions) toRunArgs() ([]string, error) {
 		args = append(args, fmt.Sprintf("--entrypoint=%s", co.Entrypoint))
 	}
 
+	if co.Cpus != "" {
+		args = append(args, fmt.Sprintf("--cpus=%s", co.Cpus))
+	}
+
+	if co.Memory != "" {
+		args = append(args, fmt.Sprintf("--memory=%s", co.Memory))
+	}
+
+	if co.Network != "" {
+		args = append(args, fmt.Sprintf("--network=%s", co.Network))
+	}
+
 	return args, nil
 }
 
@@ -0,0 +1,179 @@
+package image_signing
+
+import (
+	"crypto/ecdsa"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/asn1"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math/big"
+
+	"github.com/flant/werf/pkg/docker_registry"
+)
+
+const SimpleSigningType = "cosign container image signature"
+
+// SimpleSigningPayload is the signed document of the cosign signature format
+type SimpleSigningPayload struct {
+	Critical struct {
+		Identity struct {
+			DockerReference string `json:"docker-reference"`
+		} `json:"identity"`
+		Image struct {
+			DockerManifestDigest string `json:"docker-manifest-digest"`
+		} `json:"image"`
+		Type string `json:"type"`
+	} `json:"critical"`
+	Optional map[string]interface{} `json:"optional"`
+}
+
+func NewSimpleSigningPayload(repository, digest string) ([]byte, error) {
+	payload := SimpleSigningPayload{}
+	payload.Critical.Identity.DockerReference = repository
+	payload.Critical.Image.DockerManifestDigest = digest
+	payload.Critical.Type = SimpleSigningType
+
+	return json.Marshal(payload)
+}
+
+type ecdsaSignature struct {
+	R, S *big.Int
+}
+
+type Signer struct {
+	key *ecdsa.PrivateKey
+}
+
+func NewSigner(keyPath string) (*Signer, error) {
+	key, err := LoadPrivateKey(keyPath)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Signer{key: key}, nil
+}
+
+func (s *Signer) Sign(payload []byte) (string, error) {
+	sum := sha256.Sum256(payload)
+	sigR, sigS, err := ecdsa.Sign(rand.Reader, s.key, sum[:])
+	if err != nil {
+		return "", err
+	}
+
+	signature, err := asn1.Marshal(ecdsaSignature{R: sigR, S: sigS})
+	if err != nil {
+		return "", err
+	}
+
+	return base64.StdEncoding.EncodeToString(signature), nil
+}
+
+func (s *Signer) Verifier() *Verifier {
+	return &Verifier{key: &s.key.PublicKey}
+}
+
+// SignImage pushes detached signature of the image manifest into the image repository.
+// Nothing is pushed when the image already has a valid signature made with the same key.
+func (s *Signer) SignImage(reference string) (bool, error) {
+	repository, digest, err := docker_registry.ImageRepositoryAndDigest(reference)
+	if err != nil {
+		return false, fmt.Errorf("unable to get image %s digest: %s", reference, err)
+	}
+
+	signed, err := s.Verifier().isImageSigned(repository, digest)
+	if err != nil {
+		return false, err
+	}
+
+	if signed {
+		return false, nil
+	}
+
+	payload, err := NewSimpleSigningPayload(repository, digest)
+	if err != nil {
+		return false, err
+	}
+
+	signature, err := s.Sign(payload)
+	if err != nil {
+		return false, fmt.Errorf("unable to sign image %s: %s", reference, err)
+	}
+
+	if err := docker_registry.PushImageSignature(repository, digest, docker_registry.ImageSignature{Payload: payload, Signature: signature}); err != nil {
+		return false, fmt.Errorf("unable to push image %s signature: %s", reference, err)
+	}
+
+	return true, nil
+}
+
+type Verifier struct {
+	key *ecdsa.PublicKey
+}
+
+func NewVerifier(keyPath string) (*Verifier, error) {
+	key, err := LoadPublicKey(keyPath)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Verifier{key: key}, nil
+}
+
+func (v *Verifier) Verify(payload []byte, signature string) bool {
+	rawSignature, err := base64.StdEncoding.DecodeString(signature)
+	if err != nil {
+		return false
+	}
+
+	sig := ecdsaSignature{}
+	if rest, err := asn1.Unmarshal(rawSignature, &sig); err != nil || len(rest) != 0 {
+		return false
+	}
+
+	sum := sha256.Sum256(payload)
+	return ecdsa.Verify(v.key, sum[:], sig.R, sig.S)
+}
+
+func (v *Verifier) VerifyImage(reference string) error {
+	repository, digest, err := docker_registry.ImageRepositoryAndDigest(reference)
+	if err != nil {
+		return fmt.Errorf("unable to get image %s digest: %s", reference, err)
+	}
+
+	signed, err := v.isImageSigned(repository, digest)
+	if err != nil {
+		return err
+	}
+
+	if !signed {
+		return fmt.Errorf("image %s (%s) has no valid signature", reference, digest)
+	}
+
+	return nil
+}
+
+func (v *Verifier) isImageSigned(repository, digest string) (bool, error) {
+	signatures, err := docker_registry.ImageSignatures(repository, digest)
+	if err != nil {
+		return false, fmt.Errorf("unable to get signatures of %s@%s: %s", repository, digest, err)
+	}
+
+	for _, signature := range signatures {
+		payload := SimpleSigningPayload{}
+		if err := json.Unmarshal(signature.Payload, &payload); err != nil {
+			continue
+		}
+
+		if payload.Critical.Image.DockerManifestDigest != digest {
+			continue
+		}
+
+		if v.Verify(signature.Payload, signature.Signature) {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
@@ -0,0 +1,175 @@
+package image_signing
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"io/ioutil"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/google/go-containerregistry/pkg/name"
+	"github.com/google/go-containerregistry/pkg/registry"
+	"github.com/google/go-containerregistry/pkg/v1/random"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+	"golang.org/x/crypto/nacl/secretbox"
+	"golang.org/x/crypto/scrypt"
+)
+
+func generateKey(t *testing.T) *ecdsa.PrivateKey {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return key
+}
+
+func writeKeys(t *testing.T, dir string, key *ecdsa.PrivateKey) (string, string) {
+	privateDer, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	publicDer, err := x509.MarshalPKIXPublicKey(&key.PublicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	privatePath := filepath.Join(dir, "cosign.key")
+	publicPath := filepath.Join(dir, "cosign.pub")
+
+	if err := ioutil.WriteFile(privatePath, pem.EncodeToMemory(&pem.Block{Type: pkcs8PrivateKeyPemType, Bytes: privateDer}), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := ioutil.WriteFile(publicPath, pem.EncodeToMemory(&pem.Block{Type: publicKeyPemType, Bytes: publicDer}), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	return privatePath, publicPath
+}
+
+func TestSignAndVerifyImage(t *testing.T) {
+	server := httptest.NewServer(registry.New())
+	defer server.Close()
+
+	imageName := strings.TrimPrefix(server.URL, "http://") + "/project/app:v1"
+
+	ref, err := name.ParseReference(imageName, name.WeakValidation)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	img, err := random.Image(1024, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := remote.Write(ref, img); err != nil {
+		t.Fatal(err)
+	}
+
+	dir, err := ioutil.TempDir("", "werf-image-signing-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	privatePath, publicPath := writeKeys(t, dir, generateKey(t))
+
+	signer, err := NewSigner(privatePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	verifier, err := NewVerifier(publicPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := verifier.VerifyImage(imageName); err == nil {
+		t.Fatal("verification of unsigned image expected to fail")
+	}
+
+	if signed, err := signer.SignImage(imageName); err != nil {
+		t.Fatal(err)
+	} else if !signed {
+		t.Fatal("image expected to be signed")
+	}
+
+	if signed, err := signer.SignImage(imageName); err != nil {
+		t.Fatal(err)
+	} else if signed {
+		t.Fatal("image signature expected to be up-to-date")
+	}
+
+	if err := verifier.VerifyImage(imageName); err != nil {
+		t.Fatal(err)
+	}
+
+	_, otherPublicPath := writeKeys(t, dir, generateKey(t))
+	otherVerifier, err := NewVerifier(otherPublicPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := otherVerifier.VerifyImage(imageName); err == nil {
+		t.Fatal("verification with another key expected to fail")
+	}
+}
+
+func TestParsePrivateKey_encrypted(t *testing.T) {
+	key := generateKey(t)
+	password := []byte("secret")
+
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	envelope := &encryptedKeyEnvelope{}
+	envelope.KDF.Name = "scrypt"
+	envelope.KDF.Params.N = 1024
+	envelope.KDF.Params.R = 8
+	envelope.KDF.Params.P = 1
+	envelope.KDF.Salt = []byte("0123456789abcdef")
+	envelope.Cipher.Name = "nacl/secretbox"
+	envelope.Cipher.Nonce = []byte("0123456789abcdef01234567")
+
+	derivedKey, err := scrypt.Key(password, envelope.KDF.Salt, envelope.KDF.Params.N, envelope.KDF.Params.R, envelope.KDF.Params.P, 32)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var secretKey [32]byte
+	var nonce [24]byte
+	copy(secretKey[:], derivedKey)
+	copy(nonce[:], envelope.Cipher.Nonce)
+	envelope.Ciphertext = secretbox.Seal(nil, der, &nonce, &secretKey)
+
+	data, err := json.Marshal(envelope)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	pemData := pem.EncodeToMemory(&pem.Block{Type: cosignEncryptedPrivateKeyPemType, Bytes: data})
+
+	if _, err := parsePrivateKey(pemData, []byte("wrong")); err == nil {
+		t.Fatal("decryption with a wrong password expected to fail")
+	}
+
+	parsedKey, err := parsePrivateKey(pemData, password)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if parsedKey.D.Cmp(key.D) != 0 {
+		t.Fatal("parsed key does not match the original one")
+	}
+}
@@ -0,0 +1,175 @@
+package image_signing
+
+import (
+	"crypto/ecdsa"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+
+	"golang.org/x/crypto/nacl/secretbox"
+	"golang.org/x/crypto/scrypt"
+)
+
+const (
+	cosignEncryptedPrivateKeyPemType = "ENCRYPTED COSIGN PRIVATE KEY"
+	pkcs8PrivateKeyPemType           = "PRIVATE KEY"
+	ecPrivateKeyPemType              = "EC PRIVATE KEY"
+	publicKeyPemType                 = "PUBLIC KEY"
+)
+
+// encryptedKeyEnvelope is the cosign (go-securesystemslib) format of password protected private keys
+type encryptedKeyEnvelope struct {
+	KDF struct {
+		Name   string `json:"name"`
+		Params struct {
+			N int `json:"N"`
+			R int `json:"r"`
+			P int `json:"p"`
+		} `json:"params"`
+		Salt []byte `json:"salt"`
+	} `json:"kdf"`
+	Cipher struct {
+		Name  string `json:"name"`
+		Nonce []byte `json:"nonce"`
+	} `json:"cipher"`
+	Ciphertext []byte `json:"ciphertext"`
+}
+
+func LoadPrivateKey(path string) (*ecdsa.PrivateKey, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read sign key %s: %s", path, err)
+	}
+
+	key, err := parsePrivateKey(data, getKeyPassword())
+	if err != nil {
+		return nil, fmt.Errorf("bad sign key %s: %s", path, err)
+	}
+
+	return key, nil
+}
+
+func LoadPublicKey(path string) (*ecdsa.PublicKey, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read verification key %s: %s", path, err)
+	}
+
+	key, err := parsePublicKey(data)
+	if err != nil {
+		return nil, fmt.Errorf("bad verification key %s: %s", path, err)
+	}
+
+	return key, nil
+}
+
+func getKeyPassword() []byte {
+	for _, envName := range []string{"WERF_SIGN_KEY_PASSWORD", "COSIGN_PASSWORD"} {
+		if value := os.Getenv(envName); value != "" {
+			return []byte(value)
+		}
+	}
+
+	return nil
+}
+
+func parsePrivateKey(data, password []byte) (*ecdsa.PrivateKey, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("PEM block expected")
+	}
+
+	var der []byte
+	switch block.Type {
+	case cosignEncryptedPrivateKeyPemType:
+		var err error
+		if der, err = decryptPrivateKey(block.Bytes, password); err != nil {
+			return nil, err
+		}
+	case pkcs8PrivateKeyPemType:
+		der = block.Bytes
+	case ecPrivateKeyPemType:
+		return x509.ParseECPrivateKey(block.Bytes)
+	default:
+		return nil, fmt.Errorf("unsupported PEM block type %q", block.Type)
+	}
+
+	key, err := x509.ParsePKCS8PrivateKey(der)
+	if err != nil {
+		return nil, err
+	}
+
+	ecdsaKey, ok := key.(*ecdsa.PrivateKey)
+	if !ok {
+		return nil, fmt.Errorf("ECDSA private key expected, got %T", key)
+	}
+
+	return ecdsaKey, nil
+}
+
+func parsePublicKey(data []byte) (*ecdsa.PublicKey, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("PEM block expected")
+	}
+
+	if block.Type != publicKeyPemType {
+		return nil, fmt.Errorf("unsupported PEM block type %q", block.Type)
+	}
+
+	key, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return nil, err
+	}
+
+	ecdsaKey, ok := key.(*ecdsa.PublicKey)
+	if !ok {
+		return nil, fmt.Errorf("ECDSA public key expected, got %T", key)
+	}
+
+	return ecdsaKey, nil
+}
+
+func decryptPrivateKey(data, password []byte) ([]byte, error) {
+	if len(password) == 0 {
+		return nil, errors.New("key is encrypted: password should be specified with $WERF_SIGN_KEY_PASSWORD or $COSIGN_PASSWORD")
+	}
+
+	envelope := &encryptedKeyEnvelope{}
+	if err := json.Unmarshal(data, envelope); err != nil {
+		return nil, fmt.Errorf("unable to parse encrypted key: %s", err)
+	}
+
+	if envelope.KDF.Name != "scrypt" {
+		return nil, fmt.Errorf("unsupported kdf %q", envelope.KDF.Name)
+	}
+
+	if envelope.Cipher.Name != "nacl/secretbox" {
+		return nil, fmt.Errorf("unsupported cipher %q", envelope.Cipher.Name)
+	}
+
+	if len(envelope.Cipher.Nonce) != 24 {
+		return nil, errors.New("bad cipher nonce")
+	}
+
+	derivedKey, err := scrypt.Key(password, envelope.KDF.Salt, envelope.KDF.Params.N, envelope.KDF.Params.R, envelope.KDF.Params.P, 32)
+	if err != nil {
+		return nil, err
+	}
+
+	var secretKey [32]byte
+	var nonce [24]byte
+	copy(secretKey[:], derivedKey)
+	copy(nonce[:], envelope.Cipher.Nonce)
+
+	res, ok := secretbox.Open(nil, envelope.Ciphertext, &nonce, &secretKey)
+	if !ok {
+		return nil, errors.New("decryption failed: bad password")
+	}
+
+	return res, nil
+}
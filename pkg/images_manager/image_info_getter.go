@@ -8,7 +8,7 @@ import (
 type ImageInfoGetter interface {
 	IsNameless() bool
 	GetName() string
-	GetImageName() string
+	GetImageName() (string, error)
 	GetImageId() (string, error)
 	GetImageDigest() (string, error)
 	GetImageTag() string
@@ -32,12 +32,17 @@ func (d *ImageInfoGetterStub) GetImageTag() string {
 	return d.Tag
 }
 
-func (d *ImageInfoGetterStub) GetImageName() string {
+func (d *ImageInfoGetterStub) GetImageName() (string, error) {
 	return d.ImagesRepoManager.ImageRepoWithTag(d.Name, d.Tag)
 }
 
 func (d *ImageInfoGetterStub) GetImageId() (string, error) {
-	return docker_registry.ImageId(d.GetImageName())
+	imageName, err := d.GetImageName()
+	if err != nil {
+		return "", err
+	}
+
+	return docker_registry.ImageId(imageName)
 }
 
 type ImageInfo struct {
@@ -55,7 +60,7 @@ func (d *ImageInfo) GetName() string {
 	return d.Name
 }
 
-func (d *ImageInfo) GetImageName() string {
+func (d *ImageInfo) GetImageName() (string, error) {
 	return d.ImagesRepoManager.ImageRepoWithTag(d.Name, d.Tag)
 }
 
@@ -64,7 +69,10 @@ func (d *ImageInfo) GetImageId() (string, error) {
 		return "", nil
 	}
 
-	imageName := d.GetImageName()
+	imageName, err := d.GetImageName()
+	if err != nil {
+		return "", err
+	}
 
 	res, err := docker_registry.ImageId(imageName)
 	if err != nil {
@@ -80,7 +88,10 @@ func (d *ImageInfo) GetImageDigest() (string, error) {
 		return "", nil
 	}
 
-	imageName := d.GetImageName()
+	imageName, err := d.GetImageName()
+	if err != nil {
+		return "", err
+	}
 
 	res, err := docker_registry.ImageDigest(imageName)
 	if err != nil {
@@ -2,6 +2,6 @@ package images_manager
 
 type ImagesRepoManager interface {
 	ImagesRepo() string
-	ImageRepo(imageName string) string
-	ImageRepoWithTag(imageName, tag string) string
+	ImageRepo(imageName string) (string, error)
+	ImageRepoWithTag(imageName, tag string) (string, error)
 }
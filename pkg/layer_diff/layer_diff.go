@@ -0,0 +1,194 @@
+package layer_diff
+
+import (
+	"archive/tar"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"sort"
+	"strings"
+)
+
+// File is a state of the file in the layer without modification time
+type File struct {
+	Typeflag byte
+	Mode     int64
+	Uid      int
+	Gid      int
+	Linkname string
+	Size     int64
+	Digest   string
+}
+
+type Difference struct {
+	Path   string
+	Reason string
+}
+
+func (d Difference) String() string {
+	return fmt.Sprintf("%s: %s", d.Path, d.Reason)
+}
+
+// ReadImageLastLayerFiles reads the files of the last layer of the image from the docker save archive.
+// The archive is stored into tmpDir, because manifest.json usually goes after the layers
+func ReadImageLastLayerFiles(save func() (io.ReadCloser, error), tmpDir string) (map[string]*File, error) {
+	archive, err := ioutil.TempFile(tmpDir, "image-*.tar")
+	if err != nil {
+		return nil, fmt.Errorf("unable to create tmp file: %s", err)
+	}
+	defer os.Remove(archive.Name())
+	defer archive.Close()
+
+	rc, err := save()
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = io.Copy(archive, rc)
+	rc.Close()
+	if err != nil {
+		return nil, fmt.Errorf("unable to save image archive %s: %s", archive.Name(), err)
+	}
+
+	var manifest []struct {
+		Layers []string
+	}
+
+	if err := readArchiveEntry(archive, "manifest.json", func(r io.Reader) error {
+		return json.NewDecoder(r).Decode(&manifest)
+	}); err != nil {
+		return nil, err
+	}
+
+	if len(manifest) != 1 || len(manifest[0].Layers) == 0 {
+		return nil, fmt.Errorf("unexpected image archive manifest: single image with layers expected")
+	}
+
+	var files map[string]*File
+	if err := readArchiveEntry(archive, manifest[0].Layers[len(manifest[0].Layers)-1], func(r io.Reader) error {
+		files, err = ReadLayerFiles(r)
+		return err
+	}); err != nil {
+		return nil, err
+	}
+
+	return files, nil
+}
+
+func readArchiveEntry(archive *os.File, name string, f func(r io.Reader) error) error {
+	if _, err := archive.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+
+	tr := tar.NewReader(archive)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			return fmt.Errorf("%s not found in image archive", name)
+		} else if err != nil {
+			return fmt.Errorf("unable to read image archive: %s", err)
+		}
+
+		if header.Name == name {
+			if err := f(tr); err != nil {
+				return fmt.Errorf("unable to read %s of image archive: %s", name, err)
+			}
+			return nil
+		}
+	}
+}
+
+func ReadLayerFiles(r io.Reader) (map[string]*File, error) {
+	files := map[string]*File{}
+
+	tr := tar.NewReader(r)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, err
+		}
+
+		file := &File{
+			Typeflag: header.Typeflag,
+			Mode:     header.Mode,
+			Uid:      header.Uid,
+			Gid:      header.Gid,
+			Linkname: header.Linkname,
+			Size:     header.Size,
+		}
+
+		if header.Typeflag == tar.TypeReg || header.Typeflag == tar.TypeRegA {
+			h := sha256.New()
+			if _, err := io.Copy(h, tr); err != nil {
+				return nil, err
+			}
+			file.Digest = hex.EncodeToString(h.Sum(nil))
+		}
+
+		files[path.Join("/", header.Name)] = file
+	}
+
+	return files, nil
+}
+
+// Compare returns the differences between the files of two layers sorted by path, modification times are ignored
+func Compare(expected, actual map[string]*File) []Difference {
+	var differences []Difference
+
+	for p, expectedFile := range expected {
+		actualFile, ok := actual[p]
+		if !ok {
+			differences = append(differences, Difference{Path: p, Reason: "missing"})
+			continue
+		}
+
+		if reason := compareFiles(expectedFile, actualFile); reason != "" {
+			differences = append(differences, Difference{Path: p, Reason: reason})
+		}
+	}
+
+	for p := range actual {
+		if _, ok := expected[p]; !ok {
+			differences = append(differences, Difference{Path: p, Reason: "added"})
+		}
+	}
+
+	sort.Slice(differences, func(i, j int) bool {
+		return differences[i].Path < differences[j].Path
+	})
+
+	return differences
+}
+
+func compareFiles(expected, actual *File) string {
+	var reasons []string
+
+	if expected.Typeflag != actual.Typeflag {
+		reasons = append(reasons, "type")
+	}
+	if expected.Mode != actual.Mode {
+		reasons = append(reasons, "mode")
+	}
+	if expected.Uid != actual.Uid || expected.Gid != actual.Gid {
+		reasons = append(reasons, "owner")
+	}
+	if expected.Linkname != actual.Linkname {
+		reasons = append(reasons, "link")
+	}
+	if expected.Size != actual.Size || expected.Digest != actual.Digest {
+		reasons = append(reasons, "content")
+	}
+
+	if len(reasons) == 0 {
+		return ""
+	}
+
+	return fmt.Sprintf("%s differs", strings.Join(reasons, ", "))
+}
@@ -0,0 +1,120 @@
+package layer_diff
+
+import (
+	"archive/tar"
+	"bytes"
+	"io"
+	"io/ioutil"
+	"reflect"
+	"testing"
+	"time"
+)
+
+type layerEntry struct {
+	name    string
+	content string
+	mode    int64
+	modTime time.Time
+}
+
+func layerTar(t *testing.T, entries ...layerEntry) []byte {
+	buf := bytes.NewBuffer(nil)
+	tw := tar.NewWriter(buf)
+
+	for _, e := range entries {
+		header := &tar.Header{Name: e.name, Mode: e.mode, ModTime: e.modTime, Typeflag: tar.TypeReg, Size: int64(len(e.content))}
+		if e.mode == 0 {
+			header.Mode = 0644
+		}
+
+		if err := tw.WriteHeader(header); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := tw.Write([]byte(e.content)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	return buf.Bytes()
+}
+
+func readLayerFiles(t *testing.T, entries ...layerEntry) map[string]*File {
+	files, err := ReadLayerFiles(bytes.NewReader(layerTar(t, entries...)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return files
+}
+
+func TestCompare(t *testing.T) {
+	now := time.Now()
+
+	expected := readLayerFiles(t,
+		layerEntry{name: "app/main.py", content: "print(1)", modTime: now},
+		layerEntry{name: "app/run.sh", content: "exec app", mode: 0755},
+		layerEntry{name: "var/lib/apt/lists/index", content: "a"},
+		layerEntry{name: "tmp/build.log", content: "log"},
+	)
+
+	actual := readLayerFiles(t,
+		layerEntry{name: "app/main.py", content: "print(1)", modTime: now.Add(time.Hour)},
+		layerEntry{name: "app/run.sh", content: "exec app", mode: 0644},
+		layerEntry{name: "var/lib/apt/lists/index", content: "b"},
+		layerEntry{name: "tmp/cache", content: "cache"},
+	)
+
+	expectedDifferences := []Difference{
+		{Path: "/app/run.sh", Reason: "mode differs"},
+		{Path: "/tmp/build.log", Reason: "missing"},
+		{Path: "/tmp/cache", Reason: "added"},
+		{Path: "/var/lib/apt/lists/index", Reason: "content differs"},
+	}
+
+	if differences := Compare(expected, actual); !reflect.DeepEqual(differences, expectedDifferences) {
+		t.Errorf("Compare() = %v, want %v", differences, expectedDifferences)
+	}
+
+	if differences := Compare(expected, expected); len(differences) != 0 {
+		t.Errorf("Compare() of the same files = %v, want no differences", differences)
+	}
+}
+
+func TestReadImageLastLayerFiles(t *testing.T) {
+	buf := bytes.NewBuffer(nil)
+	tw := tar.NewWriter(buf)
+
+	for _, e := range []struct {
+		name    string
+		content []byte
+	}{
+		{"base/layer.tar", layerTar(t, layerEntry{name: "etc/os-release", content: "alpine"})},
+		{"stage/layer.tar", layerTar(t, layerEntry{name: "app/main.py", content: "print(1)"})},
+		{"manifest.json", []byte(`[{"Config":"config.json","Layers":["base/layer.tar","stage/layer.tar"]}]`)},
+	} {
+		if err := tw.WriteHeader(&tar.Header{Name: e.name, Mode: 0644, Size: int64(len(e.content))}); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := tw.Write(e.content); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	files, err := ReadImageLastLayerFiles(func() (io.ReadCloser, error) {
+		return ioutil.NopCloser(bytes.NewReader(buf.Bytes())), nil
+	}, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, ok := files["/app/main.py"]; !ok || len(files) != 1 {
+		t.Errorf("ReadImageLastLayerFiles() = %v, want only /app/main.py", files)
+	}
+}
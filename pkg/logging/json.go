@@ -0,0 +1,393 @@
+package logging
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/flant/logboek"
+)
+
+// In json format all logboek output is written into the pipe, so that the order of the log lines, the raw streams data
+// and the werf events is kept. The service records are passed in-band between the record separators and
+// decoded by the goroutine, which writes one json object per event into the stdout
+const (
+	jsonRecordStart = '\x1e'
+	jsonRecordEnd   = '\x1f'
+
+	ProcessStartEventType = "process_start"
+	ProcessEndEventType   = "process_end"
+	LogEventType          = "log"
+	ContainerLogEventType = "container_log"
+)
+
+var (
+	isJsonFormat bool
+
+	jsonPipeWriter  *os.File
+	jsonWriteMutex  sync.Mutex
+	jsonDecoderDone chan struct{}
+
+	lastJsonProcessId uint64
+
+	// kubedog logs each container log chunk in the process with the following header
+	kubedogContainerLogHeaderRegexp = regexp.MustCompile(`^(\S+/\S+) po/(\S+) container/(\S+) logs$`)
+)
+
+type Context struct {
+	Image string `json:"image,omitempty"`
+	Stage string `json:"stage,omitempty"`
+	Phase string `json:"phase,omitempty"`
+}
+
+type Event struct {
+	Time            string   `json:"time"`
+	Type            string   `json:"type"`
+	Level           string   `json:"level,omitempty"`
+	Message         string   `json:"message"`
+	Processes       []string `json:"processes,omitempty"`
+	Status          string   `json:"status,omitempty"`
+	Error           string   `json:"error,omitempty"`
+	DurationSeconds *float64 `json:"durationSeconds,omitempty"`
+	Context
+	Resource  string `json:"resource,omitempty"`
+	Pod       string `json:"pod,omitempty"`
+	Container string `json:"container,omitempty"`
+}
+
+type jsonRecord struct {
+	Level       *string  `json:"level,omitempty"`
+	Event       *Event   `json:"event,omitempty"`
+	PushContext *Context `json:"pushContext,omitempty"`
+	PopContext  bool     `json:"popContext,omitempty"`
+	// ProcessId links process start and end events, processes of the concurrent goroutines end in any order
+	ProcessId uint64 `json:"processId,omitempty"`
+}
+
+type jsonProcess struct {
+	id        uint64
+	msg       string
+	level     logboek.Level
+	startedAt time.Time
+}
+
+func IsJsonFormat() bool {
+	return isJsonFormat
+}
+
+// EnableJsonFormat redirects logboek output into the json events decoder, Close should be called before exit to flush the events
+func EnableJsonFormat() error {
+	if isJsonFormat {
+		return nil
+	}
+
+	r, w, err := os.Pipe()
+	if err != nil {
+		return fmt.Errorf("unable to create log pipe: %s", err)
+	}
+
+	// logboek raw streams can only be redirected through the os.Stdout and os.Stderr files
+	stdout, stderr := os.Stdout, os.Stderr
+	os.Stdout, os.Stderr = w, w
+	logboek.UnmuteOut()
+	logboek.UnmuteErr()
+	os.Stdout, os.Stderr = stdout, stderr
+
+	for _, level := range []logboek.Level{logboek.Error, logboek.Warn, logboek.Default, logboek.Info, logboek.Debug} {
+		level.SetStream(&jsonLevelWriter{level: levelName(level)})
+	}
+
+	DisablePrettyLog()
+	logboek.DisableLogColor()
+	logboek.ResetPrefix()
+	// Process messages should not be cut
+	logboek.SetWidth(10000)
+
+	jsonPipeWriter = w
+	jsonDecoderDone = make(chan struct{})
+	isJsonFormat = true
+
+	go func() {
+		defer close(jsonDecoderDone)
+		newJsonDecoder(stdout).decode(r)
+	}()
+
+	return nil
+}
+
+// Close flushes the json events
+func Close() {
+	if !isJsonFormat {
+		return
+	}
+
+	jsonWriteMutex.Lock()
+	_ = jsonPipeWriter.Close()
+	jsonWriteMutex.Unlock()
+
+	<-jsonDecoderDone
+}
+
+func WithContext(ctx Context, f func() error) error {
+	if !isJsonFormat {
+		return f()
+	}
+
+	writeJsonRecord(jsonRecord{PushContext: &ctx}, nil)
+	defer writeJsonRecord(jsonRecord{PopContext: true}, nil)
+
+	return f()
+}
+
+// jsonProcessStart returns the started process, which should be passed to jsonProcessEnd
+func jsonProcessStart(msg string, level logboek.Level) *jsonProcess {
+	process := &jsonProcess{id: atomic.AddUint64(&lastJsonProcessId, 1), msg: msg, level: level, startedAt: time.Now()}
+	writeJsonRecord(jsonRecord{Event: &Event{Type: ProcessStartEventType, Level: levelName(level), Message: msg}, ProcessId: process.id}, nil)
+
+	return process
+}
+
+func jsonProcessEnd(process *jsonProcess, err error) {
+	duration := time.Since(process.startedAt).Seconds()
+	event := &Event{
+		Type:            ProcessEndEventType,
+		Level:           levelName(process.level),
+		Message:         process.msg,
+		Status:          "ok",
+		DurationSeconds: &duration,
+	}
+
+	if err != nil {
+		event.Status = "failed"
+		event.Error = err.Error()
+	}
+
+	writeJsonRecord(jsonRecord{Event: event, ProcessId: process.id}, nil)
+}
+
+func writeJsonRecord(record jsonRecord, data []byte) {
+	buf := bytes.NewBuffer(nil)
+
+	recordData, err := json.Marshal(record)
+	if err != nil {
+		panic(fmt.Sprintf("runtime error: %s", err))
+	}
+
+	buf.WriteByte(jsonRecordStart)
+	buf.Write(recordData)
+	buf.WriteByte(jsonRecordEnd)
+	buf.Write(data)
+
+	if record.Level != nil && *record.Level != "" {
+		buf.WriteByte(jsonRecordStart)
+		buf.WriteString(`{"level":""}`)
+		buf.WriteByte(jsonRecordEnd)
+	}
+
+	jsonWriteMutex.Lock()
+	defer jsonWriteMutex.Unlock()
+
+	// The whole record is written at once, so that the records of the concurrent goroutines are not mixed
+	_, _ = jsonPipeWriter.Write(buf.Bytes())
+}
+
+type jsonLevelWriter struct {
+	level string
+}
+
+func (w *jsonLevelWriter) Write(data []byte) (int, error) {
+	writeJsonRecord(jsonRecord{Level: &w.level}, data)
+	return len(data), nil
+}
+
+func levelName(level logboek.Level) string {
+	switch level {
+	case logboek.Error:
+		return "error"
+	case logboek.Warn:
+		return "warning"
+	case logboek.Info:
+		return "verbose"
+	case logboek.Debug:
+		return "debug"
+	default:
+		return "info"
+	}
+}
+
+type jsonDecoder struct {
+	encoder *json.Encoder
+
+	level     string
+	line      bytes.Buffer
+	lineLevel string
+
+	processes []decodedProcess
+	contexts  []Context
+
+	containerLogHeader string
+	containerLogEvent  Event
+}
+
+type decodedProcess struct {
+	id  uint64
+	msg string
+}
+
+func newJsonDecoder(out io.Writer) *jsonDecoder {
+	return &jsonDecoder{encoder: json.NewEncoder(out)}
+}
+
+func (d *jsonDecoder) decode(r io.Reader) {
+	reader := bufio.NewReader(r)
+
+	for {
+		data, err := reader.ReadBytes(jsonRecordStart)
+		if len(data) != 0 && data[len(data)-1] == jsonRecordStart {
+			d.writeData(data[:len(data)-1])
+
+			recordData, recordErr := reader.ReadBytes(jsonRecordEnd)
+			if recordErr == nil {
+				d.handleRecord(recordData[:len(recordData)-1])
+			}
+			err = recordErr
+		} else {
+			d.writeData(data)
+		}
+
+		if err != nil {
+			d.flushLine()
+			return
+		}
+	}
+}
+
+func (d *jsonDecoder) handleRecord(data []byte) {
+	var record jsonRecord
+	if err := json.Unmarshal(data, &record); err != nil {
+		return
+	}
+
+	switch {
+	case record.Level != nil:
+		d.level = *record.Level
+	case record.PushContext != nil:
+		d.flushLine()
+		d.contexts = append(d.contexts, d.mergedContext(*record.PushContext))
+	case record.PopContext:
+		d.flushLine()
+		if len(d.contexts) != 0 {
+			d.contexts = d.contexts[:len(d.contexts)-1]
+		}
+	case record.Event != nil:
+		d.flushLine()
+
+		switch record.Event.Type {
+		case ProcessStartEventType:
+			d.emit(*record.Event)
+			d.processes = append(d.processes, decodedProcess{id: record.ProcessId, msg: record.Event.Message})
+		case ProcessEndEventType:
+			for i := len(d.processes) - 1; i >= 0; i-- {
+				if d.processes[i].id == record.ProcessId {
+					d.processes = append(d.processes[:i], d.processes[i+1:]...)
+					break
+				}
+			}
+			d.emit(*record.Event)
+		default:
+			d.emit(*record.Event)
+		}
+	}
+}
+
+func (d *jsonDecoder) writeData(data []byte) {
+	for len(data) != 0 {
+		if d.line.Len() == 0 {
+			d.lineLevel = d.level
+		}
+
+		ind := bytes.IndexAny(data, "\r\n")
+		if ind == -1 {
+			d.line.Write(data)
+			return
+		}
+
+		d.line.Write(data[:ind])
+		d.flushLine()
+		data = data[ind+1:]
+	}
+}
+
+func (d *jsonDecoder) flushLine() {
+	if d.line.Len() == 0 {
+		return
+	}
+
+	line := strings.TrimRight(d.line.String(), " \t")
+	level := d.lineLevel
+	d.line.Reset()
+
+	if strings.TrimSpace(line) == "" {
+		return
+	}
+
+	// Only raw streams data is written without level
+	if level == "" {
+		if d.containerLogHeader != "" {
+			event := d.containerLogEvent
+			event.Message = line
+			d.emit(event)
+			return
+		}
+
+		level = "info"
+	} else if header := strings.TrimSpace(line); header == d.containerLogHeader {
+		d.containerLogHeader = ""
+		return
+	} else if match := kubedogContainerLogHeaderRegexp.FindStringSubmatch(header); match != nil {
+		d.containerLogHeader = header
+		d.containerLogEvent = Event{Type: ContainerLogEventType, Level: "info", Resource: match[1], Pod: match[2], Container: match[3]}
+		return
+	}
+
+	d.emit(Event{Type: LogEventType, Level: level, Message: line})
+}
+
+func (d *jsonDecoder) emit(event Event) {
+	event.Time = time.Now().UTC().Format(time.RFC3339Nano)
+	event.Context = d.mergedContext(event.Context)
+	if len(d.processes) != 0 {
+		for _, process := range d.processes {
+			event.Processes = append(event.Processes, process.msg)
+		}
+	}
+
+	_ = d.encoder.Encode(event)
+}
+
+func (d *jsonDecoder) mergedContext(ctx Context) Context {
+	if len(d.contexts) == 0 {
+		return ctx
+	}
+
+	res := d.contexts[len(d.contexts)-1]
+	if ctx.Image != "" {
+		res.Image = ctx.Image
+	}
+	if ctx.Stage != "" {
+		res.Stage = ctx.Stage
+	}
+	if ctx.Phase != "" {
+		res.Phase = ctx.Phase
+	}
+
+	return res
+}
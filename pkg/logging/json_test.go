@@ -0,0 +1,112 @@
+package logging
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestJsonDecoder(t *testing.T) {
+	input := bytes.NewBuffer(nil)
+	writeRecord := func(record jsonRecord, data string) {
+		recordData, err := json.Marshal(record)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		input.WriteByte(jsonRecordStart)
+		input.Write(recordData)
+		input.WriteByte(jsonRecordEnd)
+		input.WriteString(data)
+	}
+	level := func(name string) *string { return &name }
+
+	writeRecord(jsonRecord{PushContext: &Context{Image: "backend"}}, "")
+	writeRecord(jsonRecord{Event: &Event{Type: ProcessStartEventType, Level: "info", Message: "Building stages"}}, "")
+	writeRecord(jsonRecord{PushContext: &Context{Stage: "install"}}, "")
+	writeRecord(jsonRecord{Level: level("info")}, "stage line\n")
+	writeRecord(jsonRecord{Level: level("")}, "")
+	writeRecord(jsonRecord{PopContext: true}, "raw output\n")
+	writeRecord(jsonRecord{Event: &Event{Type: ProcessEndEventType, Level: "info", Message: "Building stages", Status: "ok"}}, "")
+	writeRecord(jsonRecord{PopContext: true}, "")
+	writeRecord(jsonRecord{Level: level("warning")}, "  deploy/app po/app-1 container/main logs\n")
+	writeRecord(jsonRecord{Level: level("")}, "container line\n\n")
+	writeRecord(jsonRecord{Level: level("warning")}, "  deploy/app po/app-1 container/main logs\n")
+	writeRecord(jsonRecord{Level: level("")}, "")
+	writeRecord(jsonRecord{Level: level("error")}, "Error: failed")
+
+	output := bytes.NewBuffer(nil)
+	newJsonDecoder(output).decode(input)
+
+	var events []Event
+	scanner := bufio.NewScanner(output)
+	for scanner.Scan() {
+		var event Event
+		if err := json.Unmarshal(scanner.Bytes(), &event); err != nil {
+			t.Fatalf("bad event %q: %s", scanner.Text(), err)
+		}
+		events = append(events, event)
+	}
+
+	expected := []Event{
+		{Type: ProcessStartEventType, Level: "info", Message: "Building stages", Context: Context{Image: "backend"}},
+		{Type: LogEventType, Level: "info", Message: "stage line", Processes: []string{"Building stages"}, Context: Context{Image: "backend", Stage: "install"}},
+		{Type: LogEventType, Level: "info", Message: "raw output", Processes: []string{"Building stages"}, Context: Context{Image: "backend"}},
+		{Type: ProcessEndEventType, Level: "info", Message: "Building stages", Status: "ok", Context: Context{Image: "backend"}},
+		{Type: ContainerLogEventType, Level: "info", Message: "container line", Resource: "deploy/app", Pod: "app-1", Container: "main"},
+		{Type: LogEventType, Level: "error", Message: "Error: failed"},
+	}
+
+	if len(events) != len(expected) {
+		t.Fatalf("\n[EXPECTED]: %d events\n[GOT]: %d events: %+v", len(expected), len(events), events)
+	}
+
+	for i := range expected {
+		got := events[i]
+		got.Time = ""
+
+		gotData, _ := json.Marshal(got)
+		expectedData, _ := json.Marshal(expected[i])
+		if !bytes.Equal(gotData, expectedData) {
+			t.Errorf("\n[EXPECTED]: %s\n[GOT]: %s", expectedData, gotData)
+		}
+	}
+}
+
+func TestJsonDecoder_InterleavedProcesses(t *testing.T) {
+	input := bytes.NewBuffer(nil)
+	writeRecord := func(record jsonRecord, data string) {
+		recordData, err := json.Marshal(record)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		input.WriteByte(jsonRecordStart)
+		input.Write(recordData)
+		input.WriteByte(jsonRecordEnd)
+		input.WriteString(data)
+	}
+	level := func(name string) *string { return &name }
+
+	writeRecord(jsonRecord{Event: &Event{Type: ProcessStartEventType, Message: "backend"}, ProcessId: 1}, "")
+	writeRecord(jsonRecord{Event: &Event{Type: ProcessStartEventType, Message: "frontend"}, ProcessId: 2}, "")
+	writeRecord(jsonRecord{Event: &Event{Type: ProcessEndEventType, Message: "backend"}, ProcessId: 1}, "")
+	writeRecord(jsonRecord{Level: level("info")}, "frontend line\n")
+
+	output := bytes.NewBuffer(nil)
+	newJsonDecoder(output).decode(input)
+
+	var lastEvent Event
+	scanner := bufio.NewScanner(output)
+	for scanner.Scan() {
+		if err := json.Unmarshal(scanner.Bytes(), &lastEvent); err != nil {
+			t.Fatalf("bad event %q: %s", scanner.Text(), err)
+		}
+	}
+
+	if lastEvent.Message != "frontend line" || !reflect.DeepEqual(lastEvent.Processes, []string{"frontend"}) {
+		t.Errorf("\n[EXPECTED]: frontend line in the frontend process\n[GOT]: %+v", lastEvent)
+	}
+}
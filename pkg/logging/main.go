@@ -39,7 +39,11 @@ func EnableLogQuiet() {
 
 func EnableLogDebug() {
 	logboek.SetLevel(logboek.Debug)
-	logboek.SetRunningTimePrefix(logboek.DetailsStyle())
+
+	// Json events have time
+	if !isJsonFormat {
+		logboek.SetRunningTimePrefix(logboek.DetailsStyle())
+	}
 }
 
 func EnableLogVerbose() {
@@ -47,6 +51,10 @@ func EnableLogVerbose() {
 }
 
 func EnableLogColor() {
+	if isJsonFormat {
+		return
+	}
+
 	logboek.EnableLogColor()
 }
 
@@ -55,6 +63,10 @@ func DisableLogColor() {
 }
 
 func SetWidth(value int) {
+	if isJsonFormat {
+		return
+	}
+
 	logboek.SetWidth(value)
 }
 
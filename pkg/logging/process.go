@@ -0,0 +1,65 @@
+package logging
+
+import (
+	"github.com/flant/logboek"
+)
+
+// The following functions wrap logboek processes and blocks, so that the process events are written in json format
+
+func LogProcess(processMessage string, options logboek.LogProcessOptions, processFunc func() error) error {
+	if !isJsonFormat {
+		return logboek.LogProcess(processMessage, options, processFunc)
+	}
+
+	return jsonLogProcess(processMessage, options.Level, processFunc, options.InfoSectionFunc, options.SuccessInfoSectionFunc)
+}
+
+func LevelLogProcess(level logboek.Level, processMessage string, options logboek.LevelLogProcessOptions, processFunc func() error) error {
+	return LogProcess(processMessage, logboek.LogProcessOptions{LevelLogProcessOptions: options, Level: level}, processFunc)
+}
+
+func LogProcessInline(processMessage string, options logboek.LogProcessInlineOptions, processFunc func() error) error {
+	if !isJsonFormat {
+		return logboek.LogProcessInline(processMessage, options, processFunc)
+	}
+
+	return jsonLogProcess(processMessage, options.Level, processFunc, nil, nil)
+}
+
+func LevelLogProcessInline(level logboek.Level, processMessage string, options logboek.LevelLogProcessInlineOptions, processFunc func() error) error {
+	return LogProcessInline(processMessage, logboek.LogProcessInlineOptions{LevelLogProcessInlineOptions: options, Level: level}, processFunc)
+}
+
+func LogBlock(blockMessage string, options logboek.LogBlockOptions, blockFunc func() error) error {
+	if !isJsonFormat {
+		return logboek.LogBlock(blockMessage, options, blockFunc)
+	}
+
+	return jsonLogProcess(blockMessage, options.Level, blockFunc, nil, nil)
+}
+
+func LevelLogBlock(level logboek.Level, blockMessage string, options logboek.LevelLogBlockOptions, blockFunc func() error) error {
+	return LogBlock(blockMessage, logboek.LogBlockOptions{LevelLogBlockOptions: options, Level: level}, blockFunc)
+}
+
+func jsonLogProcess(processMessage string, level logboek.Level, processFunc func() error, infoSectionFunc func(err error), successInfoSectionFunc func()) error {
+	if !level.IsAccepted() {
+		return processFunc()
+	}
+
+	process := jsonProcessStart(processMessage, level)
+
+	err := processFunc()
+
+	if infoSectionFunc != nil {
+		infoSectionFunc(err)
+	}
+
+	if successInfoSectionFunc != nil && err == nil {
+		successInfoSectionFunc()
+	}
+
+	jsonProcessEnd(process, err)
+
+	return err
+}
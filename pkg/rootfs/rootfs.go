@@ -0,0 +1,394 @@
+// +build linux
+
+package rootfs
+
+import (
+	"archive/tar"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"syscall"
+
+	"golang.org/x/sys/unix"
+)
+
+const (
+	whiteoutPrefix = ".wh."
+	opaqueWhiteout = ".wh..wh..opq"
+)
+
+// Snapshot is a state of the rootfs files, it is taken before running the stage commands
+// to write the changes made by the commands as a layer tarball
+type Snapshot map[string]*fileState
+
+type fileState struct {
+	mode     os.FileMode
+	uid      uint32
+	gid      uint32
+	size     int64
+	ino      uint64
+	ctime    int64
+	mtime    int64
+	linkname string
+}
+
+func (s *fileState) equal(other *fileState) bool {
+	return *s == *other
+}
+
+func TakeSnapshot(dir string) (Snapshot, error) {
+	snapshot := Snapshot{}
+
+	if err := filepath.Walk(dir, func(p string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if p == dir {
+			return nil
+		}
+
+		state := &fileState{mode: info.Mode(), size: info.Size(), mtime: info.ModTime().UnixNano()}
+		if stat, ok := info.Sys().(*syscall.Stat_t); ok {
+			state.uid = stat.Uid
+			state.gid = stat.Gid
+			state.ino = stat.Ino
+			state.ctime = stat.Ctim.Nano()
+		}
+
+		if info.Mode()&os.ModeSymlink != 0 {
+			if state.linkname, err = os.Readlink(p); err != nil {
+				return err
+			}
+		}
+
+		snapshot[relPath(dir, p)] = state
+
+		return nil
+	}); err != nil {
+		return nil, fmt.Errorf("unable to take snapshot of %s: %s", dir, err)
+	}
+
+	return snapshot, nil
+}
+
+// WriteLayer writes the changes of the rootfs since the snapshot as a layer tarball with whiteouts for the removed files.
+// Each removed file gets its own whiteout, opaque whiteouts are not written, because a recreated directory
+// cannot be reliably detected (inode numbers are reused by the filesystem)
+func WriteLayer(dir string, before Snapshot, w io.Writer) error {
+	after, err := TakeSnapshot(dir)
+	if err != nil {
+		return err
+	}
+
+	entries := map[string]bool{}
+	whiteouts := map[string]bool{}
+
+	for p, state := range after {
+		if beforeState, exists := before[p]; exists && beforeState.equal(state) {
+			continue
+		}
+
+		entries[p] = true
+	}
+
+	for p := range before {
+		if _, exists := after[p]; exists {
+			continue
+		}
+
+		// the whole removed dir is covered by its own whiteout, the replaced one by the new entry
+		if parent := path.Dir(p); parent != "." {
+			if parentState, parentExists := after[parent]; !parentExists || !parentState.mode.IsDir() {
+				continue
+			}
+		}
+
+		whiteouts[path.Join(path.Dir(p), whiteoutPrefix+path.Base(p))] = true
+	}
+
+	for p := range entries {
+		for parent := path.Dir(p); parent != "."; parent = path.Dir(parent) {
+			entries[parent] = true
+		}
+	}
+
+	for p := range whiteouts {
+		for parent := path.Dir(p); parent != "."; parent = path.Dir(parent) {
+			entries[parent] = true
+		}
+	}
+
+	var names []string
+	for p := range entries {
+		names = append(names, p)
+	}
+	for p := range whiteouts {
+		names = append(names, p)
+	}
+	sort.Strings(names)
+
+	tw := tar.NewWriter(w)
+	hardlinks := map[uint64]string{}
+
+	for _, name := range names {
+		if whiteouts[name] {
+			if err := tw.WriteHeader(&tar.Header{Name: name, Typeflag: tar.TypeReg, Mode: 0600}); err != nil {
+				return err
+			}
+			continue
+		}
+
+		if err := writeEntry(tw, dir, name, hardlinks); err != nil {
+			return fmt.Errorf("unable to write %s into layer: %s", name, err)
+		}
+	}
+
+	return tw.Close()
+}
+
+func writeEntry(tw *tar.Writer, dir, name string, hardlinks map[uint64]string) error {
+	p := filepath.Join(dir, name)
+
+	info, err := os.Lstat(p)
+	if err != nil {
+		return err
+	}
+
+	var link string
+	if info.Mode()&os.ModeSymlink != 0 {
+		if link, err = os.Readlink(p); err != nil {
+			return err
+		}
+	}
+
+	header, err := tar.FileInfoHeader(info, link)
+	if err != nil {
+		return err
+	}
+
+	header.Name = name
+	if info.IsDir() {
+		header.Name += "/"
+	}
+	header.Uname = ""
+	header.Gname = ""
+
+	if stat, ok := info.Sys().(*syscall.Stat_t); ok && info.Mode().IsRegular() && stat.Nlink > 1 {
+		if target, exists := hardlinks[stat.Ino]; exists {
+			header.Typeflag = tar.TypeLink
+			header.Linkname = target
+			header.Size = 0
+		} else {
+			hardlinks[stat.Ino] = name
+		}
+	}
+
+	if err := tw.WriteHeader(header); err != nil {
+		return err
+	}
+
+	if header.Typeflag != tar.TypeReg {
+		return nil
+	}
+
+	f, err := os.Open(p)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	_, err = io.Copy(tw, f)
+	return err
+}
+
+// ExtractLayer applies the layer tarball to the rootfs dir, whiteouts remove files of the lower layers.
+// Opaque whiteout is applied as if it goes first in its dir: only the files of the lower layers are removed,
+// the files of the layer, which are extracted before the whiteout entry, are kept
+func ExtractLayer(r io.Reader, dir string) error {
+	tr := tar.NewReader(r)
+	extracted := map[string]bool{}
+
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		} else if err != nil {
+			return err
+		}
+
+		name := path.Clean(strings.TrimPrefix(header.Name, "/"))
+		if name == "." {
+			continue
+		}
+
+		if name == ".." || strings.HasPrefix(name, "../") {
+			return fmt.Errorf("bad layer entry %s: path outside of the rootfs", header.Name)
+		}
+
+		p := filepath.Join(dir, name)
+		base := path.Base(name)
+
+		if base == opaqueWhiteout {
+			if err := removeLowerDirContent(dir, path.Dir(name), extracted); err != nil {
+				return err
+			}
+			continue
+		} else if strings.HasPrefix(base, whiteoutPrefix) {
+			if err := os.RemoveAll(filepath.Join(filepath.Dir(p), strings.TrimPrefix(base, whiteoutPrefix))); err != nil {
+				return err
+			}
+			continue
+		}
+
+		if err := extractEntry(tr, header, dir, p); err != nil {
+			return fmt.Errorf("unable to extract %s: %s", header.Name, err)
+		}
+
+		for parent := name; parent != "."; parent = path.Dir(parent) {
+			extracted[parent] = true
+		}
+	}
+}
+
+func extractEntry(tr *tar.Reader, header *tar.Header, dir, p string) error {
+	if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+		return err
+	}
+
+	if info, err := os.Lstat(p); err == nil && !(info.IsDir() && header.Typeflag == tar.TypeDir) {
+		if err := os.RemoveAll(p); err != nil {
+			return err
+		}
+	}
+
+	mode := os.FileMode(header.Mode).Perm()
+
+	switch header.Typeflag {
+	case tar.TypeDir:
+		if err := os.Mkdir(p, mode); err != nil && !os.IsExist(err) {
+			return err
+		}
+	case tar.TypeReg, tar.TypeRegA:
+		f, err := os.OpenFile(p, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, mode)
+		if err != nil {
+			return err
+		}
+		_, err = io.Copy(f, tr)
+		f.Close()
+		if err != nil {
+			return err
+		}
+	case tar.TypeSymlink:
+		if err := os.Symlink(header.Linkname, p); err != nil {
+			return err
+		}
+	case tar.TypeLink:
+		if err := os.Link(filepath.Join(dir, path.Clean(strings.TrimPrefix(header.Linkname, "/"))), p); err != nil {
+			return err
+		}
+	case tar.TypeChar, tar.TypeBlock, tar.TypeFifo:
+		fileType := map[byte]uint32{tar.TypeChar: syscall.S_IFCHR, tar.TypeBlock: syscall.S_IFBLK, tar.TypeFifo: syscall.S_IFIFO}[header.Typeflag]
+		dev := int(unix.Mkdev(uint32(header.Devmajor), uint32(header.Devminor)))
+		if err := unix.Mknod(p, fileType|uint32(mode), dev); err != nil && !os.IsPermission(err) {
+			return err
+		}
+		return nil
+	default:
+		return nil
+	}
+
+	// Owner cannot be changed in the rootless mode
+	if err := os.Lchown(p, header.Uid, header.Gid); err != nil && !os.IsPermission(err) {
+		return err
+	}
+
+	if header.Typeflag == tar.TypeSymlink {
+		return nil
+	}
+
+	if err := os.Chmod(p, os.FileMode(header.Mode)&os.ModePerm|modeBits(header.Mode)); err != nil {
+		return err
+	}
+
+	return os.Chtimes(p, header.ModTime, header.ModTime)
+}
+
+func modeBits(mode int64) os.FileMode {
+	var m os.FileMode
+	if mode&04000 != 0 {
+		m |= os.ModeSetuid
+	}
+	if mode&02000 != 0 {
+		m |= os.ModeSetgid
+	}
+	if mode&01000 != 0 {
+		m |= os.ModeSticky
+	}
+	return m
+}
+
+// removeLowerDirContent removes the files of the rootfs dir, which are not extracted from the current layer
+func removeLowerDirContent(rootDir, name string, extracted map[string]bool) error {
+	dir := filepath.Join(rootDir, name)
+
+	f, err := os.Open(dir)
+	if os.IsNotExist(err) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	names, err := f.Readdirnames(-1)
+	f.Close()
+	if err != nil {
+		return err
+	}
+
+	for _, childName := range names {
+		childRelPath := path.Join(name, childName)
+		if !extracted[childRelPath] {
+			if err := os.RemoveAll(filepath.Join(dir, childName)); err != nil {
+				return err
+			}
+			continue
+		}
+
+		if info, err := os.Lstat(filepath.Join(dir, childName)); err != nil {
+			return err
+		} else if info.IsDir() {
+			if err := removeLowerDirContent(rootDir, childRelPath, extracted); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// Copy makes rootfs snapshot copy preserving owners, modes, hardlinks and timestamps
+func Copy(src, dst string) error {
+	if err := os.MkdirAll(dst, 0755); err != nil {
+		return err
+	}
+
+	output, err := exec.Command("cp", "-a", src+"/.", dst).CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("cp -a %s %s failed: %s\n%s", src, dst, err, output)
+	}
+
+	return nil
+}
+
+func relPath(dir, p string) string {
+	rel, err := filepath.Rel(dir, p)
+	if err != nil {
+		panic(err)
+	}
+	return filepath.ToSlash(rel)
+}
@@ -0,0 +1,246 @@
+// +build linux
+
+package rootfs
+
+import (
+	"archive/tar"
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/flant/werf/pkg/layer_diff"
+)
+
+func writeFiles(t *testing.T, dir string, files map[string]string) {
+	for name, content := range files {
+		p := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(p, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func readFiles(t *testing.T, dir string) map[string]string {
+	files := map[string]string{}
+	if err := filepath.Walk(dir, func(p string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		switch {
+		case info.Mode().IsRegular():
+			data, err := ioutil.ReadFile(p)
+			if err != nil {
+				return err
+			}
+			files[relPath(dir, p)] = string(data)
+		case info.Mode()&os.ModeSymlink != 0:
+			link, err := os.Readlink(p)
+			if err != nil {
+				return err
+			}
+			files[relPath(dir, p)] = "-> " + link
+		}
+
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	return files
+}
+
+func TestWriteAndExtractLayer(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", "rootfs-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	containerRootfs := filepath.Join(tmpDir, "container")
+	imageRootfs := filepath.Join(tmpDir, "image")
+
+	writeFiles(t, containerRootfs, map[string]string{
+		"etc/os-release":         "alpine",
+		"etc/hosts":              "127.0.0.1",
+		"var/cache/apk/index":    "index",
+		"var/cache/apk/APKINDEX": "apkindex",
+		"app/old/main.py":        "print(0)",
+	})
+
+	if err := Copy(containerRootfs, imageRootfs); err != nil {
+		t.Fatal(err)
+	}
+
+	before, err := TakeSnapshot(containerRootfs)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := os.RemoveAll(filepath.Join(containerRootfs, "var/cache/apk")); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Remove(filepath.Join(containerRootfs, "etc/hosts")); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.RemoveAll(filepath.Join(containerRootfs, "app/old")); err != nil {
+		t.Fatal(err)
+	}
+	writeFiles(t, containerRootfs, map[string]string{
+		"etc/os-release": "alpine 3.11",
+		"app/old/new.py": "print(2)",
+		"usr/bin/app.py": "print(1)",
+	})
+	if err := os.Symlink("/usr/bin/app.py", filepath.Join(containerRootfs, "usr/bin/app")); err != nil {
+		t.Fatal(err)
+	}
+
+	layer := bytes.NewBuffer(nil)
+	if err := WriteLayer(containerRootfs, before, layer); err != nil {
+		t.Fatal(err)
+	}
+
+	layerFiles, err := layer_diff.ReadLayerFiles(bytes.NewReader(layer.Bytes()))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var layerPaths []string
+	for p := range layerFiles {
+		layerPaths = append(layerPaths, p)
+	}
+	sort.Strings(layerPaths)
+
+	expectedLayerPaths := []string{
+		"/app",
+		"/app/old",
+		"/app/old/.wh.main.py",
+		"/app/old/new.py",
+		"/etc",
+		"/etc/.wh.hosts",
+		"/etc/os-release",
+		"/usr",
+		"/usr/bin",
+		"/usr/bin/app",
+		"/usr/bin/app.py",
+		"/var",
+		"/var/cache",
+		"/var/cache/.wh.apk",
+	}
+	if !reflect.DeepEqual(layerPaths, expectedLayerPaths) {
+		t.Errorf("layer paths = %v, want %v", layerPaths, expectedLayerPaths)
+	}
+
+	if err := ExtractLayer(bytes.NewReader(layer.Bytes()), imageRootfs); err != nil {
+		t.Fatal(err)
+	}
+
+	if imageFiles, containerFiles := readFiles(t, imageRootfs), readFiles(t, containerRootfs); !reflect.DeepEqual(imageFiles, containerFiles) {
+		t.Errorf("extracted layer files = %v, want %v", imageFiles, containerFiles)
+	}
+}
+
+func TestWriteLayer_RecreatedDir(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", "rootfs-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	// the recreated dir often gets the same inode, the layer should not depend on it
+	for i := 0; i < 20; i++ {
+		containerRootfs := filepath.Join(tmpDir, fmt.Sprintf("container-%d", i))
+		writeFiles(t, containerRootfs, map[string]string{"app/main.py": "print(0)", "app/lib.py": "print(1)"})
+
+		before, err := TakeSnapshot(containerRootfs)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if err := os.RemoveAll(filepath.Join(containerRootfs, "app")); err != nil {
+			t.Fatal(err)
+		}
+		writeFiles(t, containerRootfs, map[string]string{"app/main.py": "print(2)"})
+
+		layer := bytes.NewBuffer(nil)
+		if err := WriteLayer(containerRootfs, before, layer); err != nil {
+			t.Fatal(err)
+		}
+
+		layerFiles, err := layer_diff.ReadLayerFiles(bytes.NewReader(layer.Bytes()))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if _, exists := layerFiles["/app/.wh.lib.py"]; !exists || len(layerFiles) != 3 {
+			t.Fatalf("unexpected layer files: %v", layerFiles)
+		}
+	}
+}
+
+func TestExtractLayer_OpaqueWhiteout(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", "rootfs-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	writeFiles(t, tmpDir, map[string]string{
+		"app/main.py":     "print(0)",
+		"app/lib/util.py": "print(1)",
+		"etc/hosts":       "127.0.0.1",
+	})
+
+	// entries sorted before the opaque whiteout are extracted before it is applied
+	layer := bytes.NewBuffer(nil)
+	tw := tar.NewWriter(layer)
+	for _, entry := range []struct {
+		name    string
+		content string
+	}{
+		{name: "app/", content: ""},
+		{name: "app/!important", content: "layer"},
+		{name: "app/-config", content: "layer"},
+		{name: "app/.wh..wh..opq"},
+		{name: "app/lib/", content: ""},
+		{name: "app/lib/new.py", content: "print(2)"},
+	} {
+		header := &tar.Header{Name: entry.name, Mode: 0644, Typeflag: tar.TypeReg, Size: int64(len(entry.content))}
+		if strings.HasSuffix(entry.name, "/") {
+			header.Typeflag, header.Mode = tar.TypeDir, 0755
+		}
+
+		if err := tw.WriteHeader(header); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := tw.Write([]byte(entry.content)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := ExtractLayer(bytes.NewReader(layer.Bytes()), tmpDir); err != nil {
+		t.Fatal(err)
+	}
+
+	expectedFiles := map[string]string{
+		"app/!important": "layer",
+		"app/-config":    "layer",
+		"app/lib/new.py": "print(2)",
+		"etc/hosts":      "127.0.0.1",
+	}
+	if files := readFiles(t, tmpDir); !reflect.DeepEqual(files, expectedFiles) {
+		t.Errorf("extracted layer files = %v, want %v", files, expectedFiles)
+	}
+}
@@ -0,0 +1,100 @@
+package secret
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"io/ioutil"
+
+	"filippo.io/age"
+)
+
+// AgeSecret encrypts data with the age v1 format (https://age-encryption.org/v1) for X25519 recipients,
+// so encrypted data can also be decrypted by the age tool: cut -c5- | base64 -d | age -d -i KEY_FILE
+
+const ageRecipientPrefix = "age1"
+
+type AgeSecret struct {
+	recipients []age.Recipient
+	identities []age.Identity
+}
+
+// GenerateAgeIdentity returns new X25519 identity (AGE-SECRET-KEY-1...) and its recipient (age1...)
+func GenerateAgeIdentity() (string, string, error) {
+	identity, err := age.GenerateX25519Identity()
+	if err != nil {
+		return "", "", err
+	}
+
+	return identity.String(), identity.Recipient().String(), nil
+}
+
+// NewAgeSecret creates secret which encrypts data for all recipients and decrypts data by any of identities.
+// Identities data has the format of age key files: one AGE-SECRET-KEY-1... per line, lines starting with # are ignored.
+func NewAgeSecret(recipients []string, identitiesData []byte) (*AgeSecret, error) {
+	s := &AgeSecret{}
+
+	for _, recipient := range recipients {
+		r, err := age.ParseX25519Recipient(recipient)
+		if err != nil {
+			return nil, fmt.Errorf("malformed age recipient %q: %s", recipient, err)
+		}
+		s.recipients = append(s.recipients, r)
+	}
+
+	if len(bytes.TrimSpace(identitiesData)) != 0 {
+		identities, err := age.ParseIdentities(bytes.NewReader(identitiesData))
+		if err != nil {
+			return nil, fmt.Errorf("malformed age identities: %s", err)
+		}
+		s.identities = identities
+	}
+
+	return s, nil
+}
+
+func (s *AgeSecret) Encrypt(data []byte) ([]byte, error) {
+	if len(s.recipients) == 0 {
+		return nil, fmt.Errorf("no age recipients specified")
+	}
+
+	buf := bytes.NewBuffer(nil)
+
+	w, err := age.Encrypt(buf, s.recipients...)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := w.Write(data); err != nil {
+		return nil, err
+	}
+
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+
+	return append(backendPrefix(AgeBackend), []byte(base64.StdEncoding.EncodeToString(buf.Bytes()))...), nil
+}
+
+func (s *AgeSecret) Decrypt(encodedData []byte) ([]byte, error) {
+	if len(s.identities) == 0 {
+		return nil, fmt.Errorf("no age identities specified")
+	}
+
+	data, err := trimBackendPrefix(AgeBackend, encodedData)
+	if err != nil {
+		return nil, err
+	}
+
+	ageData, err := base64.StdEncoding.DecodeString(string(data))
+	if err != nil {
+		return nil, err
+	}
+
+	r, err := age.Decrypt(bytes.NewReader(ageData), s.identities...)
+	if err != nil {
+		return nil, err
+	}
+
+	return ioutil.ReadAll(r)
+}
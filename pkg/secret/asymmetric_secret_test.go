@@ -0,0 +1,133 @@
+package secret
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/ProtonMail/go-crypto/openpgp"
+	"github.com/ProtonMail/go-crypto/openpgp/armor"
+)
+
+func TestAgeSecret(t *testing.T) {
+	identity1, recipient1, err := GenerateAgeIdentity()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	identity2, recipient2, err := GenerateAgeIdentity()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	otherIdentity, _, err := GenerateAgeIdentity()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	encrypter, err := NewAgeSecret([]string{recipient1, recipient2}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, data := range [][]byte{[]byte(""), []byte("password"), bytes.Repeat([]byte("x"), 2*64*1024), bytes.Repeat([]byte("y"), 2*64*1024+1)} {
+		encodedData, err := encrypter.Encrypt(data)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if backend := DetectBackend(encodedData); backend != AgeBackend {
+			t.Fatalf("unexpected backend %s", backend)
+		}
+
+		for _, identity := range []string{identity1, "# comment\n" + identity2} {
+			decrypter, err := NewAgeSecret(nil, []byte(identity))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			resultData, err := decrypter.Decrypt(encodedData)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if !bytes.Equal(data, resultData) {
+				t.Fatalf("decrypted data of size %d does not match original data of size %d", len(resultData), len(data))
+			}
+		}
+
+		decrypter, err := NewAgeSecret(nil, []byte(otherIdentity))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if _, err := decrypter.Decrypt(encodedData); err == nil {
+			t.Fatal("decryption by identity of other recipient expected to fail")
+		}
+	}
+}
+
+func TestPgpSecret(t *testing.T) {
+	entity, err := openpgp.NewEntity("werf", "", "werf@example.com", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	publicKey := bytes.NewBuffer(nil)
+	w, err := armor.Encode(publicKey, openpgp.PublicKeyType, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := entity.Serialize(w); err != nil {
+		t.Fatal(err)
+	}
+	w.Close()
+
+	privateKey := bytes.NewBuffer(nil)
+	w, err = armor.Encode(privateKey, openpgp.PrivateKeyType, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := entity.SerializePrivate(w, nil); err != nil {
+		t.Fatal(err)
+	}
+	w.Close()
+
+	encrypter, err := NewPgpSecret([][]byte{publicKey.Bytes()}, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	data := []byte("password")
+	encodedData, err := encrypter.Encrypt(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if backend := DetectBackend(encodedData); backend != PgpBackend {
+		t.Fatalf("unexpected backend %s", backend)
+	}
+
+	decrypter, err := NewPgpSecret(nil, [][]byte{privateKey.Bytes()}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	resultData, err := decrypter.Decrypt(encodedData)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !bytes.Equal(data, resultData) {
+		t.Fatalf("expected %q, got %q", data, resultData)
+	}
+}
+
+func TestRecipientsBackend(t *testing.T) {
+	if _, err := RecipientsBackend([]string{"age1ql3z7hjy54pw3hyww5ayyfg7zqgvc7w3j2elw8zmrj2kg5sfn9aqmcac8p", "pgp:keys/werf.asc"}); err == nil {
+		t.Fatal("mixed recipients expected to fail")
+	}
+
+	if backend, err := RecipientsBackend([]string{"pgp:keys/werf.asc"}); err != nil || backend != PgpBackend {
+		t.Fatalf("unexpected backend %s: %v", backend, err)
+	}
+}
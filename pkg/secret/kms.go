@@ -0,0 +1,219 @@
+package secret
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Kms encrypts and decrypts data keys by the master key which never leaves the key management service
+type Kms interface {
+	Encrypt(plaintext []byte) (string, error)
+	Decrypt(ciphertext string) ([]byte, error)
+}
+
+const (
+	vaultRecipientPrefix = "vault:"
+	kmsRecipientPrefix   = "kms:"
+
+	defaultVaultTransitMount = "transit"
+)
+
+// NewKms creates key management service client by recipient:
+// * vault:[MOUNT/]KEY — HashiCorp Vault transit secrets engine key ($VAULT_ADDR, $VAULT_TOKEN or ~/.vault-token, $VAULT_NAMESPACE);
+// * kms:URL — generic KMS HTTP API with URL/encrypt and URL/decrypt endpoints ($WERF_SECRET_KMS_TOKEN).
+func NewKms(recipient string) (Kms, error) {
+	switch {
+	case strings.HasPrefix(recipient, vaultRecipientPrefix):
+		keyPath := strings.TrimPrefix(recipient, vaultRecipientPrefix)
+
+		mount, key := defaultVaultTransitMount, keyPath
+		if ind := strings.LastIndex(keyPath, "/"); ind != -1 {
+			mount, key = keyPath[:ind], keyPath[ind+1:]
+		}
+
+		if key == "" {
+			return nil, fmt.Errorf("invalid vault recipient %q: key name required", recipient)
+		}
+
+		address := os.Getenv("VAULT_ADDR")
+		if address == "" {
+			return nil, fmt.Errorf("$VAULT_ADDR required for vault recipient %q", recipient)
+		}
+
+		token, err := vaultToken()
+		if err != nil {
+			return nil, err
+		}
+
+		return &VaultTransit{
+			Address:   address,
+			Token:     token,
+			Namespace: os.Getenv("VAULT_NAMESPACE"),
+			Mount:     mount,
+			Key:       key,
+		}, nil
+	case strings.HasPrefix(recipient, kmsRecipientPrefix):
+		return &HttpKms{
+			Url:   strings.TrimPrefix(recipient, kmsRecipientPrefix),
+			Token: os.Getenv("WERF_SECRET_KMS_TOKEN"),
+		}, nil
+	default:
+		return nil, fmt.Errorf("unknown kms recipient %q: expected vault:[MOUNT/]KEY or kms:URL", recipient)
+	}
+}
+
+func vaultToken() (string, error) {
+	if token := os.Getenv("VAULT_TOKEN"); token != "" {
+		return token, nil
+	}
+
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+
+	data, err := ioutil.ReadFile(filepath.Join(homeDir, ".vault-token"))
+	if os.IsNotExist(err) {
+		return "", fmt.Errorf("vault token not found in: '$VAULT_TOKEN', '%s'", filepath.Join(homeDir, ".vault-token"))
+	} else if err != nil {
+		return "", err
+	}
+
+	return strings.TrimSpace(string(data)), nil
+}
+
+type VaultTransit struct {
+	Address   string
+	Token     string
+	Namespace string
+	Mount     string
+	Key       string
+}
+
+func (v *VaultTransit) Encrypt(plaintext []byte) (string, error) {
+	var response struct {
+		Data struct {
+			Ciphertext string `json:"ciphertext"`
+		} `json:"data"`
+	}
+
+	if err := v.request("encrypt", map[string]string{"plaintext": base64.StdEncoding.EncodeToString(plaintext)}, &response); err != nil {
+		return "", err
+	}
+
+	return response.Data.Ciphertext, nil
+}
+
+func (v *VaultTransit) Decrypt(ciphertext string) ([]byte, error) {
+	var response struct {
+		Data struct {
+			Plaintext string `json:"plaintext"`
+		} `json:"data"`
+	}
+
+	if err := v.request("decrypt", map[string]string{"ciphertext": ciphertext}, &response); err != nil {
+		return nil, err
+	}
+
+	return base64.StdEncoding.DecodeString(response.Data.Plaintext)
+}
+
+func (v *VaultTransit) request(operation string, body interface{}, response interface{}) error {
+	url := fmt.Sprintf("%s/v1/%s/%s/%s", strings.TrimSuffix(v.Address, "/"), v.Mount, operation, v.Key)
+
+	headers := map[string]string{"X-Vault-Token": v.Token}
+	if v.Namespace != "" {
+		headers["X-Vault-Namespace"] = v.Namespace
+	}
+
+	if err := kmsRequest(url, headers, body, response); err != nil {
+		return fmt.Errorf("vault transit %s by key %s/%s failed: %s", operation, v.Mount, v.Key, err)
+	}
+
+	return nil
+}
+
+type HttpKms struct {
+	Url   string
+	Token string
+}
+
+func (k *HttpKms) Encrypt(plaintext []byte) (string, error) {
+	var response struct {
+		Ciphertext string `json:"ciphertext"`
+	}
+
+	if err := k.request("encrypt", map[string]string{"plaintext": base64.StdEncoding.EncodeToString(plaintext)}, &response); err != nil {
+		return "", err
+	}
+
+	return response.Ciphertext, nil
+}
+
+func (k *HttpKms) Decrypt(ciphertext string) ([]byte, error) {
+	var response struct {
+		Plaintext string `json:"plaintext"`
+	}
+
+	if err := k.request("decrypt", map[string]string{"ciphertext": ciphertext}, &response); err != nil {
+		return nil, err
+	}
+
+	return base64.StdEncoding.DecodeString(response.Plaintext)
+}
+
+func (k *HttpKms) request(operation string, body interface{}, response interface{}) error {
+	headers := map[string]string{}
+	if k.Token != "" {
+		headers["Authorization"] = "Bearer " + k.Token
+	}
+
+	if err := kmsRequest(strings.TrimSuffix(k.Url, "/")+"/"+operation, headers, body, response); err != nil {
+		return fmt.Errorf("kms %s by %s failed: %s", operation, k.Url, err)
+	}
+
+	return nil
+}
+
+func kmsRequest(url string, headers map[string]string, body interface{}, response interface{}) error {
+	data, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+
+	client := &http.Client{Timeout: 30 * time.Second}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	respData, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("unexpected response status %s: %s", resp.Status, strings.TrimSpace(string(respData)))
+	}
+
+	return json.Unmarshal(respData, response)
+}
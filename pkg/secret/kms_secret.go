@@ -0,0 +1,94 @@
+package secret
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+)
+
+const kmsDataKeySize = 32
+
+// KmsSecret implements envelope encryption: data is encrypted by the AES-256-GCM data key,
+// the data key is encrypted by the key management service and stored alongside the encrypted data
+type KmsSecret struct {
+	encryptedDataKey string
+	aead             cipher.AEAD
+}
+
+// NewKmsSecret generates new data key and encrypts it by kms
+func NewKmsSecret(kms Kms) (*KmsSecret, error) {
+	dataKey := make([]byte, kmsDataKeySize)
+	if _, err := rand.Read(dataKey); err != nil {
+		return nil, err
+	}
+
+	encryptedDataKey, err := kms.Encrypt(dataKey)
+	if err != nil {
+		return nil, fmt.Errorf("unable to encrypt data key: %s", err)
+	}
+
+	return newKmsSecret(encryptedDataKey, dataKey)
+}
+
+// NewKmsSecretWithDataKey decrypts existing data key by kms
+func NewKmsSecretWithDataKey(kms Kms, encryptedDataKey string) (*KmsSecret, error) {
+	dataKey, err := kms.Decrypt(encryptedDataKey)
+	if err != nil {
+		return nil, fmt.Errorf("unable to decrypt data key: %s", err)
+	}
+
+	return newKmsSecret(encryptedDataKey, dataKey)
+}
+
+func newKmsSecret(encryptedDataKey string, dataKey []byte) (*KmsSecret, error) {
+	block, err := aes.NewCipher(dataKey)
+	if err != nil {
+		return nil, fmt.Errorf("invalid data key: %s", err)
+	}
+
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+
+	return &KmsSecret{encryptedDataKey: encryptedDataKey, aead: aead}, nil
+}
+
+func (s *KmsSecret) GetEncryptedDataKey() string {
+	return s.encryptedDataKey
+}
+
+func (s *KmsSecret) Encrypt(data []byte) ([]byte, error) {
+	nonce := make([]byte, s.aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+
+	sealed := s.aead.Seal(nonce, nonce, data, nil)
+
+	return append(backendPrefix(KmsBackend), []byte(base64.StdEncoding.EncodeToString(sealed))...), nil
+}
+
+func (s *KmsSecret) Decrypt(encodedData []byte) ([]byte, error) {
+	if len(encodedData) == 0 {
+		return encodedData, nil
+	}
+
+	data, err := trimBackendPrefix(KmsBackend, encodedData)
+	if err != nil {
+		return nil, err
+	}
+
+	sealed, err := base64.StdEncoding.DecodeString(string(data))
+	if err != nil {
+		return nil, err
+	}
+
+	if len(sealed) < s.aead.NonceSize() {
+		return nil, fmt.Errorf("encrypted data is too short")
+	}
+
+	return s.aead.Open(nil, sealed[:s.aead.NonceSize()], sealed[s.aead.NonceSize():], nil)
+}
@@ -0,0 +1,131 @@
+package secret
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+)
+
+// newVaultTransitMock imitates vault transit encrypt and decrypt endpoints of the werf key in the transit mount
+func newVaultTransitMock(token string) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Vault-Token") != token {
+			http.Error(w, `{"errors":["permission denied"]}`, http.StatusForbidden)
+			return
+		}
+
+		var request map[string]string
+		if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		var data map[string]string
+		switch r.URL.Path {
+		case "/v1/transit/encrypt/werf":
+			data = map[string]string{"ciphertext": "vault:v1:" + request["plaintext"]}
+		case "/v1/transit/decrypt/werf":
+			data = map[string]string{"plaintext": strings.TrimPrefix(request["ciphertext"], "vault:v1:")}
+		default:
+			http.NotFound(w, r)
+			return
+		}
+
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{"data": data})
+	}))
+}
+
+func TestKmsSecret(t *testing.T) {
+	server := newVaultTransitMock("token")
+	defer server.Close()
+
+	os.Setenv("VAULT_ADDR", server.URL)
+	os.Setenv("VAULT_TOKEN", "token")
+	defer os.Unsetenv("VAULT_ADDR")
+	defer os.Unsetenv("VAULT_TOKEN")
+
+	kms, err := NewKms("vault:werf")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	encrypter, err := NewKmsSecret(kms)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !strings.HasPrefix(encrypter.GetEncryptedDataKey(), "vault:v1:") {
+		t.Fatalf("unexpected encrypted data key %s", encrypter.GetEncryptedDataKey())
+	}
+
+	data := []byte("password")
+	encodedData, err := encrypter.Encrypt(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if backend := DetectBackend(encodedData); backend != KmsBackend {
+		t.Fatalf("unexpected backend %s", backend)
+	}
+
+	decrypter, err := NewKmsSecretWithDataKey(kms, encrypter.GetEncryptedDataKey())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	resultData, err := decrypter.Decrypt(encodedData)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !bytes.Equal(data, resultData) {
+		t.Fatalf("expected %q, got %q", data, resultData)
+	}
+
+	if _, err := NewKmsSecretWithDataKey(&VaultTransit{Address: server.URL, Token: "invalid", Mount: "transit", Key: "werf"}, encrypter.GetEncryptedDataKey()); err == nil || !strings.Contains(err.Error(), "permission denied") {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestHttpKms(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		var request map[string]string
+		_ = json.NewDecoder(r.Body).Decode(&request)
+
+		switch r.URL.Path {
+		case "/keys/werf/encrypt":
+			plaintext, _ := base64.StdEncoding.DecodeString(request["plaintext"])
+			_ = json.NewEncoder(w).Encode(map[string]string{"ciphertext": base64.StdEncoding.EncodeToString(append([]byte("wrapped:"), plaintext...))})
+		case "/keys/werf/decrypt":
+			ciphertext, _ := base64.StdEncoding.DecodeString(request["ciphertext"])
+			_ = json.NewEncoder(w).Encode(map[string]string{"plaintext": base64.StdEncoding.EncodeToString(bytes.TrimPrefix(ciphertext, []byte("wrapped:")))})
+		}
+	}))
+	defer server.Close()
+
+	kms := &HttpKms{Url: server.URL + "/keys/werf", Token: "token"}
+
+	ciphertext, err := kms.Encrypt([]byte("data key"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	plaintext, err := kms.Decrypt(ciphertext)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if string(plaintext) != "data key" {
+		t.Fatalf("unexpected plaintext %q", plaintext)
+	}
+}
@@ -0,0 +1,114 @@
+package secret
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"io/ioutil"
+
+	"github.com/ProtonMail/go-crypto/openpgp"
+)
+
+type PgpSecret struct {
+	recipients openpgp.EntityList
+	keyring    openpgp.EntityList
+}
+
+// NewPgpSecret creates secret which encrypts data for all recipients public keys and decrypts data by any of private keys.
+// Keys are armored OpenPGP key rings, encrypted private keys are decrypted by passphrase.
+func NewPgpSecret(recipientsKeys [][]byte, privateKeys [][]byte, passphrase []byte) (*PgpSecret, error) {
+	s := &PgpSecret{}
+
+	for _, keyData := range recipientsKeys {
+		entities, err := openpgp.ReadArmoredKeyRing(bytes.NewReader(keyData))
+		if err != nil {
+			return nil, fmt.Errorf("unable to read OpenPGP public key: %s", err)
+		}
+		s.recipients = append(s.recipients, entities...)
+	}
+
+	for _, keyData := range privateKeys {
+		entities, err := openpgp.ReadArmoredKeyRing(bytes.NewReader(keyData))
+		if err != nil {
+			return nil, fmt.Errorf("unable to read OpenPGP private key: %s", err)
+		}
+
+		for _, entity := range entities {
+			if err := decryptPgpEntity(entity, passphrase); err != nil {
+				return nil, err
+			}
+		}
+
+		s.keyring = append(s.keyring, entities...)
+	}
+
+	return s, nil
+}
+
+func decryptPgpEntity(entity *openpgp.Entity, passphrase []byte) error {
+	if entity.PrivateKey != nil && entity.PrivateKey.Encrypted {
+		if len(passphrase) == 0 {
+			return fmt.Errorf("OpenPGP private key %s is encrypted: passphrase required", entity.PrimaryKey.KeyIdString())
+		}
+
+		if err := entity.PrivateKey.Decrypt(passphrase); err != nil {
+			return fmt.Errorf("unable to decrypt OpenPGP private key %s: %s", entity.PrimaryKey.KeyIdString(), err)
+		}
+	}
+
+	for _, subkey := range entity.Subkeys {
+		if subkey.PrivateKey != nil && subkey.PrivateKey.Encrypted {
+			if err := subkey.PrivateKey.Decrypt(passphrase); err != nil {
+				return fmt.Errorf("unable to decrypt OpenPGP private subkey %s: %s", subkey.PublicKey.KeyIdString(), err)
+			}
+		}
+	}
+
+	return nil
+}
+
+func (s *PgpSecret) Encrypt(data []byte) ([]byte, error) {
+	if len(s.recipients) == 0 {
+		return nil, fmt.Errorf("no OpenPGP recipients specified")
+	}
+
+	buf := bytes.NewBuffer(nil)
+
+	w, err := openpgp.Encrypt(buf, s.recipients, nil, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := w.Write(data); err != nil {
+		return nil, err
+	}
+
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+
+	return append(backendPrefix(PgpBackend), []byte(base64.StdEncoding.EncodeToString(buf.Bytes()))...), nil
+}
+
+func (s *PgpSecret) Decrypt(encodedData []byte) ([]byte, error) {
+	if len(s.keyring) == 0 {
+		return nil, fmt.Errorf("no OpenPGP private keys specified")
+	}
+
+	data, err := trimBackendPrefix(PgpBackend, encodedData)
+	if err != nil {
+		return nil, err
+	}
+
+	message, err := base64.StdEncoding.DecodeString(string(data))
+	if err != nil {
+		return nil, err
+	}
+
+	md, err := openpgp.ReadMessage(bytes.NewReader(message), s.keyring, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return ioutil.ReadAll(md.UnverifiedBody)
+}
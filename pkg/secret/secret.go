@@ -1,10 +1,29 @@
 package secret
 
+import (
+	"bytes"
+	"fmt"
+	"strings"
+)
+
 type Secret interface {
 	Encrypt(data []byte) ([]byte, error)
 	Decrypt(encodedData []byte) ([]byte, error)
 }
 
+type Backend string
+
+const (
+	AesBackend Backend = "aes"
+	AgeBackend Backend = "age"
+	PgpBackend Backend = "pgp"
+	KmsBackend Backend = "kms"
+)
+
+// Data encrypted by other backends is prefixed with the backend name (e.g. age:BASE64),
+// the hex encoded data of the aes backend has no prefix to stay compatible with existing secrets
+const backendPrefixSeparator = ":"
+
 func NewSecret(key []byte) (Secret, error) {
 	s, err := NewAesSecret(key)
 	if err != nil {
@@ -13,3 +32,68 @@ func NewSecret(key []byte) (Secret, error) {
 
 	return s, nil
 }
+
+func DetectBackend(encodedData []byte) Backend {
+	for _, backend := range []Backend{AgeBackend, PgpBackend, KmsBackend} {
+		if bytes.HasPrefix(encodedData, backendPrefix(backend)) {
+			return backend
+		}
+	}
+
+	return AesBackend
+}
+
+// RecipientBackend detects backend by recipient: age X25519 public key (age1...), path to the armored OpenPGP public key (pgp:PATH)
+// or key management service key (vault:[MOUNT/]KEY or kms:URL)
+func RecipientBackend(recipient string) (Backend, error) {
+	switch {
+	case strings.HasPrefix(recipient, ageRecipientPrefix):
+		return AgeBackend, nil
+	case strings.HasPrefix(recipient, string(backendPrefix(PgpBackend))):
+		return PgpBackend, nil
+	case strings.HasPrefix(recipient, vaultRecipientPrefix), strings.HasPrefix(recipient, kmsRecipientPrefix):
+		return KmsBackend, nil
+	default:
+		return "", fmt.Errorf("unknown recipient %q: expected age X25519 public key (age1...), path to the armored OpenPGP public key (pgp:PATH) or kms key (vault:[MOUNT/]KEY or kms:URL)", recipient)
+	}
+}
+
+// RecipientsBackend detects the only backend used by all recipients
+func RecipientsBackend(recipients []string) (Backend, error) {
+	var res Backend
+	for _, recipient := range recipients {
+		backend, err := RecipientBackend(recipient)
+		if err != nil {
+			return "", err
+		}
+
+		if res != "" && res != backend {
+			return "", fmt.Errorf("recipients of different backends cannot be mixed: %s", strings.Join(recipients, ", "))
+		}
+		res = backend
+	}
+
+	if res == "" {
+		return "", fmt.Errorf("no recipients specified")
+	}
+
+	// data key is encrypted by the only kms key
+	if res == KmsBackend && len(recipients) > 1 {
+		return "", fmt.Errorf("only one kms recipient can be specified: %s", strings.Join(recipients, ", "))
+	}
+
+	return res, nil
+}
+
+func backendPrefix(backend Backend) []byte {
+	return []byte(string(backend) + backendPrefixSeparator)
+}
+
+func trimBackendPrefix(backend Backend, encodedData []byte) ([]byte, error) {
+	prefix := backendPrefix(backend)
+	if !bytes.HasPrefix(encodedData, prefix) {
+		return nil, fmt.Errorf("data is not encrypted by %s backend: expected %q prefix", backend, string(prefix))
+	}
+
+	return bytes.TrimSpace(bytes.TrimPrefix(encodedData, prefix)), nil
+}
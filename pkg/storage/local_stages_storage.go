@@ -30,26 +30,65 @@ func (storage *LocalStagesStorage) AddManagedImage(projectName, imageName string
 
 	fullImageName := makeConfigImageRecordImageName(projectName, imageName)
 
-	if exsts, err := docker.ImageExist(fullImageName); err != nil {
+	if exists, err := docker.ImageExist(fullImageName); err != nil {
 		return fmt.Errorf("unable to check existence of image %q: %s", fullImageName, err)
-	} else if exsts {
+	} else if exists {
 		return nil
 	}
 
-	if err := docker.CreateImage(fullImageName); err != nil {
+	if err := docker.CreateImage(fullImageName, NewManagedImageRecord(imageName).Labels()); err != nil {
 		return fmt.Errorf("unable to create image %q: %s", fullImageName, err)
 	}
 	return nil
 }
 
+func (storage *LocalStagesStorage) SetManagedImage(projectName string, record *ManagedImageRecord) error {
+	logboek.Debug.LogF("-- LocalStagesStorage.SetManagedImage %s %s\n", projectName, record.Name)
+
+	fullImageName := makeConfigImageRecordImageName(projectName, record.Name)
+
+	var oldImageId string
+	if exists, err := docker.ImageExist(fullImageName); err != nil {
+		return fmt.Errorf("unable to check existence of image %q: %s", fullImageName, err)
+	} else if exists {
+		inspect, err := docker.ImageInspect(fullImageName)
+		if err != nil {
+			return fmt.Errorf("unable to inspect image %q: %s", fullImageName, err)
+		}
+		oldImageId = inspect.ID
+	}
+
+	// the new record image takes over the tag, so the record is never missing, the old record image is removed afterwards
+	if err := docker.CreateImage(fullImageName, record.Labels()); err != nil {
+		return fmt.Errorf("unable to create image %q: %s", fullImageName, err)
+	}
+
+	if oldImageId == "" {
+		return nil
+	}
+
+	inspect, err := docker.ImageInspect(fullImageName)
+	if err != nil {
+		return fmt.Errorf("unable to inspect image %q: %s", fullImageName, err)
+	}
+
+	if inspect.ID != oldImageId {
+		if err := docker.CliRmi(oldImageId); err != nil {
+			logboek.LogWarnF("WARNING: unable to remove old managed image record %s of image %q: %s\n", oldImageId, fullImageName, err)
+		}
+	}
+
+	return nil
+}
+
 func (storage *LocalStagesStorage) RmManagedImage(projectName, imageName string) error {
 	logboek.Debug.LogF("-- LocalStagesStorage.RmManagedImage %s %s\n", projectName, imageName)
 
 	fullImageName := makeConfigImageRecordImageName(projectName, imageName)
 
-	if exsts, err := docker.ImageExist(fullImageName); err != nil {
+	if exists, err := docker.ImageExist(fullImageName); err != nil {
 		return fmt.Errorf("unable to check existence of image %q: %s", fullImageName, err)
-	} else if !exsts {
+	} else if !exists {
 		return nil
 	}
 
@@ -59,7 +98,7 @@ func (storage *LocalStagesStorage) RmManagedImage(projectName, imageName string)
 	return nil
 }
 
-func (storage *LocalStagesStorage) GetManagedImages(projectName string) ([]string, error) {
+func (storage *LocalStagesStorage) GetManagedImages(projectName string) ([]*ManagedImageRecord, error) {
 	logboek.Debug.LogF("-- LocalStagesStorage.GetManagedImages %s\n", projectName)
 
 	filterSet := filters.NewArgs()
@@ -70,16 +109,20 @@ func (storage *LocalStagesStorage) GetManagedImages(projectName string) ([]strin
 		return nil, fmt.Errorf("unable to get docker images: %s", err)
 	}
 
-	res := []string{}
+	res := []*ManagedImageRecord{}
 	for _, img := range images {
 		for _, repoTag := range img.RepoTags {
-			tag := strings.SplitN(repoTag, ":", 2)[1]
+			imageName := strings.SplitN(repoTag, ":", 2)[1]
+			if imageName == NamelessImageRecordTag {
+				imageName = ""
+			}
 
-			if tag == NamelessImageRecordTag {
-				res = append(res, "")
-			} else {
-				res = append(res, tag)
+			record, err := NewManagedImageRecordFromLabels(imageName, img.Labels)
+			if err != nil {
+				return nil, fmt.Errorf("unable to parse managed image record %q: %s", repoTag, err)
 			}
+
+			res = append(res, record)
 		}
 	}
 	return res, nil
@@ -0,0 +1,123 @@
+package storage
+
+import (
+	"fmt"
+	"os"
+	"os/user"
+	"strconv"
+	"time"
+
+	"github.com/flant/werf/pkg/image"
+)
+
+// ManagedImageRecord describes the managed image of the project.
+// The record is stored in the stages storage as an empty image with the metadata in labels, so that the same format is used for any stages storage
+type ManagedImageRecord struct {
+	Name        string                      `json:"name"`
+	AddedBy     string                      `json:"addedBy,omitempty"`
+	AddedAt     time.Time                   `json:"addedAt"`
+	Description string                      `json:"description,omitempty"`
+	Cleanup     ManagedImageCleanupSettings `json:"cleanup"`
+}
+
+// ManagedImageCleanupSettings overrides the images cleanup policies for the managed image.
+// Nil limits are not overridden, -1 disables the limit for the image
+type ManagedImageCleanupSettings struct {
+	KeepForever                 bool   `json:"keepForever"`
+	GitTagStrategyLimit         *int64 `json:"gitTagStrategyLimit,omitempty"`
+	GitTagStrategyExpiryDays    *int64 `json:"gitTagStrategyExpiryDays,omitempty"`
+	GitCommitStrategyLimit      *int64 `json:"gitCommitStrategyLimit,omitempty"`
+	GitCommitStrategyExpiryDays *int64 `json:"gitCommitStrategyExpiryDays,omitempty"`
+}
+
+func NewManagedImageRecord(imageName string) *ManagedImageRecord {
+	return &ManagedImageRecord{
+		Name:    imageName,
+		AddedBy: currentUserName(),
+		AddedAt: time.Now().UTC().Truncate(time.Second),
+	}
+}
+
+func (record *ManagedImageRecord) Labels() map[string]string {
+	labels := map[string]string{
+		image.WerfManagedImageAddedAtLabel: record.AddedAt.UTC().Format(time.RFC3339),
+	}
+
+	if record.AddedBy != "" {
+		labels[image.WerfManagedImageAddedByLabel] = record.AddedBy
+	}
+
+	if record.Description != "" {
+		labels[image.WerfManagedImageDescriptionLabel] = record.Description
+	}
+
+	if record.Cleanup.KeepForever {
+		labels[image.WerfManagedImageKeepForeverLabel] = "true"
+	}
+
+	for label, value := range map[string]*int64{
+		image.WerfManagedImageGitTagStrategyLimitLabel:         record.Cleanup.GitTagStrategyLimit,
+		image.WerfManagedImageGitTagStrategyExpiryDaysLabel:    record.Cleanup.GitTagStrategyExpiryDays,
+		image.WerfManagedImageGitCommitStrategyLimitLabel:      record.Cleanup.GitCommitStrategyLimit,
+		image.WerfManagedImageGitCommitStrategyExpiryDaysLabel: record.Cleanup.GitCommitStrategyExpiryDays,
+	} {
+		if value != nil {
+			labels[label] = strconv.FormatInt(*value, 10)
+		}
+	}
+
+	return labels
+}
+
+// NewManagedImageRecordFromLabels restores the record, the records created by the previous werf versions have no labels
+func NewManagedImageRecordFromLabels(imageName string, labels map[string]string) (*ManagedImageRecord, error) {
+	record := &ManagedImageRecord{
+		Name:        imageName,
+		AddedBy:     labels[image.WerfManagedImageAddedByLabel],
+		Description: labels[image.WerfManagedImageDescriptionLabel],
+	}
+
+	if value, ok := labels[image.WerfManagedImageAddedAtLabel]; ok {
+		addedAt, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			return nil, fmt.Errorf("bad label %s=%q: %s", image.WerfManagedImageAddedAtLabel, value, err)
+		}
+		record.AddedAt = addedAt
+	}
+
+	if value, ok := labels[image.WerfManagedImageKeepForeverLabel]; ok {
+		keepForever, err := strconv.ParseBool(value)
+		if err != nil {
+			return nil, fmt.Errorf("bad label %s=%q: %s", image.WerfManagedImageKeepForeverLabel, value, err)
+		}
+		record.Cleanup.KeepForever = keepForever
+	}
+
+	for label, field := range map[string]**int64{
+		image.WerfManagedImageGitTagStrategyLimitLabel:         &record.Cleanup.GitTagStrategyLimit,
+		image.WerfManagedImageGitTagStrategyExpiryDaysLabel:    &record.Cleanup.GitTagStrategyExpiryDays,
+		image.WerfManagedImageGitCommitStrategyLimitLabel:      &record.Cleanup.GitCommitStrategyLimit,
+		image.WerfManagedImageGitCommitStrategyExpiryDaysLabel: &record.Cleanup.GitCommitStrategyExpiryDays,
+	} {
+		value, ok := labels[label]
+		if !ok {
+			continue
+		}
+
+		number, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("bad label %s=%q: %s", label, value, err)
+		}
+		*field = &number
+	}
+
+	return record, nil
+}
+
+func currentUserName() string {
+	if u, err := user.Current(); err == nil && u.Username != "" {
+		return u.Username
+	}
+
+	return os.Getenv("USER")
+}
@@ -0,0 +1,44 @@
+package storage
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestManagedImageRecordLabels(t *testing.T) {
+	limit, expiryDays := int64(5), int64(-1)
+
+	record := &ManagedImageRecord{
+		Name:        "backend",
+		AddedBy:     "user",
+		AddedAt:     time.Date(2020, 3, 1, 10, 0, 0, 0, time.UTC),
+		Description: `kept for "audit"`,
+		Cleanup: ManagedImageCleanupSettings{
+			GitTagStrategyLimit:         &limit,
+			GitCommitStrategyExpiryDays: &expiryDays,
+		},
+	}
+
+	restored, err := NewManagedImageRecordFromLabels(record.Name, record.Labels())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(record, restored) {
+		t.Errorf("\n[EXPECTED]: %+v\n[GOT]: %+v", record, restored)
+	}
+
+	legacy, err := NewManagedImageRecordFromLabels("", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(legacy, &ManagedImageRecord{}) {
+		t.Errorf("record without labels should be empty, got %+v", legacy)
+	}
+
+	if _, err := NewManagedImageRecordFromLabels("backend", map[string]string{"werf-managed-image-git-tag-strategy-limit": "many"}); err == nil {
+		t.Errorf("bad limit label should not be accepted")
+	}
+}
@@ -26,9 +26,12 @@ type StagesStorage interface {
 	SyncStageImage(stageImage image.ImageInterface) error
 	StoreStageImage(stageImage image.ImageInterface) error
 
+	// AddManagedImage creates the record of the managed image if it does not exist, the metadata of the existing record is kept
 	AddManagedImage(projectName, imageName string) error
+	// SetManagedImage creates or replaces the record of the managed image with the metadata
+	SetManagedImage(projectName string, record *ManagedImageRecord) error
 	RmManagedImage(projectName, imageName string) error
-	GetManagedImages(projectName string) ([]string, error)
+	GetManagedImages(projectName string) ([]*ManagedImageRecord, error)
 
 	String() string
 }
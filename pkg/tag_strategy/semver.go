@@ -0,0 +1,74 @@
+package tag_strategy
+
+import (
+	"fmt"
+	"regexp"
+
+	"github.com/Masterminds/semver"
+)
+
+const LatestSemverAlias = "latest"
+
+// Only complete versions are releases: "1" and "1.4" are parsed by semver library too, but these are aliases
+var semverReleaseRegexp = regexp.MustCompile(`^v?[0-9]+\.[0-9]+\.[0-9]+(-[0-9A-Za-z.\-]+)?$`)
+
+func ParseSemverRelease(tag string) (*semver.Version, error) {
+	if !semverReleaseRegexp.MatchString(tag) {
+		return nil, fmt.Errorf("semantic version MAJOR.MINOR.PATCH expected (e.g. v1.4.2)")
+	}
+
+	return semver.NewVersion(tag)
+}
+
+// SemverAliases returns floating tags (MAJOR, MAJOR.MINOR and latest) that should be moved to the release.
+// Alias is moved only when the release is the highest one of the existing releases in the alias scope,
+// thus publishing of v1.3.5 after v1.4.2 moves only 1.3 alias. Prereleases are never aliased.
+func SemverAliases(release string, existingTags []string) ([]string, error) {
+	version, err := ParseSemverRelease(release)
+	if err != nil {
+		return nil, fmt.Errorf("bad release %s: %s", release, err)
+	}
+
+	if version.Prerelease() != "" {
+		return nil, nil
+	}
+
+	majorAlias := fmt.Sprintf("%d", version.Major())
+	minorAlias := fmt.Sprintf("%d.%d", version.Major(), version.Minor())
+
+	isHighest := map[string]bool{
+		majorAlias:        true,
+		minorAlias:        true,
+		LatestSemverAlias: true,
+	}
+
+	for _, tag := range existingTags {
+		existingVersion, err := ParseSemverRelease(tag)
+		if err != nil || existingVersion.Prerelease() != "" {
+			continue
+		}
+
+		if !existingVersion.GreaterThan(version) {
+			continue
+		}
+
+		isHighest[LatestSemverAlias] = false
+
+		if existingVersion.Major() == version.Major() {
+			isHighest[majorAlias] = false
+
+			if existingVersion.Minor() == version.Minor() {
+				isHighest[minorAlias] = false
+			}
+		}
+	}
+
+	var aliases []string
+	for _, alias := range []string{majorAlias, minorAlias, LatestSemverAlias} {
+		if isHighest[alias] {
+			aliases = append(aliases, alias)
+		}
+	}
+
+	return aliases, nil
+}
@@ -0,0 +1,44 @@
+package tag_strategy
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSemverAliases(t *testing.T) {
+	existingTags := []string{"v1.3.0", "v1.4.1", "1", "1.4", "latest", "master", "v2.0.0-rc.1"}
+
+	testCases := []struct {
+		release         string
+		existingTags    []string
+		expectedAliases []string
+	}{
+		{"v1.4.2", existingTags, []string{"1", "1.4", "latest"}},
+		{"1.4.2", existingTags, []string{"1", "1.4", "latest"}},
+		{"v1.3.5", existingTags, []string{"1.3"}},
+		{"v1.4.0", existingTags, nil},
+		{"v0.9.0", existingTags, []string{"0", "0.9"}},
+		{"v2.0.0-rc.2", existingTags, nil},
+		{"v1.4.2", append(existingTags, "v2.0.0"), []string{"1", "1.4"}},
+		{"v1.4.2", nil, []string{"1", "1.4", "latest"}},
+	}
+
+	for _, tc := range testCases {
+		aliases, err := SemverAliases(tc.release, tc.existingTags)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if !reflect.DeepEqual(aliases, tc.expectedAliases) {
+			t.Errorf("release %s: expected aliases %v, got %v", tc.release, tc.expectedAliases, aliases)
+		}
+	}
+}
+
+func TestParseSemverRelease(t *testing.T) {
+	for _, tag := range []string{"1", "1.4", "latest", "v1.4", "v1.4.2+build"} {
+		if _, err := ParseSemverRelease(tag); err == nil {
+			t.Errorf("tag %s expected not to be a release", tag)
+		}
+	}
+}
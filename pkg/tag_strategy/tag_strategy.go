@@ -7,5 +7,6 @@ const (
 	GitTag          TagStrategy = "git-tag"
 	GitBranch       TagStrategy = "git-branch"
 	GitCommit       TagStrategy = "git-commit"
+	Semver          TagStrategy = "semver"
 	StagesSignature TagStrategy = "stages-signature"
 )
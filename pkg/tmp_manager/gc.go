@@ -12,6 +12,7 @@ import (
 	"github.com/flant/logboek"
 
 	"github.com/flant/shluz"
+	"github.com/flant/werf/pkg/logging"
 	"github.com/flant/werf/pkg/util"
 	"github.com/flant/werf/pkg/werf"
 )
@@ -80,7 +81,7 @@ func checkShouldRunGC() (bool, error) {
 }
 
 func GC(dryRun bool) error {
-	return logboek.LogProcess("Running GC for tmp data", logboek.LogProcessOptions{}, func() error { return gc(dryRun) })
+	return logging.LogProcess("Running GC for tmp data", logboek.LogProcessOptions{}, func() error { return gc(dryRun) })
 }
 
 func gc(dryRun bool) error {
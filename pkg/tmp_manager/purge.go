@@ -10,12 +10,13 @@ import (
 
 	"github.com/flant/logboek"
 
+	"github.com/flant/werf/pkg/logging"
 	"github.com/flant/werf/pkg/util"
 	"github.com/flant/werf/pkg/werf"
 )
 
 func Purge(dryRun bool) error {
-	return logboek.LogProcess("Running purge for tmp data", logboek.LogProcessOptions{}, func() error { return purge(dryRun) })
+	return logging.LogProcess("Running purge for tmp data", logboek.LogProcessOptions{}, func() error { return purge(dryRun) })
 }
 
 func purge(dryRun bool) error {
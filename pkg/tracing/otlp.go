@@ -0,0 +1,192 @@
+package tracing
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	otlpTracesPath = "/v1/traces"
+
+	otlpSpanKindInternal = 1
+	otlpStatusCodeOk     = 1
+	otlpStatusCodeError  = 2
+)
+
+// OTLP JSON encoding of ExportTraceServiceRequest (https://github.com/open-telemetry/opentelemetry-proto)
+
+type otlpTracesData struct {
+	ResourceSpans []otlpResourceSpans `json:"resourceSpans"`
+}
+
+type otlpResourceSpans struct {
+	Resource   otlpResource     `json:"resource"`
+	ScopeSpans []otlpScopeSpans `json:"scopeSpans"`
+}
+
+type otlpResource struct {
+	Attributes []otlpKeyValue `json:"attributes"`
+}
+
+type otlpScopeSpans struct {
+	Scope otlpScope  `json:"scope"`
+	Spans []otlpSpan `json:"spans"`
+}
+
+type otlpScope struct {
+	Name string `json:"name"`
+}
+
+type otlpSpan struct {
+	TraceId           string         `json:"traceId"`
+	SpanId            string         `json:"spanId"`
+	ParentSpanId      string         `json:"parentSpanId,omitempty"`
+	Name              string         `json:"name"`
+	Kind              int            `json:"kind"`
+	StartTimeUnixNano string         `json:"startTimeUnixNano"`
+	EndTimeUnixNano   string         `json:"endTimeUnixNano"`
+	Attributes        []otlpKeyValue `json:"attributes,omitempty"`
+	Status            otlpStatus     `json:"status"`
+}
+
+type otlpStatus struct {
+	Code    int    `json:"code"`
+	Message string `json:"message,omitempty"`
+}
+
+type otlpKeyValue struct {
+	Key   string         `json:"key"`
+	Value otlpValueOneOf `json:"value"`
+}
+
+type otlpValueOneOf struct {
+	StringValue string `json:"stringValue"`
+}
+
+func (t *Tracer) export() error {
+	if len(t.finished) == 0 {
+		return nil
+	}
+
+	data, err := json.Marshal(t.tracesData())
+	if err != nil {
+		return err
+	}
+
+	if t.opts.File != "" {
+		if err := ioutil.WriteFile(t.opts.File, append(data, '\n'), 0644); err != nil {
+			return fmt.Errorf("unable to write traces file %s: %s", t.opts.File, err)
+		}
+	}
+
+	if t.opts.Endpoint != "" {
+		if err := postTraces(t.opts.Endpoint, data); err != nil {
+			return fmt.Errorf("unable to export traces to %s: %s", t.opts.Endpoint, err)
+		}
+	}
+
+	return nil
+}
+
+func (t *Tracer) tracesData() otlpTracesData {
+	resourceAttributes := map[string]string{"service.name": t.opts.ServiceName}
+	for k, v := range t.opts.Attributes {
+		resourceAttributes[k] = v
+	}
+
+	var spans []otlpSpan
+	for _, s := range t.finished {
+		span := otlpSpan{
+			TraceId:           t.traceId,
+			SpanId:            s.spanId,
+			ParentSpanId:      s.parentSpanId,
+			Name:              s.name,
+			Kind:              otlpSpanKindInternal,
+			StartTimeUnixNano: unixNano(s.startTime),
+			EndTimeUnixNano:   unixNano(s.endTime),
+			Attributes:        otlpAttributes(s.attributes),
+			Status:            otlpStatus{Code: otlpStatusCodeOk},
+		}
+
+		if s.err != nil {
+			span.Status = otlpStatus{Code: otlpStatusCodeError, Message: s.err.Error()}
+		}
+
+		spans = append(spans, span)
+	}
+
+	return otlpTracesData{
+		ResourceSpans: []otlpResourceSpans{
+			{
+				Resource: otlpResource{Attributes: otlpAttributes(resourceAttributes)},
+				ScopeSpans: []otlpScopeSpans{
+					{
+						Scope: otlpScope{Name: "werf"},
+						Spans: spans,
+					},
+				},
+			},
+		},
+	}
+}
+
+func postTraces(endpoint string, data []byte) error {
+	url := strings.TrimSuffix(endpoint, "/")
+	if !strings.HasSuffix(url, otlpTracesPath) {
+		url += otlpTracesPath
+	}
+
+	req, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	// The same format as OTEL_EXPORTER_OTLP_HEADERS of OpenTelemetry SDKs: key1=value1,key2=value2
+	for _, header := range strings.Split(os.Getenv("OTEL_EXPORTER_OTLP_HEADERS"), ",") {
+		parts := strings.SplitN(header, "=", 2)
+		if len(parts) == 2 {
+			req.Header.Set(strings.TrimSpace(parts[0]), strings.TrimSpace(parts[1]))
+		}
+	}
+
+	client := &http.Client{Timeout: 30 * time.Second}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		body, _ := ioutil.ReadAll(resp.Body)
+		return fmt.Errorf("unexpected response status %s: %s", resp.Status, strings.TrimSpace(string(body)))
+	}
+
+	return nil
+}
+
+func otlpAttributes(attributes map[string]string) []otlpKeyValue {
+	var keys []string
+	for k := range attributes {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var res []otlpKeyValue
+	for _, k := range keys {
+		res = append(res, otlpKeyValue{Key: k, Value: otlpValueOneOf{StringValue: attributes[k]}})
+	}
+
+	return res
+}
+
+func unixNano(t time.Time) string {
+	return fmt.Sprintf("%d", t.UnixNano())
+}
@@ -0,0 +1,234 @@
+package tracing
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"sync"
+	"time"
+)
+
+type Options struct {
+	// Endpoint of the OTLP/HTTP collector (e.g. http://localhost:4318)
+	Endpoint string
+	// File to write traces in the OTLP JSON format for offline analysis
+	File string
+
+	ServiceName string
+	Attributes  map[string]string
+}
+
+var (
+	// tracer is nil when tracing is disabled, all spans are no-op in that case
+	tracer      *Tracer
+	tracerMutex sync.RWMutex
+)
+
+type Tracer struct {
+	opts    Options
+	traceId string
+
+	mutex    sync.Mutex
+	stack    []*Span
+	finished []*Span
+	// stopped tracer does not change finished spans, which are being exported
+	stopped bool
+}
+
+type Span struct {
+	tracer *Tracer
+
+	name         string
+	spanId       string
+	parentSpanId string
+	startTime    time.Time
+	endTime      time.Time
+	attributes   map[string]string
+	err          error
+}
+
+func Init(opts Options) error {
+	if opts.Endpoint == "" && opts.File == "" {
+		return nil
+	}
+
+	traceId, err := generateId(16)
+	if err != nil {
+		return fmt.Errorf("unable to generate trace id: %s", err)
+	}
+
+	setTracer(&Tracer{opts: opts, traceId: traceId})
+
+	return nil
+}
+
+func IsEnabled() bool {
+	return getTracer() != nil
+}
+
+func getTracer() *Tracer {
+	tracerMutex.RLock()
+	defer tracerMutex.RUnlock()
+	return tracer
+}
+
+func setTracer(t *Tracer) *Tracer {
+	tracerMutex.Lock()
+	defer tracerMutex.Unlock()
+	old := tracer
+	tracer = t
+	return old
+}
+
+// Shutdown ends unfinished spans and exports the trace
+func Shutdown() error {
+	t := setTracer(nil)
+	if t == nil {
+		return nil
+	}
+
+	t.mutex.Lock()
+	t.stopped = true
+	for i := len(t.stack) - 1; i >= 0; i-- {
+		t.stack[i].endTime = time.Now()
+		t.finished = append(t.finished, t.stack[i])
+	}
+	t.stack = nil
+	t.mutex.Unlock()
+
+	return t.export()
+}
+
+// StartSpan starts a child span of the current innermost span (werf processes are sequential and nested like logboek processes)
+func StartSpan(name string, attributes map[string]string) *Span {
+	span := newSpan(name, attributes)
+	if span == nil {
+		return nil
+	}
+
+	t := span.tracer
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	if len(t.stack) > 0 {
+		span.parentSpanId = t.stack[len(t.stack)-1].spanId
+	}
+	t.stack = append(t.stack, span)
+
+	return span
+}
+
+// StartChildSpan starts a span of the explicitly passed parent span without changing the current innermost span,
+// so it is safe to use in the concurrent goroutines (the parent is usually taken by CurrentSpan before starting the goroutines)
+func StartChildSpan(parent *Span, name string, attributes map[string]string) *Span {
+	span := newSpan(name, attributes)
+	if span == nil {
+		return nil
+	}
+
+	if parent != nil {
+		span.parentSpanId = parent.spanId
+	}
+
+	return span
+}
+
+// CurrentSpan returns the current innermost span or nil when tracing is disabled or there are no started spans
+func CurrentSpan() *Span {
+	t := getTracer()
+	if t == nil {
+		return nil
+	}
+
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	if len(t.stack) == 0 {
+		return nil
+	}
+
+	return t.stack[len(t.stack)-1]
+}
+
+func newSpan(name string, attributes map[string]string) *Span {
+	t := getTracer()
+	if t == nil {
+		return nil
+	}
+
+	spanId, err := generateId(8)
+	if err != nil {
+		return nil
+	}
+
+	span := &Span{
+		tracer:     t,
+		name:       name,
+		spanId:     spanId,
+		startTime:  time.Now(),
+		attributes: map[string]string{},
+	}
+
+	for k, v := range attributes {
+		span.attributes[k] = v
+	}
+
+	return span
+}
+
+// WithSpan runs f inside the span and records returned error
+func WithSpan(name string, attributes map[string]string, f func() error) error {
+	span := StartSpan(name, attributes)
+	err := f()
+	span.End(err)
+
+	return err
+}
+
+func (s *Span) SetAttribute(key, value string) {
+	if s == nil {
+		return
+	}
+
+	s.tracer.mutex.Lock()
+	defer s.tracer.mutex.Unlock()
+
+	if s.tracer.stopped {
+		return
+	}
+
+	s.attributes[key] = value
+}
+
+func (s *Span) End(err error) {
+	if s == nil {
+		return
+	}
+
+	s.tracer.mutex.Lock()
+	defer s.tracer.mutex.Unlock()
+
+	if s.tracer.stopped {
+		return
+	}
+
+	for i := len(s.tracer.stack) - 1; i >= 0; i-- {
+		if s.tracer.stack[i] == s {
+			s.tracer.stack = append(s.tracer.stack[:i], s.tracer.stack[i+1:]...)
+			break
+		}
+	}
+
+	s.endTime = time.Now()
+	s.err = err
+	s.tracer.finished = append(s.tracer.finished, s)
+}
+
+func generateId(size int) (string, error) {
+	id := make([]byte, size)
+	if _, err := rand.Read(id); err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(id), nil
+}
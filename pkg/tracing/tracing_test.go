@@ -0,0 +1,165 @@
+package tracing
+
+import (
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+)
+
+func TestExportToEndpoint(t *testing.T) {
+	var requestPath string
+	var data otlpTracesData
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requestPath = r.URL.Path
+
+		body, err := ioutil.ReadAll(r.Body)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if err := json.Unmarshal(body, &data); err != nil {
+			t.Fatal(err)
+		}
+	}))
+	defer server.Close()
+
+	if err := Init(Options{Endpoint: server.URL, ServiceName: "werf"}); err != nil {
+		t.Fatal(err)
+	}
+
+	_ = WithSpan("build", nil, func() error {
+		StartSpan("stage from", map[string]string{"werf.stage.signature": "sig"}).End(nil)
+		return WithSpan("stage install", nil, func() error {
+			return errors.New("failed")
+		})
+	})
+
+	if err := Shutdown(); err != nil {
+		t.Fatal(err)
+	}
+
+	if IsEnabled() {
+		t.Fatal("tracing expected to be disabled after shutdown")
+	}
+
+	if requestPath != otlpTracesPath {
+		t.Fatalf("unexpected request path %s", requestPath)
+	}
+
+	spans := data.ResourceSpans[0].ScopeSpans[0].Spans
+	if len(spans) != 3 {
+		t.Fatalf("expected 3 spans, got %d", len(spans))
+	}
+
+	spansByName := map[string]otlpSpan{}
+	for _, span := range spans {
+		spansByName[span.Name] = span
+	}
+
+	root := spansByName["build"]
+	if root.ParentSpanId != "" || root.Status.Code != otlpStatusCodeError {
+		t.Fatalf("unexpected root span %+v", root)
+	}
+
+	for _, name := range []string{"stage from", "stage install"} {
+		if spansByName[name].ParentSpanId != root.SpanId || spansByName[name].TraceId != root.TraceId {
+			t.Fatalf("span %s expected to be a child of the root span: %+v", name, spansByName[name])
+		}
+	}
+
+	if attrs := spansByName["stage from"].Attributes; len(attrs) != 1 || attrs[0].Value.StringValue != "sig" {
+		t.Fatalf("unexpected attributes %+v", attrs)
+	}
+}
+
+func TestDisabled(t *testing.T) {
+	if err := Init(Options{}); err != nil {
+		t.Fatal(err)
+	}
+
+	span := StartSpan("noop", nil)
+	span.SetAttribute("key", "value")
+	span.End(nil)
+
+	if err := Shutdown(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestStartChildSpan(t *testing.T) {
+	if err := Init(Options{Endpoint: "http://localhost:0"}); err != nil {
+		t.Fatal(err)
+	}
+	defer setTracer(nil)
+
+	parent := StartSpan("images", nil)
+
+	var wg sync.WaitGroup
+	children := make([]*Span, 10)
+	for i := range children {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			children[i] = StartChildSpan(parent, "image", nil)
+			children[i].End(nil)
+		}(i)
+	}
+	wg.Wait()
+
+	if CurrentSpan() != parent {
+		t.Fatal("child spans should not change the current span")
+	}
+
+	for _, child := range children {
+		if child.parentSpanId != parent.spanId {
+			t.Fatalf("span %s expected to be a child of the span %s, got parent %s", child.spanId, parent.spanId, child.parentSpanId)
+		}
+	}
+
+	parent.End(nil)
+
+	if CurrentSpan() != nil {
+		t.Fatal("no current span expected after the parent span is ended")
+	}
+
+	if len(tracer.finished) != len(children)+1 {
+		t.Fatalf("expected %d finished spans, got %d", len(children)+1, len(tracer.finished))
+	}
+}
+
+func TestShutdown_ConcurrentSpans(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+
+	if err := Init(Options{Endpoint: server.URL}); err != nil {
+		t.Fatal(err)
+	}
+	defer setTracer(nil)
+
+	parent := StartSpan("images", nil)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				StartChildSpan(parent, "image", nil).End(nil)
+			}
+		}()
+	}
+
+	if err := Shutdown(); err != nil {
+		t.Fatal(err)
+	}
+	wg.Wait()
+
+	if IsEnabled() {
+		t.Fatal("tracing should be disabled after shutdown")
+	}
+}
@@ -8,6 +8,7 @@ import (
 	"io/ioutil"
 	"os"
 	"path/filepath"
+	"sort"
 
 	"gopkg.in/src-d/go-billy.v4/osfs"
 	"gopkg.in/src-d/go-git.v4"
@@ -17,6 +18,7 @@ import (
 
 	"github.com/flant/logboek"
 
+	"github.com/flant/werf/pkg/git_lfs"
 	"github.com/flant/werf/pkg/git_repo/ls_tree"
 	"github.com/flant/werf/pkg/path_matcher"
 	"github.com/flant/werf/pkg/util"
@@ -25,11 +27,18 @@ import (
 type ArchiveOptions struct {
 	Commit      string
 	PathMatcher path_matcher.PathMatcher
+	// LfsResolver enables replacing of git lfs pointers with the objects content
+	LfsResolver LfsResolver
+}
+
+type LfsResolver interface {
+	FetchObjects(pointers []*git_lfs.Pointer) (map[string]string, error)
 }
 
 type ArchiveDescriptor struct {
 	Type    ArchiveType
 	IsEmpty bool
+	LfsOids []string
 }
 
 type ArchiveType string
@@ -141,6 +150,28 @@ func writeArchive(out io.Writer, gitDir, workTreeCacheDir string, withSubmodules
 	}
 	logboek.Debug.LogProcessEnd(logboek.LevelLogProcessEndOptions{})
 
+	var lfsPointers map[string]*git_lfs.Pointer
+	var lfsObjectsPaths map[string]string
+	if opts.LfsResolver != nil {
+		lfsPointers, err = findLfsPointers(result, workTreeDir)
+		if err != nil {
+			return nil, err
+		}
+
+		if len(lfsPointers) != 0 {
+			var pointers []*git_lfs.Pointer
+			for _, pointer := range lfsPointers {
+				pointers = append(pointers, pointer)
+				desc.LfsOids = appendUnique(desc.LfsOids, pointer.Oid)
+			}
+			sort.Strings(desc.LfsOids)
+
+			if lfsObjectsPaths, err = opts.LfsResolver.FetchObjects(pointers); err != nil {
+				return nil, fmt.Errorf("unable to fetch lfs objects: %s", err)
+			}
+		}
+	}
+
 	logProcessMsg = fmt.Sprintf("ls-tree result walk (%s)", opts.PathMatcher.String())
 	logboek.Debug.LogProcessStart(logProcessMsg, logboek.LevelLogProcessStartOptions{})
 	if err := result.Walk(func(lsTreeEntry *ls_tree.LsTreeEntry) error {
@@ -157,11 +188,21 @@ func writeArchive(out io.Writer, gitDir, workTreeCacheDir string, withSubmodules
 
 		switch gitFileMode {
 		case filemode.Regular, filemode.Executable, filemode.Deprecated:
+			size := info.Size()
+			if pointer, ok := lfsPointers[lsTreeEntry.Filepath]; ok {
+				absFilepath = lfsObjectsPaths[pointer.Oid]
+				size = pointer.Size
+
+				if debugArchive() {
+					logboek.Debug.LogF("Replaced lfs pointer '%s' with object %s\n", relToBasePathFilepath, pointer.Oid)
+				}
+			}
+
 			err = tw.WriteHeader(&tar.Header{
 				Format:     tar.FormatGNU,
 				Name:       tarEntryName,
 				Mode:       int64(gitFileMode),
-				Size:       info.Size(),
+				Size:       size,
 				ModTime:    info.ModTime(),
 				AccessTime: info.ModTime(),
 				ChangeTime: info.ModTime(),
@@ -245,6 +286,42 @@ func writeArchive(out io.Writer, gitDir, workTreeCacheDir string, withSubmodules
 	return desc, nil
 }
 
+// findLfsPointers returns git lfs pointers by the file path, only small regular files are checked
+func findLfsPointers(result *ls_tree.Result, workTreeDir string) (map[string]*git_lfs.Pointer, error) {
+	res := map[string]*git_lfs.Pointer{}
+
+	err := result.Walk(func(lsTreeEntry *ls_tree.LsTreeEntry) error {
+		switch lsTreeEntry.Mode {
+		case filemode.Regular, filemode.Executable, filemode.Deprecated:
+		default:
+			return nil
+		}
+
+		absFilepath := filepath.Join(workTreeDir, lsTreeEntry.Filepath)
+		info, err := os.Lstat(absFilepath)
+		if err != nil {
+			return fmt.Errorf("lstat %s failed: %s", absFilepath, err)
+		}
+
+		if info.Size() > git_lfs.MaxPointerSize {
+			return nil
+		}
+
+		data, err := ioutil.ReadFile(absFilepath)
+		if err != nil {
+			return fmt.Errorf("cannot read file %s: %s", absFilepath, err)
+		}
+
+		if pointer, ok := git_lfs.ParsePointer(data); ok {
+			res[lsTreeEntry.Filepath] = pointer
+		}
+
+		return nil
+	})
+
+	return res, err
+}
+
 func gitOpenWithCustomWorktreeDir(gitDir string, worktreeDir string) (*git.Repository, error) {
 	worktreeFilesystem := osfs.New(worktreeDir)
 	storage := filesystem.NewStorage(osfs.New(gitDir), cache.NewObjectLRUDefault())
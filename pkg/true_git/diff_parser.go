@@ -8,6 +8,7 @@ import (
 	"strconv"
 	"strings"
 
+	"github.com/flant/werf/pkg/git_lfs"
 	"github.com/flant/werf/pkg/path_matcher"
 )
 
@@ -18,6 +19,8 @@ func makeDiffParser(out io.Writer, pathMatcher path_matcher.PathMatcher) *diffPa
 		OutLines:    0,
 		Paths:       make([]string, 0),
 		BinaryPaths: make([]string, 0),
+		LfsPaths:    make([]string, 0),
+		LfsOids:     make([]string, 0),
 		state:       unrecognized,
 		lineBuf:     make([]byte, 0, 4096),
 	}
@@ -46,6 +49,16 @@ type diffParser struct {
 	BinaryPaths   []string
 	LastSeenPaths []string
 
+	// LfsPaths are paths of git lfs pointers, LfsOids and LfsSize describe objects of the new pointers
+	LfsPaths []string
+	LfsOids  []string
+	LfsSize  int64
+
+	// lfsPointerCandidates collect the old and the new versions of the last seen file, which hunk starts with the lfs pointer version line,
+	// nil candidate is not a pointer
+	lfsPointerCandidates [2]*bytes.Buffer
+	isLfsPointerHunkSeen bool
+
 	state   parserState
 	lineBuf []byte
 }
@@ -210,6 +223,7 @@ func (p *diffParser) handleDiffLine(line string) error {
 		if strings.HasPrefix(line, "Submodule ") {
 			return p.handleSubmoduleLine(line)
 		}
+		p.handleLfsPointerLine(line)
 		return p.writeOutLine(line)
 	}
 
@@ -237,6 +251,7 @@ func (p *diffParser) handleDiffBegin(line string) error {
 
 	trimmedPaths := make(map[string]string)
 
+	p.handleLfsPointerEnd()
 	p.LastSeenPaths = nil
 
 	for _, data := range []struct{ PathWithPrefix, Prefix string }{{a, "a/"}, {b, "b/"}} {
@@ -357,3 +372,90 @@ func (p *diffParser) handleShortBinaryHeader(line string) error {
 
 	return p.writeOutLine(line)
 }
+
+const (
+	lfsPointerOldSide = iota
+	lfsPointerNewSide
+)
+
+var lfsPointerSideSigns = [...]string{lfsPointerOldSide: "-", lfsPointerNewSide: "+"}
+
+// handleLfsPointerLine collects the old and the new versions of git lfs pointer by the hunk lines (pointer is shorter than the diff context),
+// the hunk of the pointer starts with the version line and is the only hunk of the file
+func (p *diffParser) handleLfsPointerLine(line string) {
+	if strings.HasPrefix(line, "@@ ") {
+		p.handleLfsPointerHunkHeader(line)
+		return
+	}
+
+	if line == "" || !strings.ContainsAny(line[:1], "+- ") {
+		return
+	}
+	sign, content := line[:1], line[1:]
+
+	for side, sideSign := range lfsPointerSideSigns {
+		candidate := p.lfsPointerCandidates[side]
+		if candidate == nil || (sign != " " && sign != sideSign) {
+			continue
+		}
+
+		if candidate.Len() == 0 && content != "version "+git_lfs.PointerVersion {
+			p.lfsPointerCandidates[side] = nil
+			continue
+		}
+
+		candidate.WriteString(content + "\n")
+		if candidate.Len() > git_lfs.MaxPointerSize {
+			p.lfsPointerCandidates[side] = nil
+		}
+	}
+}
+
+func (p *diffParser) handleLfsPointerHunkHeader(line string) {
+	isFirstHunk := !p.isLfsPointerHunkSeen
+	p.isLfsPointerHunkSeen = true
+
+	// @@ -oldStart[,oldCount] +newStart[,newCount] @@
+	fields := strings.Fields(line)
+	for side, sidePrefix := range lfsPointerSideSigns {
+		p.lfsPointerCandidates[side] = nil
+
+		if !isFirstHunk || len(fields) < 3 || !strings.HasPrefix(fields[side+1], sidePrefix) {
+			continue
+		}
+
+		start := strings.SplitN(strings.TrimPrefix(fields[side+1], sidePrefix), ",", 2)[0]
+		if start == "0" || start == "1" {
+			p.lfsPointerCandidates[side] = bytes.NewBuffer(nil)
+		}
+	}
+}
+
+// handleLfsPointerEnd marks the last seen file as the lfs pointer if the old or the new version is a valid pointer,
+// only objects of the new pointer are recorded
+func (p *diffParser) handleLfsPointerEnd() {
+	defer func() {
+		p.lfsPointerCandidates = [2]*bytes.Buffer{}
+		p.isLfsPointerHunkSeen = false
+	}()
+
+	for side, candidate := range p.lfsPointerCandidates {
+		if candidate == nil {
+			continue
+		}
+
+		pointer, ok := git_lfs.ParsePointer(candidate.Bytes())
+		if !ok {
+			continue
+		}
+
+		for _, path := range p.LastSeenPaths {
+			p.LfsPaths = appendUnique(p.LfsPaths, path)
+		}
+
+		if side == lfsPointerNewSide {
+			p.LfsOids = appendUnique(p.LfsOids, pointer.Oid)
+			p.LfsSize += pointer.Size
+		}
+	}
+}
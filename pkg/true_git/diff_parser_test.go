@@ -0,0 +1,135 @@
+package true_git
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/flant/werf/pkg/path_matcher"
+)
+
+const (
+	testLfsOid    = "4d7a214614ab2935c943f9e0ff69d22eadbb8f32b1258daaa5e2ca24d17e2393"
+	testLfsNewOid = "5e8a214614ab2935c943f9e0ff69d22eadbb8f32b1258daaa5e2ca24d17e2393"
+)
+
+func TestDiffParser_LfsPointers(t *testing.T) {
+	tests := []struct {
+		name             string
+		diff             []string
+		expectedLfsPaths []string
+		expectedLfsOids  []string
+		expectedLfsSize  int64
+	}{
+		{
+			name: "new pointer",
+			diff: []string{
+				"diff --git a/image.png b/image.png",
+				"new file mode 100644",
+				"index 0000000..1111111",
+				"--- /dev/null",
+				"+++ b/image.png",
+				"@@ -0,0 +1,3 @@",
+				"+version https://git-lfs.github.com/spec/v1",
+				"+oid sha256:" + testLfsOid,
+				"+size 12345",
+			},
+			expectedLfsPaths: []string{"image.png"},
+			expectedLfsOids:  []string{testLfsOid},
+			expectedLfsSize:  12345,
+		},
+		{
+			name: "modified pointer",
+			diff: []string{
+				"diff --git a/image.png b/image.png",
+				"index 1111111..2222222 100644",
+				"--- a/image.png",
+				"+++ b/image.png",
+				"@@ -1,3 +1,3 @@",
+				" version https://git-lfs.github.com/spec/v1",
+				"-oid sha256:" + testLfsOid,
+				"-size 12345",
+				"+oid sha256:" + testLfsNewOid,
+				"+size 54321",
+			},
+			expectedLfsPaths: []string{"image.png"},
+			expectedLfsOids:  []string{testLfsNewOid},
+			expectedLfsSize:  54321,
+		},
+		{
+			name: "deleted pointer",
+			diff: []string{
+				"diff --git a/image.png b/image.png",
+				"deleted file mode 100644",
+				"index 1111111..0000000",
+				"--- a/image.png",
+				"+++ /dev/null",
+				"@@ -1,3 +0,0 @@",
+				"-version https://git-lfs.github.com/spec/v1",
+				"-oid sha256:" + testLfsOid,
+				"-size 12345",
+			},
+			expectedLfsPaths: []string{"image.png"},
+		},
+		{
+			name: "version line without oid and size",
+			diff: []string{
+				"diff --git a/README.md b/README.md",
+				"new file mode 100644",
+				"index 0000000..1111111",
+				"--- /dev/null",
+				"+++ b/README.md",
+				"@@ -0,0 +1,2 @@",
+				"+version https://git-lfs.github.com/spec/v1",
+				"+is the pointer version line",
+			},
+		},
+		{
+			name: "version line in the middle of the file",
+			diff: []string{
+				"diff --git a/README.md b/README.md",
+				"index 1111111..2222222 100644",
+				"--- a/README.md",
+				"+++ b/README.md",
+				"@@ -10,2 +10,5 @@",
+				" Pointer example:",
+				"+version https://git-lfs.github.com/spec/v1",
+				"+oid sha256:" + testLfsOid,
+				"+size 12345",
+				" ",
+			},
+		},
+		{
+			name: "pointer exceeding max pointer size",
+			diff: []string{
+				"diff --git a/README.md b/README.md",
+				"new file mode 100644",
+				"index 0000000..1111111",
+				"--- /dev/null",
+				"+++ b/README.md",
+				"@@ -0,0 +1,4 @@",
+				"+version https://git-lfs.github.com/spec/v1",
+				"+oid sha256:" + testLfsOid,
+				"+size 12345",
+				"+x " + strings.Repeat("x", 1024),
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			p := makeDiffParser(bytes.NewBuffer(nil), path_matcher.NewSimplePathMatcher("", nil, false))
+			if err := p.HandleStdout([]byte(strings.Join(test.diff, "\n") + "\n")); err != nil {
+				t.Fatal(err)
+			}
+			p.handleLfsPointerEnd()
+
+			expectedLfsPaths := append([]string{}, test.expectedLfsPaths...)
+			expectedLfsOids := append([]string{}, test.expectedLfsOids...)
+			if !reflect.DeepEqual(expectedLfsPaths, p.LfsPaths) || !reflect.DeepEqual(expectedLfsOids, p.LfsOids) || test.expectedLfsSize != p.LfsSize {
+				t.Errorf("\n[EXPECTED]: %v %v %d\n[GOT]: %v %v %d", expectedLfsPaths, expectedLfsOids, test.expectedLfsSize, p.LfsPaths, p.LfsOids, p.LfsSize)
+			}
+		})
+	}
+}
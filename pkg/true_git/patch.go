@@ -23,6 +23,9 @@ type PatchOptions struct {
 type PatchDescriptor struct {
 	Paths       []string
 	BinaryPaths []string
+	LfsPaths    []string
+	LfsOids     []string
+	LfsSize     int64
 }
 
 func PatchWithSubmodules(out io.Writer, gitDir, workTreeCacheDir string, opts PatchOptions) (*PatchDescriptor, error) {
@@ -207,9 +210,14 @@ WaitForData:
 		return nil, fmt.Errorf("git diff error: %s\nunrecognized output:\n%s", err, p.UnrecognizedCapture.String())
 	}
 
+	p.handleLfsPointerEnd()
+
 	desc := &PatchDescriptor{
 		Paths:       p.Paths,
 		BinaryPaths: p.BinaryPaths,
+		LfsPaths:    p.LfsPaths,
+		LfsOids:     p.LfsOids,
+		LfsSize:     p.LfsSize,
 	}
 
 	if debugPatch() {
@@ -220,6 +228,9 @@ WaitForData:
 		for _, path := range desc.BinaryPaths {
 			fmt.Printf("Binary patch path %s\n", path)
 		}
+		for _, path := range desc.LfsPaths {
+			fmt.Printf("Lfs pointer patch path %s\n", path)
+		}
 	}
 
 	return desc, nil
@@ -12,6 +12,7 @@ import (
 
 	"github.com/flant/logboek"
 	"github.com/flant/shluz"
+	"github.com/flant/werf/pkg/logging"
 )
 
 type WithWorkTreeOptions struct {
@@ -137,8 +138,8 @@ func prepareWorkTree(repoDir, workTreeCacheDir string, commit string, withSubmod
 
 	// Switch worktree state to the desired commit.
 	// If worktree already exists — it will be used as a cache.
-	logProcessMsg := fmt.Sprintf("Switch work tree to commit %s", workTreeDir, commit)
-	if err := logboek.Info.LogProcess(logProcessMsg, logboek.LevelLogProcessOptions{}, func() error {
+	logProcessMsg := fmt.Sprintf("Switch work tree %s to commit %s", workTreeDir, commit)
+	if err := logging.LevelLogProcess(logboek.Info, logProcessMsg, logboek.LevelLogProcessOptions{}, func() error {
 		logboek.Info.LogFDetails("Work tree dir: %s\n", workTreeDir)
 		logboek.Info.LogFDetails("Commit: %s\n", commit)
 		if currentCommit != "" {